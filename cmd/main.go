@@ -19,13 +19,21 @@ import (
 	"os"
 
 	"github.com/danielfoehrkn/kubeswitch/cmd/switcher"
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
 )
 
 func main() {
+	util.SetDefaultEnvironment()
+
 	rootCommand := switcher.NewCommandStartSwitcher()
+	finishTracing := switcher.StartTracing()
 
-	if err := rootCommand.Execute(); err != nil {
-		fmt.Print(err)
-		os.Exit(1)
+	cmd, err := rootCommand.ExecuteC()
+	store.CleanupPlugins()
+	finishTracing(cmd.CommandPath(), err)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(switcher.ExitCode(err))
 	}
 }
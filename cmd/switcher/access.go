@@ -0,0 +1,50 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/access"
+)
+
+var (
+	accessCmd = &cobra.Command{
+		Use:   "access [PATTERN...]",
+		Short: "Check which clusters accept your credentials",
+		Long: `Retrieves the kubeconfigs of the contexts matching one of the patterns (all contexts without patterns) from their kubeconfig stores
+and checks whether their clusters accept the credentials, using a SelfSubjectReview. Patterns accept the wildcards '*' and '?'.
+The results are stored in the search index. With "accessCheck" configured in the SwitchConfig, the contexts of clusters rejecting the credentials
+are dimmed or hidden in the search results until the result expires.`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			lc, _ := listContexts(toComplete)
+			return lc, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return access.Check(args, stores, config, stateDirectory, noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(accessCmd)
+	rootCommand.AddCommand(accessCmd)
+}
@@ -26,6 +26,7 @@ var (
 	aliasContextCmd = &cobra.Command{
 		Use:   "alias",
 		Short: "Create an alias for a context. Use ALIAS=CONTEXT_NAME",
+		Long:  `Creates an alias for a context. Use ALIAS=CONTEXT_NAME. With --namespace, switching to the alias also switches to the namespace.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 || !strings.Contains(args[0], "=") || len(strings.Split(args[0], "=")) != 2 {
 				return fmt.Errorf("please provide the alias in the form ALIAS=CONTEXT_NAME")
@@ -42,7 +43,7 @@ var (
 				return err
 			}
 
-			return alias.Alias(arguments[0], ctxName, stores, config, stateDirectory, noIndex)
+			return alias.Alias(arguments[0], ctxName, aliasNamespace, stores, config, stateDirectory, noIndex)
 		},
 		SilenceErrors: true,
 	}
@@ -75,6 +76,63 @@ var (
 		},
 		SilenceErrors: true,
 	}
+
+	aliasExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export all aliases as YAML",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return alias.ExportAliases(stateDirectory, os.Stdout)
+		},
+		SilenceErrors: true,
+	}
+
+	aliasImportCmd = &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import aliases from a YAML file",
+		Long: `Imports the aliases of a YAML file created with "switch alias export". Use "-" to read from stdin.
+The rewrite rules of the file create aliases for all matching contexts, e.g.
+
+aliases:
+  prod: gke_my-project_europe-west1_prod
+rules:
+- regex: '^.*arn:aws:eks:[^:]+:[0-9]+:cluster/(.*)$'
+  replacement: '$1'`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return alias.ImportAliases(args[0], stores, config, stateDirectory, noIndex, dryRun)
+		},
+		SilenceErrors: true,
+	}
+
+	aliasRewriteCmd = &cobra.Command{
+		Use:   "rewrite REGEX REPLACEMENT",
+		Short: "Create aliases for all contexts matching the regex",
+		Long: `Creates an alias for every context whose name matches the regex by replacing the matches with the replacement.
+The replacement can reference capture groups, e.g. "switch alias rewrite '^.*cluster/(.*)$' '$1'".`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			rules := []alias.RewriteRule{{Regex: args[0], Replacement: args[1]}}
+			return alias.RewriteAliases(rules, stores, config, stateDirectory, noIndex, dryRun)
+		},
+		SilenceErrors: true,
+	}
 )
 
 func init() {
@@ -84,10 +142,28 @@ func init() {
 		os.ExpandEnv("$HOME/.kube/switch-state"),
 		"path to the state directory.")
 
+	aliasExportCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the state directory.")
+
+	setFlagsForContextCommands(aliasImportCmd)
+	setFlagsForContextCommands(aliasRewriteCmd)
+
 	aliasContextCmd.AddCommand(aliasLsCmd)
 	aliasContextCmd.AddCommand(aliasRmCmd)
+	aliasContextCmd.AddCommand(aliasExportCmd)
+	aliasContextCmd.AddCommand(aliasImportCmd)
+	aliasContextCmd.AddCommand(aliasRewriteCmd)
 
 	setFlagsForContextCommands(aliasContextCmd)
+	aliasContextCmd.Flags().StringVarP(
+		&aliasNamespace,
+		"namespace",
+		"n",
+		"",
+		"the namespace to switch to when switching to the alias.")
 
 	rootCommand.AddCommand(aliasContextCmd)
 }
@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/audit"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince   time.Duration
+	auditContext string
+	auditUser    string
+	auditLimit   int
+	auditOutput  string
+
+	auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Show the audit log of context switches",
+		Long: `Shows the context switches recorded in the audit log, oldest first.
+Recording is enabled with "audit.enabled: true" in the SwitchConfig. Every switch is appended as one JSON object per line to the file "switch.audit.jsonl" in the state directory, which is rotated once it exceeds "audit.maxSize".`,
+		Example: `  switch audit --since 24h --context "prod-*"`,
+		Args:    cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if auditOutput != "table" && auditOutput != "json" {
+				return fmt.Errorf("unknown output format %q. Valid formats are \"table\" and \"json\"", auditOutput)
+			}
+
+			config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+			if err != nil {
+				return fmt.Errorf("failed to read switch config file: %v", err)
+			}
+
+			filter := audit.Filter{
+				ContextPattern: auditContext,
+				User:           auditUser,
+				Limit:          auditLimit,
+			}
+			if auditSince > 0 {
+				filter.Since = time.Now().Add(-auditSince)
+			}
+
+			entries, err := audit.Query(audit.GetPath(config, stateDirectory), filter)
+			if err != nil {
+				return err
+			}
+			return audit.Print(os.Stdout, entries, auditOutput == "json")
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	auditCmd.Flags().DurationVar(
+		&auditSince,
+		"since",
+		0,
+		"only show context switches within the given duration, e.g. 24h.")
+	auditCmd.Flags().StringVar(
+		&auditContext,
+		"context",
+		"",
+		"only show switches to contexts matching the given wildcard pattern.")
+	auditCmd.Flags().StringVar(
+		&auditUser,
+		"user",
+		"",
+		"only show context switches of the given user.")
+	auditCmd.Flags().IntVar(
+		&auditLimit,
+		"limit",
+		0,
+		"only show the given number of most recent context switches.")
+	auditCmd.Flags().StringVarP(
+		&auditOutput,
+		"output",
+		"o",
+		"table",
+		"the output format. Either \"table\" or \"json\".")
+	auditCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+	auditCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the local directory used for storing internal state.")
+
+	rootCommand.AddCommand(auditCmd)
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/auto"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	autoPrintSource bool
+
+	autoCmd = &cobra.Command{
+		Use:   "auto",
+		Short: "Switch to the context declared for the current directory",
+		Long: `Switch to the context (and namespace) declared in the .kubeswitch file of the current directory or its parent directories.
+Otherwise, switch to the context of the first "repositoryContexts" entry of the SwitchConfig matching the remote URLs or the root directory of the Git repository.
+The shell hook installed with 'switcher init <shell> --cd-hook' runs this command when entering a directory and restores the previous context when leaving it.
+A .kubeswitch file has to be allowed with 'switch auto allow' before switching to its context, and again after it has been changed.
+
+Example .kubeswitch file:
+
+  context: dev-cluster
+  namespace: my-service`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if autoPrintSource {
+				// called by the cd hook on each directory change, hence only load the SwitchConfig without initializing the stores
+				config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+				if err != nil {
+					return fmt.Errorf("failed to read switch config file: %v", err)
+				}
+
+				target, err := auto.FindTarget(config)
+				if err != nil {
+					return err
+				}
+				if target != nil {
+					fmt.Println(target.Source)
+				}
+				return nil
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			kubeconfigPath, contextName, err := auto.Auto(stores, config, stateDirectory, noIndex)
+			if err != nil {
+				return err
+			}
+
+			if nonInteractive {
+				if err := prepareNewContext(*kubeconfigPath, *contextName); err != nil {
+					return err
+				}
+				fmt.Println(*kubeconfigPath)
+				runPostSwitchHooks(*kubeconfigPath)
+				return nil
+			}
+			return reportNewContext(kubeconfigPath, contextName)
+		},
+		SilenceUsage: true,
+	}
+
+	autoAllowCmd = &cobra.Command{
+		Use:   "allow [path]",
+		Short: "Allow the .kubeswitch file to switch the context",
+		Long: `Trusts the current content of the .kubeswitch file of the current directory or its parent directories, or of the given path.
+Changing the file requires allowing it again.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := directoryConfigPath(args)
+			if err != nil {
+				return err
+			}
+			if err := auto.Allow(util.ExpandEnv(stateDirectory), path); err != nil {
+				return err
+			}
+			fmt.Printf("Allowed %q\n", path)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	autoDenyCmd = &cobra.Command{
+		Use:   "deny [path]",
+		Short: "Revoke the trust in the .kubeswitch file",
+		Long:  `Revokes the trust in the .kubeswitch file of the current directory or its parent directories, or of the given path.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := directoryConfigPath(args)
+			if err != nil {
+				return err
+			}
+			if err := auto.Deny(util.ExpandEnv(stateDirectory), path); err != nil {
+				return err
+			}
+			fmt.Printf("Denied %q\n", path)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+// directoryConfigPath returns the given path or the path of the .kubeswitch file for the current directory
+func directoryConfigPath(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	path, err := auto.FindDirectoryConfig()
+	if err != nil {
+		return "", err
+	}
+	if len(path) == 0 {
+		return "", fmt.Errorf("no %s file in the current directory or its parent directories", auto.DirectoryConfigFileName)
+	}
+	return path, nil
+}
+
+func init() {
+	autoCmd.Flags().BoolVar(
+		&autoPrintSource,
+		"print-source",
+		false,
+		"only print the path of the .kubeswitch file or the root directory of the Git repository declaring the context without switching.")
+
+	for _, command := range []*cobra.Command{autoAllowCmd, autoDenyCmd} {
+		command.Flags().StringVar(
+			&stateDirectory,
+			"state-directory",
+			os.ExpandEnv("$HOME/.kube/switch-state"),
+			"path to the state directory.")
+		autoCmd.AddCommand(command)
+	}
+
+	setFlagsForContextCommands(autoCmd)
+	setNonInteractiveFlags(autoCmd)
+	setSwitchFlags(autoCmd)
+	rootCommand.AddCommand(autoCmd)
+}
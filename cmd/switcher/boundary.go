@@ -0,0 +1,159 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/boundary"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+)
+
+var (
+	boundaryOptions    boundary.Options
+	boundaryAddr       string
+	boundaryExecutable string
+
+	boundaryCmd = &cobra.Command{
+		Use:   "boundary",
+		Short: "Manage the HashiCorp Boundary sessions of the contexts",
+		Long: `Contexts matching the patterns of "boundaryTargets" in the SwitchConfig connect to their Boundary target when switching to them.
+The API server of the context is pointed to the local listener of the session, which is canceled once the terminal session ends
+or it is stopped with "switch boundary stop".`,
+	}
+
+	boundaryLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the Boundary sessions started by kubeswitch",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sessions, err := boundary.ListSessions(util.ExpandEnv(stateDirectory))
+			if err != nil {
+				return err
+			}
+			if len(sessions) == 0 {
+				fmt.Println("No Boundary sessions started")
+				return nil
+			}
+			for _, session := range sessions {
+				status := "stopped"
+				if boundary.Running(session) {
+					status = fmt.Sprintf("running for %s", time.Since(session.StartedAt).Round(time.Second))
+				}
+				fmt.Printf("%s on 127.0.0.1:%d for shell %d (PID %d): %s\n", session.Target, session.Port, session.ShellPID, session.PID, status)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	boundaryStopCmd = &cobra.Command{
+		Use:     "stop [TARGET...]",
+		Short:   "Cancel the Boundary sessions started by kubeswitch",
+		Long:    `Cancels the Boundary sessions to the given targets. Cancels all Boundary sessions started by kubeswitch if no target is given.`,
+		Example: "switch boundary stop\nswitch boundary stop ttcp_1234567890",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			sessions, _ := boundary.ListSessions(util.ExpandEnv(stateDirectory))
+			var targets []string
+			for _, session := range sessions {
+				if !slices.Contains(targets, session.Target) {
+					targets = append(targets, session.Target)
+				}
+			}
+			return targets, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stateDir := util.ExpandEnv(stateDirectory)
+			sessions, err := boundary.ListSessions(stateDir)
+			if err != nil {
+				return err
+			}
+
+			stopped := 0
+			for _, session := range sessions {
+				if len(args) > 0 && !slices.Contains(args, session.Target) {
+					continue
+				}
+				if dryrun.Enabled() {
+					dryrun.Printf("cancel the Boundary session to target %q on 127.0.0.1:%d (PID %d)", session.Target, session.Port, session.PID)
+					continue
+				}
+				if err := boundary.StopSession(stateDir, session); err != nil {
+					return err
+				}
+				fmt.Printf("Canceled the Boundary session to target %q on 127.0.0.1:%d\n", session.Target, session.Port)
+				stopped++
+			}
+
+			if len(args) > 0 && stopped == 0 && !dryrun.Enabled() {
+				return fmt.Errorf("no Boundary session to the targets %q", args)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	boundarySessionCmd = &cobra.Command{
+		Use:    boundary.Command,
+		Short:  "Keeps the Boundary session of a terminal session",
+		Long:   `Connects to the Boundary target and cancels the session once the shell of the terminal session exits. Started when switching to a context of a Boundary target, not intended to be used directly.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(boundaryAddr) > 0 {
+				boundaryOptions.Target.Addr = &boundaryAddr
+			}
+			if len(boundaryExecutable) > 0 {
+				boundaryOptions.Target.Executable = &boundaryExecutable
+			}
+			boundaryOptions.StateDir = util.ExpandEnv(stateDirectory)
+			return boundary.Keep(boundaryOptions)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	for _, command := range []*cobra.Command{boundaryLsCmd, boundaryStopCmd, boundarySessionCmd} {
+		command.Flags().StringVar(
+			&stateDirectory,
+			"state-directory",
+			os.ExpandEnv("$HOME/.kube/switch-state"),
+			"path to the state directory.")
+	}
+
+	boundarySessionCmd.Flags().IntVar(&boundaryOptions.ShellPID, "shell-pid", 0, "process ID of the shell of the terminal session.")
+	boundarySessionCmd.Flags().IntVar(&boundaryOptions.Port, "listen-port", 0, "port of the local listener.")
+	boundarySessionCmd.Flags().StringVar(&boundaryOptions.Target.TargetID, "target-id", "", "ID of the Boundary target.")
+	boundarySessionCmd.Flags().StringVar(&boundaryOptions.Target.TargetName, "target-name", "", "name of the Boundary target.")
+	boundarySessionCmd.Flags().StringVar(&boundaryOptions.Target.TargetScopeID, "target-scope-id", "", "ID of the scope of the target name.")
+	boundarySessionCmd.Flags().StringVar(&boundaryOptions.Target.TargetScopeName, "target-scope-name", "", "name of the scope of the target name.")
+	boundarySessionCmd.Flags().StringVar(&boundaryAddr, "addr", "", "address of the Boundary controller.")
+	boundarySessionCmd.Flags().StringVar(&boundaryExecutable, "executable", "", "path of the boundary CLI.")
+
+	boundaryCmd.AddCommand(boundaryLsCmd)
+	boundaryCmd.AddCommand(boundaryStopCmd)
+	rootCommand.AddCommand(boundaryCmd)
+	rootCommand.AddCommand(boundarySessionCmd)
+}
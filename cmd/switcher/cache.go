@@ -14,6 +14,7 @@
 package switcher
 
 import (
+	_ "github.com/danielfoehrkn/kubeswitch/pkg/cache/database"
 	_ "github.com/danielfoehrkn/kubeswitch/pkg/cache/file"
 	_ "github.com/danielfoehrkn/kubeswitch/pkg/cache/memory"
 )
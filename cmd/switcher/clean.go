@@ -14,29 +14,70 @@
 package switcher
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/clean"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/symlink"
+	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/spf13/cobra"
 )
 
 var (
+	cleanMaxAge       time.Duration
+	cleanMaxTotalSize string
+	cleanForce        bool
+
 	cleanCmd = &cobra.Command{
 		Use:   "clean",
 		Short: "Cleans all temporary and cached kubeconfig files",
-		Long:  `Cleans the temporary kubeconfig files created in the directory $HOME/.kube/switch_tmp and flushes every cache`,
-		Args:  cobra.NoArgs,
+		Long: `Cleans the temporary kubeconfig files created in the directory $HOME/.kube/.switch_tmp (%LOCALAPPDATA%\kubeswitch\tmp on Windows) and flushes every cache.
+Temporary kubeconfig files that are still used by a running terminal session are never deleted.
+This is detected from the environment of the running processes in /proc. On systems without /proc (e.g. macOS and Windows), temporary kubeconfig files are only deleted with --force.
+If --max-age or --max-total-size is set, only the temporary kubeconfig files exceeding these limits are deleted and the caches are kept.`,
+		Args: cobra.NoArgs,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			stores, _, err := initialize()
+			stores, config, err := initialize()
 			if err != nil {
 				return err
 			}
-			return clean.Clean(stores)
+
+			var policy *types.CleanConfig
+			if cmd.Flags().Changed("max-age") || cmd.Flags().Changed("max-total-size") {
+				policy = &types.CleanConfig{}
+				if cmd.Flags().Changed("max-age") {
+					if cleanMaxAge <= 0 {
+						return fmt.Errorf("--max-age has to be a positive duration, got %s", cleanMaxAge)
+					}
+					policy.MaxAge = &cleanMaxAge
+				}
+				if cmd.Flags().Changed("max-total-size") {
+					policy.MaxTotalSize = &cleanMaxTotalSize
+				}
+			}
+			return clean.Clean(stores, policy, symlink.Targets(config), cleanForce)
 		},
 	}
 )
 
 func init() {
+	cleanCmd.Flags().DurationVar(
+		&cleanMaxAge,
+		"max-age",
+		0,
+		"only delete temporary kubeconfig files older than the given duration, e.g. 24h.")
+	cleanCmd.Flags().StringVar(
+		&cleanMaxTotalSize,
+		"max-total-size",
+		"",
+		"delete the oldest temporary kubeconfig files until the temporary kubeconfig directory does not exceed the given size, e.g. 10Mi.")
+	cleanCmd.Flags().BoolVar(
+		&cleanForce,
+		"force",
+		false,
+		"delete temporary kubeconfig files even if the files used by other terminal sessions cannot be detected (systems without /proc). Only the kubeconfig of the current shell is kept.")
 	rootCommand.AddCommand(cleanCmd)
 }
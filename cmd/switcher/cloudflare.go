@@ -0,0 +1,66 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	cloudflaretoken "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/cloudflare-token"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloudflareOptions cloudflaretoken.Options
+
+	cloudflareTokenCmd = &cobra.Command{
+		Use:   cloudflaretoken.Command,
+		Short: "Exec credential plugin for the Cloudflare Access login",
+		Long: `Implements the client-go credential plugin protocol for kubeconfigs of API servers protected by Cloudflare Access (cloudflareAccess in the kubeconfig store configuration).
+Called by kubectl, not intended to be used directly.
+
+Returns the Cloudflare Access token of the application obtained with "cloudflared access token". The token is cached by cloudflared.
+If there is no valid token, logs in with "cloudflared access login", which opens the browser.
+Without --application, the API server URL of the cluster provided by the client (provideClusterInfo) is used as application.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			execCredential, err := cloudflaretoken.GetExecCredential(cloudflareOptions)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(os.Stdout, string(execCredential))
+			return err
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	cloudflareTokenCmd.Flags().StringVar(
+		&cloudflareOptions.Application,
+		"application",
+		"",
+		"URL of the Cloudflare Access application.")
+	cloudflareTokenCmd.Flags().StringVar(
+		&cloudflareOptions.Cloudflared,
+		"cloudflared",
+		cloudflaretoken.DefaultCloudflared,
+		"path of cloudflared.")
+
+	rootCommand.AddCommand(cloudflareTokenCmd)
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/config/schema"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "SwitchConfig specific commands",
+		Long:  `Commands to work with the SwitchConfig file.`,
+	}
+
+	configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the SwitchConfig",
+		Long:  `Validates the SwitchConfig file against the schema and reports unknown fields, type errors and missing required store options with line numbers.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := util.ExpandEnv(configPath)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read switch config file: %v", err)
+			}
+
+			issues, err := schema.Validate(data)
+			if err != nil {
+				return err
+			}
+
+			for _, issue := range issues {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, issue)
+			}
+
+			if len(issues) > 0 {
+				return fmt.Errorf("the switch configuration file contains %d error(s)", len(issues))
+			}
+			fmt.Fprintf(os.Stdout, "%s is valid\n", path)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	configSchemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON schema of the SwitchConfig",
+		Long:  `Prints the JSON schema of the SwitchConfig. Can be used by editors for completion and validation of the SwitchConfig file.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonSchema, err := schema.Generate()
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(jsonSchema)
+			return err
+		},
+	}
+)
+
+func init() {
+	configValidateCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCommand.AddCommand(configCmd)
+}
@@ -14,16 +14,41 @@
 package switcher
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/audit"
 	delete_context "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/delete-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/global"
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history"
+	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/hooks"
 	list_contexts "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/list-contexts"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/ns"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/revert"
 	set_context "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	show_path "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/show-path"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/symlink"
 	unset_context "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/unset-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/verify"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -44,8 +69,10 @@ var (
 			}
 
 			kubeconfigPath, contextName, err := history.SetPreviousContext(stores, config, stateDirectory, noIndex)
-			reportNewContext(kubeconfigPath, contextName)
-			return err
+			if err != nil {
+				return err
+			}
+			return reportNewContext(kubeconfigPath, contextName)
 		},
 	}
 
@@ -64,8 +91,10 @@ var (
 			}
 
 			kubeconfigPath, contextName, err := history.SetLastContext(stores, config, stateDirectory, noIndex)
-			reportNewContext(kubeconfigPath, contextName)
-			return err
+			if err != nil {
+				return err
+			}
+			return reportNewContext(kubeconfigPath, contextName)
 		},
 	}
 
@@ -73,7 +102,9 @@ var (
 		Use:     "list-contexts [wildcard-search]",
 		Aliases: []string{"ls"},
 		Short:   "List all available contexts",
-		Long:    `List all available contexts - give a second parameter to do a wildcard search. Eg: switch list-contexts "*-dev*"`,
+		Long: `List all available contexts - give a second parameter to do a wildcard search. Eg: switch list-contexts "*-dev*"
+With --regex, the parameter is a regular expression instead. Eg: switch list-contexts --regex '^eks_(eu|us)-.*-prod$'
+With -o json, the store, the API server, the Kubernetes version and the cloud tags (see "cloudTags" of the kubeconfig stores) of each context are printed as well.`,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			var comps []string
 			if len(args) != 0 {
@@ -83,18 +114,48 @@ var (
 			return comps, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			stores, config, err := initialize()
-			if err != nil {
-				return err
-			}
 			// Get all contexts by default
 			pattern := "*"
 			if len(args) == 1 && len(args[0]) > 0 {
 				pattern = args[0]
 			}
-			contexts, err := list_contexts.ListContexts(pattern, stores, config, stateDirectory, noIndex)
-			if err != nil {
-				return err
+
+			switch listContextsOutput {
+			case "plain":
+			case "json":
+				return printContextDetails(args)
+			default:
+				return fmt.Errorf("unknown output format %q. Valid formats are \"plain\" and \"json\"", listContextsOutput)
+			}
+
+			var contexts []string
+			if regex {
+				var expression string
+				if len(args) == 1 {
+					expression = args[0]
+				}
+				re, err := regexp.Compile(expression)
+				if err != nil {
+					return fmt.Errorf("invalid regular expression %q: %v", expression, err)
+				}
+				if contexts, err = listContextsRegex(re); err != nil {
+					return err
+				}
+			} else if client := getDaemonClient(); client != nil {
+				daemonContexts, err := listContextsFromDaemon(client, pattern)
+				if err != nil {
+					return err
+				}
+				contexts = daemonContexts
+			} else {
+				stores, config, err := initialize()
+				if err != nil {
+					return err
+				}
+				contexts, err = list_contexts.ListContexts(pattern, stores, config, stateDirectory, noIndex)
+				if err != nil {
+					return err
+				}
 			}
 			for _, context := range contexts {
 				fmt.Println(context)
@@ -104,40 +165,91 @@ var (
 	}
 
 	setContextCmd = &cobra.Command{
-		Use:     "set-context",
-		Short:   "Switch to context name provided as first argument",
-		Long:    `Switch to context name provided as first argument. KubeContext name has to exist in any of the found Kubeconfig files.`,
+		Use:   "set-context",
+		Short: "Switch to context name provided as first argument",
+		Long: `Switch to context name provided as first argument. KubeContext name has to exist in any of the found Kubeconfig files.
+An optional second argument sets the namespace of the new context.`,
 		Aliases: []string{"set", "sc", "set-context"},
-		Args:    cobra.ExactArgs(1),
+		Args:    cobra.RangeArgs(1, 2),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) != 0 {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-			lc, _ := listContexts(toComplete)
-			return lc, cobra.ShellCompDirectiveNoFileComp
+			return completeContextArgs(args, toComplete)
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			log := logrus.New().WithField("hook", "")
-			return hooks.Hooks(log, configPath, stateDirectory, "", false)
+			log := logging.New().WithField("hook", "")
+			return hooks.Hooks(log, configPath, stateDirectory, "", false, dryRun)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// the daemon only matches context names and writes the temporary kubeconfig
+			if client := getDaemonClient(); client != nil && !regex && !dryRun && len(writeKubeconfigPath) == 0 {
+				kubeconfigPath, contextName, err := client.SetContext(args[0], nonInteractive)
+				if err != nil {
+					return err
+				}
+				if err := setNamespaceFromArgs(kubeconfigPath, args); err != nil {
+					return err
+				}
+				if nonInteractive {
+					if err := prepareNewContext(*kubeconfigPath, *contextName); err != nil {
+						return err
+					}
+					fmt.Println(*kubeconfigPath)
+					runPostSwitchHooks(*kubeconfigPath)
+					return nil
+				}
+				return reportNewContext(kubeconfigPath, contextName)
+			}
+
 			stores, config, err := initialize()
 			if err != nil {
 				return err
 			}
 
-			kubeconfigPath, contextName, err := set_context.SetContext(args[0], stores, config, stateDirectory, noIndex, true)
-			reportNewContext(kubeconfigPath, contextName)
-			return err
+			if nonInteractive || regex {
+				setContext := set_context.SetContextExact
+				if regex {
+					setContext = set_context.SetContextRegex
+				}
+				kubeconfigPath, contextName, err := setContext(args[0], stores, config, stateDirectory, noIndex, len(writeKubeconfigPath) == 0)
+				if err != nil {
+					return err
+				}
+				if err := setNamespaceFromArgs(kubeconfigPath, args); err != nil {
+					return err
+				}
+				if len(writeKubeconfigPath) > 0 {
+					return writeNewContext(*kubeconfigPath, *contextName)
+				}
+				if err := prepareNewContext(*kubeconfigPath, *contextName); err != nil {
+					return err
+				}
+				if dryRun {
+					return nil
+				}
+				// only print the path so that scripts can directly use the output, e.g. KUBECONFIG=$(switcher --non-interactive <context>)
+				fmt.Println(*kubeconfigPath)
+				runPostSwitchHooks(*kubeconfigPath)
+				return nil
+			}
+
+			kubeconfigPath, contextName, err := set_context.SetContext(args[0], stores, config, stateDirectory, noIndex, len(writeKubeconfigPath) == 0)
+			if err != nil {
+				return err
+			}
+			if err := setNamespaceFromArgs(kubeconfigPath, args); err != nil {
+				return err
+			}
+			return reportNewContext(kubeconfigPath, contextName)
 		},
 		SilenceUsage: true,
 	}
 
 	deleteContextCmd = &cobra.Command{
-		Use:   "delete-context",
+		Use:   "delete-context [context]",
 		Short: "Delete context name provided as first argument",
-		Long:  `Delete context name provided as first argument. KubeContext name has to exist in the current Kubeconfig file.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Delete context name provided as first argument from the search index and the cache of its kubeconfig store, so that stale contexts are no longer shown.
+Without argument, the contexts to delete are selected interactively (select multiple contexts with Tab).
+Contexts of filesystem stores can additionally be removed from their kubeconfig file with --from-file. A backup of the file is written with the suffix ".bak", previous backups are rotated.`,
+		Args: cobra.MaximumNArgs(1),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) != 0 {
 				return nil, cobra.ShellCompDirectiveNoFileComp
@@ -146,12 +258,20 @@ var (
 			return lc, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctxName, err := resolveContextName(args[0])
-			fmt.Println("delete-context", ctxName, args, err)
+			var ctxName string
+			if len(args) == 1 {
+				resolved, err := resolveContextName(args[0])
+				if err != nil {
+					return err
+				}
+				ctxName = resolved
+			}
+
+			stores, config, err := initialize()
 			if err != nil {
 				return err
 			}
-			return delete_context.DeleteContext(ctxName)
+			return delete_context.DeleteContext(ctxName, stores, config, stateDirectory, noIndex, deleteFromFile)
 		},
 	}
 
@@ -164,7 +284,13 @@ var (
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return unset_context.UnsetCurrentContext()
+			if err := unset_context.UnsetCurrentContext(); err != nil {
+				return err
+			}
+			if err := hooks.ClearTerminalTitle(logging.New().WithField("hook", ""), configPath); err != nil {
+				logrus.Warnf("failed to clear the terminal title: %v", err)
+			}
+			return nil
 		},
 	}
 
@@ -181,10 +307,22 @@ var (
 			if err != nil {
 				return err
 			}
+			if colorCurrentContext {
+				ctx = colorizeContextName(ctx)
+			}
+			// warn that the terminal is reverted to the previous context (switch --for)
+			if kubeconfig, err := kubeconfigutil.LoadCurrentKubeconfig(); err == nil {
+				if remaining, ok := revert.Remaining(kubeconfig); ok {
+					ctx = fmt.Sprintf("%s (reverts in %s)", ctx, formatRemaining(remaining))
+				}
+			}
 			fmt.Println(ctx)
 			return nil
 		},
 	}
+
+	// colorCurrentContext colors the output of current-context by environment, e.g. for shell prompts
+	colorCurrentContext bool
 )
 
 func init() {
@@ -196,14 +334,52 @@ func init() {
 	rootCommand.AddCommand(previousContextCmd)
 	rootCommand.AddCommand(lastContextCmd)
 
+	currentContextCmd.Flags().BoolVar(
+		&colorCurrentContext,
+		"color",
+		false,
+		"color the context name by the matching environment of the SwitchConfig, even if the output is not a terminal.")
+	currentContextCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
 	setFlagsForContextCommands(setContextCmd)
+	setFlagsForContextCommands(deleteContextCmd)
+	deleteContextCmd.Flags().BoolVar(
+		&deleteFromFile,
+		"from-file",
+		false,
+		"also remove the context from its kubeconfig file (filesystem stores only). A backup of the file is written with the suffix \".bak\", previous backups are rotated.")
+	setNonInteractiveFlags(setContextCmd)
+	setSwitchFlags(setContextCmd)
+	setWriteKubeconfigFlags(setContextCmd)
 	setFlagsForContextCommands(listContextsCmd)
+	setFilterFlags(listContextsCmd)
+	listContextsCmd.Flags().BoolVar(
+		&regex,
+		"regex",
+		false,
+		"interpret the search parameter as regular expression matching the context names instead of a wildcard search.")
+	listContextsCmd.Flags().StringVarP(
+		&listContextsOutput,
+		"output",
+		"o",
+		"plain",
+		"the output format. Either \"plain\" (the context names) or \"json\" (including the store, the API server, the Kubernetes version and the cloud tags).")
 	// need to add flags as the namespace history allows switching to any {context: namespace} combination
 	setFlagsForContextCommands(previousContextCmd)
 	setFlagsForContextCommands(lastContextCmd)
+	setSwitchFlags(previousContextCmd)
+	setSwitchFlags(lastContextCmd)
 }
 
 func listContexts(prefix string) ([]string, error) {
+	if client := getDaemonClient(); client != nil {
+		return listContextsFromDaemon(client, "*")
+	}
+
 	stores, config, err := initialize()
 	if err != nil {
 		return nil, err
@@ -216,6 +392,74 @@ func listContexts(prefix string) ([]string, error) {
 	return lc, nil
 }
 
+// completeContextArgs completes the context names and aliases for the first argument and the
+// namespaces of the chosen context for the second argument.
+// Only the index files and the namespace cache in the state directory are read, as completion runs on every keystroke.
+func completeContextArgs(args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// the completion reads the index without the full initialization of the kubeconfig stores
+	var exclusions pkg.Exclusions
+	if config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath)); err == nil && config != nil {
+		if err := configureIndexEncryption(config); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		exclusions = pkg.NewExclusions(config.ExcludePatterns)
+	}
+
+	switch len(args) {
+	case 0:
+		if strings.HasPrefix(toComplete, "~") {
+			return completeRecentContexts(toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		contexts, err := list_contexts.ListCachedContexts(toComplete, stateDirectory, exclusions)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		comps := make([]string, 0, len(contexts))
+		for _, c := range contexts {
+			comps = append(comps, fmt.Sprintf("%s\t%s", c.Name, c.Description))
+		}
+		return comps, cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		namespaces, _ := ns.ListCachedNamespaces(stateDirectory, args[0])
+		return namespaces, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRecentContexts completes the history shortcuts "~N" with the recently used contexts as description
+func completeRecentContexts(toComplete string) []string {
+	entries, err := historyutil.RecentContexts()
+	if err != nil {
+		return nil
+	}
+
+	var comps []string
+	for i, entry := range entries {
+		shortcut := fmt.Sprintf("~%d", i)
+		if !strings.HasPrefix(shortcut, toComplete) {
+			continue
+		}
+		context, _, err := historyutil.ParseHistoryEntry(entry)
+		if err != nil {
+			continue
+		}
+		comps = append(comps, fmt.Sprintf("%s\t%s", shortcut, *context))
+	}
+	return comps
+}
+
+// setNamespaceFromArgs sets the namespace given as second argument in the new temporary kubeconfig
+func setNamespaceFromArgs(kubeconfigPath *string, args []string) error {
+	if kubeconfigPath == nil || len(args) < 2 {
+		return nil
+	}
+	if dryRun {
+		dryrun.Printf("set namespace %q in %q", args[1], *kubeconfigPath)
+		return nil
+	}
+	return ns.SwitchToNamespace(args[1], *kubeconfigPath, false)
+}
+
 func resolveContextName(contextName string) (string, error) {
 	if contextName == "." {
 		c, err := util.GetCurrentContext()
@@ -249,6 +493,21 @@ func setFlagsForContextCommands(command *cobra.Command) {
 		"config-path",
 		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
 		"path on the local filesystem to the configuration file.")
+	command.Flags().StringVar(
+		&profile,
+		"profile",
+		"",
+		"only use the kubeconfig stores of this profile defined in the SwitchConfig. Defaults to the environment variable \"SWITCH_PROFILE\".")
+	command.Flags().StringSliceVar(
+		&storeSelectors,
+		"stores",
+		nil,
+		"only use these kubeconfig stores, e.g. \"gke,exoscale\". Accepts store kinds and store IDs. Defaults to the environment variable \"SWITCH_STORES\".")
+	command.Flags().BoolVar(
+		&offlineMode,
+		"offline",
+		false,
+		"do not call the APIs of the kubeconfig stores. The contexts are read from the search index and the kubeconfigs from the cache, even if their credentials expired. See \"offline\" of the SwitchConfig to enable it automatically.")
 	// not used for setContext command. Makes call in switch.sh script easier (no need to exclude flag from call)
 	command.Flags().BoolVar(
 		&showPreview,
@@ -257,13 +516,537 @@ func setFlagsForContextCommands(command *cobra.Command) {
 		"show preview of the selected kubeconfig. Possibly makes sense to disable when using vault as the kubeconfig store to prevent excessive requests against the API.")
 }
 
-func reportNewContext(kubeconfigPath *string, contextName *string) {
+// setFilterFlags adds the flags filtering the contexts by the Kubernetes version and the tags of their clusters
+func setFilterFlags(command *cobra.Command) {
+	command.Flags().StringVar(
+		&minVersion,
+		"min-version",
+		"",
+		"only show the contexts of clusters with at least this Kubernetes version, e.g. \"1.28\". Contexts of clusters with an unknown version are not shown.")
+	command.Flags().StringVar(
+		&maxVersion,
+		"max-version",
+		"",
+		"only show the contexts of clusters with at most this Kubernetes version, e.g. \"1.27\" to find the clusters to upgrade. Contexts of clusters with an unknown version are not shown.")
+	command.Flags().StringSliceVar(
+		&tagSelectors,
+		"tags",
+		nil,
+		"only show the contexts whose tags match all requirements, e.g. \"env=prod,state=running\". Values can contain the wildcards * and ?, \"key!=value\" excludes a value and \"key\" requires the tag. Cloud tags match without the prefix \"tag:\".")
+}
+
+func setNonInteractiveFlags(command *cobra.Command) {
+	command.Flags().BoolVar(
+		&nonInteractive,
+		"non-interactive",
+		false,
+		"the given context name has to match exactly one context. Prints only the path to the temporary kubeconfig file. Exits with code 2 if the context is not found and with code 3 if it is ambiguous.")
+	command.Flags().BoolVar(
+		&nonInteractive,
+		"exact",
+		false,
+		"alias for --non-interactive.")
+	command.Flags().BoolVar(
+		&regex,
+		"regex",
+		false,
+		"interpret the given context name as regular expression that has to match exactly one context. Implies --non-interactive.")
+}
+
+// listContextsRegex returns the sorted context names (or aliases) matching the regular expression
+func listContextsRegex(re *regexp.Regexp) ([]string, error) {
+	if client := getDaemonClient(); client != nil {
+		daemonContexts, err := listContextsFromDaemon(client, "*")
+		if err != nil {
+			return nil, err
+		}
+		return slices.DeleteFunc(daemonContexts, func(name string) bool {
+			return !re.MatchString(name)
+		}), nil
+	}
+
+	stores, config, err := initialize()
+	if err != nil {
+		return nil, err
+	}
+	return list_contexts.ListContextsMatching(re.MatchString, stores, config, stateDirectory, noIndex)
+}
+
+// printContextDetails prints the contexts matching the wildcard pattern (or the regular expression with --regex) as JSON.
+// The details are not known to the daemon, hence the kubeconfig stores are always searched.
+func printContextDetails(args []string) error {
+	var pattern string
+	if len(args) == 1 {
+		pattern = args[0]
+	}
+
+	var match func(name string) bool
+	if regex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %v", pattern, err)
+		}
+		match = re.MatchString
+	} else {
+		if len(pattern) == 0 {
+			pattern = "*"
+		}
+		match = wildmatch.NewWildMatch(pattern).IsMatch
+	}
+
+	stores, config, err := initialize()
+	if err != nil {
+		return err
+	}
+	contexts, err := list_contexts.ListContextDetailsMatching(match, stores, config, stateDirectory, noIndex)
+	if err != nil {
+		return err
+	}
+	if contexts == nil {
+		contexts = []list_contexts.Context{}
+	}
+
+	output, err := json.MarshalIndent(contexts, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+// setSwitchFlags adds the flags configuring the switch to the new context
+func setSwitchFlags(command *cobra.Command) {
+	command.Flags().StringVar(
+		&impersonateUser,
+		"as",
+		"",
+		"impersonate the given user in the temporary kubeconfig. Overrides the \"impersonate\" setting of the kubeconfig store.")
+	command.Flags().StringSliceVar(
+		&impersonateGroups,
+		"as-group",
+		nil,
+		"impersonate the given group in the temporary kubeconfig. Can be repeated. Overrides the \"impersonate\" setting of the kubeconfig store.")
+	command.Flags().DurationVar(
+		&switchFor,
+		"for",
+		0,
+		"revert the terminal to the previous context after the given duration, e.g. 30m.")
+	command.Flags().BoolVar(
+		&yesIMeanProd,
+		"yes-i-mean-prod",
+		false,
+		"switch to a context matching the \"protectedContexts\" of the SwitchConfig without typing the context name.")
+	command.Flags().BoolVar(
+		&switchGlobal,
+		"global",
+		false,
+		"also set the current context of the default kubeconfig (~/.kube/config) for tools ignoring KUBECONFIG. See \"globalMode\" of the SwitchConfig.")
+	command.Flags().StringVar(
+		&clipboard,
+		"clipboard",
+		"",
+		"copy the temporary kubeconfig (\"kubeconfig\") or its path (\"path\") to the clipboard after switching.")
+	command.Flags().Lookup("clipboard").NoOptDefVal = "kubeconfig"
+}
+
+// setWriteKubeconfigFlags adds the flags writing the kubeconfig of the new context to a custom location
+func setWriteKubeconfigFlags(command *cobra.Command) {
+	command.Flags().StringVar(
+		&writeKubeconfigPath,
+		"write-kubeconfig",
+		"",
+		"write the kubeconfig of the selected context to the given path instead of switching the terminal, e.g. for CI jobs. The history and the default kubeconfig are not changed. Prints the path.")
+}
+
+func reportNewContext(kubeconfigPath *string, contextName *string) error {
 	if kubeconfigPath == nil || contextName == nil {
-		return
+		return nil
+	}
+
+	if len(writeKubeconfigPath) > 0 {
+		return writeNewContext(*kubeconfigPath, *contextName)
+	}
+
+	if err := prepareNewContext(*kubeconfigPath, *contextName); err != nil {
+		return err
+	}
+
+	// the post-switch hooks have already been printed with the temporary kubeconfig
+	if dryRun {
+		return nil
+	}
+
+	if reportToKubectl(*kubeconfigPath, *contextName) {
+		runPostSwitchHooks(*kubeconfigPath)
+		return nil
 	}
 
 	// print kubeconfig path and context name to std.out
 	// captured by calling script setting KUBECONFIG environment variable
 	// prefixed with "__ " to distinguish kubeconfig path output from other responses (e.g., errors, list of context, ...)
 	fmt.Printf("__ %s,%s", *kubeconfigPath, *contextName)
+
+	runPostSwitchHooks(*kubeconfigPath)
+	return nil
+}
+
+// prepareNewContext configures the impersonation requested via flags, verifies the new context
+// and schedules the revert of the terminal to the previous context if requested
+func prepareNewContext(kubeconfigPath, contextName string) error {
+	if dryRun {
+		printNewContext(kubeconfigPath, contextName)
+		return nil
+	}
+
+	// without the SwitchConfig, it is unknown whether the context is protected
+	config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+	if err != nil {
+		removeTemporaryKubeconfig(kubeconfigPath)
+		return fmt.Errorf("failed to load the SwitchConfig to verify context %q: %v", contextName, err)
+	}
+
+	if len(impersonateUser) > 0 || len(impersonateGroups) > 0 {
+		if err := impersonate(kubeconfigPath); err != nil {
+			removeTemporaryKubeconfig(kubeconfigPath)
+			return fmt.Errorf("failed to configure impersonation for context %q: %v", contextName, err)
+		}
+	}
+
+	if err := verifyNewContext(config, kubeconfigPath, contextName); err != nil {
+		return err
+	}
+
+	// the default kubeconfig would keep the context after the subshell has been exited
+	if !scopedSession && (switchGlobal || global.IsEnabled(config)) {
+		path := global.GetPath(config)
+		if _, err := global.Write(kubeconfigPath, contextName, path); err != nil {
+			return fmt.Errorf("failed to set the current context of %q: %v", path, err)
+		}
+	}
+
+	// the temporary kubeconfig of the subshell is removed when it is exited
+	if !scopedSession && symlink.IsEnabled(config) {
+		path := symlink.GetPath(config)
+		if err := symlink.Update(path, kubeconfigPath); err != nil {
+			logrus.Warnf("failed to point the symlink %q to the kubeconfig of context %q: %v", path, contextName, err)
+		}
+	}
+
+	if switchFor > 0 {
+		if err := revert.Schedule(kubeconfigPath, switchFor); err != nil {
+			return fmt.Errorf("failed to schedule the revert of context %q: %v", contextName, err)
+		}
+	}
+
+	// the switch succeeded even if copying fails
+	if len(clipboard) > 0 {
+		if err := copyToClipboard(kubeconfigPath); err != nil {
+			logrus.Warnf("failed to copy the %s of context %q to the clipboard: %v", clipboard, contextName, err)
+		}
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	recordAudit(config, kubeconfigPath, contextName)
+	notifyNewContext(config, kubeconfigPath, contextName)
+	notifyWebhooks(config, kubeconfigPath, contextName)
+	return nil
+}
+
+// writeNewContext writes the kubeconfig of the new context to the path given by --write-kubeconfig instead of switching the terminal.
+// Only the flags configuring the kubeconfig are applied: the session state (e.g. the default kubeconfig) is not changed.
+// The switch is still verified and recorded, as the kubeconfig grants the same access as switching.
+func writeNewContext(kubeconfigPath, contextName string) error {
+	if switchFor > 0 || switchGlobal || len(clipboard) > 0 {
+		removeTemporaryKubeconfig(kubeconfigPath)
+		return fmt.Errorf("--write-kubeconfig cannot be combined with --for, --global or --clipboard")
+	}
+
+	path := util.ExpandEnv(writeKubeconfigPath)
+	if dryRun {
+		dryrun.Printf("write the kubeconfig of context %q to %q", contextName, path)
+		return nil
+	}
+	defer removeTemporaryKubeconfig(kubeconfigPath)
+
+	config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+	if err != nil {
+		return fmt.Errorf("failed to load the SwitchConfig to verify context %q: %v", contextName, err)
+	}
+
+	if len(impersonateUser) > 0 || len(impersonateGroups) > 0 {
+		if err := impersonate(kubeconfigPath); err != nil {
+			return fmt.Errorf("failed to configure impersonation for context %q: %v", contextName, err)
+		}
+	}
+
+	if err := verifyNewContext(config, kubeconfigPath, contextName); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the kubeconfig of context %q: %v", contextName, err)
+	}
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create the directory of %q: %v", path, err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write the kubeconfig of context %q to %q: %v", contextName, path, err)
+	}
+	// an existing file keeps its permissions, but the kubeconfig contains credentials
+	if err := permissions.Restrict(path); err != nil {
+		logrus.Warnf("failed to restrict the access to %q: %v", path, err)
+	}
+
+	if config != nil {
+		recordAudit(config, path, contextName)
+		notifyWebhooks(config, path, contextName)
+	}
+
+	// only print the path so that scripts can directly use the output
+	fmt.Println(path)
+	return nil
+}
+
+// printNewContext prints the changes prepareNewContext would make in dry-run mode.
+// The new context is not verified, as the temporary kubeconfig has not been written.
+func printNewContext(kubeconfigPath, contextName string) {
+	if len(impersonateUser) > 0 {
+		dryrun.Printf("impersonate user %q in %q", impersonateUser, kubeconfigPath)
+	}
+	if len(impersonateGroups) > 0 {
+		dryrun.Printf("impersonate groups %q in %q", strings.Join(impersonateGroups, ","), kubeconfigPath)
+	}
+
+	if switchFor > 0 {
+		dryrun.Printf("revert the terminal to the previous context after %s", switchFor)
+	}
+
+	if len(clipboard) > 0 {
+		dryrun.Printf("copy the %s of context %q to the clipboard", clipboard, contextName)
+	}
+
+	config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+	if err != nil {
+		config = nil
+	}
+
+	if switchGlobal || global.IsEnabled(config) {
+		path := global.GetPath(config)
+		dryrun.Printf("set the current context of %q to %q, backing up the previous content to %q", path, contextName, global.BackupPath(path))
+	}
+
+	if symlink.IsEnabled(config) {
+		dryrun.Printf("point the symlink %q to the temporary kubeconfig of context %q", symlink.GetPath(config), contextName)
+	}
+
+	if config == nil {
+		return
+	}
+
+	for _, webhook := range matchingWebhooks(config, kubeconfigPath, contextName) {
+		dryrun.Printf("notify the webhook %q about the switch to context %q", audit.WebhookHost(webhook), contextName)
+	}
+
+	if !audit.IsEnabled(config) {
+		return
+	}
+
+	directory := stateDirectory
+	if len(directory) == 0 {
+		directory = os.ExpandEnv("$HOME/.kube/switch-state")
+	}
+	dryrun.Printf("record context %q in the audit log %q", contextName, audit.GetPath(config, directory))
+}
+
+// recordAudit appends the context switch to the audit log if enabled in the SwitchConfig.
+// The switch succeeded even if the entry cannot be recorded.
+func recordAudit(config *types.Config, kubeconfigPath, contextName string) {
+	if !audit.IsEnabled(config) {
+		return
+	}
+
+	directory := stateDirectory
+	if len(directory) == 0 {
+		directory = os.ExpandEnv("$HOME/.kube/switch-state")
+	}
+	if err := audit.Record(config, directory, newAuditEntry(kubeconfigPath, contextName)); err != nil {
+		logrus.Warnf("failed to record context %q in the audit log: %v", contextName, err)
+	}
+}
+
+// newAuditEntry returns the audit entry of the switch to the new context
+func newAuditEntry(kubeconfigPath, contextName string) audit.Entry {
+	entry := audit.NewEntry(time.Now())
+	entry.Context = contextName
+	entry.KubeconfigPath = kubeconfigPath
+
+	// the KUBECONFIG environment variable still points to the kubeconfig before the switch
+	if previous, err := show_path.GetSession(); err == nil {
+		entry.PreviousContext = previous.Context
+		entry.PreviousNamespace = previous.Namespace
+	}
+	if session, err := show_path.GetSessionForPath(kubeconfigPath); err == nil {
+		entry.Namespace = session.Namespace
+		entry.StoreKind = session.StoreKind
+		entry.StoreID = session.StoreID
+	}
+	return entry
+}
+
+// notifyWebhooks posts the switch to the webhooks of the SwitchConfig matching the new context.
+// The switch succeeded even if a webhook cannot be notified.
+func notifyWebhooks(config *types.Config, kubeconfigPath, contextName string) {
+	webhooks := matchingWebhooks(config, kubeconfigPath, contextName)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	if offline.Enabled() {
+		logrus.Warnf("not notifying %d webhook(s) about the switch to context %q in offline mode", len(webhooks), contextName)
+		return
+	}
+
+	if err := audit.NotifyWebhooks(webhooks, newAuditEntry(kubeconfigPath, contextName)); err != nil {
+		logrus.Warnf("failed to notify webhooks about the switch to context %q: %v", contextName, err)
+	}
+}
+
+// matchingWebhooks returns the webhooks of the SwitchConfig whose context patterns match the new context
+func matchingWebhooks(config *types.Config, kubeconfigPath, contextName string) []types.WebhookConfig {
+	var webhooks []types.WebhookConfig
+	for _, webhook := range config.Webhooks {
+		if isProtectedContext(audit.WebhookPatterns(webhook, config.ProtectedContexts), kubeconfigPath, contextName) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks
+}
+
+// notifyNewContext shows a desktop notification for the new context if enabled in the SwitchConfig.
+// The switch succeeded even if the notification cannot be shown.
+func notifyNewContext(config *types.Config, kubeconfigPath, contextName string) {
+	if config.Notify == nil || *config.Notify == types.NotifyModeNever {
+		return
+	}
+
+	protected := isProtectedContext(config.ProtectedContexts, kubeconfigPath, contextName)
+	if *config.Notify == types.NotifyModeProtected && !protected {
+		return
+	}
+
+	message := fmt.Sprintf("Switched to context %q", contextName)
+	if protected {
+		message = fmt.Sprintf("Switched to protected context %q", contextName)
+	}
+	if err := util.Notify("kubeswitch", message, protected); err != nil {
+		logrus.Warnf("failed to show a desktop notification: %v", err)
+	}
+}
+
+// copyToClipboard copies the temporary kubeconfig or its path to the clipboard as given by the --clipboard flag
+func copyToClipboard(kubeconfigPath string) error {
+	switch clipboard {
+	case "path":
+		return util.CopyToClipboard(kubeconfigPath)
+	case "kubeconfig":
+		content, err := os.ReadFile(kubeconfigPath)
+		if err != nil {
+			return err
+		}
+		return util.CopyToClipboard(string(content))
+	default:
+		return fmt.Errorf("unknown value %q. Use either \"kubeconfig\" or \"path\"", clipboard)
+	}
+}
+
+// impersonate configures the user of the temporary kubeconfig to impersonate the user and groups of the flags
+func impersonate(kubeconfigPath string) error {
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	if err := kubeconfig.SetImpersonation(impersonateUser, impersonateGroups); err != nil {
+		return err
+	}
+	_, err = kubeconfig.WriteKubeconfigFile()
+	return err
+}
+
+// verifyNewContext asks for the context name if the new context is protected.
+// Then verifies that the cluster of the new context is reachable and accepts the credentials
+// if enabled in the SwitchConfig and asks for confirmation if the verification fails.
+// Removes the temporary kubeconfig if the switch is aborted.
+func verifyNewContext(config *types.Config, kubeconfigPath, contextName string) error {
+	if config == nil {
+		return nil
+	}
+	displayName := theme.New(config.Environments).Colorize(contextName, nil)
+
+	if !yesIMeanProd && isProtectedContext(config.ProtectedContexts, kubeconfigPath, contextName) && !verify.ConfirmProtected(contextName, displayName) {
+		removeTemporaryKubeconfig(kubeconfigPath)
+		return fmt.Errorf("aborted switch to protected context %q: type the context name or pass --yes-i-mean-prod to switch", contextName)
+	}
+
+	if config.VerifyBeforeSwitch == nil || !*config.VerifyBeforeSwitch {
+		return nil
+	}
+
+	verificationError := verify.Cluster(kubeconfigPath)
+	if verificationError == nil || verify.Confirm(displayName, verificationError) {
+		return nil
+	}
+
+	removeTemporaryKubeconfig(kubeconfigPath)
+	return fmt.Errorf("aborted switch to context %q: %v", contextName, verificationError)
+}
+
+// isProtectedContext checks the context name (or alias) and the original context name in the temporary kubeconfig
+// against the patterns of protected contexts
+func isProtectedContext(patterns []string, kubeconfigPath, contextName string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	var originalContextName string
+	if kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath); err == nil {
+		originalContextName = kubeconfig.GetCurrentContext()
+	}
+	return verify.IsProtected(patterns, contextName, originalContextName)
+}
+
+func removeTemporaryKubeconfig(kubeconfigPath string) {
+	if err := os.Remove(kubeconfigPath); err != nil {
+		logrus.Debugf("failed to remove temporary kubeconfig %q: %v", kubeconfigPath, err)
+	}
+}
+
+// formatRemaining formats the remaining duration in minutes, or seconds for the last minute
+func formatRemaining(remaining time.Duration) string {
+	if remaining < time.Minute {
+		return remaining.Round(time.Second).String()
+	}
+	return strings.TrimSuffix(remaining.Round(time.Minute).String(), "0s")
+}
+
+// colorizeContextName colors the context name by the matching environment of the SwitchConfig.
+// Only the context name is matched, as the tags of the kubeconfig are not known without a search.
+func colorizeContextName(contextName string) string {
+	config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+	if err != nil || config == nil {
+		return contextName
+	}
+	return theme.New(config.Environments).Colorize(contextName, nil)
+}
+
+// runPostSwitchHooks runs the hooks with trigger "PostSwitch" after a successful switch.
+// The output of the hooks is logged to std.err to not interfere with the kubeconfig path printed to std.out.
+func runPostSwitchHooks(kubeconfigPath string) {
+	log := logging.New().WithField("hook", "")
+	if err := hooks.PostSwitchHooks(log, configPath, kubeconfigPath); err != nil {
+		log.Error(err)
+	}
 }
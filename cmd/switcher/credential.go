@@ -0,0 +1,207 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/encryption"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	execcredential "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/exec-credential"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	encryptedCredentials  string
+	execCredentialStoreID string
+	execCredentialPath    string
+	execCredentialUser    string
+	execCredentialTags    []string
+	execCredentialContext string
+	execCredentialAlias   string
+	encryptionKeySource   string
+	encryptionAgeIdentity string
+
+	execCredentialCmd = &cobra.Command{
+		Use:   kubeconfigutil.ExecCredentialCommand,
+		Short: "Exec credential plugin for temporary kubeconfig files",
+		Long: `Implements the client-go credential plugin protocol for the temporary kubeconfig files written by kubeswitch.
+Called by kubectl, not intended to be used directly.
+
+With --encrypted-data, decrypts the credentials of a temporary kubeconfig file with encrypted credentials.
+With --store, retrieves the kubeconfig with the given --path from the kubeconfig store and returns the credentials of the --user.
+This is used by thin kubeconfigs (thinKubeconfigs: true in the SwitchConfig) to retrieve the credentials only when they are needed.
+For kubeconfig stores with "kubeconfigProvider: exec", short-lived credentials are minted with the API of the store instead
+and cached in the state directory until they expire, encrypted with the key of "temporaryKubeconfigEncryptionKey".`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				execCredential []byte
+				err            error
+			)
+			switch {
+			case len(encryptedCredentials) > 0:
+				keySource := types.EncryptionKeySource(encryptionKeySource)
+				execCredential, err = encryption.GetExecCredential(&types.EncryptionKeyConfig{
+					KeySource:   &keySource,
+					AgeIdentity: &encryptionAgeIdentity,
+				}, stateDirectory, encryptedCredentials)
+			case len(execCredentialStoreID) > 0:
+				execCredential, err = getExecCredentialFromStore()
+			default:
+				return fmt.Errorf("either the flag --encrypted-data or --store is required")
+			}
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(os.Stdout, string(execCredential))
+			return err
+		},
+		SilenceUsage: true,
+	}
+)
+
+// getExecCredentialFromStore returns the credentials of the user in the kubeconfig of the kubeconfig store
+func getExecCredentialFromStore() ([]byte, error) {
+	if len(execCredentialPath) == 0 || len(execCredentialUser) == 0 {
+		return nil, fmt.Errorf("the flags --path and --user are required with --store")
+	}
+
+	tags := make(map[string]string, len(execCredentialTags))
+	for _, tag := range execCredentialTags {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q: must be key=value", tag)
+		}
+		tags[key] = value
+	}
+
+	stores, config, err := initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, store := range stores {
+		if store.GetID() != execCredentialStoreID {
+			continue
+		}
+
+		// the credentials are handed out like when switching to the context, hence the kubeconfig policy has to be enforced
+		materialize := func(data []byte) ([]byte, error) {
+			kubeconfig, err := pkg.MaterializeKubeconfig(config, stateDirectory, pkg.MaterializeOptions{
+				Store:       store,
+				Path:        execCredentialPath,
+				Tags:        tags,
+				Data:        data,
+				ContextName: execCredentialContext,
+				Alias:       execCredentialAlias,
+				Portable:    true,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return kubeconfig.GetBytes()
+		}
+		return execcredential.GetExecCredential(store, execCredentialPath, tags, execCredentialUser, stateDirectory, mintCacheKey(store, config), materialize)
+	}
+	return nil, fmt.Errorf("kubeconfig store %q is not configured", execCredentialStoreID)
+}
+
+// mintCacheKey returns the key encrypting the cache of the credentials minted by the kubeconfig store.
+// Returns nil if the store does not mint credentials or the key cannot be loaded, so that the credentials are not cached.
+func mintCacheKey(store storetypes.KubeconfigStore, config *types.Config) []byte {
+	if !execcredential.MintsCredentials(store.GetStoreConfig()) {
+		return nil
+	}
+
+	var keyConfig *types.EncryptionKeyConfig
+	if config != nil {
+		keyConfig = config.TemporaryKubeconfigEncryptionKey
+	}
+	key, err := encryption.LoadKey(keyConfig, stateDirectory)
+	if err != nil {
+		store.GetLogger().Debugf("not caching the minted credentials, as the encryption key cannot be loaded: %v", err)
+		return nil
+	}
+	return key
+}
+
+func init() {
+	execCredentialCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the local directory used for storing internal state.")
+	execCredentialCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+	execCredentialCmd.Flags().StringVar(
+		&encryptedCredentials,
+		"encrypted-data",
+		"",
+		"the encrypted credentials.")
+	execCredentialCmd.Flags().StringVar(
+		&encryptionKeySource,
+		"key-source",
+		string(types.EncryptionKeySourceKeychain),
+		"where the key of the encrypted credentials is stored. One of keychain, age or file.")
+	execCredentialCmd.Flags().StringVar(
+		&encryptionAgeIdentity,
+		"age-identity",
+		"",
+		"path to the age identity decrypting the key for the key source age.")
+	execCredentialCmd.Flags().StringVar(
+		&execCredentialStoreID,
+		"store",
+		"",
+		"ID of the kubeconfig store to retrieve the kubeconfig from.")
+	execCredentialCmd.Flags().StringVar(
+		&execCredentialPath,
+		"path",
+		"",
+		"path of the kubeconfig in the kubeconfig store.")
+	execCredentialCmd.Flags().StringVar(
+		&execCredentialUser,
+		"user",
+		"",
+		"name of the user in the kubeconfig to return the credentials of.")
+	execCredentialCmd.Flags().StringArrayVar(
+		&execCredentialTags,
+		"tag",
+		nil,
+		"tag of the kubeconfig in the kubeconfig store as key=value. Can be repeated.")
+	execCredentialCmd.Flags().StringVar(
+		&execCredentialContext,
+		"context",
+		"",
+		"name of the context in the search, to enforce the kubeconfig policy. Defaults to the current context of the kubeconfig.")
+	execCredentialCmd.Flags().StringVar(
+		&execCredentialAlias,
+		"alias",
+		"",
+		"alias of the context, to enforce the kubeconfig policy.")
+	setLogFlags(execCredentialCmd)
+
+	rootCommand.AddCommand(execCredentialCmd)
+}
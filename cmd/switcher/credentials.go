@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/credentials"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	credentialsCmd = &cobra.Command{
+		Use:   "credentials",
+		Short: "Manage secrets of kubeconfig stores in the OS keychain",
+		Long: `Store secrets of kubeconfig stores (e.g. API keys and tokens) in the OS keychain (macOS Keychain, Windows Credential Manager or libsecret on Linux) instead of the SwitchConfig.
+Secrets configured in the SwitchConfig take precedence over secrets in the OS keychain.`,
+	}
+
+	credentialsSetCmd = &cobra.Command{
+		Use:   "set <store> [field...]",
+		Short: "Store secrets of a kubeconfig store in the OS keychain",
+		Long: `Prompts for the secrets of the kubeconfig store and stores them in the OS keychain.
+The store is identified by its ID, its kind (if the store has no ID) or "<kind>.<id>".
+Per default, prompts for all secret fields of the store.`,
+		Example: "switch credentials set exoscale\nswitch credentials set rancher.prod rancherToken",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switchConfig, err := loadConfigForCredentials()
+			if err != nil {
+				return err
+			}
+			return credentials.Set(switchConfig, args[0], args[1:])
+		},
+		SilenceUsage: true,
+	}
+
+	credentialsDeleteCmd = &cobra.Command{
+		Use:   "delete <store> [field...]",
+		Short: "Delete secrets of a kubeconfig store from the OS keychain",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switchConfig, err := loadConfigForCredentials()
+			if err != nil {
+				return err
+			}
+			return credentials.Delete(switchConfig, args[0], args[1:])
+		},
+		SilenceUsage: true,
+	}
+)
+
+func loadConfigForCredentials() (*types.Config, error) {
+	switchConfig, err := config.LoadConfig(util.ExpandEnv(configPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read switch config file: %v", err)
+	}
+	return switchConfig, nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{credentialsSetCmd, credentialsDeleteCmd} {
+		cmd.Flags().StringVar(
+			&configPath,
+			"config-path",
+			os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+			"path on the local filesystem to the configuration file.")
+		credentialsCmd.AddCommand(cmd)
+	}
+
+	rootCommand.AddCommand(credentialsCmd)
+}
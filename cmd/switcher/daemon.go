@@ -0,0 +1,190 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/daemon"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var (
+	daemonSocket          string
+	daemonRefreshInterval time.Duration
+	daemonRefreshIndex    bool
+
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Serve the contexts of all kubeconfig stores on a local Unix socket",
+		Long: `Searches all kubeconfig stores once, keeps the discovered contexts in memory and serves them on a local Unix socket.
+The kubeconfig stores are searched again in the given refresh interval to keep the contexts and the search index warm.
+Changes of the SwitchConfig (e.g. added or removed kubeconfig stores or changed credentials) are applied without restarting.
+Set the environment variable "SWITCH_DAEMON_SOCKET" to the socket to let "switch list-contexts", "switch set-context" and the shell completion query the daemon instead of the kubeconfig stores.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return daemon.Run(daemon.Options{
+				SocketPath:      getDaemonSocket(),
+				StateDirectory:  util.ExpandEnv(stateDirectory),
+				RefreshInterval: daemonRefreshInterval,
+				NoIndex:         noIndex,
+				RefreshIndex:    daemonRefreshIndex,
+				Stores:          stores,
+				Config:          config,
+				WatchConfig: func(ctx context.Context, onReload func([]storetypes.KubeconfigStore, *types.Config)) {
+					watchConfig(ctx, logging.New().WithField("component", "daemon"), onReload)
+				},
+			})
+		},
+		SilenceUsage: true,
+	}
+
+	daemonStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show the status of the running daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := daemon.NewClient(getDaemonSocket()).Status()
+			if err != nil {
+				return err
+			}
+			printDaemonStatus(status)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	daemonRefreshCmd = &cobra.Command{
+		Use:   "refresh",
+		Short: "Let the running daemon search all kubeconfig stores again",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := daemon.NewClient(getDaemonSocket()).Refresh()
+			if err != nil {
+				return err
+			}
+			printDaemonStatus(status)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(daemonCmd)
+	setDaemonSocketFlag(daemonCmd)
+	daemonCmd.Flags().DurationVar(
+		&daemonRefreshInterval,
+		"refresh-interval",
+		5*time.Minute,
+		"interval in which the kubeconfig stores are searched again.")
+	daemonCmd.Flags().BoolVar(
+		&daemonRefreshIndex,
+		"refresh-index",
+		false,
+		"additionally refresh the search index of each kubeconfig store on its own schedule (see \"switch refresh --watch\").")
+
+	for _, command := range []*cobra.Command{daemonStatusCmd, daemonRefreshCmd} {
+		setDaemonSocketFlag(command)
+		command.Flags().StringVar(
+			&stateDirectory,
+			"state-directory",
+			os.ExpandEnv("$HOME/.kube/switch-state"),
+			"path to the local directory used for storing internal state.")
+		daemonCmd.AddCommand(command)
+	}
+
+	rootCommand.AddCommand(daemonCmd)
+}
+
+func setDaemonSocketFlag(command *cobra.Command) {
+	command.Flags().StringVar(
+		&daemonSocket,
+		"socket",
+		"",
+		fmt.Sprintf("path to the Unix socket of the daemon. Defaults to the environment variable %q or %q in the state directory.", daemon.EnvSocket, daemon.SocketName))
+}
+
+// getDaemonSocket returns the socket of the daemon from the flag, the environment or the state directory
+func getDaemonSocket() string {
+	if len(daemonSocket) > 0 {
+		return util.ExpandEnv(daemonSocket)
+	}
+	if socket := os.Getenv(daemon.EnvSocket); len(socket) > 0 {
+		return util.ExpandEnv(socket)
+	}
+	return filepath.Join(util.ExpandEnv(stateDirectory), daemon.SocketName)
+}
+
+// getDaemonClient returns a client for the running daemon if the environment variable SWITCH_DAEMON_SOCKET is set.
+// Returns nil if the daemon should not be used or does not respond.
+func getDaemonClient() *daemon.Client {
+	socket := os.Getenv(daemon.EnvSocket)
+	// the daemon does not filter by the Kubernetes version or the tags, would call the kubeconfig stores in offline mode
+	// and does not record or replay the requests of its stores
+	if len(socket) == 0 || len(minVersion) > 0 || len(maxVersion) > 0 || len(tagSelectors) > 0 || offlineMode || len(recordPath) > 0 || len(replayPath) > 0 {
+		return nil
+	}
+
+	client := daemon.NewClient(util.ExpandEnv(socket))
+	if !client.Available() {
+		logrus.Debugf("daemon on socket %s does not respond. Searching the kubeconfig stores", socket)
+		return nil
+	}
+	return client
+}
+
+// listContextsFromDaemon returns the names (or aliases) of the contexts matching the wildcard pattern from the running daemon
+func listContextsFromDaemon(client *daemon.Client, pattern string) ([]string, error) {
+	daemonContexts, err := client.ListContexts(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list contexts: %v", err)
+	}
+
+	var contexts []string
+	for _, context := range daemonContexts {
+		name := context.Name
+		if len(context.Alias) > 0 {
+			name = context.Alias
+		}
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+func printDaemonStatus(status *daemon.Status) {
+	fmt.Printf("Contexts: %d\n", status.Contexts)
+	fmt.Printf("Last refresh: %s (%s ago)\n", status.LastRefresh.Format(time.RFC3339), time.Since(status.LastRefresh).Round(time.Second))
+	for _, err := range status.Errors {
+		fmt.Printf("Error: %s\n", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/dashboard"
+	set_context "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var (
+	dashboardTool        string
+	dashboardPort        int
+	dashboardOpenBrowser bool
+
+	dashboardCmd = &cobra.Command{
+		Use:   "dashboard [context]",
+		Short: "Start a local dashboard for a context",
+		Long: `Starts a local dashboard (Headlamp or Octant) pointed at the given context, or at the current context of the terminal if no context is given.
+The dashboard is stopped when the command exits, e.g. on Ctrl+C. The kubeconfig written for a given context is removed afterwards.
+The current terminal is not switched.`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeContextArgs(args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			dashboardConfig := &types.DashboardConfig{}
+			if config.Dashboard != nil {
+				dashboardConfig = config.Dashboard
+			}
+			if cmd.Flags().Changed("tool") {
+				dashboardConfig.Tool = ptr.To(types.DashboardTool(dashboardTool))
+				dashboardConfig.Command = nil
+			}
+			if cmd.Flags().Changed("port") {
+				dashboardConfig.Port = ptr.To(dashboardPort)
+			}
+
+			if len(args) == 0 {
+				kubeconfigPath, err := kubeconfigutil.CurrentKubeconfigPath()
+				if err != nil {
+					return err
+				}
+				kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+				if err != nil {
+					return err
+				}
+				return dashboard.Start(dashboardConfig, kubeconfigPath, kubeconfig.GetCurrentContext(), dashboardOpenBrowser)
+			}
+
+			ctxName, err := resolveContextName(args[0])
+			if err != nil {
+				return err
+			}
+
+			kubeconfigPath, contextName, err := set_context.SetContextExact(ctxName, stores, config, stateDirectory, noIndex, false)
+			if err != nil {
+				return err
+			}
+			defer removeTemporaryKubeconfig(*kubeconfigPath)
+
+			return dashboard.Start(dashboardConfig, *kubeconfigPath, *contextName, dashboardOpenBrowser)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(dashboardCmd)
+	dashboardCmd.Flags().StringVar(
+		&dashboardTool,
+		"tool",
+		string(types.DashboardToolHeadlamp),
+		"the dashboard to start: \"headlamp\" or \"octant\". Overrides the dashboard configured in the SwitchConfig.")
+	dashboardCmd.Flags().IntVar(
+		&dashboardPort,
+		"port",
+		7777,
+		"the local port the dashboard listens on, if supported by the dashboard.")
+	dashboardCmd.Flags().BoolVar(
+		&dashboardOpenBrowser,
+		"open-browser",
+		true,
+		"open the dashboard in the browser, if supported by the dashboard.")
+	_ = dashboardCmd.RegisterFlagCompletionFunc("tool", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return types.ValidDashboardTools.List(), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCommand.AddCommand(dashboardCmd)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/diff"
+)
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff CONTEXT_A CONTEXT_B",
+		Short: "Compare the effective configuration of two contexts",
+		Long: `Fetches the kubeconfigs of both contexts like when switching to them and shows the differences of their effective configuration:
+server, certificate authority, namespace and authentication (method, client certificate, exec plugin command and arguments).
+Secrets like tokens and client keys are redacted, tokens are compared by a short fingerprint. Use "." for the current context.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) >= 2 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			lc, _ := listContexts(toComplete)
+			return lc, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contextA, err := resolveContextName(args[0])
+			if err != nil {
+				return err
+			}
+			contextB, err := resolveContextName(args[1])
+			if err != nil {
+				return err
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return diff.Diff(contextA, contextB, stores, config, stateDirectory, noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(diffCmd)
+	rootCommand.AddCommand(diffCmd)
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/doctor"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the kubeswitch installation and configuration",
+		Long: `Checks the shell integration and completion, the SwitchConfig, the connectivity and credentials of the kubeconfig stores, the temporary kubeconfig directory and the freshness of the search index.
+Prints an actionable fix for each problem.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doctor.Doctor(doctor.Options{
+				ConfigPath:     util.ExpandEnv(configPath),
+				StateDirectory: util.ExpandEnv(stateDirectory),
+				Initialize:     initialize,
+			})
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(doctorCmd)
+	rootCommand.AddCommand(doctorCmd)
+}
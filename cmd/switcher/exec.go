@@ -23,15 +23,16 @@ import (
 
 var (
 	execCmd = &cobra.Command{
-		Use:                   "exec wildcard-search -- COMMAND [args...]",
+		Use:                   "exec [wildcard-search] -- COMMAND [args...]",
 		DisableFlagsInUseLine: true,
 		Aliases:               []string{"e"},
 		Short:                 "Execute any command towards the matching contexts from the wildcard search",
-		Long:                  `Execute any command to all the matching cluster contexts given by the search parameter. Eg: switch exec "*-dev-?" -- kubectl get namespaces"`,
+		Long: `Execute any command to all the matching cluster contexts given by the search parameter. Eg: switch exec "*-dev-?" -- kubectl get namespaces"
+Without search parameter, the contexts are selected interactively (select multiple contexts with Tab).`,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			var comps []string
 			if len(args) == 0 {
-				comps = cobra.AppendActiveHelp(comps, "You must provide a wildcard search string, like so: '*-dev-*'")
+				comps = cobra.AppendActiveHelp(comps, "Provide a wildcard search string, like so: '*-dev-*', or '--' to select the contexts interactively")
 				return comps, cobra.ShellCompDirectiveNoFileComp
 			} else if len(args) == 1 {
 				comps = cobra.AppendActiveHelp(comps, "Give a '--' to indicate start of command")
@@ -49,20 +50,20 @@ var (
 			}
 			// split additional args from the command and populate args after "--"
 			cmdArgs := util.SplitAdditionalArgs(&args)
-			if len(cmdArgs) >= 1 && len(args[0]) > 0 {
-				return exec.ExecuteCommand(args[0], cmdArgs, stores, config, stateDirectory, noIndex, showDebugLogs)
+			if len(cmdArgs) >= 1 && len(args) <= 1 {
+				var pattern string
+				if len(args) == 1 {
+					pattern = args[0]
+				}
+				return exec.ExecuteCommand(pattern, cmdArgs, stores, config, stateDirectory, noIndex)
 			}
-			return fmt.Errorf("please provide a search string and the command to execute on each cluster")
+			return fmt.Errorf("please provide an optional search string and the command to execute on each cluster")
 		},
 	}
 )
 
 func init() {
-	execCmd.Flags().BoolVar(
-		&showDebugLogs,
-		"debug",
-		false,
-		"show debug logs")
+	setLogFlags(execCmd)
 
 	rootCommand.AddCommand(execCmd)
 }
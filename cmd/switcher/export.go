@@ -0,0 +1,58 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/export"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutputPath string
+
+	exportCmd = &cobra.Command{
+		Use:   "export [PATTERN...]",
+		Short: "Merge contexts into a single kubeconfig file",
+		Long: `Fetches the kubeconfigs of the contexts matching one of the patterns from their kubeconfig stores and merges them into a single kubeconfig file.
+Patterns accept the wildcards '*' and '?'. Without patterns, the contexts are selected interactively (select multiple contexts with Tab).
+Files referenced by the kubeconfigs (e.g. certificates) are embedded, so the kubeconfig can be handed to other tools.`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			lc, _ := listContexts(toComplete)
+			return lc, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return export.Export(args, exportOutputPath, stores, config, stateDirectory, noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(exportCmd)
+	exportCmd.Flags().StringVarP(
+		&exportOutputPath,
+		"output",
+		"o",
+		"",
+		"path of the kubeconfig file to write.")
+	_ = exportCmd.MarkFlagRequired("output")
+
+	rootCommand.AddCommand(exportCmd)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/global"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+)
+
+var (
+	globalCmd = &cobra.Command{
+		Use:   "global",
+		Short: "Manage the default kubeconfig written in global mode",
+		Long: `In global mode ("globalMode.enabled" in the SwitchConfig or --global), switching the context also sets the current context of the default kubeconfig (~/.kube/config),
+for tools ignoring the KUBECONFIG environment variable. Contexts not contained in the default kubeconfig are merged into it with their cluster and user.
+The previous content of the default kubeconfig is backed up with the suffix ".kubeswitch.bak" before every modification.
+The last 5 backups are kept.`,
+	}
+
+	globalRollbackCmd = &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the default kubeconfig from the backup written by the last switch",
+		Long: `Restores the default kubeconfig from the backup written by the last switch in global mode.
+The replaced content becomes the new backup, so that running rollback again undoes the rollback.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
+			if err != nil {
+				return fmt.Errorf("failed to read switch config file: %v", err)
+			}
+
+			path := global.GetPath(config)
+			if dryrun.Enabled() {
+				dryrun.Printf("restore %q from the backup %q", path, global.BackupPath(path))
+				return nil
+			}
+			if err := global.Rollback(path); err != nil {
+				return err
+			}
+			fmt.Printf("Restored %q from the backup %q\n", path, global.BackupPath(path))
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	globalRollbackCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
+	globalCmd.AddCommand(globalRollbackCmd)
+	rootCommand.AddCommand(globalCmd)
+}
@@ -35,13 +35,16 @@ var (
 			}
 
 			kubeconfigPath, contextName, err := history.SwitchToHistory(stores, config, stateDirectory, noIndex)
-			reportNewContext(kubeconfigPath, contextName)
-			return err
+			if err != nil {
+				return err
+			}
+			return reportNewContext(kubeconfigPath, contextName)
 		},
 	}
 )
 
 func init() {
 	setFlagsForContextCommands(historyCmd)
+	setSwitchFlags(historyCmd)
 	rootCommand.AddCommand(historyCmd)
 }
@@ -17,7 +17,7 @@ import (
 	"os"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/hooks"
-	"github.com/sirupsen/logrus"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -26,11 +26,40 @@ var (
 		Use:   "hooks",
 		Short: "Run configured hooks",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			log := logrus.New().WithField("hook", hookName)
-			return hooks.Hooks(log, configPath, stateDirectory, hookName, runImmediately)
+			log := logging.New().WithField("hook", hookName)
+			return hooks.Hooks(log, configPath, stateDirectory, hookName, runImmediately, dryRun)
 		},
 	}
 
+	hookRunCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Run configured hooks",
+		Long:  `Runs all hooks executed prior to the search or the hook given by --hook-name. With --dry-run, the hooks that would be executed are only printed.`,
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.New().WithField("hook", hookName)
+			return hooks.Hooks(log, configPath, stateDirectory, hookName, runImmediately, dryRun)
+		},
+		SilenceUsage: true,
+	}
+
+	hookClearTitleCmd = &cobra.Command{
+		Use:   "clear-title",
+		Short: "Clear the terminal title set by hooks of type TerminalTitle",
+		Long:  `Clears the terminal title and the iTerm2 badge set by hooks of type TerminalTitle. Called by the shell integration (switch init) when the shell exits.`,
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return hooks.ClearTerminalTitle(logging.New().WithField("hook", "clear-title"), configPath)
+		},
+		SilenceUsage: true,
+	}
+
 	hookLsCmd = &cobra.Command{
 		Use:   "ls",
 		Short: "List configured hooks",
@@ -39,7 +68,7 @@ var (
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			log := logrus.New().WithField("hook-ls", hookName)
+			log := logging.New().WithField("hook-ls", hookName)
 			return hooks.ListHooks(log, configPath, stateDirectory)
 		},
 	}
@@ -60,6 +89,40 @@ func init() {
 
 	hookCmd.AddCommand(hookLsCmd)
 
+	hookClearTitleCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
+	hookCmd.AddCommand(hookClearTitleCmd)
+
+	hookRunCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
+	hookRunCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the state directory.")
+
+	hookRunCmd.Flags().StringVar(
+		&hookName,
+		"hook-name",
+		"",
+		"the name of the hook that should be run.")
+
+	hookRunCmd.Flags().BoolVar(
+		&runImmediately,
+		"run-immediately",
+		true,
+		"run hooks right away. Do not respect the hooks execution configuration.")
+
+	hookCmd.AddCommand(hookRunCmd)
+
 	hookCmd.Flags().StringVar(
 		&configPath,
 		"config-path",
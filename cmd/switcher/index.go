@@ -0,0 +1,79 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	sharedindex "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/shared-index"
+)
+
+var (
+	indexCmd = &cobra.Command{
+		Use:   "index",
+		Short: "Share the index of the kubeconfig stores within a team",
+		Long: `Publishes the index of the kubeconfig stores to the location configured in "sharedIndex" of the SwitchConfig, or merges the published index into the local index.
+The shared index contains the names, paths and metadata of the contexts, but no credentials: team members fetch the kubeconfigs from their kubeconfig stores with their own credentials.`,
+	}
+
+	indexPublishCmd = &cobra.Command{
+		Use:   "publish",
+		Short: "Publish the contexts of the kubeconfig stores to the shared index",
+		Long: `Searches the kubeconfig stores and uploads the names, paths and metadata of their contexts to the shared index.
+Only the kubeconfig stores listed in "sharedIndex.stores" are published, if configured. Contexts of filesystem stores are never published.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return sharedindex.Publish(stores, config, stateDirectory, noIndex)
+		},
+		SilenceUsage: true,
+	}
+
+	indexPullCmd = &cobra.Command{
+		Use:   "pull",
+		Short: "Merge the shared index into the local index",
+		Long: `Downloads the shared index and merges its contexts into the index of the kubeconfig stores with the same ID, e.g. to list the clusters of the team right after setting up kubeswitch.
+Contexts already found by the local kubeconfig stores are kept. The merged contexts are replaced by the contexts the kubeconfig store returns once its index is refreshed.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return sharedindex.Pull(stores, config, stateDirectory)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(indexPublishCmd)
+	setFlagsForContextCommands(indexPullCmd)
+
+	indexCmd.AddCommand(indexPublishCmd)
+	indexCmd.AddCommand(indexPullCmd)
+	rootCommand.AddCommand(indexCmd)
+}
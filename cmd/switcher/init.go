@@ -16,6 +16,7 @@ package switcher
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -50,9 +51,11 @@ function switch(){
   fi
 
   RESPONSE="$($EXECUTABLE_PATH "${opts[@]}")"
-  if [ $? -ne 0 -o -z "$RESPONSE" ]; then
+  # keep the exit code of the switcher binary, e.g. for the exit codes of --non-interactive
+  local EXIT_CODE=$?
+  if [ $EXIT_CODE -ne 0 -o -z "$RESPONSE" ]; then
 	printf "%s\n" "$RESPONSE"
-	return $?
+	return $EXIT_CODE
   fi
 
   # switcher returns a response that contains a kubeconfig path with a prefix "__ " to be able to
@@ -91,8 +94,27 @@ function switch(){
   fi
 
   export KUBECONFIG="$KUBECONFIG_PATH"
+  # remember the kubeconfig of the pane for the tmux status line (switch tmux status)
+  if [ -n "$TMUX" ]; then
+	command tmux set-option -p @kubeswitch_kubeconfig "$KUBECONFIG" 2>/dev/null
+  fi
   printf "switched to context %s\n" "$SELECTED_CONTEXT"
-}`
+}
+
+# clears the terminal title set by hooks of type TerminalTitle when a shell that switched the context exits
+kubeswitch_exit() {
+  case "$KUBECONFIG" in
+	*"/.kube/.switch_tmp/"*) command "${EXECUTABLE_PATH:-switcher}" hooks clear-title 2>/dev/null ;;
+  esac
+}
+
+if [ -n "$ZSH_VERSION" ]; then
+  autoload -Uz add-zsh-hook
+  add-zsh-hook zshexit kubeswitch_exit
+elif [ -z "$(trap -p EXIT)" ]; then
+  # an EXIT trap of the user is not replaced
+  trap kubeswitch_exit EXIT
+fi`
 
 	fishScript string = `
 function kubeswitch
@@ -102,15 +124,18 @@ function kubeswitch
   set -f REPORT_RESPONSE
   set -f opts
 
-  for i in $argv
-	switch "$i"
+  set -l i 1
+  while test $i -le (count $argv)
+	switch "$argv[$i]"
 	  case --executable-path
-		set -f EXECUTABLE_PATH $i
+		set i (math $i + 1)
+		set -f EXECUTABLE_PATH $argv[$i]
 	  case completion
-		set -a opts $i --cmd kubeswitch
+		set -a opts $argv[$i] --cmd kubeswitch
 	  case '*'
-		set -a opts $i
+		set -a opts $argv[$i]
 	end
+	set i (math $i + 1)
   end
 
   if test -z "$EXECUTABLE_PATH"
@@ -158,119 +183,380 @@ function kubeswitch
 	end
 
 	set -gx KUBECONFIG "$KUBECONFIG_PATH"
+	# remember the kubeconfig of the pane for the tmux status line (switch tmux status)
+	if set -q TMUX
+	  command tmux set-option -p @kubeswitch_kubeconfig "$KUBECONFIG" 2>/dev/null
+	end
 	printf "switched to context %s\n" "$SELECTED_CONTEXT"
 	return
   end
   printf "%s\n" $RESPONSE
-    end`
+end
 
-	powershellScript string = `
-function has_prefix {
-	param (
-		[string]$prefix,
-		[string]$string
-	)
-
-	if ($string.StartsWith($prefix)) {
-		return $true
-	} else {
-		return $false
-	}
+# complete the kubeswitch function like the switcher binary
+complete -c kubeswitch --wraps switcher
+
+# prints the current context and namespace, e.g. in fish_right_prompt. Arguments are passed to "switcher prompt"
+function kubeswitch_prompt
+  set -l executable switcher
+  if set -q EXECUTABLE_PATH
+	set executable $EXECUTABLE_PATH
+  end
+  $executable prompt $argv 2>/dev/null
+end
+
+# clears the terminal title set by hooks of type TerminalTitle when a shell that switched the context exits
+function __kubeswitch_exit --on-event fish_exit
+  if string match -q "*/.kube/.switch_tmp/*" -- "$KUBECONFIG"
+	set -l executable switcher
+	if set -q EXECUTABLE_PATH
+	  set executable $EXECUTABLE_PATH
+	end
+	$executable hooks clear-title 2>/dev/null
+  end
+end
+
+# opens the history picker from a key binding, e.g. "bind \eh kubeswitch_history_widget"
+function kubeswitch_history_widget
+  kubeswitch history
+  commandline -f repaint
+end
+
+# opens the picker from a key binding and switches the context of the current shell, e.g. "bind \ck kubeswitch_widget".
+# The typed command line is kept.
+function kubeswitch_widget
+  kubeswitch </dev/tty
+  commandline -f repaint
+end`
+
+	// shellWidgetScript opens the picker from a key binding (a ZLE widget in zsh, a readline binding in bash)
+	// and switches the context of the current shell without typing a command. The typed command line is kept.
+	shellWidgetScript string = `
+kubeswitch_widget() {
+  # the picker reads from the terminal, which is not the standard input of widgets
+  [ -n "$ZSH_VERSION" ] && zle -I
+  switch </dev/tty
+  local ret=$?
+  [ -n "$ZSH_VERSION" ] && zle reset-prompt
+  return $ret
 }
 
+[ -n "$ZSH_VERSION" ] && zle -N kubeswitch_widget`
+
+	// shellCdHookScript switches to the context declared for a directory when entering it
+	// and restores the previous context when leaving it
+	shellCdHookScript string = `
+_kubeswitch_cd_hook() {
+  [ "$PWD" = "$_KUBESWITCH_LAST_PWD" ] && return
+  _KUBESWITCH_LAST_PWD="$PWD"
+
+  local executable="${EXECUTABLE_PATH:-switcher}"
+  local source_path
+  source_path="$($executable auto --print-source 2>/dev/null)"
+  [ "$source_path" = "$_KUBESWITCH_AUTO_SOURCE" ] && return
+
+  if [ -n "$_KUBESWITCH_AUTO_SOURCE" ]; then
+	# restore the previous context unless the context has been changed in the meantime
+	if [ -n "$_KUBESWITCH_AUTO_KUBECONFIG" ] && [ "$KUBECONFIG" = "$_KUBESWITCH_AUTO_KUBECONFIG" ]; then
+	  \rm -f "$_KUBESWITCH_AUTO_KUBECONFIG"
+	  if [ -n "$_KUBESWITCH_PREVIOUS_KUBECONFIG" ]; then
+		export KUBECONFIG="$_KUBESWITCH_PREVIOUS_KUBECONFIG"
+	  else
+		unset KUBECONFIG
+	  fi
+	  [ -n "$TMUX" ] && command tmux set-option -p @kubeswitch_kubeconfig "$KUBECONFIG" 2>/dev/null
+	  printf "restored the previous context\n"
+	fi
+	unset _KUBESWITCH_AUTO_SOURCE _KUBESWITCH_AUTO_KUBECONFIG _KUBESWITCH_PREVIOUS_KUBECONFIG
+  fi
+
+  if [ -z "$source_path" ]; then
+	return
+  fi
+
+  # remember the source even if the switch fails to not retry on every directory change
+  _KUBESWITCH_AUTO_SOURCE="$source_path"
+  local kubeconfig_path
+  kubeconfig_path="$($executable auto --non-interactive)" || return
+  _KUBESWITCH_AUTO_KUBECONFIG="$kubeconfig_path"
+  _KUBESWITCH_PREVIOUS_KUBECONFIG="$KUBECONFIG"
+  export KUBECONFIG="$kubeconfig_path"
+  [ -n "$TMUX" ] && command tmux set-option -p @kubeswitch_kubeconfig "$KUBECONFIG" 2>/dev/null
+  printf "switched to the context of %s\n" "$source_path"
+}
+
+if [ -n "$ZSH_VERSION" ]; then
+  autoload -Uz add-zsh-hook
+  add-zsh-hook precmd _kubeswitch_cd_hook
+else
+  case ";${PROMPT_COMMAND};" in
+	*";_kubeswitch_cd_hook;"*) ;;
+	*) PROMPT_COMMAND="_kubeswitch_cd_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+  esac
+fi`
+
+	fishCdHookScript string = `
+function __kubeswitch_cd_hook --on-variable PWD
+  set -l executable switcher
+  if set -q EXECUTABLE_PATH
+	set executable $EXECUTABLE_PATH
+  end
+
+  set -l source_path ($executable auto --print-source 2>/dev/null)
+  if test "$source_path" = "$__kubeswitch_auto_source"
+	return
+  end
+
+  if set -q __kubeswitch_auto_source
+	# restore the previous context unless the context has been changed in the meantime
+	if test -n "$__kubeswitch_auto_kubeconfig"; and test "$KUBECONFIG" = "$__kubeswitch_auto_kubeconfig"
+	  command rm -f "$__kubeswitch_auto_kubeconfig"
+	  if test -n "$__kubeswitch_previous_kubeconfig"
+		set -gx KUBECONFIG "$__kubeswitch_previous_kubeconfig"
+	  else
+		set -e KUBECONFIG
+	  end
+	  set -q TMUX; and command tmux set-option -p @kubeswitch_kubeconfig "$KUBECONFIG" 2>/dev/null
+	  printf "restored the previous context\n"
+	end
+	set -e __kubeswitch_auto_source
+	set -e __kubeswitch_auto_kubeconfig
+	set -e __kubeswitch_previous_kubeconfig
+  end
+
+  if test -z "$source_path"
+	return
+  end
+
+  # remember the source even if the switch fails to not retry on every directory change
+  set -g __kubeswitch_auto_source $source_path
+  set -l kubeconfig_path ($executable auto --non-interactive); or return
+  set -g __kubeswitch_auto_kubeconfig $kubeconfig_path
+  set -g __kubeswitch_previous_kubeconfig "$KUBECONFIG"
+  set -gx KUBECONFIG $kubeconfig_path
+  set -q TMUX; and command tmux set-option -p @kubeswitch_kubeconfig "$KUBECONFIG" 2>/dev/null
+  printf "switched to the context of %s\n" $source_path
+end
+
+__kubeswitch_cd_hook`
+
+	powershellScript string = `
 function kubeswitch {
+	# the switcher binary has to be on the PATH, unless $env:EXECUTABLE_PATH or --executable-path is set
+	$executablePath = "switcher_windows_amd64.exe"
+	if ($env:EXECUTABLE_PATH) {
+		$executablePath = $env:EXECUTABLE_PATH
+	}
 
-	#You need to have switcher_windows_amd64.exe in your PATH, or you need to change the value of EXECUTABLE_PATH here
-	$EXECUTABLE_PATH = "switcher_windows_amd64.exe"
-
-	if (-not $args) {
-	Write-Output "no options provided"
-		Write-Output $EXECUTABLE_PATH $args
-		$RESPONSE = & $EXECUTABLE_PATH 
-	} 
-	else{
-	Write-Output "options provided:" $args
-			Write-Output $EXECUTABLE_PATH $args
-		$RESPONSE = & $EXECUTABLE_PATH  $args
+	$opts = @()
+	for ($i = 0; $i -lt $args.Count; $i++) {
+		switch -Exact ($args[$i]) {
+			"--executable-path" {
+				$i++
+				$executablePath = $args[$i]
+			}
+			"completion" {
+				$opts += $args[$i], "--cmd", "kubeswitch"
+			}
+			default {
+				$opts += $args[$i]
+			}
+		}
 	}
 
-	if ($LASTEXITCODE -ne 0 -or -not $RESPONSE) {
-		Write-Output $RESPONSE
-		return $LASTEXITCODE
+	$response = & $executablePath @opts
+	if ($LASTEXITCODE -ne 0 -or -not $response) {
+		$response
+		return
 	}
 
 	# switcher returns a response that contains a kubeconfig path with a prefix "__ " to be able to
 	# distinguish it from other responses which just need to write to STDOUT
-	$prefix = "__ "
-	if (-not (has_prefix $prefix $RESPONSE)) {
-		Write-Output $RESPONSE
+	if (-not ($response -is [string]) -or -not $response.StartsWith("__ ")) {
+		$response
 		return
 	}
 
-
-	$RESPONSE = $RESPONSE -replace $prefix, ""
-	Write-Output $RESPONSE
-	$remainder = $RESPONSE
-	Write-Output $remainder
-	Write-Output $remainder.split(",")[0]
-	Write-Output $remainder.split(",")[1]
-	$KUBECONFIG_PATH = $remainder.split(",")[0]
-	$KUBECONFIG_PATH = $KUBECONFIG_PATH -replace '\\', '/'
-	$KUBECONFIG_PATH = $KUBECONFIG_PATH -replace "C:", ""
-	Write-Output $KUBECONFIG_PATH
-	$SELECTED_CONTEXT = $remainder.split(",")[1]
-
-	if (-not $KUBECONFIG_PATH) { 
-		Write-Output $RESPONSE
+	# the response from the switcher binary is "kubeconfig_path,selected_context"
+	$kubeconfigPath, $selectedContext = $response.Substring(3).Split(",", 2)
+	if (-not $kubeconfigPath -or -not $selectedContext) {
+		$response
 		return
 	}
 
-	if (-not $SELECTED_CONTEXT) {
-		Write-Output $RESPONSE
+	if (-not (Test-Path -LiteralPath $kubeconfigPath)) {
+		Write-Error ('"{0}" does not exist' -f $kubeconfigPath)
 		return
 	}
 
-	$switchTmpDirectory = "$env:USERPROFILE\.kube\.switch_tmp\config"
-	if ($env:KUBECONFIG -and $env:KUBECONFIG -like "*$switchTmpDirectory*") {
-		Remove-Item -Path $env:KUBECONFIG -Force
+	$switchTmpDirectory = Join-Path $env:LOCALAPPDATA "kubeswitch\tmp"
+	if ($env:KUBECONFIG -and $env:KUBECONFIG.StartsWith($switchTmpDirectory, [System.StringComparison]::OrdinalIgnoreCase)) {
+		Remove-Item -LiteralPath $env:KUBECONFIG -Force -ErrorAction SilentlyContinue
 	}
 
-	$env:KUBECONFIG = $KUBECONFIG_PATH
-	Write-Output "switched to context $SELECTED_CONTEXT"
+	# environment variables apply to the whole PowerShell session
+	$env:KUBECONFIG = $kubeconfigPath
+	Write-Output "switched to context $selectedContext"
 }
 
-#Env variable HOME doesn't exist on windows, we create it from USERPROFILE
-$Env:HOME = $Env:USERPROFILE
+# Env variable HOME doesn't exist on windows, we create it from USERPROFILE
+if (-not $env:HOME) {
+	$env:HOME = $env:USERPROFILE
+}
+
+# clears the terminal title set by hooks of type TerminalTitle when a session that switched the context exits
+$null = Register-EngineEvent -SourceIdentifier PowerShell.Exiting -SupportEvent -Action {
+	$switchTmpDirectory = Join-Path $env:LOCALAPPDATA "kubeswitch\tmp"
+	if ($env:KUBECONFIG -and $env:KUBECONFIG.StartsWith($switchTmpDirectory, [System.StringComparison]::OrdinalIgnoreCase)) {
+		$executablePath = "switcher_windows_amd64.exe"
+		if ($env:EXECUTABLE_PATH) {
+			$executablePath = $env:EXECUTABLE_PATH
+		}
+		& $executablePath hooks clear-title 2>$null
+	}
+}
+`
+
+	// nushellScript is a module of commands for nushell, which cannot evaluate the POSIX shell function.
+	// Completions call the "__complete" command of the switcher binary generated by cobra.
+	nushellScript string = `
+def "nu-complete kubeswitch" [context: string] {
+  let executable = ($env.EXECUTABLE_PATH? | default "switcher")
+  # the last word is the one to complete. It is empty if the command line ends with a space
+  let words = ($context | str trim --left | split row " " | skip 1)
+  ^$executable __complete ...$words
+  | lines
+  | where {|line| not ($line | str starts-with ":") }
+  | each {|line|
+    let parts = ($line | split row "\t")
+    { value: ($parts | first), description: ($parts.1? | default "") }
+  }
+}
+
+def --env kubeswitch [...args: string@"nu-complete kubeswitch"] {
+  # if the executable path is not set, the switcher binary has to be on the path
+  let executable = ($env.EXECUTABLE_PATH? | default "switcher")
+  let response = (^$executable ...$args | str trim)
+
+  # switcher returns a response that contains a kubeconfig path with a prefix "__ " to be able to
+  # distinguish it from other responses which just need to write to STDOUT
+  if not ($response | str starts-with "__ ") {
+    if ($response | is-not-empty) {
+      print $response
+    }
+    return
+  }
+
+  # the response from the switcher binary is "kubeconfig_path,selected_context"
+  let parts = ($response | str replace --regex "^__ " "" | split row ",")
+  let kubeconfig_path = ($parts | first)
+  let selected_context = ($parts | skip 1 | str join ",")
+
+  if not ($kubeconfig_path | path exists) {
+    error make { msg: $"\"($kubeconfig_path)\" does not exist" }
+  }
+
+  let switch_tmp_directory = ($env.HOME | path join ".kube" ".switch_tmp" "config")
+  if ($env.KUBECONFIG? | default "" | str starts-with $switch_tmp_directory) {
+    rm --force $env.KUBECONFIG
+  }
+
+  $env.KUBECONFIG = $kubeconfig_path
+  # remember the kubeconfig of the pane for the tmux status line (switch tmux status)
+  if ($env.TMUX? | is-not-empty) {
+    do --ignore-errors { ^tmux set-option -p @kubeswitch_kubeconfig $kubeconfig_path }
+  }
+  print $"switched to context ($selected_context)"
+}
 `
 )
 
 var (
+	initCdHook  bool
+	initKubectl bool
+	initBindKey string
+
 	initCmd = &cobra.Command{
-		Use:                   "init [bash|zsh|fish|powershell]",
+		Use:                   "init [bash|zsh|fish|powershell|nu]",
 		Short:                 "generate init and completion script",
-		Long:                  "generate and load the init and completion script for switch into the current shell. Use it like this: 'source <(switcher init zsh)'",
+		Long:                  "generate and load the init and completion script for switch into the current shell. Use it like this: 'source <(switcher init zsh)'. Nushell cannot source the output of a command, save it to a file and source the file in config.nu instead. With --cd-hook, the context declared in a .kubeswitch file or for a Git repository is switched to automatically when entering its directory. With --bind-key, a key binding opens the picker in place and switches the context of the current shell, like Ctrl-R of fzf.",
+		Example:               "source <(switcher init zsh --bind-key ctrl-k)",
 		DisableFlagsInUseLine: true,
-		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell", "nu"},
 		Args:                  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			root := cmd.Root()
 			if setName != "" {
 				root.Use = setName
 			}
+
+			var binding string
+			if len(initBindKey) > 0 {
+				script, err := keyBinding(args[0], initBindKey)
+				if err != nil {
+					return err
+				}
+				binding = script + "\n"
+			}
+
 			switch args[0] {
 			case "bash":
 				// same shell script as zsh, but different bash completion
 				fmt.Println(shellScript)
+				fmt.Println(shellWidgetScript)
+				fmt.Print(binding)
+				if initCdHook {
+					fmt.Println(shellCdHookScript)
+				}
+				if initKubectl {
+					fmt.Println(kubectlShimScript)
+				}
 				return root.GenBashCompletion(os.Stdout)
 			case "zsh":
 				fmt.Println(shellScript)
+				fmt.Println(shellWidgetScript)
+				fmt.Print(binding)
+				if initCdHook {
+					fmt.Println(shellCdHookScript)
+				}
+				if initKubectl {
+					fmt.Println(kubectlShimScript)
+				}
 				return root.GenZshCompletion(os.Stdout)
 			case "fish":
 				fmt.Println(fishScript)
+				fmt.Print(binding)
+				if initCdHook {
+					fmt.Println(fishCdHookScript)
+				}
+				if initKubectl {
+					fmt.Println(kubectlFishShimScript)
+				}
 				return root.GenFishCompletion(os.Stdout, true)
 			case "powershell":
+				if initCdHook {
+					return fmt.Errorf("the cd hook is not supported for powershell")
+				}
+				if initKubectl {
+					return fmt.Errorf("the kubectl shim is not supported for powershell")
+				}
 				fmt.Println(powershellScript)
-				return root.GenPowerShellCompletion(os.Stdout)
+				fmt.Print(binding)
+				if err := root.GenPowerShellCompletion(os.Stdout); err != nil {
+					return err
+				}
+				// complete contexts, namespaces and commands of the kubeswitch function like the switcher binary
+				fmt.Printf("Register-ArgumentCompleter -CommandName 'kubeswitch' -ScriptBlock ${__%sCompleterBlock}\n", root.Name())
+				return nil
+			case "nu", "nushell":
+				if initCdHook {
+					return fmt.Errorf("the cd hook is not supported for nushell")
+				}
+				if initKubectl {
+					return fmt.Errorf("the kubectl shim is not supported for nushell")
+				}
+				fmt.Println(nushellScript)
+				return nil
 			}
 			return fmt.Errorf("unsupported shell type: %s", args[0])
 		},
@@ -278,5 +564,63 @@ var (
 )
 
 func init() {
+	initCmd.Flags().BoolVar(
+		&initCdHook,
+		"cd-hook",
+		false,
+		"switch to the context declared in a .kubeswitch file or for a Git repository when entering its directory and restore the previous context when leaving it.")
+	initCmd.Flags().BoolVar(
+		&initKubectl,
+		"kubectl",
+		isKubectlPlugin(),
+		"wrap kubectl so that \"kubectl switch\" changes the context of the current shell like switch. Defaults to true if invoked as kubectl plugin.")
+	initCmd.Flags().StringVar(
+		&initBindKey,
+		"bind-key",
+		"",
+		"bind the given key to open the picker and switch the context of the current shell, e.g. \"ctrl-k\" or \"alt-k\". The command line typed so far is kept.")
+
 	rootCommand.AddCommand(initCmd)
 }
+
+// keyBinding returns the script binding the key (e.g. "ctrl-k" or "alt-k") to the widget opening the picker in the given shell
+func keyBinding(shell, key string) (string, error) {
+	modifier, letter, found := strings.Cut(strings.ToLower(key), "-")
+	if !found || len(letter) != 1 || !(letter[0] >= 'a' && letter[0] <= 'z' || letter[0] >= '0' && letter[0] <= '9') || (modifier != "ctrl" && modifier != "alt") {
+		return "", fmt.Errorf("unsupported key %q. Use \"ctrl-<letter>\" or \"alt-<letter or digit>\"", key)
+	}
+	if modifier == "ctrl" && letter[0] >= '0' && letter[0] <= '9' {
+		return "", fmt.Errorf("unsupported key %q: terminals do not send Ctrl with digits", key)
+	}
+
+	ctrl := modifier == "ctrl"
+	switch shell {
+	case "bash":
+		// bind fails in non-interactive shells without line editing
+		if ctrl {
+			return fmt.Sprintf(`[[ $- == *i* ]] && bind -x '"\C-%s": kubeswitch_widget'`, letter), nil
+		}
+		return fmt.Sprintf(`[[ $- == *i* ]] && bind -x '"\e%s": kubeswitch_widget'`, letter), nil
+	case "zsh":
+		// emacs and vi insert mode
+		if ctrl {
+			return fmt.Sprintf("bindkey -M emacs '^%[1]s' kubeswitch_widget\nbindkey -M viins '^%[1]s' kubeswitch_widget", strings.ToUpper(letter)), nil
+		}
+		return fmt.Sprintf("bindkey -M emacs '^[%[1]s' kubeswitch_widget\nbindkey -M viins '^[%[1]s' kubeswitch_widget", letter), nil
+	case "fish":
+		if ctrl {
+			return fmt.Sprintf(`bind \c%s kubeswitch_widget`, letter), nil
+		}
+		return fmt.Sprintf(`bind \e%s kubeswitch_widget`, letter), nil
+	case "powershell":
+		chord := "Alt+" + letter
+		if ctrl {
+			chord = "Ctrl+" + letter
+		}
+		return fmt.Sprintf(`Set-PSReadLineKeyHandler -Chord '%s' -BriefDescription 'kubeswitch' -ScriptBlock {
+	kubeswitch
+	[Microsoft.PowerShell.PSConsoleReadLine]::InvokePrompt()
+}`, chord), nil
+	}
+	return "", fmt.Errorf("the key binding is not supported for %s", shell)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/inventory"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inventoryOutput string
+
+	inventoryCmd = &cobra.Command{
+		Use:   "inventory [wildcard-search]",
+		Short: "Report all clusters of the kubeconfig stores as CSV or JSON",
+		Long: `Searches all kubeconfig stores and reports one entry per cluster with its contexts, the kubeconfig store, the cloud provider, account, region, Kubernetes version, API server and tags.
+Contexts of the same kubeconfig store pointing to the same API server are reported as one cluster.
+The kubeconfig stores are searched even if their search index is up to date, unless in offline mode.
+The optional wildcard search only reports the clusters with a matching context.`,
+		Example: `  switch inventory -o csv > clusters.csv
+  switch inventory "*-prod*" -o json`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if inventoryOutput != inventory.FormatCSV && inventoryOutput != inventory.FormatJSON {
+				return fmt.Errorf("unknown output format %q. Valid formats are %q and %q", inventoryOutput, inventory.FormatCSV, inventory.FormatJSON)
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			var pattern string
+			if len(args) == 1 {
+				pattern = args[0]
+			}
+
+			// the index can only be read in offline mode
+			clusters, err := inventory.Inventory(pattern, stores, config, stateDirectory, !offline.Enabled())
+			if err != nil {
+				return err
+			}
+			return inventory.Print(os.Stdout, clusters, inventoryOutput)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(inventoryCmd)
+	inventoryCmd.Flags().StringVarP(
+		&inventoryOutput,
+		"output",
+		"o",
+		inventory.FormatCSV,
+		"the output format. Either \"csv\" or \"json\".")
+
+	rootCommand.AddCommand(inventoryCmd)
+}
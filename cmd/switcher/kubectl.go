@@ -0,0 +1,104 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// kubectlPluginName is the executable name kubectl looks up on the PATH for "kubectl switch"
+	kubectlPluginName = "kubectl-switch"
+	// kubectlCompletionName is the executable name kubectl (>= v1.26) runs to complete the arguments of "kubectl switch"
+	kubectlCompletionName = "kubectl_complete-switch"
+	// envKubectlShim is set by the kubectl shell shim. The kubeconfig path is then reported to the shell function
+	// instead of printing an export statement.
+	envKubectlShim = "KUBESWITCH_KUBECTL_SHIM"
+)
+
+var (
+	// kubectlShimScript routes "kubectl switch" through the switch function, as kubectl runs plugins
+	// in a child process which cannot change the KUBECONFIG of the current shell
+	kubectlShimScript string = `
+function kubectl(){
+  if [ "$1" = "switch" ]; then
+	shift
+	KUBESWITCH_KUBECTL_SHIM=1 EXECUTABLE_PATH="${EXECUTABLE_PATH:-kubectl-switch}" switch "$@"
+  else
+	command kubectl "$@"
+  fi
+}`
+
+	kubectlFishShimScript string = `
+function kubectl --wraps kubectl
+  if test "$argv[1]" = switch
+	set -lx KUBESWITCH_KUBECTL_SHIM 1
+	set -l executable_path kubectl-switch
+	if set -q EXECUTABLE_PATH
+	  set executable_path $EXECUTABLE_PATH
+	end
+	kubeswitch --executable-path $executable_path $argv[2..-1]
+  else
+	command kubectl $argv
+  end
+end`
+)
+
+// executableName returns the name the binary has been invoked with, without the extension on Windows
+func executableName() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
+
+// isKubectlPlugin returns true if the binary has been invoked by kubectl as plugin, e.g. "kubectl switch"
+func isKubectlPlugin() bool {
+	name := executableName()
+	return name == kubectlPluginName || name == kubectlCompletionName
+}
+
+// configureKubectlPlugin adapts the root command if the binary has been invoked as kubectl plugin
+func configureKubectlPlugin(root *cobra.Command) {
+	if !isKubectlPlugin() {
+		return
+	}
+
+	// shows "kubectl switch" instead of "switcher" in the usage and help texts
+	if root.Annotations == nil {
+		root.Annotations = map[string]string{}
+	}
+	root.Annotations[cobra.CommandDisplayNameAnnotation] = "kubectl switch"
+
+	// kubectl calls kubectl_complete-switch with the arguments to complete
+	if executableName() == kubectlCompletionName {
+		root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, os.Args[1:]...))
+	}
+}
+
+// reportToKubectl prints the export statement for the new kubeconfig if "kubectl switch" has not been
+// called through the shell shim, as the plugin cannot change the KUBECONFIG of the current shell.
+// Returns false if the kubeconfig path should be reported to the shell function instead.
+func reportToKubectl(kubeconfigPath, contextName string) bool {
+	if !isKubectlPlugin() || len(os.Getenv(envKubectlShim)) > 0 {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "switched to context %s. kubectl plugins cannot change the KUBECONFIG of the current shell: run 'eval \"$(kubectl switch ...)\"' or load the shell shim with 'source <(kubectl switch init bash)'\n", contextName)
+	fmt.Printf("export KUBECONFIG='%s'\n", kubeconfigPath)
+	return true
+}
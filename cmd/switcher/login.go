@@ -0,0 +1,47 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/login"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginCmd = &cobra.Command{
+		Use:   "login [store...]",
+		Short: "Authenticate against the kubeconfig stores",
+		Long: `Runs the authentication flow of the kubeconfig stores up front (e.g. "aws sso login" for EKS, "gcloud auth application-default login" for GKE, "az login" for Azure or a Vault token check) and reports when the credentials expire.
+Stores are given by kind or ID. Per default, all kubeconfig stores are used.`,
+		Example: "switch login\nswitch login eks gke",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				storeSelectors = append(storeSelectors, args...)
+			}
+
+			stores, _, err := initialize()
+			if err != nil {
+				return err
+			}
+			return login.Login(stores)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(loginCmd)
+	rootCommand.AddCommand(loginCmd)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/mcp"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+)
+
+var (
+	mcpReadOnly bool
+
+	mcpCmd = &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve kubeswitch as Model Context Protocol (MCP) server on stdio",
+		Long: `Serves the contexts of all kubeconfig stores as Model Context Protocol (MCP) server on stdio,
+so that AI assistants can list clusters, get a temporary kubeconfig for a context, list and set namespaces and read the history.
+Credentials are never returned to the assistant.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return mcp.Serve(mcp.Options{
+				Version:        version,
+				StateDirectory: util.ExpandEnv(stateDirectory),
+				NoIndex:        noIndex,
+				Stores:         stores,
+				Config:         config,
+				ReadOnly:       mcpReadOnly,
+				OnSwitch:       runPostSwitchHooks,
+			})
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(mcpCmd)
+	mcpCmd.Flags().BoolVar(
+		&mcpReadOnly,
+		"read-only",
+		false,
+		"only expose tools that neither write temporary kubeconfigs nor change namespaces (list_contexts, list_namespaces and get_history).")
+	rootCommand.AddCommand(mcpCmd)
+}
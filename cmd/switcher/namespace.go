@@ -21,10 +21,11 @@ import (
 var (
 	checkExistence   bool = true
 	namespaceCommand      = &cobra.Command{
-		Use:     "namespace",
+		Use:     "namespace [NAMESPACE|-]",
 		Aliases: []string{"ns"},
 		Short:   "Change the current namespace",
-		Long:    `Search namespaces in the current cluster and change to it.`,
+		Long: `Search namespaces in the current cluster and change to it.
+Use "switch ns -" to change back to the namespace of the current context used before the current namespace.`,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) != 0 {
 				return nil, cobra.ShellCompDirectiveNoFileComp
@@ -33,6 +34,10 @@ var (
 			return list, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && args[0] == "-" {
+				return ns.SwitchToPreviousNamespace(getKubeconfigPathFromFlag(), checkExistence)
+			}
+
 			if len(args) == 1 && len(args[0]) > 0 {
 				return ns.SwitchToNamespace(args[0], getKubeconfigPathFromFlag(), checkExistence)
 			}
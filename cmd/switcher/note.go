@@ -0,0 +1,143 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/notes"
+)
+
+var (
+	noteCmd = &cobra.Command{
+		Use:   "note CONTEXT [NOTE]",
+		Short: "Attach a note to a context or show the note of a context",
+		Long: `Attaches a free-text note to a context, e.g. "owned by team X, deploy window Tuesdays". Without a note, the note of the context is shown.
+Use "." for the current context. Notes are shown in the preview of the picker and are searched in addition to the context names.`,
+		Args: cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeContextArgs(args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contextName, err := resolveContextName(args[0])
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				note, err := notes.GetNote(stateDirectory, contextName)
+				if err != nil {
+					return err
+				}
+				if len(note) == 0 {
+					return fmt.Errorf("no note attached to context %q", contextName)
+				}
+				fmt.Println(note)
+				return nil
+			}
+			return notes.SetNote(stateDirectory, contextName, strings.Join(args[1:], " "))
+		},
+		SilenceErrors: true,
+	}
+
+	noteEditCmd = &cobra.Command{
+		Use:   "edit CONTEXT",
+		Short: "Edit the note of a context in $VISUAL or $EDITOR",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeContextArgs(args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contextName, err := resolveContextName(args[0])
+			if err != nil {
+				return err
+			}
+
+			editor, save, err := notes.EditCommand(stateDirectory, contextName)
+			if err != nil {
+				return err
+			}
+			editor.Stdin, editor.Stdout, editor.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := editor.Run(); err != nil {
+				return fmt.Errorf("editor failed: %v", err)
+			}
+			_, err = save()
+			return err
+		},
+		SilenceErrors: true,
+	}
+
+	noteRmCmd = &cobra.Command{
+		Use:   "rm CONTEXT",
+		Short: "Remove the note of a context",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			contexts, _ := notes.GetNotes(stateDirectory)
+			var comps []string
+			for context := range contexts {
+				comps = append(comps, context)
+			}
+			return comps, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contextName, err := resolveContextName(args[0])
+			if err != nil {
+				return err
+			}
+			return notes.SetNote(stateDirectory, contextName, "")
+		},
+		SilenceErrors: true,
+	}
+
+	noteLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the notes of all contexts",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return notes.ListNotes(stateDirectory)
+		},
+	}
+)
+
+func init() {
+	for _, command := range []*cobra.Command{noteCmd, noteEditCmd, noteRmCmd, noteLsCmd} {
+		command.Flags().StringVar(
+			&stateDirectory,
+			"state-directory",
+			os.ExpandEnv("$HOME/.kube/switch-state"),
+			"path to the state directory.")
+	}
+
+	noteCmd.AddCommand(noteEditCmd)
+	noteCmd.AddCommand(noteRmCmd)
+	noteCmd.AddCommand(noteLsCmd)
+	rootCommand.AddCommand(noteCmd)
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	oidctoken "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/oidc-token"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	oidcOptions   oidctoken.Options
+	oidcGrantType string
+
+	oidcTokenCmd = &cobra.Command{
+		Use:   oidctoken.Command,
+		Short: "Exec credential plugin for the built-in OIDC login",
+		Long: `Implements the client-go credential plugin protocol for kubeconfigs using the built-in OIDC login (oidc in the kubeconfig store configuration).
+Called by kubectl, not intended to be used directly.
+
+Returns the ID token of the OIDC provider. The tokens are cached in the state directory and refreshed once the ID token expired.
+If there is no valid refresh token, runs the browser or device code flow to log in.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(oidcOptions.IssuerURL) == 0 || len(oidcOptions.ClientID) == 0 {
+				return fmt.Errorf("the flags --issuer-url and --client-id are required")
+			}
+
+			oidcOptions.GrantType = types.OIDCGrantType(oidcGrantType)
+			switch oidcOptions.GrantType {
+			case types.OIDCGrantTypeBrowser, types.OIDCGrantTypeDeviceCode:
+			default:
+				return fmt.Errorf("unsupported grant type %q: must be %q or %q", oidcGrantType, types.OIDCGrantTypeBrowser, types.OIDCGrantTypeDeviceCode)
+			}
+
+			execCredential, err := oidctoken.GetExecCredential(oidcOptions, stateDirectory)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(os.Stdout, string(execCredential))
+			return err
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	oidcTokenCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the local directory used for storing internal state.")
+	oidcTokenCmd.Flags().StringVar(
+		&oidcOptions.IssuerURL,
+		"issuer-url",
+		"",
+		"URL of the OIDC provider.")
+	oidcTokenCmd.Flags().StringVar(
+		&oidcOptions.ClientID,
+		"client-id",
+		"",
+		"ID of the OIDC client.")
+	oidcTokenCmd.Flags().StringVar(
+		&oidcOptions.ClientSecret,
+		"client-secret",
+		"",
+		"secret of the OIDC client.")
+	oidcTokenCmd.Flags().StringArrayVar(
+		&oidcOptions.ExtraScopes,
+		"extra-scope",
+		nil,
+		"scope to request in addition to openid and offline_access. Can be repeated.")
+	oidcTokenCmd.Flags().StringVar(
+		&oidcGrantType,
+		"grant-type",
+		string(types.OIDCGrantTypeBrowser),
+		"flow used to log in. Either browser or device-code.")
+	oidcTokenCmd.Flags().StringVar(
+		&oidcOptions.ListenAddress,
+		"listen-address",
+		oidctoken.DefaultListenAddress,
+		"address of the local server receiving the redirect of the browser.")
+
+	rootCommand.AddCommand(oidcTokenCmd)
+}
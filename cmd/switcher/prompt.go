@@ -0,0 +1,104 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/prompt"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promptFormat  string
+	promptNoColor bool
+	promptShell   string
+
+	promptCmd = &cobra.Command{
+		Use:   "prompt",
+		Short: "Print the current context and namespace for shell prompts",
+		Long: `Print the current context and namespace for shell prompts, e.g. in a custom module of starship or a segment of powerlevel10k.
+Only reads the kubeconfig of the current terminal session and never searches the kubeconfig stores.
+
+The format is a Go template with the fields .Context, .Namespace, .StoreKind, .StoreID, .Environment and .CredentialsExpiry
+(e.g. "credentials expire in 25m", only set if the credentials expire within an hour).
+Use {{envColor .Context}} to color text by the environment of the context and {{color "cyan" .Namespace}} for a fixed color
+(a color name, an ANSI 256 color code or a hex color).
+
+Example starship module:
+
+  [custom.kubeswitch]
+  command = "switcher prompt"
+  when = "test -n \"$KUBECONFIG\""`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch promptShell {
+			case "", "zsh", "bash", "tmux":
+			default:
+				return fmt.Errorf("unknown shell %q. Valid shells are \"zsh\", \"bash\" and \"tmux\"", promptShell)
+			}
+
+			// the environments are optional, do not break the prompt if the SwitchConfig cannot be read
+			var environments []types.Environment
+			if config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath)); err == nil && config != nil {
+				environments = config.Environments
+			}
+
+			output, err := prompt.Prompt(environments, prompt.Options{
+				Format:  promptFormat,
+				NoColor: promptNoColor,
+				Shell:   promptShell,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Print(output)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	promptCmd.Flags().StringVar(
+		&promptFormat,
+		"format",
+		prompt.DefaultFormat,
+		"Go template of the output.")
+	promptCmd.Flags().BoolVar(
+		&promptNoColor,
+		"no-color",
+		false,
+		"do not print ANSI color codes.")
+	promptCmd.Flags().StringVar(
+		&promptShell,
+		"shell",
+		"",
+		"mark the color codes as zero-width for the prompt of the shell, either \"zsh\" or \"bash\". Not needed for starship and powerlevel10k. With \"tmux\", the colors are rendered as tmux styles.")
+	promptCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
+	rootCommand.AddCommand(promptCmd)
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/proxy"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+)
+
+var (
+	proxyCmd = &cobra.Command{
+		Use:   "proxy",
+		Short: "Manage the tunnels providing the proxies of the contexts",
+		Long: `Proxies configured for kubeconfig stores ("proxy") or context patterns ("proxies") in the SwitchConfig are set as proxy-url of the clusters in the temporary kubeconfig.
+Proxies with an SSH jump host or a Cloudflare Access hostname start an SSH tunnel or a cloudflared TCP tunnel providing the SOCKS5 proxy
+when switching to a context using the proxy.
+The tunnels keep running in the background until they are stopped with "switch proxy stop".`,
+	}
+
+	proxyLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the tunnels started by kubeswitch",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tunnels, err := proxy.ListTunnels(util.ExpandEnv(stateDirectory))
+			if err != nil {
+				return err
+			}
+			if len(tunnels) == 0 {
+				fmt.Println("No tunnels started")
+				return nil
+			}
+			for _, tunnel := range tunnels {
+				status := "stopped"
+				if proxy.Running(tunnel.URL) {
+					status = fmt.Sprintf("running for %s", time.Since(tunnel.StartedAt).Round(time.Second))
+				}
+				fmt.Printf("%s via %s (PID %d): %s\n", tunnel.URL, tunnel.Host, tunnel.PID, status)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	proxyStopCmd = &cobra.Command{
+		Use:     "stop [URL...]",
+		Short:   "Stop the tunnels started by kubeswitch",
+		Long:    `Stops the tunnels providing the given proxy URLs. Stops all tunnels started by kubeswitch if no URL is given.`,
+		Example: "switch proxy stop\nswitch proxy stop socks5://localhost:1080",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			tunnels, _ := proxy.ListTunnels(util.ExpandEnv(stateDirectory))
+			var urls []string
+			for _, tunnel := range tunnels {
+				urls = append(urls, tunnel.URL)
+			}
+			return urls, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stateDir := util.ExpandEnv(stateDirectory)
+			tunnels, err := proxy.ListTunnels(stateDir)
+			if err != nil {
+				return err
+			}
+
+			stopped := 0
+			for _, tunnel := range tunnels {
+				if len(args) > 0 && !slices.Contains(args, tunnel.URL) {
+					continue
+				}
+				if dryrun.Enabled() {
+					dryrun.Printf("stop the tunnel to %q providing the proxy %s (PID %d)", tunnel.Host, tunnel.URL, tunnel.PID)
+					continue
+				}
+				if err := proxy.StopTunnel(stateDir, tunnel); err != nil {
+					return err
+				}
+				fmt.Printf("Stopped the tunnel to %q providing the proxy %s\n", tunnel.Host, tunnel.URL)
+				stopped++
+			}
+
+			if len(args) > 0 && stopped == 0 && !dryrun.Enabled() {
+				return fmt.Errorf("no tunnel provides the proxy %q", args)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	for _, command := range []*cobra.Command{proxyLsCmd, proxyStopCmd} {
+		command.Flags().StringVar(
+			&stateDirectory,
+			"state-directory",
+			os.ExpandEnv("$HOME/.kube/switch-state"),
+			"path to the state directory.")
+	}
+
+	proxyCmd.AddCommand(proxyLsCmd)
+	proxyCmd.AddCommand(proxyStopCmd)
+	rootCommand.AddCommand(proxyCmd)
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/refresh"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var (
+	refreshWatch bool
+
+	refreshCmd = &cobra.Command{
+		Use:   "refresh [store...]",
+		Short: "Refresh the search index of the kubeconfig stores",
+		Long: `Searches the kubeconfig stores without reading from the search index, replaces the index and reports the added and removed contexts.
+Removed contexts, e.g. of deleted clusters or removed kubeconfig files, are also removed from the cache of the kubeconfig store and their aliases are removed.
+Stores are given by kind or ID. Per default, the index of all kubeconfig stores is refreshed.
+With --watch, keeps running and refreshes the index of each kubeconfig store on its own schedule, so that interactive searches always read a current index.
+The index of a kubeconfig store is then refreshed after three quarters of its "refreshIndexAfter". Kubeconfig stores without "refreshIndexAfter" do not use an index and are skipped.`,
+		Example: "switch refresh\nswitch refresh eks.prod\nswitch refresh --watch",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				storeSelectors = append(storeSelectors, args...)
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			stateDir := util.ExpandEnv(stateDirectory)
+
+			if refreshWatch {
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+
+				return watchIndex(ctx, stores, config, stateDir)
+			}
+
+			return refreshIndex(stores, config, stateDir)
+		},
+		SilenceUsage: true,
+	}
+)
+
+// watchIndex refreshes the index of each kubeconfig store on its own schedule until the context is cancelled.
+// Changes of the SwitchConfig are applied by restarting the refresh with the reloaded kubeconfig stores.
+func watchIndex(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string) error {
+	log := logging.New().WithField("component", "refresh")
+
+	type reload struct {
+		stores []storetypes.KubeconfigStore
+		config *types.Config
+	}
+	reloads := make(chan reload)
+	go watchConfig(ctx, log, func(stores []storetypes.KubeconfigStore, config *types.Config) {
+		select {
+		case reloads <- reload{stores, config}:
+		case <-ctx.Done():
+		}
+	})
+
+	reloaded := false
+	for {
+		watchCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func(stores []storetypes.KubeconfigStore, config *types.Config) {
+			done <- refresh.Watch(watchCtx, stores, config, stateDir, func(result refresh.Result) {
+				if result.Err != nil {
+					log.Warnf("failed to refresh the index of store %s: %v", result.Store.GetID(), result.Err)
+					return
+				}
+				log.Infof("Refreshed the index of store %s: %d contexts (%d added, %d removed) in %s", result.Store.GetID(), result.Contexts, len(result.Added), len(result.Removed), result.Duration.Round(time.Millisecond))
+				if len(result.PrunedAliases) > 0 {
+					log.Infof("Removed the aliases %s of removed contexts of store %s", strings.Join(result.PrunedAliases, ", "), result.Store.GetID())
+				}
+				if len(result.Evicted) > 0 {
+					log.Infof("Removed %d kubeconfig(s) of removed contexts from the cache of store %s", len(result.Evicted), result.Store.GetID())
+				}
+			})
+		}(stores, config)
+
+		select {
+		case err := <-done:
+			cancel()
+			if err == nil || !reloaded {
+				return err
+			}
+			// wait for the next change of the SwitchConfig instead of exiting, e.g. if no store uses an index anymore
+			log.Warnf("not refreshing the search index in the background: %v", err)
+			select {
+			case r := <-reloads:
+				stores, config = r.stores, r.config
+			case <-ctx.Done():
+				return nil
+			}
+		case r := <-reloads:
+			cancel()
+			<-done
+			stores, config = r.stores, r.config
+		}
+		reloaded = true
+	}
+}
+
+// refreshIndex refreshes the index of the kubeconfig stores one after another and prints the added and removed contexts
+func refreshIndex(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string) error {
+	var (
+		refreshed, contexts, added, removed int
+		failed                              []string
+	)
+
+	for _, store := range stores {
+		if !pkg.Indexed(store) {
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Refreshing the index of store %s...", store.GetID())
+		result := refresh.Store(store, config, stateDir)
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", result.Err)
+			failed = append(failed, store.GetID())
+			continue
+		}
+		fmt.Fprintf(os.Stderr, " %d contexts in %s\n", result.Contexts, result.Duration.Round(time.Millisecond))
+
+		for _, name := range result.Added {
+			fmt.Printf("+ %s (%s)\n", name, store.GetID())
+		}
+		for _, name := range result.Removed {
+			fmt.Printf("- %s (%s)\n", name, store.GetID())
+		}
+		for _, alias := range result.PrunedAliases {
+			fmt.Printf("- alias %s (%s)\n", alias, store.GetID())
+		}
+		for _, path := range result.Evicted {
+			fmt.Printf("- cached kubeconfig %s (%s)\n", path, store.GetID())
+		}
+
+		refreshed++
+		contexts += result.Contexts
+		added += len(result.Added)
+		removed += len(result.Removed)
+	}
+
+	fmt.Printf("Refreshed the index of %d kubeconfig store(s): %d contexts, %d added, %d removed\n", refreshed, contexts, added, removed)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to refresh the index of the kubeconfig store(s) %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// revalidateIndex starts a background process refreshing the index of the kubeconfig store
+func revalidateIndex(store storetypes.KubeconfigStore) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"refresh", store.GetID(), "--config-path", util.ExpandEnv(configPath), "--state-directory", util.ExpandEnv(stateDirectory)}
+	if len(profile) > 0 {
+		args = append(args, "--profile", profile)
+	}
+
+	// the process must not inherit stdout, as the shell integration reads stdout until it is closed
+	cmd := exec.Command(executable, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start background process to refresh the index: %v", err)
+	}
+	return cmd.Process.Release()
+}
+
+func init() {
+	setFlagsForContextCommands(refreshCmd)
+	refreshCmd.Flags().BoolVar(
+		&refreshWatch,
+		"watch",
+		false,
+		"keep running and refresh the index of each kubeconfig store on its own schedule.")
+	rootCommand.AddCommand(refreshCmd)
+}
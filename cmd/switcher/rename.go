@@ -0,0 +1,57 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias"
+)
+
+var (
+	renameContextCmd = &cobra.Command{
+		Use:   "rename-context OLD_NAME NEW_NAME",
+		Short: "Rename a context without changing its kubeconfig store",
+		Long: `Renames a context in the search results, the history and the kubeconfigs written when switching, without changing the kubeconfig store.
+The new name is recorded as an alias of the context (see "switch alias ls"). Renaming an alias renames the alias and keeps its namespace.
+The history entries of the old name are rewritten to the new name.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			lc, _ := listContexts(toComplete)
+			return lc, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldName, err := resolveContextName(args[0])
+			if err != nil {
+				return err
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return alias.Rename(oldName, args[1], stores, config, stateDirectory, noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(renameContextCmd)
+	rootCommand.AddCommand(renameContextCmd)
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/revert"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revertKubeconfigPath         string
+	revertPreviousKubeconfigPath string
+	revertAt                     string
+
+	revertKubeconfigCmd = &cobra.Command{
+		Use:    revert.Command,
+		Short:  "Reverts a temporary kubeconfig file to the previous kubeconfig",
+		Long:   `Waits until the given time and replaces the content of the temporary kubeconfig file with the previous kubeconfig. Started by "switch --for", not intended to be used directly.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return revert.Revert(revertKubeconfigPath, revertPreviousKubeconfigPath, revertAt)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	revertKubeconfigCmd.Flags().StringVar(
+		&revertKubeconfigPath,
+		"kubeconfig-path",
+		"",
+		"path to the temporary kubeconfig file.")
+	revertKubeconfigCmd.Flags().StringVar(
+		&revertPreviousKubeconfigPath,
+		"previous-kubeconfig-path",
+		"",
+		"path to the copy of the previous kubeconfig file.")
+	revertKubeconfigCmd.Flags().StringVar(
+		&revertAt,
+		"revert-at",
+		"",
+		"the time (RFC 3339) at which the kubeconfig is reverted.")
+
+	rootCommand.AddCommand(revertKubeconfigCmd)
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/hooks"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/run"
+	set_context "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// scopedSession is set if the new context is only used in a subshell and must not leave its scope
+	scopedSession bool
+
+	runCmd = &cobra.Command{
+		Use:   "run [CONTEXT]",
+		Short: "Start a subshell scoped to the context",
+		Long: `Start an interactive subshell with KUBECONFIG pointing to a temporary kubeconfig of the context and the context name in front of the prompt.
+Exiting the subshell ends the scope of the context and removes the temporary kubeconfig. The shell the command was started from keeps its context.
+Without argument, the context is selected interactively.`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeContextArgs(args, toComplete)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if switchFor > 0 || switchGlobal {
+				return fmt.Errorf("--for and --global cannot be used with %q, as the context ends with the subshell", cmd.CommandPath())
+			}
+			log := logging.New().WithField("hook", "")
+			return hooks.Hooks(log, configPath, stateDirectory, "", false, false)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			var kubeconfigPath, contextName *string
+			switch {
+			case len(args) == 0:
+				// config file setting overwrites the command line default (--showPreview true)
+				if showPreview && config.ShowPreview != nil && !*config.ShowPreview {
+					showPreview = false
+				}
+				kubeconfigPath, contextName, err = pkg.Switcher(stores, config, stateDirectory, noIndex, showPreview, true)
+			case regex:
+				kubeconfigPath, contextName, err = set_context.SetContextRegex(args[0], stores, config, stateDirectory, noIndex, true)
+			default:
+				kubeconfigPath, contextName, err = set_context.SetContextExact(args[0], stores, config, stateDirectory, noIndex, true)
+			}
+			if err != nil {
+				return err
+			}
+			if kubeconfigPath == nil || contextName == nil {
+				return nil
+			}
+
+			scopedSession = true
+			if err := prepareNewContext(*kubeconfigPath, *contextName); err != nil {
+				return err
+			}
+			runPostSwitchHooks(*kubeconfigPath)
+
+			exitCode, err := run.Shell(*kubeconfigPath, *contextName)
+			removeTemporaryKubeconfig(*kubeconfigPath)
+			if err != nil {
+				return err
+			}
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(runCmd)
+	setSwitchFlags(runCmd)
+	setFilterFlags(runCmd)
+	runCmd.Flags().BoolVar(
+		&regex,
+		"regex",
+		false,
+		"interpret the given context name as regular expression that has to match exactly one context.")
+
+	rootCommand.AddCommand(runCmd)
+}
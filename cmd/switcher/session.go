@@ -0,0 +1,181 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/session"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/tmux"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sessionSlot string
+	sessionTmux bool
+
+	sessionCmd = &cobra.Command{
+		Use:   "session",
+		Short: "Save and restore the contexts of a set of terminals",
+		Long: `Named sessions remember the context and namespace of several terminals ("slots"), e.g. for incident response or release days.
+Run "switch session save NAME" in each terminal to add it as a slot, and "switch session restore NAME" to switch to the contexts of all slots again later.`,
+	}
+
+	sessionSaveCmd = &cobra.Command{
+		Use:   "save NAME",
+		Short: "Save the context and namespace of the current terminal as a slot of the session",
+		Long: `Saves the context and namespace of the current terminal as a slot of the named session. The session is created if it does not exist.
+The slot is named after the context unless --slot is given. An existing slot with the same name is replaced.`,
+		Example: `  switch session save oncall --slot logs`,
+		Args:    cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeSessionNames(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			slot, err := session.CurrentSlot(sessionSlot)
+			if err != nil {
+				return err
+			}
+
+			if err := permissions.MkdirAll(stateDirectory); err != nil {
+				return err
+			}
+			if err := session.Save(stateDirectory, args[0], *slot); err != nil {
+				return err
+			}
+			fmt.Printf("Saved slot %q (%s) of session %q\n", slot.Name, slot.Context, args[0])
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	sessionRestoreCmd = &cobra.Command{
+		Use:   "restore NAME [SLOT]",
+		Short: "Switch to the contexts of the slots of the session",
+		Long: `Creates a temporary kubeconfig for each slot of the named session.
+If the session has a single slot or a slot is given, the current terminal is switched to it.
+Otherwise, the commands to switch other terminals are printed. With --tmux, a new tmux window is opened for each slot instead.`,
+		Example: `  switch session restore oncall
+  switch session restore oncall logs
+  switch session restore oncall --tmux`,
+		Args: cobra.RangeArgs(1, 2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeSessionNames(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var slotName string
+			if len(args) == 2 {
+				slotName = args[1]
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			restored, err := session.Restore(args[0], slotName, stores, config, stateDirectory, noIndex)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case sessionTmux:
+				for _, slot := range restored {
+					if err := tmux.NewWindow(slot.Name, slot.KubeconfigPath); err != nil {
+						return fmt.Errorf("failed to open a tmux window for slot %q: %w", slot.Name, err)
+					}
+				}
+				return nil
+			case len(restored) == 1:
+				return reportNewContext(&restored[0].KubeconfigPath, &restored[0].Context)
+			default:
+				for _, slot := range restored {
+					fmt.Printf("export KUBECONFIG=%s # %s: %s\n", slot.KubeconfigPath, slot.Name, slot.Context)
+				}
+				return nil
+			}
+		},
+		SilenceUsage: true,
+	}
+
+	sessionLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List all saved sessions",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return session.ListSessions(stateDirectory)
+		},
+	}
+
+	sessionRmCmd = &cobra.Command{
+		Use:   "rm NAME [SLOT]",
+		Short: "Remove a saved session or a slot of it",
+		Args:  cobra.RangeArgs(1, 2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeSessionNames(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var slotName string
+			if len(args) == 2 {
+				slotName = args[1]
+			}
+			return session.Delete(stateDirectory, args[0], slotName)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	sessionSaveCmd.Flags().StringVar(
+		&sessionSlot,
+		"slot",
+		"",
+		"the name of the slot. Defaults to the name of the context.")
+	sessionRestoreCmd.Flags().BoolVar(
+		&sessionTmux,
+		"tmux",
+		false,
+		"open a new tmux window for each slot.")
+	setFlagsForContextCommands(sessionRestoreCmd)
+	setSwitchFlags(sessionRestoreCmd)
+
+	for _, command := range []*cobra.Command{sessionSaveCmd, sessionLsCmd, sessionRmCmd} {
+		command.Flags().StringVar(
+			&stateDirectory,
+			"state-directory",
+			os.ExpandEnv("$HOME/.kube/switch-state"),
+			"path to the state directory.")
+	}
+
+	sessionCmd.AddCommand(sessionSaveCmd)
+	sessionCmd.AddCommand(sessionRestoreCmd)
+	sessionCmd.AddCommand(sessionLsCmd)
+	sessionCmd.AddCommand(sessionRmCmd)
+	rootCommand.AddCommand(sessionCmd)
+}
+
+// completeSessionNames completes the name of a session as first argument
+func completeSessionNames(args []string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, _ := session.GetSessionNames(stateDirectory)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+
+	show_path "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/show-path"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showPathWithContext bool
+	showPathOutput      string
+
+	showPathCmd = &cobra.Command{
+		Use:   "show-path",
+		Short: "Print the path of the kubeconfig of the current terminal session",
+		Long: `Prints the path of the kubeconfig of the current terminal session (the KUBECONFIG environment variable), e.g. to use the kubeconfig set up by kubeswitch in other tools.
+With --with-context, the current context and namespace are printed separated by tabs. With --output json, all details are printed as JSON.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch showPathOutput {
+			case "plain":
+				return show_path.ShowPath(showPathWithContext, false)
+			case "json":
+				return show_path.ShowPath(showPathWithContext, true)
+			default:
+				return fmt.Errorf("unknown output format %q. Valid formats are \"plain\" and \"json\"", showPathOutput)
+			}
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	showPathCmd.Flags().BoolVar(
+		&showPathWithContext,
+		"with-context",
+		false,
+		"also print the current context and its namespace.")
+	showPathCmd.Flags().StringVarP(
+		&showPathOutput,
+		"output",
+		"o",
+		"plain",
+		"the output format. Either \"plain\" or \"json\".")
+
+	rootCommand.AddCommand(showPathCmd)
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/stats"
+)
+
+var (
+	statsStores []string
+	statsOutput string
+	statsReset  bool
+
+	statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Show performance metrics of the kubeconfig stores",
+		Long: `Shows the performance metrics of each kubeconfig store over its recent searches, slowest store first.
+Every search records the duration, the number of contexts found, if the contexts were read from the search index, the hits of the kubeconfig cache and the number of errors per kubeconfig store.
+The last 50 searches of each kubeconfig store are kept in the file "switch.stats.db" in the state directory.`,
+		Example: `  switch stats --store eks.prod`,
+		Args:    cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if statsReset {
+				return stats.Reset(stateDirectory)
+			}
+
+			if statsOutput != "table" && statsOutput != "json" {
+				return fmt.Errorf("unknown output format %q. Valid formats are \"table\" and \"json\"", statsOutput)
+			}
+
+			runs, err := stats.Load(stateDirectory)
+			if err != nil {
+				return err
+			}
+
+			if len(statsStores) > 0 {
+				selected := make(map[string][]stats.Run, len(statsStores))
+				for _, storeID := range statsStores {
+					if storeRuns, ok := runs[storeID]; ok {
+						selected[storeID] = storeRuns
+					}
+				}
+				runs = selected
+			}
+
+			return stats.Print(os.Stdout, stats.Summarize(runs), statsOutput == "json")
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	statsCmd.Flags().StringSliceVar(
+		&statsStores,
+		"store",
+		nil,
+		"only show the kubeconfig stores with the given IDs, e.g. \"eks.prod\". Can be repeated.")
+	statsCmd.Flags().StringVarP(
+		&statsOutput,
+		"output",
+		"o",
+		"table",
+		"the output format. Either \"table\" or \"json\".")
+	statsCmd.Flags().BoolVar(
+		&statsReset,
+		"reset",
+		false,
+		"remove the recorded performance metrics of all kubeconfig stores.")
+	statsCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the local directory used for storing internal state.")
+
+	rootCommand.AddCommand(statsCmd)
+}
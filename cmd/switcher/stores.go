@@ -0,0 +1,130 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/stores"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+)
+
+var (
+	storesOutput      string
+	storesTestTimeout time.Duration
+
+	storesCmd = &cobra.Command{
+		Use:   "stores",
+		Short: "List and test the kubeconfig stores",
+		Long:  `Lists the configured kubeconfig stores with the state of their search index, or tests a kubeconfig store that misbehaves.`,
+	}
+
+	storesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the kubeconfig stores with the state of their search index",
+		Long: `Lists the kind, the ID and the kubeconfig cache of each kubeconfig store, the number of contexts in its search index and when the index was refreshed the last time.
+Kubeconfig stores that are skipped after repeatedly failed searches are shown as degraded. The APIs of the kubeconfig stores are not called.`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if storesOutput != "table" && storesOutput != "json" {
+				return fmt.Errorf("unknown output format %q. Valid formats are \"table\" and \"json\"", storesOutput)
+			}
+
+			kubeconfigStores, _, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			list, err := stores.List(kubeconfigStores, util.ExpandEnv(stateDirectory))
+			if err != nil {
+				return err
+			}
+			return stores.PrintList(os.Stdout, list, storesOutput == "json")
+		},
+		SilenceUsage: true,
+	}
+
+	storesTestCmd = &cobra.Command{
+		Use:   "test <store...>",
+		Short: "Test the authentication and the API of kubeconfig stores",
+		Long: `Checks the credentials and the configuration of the kubeconfig store, then searches it until the first context is found, reporting the latency of each step.
+The search index and the kubeconfig cache are bypassed. The credentials are only checked: run "switch login" to authenticate.
+Stores are given by kind or ID. Fails if a step failed for any of the kubeconfig stores.`,
+		Example: "switch stores test eks.prod\nswitch stores test gke --timeout 10s",
+		Args:    cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if storesOutput != "table" && storesOutput != "json" {
+				return fmt.Errorf("unknown output format %q. Valid formats are \"table\" and \"json\"", storesOutput)
+			}
+			storeSelectors = append(storeSelectors, args...)
+
+			kubeconfigStores, _, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			var (
+				results []stores.Result
+				failed  int
+			)
+			for _, store := range kubeconfigStores {
+				result := stores.Test(store, storesTestTimeout)
+				if result.Failed() {
+					failed++
+				}
+				results = append(results, result)
+			}
+
+			if err := stores.PrintTest(os.Stdout, results, storesOutput == "json"); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("the test failed for %d kubeconfig store(s)", failed)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	for _, command := range []*cobra.Command{storesListCmd, storesTestCmd} {
+		setFlagsForContextCommands(command)
+		command.Flags().StringVarP(
+			&storesOutput,
+			"output",
+			"o",
+			"table",
+			"the output format. Either \"table\" or \"json\".")
+		storesCmd.AddCommand(command)
+	}
+	storesTestCmd.Flags().DurationVar(
+		&storesTestTimeout,
+		"timeout",
+		30*time.Second,
+		"the maximum duration of each step of the test.")
+
+	rootCommand.AddCommand(storesCmd)
+}
@@ -15,9 +15,14 @@
 package switcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bombsimon/logrusr/v4"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,8 +37,25 @@ import (
 
 	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
 	"github.com/danielfoehrkn/kubeswitch/pkg/config/validation"
+	"github.com/danielfoehrkn/kubeswitch/pkg/credentials"
+	"github.com/danielfoehrkn/kubeswitch/pkg/encryption"
 	"github.com/danielfoehrkn/kubeswitch/pkg/store"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/clean"
+	execcredential "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/exec-credential"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history"
+	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/symlink"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tracing"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/database"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/fixtures"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -42,6 +64,13 @@ const (
 	defaultKubeconfigName       = "config"
 	defaultKubeconfigPath       = "$HOME/.kube/config"
 	linuxEnvKubeconfigSeperator = ":"
+
+	// exitCodeError is the exit code for all errors without a dedicated exit code
+	exitCodeError = 1
+	// exitCodeContextNotFound is the exit code if the desired context does not exist (non-interactive mode only)
+	exitCodeContextNotFound = 2
+	// exitCodeContextAmbiguous is the exit code if the desired context matches multiple contexts (non-interactive mode only)
+	exitCodeContextAmbiguous = 3
 )
 
 var (
@@ -52,6 +81,40 @@ var (
 	deleteContext  bool
 	unsetContext   bool
 	currentContext bool
+	nonInteractive bool
+	// regex interprets the context name or the pattern as regular expression
+	regex          bool
+	yesIMeanProd   bool
+	switchFor      time.Duration
+	switchGlobal   bool
+	clipboard      string
+	profile        string
+	storeSelectors []string
+
+	// writeKubeconfigPath writes the kubeconfig of the new context to the given path instead of switching the terminal
+	writeKubeconfigPath string
+
+	// recordPath and replayPath are the fixture files the requests of the stores are recorded to or replayed from
+	recordPath string
+	replayPath string
+
+	// delete-context command
+	deleteFromFile bool
+
+	// list-contexts command
+	listContextsOutput string
+
+	// Kubernetes version and tag filter
+	minVersion   string
+	maxVersion   string
+	tagSelectors []string
+
+	// offline mode
+	offlineMode bool
+
+	// impersonation
+	impersonateUser   string
+	impersonateGroups []string
 
 	// vault store
 	storageBackend          string
@@ -63,12 +126,19 @@ var (
 	hookName       string
 	runImmediately bool
 
+	// alias command
+	aliasNamespace string
+
 	// version command
 	version   string
 	buildDate string
 
 	showDebugLogs bool
+	logLevel      string
+	logFormat     string
 	noIndex       bool
+	// dryRun only prints the changes of state-changing commands
+	dryRun bool
 
 	rootCommand = &cobra.Command{
 		Use:     "switcher",
@@ -85,10 +155,21 @@ var (
 				if err := cobra.NoArgs(cmd, args); err != nil {
 					return err
 				}
+			case nonInteractive || regex:
+				if err := cobra.RangeArgs(1, 2)(cmd, args); err != nil {
+					return fmt.Errorf("a context name is required in non-interactive mode: %v", err)
+				}
 			}
 			return cmd.ParseFlags(args)
 		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeContextArgs(args, toComplete)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun && (unsetContext || (len(args) > 0 && (args[0] == "-" || args[0] == "." || isRecentContext(args[0])))) {
+				return fmt.Errorf("--dry-run is only supported when switching to a context name")
+			}
+
 			switch {
 			case deleteContext:
 				return deleteContextCmd.RunE(cmd, args)
@@ -104,9 +185,11 @@ var (
 					return previousContextCmd.RunE(cmd, args[1:])
 				case ".":
 					return lastContextCmd.RunE(cmd, args[1:])
-				default:
-					return setContextCmd.RunE(cmd, args)
 				}
+				if n, ok := recentContextPosition(args[0]); ok {
+					return switchToRecentContext(n)
+				}
+				return setContextCmd.RunE(cmd, args)
 			}
 
 			stores, config, err := initialize()
@@ -119,9 +202,11 @@ var (
 				showPreview = false
 			}
 
-			kubeconfigPath, contextName, err := pkg.Switcher(stores, config, stateDirectory, noIndex, showPreview)
-			reportNewContext(kubeconfigPath, contextName)
-			return err
+			kubeconfigPath, contextName, err := pkg.Switcher(stores, config, stateDirectory, noIndex, showPreview, len(writeKubeconfigPath) == 0)
+			if err != nil {
+				return err
+			}
+			return reportNewContext(kubeconfigPath, contextName)
 		},
 		SilenceUsage: true,
 	}
@@ -129,21 +214,108 @@ var (
 
 func init() {
 	setFlagsForContextCommands(rootCommand)
+	setNonInteractiveFlags(rootCommand)
+	setSwitchFlags(rootCommand)
+	setWriteKubeconfigFlags(rootCommand)
+	setFilterFlags(rootCommand)
 	rootCommand.Flags().BoolVarP(&deleteContext, "d", "d", false, "delete desired context. Context name is required")
 	rootCommand.Flags().BoolVarP(&unsetContext, "unset", "u", false, "unset current context")
 	rootCommand.Flags().BoolVarP(&currentContext, "current", "c", false, "show current context")
+
+	rootCommand.PersistentFlags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"only print the files that would be written, the environment variables that would be set and the hooks that would be executed.")
+	rootCommand.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if dryRun && !supportsDryRun(cmd) {
+			return fmt.Errorf("%q does not support --dry-run", cmd.CommandPath())
+		}
+		dryrun.Enable(dryRun)
+		return nil
+	}
+}
+
+// supportsDryRun returns true if the command only prints the changes it would make with --dry-run
+func supportsDryRun(cmd *cobra.Command) bool {
+	return slices.Contains([]*cobra.Command{
+		rootCommand,
+		setContextCmd,
+		deleteContextCmd,
+		cleanCmd,
+		aliasContextCmd,
+		aliasRmCmd,
+		aliasImportCmd,
+		aliasRewriteCmd,
+		renameContextCmd,
+		hookCmd,
+		hookRunCmd,
+		syncCmd,
+		proxyStopCmd,
+		boundaryStopCmd,
+		globalRollbackCmd,
+		indexPublishCmd,
+		indexPullCmd,
+	}, cmd)
 }
 
 func NewCommandStartSwitcher() *cobra.Command {
+	configureKubectlPlugin(rootCommand)
 	return rootCommand
 }
 
+// StartTracing starts the trace of the command if an OTLP endpoint is configured.
+// The returned function ends the trace once the command has been executed.
+func StartTracing() func(command string, err error) {
+	return tracing.Init(version)
+}
+
+// ExitCode returns the process exit code for the error returned by the root command
+// Scripts can rely on these to distinguish a missing from an ambiguous context.
+// The dedicated exit codes only apply to non-interactive mode.
+func ExitCode(err error) int {
+	switch {
+	case !nonInteractive && !regex:
+		return exitCodeError
+	case errors.Is(err, setcontext.ErrContextNotFound):
+		return exitCodeContextNotFound
+	case errors.Is(err, setcontext.ErrContextAmbiguous):
+		return exitCodeContextAmbiguous
+	default:
+		return exitCodeError
+	}
+}
+
+// recentContextPosition returns the position of the history shortcut "~N" switching to the N-th most recently used context
+func recentContextPosition(arg string) (int, bool) {
+	if !strings.HasPrefix(arg, "~") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(arg, "~"))
+	return n, err == nil && n >= 0
+}
+
+func isRecentContext(arg string) bool {
+	_, ok := recentContextPosition(arg)
+	return ok
+}
+
+// switchToRecentContext switches to the n-th most recently used context from the history without showing the picker
+func switchToRecentContext(n int) error {
+	stores, config, err := initialize()
+	if err != nil {
+		return err
+	}
+
+	kubeconfigPath, contextName, err := history.SetRecentContext(n, stores, config, stateDirectory, noIndex)
+	if err != nil {
+		return err
+	}
+	return reportNewContext(kubeconfigPath, contextName)
+}
+
 func setCommonFlags(command *cobra.Command) {
-	command.Flags().BoolVar(
-		&showDebugLogs,
-		"debug",
-		false,
-		"show debug logs")
+	setLogFlags(command)
 	command.Flags().BoolVar(
 		&noIndex,
 		"no-index",
@@ -159,14 +331,121 @@ func setCommonFlags(command *cobra.Command) {
 		"state-directory",
 		os.ExpandEnv("$HOME/.kube/switch-state"),
 		"path to the local directory used for storing internal state.")
+	command.Flags().StringVar(
+		&recordPath,
+		"record",
+		"",
+		"record the requests of the kubeconfig stores to the APIs of cloud providers to this fixture file. Credentials are removed. Implies --no-index.")
+	command.Flags().StringVar(
+		&replayPath,
+		"replay",
+		"",
+		"replay the responses recorded with --record from this fixture file instead of sending requests to the APIs of cloud providers. Implies --no-index.")
 }
 
-func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
+func setLogFlags(command *cobra.Command) {
+	command.Flags().BoolVar(
+		&showDebugLogs,
+		"debug",
+		false,
+		"show debug logs. Same as --log-level debug")
+	command.Flags().StringVar(
+		&logLevel,
+		"log-level",
+		"",
+		"minimum level of the log messages shown (panic, fatal, error, warn, info, debug or trace). Overrides the log level of the switch configuration file.")
+	command.Flags().StringVar(
+		&logFormat,
+		"log-format",
+		"",
+		"format of the log messages (console or json). Overrides the log format of the switch configuration file.")
+}
+
+// configureFixtures records the requests of the kubeconfig stores to a fixture file or replays the recorded responses.
+// The index is bypassed, as the stores would not send requests otherwise.
+func configureFixtures() error {
+	switch {
+	case len(recordPath) == 0 && len(replayPath) == 0:
+		return nil
+	case len(recordPath) > 0 && len(replayPath) > 0:
+		return fmt.Errorf("--record and --replay cannot be used together")
+	case offline.Enabled():
+		return fmt.Errorf("--record and --replay cannot be used in offline mode, as the kubeconfig stores are not searched")
+	}
+
+	noIndex = true
+	if len(recordPath) > 0 {
+		return fixtures.Record(util.ExpandEnv(recordPath))
+	}
+	return fixtures.Replay(util.ExpandEnv(replayPath))
+}
+
+// configureLogging sets the level and the format of the log messages.
+// The flags take precedence over the switch configuration file.
+func configureLogging(config *types.Config) error {
+	level := logrus.InfoLevel.String()
+	if config != nil && config.LogLevel != nil {
+		level = *config.LogLevel
+	}
 	if showDebugLogs {
-		logrus.SetLevel(logrus.DebugLevel)
+		level = logrus.DebugLevel.String()
+	}
+	if logLevel != "" {
+		level = logLevel
 	}
 
-	config, err := switchconfig.LoadConfigFromFile(util.ExpandEnv(configPath))
+	parsedLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	format := types.LogFormatConsole
+	if config != nil && config.LogFormat != nil {
+		format = *config.LogFormat
+	}
+	if logFormat != "" {
+		format = types.LogFormat(logFormat)
+	}
+	if format != types.LogFormatConsole && format != types.LogFormatJSON {
+		return fmt.Errorf("unknown log format %q: must be %q or %q", format, types.LogFormatConsole, types.LogFormatJSON)
+	}
+
+	logging.Configure(parsedLevel, format)
+	return nil
+}
+
+// setStoreLogLevel applies the log level configured for the kubeconfig store, if any
+func setStoreLogLevel(s storetypes.KubeconfigStore, storeConfig types.KubeconfigStore) {
+	if storeConfig.LogLevel == nil {
+		return
+	}
+	// the level has been validated together with the switch configuration
+	if level, err := logrus.ParseLevel(*storeConfig.LogLevel); err == nil {
+		logging.SetLevel(s.GetLogger().Logger, level)
+	}
+}
+
+// configureIndexEncryption encrypts the search index and the database cache at rest if configured
+func configureIndexEncryption(config *types.Config) error {
+	if config == nil || config.EncryptIndex == nil {
+		return nil
+	}
+
+	key, err := encryption.LoadKey(config.EncryptIndex, stateDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to load the encryption key of the search index: %v", err)
+	}
+	database.EnableEncryption(key)
+	return nil
+}
+
+func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
+	// apply the flags while reading the switch configuration file
+	if err := configureLogging(nil); err != nil {
+		return nil, nil, err
+	}
+
+	config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read switch config file: %v", err)
 	}
@@ -179,6 +458,42 @@ func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 		config = &types.Config{}
 	}
 
+	// added before the profile and the store selectors are applied, so that they can exclude it
+	addDefaultDigitalOceanStore(config)
+
+	if len(profile) == 0 {
+		profile = os.Getenv(switchconfig.EnvProfile)
+	}
+
+	if len(profile) > 0 {
+		if err := switchconfig.ApplyProfile(config, profile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := configureLogging(config); err != nil {
+		return nil, nil, err
+	}
+
+	if err := configureIndexEncryption(config); err != nil {
+		return nil, nil, err
+	}
+
+	httptransport.Configure(config.HTTPTransport)
+	execcredential.SetConfigPath(util.ExpandEnv(configPath))
+
+	// the automatic garbage collection is skipped on systems where the temporary kubeconfig files used by other terminal sessions cannot be detected
+	if config.Clean != nil && config.Clean.Auto != nil && *config.Clean.Auto && clean.CanDetectKubeconfigsInUse() {
+		if _, err := clean.GarbageCollect(*config.Clean, symlink.Targets(config), false); err != nil {
+			logrus.Debugf("failed to clean temporary kubeconfig files: %v", err)
+		}
+	}
+
+	// files written by previous versions may be accessible by other users
+	if !dryRun {
+		permissions.Enforce(util.ExpandEnv(stateDirectory), os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir), os.ExpandEnv(historyutil.HistoryFilePath))
+	}
+
 	if kubeconfigName == defaultKubeconfigName {
 		if config.KubeconfigName != nil && *config.KubeconfigName != "" {
 			kubeconfigName = *config.KubeconfigName
@@ -190,156 +505,64 @@ func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 		config.KubeconfigStores = append(config.KubeconfigStores, *storeFromFlags)
 	}
 
-	if len(config.KubeconfigStores) == 0 {
-		return nil, nil, fmt.Errorf("you need to point kubeswitch to a kubeconfig file. This can be done by setting the environment variable KUBECONFIG, setting the flag --kubeconfig-path, having a default kubeconfig file at ~/.kube/config or providing a switch configuration file")
+	if len(storeSelectors) == 0 {
+		if storesFromEnv := os.Getenv(switchconfig.EnvStores); len(storesFromEnv) > 0 {
+			storeSelectors = strings.Split(storesFromEnv, ",")
+		}
 	}
 
-	var (
-		stores                          []storetypes.KubeconfigStore
-		digitalOceanStoreAddedViaConfig bool
-	)
-	for _, kubeconfigStoreFromConfig := range config.KubeconfigStores {
-		var s storetypes.KubeconfigStore
+	if len(storeSelectors) > 0 {
+		if err := switchconfig.SelectStores(config, storeSelectors); err != nil {
+			return nil, nil, err
+		}
+	}
 
-		if kubeconfigStoreFromConfig.KubeconfigName != nil && *kubeconfigStoreFromConfig.KubeconfigName != "" {
-			kubeconfigName = *kubeconfigStoreFromConfig.KubeconfigName
+	if len(minVersion) > 0 || len(maxVersion) > 0 {
+		versionRange, err := pkg.NewVersionRange(minVersion, maxVersion)
+		if err != nil {
+			return nil, nil, err
 		}
+		pkg.KubernetesVersionRange = versionRange
+	}
 
-		switch kubeconfigStoreFromConfig.Kind {
-		case types.StoreKindFilesystem:
-			filesystemStore, err := store.NewFilesystemStore(kubeconfigName, kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = filesystemStore
+	if len(tagSelectors) > 0 {
+		tagSelector, err := pkg.NewTagSelector(tagSelectors)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkg.ContextTagSelector = tagSelector
+	}
 
-		case types.StoreKindVault:
-			vaultStore, err := store.NewVaultStore(vaultAPIAddressFromFlag,
-				vaultTokenFileName,
-				kubeconfigName,
-				kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = vaultStore
+	offline.Enable(offlineMode || offline.Detect(config.Offline))
+	if err := configureFixtures(); err != nil {
+		return nil, nil, err
+	}
+	if offline.Enabled() && noIndex {
+		return nil, nil, fmt.Errorf("the search index is required in offline mode and cannot be disabled with --no-index")
+	}
 
-		case types.StoreKindGardener:
-			gardenerStore, err := store.NewGardenerStore(kubeconfigStoreFromConfig, stateDirectory)
-			if err != nil {
-				return nil, nil, fmt.Errorf("unable to create Gardener store: %w", err)
-			}
-			s = gardenerStore
+	if len(config.KubeconfigStores) == 0 {
+		return nil, nil, fmt.Errorf("you need to point kubeswitch to a kubeconfig file. This can be done by setting the environment variable KUBECONFIG, setting the flag --kubeconfig-path, having a default kubeconfig file at ~/.kube/config or providing a switch configuration file")
+	}
 
-		case types.StoreKindGKE:
-			gkeStore, err := store.NewGKEStore(kubeconfigStoreFromConfig, stateDirectory)
-			if err != nil {
-				return nil, nil, fmt.Errorf("unable to create GKE store: %w", err)
-			}
-			s = gkeStore
+	var stores []storetypes.KubeconfigStore
+	for _, kubeconfigStoreFromConfig := range config.KubeconfigStores {
+		if kubeconfigStoreFromConfig.KubeconfigName != nil && *kubeconfigStoreFromConfig.KubeconfigName != "" {
+			kubeconfigName = *kubeconfigStoreFromConfig.KubeconfigName
+		}
 
-		case types.StoreKindAzure:
-			azureStore, err := store.NewAzureStore(kubeconfigStoreFromConfig, stateDirectory)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, fmt.Errorf("unable to create Azure store: %w", err)
-			}
-			s = azureStore
-		case types.StoreKindEKS:
-			eksStore, err := store.NewEKSStore(kubeconfigStoreFromConfig, stateDirectory)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = eksStore
-		case types.StoreKindExoscale:
-			exoscaleStore, err := store.NewExoscaleStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = exoscaleStore
-		case types.StoreKindRancher:
-			rancherStore, err := store.NewRancherStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = rancherStore
-		case types.StoreKindOVH:
-			ovhStore, err := store.NewOVHStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = ovhStore
-		case types.StoreKindScaleway:
-			scalewayStore, err := store.NewScalewayStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = scalewayStore
-		case types.StoreKindDigitalOcean:
-			doStore, err := store.NewDigitalOceanStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = doStore
-			digitalOceanStoreAddedViaConfig = true
-		case types.StoreKindAkamai:
-			akamaiStore, err := store.NewAkamaiStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = akamaiStore
-		case types.StoreKindCapi:
-			capiStore, err := store.NewCapiStore(kubeconfigStoreFromConfig, stateDirectory)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = capiStore
-		case types.StoreKindPlugin:
-			pluginStore, err := store.NewPluginStore(kubeconfigStoreFromConfig)
-			if err != nil {
-				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
-					continue
-				}
-				return nil, nil, err
-			}
-			s = pluginStore
-		default:
-			return nil, nil, fmt.Errorf("unknown store %q", kubeconfigStoreFromConfig.Kind)
+		// failover stores are added to the chain of their primary store
+		if switchconfig.IsFailoverStore(config, kubeconfigStoreFromConfig) {
+			continue
 		}
 
-		if showDebugLogs {
-			s.GetLogger().Logger.SetLevel(logrus.DebugLevel)
+		s := newLazyStore(kubeconfigStoreFromConfig, kubeconfigName)
+		if failoverStores := switchconfig.FailoverStores(config, kubeconfigStoreFromConfig); len(failoverStores) > 0 {
+			var failover []storetypes.KubeconfigStore
+			for _, failoverStore := range failoverStores {
+				failover = append(failover, newLazyStore(failoverStore, kubeconfigName))
+			}
+			s = store.NewFailoverStore(s, failover...)
 		}
 
 		// Add cache to the store
@@ -355,33 +578,185 @@ func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 		stores = append(stores, s)
 	}
 
-	// the Digital Ocean store is enabled by default for a seamless experience for `doctl` users (automatically discovers the `doctl` config file with stored credentials)
-	// this is optional, so don't care about errors
-	if !digitalOceanStoreAddedViaConfig {
-		doStore, _ := store.NewDigitalOceanStore(types.KubeconfigStore{
-			ID:   ptr.To("doDefaultStore"),
-			Kind: types.StoreKindDigitalOcean,
-			// for users with outdated `doctl` configs, don't show errors if they have no explicitly enabled the DO backing store
-			Required:   ptr.To(false),
-			ShowPrefix: ptr.To(true),
-		})
-		if doStore != nil {
-			// we found a valid `doctl` config, hence add Digital Ocean as a backing store with default configuration
-			s, err := cache.New("memory", doStore, nil)
-			if err != nil {
-				return nil, nil, err
-			}
-			stores = append(stores, s)
-		}
-	}
-
 	// set 'logr' log implementation for the controller-runtime (otherwise controller-runtime code cannot log)
-	log := logrusr.New(logrus.New())
+	log := logrusr.New(logging.New())
 	logf.SetLogger(log)
 
+	// outdated indexes of kubeconfig stores with "staleWhileRevalidate" are refreshed by a background process
+	pkg.RevalidateIndex = revalidateIndex
+
 	return stores, config, nil
 }
 
+// addDefaultDigitalOceanStore adds the Digital Ocean store if it is not configured explicitly.
+// The Digital Ocean store is enabled by default for a seamless experience for `doctl` users (automatically discovers the `doctl` config file with stored credentials)
+func addDefaultDigitalOceanStore(config *types.Config) {
+	if slices.ContainsFunc(config.KubeconfigStores, func(store types.KubeconfigStore) bool {
+		return store.Kind == types.StoreKindDigitalOcean
+	}) {
+		return
+	}
+
+	defaultStore := types.KubeconfigStore{
+		ID:   ptr.To("doDefaultStore"),
+		Kind: types.StoreKindDigitalOcean,
+		// for users with outdated `doctl` configs, don't show errors if they have no explicitly enabled the DO backing store
+		Required:   ptr.To(false),
+		ShowPrefix: ptr.To(true),
+	}
+
+	// this is optional, so don't care about errors
+	if doStore, _ := store.NewDigitalOceanStore(defaultStore); doStore != nil {
+		// we found a valid `doctl` config, hence add Digital Ocean as a backing store with default configuration
+		config.KubeconfigStores = append(config.KubeconfigStores, defaultStore)
+	}
+}
+
+// newLazyStore returns the kubeconfig store for the configuration.
+// The store is only created when it is searched or a kubeconfig is retrieved.
+// Errors are returned from the search, unless the store is optional.
+func newLazyStore(kubeconfigStoreFromConfig types.KubeconfigStore, kubeconfigName string) storetypes.KubeconfigStore {
+	if kubeconfigStoreFromConfig.KubeconfigName != nil && *kubeconfigStoreFromConfig.KubeconfigName != "" {
+		kubeconfigName = *kubeconfigStoreFromConfig.KubeconfigName
+	}
+
+	s := store.NewLazyStore(kubeconfigStoreFromConfig, func() (storetypes.KubeconfigStore, error) {
+		return newStore(kubeconfigStoreFromConfig, kubeconfigName)
+	})
+	setStoreLogLevel(s, kubeconfigStoreFromConfig)
+	return s
+}
+
+// newStore creates the kubeconfig store for the configuration after expanding its templates and resolving its secrets
+func newStore(kubeconfigStoreFromConfig types.KubeconfigStore, kubeconfigName string) (storetypes.KubeconfigStore, error) {
+	if err := switchconfig.ExpandStoreTemplates(&kubeconfigStoreFromConfig); err != nil {
+		return nil, err
+	}
+
+	if err := credentials.ResolveReferences(&kubeconfigStoreFromConfig); err != nil {
+		return nil, err
+	}
+
+	if err := credentials.InjectSecrets(&kubeconfigStoreFromConfig); err != nil {
+		return nil, err
+	}
+
+	var s storetypes.KubeconfigStore
+	switch kubeconfigStoreFromConfig.Kind {
+	case types.StoreKindFilesystem:
+		filesystemStore, err := store.NewFilesystemStore(kubeconfigName, kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = filesystemStore
+
+	case types.StoreKindVault:
+		vaultStore, err := store.NewVaultStore(vaultAPIAddressFromFlag,
+			vaultTokenFileName,
+			kubeconfigName,
+			kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = vaultStore
+
+	case types.StoreKindGardener:
+		gardenerStore, err := store.NewGardenerStore(kubeconfigStoreFromConfig, stateDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Gardener store: %w", err)
+		}
+		s = gardenerStore
+
+	case types.StoreKindGKE:
+		gkeStore, err := store.NewGKEStore(kubeconfigStoreFromConfig, stateDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create GKE store: %w", err)
+		}
+		s = gkeStore
+
+	case types.StoreKindAzure:
+		azureStore, err := store.NewAzureStore(kubeconfigStoreFromConfig, stateDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Azure store: %w", err)
+		}
+		s = azureStore
+	case types.StoreKindEKS:
+		eksStore, err := store.NewEKSStore(kubeconfigStoreFromConfig, stateDirectory)
+		if err != nil {
+			return nil, err
+		}
+		s = eksStore
+	case types.StoreKindExoscale:
+		exoscaleStore, err := store.NewExoscaleStore(kubeconfigStoreFromConfig, stateDirectory)
+		if err != nil {
+			return nil, err
+		}
+		s = exoscaleStore
+	case types.StoreKindRancher:
+		rancherStore, err := store.NewRancherStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = rancherStore
+	case types.StoreKindOVH:
+		ovhStore, err := store.NewOVHStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = ovhStore
+	case types.StoreKindScaleway:
+		scalewayStore, err := store.NewScalewayStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = scalewayStore
+	case types.StoreKindDigitalOcean:
+		doStore, err := store.NewDigitalOceanStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		if doStore == nil {
+			return nil, fmt.Errorf("the doctl config file of the Digital Ocean store was not found")
+		}
+		s = doStore
+	case types.StoreKindAkamai:
+		akamaiStore, err := store.NewAkamaiStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = akamaiStore
+	case types.StoreKindCapi:
+		capiStore, err := store.NewCapiStore(kubeconfigStoreFromConfig, stateDirectory)
+		if err != nil {
+			return nil, err
+		}
+		s = capiStore
+	case types.StoreKindPlugin:
+		pluginStore, err := store.NewPluginStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = pluginStore
+	case types.StoreKindExec:
+		execStore, err := store.NewExecStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = execStore
+	case types.StoreKindMock:
+		mockStore, err := store.NewMockStore(kubeconfigStoreFromConfig)
+		if err != nil {
+			return nil, err
+		}
+		s = mockStore
+	default:
+		return nil, fmt.Errorf("unknown store %q", kubeconfigStoreFromConfig.Kind)
+	}
+
+	setStoreLogLevel(s, kubeconfigStoreFromConfig)
+	return s, nil
+}
+
 // getStoreFromFlagAndEnv translates the kubeconfig flag --kubeconfig-path & environment variable KUBECONFIG into a
 // dedicated store in addition to the stores configured in the switch-config.yaml.
 // This way, it is "just another store" -> does not need special handling
@@ -456,3 +831,22 @@ func isDuplicatePath(kubeconfigStores []types.KubeconfigStore, newPath string) b
 	}
 	return false
 }
+
+// watchConfig initializes the kubeconfig stores again whenever the SwitchConfig files change and calls onReload
+// until the context is cancelled. The changes are logged. Invalid configurations are not applied.
+func watchConfig(ctx context.Context, log *logrus.Entry, onReload func(stores []storetypes.KubeconfigStore, config *types.Config)) {
+	switchconfig.Watch(ctx, util.ExpandEnv(configPath), switchconfig.WatchInterval, func(changes []string, err error) {
+		if err != nil {
+			log.Warnf("failed to read the changed SwitchConfig, keeping the previous configuration: %v", err)
+			return
+		}
+		log.Infof("SwitchConfig changed: %s", strings.Join(changes, ", "))
+
+		stores, config, err := initialize()
+		if err != nil {
+			log.Warnf("not applying the changed SwitchConfig: %v", err)
+			return
+		}
+		onReload(stores, config)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/tailscale"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+)
+
+var (
+	tailscaleCmd = &cobra.Command{
+		Use:   "tailscale",
+		Short: "List the contexts reachable over the Tailscale network",
+		Long: `Queries the status of the Tailscale network (tailnet) and lists the contexts whose API servers are tailnet IP addresses
+or MagicDNS names, or which match the patterns of "tailscale.contexts" in the SwitchConfig, together with the state of the nodes
+serving their API servers. Also lists the API server proxies of the Tailscale Kubernetes operator that are not referenced by any context.`,
+		Example: "switch tailscale",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return tailscale.Tailscale(stores, config, util.ExpandEnv(stateDirectory), noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(tailscaleCmd)
+	rootCommand.AddCommand(tailscaleCmd)
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/prompt"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/tmux"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	tmuxStatusFormat     string
+	tmuxStatusKubeconfig string
+	tmuxPopupWidth       string
+	tmuxPopupHeight      string
+	tmuxPopupTargetPane  string
+
+	tmuxCmd = &cobra.Command{
+		Use:   "tmux",
+		Short: "tmux integration",
+		Long:  `Show the current context in the tmux status line and select contexts in a tmux popup.`,
+	}
+
+	tmuxStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Print the context of the active pane for the tmux status line",
+		Long: `Print the context and namespace of the active pane for the tmux status line, colored by environment.
+The switch shell function remembers the kubeconfig of each pane in the pane option "@kubeswitch_kubeconfig".
+
+Add to ~/.tmux.conf:
+
+  set -g status-right '#(switcher tmux status --kubeconfig "#{@kubeswitch_kubeconfig}")'`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var environments []types.Environment
+			if config, err := switchconfig.LoadConfig(util.ExpandEnv(configPath)); err == nil && config != nil {
+				environments = config.Environments
+			}
+
+			output, err := prompt.Prompt(environments, prompt.Options{
+				Format:         tmuxStatusFormat,
+				Shell:          "tmux",
+				KubeconfigPath: tmuxStatusKubeconfig,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Print(output)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	tmuxPopupCmd = &cobra.Command{
+		Use:   "popup",
+		Short: "Select a context in a tmux popup",
+		Long: `Open the selection dialog in a tmux popup. The selected context is exported in the shell of the pane the popup was opened from.
+
+Add to ~/.tmux.conf:
+
+  bind-key k run-shell 'switcher tmux popup'`,
+		Args: cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(tmuxPopupTargetPane) == 0 {
+				pane, err := tmux.CurrentPane()
+				if err != nil {
+					return err
+				}
+				// run the selection dialog inside the popup, forwarding the flags of this invocation
+				return tmux.OpenPopup(append([]string{"tmux", "popup", "--target-pane", pane}, changedFlags(cmd)...), tmuxPopupWidth, tmuxPopupHeight)
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			// config file setting overwrites the command line default (--showPreview true)
+			if showPreview && config.ShowPreview != nil && !*config.ShowPreview {
+				showPreview = false
+			}
+
+			kubeconfigPath, contextName, err := pkg.Switcher(stores, config, stateDirectory, noIndex, showPreview, true)
+			if err != nil {
+				return err
+			}
+			if kubeconfigPath == nil || contextName == nil {
+				return nil
+			}
+
+			if err := prepareNewContext(*kubeconfigPath, *contextName); err != nil {
+				return err
+			}
+			if err := tmux.SetPaneKubeconfig(tmuxPopupTargetPane, *kubeconfigPath); err != nil {
+				return err
+			}
+			runPostSwitchHooks(*kubeconfigPath)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+// changedFlags returns the flags set on the command line as arguments
+func changedFlags(cmd *cobra.Command) []string {
+	var args []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range sliceValue.GetSlice() {
+				args = append(args, fmt.Sprintf("--%s=%s", flag.Name, value))
+			}
+			return
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+	return args
+}
+
+func init() {
+	tmuxStatusCmd.Flags().StringVar(
+		&tmuxStatusFormat,
+		"format",
+		prompt.DefaultFormat,
+		"Go template of the output, see 'switch prompt --help'.")
+	tmuxStatusCmd.Flags().StringVar(
+		&tmuxStatusKubeconfig,
+		"kubeconfig",
+		"",
+		"the kubeconfig of the active pane. Defaults to the KUBECONFIG environment variable of the tmux server.")
+	tmuxStatusCmd.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+
+	tmuxPopupCmd.Flags().StringVar(
+		&tmuxPopupWidth,
+		"width",
+		"80%",
+		"width of the popup.")
+	tmuxPopupCmd.Flags().StringVar(
+		&tmuxPopupHeight,
+		"height",
+		"80%",
+		"height of the popup.")
+	tmuxPopupCmd.Flags().StringVar(
+		&tmuxPopupTargetPane,
+		"target-pane",
+		"",
+		"the pane to export the selected context in. Set when opening the popup.")
+	tmuxPopupCmd.Flags().MarkHidden("target-pane")
+	setFlagsForContextCommands(tmuxPopupCmd)
+	setSwitchFlags(tmuxPopupCmd)
+
+	tmuxCmd.AddCommand(tmuxStatusCmd)
+	tmuxCmd.AddCommand(tmuxPopupCmd)
+	rootCommand.AddCommand(tmuxCmd)
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/ui"
+)
+
+var (
+	uiAddress string
+
+	uiCmd = &cobra.Command{
+		Use:   "ui",
+		Short: "Serve a local web UI to browse and switch clusters",
+		Long: `Serves a local web UI showing the contexts of all kubeconfig stores grouped by store, with search, tags and health checks.
+Kubeconfigs can be copied and a temporary kubeconfig can be created for a context.
+Requires a running daemon (see "switch daemon").`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ui.Run(ui.Options{
+				Address:    uiAddress,
+				SocketPath: getDaemonSocket(),
+			})
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setDaemonSocketFlag(uiCmd)
+	uiCmd.Flags().StringVar(
+		&uiAddress,
+		"address",
+		"127.0.0.1:8765",
+		"local address to serve the web UI on. Only loopback addresses are allowed.")
+	uiCmd.Flags().StringVar(
+		&stateDirectory,
+		"state-directory",
+		os.ExpandEnv("$HOME/.kube/switch-state"),
+		"path to the local directory used for storing internal state.")
+	rootCommand.AddCommand(uiCmd)
+}
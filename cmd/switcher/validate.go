@@ -0,0 +1,50 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/validate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate [PATTERN...]",
+		Short: "Validate the kubeconfigs of the kubeconfig stores",
+		Long: `Retrieves the kubeconfigs of the contexts matching one of the patterns (all contexts without patterns) from their kubeconfig stores and validates them.
+Patterns accept the wildcards '*' and '?'.
+Checks that the kubeconfigs can be parsed, that the clusters and users referenced by the contexts exist,
+that the certificate authorities and client certificates parse and that the binaries of exec credential plugins are on the PATH.
+Prints the problems found per kubeconfig store and path. To validate the kubeconfig before every switch, set validateKubeconfigs: true in the SwitchConfig.`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			lc, _ := listContexts(toComplete)
+			return lc, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return validate.Validate(args, stores, config, stateDirectory, noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(validateCmd)
+	rootCommand.AddCommand(validateCmd)
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"github.com/spf13/cobra"
+
+	verifycache "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/verify-cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+)
+
+var (
+	verifyCacheCmd = &cobra.Command{
+		Use:   "verify-cache [store...]",
+		Short: "Detect changes of the cached kubeconfigs in the kubeconfig stores",
+		Long: `Retrieves the cached kubeconfigs from their kubeconfig stores and compares them with the cache using content hashes,
+e.g. to detect rotated certificates or changed API server endpoints. Only the changed kubeconfigs are replaced in the cache,
+unchanged kubeconfigs keep being served from the cache. Prints the changes per kubeconfig path.
+Stores are given by kind or ID. Per default, the caches of all kubeconfig stores are verified. Kubeconfig stores without a
+cache of type "filesystem" or "database" are skipped.`,
+		Example: "switch verify-cache\nswitch verify-cache vault",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				storeSelectors = append(storeSelectors, args...)
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			return verifycache.VerifyCache(stores, config, util.ExpandEnv(stateDirectory), noIndex)
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(verifyCacheCmd)
+	rootCommand.AddCommand(verifyCacheCmd)
+}
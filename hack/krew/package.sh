@@ -0,0 +1,43 @@
+#!/bin/bash
+
+set -e
+
+# Packages the binaries built by "make build-switcher" as kubectl plugin archives with the
+# layout expected by krew and renders the krew plugin manifest with their checksums.
+
+VERSION="$1"
+if [ -z "$VERSION" ]; then
+  echo "usage: $0 <version>"
+  exit 1
+fi
+
+echo "> Package kubectl plugin $VERSION"
+
+ROOT="$(cd "$(dirname "$0")/../.." && pwd)"
+DIST="$ROOT/hack/krew/dist"
+rm -rf "$DIST"
+mkdir -p "$DIST"
+
+manifest="$(cat "$ROOT/hack/krew/switch.yaml")"
+manifest="${manifest//\$\{VERSION\}/$VERSION}"
+
+for platform in darwin_amd64 darwin_arm64 linux_amd64 linux_arm64 windows_amd64; do
+  binary="switcher_${platform}"
+  plugin="kubectl-switch"
+  if [[ "$platform" == windows_* ]]; then
+    binary="${binary}.exe"
+    plugin="${plugin}.exe"
+  fi
+
+  staging="$(mktemp -d)"
+  cp "$ROOT/hack/switch/$binary" "$staging/$plugin"
+  cp "$ROOT/LICENSE" "$staging/LICENSE"
+  tar -czf "$DIST/kubectl-switch_${platform}.tar.gz" -C "$staging" "$plugin" LICENSE
+  rm -rf "$staging"
+
+  sha="$(sha256sum "$DIST/kubectl-switch_${platform}.tar.gz" | awk '{print $1}')"
+  placeholder="SHA_$(echo "$platform" | tr '[:lower:]' '[:upper:]')"
+  manifest="${manifest//\$\{$placeholder\}/$sha}"
+done
+
+printf "%s\n" "$manifest" > "$DIST/switch.yaml"
@@ -31,9 +31,11 @@ function switch(){
   fi
 
   RESPONSE="$($EXECUTABLE_PATH "${opts[@]}")"
-  if [ $? -ne 0 -o -z "$RESPONSE" ]; then
+  # keep the exit code of the switcher binary, e.g. for the exit codes of --non-interactive
+  local EXIT_CODE=$?
+  if [ $EXIT_CODE -ne 0 -o -z "$RESPONSE" ]; then
     printf "%s\n" "$RESPONSE"
-    return $?
+    return $EXIT_CODE
   fi
 
   # switcher returns a response that contains a kubeconfig path with a prefix "__ " to be able to
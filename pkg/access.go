@@ -0,0 +1,175 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tui"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// accessCheckTimeout is the maximum duration to wait for the API server when checking the access to the cluster
+	accessCheckTimeout = 5 * time.Second
+	// defaultAccessCacheDuration is the default duration the result of an access check is cached
+	defaultAccessCacheDuration = 24 * time.Hour
+)
+
+// ErrAccessDenied is returned if the cluster of a context rejects the credentials of the user
+var ErrAccessDenied = errors.New("credentials rejected by the cluster")
+
+// CheckAccess checks whether the cluster of the context accepts the credentials of the kubeconfig with the path in the kubeconfig store.
+// Creates a SelfSubjectReview, which every authenticated user is allowed to, or a SelfSubjectAccessReview for clusters older than Kubernetes 1.28.
+// Returns ErrAccessDenied if the credentials are rejected and another error if the access cannot be checked, e.g. because the cluster is unreachable.
+// The result is stored in the search index of the kubeconfig store unless the access cannot be checked.
+func CheckAccess(kubeconfigStore storetypes.KubeconfigStore, path string, tags map[string]string, contextName, stateDir string) error {
+	restConfig, err := getRestConfigForPath(kubeconfigStore, path, tags, contextName)
+	if err != nil {
+		return err
+	}
+	restConfig.Timeout = accessCheckTimeout
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), accessCheckTimeout)
+	defer cancel()
+
+	_, err = clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if apierrors.IsNotFound(err) {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "namespaces"},
+			},
+		}
+		_, err = clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	}
+
+	access := index.Access{CheckedAt: time.Now().UTC()}
+	switch {
+	case err == nil, apierrors.IsForbidden(err):
+		// the user is authenticated, even if not allowed to create the review
+	case apierrors.IsUnauthorized(err):
+		access.Denied = true
+	default:
+		return fmt.Errorf("failed to check access: %v", err)
+	}
+
+	if err := index.RecordAccess(stateDir, kubeconfigStore.GetID(), contextName, access); err != nil {
+		logger.Debugf("failed to record the access check of context %q: %v", contextName, err)
+	}
+	if access.Denied {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// AccessDenied returns true if the last access check of the context found that its cluster rejects the credentials
+// and the result is not older than the cache duration of the access check
+func AccessDenied(config *types.Config, access index.Access) bool {
+	return access.Denied && AccessCheckCached(config, access)
+}
+
+// AccessCheckCached returns true if the result of the access check is not older than the cache duration of the access check
+func AccessCheckCached(config *types.Config, access index.Access) bool {
+	cacheDuration := defaultAccessCacheDuration
+	if config != nil && config.AccessCheck != nil && config.AccessCheck.CacheDuration != nil {
+		cacheDuration = *config.AccessCheck.CacheDuration
+	}
+	return time.Since(access.CheckedAt) < cacheDuration
+}
+
+// accessCheckMode returns how contexts are shown whose cluster rejected the credentials of the user
+func accessCheckMode(config *types.Config) types.AccessCheckMode {
+	if config.AccessCheck.Mode != nil {
+		return *config.AccessCheck.Mode
+	}
+	return types.AccessCheckModeDim
+}
+
+// loadContextAccess returns the results of the previous access checks by store ID and context name.
+// Returns nil if the access check is not configured.
+func loadContextAccess(config *types.Config, stateDir string, storeIDToStore map[string]storetypes.KubeconfigStore) map[string]map[string]index.Access {
+	if config.AccessCheck == nil {
+		return nil
+	}
+
+	var storeIDs []string
+	for id := range storeIDToStore {
+		storeIDs = append(storeIDs, id)
+	}
+	contextAccess, err := index.LoadAccess(stateDir, storeIDs)
+	if err != nil {
+		logger.Debugf("failed to read the results of the access checks: %v", err)
+		return map[string]map[string]index.Access{}
+	}
+	return contextAccess
+}
+
+// checkItemAccess returns true if the cluster of the item of the picker rejects the credentials.
+// The cluster is only checked if the result of the previous check has expired.
+func checkItemAccess(storeIDToStore map[string]storetypes.KubeconfigStore, config *types.Config, stateDir string, contextAccess map[string]map[string]index.Access, item tui.Item) bool {
+	path := readFromContextToPathMapping(item.Name)
+	kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(path)]
+	if !ok {
+		return false
+	}
+
+	// the index contains the context names, not the aliases
+	name := item.Name
+	if original := readFromAliasToContext(item.Name); len(original) > 0 {
+		name = original
+	}
+	if access, ok := contextAccess[kubeconfigStore.GetID()][name]; ok && AccessCheckCached(config, access) {
+		return access.Denied
+	}
+
+	err := CheckAccess(kubeconfigStore, path, readFromPathToTagsMapping(path), name, stateDir)
+	if err != nil && !errors.Is(err, ErrAccessDenied) {
+		logger.Debugf("failed to check the access to the cluster of context %q: %v", item.Name, err)
+	}
+	return errors.Is(err, ErrAccessDenied)
+}
+
+// HiddenByAccessCheck returns a function returning true for the discovered contexts whose cluster rejected the credentials
+// in a previous access check. Returns nil unless the access check hides these contexts (see "accessCheck").
+func HiddenByAccessCheck(config *types.Config, stateDir string, stores []storetypes.KubeconfigStore) func(discoveredContext DiscoveredContext) bool {
+	if config == nil || config.AccessCheck == nil || accessCheckMode(config) != types.AccessCheckModeHide {
+		return nil
+	}
+
+	storeIDToStore := map[string]storetypes.KubeconfigStore{}
+	for _, store := range stores {
+		storeIDToStore[store.GetID()] = store
+	}
+	contextAccess := loadContextAccess(config, stateDir, storeIDToStore)
+	return func(discoveredContext DiscoveredContext) bool {
+		return discoveredContext.Store != nil && AccessDenied(config, contextAccess[(*discoveredContext.Store).GetID()][discoveredContext.Name])
+	}
+}
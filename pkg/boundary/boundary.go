@@ -0,0 +1,431 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package boundary brokers the access to API servers through HashiCorp Boundary sessions.
+// Switching to a context of a Boundary target connects to the target in the background and points the cluster
+// to the local listener of the session. The session is canceled once the terminal session ends.
+package boundary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// Command is the hidden command keeping the Boundary session of a terminal session in the background
+	Command = "boundary-session"
+
+	// sessionDirectory is the directory in the state directory containing the Boundary sessions started by kubeswitch
+	sessionDirectory = "boundary"
+	// defaultExecutable is the default executable of the boundary CLI
+	defaultExecutable = "boundary"
+	// listenAddress is the address of the local listener of the sessions
+	listenAddress = "127.0.0.1"
+	// defaultStartTimeout is the default maximum duration to wait for a session to accept connections
+	defaultStartTimeout = 30 * time.Second
+	// pollInterval is how often the background process checks if the terminal session ended
+	pollInterval = 2 * time.Second
+	// stopTimeout is how long to wait for the boundary CLI to cancel the session before it is killed
+	stopTimeout = 10 * time.Second
+	// dialTimeout is the timeout of checking if a session accepts connections
+	dialTimeout = 500 * time.Millisecond
+)
+
+var logger = logging.New()
+
+// Session is a Boundary session started by kubeswitch for a terminal session
+type Session struct {
+	// Target is the ID or the scope and name of the target
+	Target string `json:"target"`
+	// ShellPID is the process ID of the shell of the terminal session. The session is canceled once it exits.
+	ShellPID int `json:"shellPID"`
+	// PID is the process ID of "boundary connect"
+	PID int `json:"pid"`
+	// Port is the port of the local listener
+	Port int `json:"port"`
+	// StartedAt is the time the session has been started
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Options configure the background process keeping a Boundary session
+type Options struct {
+	Target   types.BoundaryTarget
+	ShellPID int
+	Port     int
+	StateDir string
+}
+
+// Select returns the first Boundary target of the SwitchConfig whose patterns match one of the context names
+// (e.g. the alias and the name in the kubeconfig). Returns nil if no target matches.
+func Select(config *types.Config, contextNames ...string) *types.BoundaryTarget {
+	if config == nil {
+		return nil
+	}
+	for i, target := range config.BoundaryTargets {
+		for _, pattern := range target.Contexts {
+			m := wildmatch.NewWildMatch(pattern)
+			for _, contextName := range contextNames {
+				if len(contextName) > 0 && m.IsMatch(contextName) {
+					return &config.BoundaryTargets[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Apply connects to the Boundary target of the context unless the terminal session already has a session to it,
+// and points the clusters with the API server of the current context to the local listener of the session.
+// The original host is kept as TLS server name, so that the certificate of the API server is still verified.
+func Apply(kubeconfig *kubeconfigutil.Kubeconfig, config *types.Config, stateDir string, contextNames ...string) error {
+	target := Select(config, contextNames...)
+	if target == nil {
+		return nil
+	}
+
+	server, err := kubeconfig.ServerOfContext(kubeconfig.GetCurrentContext())
+	if err != nil {
+		return err
+	}
+	apiServer, err := url.Parse(server)
+	if err != nil || len(apiServer.Host) == 0 {
+		return fmt.Errorf("the context %q has no valid API server to connect to through Boundary: %q", kubeconfig.GetCurrentContext(), server)
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Printf("connect to the Boundary target %q for the API server %q", TargetName(*target), server)
+		return nil
+	}
+
+	session, err := EnsureSession(*target, stateDir, os.Getppid())
+	if err != nil {
+		return err
+	}
+
+	tlsServerNames := kubeconfig.GetClusterFields("tls-server-name")
+	for cluster, clusterServer := range kubeconfig.GetClusterServers() {
+		if clusterServer != server {
+			continue
+		}
+		if _, ok := tlsServerNames[cluster]; !ok {
+			kubeconfig.SetClusterFieldOf(cluster, "tls-server-name", apiServer.Hostname())
+		}
+		local := *apiServer
+		local.Host = net.JoinHostPort(listenAddress, strconv.Itoa(session.Port))
+		kubeconfig.SetClusterServer(cluster, local.String())
+	}
+	return nil
+}
+
+// TargetName returns the ID or the scope and name of the target
+func TargetName(target types.BoundaryTarget) string {
+	if len(target.TargetID) > 0 {
+		return target.TargetID
+	}
+	scope := target.TargetScopeID
+	if len(scope) == 0 {
+		scope = target.TargetScopeName
+	}
+	return fmt.Sprintf("%s/%s", scope, target.TargetName)
+}
+
+// EnsureSession returns the session of the terminal session to the target if it still accepts connections.
+// Otherwise, starts the background process connecting to the target and waits until the session accepts connections.
+func EnsureSession(target types.BoundaryTarget, stateDir string, shellPID int) (*Session, error) {
+	file := sessionFile(stateDir, shellPID, TargetName(target))
+	if session, err := readSession(file); err == nil && Running(*session) {
+		return session, nil
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a free port for the Boundary session: %v", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// the process outlives kubeswitch, hence logs to a file instead of a pipe.
+	// It must not inherit stdout, as the shell integration reads stdout until it is closed.
+	if err := permissions.MkdirAll(filepath.Join(stateDir, sessionDirectory)); err != nil {
+		return nil, err
+	}
+	logFile := strings.TrimSuffix(file, ".json") + ".log"
+	output, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, permissions.FileMode)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Close()
+
+	options := Options{Target: target, ShellPID: shellPID, Port: port, StateDir: stateDir}
+	cmd := exec.Command(executable, options.args()...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	detach(cmd)
+	logger.Debugf("Starting Boundary session: %s %s", executable, strings.Join(options.args(), " "))
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to connect to the Boundary target %q: %v", TargetName(target), err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	timeout := defaultStartTimeout
+	if target.StartTimeout != nil {
+		timeout = *target.StartTimeout
+	}
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	session := &Session{Target: TargetName(target), ShellPID: shellPID, Port: port}
+	for !accepting(port) {
+		select {
+		case err := <-exited:
+			output, _ := os.ReadFile(logFile)
+			return nil, fmt.Errorf("the Boundary session to target %q exited: %v: %s", TargetName(target), err, strings.TrimSpace(string(output)))
+		case <-deadline:
+			_ = cmd.Process.Kill()
+			return nil, fmt.Errorf("the Boundary session to target %q did not accept connections on port %d within %s", TargetName(target), port, timeout)
+		case <-ticker.C:
+		}
+	}
+
+	logger.Infof("Connected to Boundary target %q on %s:%d until the terminal session ends", TargetName(target), listenAddress, port)
+	return session, nil
+}
+
+// Keep connects to the target with "boundary connect" and cancels the session once the shell of the terminal session exits.
+// Runs in the background process started by EnsureSession.
+func Keep(options Options) error {
+	executable := defaultExecutable
+	if options.Target.Executable != nil && len(*options.Target.Executable) > 0 {
+		executable = *options.Target.Executable
+	}
+
+	args := []string{"connect", "-listen-addr", listenAddress, "-listen-port", strconv.Itoa(options.Port), "-format", "json"}
+	if len(options.Target.TargetID) > 0 {
+		args = append(args, "-target-id", options.Target.TargetID)
+	} else {
+		args = append(args, "-target-name", options.Target.TargetName)
+		if len(options.Target.TargetScopeID) > 0 {
+			args = append(args, "-target-scope-id", options.Target.TargetScopeID)
+		} else {
+			args = append(args, "-target-scope-name", options.Target.TargetScopeName)
+		}
+	}
+	if options.Target.Addr != nil && len(*options.Target.Addr) > 0 {
+		args = append(args, "-addr", *options.Target.Addr)
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	file := sessionFile(options.StateDir, options.ShellPID, TargetName(options.Target))
+	defer os.Remove(file)
+
+	session := Session{
+		Target:    TargetName(options.Target),
+		ShellPID:  options.ShellPID,
+		PID:       cmd.Process.Pid,
+		Port:      options.Port,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := writeSession(file, session); err != nil {
+		logger.Warnf("failed to record the Boundary session to target %q: %v", session.Target, err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-exited:
+			return err
+		case <-ticker.C:
+			if processRunning(options.ShellPID) {
+				continue
+			}
+			logger.Infof("The terminal session ended, canceling the Boundary session to target %q", session.Target)
+			_ = os.Remove(strings.TrimSuffix(file, ".json") + ".log")
+			return stop(cmd.Process, exited)
+		}
+	}
+}
+
+// ListSessions returns the Boundary sessions started by kubeswitch, sorted by target and port
+func ListSessions(stateDir string) ([]Session, error) {
+	entries, err := os.ReadDir(filepath.Join(stateDir, sessionDirectory))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		session, err := readSession(filepath.Join(stateDir, sessionDirectory, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Boundary session %q: %v", entry.Name(), err)
+		}
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Target != sessions[j].Target {
+			return sessions[i].Target < sessions[j].Target
+		}
+		return sessions[i].Port < sessions[j].Port
+	})
+	return sessions, nil
+}
+
+// StopSession cancels the Boundary session started by kubeswitch by stopping "boundary connect".
+// The background process of the session removes it from the state directory.
+func StopSession(stateDir string, session Session) error {
+	process, err := os.FindProcess(session.PID)
+	if err == nil {
+		err = interrupt(process)
+	}
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to cancel the Boundary session to target %q (PID %d): %v", session.Target, session.PID, err)
+	}
+	_ = os.Remove(strings.TrimSuffix(sessionFile(stateDir, session.ShellPID, session.Target), ".json") + ".log")
+	return nil
+}
+
+// Running returns true if the local listener of the session accepts connections
+func Running(session Session) bool {
+	return accepting(session.Port)
+}
+
+// stop interrupts "boundary connect", which cancels the session, and kills it if it does not exit in time
+func stop(process *os.Process, exited <-chan error) error {
+	if err := interrupt(process); err != nil {
+		return process.Kill()
+	}
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(stopTimeout):
+		return process.Kill()
+	}
+}
+
+// args returns the arguments of the background process keeping the session
+func (o Options) args() []string {
+	args := []string{Command,
+		"--state-directory", o.StateDir,
+		"--shell-pid", strconv.Itoa(o.ShellPID),
+		"--listen-port", strconv.Itoa(o.Port),
+	}
+	for _, flag := range []struct{ name, value string }{
+		{"--target-id", o.Target.TargetID},
+		{"--target-name", o.Target.TargetName},
+		{"--target-scope-id", o.Target.TargetScopeID},
+		{"--target-scope-name", o.Target.TargetScopeName},
+	} {
+		if len(flag.value) > 0 {
+			args = append(args, flag.name, flag.value)
+		}
+	}
+	if o.Target.Addr != nil && len(*o.Target.Addr) > 0 {
+		args = append(args, "--addr", *o.Target.Addr)
+	}
+	if o.Target.Executable != nil && len(*o.Target.Executable) > 0 {
+		args = append(args, "--executable", *o.Target.Executable)
+	}
+	return args
+}
+
+// accepting returns true if the local port accepts connections
+func accepting(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(listenAddress, strconv.Itoa(port)), dialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// freePort returns a local port that is currently not in use
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(listenAddress, "0"))
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// readSession reads the session recorded in the file
+func readSession(file string) (*Session, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// writeSession records the session in the file
+func writeSession(file string, session Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, permissions.FileMode)
+}
+
+// sessionFile returns the file recording the session of the terminal session to the target
+func sessionFile(stateDir string, shellPID int, targetName string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(targetName)
+	return filepath.Join(stateDir, sessionDirectory, fmt.Sprintf("%d-%s.json", shellPID, name))
+}
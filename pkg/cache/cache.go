@@ -15,16 +15,29 @@
 package cache
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
 var (
 	cachesMu sync.RWMutex
 	caches   = make(map[string]CacheFactory)
+
+	// the kubeconfigs found in and missing from the caches by kubeconfig store ID
+	statsMu sync.Mutex
+	hits    = make(map[string]int)
+	misses  = make(map[string]int)
 )
 
 type CacheFactory func(store storetypes.KubeconfigStore, cfg *types.Cache) (storetypes.KubeconfigStore, error)
@@ -49,6 +62,135 @@ func New(kind string, store storetypes.KubeconfigStore, cfg *types.Cache) (store
 	return cache, nil
 }
 
+// RecordHit counts a kubeconfig of the kubeconfig store found in its persistent cache
+func RecordHit(storeID string) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	hits[storeID]++
+}
+
+// RecordMiss counts a kubeconfig of the kubeconfig store missing from its persistent cache
+func RecordMiss(storeID string) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	misses[storeID]++
+}
+
+// Stats returns the number of kubeconfigs of the kubeconfig store found in and missing from its cache so far
+func Stats(storeID string) (int, int) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	return hits[storeID], misses[storeID]
+}
+
+// CredentialsExpired returns when the credentials in the cached kubeconfig expired, or nil if they are still valid.
+// Kubeconfigs with expired credentials are retrieved from the upstream store again instead of failing with "Unauthorized" after the switch.
+func CredentialsExpired(kubeconfig []byte) *time.Time {
+	expiry := util.GetCredentialsExpiry(kubeconfig)
+	if expiry == nil || time.Now().Before(*expiry) {
+		return nil
+	}
+	return expiry
+}
+
 type Flushable interface {
 	Flush() (int, error)
 }
+
+// Evictable is implemented by caches that can remove the cached kubeconfig of a single path
+type Evictable interface {
+	Evict(path string) (bool, error)
+}
+
+// Verifiable is implemented by caches that can detect drift between the cached kubeconfig of a path and the upstream store
+type Verifiable interface {
+	// Verify retrieves the kubeconfig from the upstream store and compares it with the cached kubeconfig.
+	// A changed kubeconfig is replaced in the cache. Returns nil if the kubeconfig of the path is not cached.
+	Verify(path string, tags map[string]string) (*Drift, error)
+}
+
+// Drift describes how the kubeconfig of the upstream store differs from the cached kubeconfig
+type Drift struct {
+	// Path is the kubeconfig path in the upstream store
+	Path string
+	// Changes are the changed settings, e.g. the server of a cluster. Empty if the cached kubeconfig is up to date.
+	Changes []string
+}
+
+// Hash returns the hash of the kubeconfig stored with the cached kubeconfig
+func Hash(kubeconfig []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(kubeconfig))
+}
+
+// Changes returns the settings of the upstream kubeconfig that differ from the cached kubeconfig, sorted by name.
+// Returns nil if both kubeconfigs have the same hash.
+func Changes(cached, upstream []byte) []string {
+	if Hash(cached) == Hash(upstream) {
+		return nil
+	}
+
+	before, errBefore := clientcmd.Load(cached)
+	after, errAfter := clientcmd.Load(upstream)
+	if errBefore != nil || errAfter != nil {
+		return []string{"content"}
+	}
+
+	var changes []string
+	for name, cluster := range after.Clusters {
+		previous, ok := before.Clusters[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("added cluster %q", name))
+		case previous.Server != cluster.Server:
+			changes = append(changes, fmt.Sprintf("server of cluster %q (%s -> %s)", name, previous.Server, cluster.Server))
+		case !bytes.Equal(previous.CertificateAuthorityData, cluster.CertificateAuthorityData) || previous.CertificateAuthority != cluster.CertificateAuthority:
+			changes = append(changes, fmt.Sprintf("certificate authority of cluster %q", name))
+		case !reflect.DeepEqual(previous, cluster):
+			changes = append(changes, fmt.Sprintf("cluster %q", name))
+		}
+	}
+
+	for name, authInfo := range after.AuthInfos {
+		previous, ok := before.AuthInfos[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("added user %q", name))
+		case !bytes.Equal(previous.ClientCertificateData, authInfo.ClientCertificateData) || previous.ClientCertificate != authInfo.ClientCertificate:
+			changes = append(changes, fmt.Sprintf("client certificate of user %q", name))
+		case previous.Token != authInfo.Token || previous.TokenFile != authInfo.TokenFile:
+			changes = append(changes, fmt.Sprintf("token of user %q", name))
+		case !reflect.DeepEqual(previous, authInfo):
+			changes = append(changes, fmt.Sprintf("user %q", name))
+		}
+	}
+
+	for name, context := range after.Contexts {
+		if previous, ok := before.Contexts[name]; !ok {
+			changes = append(changes, fmt.Sprintf("added context %q", name))
+		} else if !reflect.DeepEqual(previous, context) {
+			changes = append(changes, fmt.Sprintf("context %q", name))
+		}
+	}
+
+	changes = append(changes, removed("cluster", before.Clusters, after.Clusters)...)
+	changes = append(changes, removed("user", before.AuthInfos, after.AuthInfos)...)
+	changes = append(changes, removed("context", before.Contexts, after.Contexts)...)
+
+	// e.g. the current context, extensions or formatting
+	if len(changes) == 0 {
+		return []string{"content"}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// removed describes the entries of before not contained in after
+func removed[T any](kind string, before, after map[string]T) []string {
+	var changes []string
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, fmt.Sprintf("removed %s %q", kind, name))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,357 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	statedatabase "github.com/danielfoehrkn/kubeswitch/pkg/util/database"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	cacheKey = "database"
+	// defaultPath is the default path of the database file
+	defaultPath = "$HOME/.kube/switch-state/switch.cache.db"
+)
+
+func init() {
+	cache.Register(cacheKey, New)
+}
+
+func New(upstream storetypes.KubeconfigStore, ccfg *types.Cache) (storetypes.KubeconfigStore, error) {
+	cfg, err := unmarshalDatabaseCacheCfg(ccfg)
+	if err != nil {
+		return nil, err
+	}
+
+	path := defaultPath
+	if len(cfg.Path) > 0 {
+		path = cfg.Path
+	}
+	path = util.ExpandEnv(path)
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("directory of path: %s was not able to be created", path)
+	}
+
+	log := logging.New().WithField("store", upstream.GetID()).WithField("cache", cacheKey)
+
+	return &databaseCache{
+		upstream: upstream,
+		path:     path,
+		logger:   log,
+	}, nil
+}
+
+type databaseCache struct {
+	upstream storetypes.KubeconfigStore
+	// path of the database file
+	path   string
+	logger *logrus.Entry
+}
+
+type databaseCacheCfg struct {
+	// Path of the database file. Defaults to "switch.cache.db" in the default state directory.
+	Path string `yaml:"path"`
+}
+
+// entry is a kubeconfig in the cache with its metadata
+type entry struct {
+	Kubeconfig []byte `json:"kubeconfig"`
+	// CachedAt is the time the kubeconfig has been loaded from the upstream store
+	CachedAt time.Time `json:"cachedAt"`
+	// Hash is the hash of the kubeconfig
+	Hash string `json:"hash"`
+}
+
+func unmarshalDatabaseCacheCfg(ccfg *types.Cache) (databaseCacheCfg, error) {
+	var databaseCacheCfg databaseCacheCfg
+	if ccfg == nil || ccfg.Config == nil {
+		return databaseCacheCfg, nil
+	}
+	buf, err := yaml.Marshal(ccfg.Config)
+	if err != nil {
+		return databaseCacheCfg, fmt.Errorf("failed to marshal cache config: %w", err)
+	}
+	err = yaml.Unmarshal(buf, &databaseCacheCfg)
+	if err != nil {
+		return databaseCacheCfg, fmt.Errorf("cache config is invalid: %w", err)
+	}
+	return databaseCacheCfg, nil
+}
+
+// bucket returns the name of the bucket containing the kubeconfigs of the upstream store
+func (c *databaseCache) bucket() []byte {
+	return statedatabase.Key(c.upstream.GetID())
+}
+
+// removePlaintextBucket removes the kubeconfigs of the upstream store cached before the encryption has been enabled
+func (c *databaseCache) removePlaintextBucket(tx *bolt.Tx) error {
+	if !statedatabase.Encrypted() || tx.Bucket([]byte(c.upstream.GetID())) == nil {
+		return nil
+	}
+	return tx.DeleteBucket([]byte(c.upstream.GetID()))
+}
+
+// GetKubeconfigForPath returns the kubeconfig for the given path.
+// First, it checks if the kubeconfig is already available in cache.
+// If not, it is loaded from the upstream store and stored in cache
+func (c *databaseCache) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	c.logger.Debugf("Looking for '%s'", path)
+
+	cached, err := c.read(path)
+	if err != nil && !os.IsNotExist(err) {
+		c.logger.Debugf("failed to read '%s' from cache: %v", path, err)
+	}
+	if err == nil && cached != nil { // return cached kubeconfig if found
+		expiry := cache.CredentialsExpired(cached.Kubeconfig)
+		// in offline mode, kubeconfigs with expired credentials are served as well, as the store cannot be called
+		if expiry == nil || offline.Enabled() {
+			c.logger.Debugf("kubeconfig found in cache '%s'", path)
+			cache.RecordHit(c.upstream.GetID())
+			return cached.Kubeconfig, nil
+		}
+		c.logger.Debugf("credentials of the cached kubeconfig '%s' expired at %s", path, expiry.Format(time.RFC3339))
+	} else {
+		c.logger.Debugf("kubeconfig not found in cache '%s'", path)
+	}
+	cache.RecordMiss(c.upstream.GetID())
+	// kubeconfig not found in cache, load from upstream store
+	kubeconfig, err := c.upstream.GetKubeconfigForPath(path, tags)
+	if err != nil { // if the upstream returns an error, the result is not cached
+		return kubeconfig, err
+	}
+
+	// store the kubeconfig in the cache
+	if err := c.write(path, kubeconfig); err != nil {
+		return nil, err
+	}
+	return kubeconfig, nil
+}
+
+// read returns the cached entry for the given path or nil if the kubeconfig is not cached
+func (c *databaseCache) read(path string) (*entry, error) {
+	var cached *entry
+	err := statedatabase.With(c.path, true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(c.bucket())
+			if bucket == nil {
+				return nil
+			}
+			value := bucket.Get(statedatabase.Key(path))
+			if value == nil {
+				return nil
+			}
+			value, err := statedatabase.DecryptValue(value)
+			if err != nil {
+				return err
+			}
+			cached = &entry{}
+			return json.Unmarshal(value, cached)
+		})
+	})
+	return cached, err
+}
+
+// write stores the kubeconfig for the given path in the cache
+func (c *databaseCache) write(path string, kubeconfig []byte) error {
+	value, err := json.Marshal(entry{
+		Kubeconfig: kubeconfig,
+		CachedAt:   time.Now().UTC(),
+		Hash:       cache.Hash(kubeconfig),
+	})
+	if err != nil {
+		return err
+	}
+	value, err = statedatabase.EncryptValue(value)
+	if err != nil {
+		return err
+	}
+
+	err = statedatabase.With(c.path, false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			if err := c.removePlaintextBucket(tx); err != nil {
+				return err
+			}
+			bucket, err := tx.CreateBucketIfNotExists(c.bucket())
+			if err != nil {
+				return err
+			}
+			return bucket.Put(statedatabase.Key(path), value)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store kubeconfig in cache: %w", err)
+	}
+	return nil
+}
+
+// Verify compares the hash of the cached kubeconfig for the given path with the hash of the kubeconfig in the upstream store.
+// Only a changed kubeconfig is written to the cache again.
+func (c *databaseCache) Verify(path string, tags map[string]string) (*cache.Drift, error) {
+	cached, err := c.read(path)
+	if os.IsNotExist(err) || (err == nil && cached == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s' from cache: %w", path, err)
+	}
+
+	kubeconfig, err := c.upstream.GetKubeconfigForPath(path, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &cache.Drift{Path: path}
+	hash := cached.Hash
+	if len(hash) == 0 {
+		hash = cache.Hash(cached.Kubeconfig)
+	}
+	if hash == cache.Hash(kubeconfig) {
+		return drift, nil
+	}
+
+	drift.Changes = cache.Changes(cached.Kubeconfig, kubeconfig)
+	return drift, c.write(path, kubeconfig)
+}
+
+// Flush cache by deleting all kubeconfigs of the upstream store
+func (c *databaseCache) Flush() (int, error) {
+	deleted := 0
+	err := statedatabase.With(c.path, false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			if err := c.removePlaintextBucket(tx); err != nil {
+				return err
+			}
+			bucket := tx.Bucket(c.bucket())
+			if bucket == nil {
+				return nil
+			}
+			deleted = bucket.Stats().KeyN
+			return tx.DeleteBucket(c.bucket())
+		})
+	})
+	return deleted, err
+}
+
+// Evict deletes the cached kubeconfig for the given path, so that it is loaded from the upstream store again
+func (c *databaseCache) Evict(path string) (bool, error) {
+	evicted := false
+	err := statedatabase.With(c.path, false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(c.bucket())
+			if bucket == nil || bucket.Get(statedatabase.Key(path)) == nil {
+				return nil
+			}
+			evicted = true
+			return bucket.Delete(statedatabase.Key(path))
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete cached kubeconfig for '%s': %w", path, err)
+	}
+	return evicted, nil
+}
+
+// passthru requests to the upstream store
+
+func (c *databaseCache) GetID() string {
+	return c.upstream.GetID()
+}
+
+func (c *databaseCache) GetKind() types.StoreKind {
+	return c.upstream.GetKind()
+}
+
+func (c *databaseCache) GetContextPrefix(path string) string {
+	return c.upstream.GetContextPrefix(path)
+}
+
+func (c *databaseCache) VerifyKubeconfigPaths() error {
+	return c.upstream.VerifyKubeconfigPaths()
+}
+
+func (c *databaseCache) StartSearch(channel chan storetypes.SearchResult) {
+	c.upstream.StartSearch(channel)
+}
+
+func (c *databaseCache) GetLogger() *logrus.Entry {
+	return c.upstream.GetLogger()
+}
+
+func (c *databaseCache) GetStoreConfig() types.KubeconfigStore {
+	return c.upstream.GetStoreConfig()
+}
+
+func (c *databaseCache) GetSearchPreview(path string, optionalTags map[string]string) (string, error) {
+	previewer, ok := c.upstream.(storetypes.Previewer)
+	if !ok {
+		// if the wrapped store is not a previewer, simply return an empty string, hence causing no visual distortion
+		return "", nil
+	}
+
+	return previewer.GetSearchPreview(path, optionalTags)
+}
+
+func (c *databaseCache) GetConsoleURL(path string, tags map[string]string) (string, error) {
+	linker, ok := c.upstream.(storetypes.ConsoleLinker)
+	if !ok {
+		return "", storetypes.ErrConsoleNotSupported
+	}
+
+	return linker.GetConsoleURL(path, tags)
+}
+
+// MintKubeconfigForPath always mints new credentials, short-lived credentials are not cached
+func (c *databaseCache) MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	minter, ok := c.upstream.(storetypes.CredentialMinter)
+	if !ok {
+		return nil, storetypes.ErrMintingNotSupported
+	}
+
+	return minter.MintKubeconfigForPath(path, tags)
+}
+
+func (c *databaseCache) Login() (*time.Time, error) {
+	authenticator, ok := c.upstream.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+
+	return authenticator.Login()
+}
+
+func (c *databaseCache) CheckCredentials() (*time.Time, error) {
+	authenticator, ok := c.upstream.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+
+	return authenticator.CheckCredentials()
+}
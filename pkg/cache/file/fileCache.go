@@ -20,11 +20,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -56,13 +60,16 @@ func New(upstream storetypes.KubeconfigStore, ccfg *types.Cache) (storetypes.Kub
 		path = filepath.Join(homedir, path[2:])
 	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		if err := permissions.MkdirAll(path); err != nil {
 			return nil, fmt.Errorf("path: %s was not able to be created", path)
 		}
+	} else {
+		// kubeconfigs cached by previous versions may be accessible by other users
+		permissions.Enforce(path)
 	}
 	cfgStore.Paths = []string{path}
 
-	log := logrus.New().WithField("store", types.StoreKindFilesystem).WithField("cache", cacheKey)
+	log := logging.New().WithField("store", types.StoreKindFilesystem).WithField("cache", cacheKey)
 
 	return &fileCache{
 		upstream: upstream,
@@ -110,6 +117,12 @@ func (c *fileCache) suffix() string {
 	return fmt.Sprintf(".%s.%s", c.upstream.GetID(), kubeconfigSuffix)
 }
 
+// cacheFile returns the file the kubeconfig for the given path is cached in
+func (c *fileCache) cacheFile(path string) string {
+	cacheFilename := fmt.Sprintf("%s%s", c.hash(path), c.suffix())
+	return util.ExpandEnv(filepath.Join(c.cfg.Path, cacheFilename))
+}
+
 // GetKubeconfigForPath returns the kubeconfig for the given path.
 // First, it checks if the kubeconfig is already available in cache.
 // If not, it is loaded from the upstream store and stored in cache
@@ -117,16 +130,26 @@ func (c *fileCache) GetKubeconfigForPath(path string, tags map[string]string) ([
 	c.logger.Debugf("Looking for '%s'", path)
 
 	// check if kubeconfig is already available in the cache
-	cacheFilename := fmt.Sprintf("%s%s", c.hash(path), c.suffix())
-	file := filepath.Join(c.cfg.Path, cacheFilename)
-	file = util.ExpandEnv(file)
+	file := c.cacheFile(path)
 
 	k, err := kubeconfigutil.NewKubeconfigForPath(file)
 	if err == nil { // return cached kubeconfig if found
-		c.logger.Debugf("kubeconfig found in cache '%s'", path)
-		return k.GetBytes()
+		cached, err := k.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		expiry := cache.CredentialsExpired(cached)
+		// in offline mode, kubeconfigs with expired credentials are served as well, as the store cannot be called
+		if expiry == nil || offline.Enabled() {
+			c.logger.Debugf("kubeconfig found in cache '%s'", path)
+			cache.RecordHit(c.upstream.GetID())
+			return cached, nil
+		}
+		c.logger.Debugf("credentials of the cached kubeconfig '%s' expired at %s", path, expiry.Format(time.RFC3339))
+	} else {
+		c.logger.Debugf("kubeconfig not found in cache '%s'", path)
 	}
-	c.logger.Debugf("kubeconfig not found in cache '%s'", path)
+	cache.RecordMiss(c.upstream.GetID())
 	// kubeconfig not found in cache, load from upstream store
 	kubeconfig, err := c.upstream.GetKubeconfigForPath(path, tags)
 	if err != nil { // if the upstream returns an error, the result is not cached
@@ -143,6 +166,47 @@ func (c *fileCache) GetKubeconfigForPath(path string, tags map[string]string) ([
 	return kubeconfig, err
 }
 
+// Verify compares the cached kubeconfig for the given path with the kubeconfig in the upstream store.
+// Only a changed kubeconfig is written to the cache again.
+func (c *fileCache) Verify(path string, tags map[string]string) (*cache.Drift, error) {
+	file := c.cacheFile(path)
+	k, err := kubeconfigutil.NewKubeconfigForPath(file)
+	if err != nil {
+		// kubeconfig is not cached
+		return nil, nil
+	}
+	cached, err := k.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfig, err := c.upstream.GetKubeconfigForPath(path, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache file contains the re-encoded kubeconfig, hence compare it with the re-encoded upstream kubeconfig
+	k, err = kubeconfigutil.New(kubeconfig, file, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig '%s': %w", path, err)
+	}
+	upstream, err := k.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &cache.Drift{Path: path}
+	if cache.Hash(cached) == cache.Hash(upstream) {
+		return drift, nil
+	}
+
+	drift.Changes = cache.Changes(cached, upstream)
+	if _, err := k.WriteKubeconfigFile(); err != nil {
+		return nil, fmt.Errorf("failed to store kubeconfig in cache: %w", err)
+	}
+	return drift, nil
+}
+
 // Flush cache by deleting all files in the cache directory
 func (c *fileCache) Flush() (int, error) {
 	path := util.ExpandEnv(c.cfg.Path)
@@ -164,6 +228,18 @@ func (c *fileCache) Flush() (int, error) {
 	return deleted, nil
 }
 
+// Evict deletes the cached kubeconfig for the given path, so that it is loaded from the upstream store again
+func (c *fileCache) Evict(path string) (bool, error) {
+	err := os.Remove(c.cacheFile(path))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete cached kubeconfig for '%s': %w", path, err)
+	}
+	return true, nil
+}
+
 // passthru requests to the upstream store
 
 func (c *fileCache) GetID() string {
@@ -202,3 +278,40 @@ func (c *fileCache) GetSearchPreview(path string, optionalTags map[string]string
 
 	return previewer.GetSearchPreview(path, optionalTags)
 }
+
+func (c *fileCache) GetConsoleURL(path string, tags map[string]string) (string, error) {
+	linker, ok := c.upstream.(storetypes.ConsoleLinker)
+	if !ok {
+		return "", storetypes.ErrConsoleNotSupported
+	}
+
+	return linker.GetConsoleURL(path, tags)
+}
+
+// MintKubeconfigForPath always mints new credentials, short-lived credentials are not cached
+func (c *fileCache) MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	minter, ok := c.upstream.(storetypes.CredentialMinter)
+	if !ok {
+		return nil, storetypes.ErrMintingNotSupported
+	}
+
+	return minter.MintKubeconfigForPath(path, tags)
+}
+
+func (c *fileCache) Login() (*time.Time, error) {
+	authenticator, ok := c.upstream.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+
+	return authenticator.Login()
+}
+
+func (c *fileCache) CheckCredentials() (*time.Time, error) {
+	authenticator, ok := c.upstream.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+
+	return authenticator.CheckCredentials()
+}
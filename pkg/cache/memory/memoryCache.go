@@ -15,6 +15,8 @@
 package memory
 
 import (
+	"time"
+
 	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/types"
@@ -91,3 +93,40 @@ func (c *memoryCache) GetSearchPreview(path string, optionalTags map[string]stri
 
 	return previewer.GetSearchPreview(path, optionalTags)
 }
+
+func (c *memoryCache) GetConsoleURL(path string, tags map[string]string) (string, error) {
+	linker, ok := c.upstream.(storetypes.ConsoleLinker)
+	if !ok {
+		return "", storetypes.ErrConsoleNotSupported
+	}
+
+	return linker.GetConsoleURL(path, tags)
+}
+
+// MintKubeconfigForPath always mints new credentials, short-lived credentials are not cached
+func (c *memoryCache) MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	minter, ok := c.upstream.(storetypes.CredentialMinter)
+	if !ok {
+		return nil, storetypes.ErrMintingNotSupported
+	}
+
+	return minter.MintKubeconfigForPath(path, tags)
+}
+
+func (c *memoryCache) Login() (*time.Time, error) {
+	authenticator, ok := c.upstream.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+
+	return authenticator.Login()
+}
+
+func (c *memoryCache) CheckCredentials() (*time.Time, error) {
+	authenticator, ok := c.upstream.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+
+	return authenticator.CheckCredentials()
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// copyKubeconfigToClipboard copies the kubeconfig of the context to the clipboard, materialized like when switching to the context.
+// The kubeconfigs of several contexts are merged into a single kubeconfig like by "switch export".
+func copyKubeconfigToClipboard(storeIDToStore map[string]storetypes.KubeconfigStore, config *types.Config, stateDir string, contextNames []string) (string, error) {
+	if len(contextNames) != 1 {
+		return copyMergedKubeconfigToClipboard(storeIDToStore, config, stateDir, contextNames)
+	}
+
+	options, err := materializeOptionsForContext(storeIDToStore, contextNames[0])
+	if err != nil {
+		return "", err
+	}
+	options.Portable = true
+
+	kubeconfig, err := MaterializeKubeconfig(config, stateDir, options)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := kubeconfig.GetBytes()
+	if err != nil {
+		return "", err
+	}
+
+	if err := util.CopyToClipboard(string(content)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("copied the kubeconfig of %s to the clipboard", contextNames[0]), nil
+}
+
+// copyMergedKubeconfigToClipboard merges the kubeconfigs of the contexts and copies the merged kubeconfig to the clipboard
+func copyMergedKubeconfigToClipboard(storeIDToStore map[string]storetypes.KubeconfigStore, config *types.Config, stateDir string, contextNames []string) (string, error) {
+	merged := clientcmdapi.NewConfig()
+	for _, contextName := range contextNames {
+		options, err := materializeOptionsForContext(storeIDToStore, contextName)
+		if err != nil {
+			return "", err
+		}
+		if err := AddContextToKubeconfig(merged, contextName, config, stateDir, options); err != nil {
+			return "", fmt.Errorf("failed to merge the kubeconfig of %s: %v", contextName, err)
+		}
+	}
+	merged.CurrentContext = contextNames[0]
+
+	content, err := clientcmd.Write(*merged)
+	if err != nil {
+		return "", err
+	}
+
+	if err := util.CopyToClipboard(string(content)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("copied the merged kubeconfig of %d contexts to the clipboard", len(contextNames)), nil
+}
+
+// materializeOptionsForContext returns the options to materialize the kubeconfig of the context shown in the picker
+func materializeOptionsForContext(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) (MaterializeOptions, error) {
+	path := readFromContextToPathMapping(contextName)
+	kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(path)]
+	if !ok {
+		return MaterializeOptions{}, fmt.Errorf("unknown kubeconfig store of context %q", contextName)
+	}
+
+	options := MaterializeOptions{
+		Store:       kubeconfigStore,
+		Path:        path,
+		Tags:        readFromPathToTagsMapping(path),
+		ContextName: contextName,
+	}
+	if original := readFromAliasToContext(contextName); len(original) > 0 {
+		options.ContextName, options.Alias = original, contextName
+	}
+	return options, nil
+}
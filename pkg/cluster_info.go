@@ -0,0 +1,150 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// clusterInfoTimeout is the maximum duration to wait for the API server when retrieving the cluster info
+	clusterInfoTimeout = 3 * time.Second
+	// probeTimeout is the maximum duration to wait for a connection to the API server when probing the cluster
+	probeTimeout = 2 * time.Second
+)
+
+// getClusterInfo queries the cluster of the context and returns its Kubernetes version and node count.
+// Returns an error if the cluster is not reachable.
+func getClusterInfo(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) (string, error) {
+	version, nodes, err := queryCluster(storeIDToStore, contextName)
+	if err != nil {
+		logger.Debugf("failed to get cluster info for context %s: %v", contextName, err)
+		return "", err
+	}
+	if nodes < 0 {
+		return version, nil
+	}
+	return fmt.Sprintf("%s • %d nodes", version, nodes), nil
+}
+
+// probeCluster connects to the API server of the context and returns the round-trip latency.
+// Only establishes a TCP connection, hence does not require valid credentials.
+func probeCluster(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) (time.Duration, error) {
+	restConfig, err := getRestConfig(storeIDToStore, contextName)
+	if err != nil {
+		return 0, err
+	}
+
+	server, err := url.Parse(restConfig.Host)
+	if err != nil {
+		return 0, fmt.Errorf("invalid API server URL %q: %v", restConfig.Host, err)
+	}
+
+	address := server.Host
+	if len(server.Port()) == 0 {
+		port := "443"
+		if server.Scheme == "http" {
+			port = "80"
+		}
+		address = net.JoinHostPort(server.Hostname(), port)
+	}
+
+	start := time.Now()
+	connection, err := net.DialTimeout("tcp", address, probeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	_ = connection.Close()
+	return latency, nil
+}
+
+func queryCluster(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) (string, int, error) {
+	restConfig, err := getRestConfig(storeIDToStore, contextName)
+	if err != nil {
+		return "", 0, err
+	}
+	restConfig.Timeout = clusterInfoTimeout
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", 0, err
+	}
+
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterInfoTimeout)
+	defer cancel()
+
+	// resource version 0 serves the nodes from the watch cache of the API server
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		// the version is still useful, e.g. if the user is not allowed to list nodes
+		logger.Debugf("failed to list nodes of context %s: %v", contextName, err)
+		return version.GitVersion, -1, nil
+	}
+	return version.GitVersion, len(nodes.Items), nil
+}
+
+// getRestConfig returns the client configuration for the context from the kubeconfig in the store
+func getRestConfig(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) (*rest.Config, error) {
+	path := readFromContextToPathMapping(contextName)
+	kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(path)]
+	if !ok {
+		return nil, fmt.Errorf("unknown kubeconfig store")
+	}
+
+	// the context name in the kubeconfig file is not aliased
+	name := contextName
+	if original := readFromAliasToContext(contextName); len(original) > 0 {
+		name = original
+	}
+	return getRestConfigForPath(kubeconfigStore, path, readFromPathToTagsMapping(path), name)
+}
+
+// getRestConfigForPath returns the client configuration for the context from the kubeconfig with the path in the store
+func getRestConfigForPath(kubeconfigStore storetypes.KubeconfigStore, path string, tags map[string]string, contextName string) (*rest.Config, error) {
+	kubeconfigData, err := kubeconfigStore.GetKubeconfigForPath(path, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := clientcmd.Load(kubeconfigData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+
+	// the context name in the kubeconfig file is not prefixed
+	name := contextName
+	if prefix := kubeconfigStore.GetContextPrefix(path); len(prefix) > 0 {
+		name = strings.TrimPrefix(name, fmt.Sprintf("%s/", prefix))
+	}
+
+	return clientcmd.NewNonInteractiveClientConfig(*config, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+}
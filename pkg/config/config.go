@@ -21,11 +21,15 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/config/migration"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
 // LoadConfigFromFile takes a filename and de-serializes the contents into a Configuration object.
-func LoadConfigFromFile(filepath string) (*types.Config, error) {
+// A SwitchConfig in the legacy format is converted to the current format.
+// Only if migrateInPlace is set, the file is overwritten with the converted SwitchConfig (after copying it to <filename>.old).
+// This must only be set for the SwitchConfig of the user, never for system-wide, project or included files.
+func LoadConfigFromFile(filepath string, migrateInPlace bool) (*types.Config, error) {
 	// a config file is not required. Its ok if it does not exist.
 	if _, err := os.Stat(filepath); err != nil {
 		if os.IsNotExist(err) {
@@ -45,23 +49,36 @@ func LoadConfigFromFile(filepath string) (*types.Config, error) {
 	}
 
 	err = yaml.Unmarshal(bytes, &config)
-	// if version field is not set, it is an old config
-	// check that kubeconfig stores are not set to avoid migrating a new config
+	// if version field is not set, it may be an old config.
+	// Only configs with the kubeconfigPaths of the old format are migrated, as e.g. included files do not need a version.
 	if err != nil || (len(config.Version) == 0 && len(config.KubeconfigStores) == 0) {
-		// try with old config
-		oldConfig := &types.ConfigOld{}
-		err = yaml.Unmarshal(bytes, &oldConfig)
-		if err == nil && oldConfig != nil {
+		if oldConfig, ok := parseOldConfig(bytes); ok {
+			if !migrateInPlace {
+				newConfig := migration.ConvertConfiguration(*oldConfig)
+				return &newConfig, nil
+			}
 			return MigrateConfig(*oldConfig, filepath)
 		}
+	}
+	if err != nil {
 		return nil, fmt.Errorf("could not unmarshal config with path '%s': %v", filepath, err)
 	}
+	ExpandConfig(config)
 	return config, nil
 }
 
+// parseOldConfig returns the config if it is in the format used before the introduction of kubeconfig stores
+func parseOldConfig(bytes []byte) (*types.ConfigOld, bool) {
+	oldConfig := &types.ConfigOld{}
+	if err := yaml.Unmarshal(bytes, &oldConfig); err != nil || oldConfig == nil || len(oldConfig.KubeconfigPaths) == 0 {
+		return nil, false
+	}
+	return oldConfig, true
+}
+
 func MigrateConfig(old types.ConfigOld, filename string) (*types.Config, error) {
 	// first, copy the old configuration
-	file, err := os.Create(fmt.Sprintf("%s.old", filename))
+	file, err := os.OpenFile(fmt.Sprintf("%s.old", filename), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, permissions.FileMode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate SwitchConfig file: %w", err)
 	}
@@ -79,7 +96,7 @@ func MigrateConfig(old types.ConfigOld, filename string) (*types.Config, error)
 
 	// then overwrite the configuration with the new format
 	new := migration.ConvertConfiguration(old)
-	fileNew, err := os.Create(filename)
+	fileNew, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, permissions.FileMode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate SwitchConfig file: %w", err)
 	}
@@ -0,0 +1,216 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// envVariableReference matches ${VAR} as well as the escaped form $${VAR}
+var envVariableReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templateFuncs are the functions available in templates in SwitchConfig values
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"homeDir": func() (string, error) {
+		return os.UserHomeDir()
+	},
+	"hostname": func() (string, error) {
+		return os.Hostname()
+	},
+	"user": func() (string, error) {
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	},
+	"os": func() string {
+		return runtime.GOOS
+	},
+	"arch": func() string {
+		return runtime.GOARCH
+	},
+	"default": func(defaultValue, value string) string {
+		if len(value) == 0 {
+			return defaultValue
+		}
+		return value
+	},
+}
+
+// ExpandConfig expands environment variables (${VAR}) in the paths, IDs and prefixes of the SwitchConfig:
+// the includes, the IDs, paths and configuration of the kubeconfig stores and the kubeconfig stores referenced by profiles.
+// Other values, e.g. hook commands, are expanded where they are used, so that they see the environment at that time.
+// Environment variables that are not set are left untouched. $${VAR} can be used to keep a literal ${VAR}.
+// Go templates are not expanded here, but where the value is used, see ExpandString and ExpandStoreTemplates.
+func ExpandConfig(config *types.Config) {
+	expand := func(value string) (string, error) {
+		return expandEnv(value, nil), nil
+	}
+
+	for i, include := range config.Includes {
+		config.Includes[i] = expandEnv(include, nil)
+	}
+
+	for i := range config.KubeconfigStores {
+		store := &config.KubeconfigStores[i]
+		if store.ID != nil {
+			id := expandEnv(*store.ID, nil)
+			store.ID = &id
+		}
+		for j, path := range store.Paths {
+			store.Paths[j] = expandEnv(path, nil)
+		}
+		// expanding environment variables cannot fail
+		store.Config, _ = expandValue(store.Config, "config", expand)
+	}
+
+	for i := range config.Profiles {
+		for j, store := range config.Profiles[i].Stores {
+			config.Profiles[i].Stores[j] = expandEnv(store, nil)
+		}
+	}
+}
+
+// ExpandStoreTemplates expands the Go templates ({{ env "VAR" }}) in the paths and the configuration of the kubeconfig store.
+// Called when the kubeconfig store is created, so that only the templates of the used kubeconfig stores are expanded.
+func ExpandStoreTemplates(store *types.KubeconfigStore) error {
+	expand := func(value string) (string, error) {
+		if !strings.Contains(value, "{{") {
+			return value, nil
+		}
+		return expandTemplate(value, nil)
+	}
+
+	paths := make([]string, 0, len(store.Paths))
+	for _, path := range store.Paths {
+		expanded, err := expand(path)
+		if err != nil {
+			return fmt.Errorf("failed to expand path %q of kubeconfig store %q: %v", path, store.Kind, err)
+		}
+		paths = append(paths, expanded)
+	}
+	store.Paths = paths
+
+	config, err := expandValue(store.Config, "config", expand)
+	if err != nil {
+		return fmt.Errorf("failed to expand configuration of kubeconfig store %q: %v", store.Kind, err)
+	}
+	store.Config = config
+	return nil
+}
+
+// expandValue returns a copy of the value with all string values expanded.
+// Expanded values remain strings, e.g. an account ID "012345678901" must not become a number.
+func expandValue(value interface{}, path string, expand func(string) (string, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		expanded, err := expand(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand value of %q: %v", path, err)
+		}
+		return expanded, nil
+	case map[interface{}]interface{}:
+		expandedMap := make(map[interface{}]interface{}, len(v))
+		for key, item := range v {
+			childPath := fmt.Sprintf("%v", key)
+			if len(path) > 0 {
+				childPath = fmt.Sprintf("%s.%v", path, key)
+			}
+
+			expanded, err := expandValue(item, childPath, expand)
+			if err != nil {
+				return nil, err
+			}
+			expandedMap[key] = expanded
+		}
+		return expandedMap, nil
+	case []interface{}:
+		expandedList := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			expanded, err := expandValue(item, fmt.Sprintf("%s[%d]", path, i), expand)
+			if err != nil {
+				return nil, err
+			}
+			expandedList = append(expandedList, expanded)
+		}
+		return expandedList, nil
+	default:
+		return value, nil
+	}
+}
+
+// ValidateTemplate checks if the value is a valid template for ExpandString
+func ValidateTemplate(value string) error {
+	_, err := parseTemplate(value)
+	return err
+}
+
+func parseTemplate(value string) (*template.Template, error) {
+	return template.New("value").Funcs(templateFuncs).Option("missingkey=error").Parse(value)
+}
+
+// ExpandString expands environment variables (${VAR}) and Go templates in the value.
+// data is passed to the template, env contains additional environment variables.
+func ExpandString(value string, data interface{}, env map[string]string) (string, error) {
+	if strings.Contains(value, "{{") {
+		expanded, err := expandTemplate(value, data)
+		if err != nil {
+			return "", err
+		}
+		value = expanded
+	}
+	return expandEnv(value, env), nil
+}
+
+func expandTemplate(value string, data interface{}) (string, error) {
+	tmpl, err := parseTemplate(value)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// expandEnv replaces the environment variables (${VAR}) in the value. env takes precedence over the environment of the process.
+func expandEnv(value string, env map[string]string) string {
+	return envVariableReference.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		name := envVariableReference.FindStringSubmatch(match)[1]
+		if variable, ok := env[name]; ok {
+			return variable
+		}
+		if variable, ok := os.LookupEnv(name); ok {
+			return variable
+		}
+		return match
+	})
+}
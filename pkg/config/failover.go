@@ -0,0 +1,61 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"slices"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// FailoverStores returns the kubeconfig stores referenced in the failoverStores of the kubeconfig store in the configured order
+func FailoverStores(config *types.Config, store types.KubeconfigStore) []types.KubeconfigStore {
+	var stores []types.KubeconfigStore
+	for _, reference := range store.FailoverStores {
+		for _, failover := range config.KubeconfigStores {
+			if StoreMatchesReference(failover, reference) {
+				stores = append(stores, failover)
+				break
+			}
+		}
+	}
+	return stores
+}
+
+// IsFailoverStore returns true if the kubeconfig store is a failover store of another kubeconfig store of the configuration.
+// Failover stores are only used as part of the chain of the other kubeconfig store.
+func IsFailoverStore(config *types.Config, store types.KubeconfigStore) bool {
+	for _, primary := range config.KubeconfigStores {
+		for _, reference := range primary.FailoverStores {
+			if StoreMatchesReference(store, reference) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withFailoverStores adds the failover stores of the selected kubeconfig stores, so that a selected store keeps its failover chain
+func withFailoverStores(config *types.Config, selected []types.KubeconfigStore) []types.KubeconfigStore {
+	stores := slices.Clone(selected)
+	for _, store := range selected {
+		for _, failover := range FailoverStores(config, store) {
+			if !slices.ContainsFunc(stores, func(s types.KubeconfigStore) bool { return storeKey(s) == storeKey(failover) }) {
+				stores = append(stores, failover)
+			}
+		}
+	}
+	return stores
+}
@@ -0,0 +1,285 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// ProjectConfigFileName is the name of the project-specific SwitchConfig file.
+// It is searched in the current working directory and its parent directories.
+const ProjectConfigFileName = ".switch-config.yaml"
+
+// projectConfigFields are the fields of the SwitchConfig that a project SwitchConfig may set.
+// A project SwitchConfig is picked up from any parent directory, e.g. of a cloned repository. Hence, it must not set fields
+// that run code (e.g. hooks, keybindings, exec and plugin kubeconfig stores or cmd:// secret references), send data elsewhere (e.g. webhooks)
+// or weaken the protection configured by the user (e.g. protected contexts or the kubeconfig policy).
+var projectConfigFields = sets.New(
+	"kind",
+	"version",
+	"showPreview",
+	"picker",
+	"showClusterInfo",
+	"showReachability",
+	"kubernetesVersion",
+	"prefetchKubeconfigs",
+	"sortOrder",
+	"resultsView",
+	"matching",
+	"caseSensitivity",
+	"searchMetadata",
+	"showStoreIcons",
+	"storeIcons",
+	"collisionSuffix",
+	"aliasRules",
+	"duplicateClusters",
+	"previewTemplate",
+	"environments",
+	"excludePatterns",
+	"profiles",
+)
+
+// SystemConfigPath returns the path to the system-wide SwitchConfig file
+func SystemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "kubeswitch", "switch-config.yaml")
+	}
+	return "/etc/kubeswitch/switch-config.yaml"
+}
+
+// LoadConfig loads the SwitchConfig files from the search path and merges them.
+// The search path is (in order of increasing precedence)
+//   - the system-wide SwitchConfig (see SystemConfigPath)
+//   - the user SwitchConfig at the given path
+//   - the project SwitchConfig (see ProjectConfigFileName), only if enabled via "loadProjectConfig" in the system or user SwitchConfig.
+//     The project SwitchConfig cannot include other files and only the fields in projectConfigFields are used.
+//
+// Each file may include other files via "includes". Included files are merged before the including file.
+// Returns nil if none of the files exist.
+func LoadConfig(userConfigPath string) (*types.Config, error) {
+	var merged *types.Config
+	for _, path := range []string{SystemConfigPath(), userConfigPath} {
+		// only the SwitchConfig of the user is migrated in place, the others are converted in memory
+		config, err := loadConfigWithIncludes(path, path == userConfigPath, sets.New[string]())
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeConfig(merged, config)
+	}
+
+	if merged == nil || merged.LoadProjectConfig == nil || !*merged.LoadProjectConfig {
+		return merged, nil
+	}
+
+	projectConfigPath, err := findProjectConfig()
+	if err != nil || len(projectConfigPath) == 0 || sameFile(projectConfigPath, userConfigPath) {
+		return merged, err
+	}
+
+	config, err := LoadConfigFromFile(projectConfigPath, false)
+	if err != nil || config == nil {
+		return merged, err
+	}
+	if ignored := restrictProjectConfig(config); len(ignored) > 0 {
+		logrus.Warnf("Ignoring %s of the project SwitchConfig %q. These fields can only be set in the system-wide or user SwitchConfig.", strings.Join(ignored, ", "), projectConfigPath)
+	}
+	return mergeConfig(merged, config), nil
+}
+
+// restrictProjectConfig unsets the fields of the project SwitchConfig that are not in projectConfigFields and returns their names
+func restrictProjectConfig(config *types.Config) []string {
+	var ignored []string
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if projectConfigFields.Has(name) || value.Field(i).IsZero() {
+			continue
+		}
+		ignored = append(ignored, name)
+		value.Field(i).Set(reflect.Zero(value.Field(i).Type()))
+	}
+	return ignored
+}
+
+// loadConfigWithIncludes loads the SwitchConfig file and merges the included files into it.
+// The included files are never migrated in place.
+func loadConfigWithIncludes(path string, migrateInPlace bool, visited sets.Set[string]) (*types.Config, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if visited.Has(absolutePath) {
+		return nil, fmt.Errorf("SwitchConfig %q is included recursively", path)
+	}
+	visited.Insert(absolutePath)
+	defer visited.Delete(absolutePath)
+
+	config, err := LoadConfigFromFile(absolutePath, migrateInPlace)
+	if err != nil || config == nil {
+		return config, err
+	}
+
+	var merged *types.Config
+	for _, include := range config.Includes {
+		includePaths, err := resolveInclude(filepath.Dir(absolutePath), include)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve include %q of SwitchConfig %q: %v", include, path, err)
+		}
+
+		for _, includePath := range includePaths {
+			includedConfig, err := loadConfigWithIncludes(includePath, false, visited)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeConfig(merged, includedConfig)
+		}
+	}
+
+	merged = mergeConfig(merged, config)
+	merged.Includes = nil
+	return merged, nil
+}
+
+// resolveInclude returns the files matching the include.
+// Relative includes are resolved relative to the directory of the including file.
+// Includes may contain glob patterns. A pattern without matches is not an error, a missing file is.
+func resolveInclude(dir, include string) ([]string, error) {
+	include = util.ExpandEnv(include)
+	if !filepath.IsAbs(include) {
+		include = filepath.Join(dir, include)
+	}
+
+	if !strings.ContainsAny(include, "*?[") {
+		if _, err := os.Stat(include); err != nil {
+			return nil, err
+		}
+		return []string{include}, nil
+	}
+
+	matches, err := filepath.Glob(include)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// findProjectConfig searches the project SwitchConfig in the current working directory and its parent directories
+func findProjectConfig() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// mergeConfig merges the override into the base configuration.
+//   - fields set in the override replace the fields of the base
+//   - kubeconfig stores with the same kind and ID are replaced, other kubeconfig stores are appended
+//   - hooks, profiles and environments with the same name are replaced, others are appended
+func mergeConfig(base, override *types.Config) *types.Config {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	merged := *base
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	overrideValue := reflect.ValueOf(override).Elem()
+	for i := 0; i < mergedValue.NumField(); i++ {
+		if !overrideValue.Field(i).IsZero() {
+			mergedValue.Field(i).Set(overrideValue.Field(i))
+		}
+	}
+
+	merged.KubeconfigStores = mergeByKey(base.KubeconfigStores, override.KubeconfigStores, func(store types.KubeconfigStore) string {
+		id := ""
+		if store.ID != nil {
+			id = *store.ID
+		}
+		return fmt.Sprintf("%s:%s", store.Kind, id)
+	})
+	merged.Hooks = mergeByKey(base.Hooks, override.Hooks, func(hook types.Hook) string {
+		return hook.Name
+	})
+	merged.Profiles = mergeByKey(base.Profiles, override.Profiles, func(profile types.Profile) string {
+		return profile.Name
+	})
+	merged.Environments = mergeByKey(base.Environments, override.Environments, func(environment types.Environment) string {
+		return environment.Name
+	})
+	return &merged
+}
+
+func mergeByKey[T any](base, override []T, key func(T) string) []T {
+	merged := append([]T{}, base...)
+	for _, item := range override {
+		replaced := false
+		for i := range merged {
+			if key(merged[i]) == key(item) {
+				merged[i] = item
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, item)
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
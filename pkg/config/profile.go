@@ -0,0 +1,101 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// EnvProfile is the environment variable selecting the profile if the flag --profile is not set
+	EnvProfile = "SWITCH_PROFILE"
+	// EnvStores is the environment variable selecting the kubeconfig stores if the flag --stores is not set
+	EnvStores = "SWITCH_STORES"
+)
+
+// StoreMatchesReference returns true if the kubeconfig store is referenced by the given reference.
+// A kubeconfig store is referenced by its ID, its kind (if the store has no ID) or "<kind>.<id>".
+func StoreMatchesReference(store types.KubeconfigStore, reference string) bool {
+	return reference == storeKey(store) ||
+		(store.ID != nil && reference == *store.ID) ||
+		(store.ID == nil && reference == string(store.Kind))
+}
+
+// GetProfile returns the profile with the given name
+func GetProfile(config *types.Config, name string) (*types.Profile, error) {
+	var names []string
+	for i, profile := range config.Profiles {
+		if profile.Name == name {
+			return &config.Profiles[i], nil
+		}
+		names = append(names, profile.Name)
+	}
+	return nil, fmt.Errorf("profile %q is not defined in the SwitchConfig. Defined profiles: %q", name, names)
+}
+
+// ApplyProfile removes all kubeconfig stores that are not part of the profile with the given name from the configuration
+func ApplyProfile(config *types.Config, name string) error {
+	profile, err := GetProfile(config, name)
+	if err != nil {
+		return err
+	}
+
+	var stores []types.KubeconfigStore
+	for _, store := range config.KubeconfigStores {
+		for _, reference := range profile.Stores {
+			if StoreMatchesReference(store, reference) {
+				stores = append(stores, store)
+				break
+			}
+		}
+	}
+	config.KubeconfigStores = withFailoverStores(config, stores)
+	return nil
+}
+
+// SelectStores removes all kubeconfig stores from the configuration that are not selected.
+// A selector is either a store kind (selects all stores of this kind) or a reference to a single store (see StoreMatchesReference).
+// Fails if a selector does not match any kubeconfig store.
+func SelectStores(config *types.Config, selectors []string) error {
+	var (
+		stores  []types.KubeconfigStore
+		matched = make([]bool, len(selectors))
+	)
+	for _, store := range config.KubeconfigStores {
+		selected := false
+		for i, selector := range selectors {
+			selector = strings.TrimSpace(selector)
+			if selector == string(store.Kind) || StoreMatchesReference(store, selector) {
+				matched[i] = true
+				selected = true
+			}
+		}
+		if selected {
+			stores = append(stores, store)
+		}
+	}
+
+	for i, selector := range selectors {
+		if !matched[i] {
+			return fmt.Errorf("no kubeconfig store matches %q", selector)
+		}
+	}
+
+	config.KubeconfigStores = withFailoverStores(config, stores)
+	return nil
+}
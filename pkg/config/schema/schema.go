@@ -0,0 +1,111 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	schemaVersion = "http://json-schema.org/draft-07/schema#"
+	// SchemaID is the ID of the JSON schema of the SwitchConfig
+	SchemaID = "https://raw.githubusercontent.com/danielfoehrkn/kubeswitch/master/resources/switch-config.schema.json"
+	// durationPattern matches Go durations such as "1h30m"
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+)
+
+// Generate returns the JSON schema of the SwitchConfig
+func Generate() ([]byte, error) {
+	root := schemaFor(reflect.TypeOf(types.Config{}))
+	root["$schema"] = schemaVersion
+	root["$id"] = SchemaID
+	root["title"] = "SwitchConfig"
+
+	// the store-specific configuration depends on the kind of the kubeconfig store
+	storeSchema := root["properties"].(map[string]interface{})["kubeconfigStores"].(map[string]interface{})["items"].(map[string]interface{})
+	kinds := make([]string, 0, len(storeConfigTypes))
+	for kind := range storeConfigTypes {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+
+	var conditions []interface{}
+	for _, kind := range kinds {
+		conditions = append(conditions, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{"kind": map[string]interface{}{"const": kind}},
+				"required":   []string{"kind"},
+			},
+			"then": map[string]interface{}{
+				"properties": map[string]interface{}{"config": schemaFor(storeConfigTypes[types.StoreKind(kind)])},
+			},
+		})
+	}
+	storeSchema["allOf"] = conditions
+	storeSchema["required"] = []string{"kind"}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON schema: %v", err)
+	}
+	return append(out, '\n'), nil
+}
+
+func schemaFor(t reflect.Type) map[string]interface{} {
+	t = deref(t)
+
+	if t == durationType {
+		return map[string]interface{}{"type": "string", "pattern": durationPattern}
+	}
+
+	if values, ok := enums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		names, fields := yamlFields(t)
+		properties := map[string]interface{}{}
+		for _, name := range names {
+			properties[name] = schemaFor(fields[name].Type)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	default:
+		// interface{}: any value
+		return map[string]interface{}{}
+	}
+}
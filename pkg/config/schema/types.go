@@ -0,0 +1,102 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	// storeConfigTypes are the types of the store-specific configuration of each kubeconfig store kind.
+	// Kinds without store-specific configuration are not contained.
+	storeConfigTypes = map[types.StoreKind]reflect.Type{
+		types.StoreKindVault:    reflect.TypeOf(types.StoreConfigVault{}),
+		types.StoreKindGardener: reflect.TypeOf(types.StoreConfigGardener{}),
+		types.StoreKindGKE:      reflect.TypeOf(types.StoreConfigGKE{}),
+		types.StoreKindAzure:    reflect.TypeOf(types.StoreConfigAzure{}),
+		types.StoreKindEKS:      reflect.TypeOf(types.StoreConfigEKS{}),
+		types.StoreKindExoscale: reflect.TypeOf(types.StoreConfigExoscale{}),
+		types.StoreKindRancher:  reflect.TypeOf(types.StoreConfigRancher{}),
+		types.StoreKindOVH:      reflect.TypeOf(types.StoreConfigOVH{}),
+		types.StoreKindScaleway: reflect.TypeOf(types.StoreConfigScaleway{}),
+		types.StoreKindAkamai:   reflect.TypeOf(types.StoreConfigAkamai{}),
+		types.StoreKindCapi:     reflect.TypeOf(types.StoreConfigCapi{}),
+		types.StoreKindPlugin:   reflect.TypeOf(types.StoreConfigPlugin{}),
+		types.StoreKindExec:     reflect.TypeOf(types.StoreConfigExec{}),
+		types.StoreKindMock:     reflect.TypeOf(types.StoreConfigMock{}),
+	}
+
+	// enums are the allowed values of string types
+	enums = map[reflect.Type][]string{
+		reflect.TypeOf(types.StoreKind("")):             types.ValidStoreKinds.List(),
+		reflect.TypeOf(types.HookType("")):              types.ValidHookTypes.List(),
+		reflect.TypeOf(types.Picker("")):                types.ValidPickers.List(),
+		reflect.TypeOf(types.PickerAction("")):          types.ValidPickerActions.List(),
+		reflect.TypeOf(types.SortOrder("")):             types.ValidSortOrders.List(),
+		reflect.TypeOf(types.ResultsView("")):           types.ValidResultsViews.List(),
+		reflect.TypeOf(types.MatchingAlgorithm("")):     types.ValidMatchingAlgorithms.List(),
+		reflect.TypeOf(types.CaseSensitivity("")):       types.ValidCaseSensitivities.List(),
+		reflect.TypeOf(types.CollisionSuffix("")):       types.ValidCollisionSuffixes.List(),
+		reflect.TypeOf(types.DuplicateClusters("")):     types.ValidDuplicateClusters.List(),
+		reflect.TypeOf(types.NotifyMode("")):            types.ValidNotifyModes.List(),
+		reflect.TypeOf(types.AccessCheckMode("")):       types.ValidAccessCheckModes.List(),
+		reflect.TypeOf(types.SyncKind("")):              types.ValidSyncKinds.List(),
+		reflect.TypeOf(types.WebhookFormat("")):         types.ValidWebhookFormats.List(),
+		reflect.TypeOf(types.DashboardTool("")):         types.ValidDashboardTools.List(),
+		reflect.TypeOf(types.EncryptionKeySource("")):   types.ValidEncryptionKeySources.List(),
+		reflect.TypeOf(types.HookTrigger("")):           types.ValidHookTriggers.List(),
+		reflect.TypeOf(types.HookShell("")):             types.ValidHookShells.List(),
+		reflect.TypeOf(types.GKEPreferredEndpoint("")):  {string(types.GkePrivateEndpoint), string(types.GkePublicEndpoint), string(types.GkeDnsEndpoint)},
+		reflect.TypeOf(types.GCPAuthenticationType("")): {string(types.GcloudAuthentication), string(types.APIKeyAuthentication), string(types.ServiceAccountAuthentication), string(types.LegacyAuthentication)},
+	}
+)
+
+// yamlFields returns the fields of the struct type by their yaml name
+func yamlFields(t reflect.Type) ([]string, map[string]reflect.StructField) {
+	var (
+		names  []string
+		fields = map[string]reflect.StructField{}
+	)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			// default of gopkg.in/yaml.v2
+			name = strings.ToLower(f.Name)
+		}
+		names = append(names, name)
+		fields[name] = f
+	}
+	return names, fields
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,257 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/config/validation"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var (
+	kubeconfigStoreType = reflect.TypeOf(types.KubeconfigStore{})
+	// fieldPathIndex matches the index of a field path element, e.g. "kubeconfigStores[0]"
+	fieldPathIndex = regexp.MustCompile(`^([^\[]*)\[(\d+)\]$`)
+	// yaml11Bools are booleans in YAML 1.1 (used by gopkg.in/yaml.v2 to read the SwitchConfig), but strings in YAML 1.2
+	yaml11Bools = sets.New("y", "Y", "yes", "Yes", "YES", "n", "N", "no", "No", "NO", "on", "On", "ON", "off", "Off", "OFF")
+)
+
+// Issue is a problem found in the SwitchConfig
+type Issue struct {
+	// Line is the line in the SwitchConfig file. 0 if unknown
+	Line int
+	// Field is the path of the field, e.g. "kubeconfigStores[0].kind"
+	Field string
+	// Message describes the problem
+	Message string
+}
+
+func (i Issue) String() string {
+	if len(i.Field) == 0 {
+		return i.Message
+	}
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s", i.Field, i.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", i.Line, i.Field, i.Message)
+}
+
+// Validate validates the SwitchConfig against the schema and reports unknown fields, type errors
+// and semantic errors (e.g. missing required store options) with their line numbers.
+func Validate(data []byte) ([]Issue, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("the SwitchConfig is not valid YAML: %v", err)
+	}
+
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	root := document.Content[0]
+
+	var issues []Issue
+	walk(root, reflect.TypeOf(types.Config{}), "", &issues)
+
+	// semantic validation requires a config that can be unmarshalled
+	if len(issues) > 0 {
+		return issues, nil
+	}
+
+	config := &types.Config{}
+	if err := yamlv2.Unmarshal(data, config); err != nil {
+		return append(issues, Issue{Message: err.Error()}), nil
+	}
+	switchconfig.ExpandConfig(config)
+
+	for _, err := range validation.ValidateConfig(config) {
+		issues = append(issues, Issue{
+			Line:    lineOf(root, err.Field),
+			Field:   err.Field,
+			Message: err.ErrorBody(),
+		})
+	}
+	return issues, nil
+}
+
+func walk(node *yaml.Node, t reflect.Type, path string, issues *[]Issue) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+
+	t = deref(t)
+	report := func(format string, args ...interface{}) {
+		*issues = append(*issues, Issue{Line: node.Line, Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// values with environment variables or templates can only be checked after expansion
+	if node.Kind == yaml.ScalarNode && isTemplated(node.Value) {
+		return
+	}
+
+	if t == durationType {
+		if node.Kind != yaml.ScalarNode {
+			report("expected a duration, e.g. \"24h\"")
+		} else if _, err := time.ParseDuration(node.Value); err != nil && node.Tag != "!!int" {
+			report("invalid duration %q, e.g. \"24h\"", node.Value)
+		}
+		return
+	}
+
+	if values, ok := enums[t]; ok {
+		if node.Kind != yaml.ScalarNode || !slices.Contains(values, node.Value) {
+			report("invalid value %q. Valid values are %q", node.Value, values)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		// every scalar can be unmarshalled into a string
+		if node.Kind != yaml.ScalarNode {
+			report("expected a string")
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!bool" && !yaml11Bools.Has(node.Value)) {
+			report("expected a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			report("expected an integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			report("expected a number")
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			report("expected a list")
+			return
+		}
+		for i, item := range node.Content {
+			walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			report("expected an object")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walk(node.Content[i+1], t.Elem(), childPath(path, node.Content[i].Value), issues)
+		}
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			report("expected an object")
+			return
+		}
+
+		_, fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			f, ok := fields[key.Value]
+			if !ok {
+				*issues = append(*issues, Issue{Line: key.Line, Field: childPath(path, key.Value), Message: "unknown field"})
+				continue
+			}
+
+			fieldType := f.Type
+			if t == kubeconfigStoreType && key.Value == "config" {
+				kind := valueOf(node, "kind")
+				if kind == nil {
+					continue
+				}
+				storeConfigType, ok := storeConfigTypes[types.StoreKind(kind.Value)]
+				if !ok {
+					continue
+				}
+				fieldType = storeConfigType
+			}
+			walk(value, fieldType, childPath(path, key.Value), issues)
+		}
+	}
+}
+
+// lineOf returns the line of the node with the given field path, e.g. "kubeconfigStores[0].paths".
+// If the node does not exist, the line of the closest existing parent is returned.
+func lineOf(root *yaml.Node, fieldPath string) int {
+	node, line := root, root.Line
+	for _, element := range strings.Split(fieldPath, ".") {
+		name, index := element, -1
+		if match := fieldPathIndex.FindStringSubmatch(element); match != nil {
+			name = match[1]
+			index, _ = strconv.Atoi(match[2])
+		}
+
+		if len(name) > 0 {
+			key, value := keyValueOf(node, name)
+			if value == nil {
+				return line
+			}
+			node, line = value, key.Line
+		}
+
+		if index >= 0 {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return line
+			}
+			node = node.Content[index]
+			line = node.Line
+		}
+	}
+	return line
+}
+
+func valueOf(mapNode *yaml.Node, key string) *yaml.Node {
+	_, value := keyValueOf(mapNode, key)
+	return value
+}
+
+func keyValueOf(mapNode *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapNode.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapNode.Content); i += 2 {
+		if mapNode.Content[i].Value == key {
+			return mapNode.Content[i], mapNode.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func childPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", path, name)
+}
+
+func isTemplated(value string) bool {
+	return strings.Contains(value, "${") || strings.Contains(value, "{{")
+}
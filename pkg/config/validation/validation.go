@@ -16,12 +16,25 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
 
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/credentials"
 	gardenerstore "github.com/danielfoehrkn/kubeswitch/pkg/store/gardener"
 	gkestore "github.com/danielfoehrkn/kubeswitch/pkg/store/gke"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -42,6 +55,48 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 		errors = append(errors, field.Invalid(field.NewPath("version"), config.Version, fmt.Sprintf("Config version %q is unknown. Valid versions are %q", config.Version, types.ValidConfigVersions)))
 	}
 
+	if config.Picker != nil && !types.ValidPickers.Has(string(*config.Picker)) {
+		errors = append(errors, field.Invalid(field.NewPath("picker"), *config.Picker, fmt.Sprintf("Picker %q is unknown. Valid pickers are %q", *config.Picker, types.ValidPickers)))
+	}
+
+	if config.SortOrder != nil && !types.ValidSortOrders.Has(string(*config.SortOrder)) {
+		errors = append(errors, field.Invalid(field.NewPath("sortOrder"), *config.SortOrder, fmt.Sprintf("Sort order %q is unknown. Valid sort orders are %q", *config.SortOrder, types.ValidSortOrders)))
+	}
+
+	if config.ResultsView != nil && !types.ValidResultsViews.Has(string(*config.ResultsView)) {
+		errors = append(errors, field.Invalid(field.NewPath("resultsView"), *config.ResultsView, fmt.Sprintf("Results view %q is unknown. Valid results views are %q", *config.ResultsView, types.ValidResultsViews)))
+	}
+
+	if config.Matching != nil && !types.ValidMatchingAlgorithms.Has(string(*config.Matching)) {
+		errors = append(errors, field.Invalid(field.NewPath("matching"), *config.Matching, fmt.Sprintf("Matching algorithm %q is unknown. Valid matching algorithms are %q", *config.Matching, types.ValidMatchingAlgorithms)))
+	}
+
+	if config.CaseSensitivity != nil && !types.ValidCaseSensitivities.Has(string(*config.CaseSensitivity)) {
+		errors = append(errors, field.Invalid(field.NewPath("caseSensitivity"), *config.CaseSensitivity, fmt.Sprintf("Case sensitivity %q is unknown. Valid case sensitivities are %q", *config.CaseSensitivity, types.ValidCaseSensitivities)))
+	}
+
+	if config.PrefetchKubeconfigs != nil && *config.PrefetchKubeconfigs < 0 {
+		errors = append(errors, field.Invalid(field.NewPath("prefetchKubeconfigs"), *config.PrefetchKubeconfigs, "the number of prefetched kubeconfigs must not be negative"))
+	}
+
+	if config.CollisionSuffix != nil && !types.ValidCollisionSuffixes.Has(string(*config.CollisionSuffix)) {
+		errors = append(errors, field.Invalid(field.NewPath("collisionSuffix"), *config.CollisionSuffix, fmt.Sprintf("Collision suffix %q is unknown. Valid suffixes are %q", *config.CollisionSuffix, types.ValidCollisionSuffixes)))
+	}
+
+	if config.DuplicateClusters != nil && !types.ValidDuplicateClusters.Has(string(*config.DuplicateClusters)) {
+		errors = append(errors, field.Invalid(field.NewPath("duplicateClusters"), *config.DuplicateClusters, fmt.Sprintf("Mode %q is unknown. Valid modes are %q", *config.DuplicateClusters, types.ValidDuplicateClusters)))
+	}
+
+	if config.Notify != nil && !types.ValidNotifyModes.Has(string(*config.Notify)) {
+		errors = append(errors, field.Invalid(field.NewPath("notify"), *config.Notify, fmt.Sprintf("Notify mode %q is unknown. Valid modes are %q", *config.Notify, types.ValidNotifyModes)))
+	}
+
+	if config.PreviewTemplate != nil {
+		if err := switchconfig.ValidateTemplate(*config.PreviewTemplate); err != nil {
+			errors = append(errors, field.Invalid(field.NewPath("previewTemplate"), *config.PreviewTemplate, fmt.Sprintf("Preview template cannot be parsed: %v", err)))
+		}
+	}
+
 	for i, kubeconfigStore := range config.KubeconfigStores {
 		id := kubeconfigStore.ID
 		if kubeconfigStore.ID == nil {
@@ -56,6 +111,10 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 
 		indexFieldPath := storesPath.Index(i)
 
+		if kubeconfigStore.StaleWhileRevalidate != nil && *kubeconfigStore.StaleWhileRevalidate && !storeUsesIndex {
+			errors = append(errors, field.Invalid(indexFieldPath.Child("staleWhileRevalidate"), *kubeconfigStore.StaleWhileRevalidate, "staleWhileRevalidate requires the kubeconfig store to use an index. Please set refreshIndexAfter for the kubeconfig store or globally"))
+		}
+
 		if !types.ValidStoreKinds.Has(string(kubeconfigStore.Kind)) {
 			errors = append(errors, field.Invalid(indexFieldPath.Child("kind"), kubeconfigStore.Kind, fmt.Sprintf("kind %q of kubeconfig store is unknown. Valid kinds are %q", kubeconfigStore.Kind, types.ValidStoreKinds)))
 		}
@@ -81,6 +140,79 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 			errors = append(errors, errorList...)
 		}
 
+		if kubeconfigStore.Kind == types.StoreKindExec {
+			errors = append(errors, validateExecStore(indexFieldPath.Child("config"), kubeconfigStore)...)
+		}
+
+		if kubeconfigStore.Kind == types.StoreKindMock {
+			errors = append(errors, validateMockStore(indexFieldPath.Child("config"), kubeconfigStore)...)
+		}
+
+		if kubeconfigStore.Kind == types.StoreKindExoscale {
+			errors = append(errors, validateExoscaleStore(indexFieldPath.Child("config"), kubeconfigStore)...)
+		}
+
+		errors = append(errors, validateSecretReferences(indexFieldPath.Child("config"), kubeconfigStore)...)
+
+		errors = append(errors, validateExcludePatterns(indexFieldPath.Child("excludePatterns"), kubeconfigStore.ExcludePatterns)...)
+
+		if kubeconfigStore.SearchTimeout != nil && *kubeconfigStore.SearchTimeout <= 0 {
+			errors = append(errors, field.Invalid(indexFieldPath.Child("searchTimeout"), kubeconfigStore.SearchTimeout.String(), "the timeout has to be positive"))
+		}
+
+		if kubeconfigStore.MaxConcurrency != nil && *kubeconfigStore.MaxConcurrency <= 0 {
+			errors = append(errors, field.Invalid(indexFieldPath.Child("maxConcurrency"), *kubeconfigStore.MaxConcurrency, "the maximum concurrency has to be positive"))
+		}
+
+		if kubeconfigStore.LogLevel != nil {
+			errors = append(errors, validateLogLevel(indexFieldPath.Child("logLevel"), *kubeconfigStore.LogLevel)...)
+		}
+
+		if kubeconfigStore.OIDC != nil {
+			errors = append(errors, validateOIDC(indexFieldPath.Child("oidc"), *kubeconfigStore.OIDC)...)
+		}
+
+		if kubeconfigStore.CloudflareAccess != nil {
+			if kubeconfigStore.OIDC != nil {
+				errors = append(errors, field.Forbidden(indexFieldPath.Child("cloudflareAccess"), "the credentials are replaced either by the OIDC login or the Cloudflare Access login"))
+			}
+			if application := kubeconfigStore.CloudflareAccess.Application; application != nil {
+				if u, err := url.Parse(*application); err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+					errors = append(errors, field.Invalid(indexFieldPath.Child("cloudflareAccess", "application"), *application, "the application has to be an HTTPS URL"))
+				}
+			}
+		}
+
+		if kubeconfigStore.KubeconfigProvider != nil {
+			errors = append(errors, validateKubeconfigProvider(indexFieldPath.Child("kubeconfigProvider"), kubeconfigStore)...)
+		}
+
+		if kubeconfigStore.Proxy != nil {
+			errors = append(errors, validateProxy(indexFieldPath.Child("proxy"), *kubeconfigStore.Proxy)...)
+		}
+
+		if kubeconfigStore.RateLimit != nil {
+			errors = append(errors, validateRateLimit(indexFieldPath.Child("rateLimit"), *kubeconfigStore.RateLimit)...)
+		}
+
+		if kubeconfigStore.HTTPClient != nil {
+			errors = append(errors, validateHTTPClient(indexFieldPath.Child("httpClient"), kubeconfigStore)...)
+		}
+
+		if len(kubeconfigStore.CloudTags) > 0 {
+			errors = append(errors, validateCloudTags(indexFieldPath.Child("cloudTags"), kubeconfigStore)...)
+		}
+
+		if len(kubeconfigStore.FailoverStores) > 0 {
+			errors = append(errors, validateFailoverStores(indexFieldPath.Child("failoverStores"), config, kubeconfigStore)...)
+		}
+
+		if kubeconfigStore.ContextNameTemplate != nil {
+			if err := switchconfig.ValidateTemplate(*kubeconfigStore.ContextNameTemplate); err != nil {
+				errors = append(errors, field.Invalid(indexFieldPath.Child("contextNameTemplate"), *kubeconfigStore.ContextNameTemplate, fmt.Sprintf("Context name template cannot be parsed: %v", err)))
+			}
+		}
+
 		// if the kubeconfig store uses an index, we need to specify a unique ID for the kubeconfigStore to write a unique index file name
 		if storeUsesIndex && storeKinds.Has(fmt.Sprintf("%s:%s", kubeconfigStore.Kind, *id)) {
 			errors = append(errors, field.Invalid(indexFieldPath.Child("id"), id, fmt.Sprintf("there are multiple kubeconfig stores with the same Kind %q configured. "+
@@ -92,21 +224,350 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 	}
 
 	if len(config.Hooks) > 0 {
-		errors = append(errors, validateHooks(field.NewPath("hooks"), config.Hooks)...)
+		errors = append(errors, validateHooks(field.NewPath("hooks"), config)...)
 	}
 
+	if len(config.Profiles) > 0 {
+		errors = append(errors, validateProfiles(field.NewPath("profiles"), config)...)
+	}
+
+	if config.Clean != nil {
+		errors = append(errors, validateClean(field.NewPath("clean"), *config.Clean)...)
+	}
+
+	if config.Audit != nil {
+		errors = append(errors, validateAudit(field.NewPath("audit"), *config.Audit)...)
+	}
+
+	for i, webhook := range config.Webhooks {
+		errors = append(errors, validateWebhook(field.NewPath("webhooks").Index(i), webhook, len(config.ProtectedContexts) > 0)...)
+	}
+
+	if config.Sync != nil {
+		errors = append(errors, validateSync(field.NewPath("sync"), *config.Sync)...)
+	}
+
+	if config.AccessCheck != nil {
+		errors = append(errors, validateAccessCheck(field.NewPath("accessCheck"), *config.AccessCheck)...)
+	}
+
+	if config.KubernetesVersion != nil && config.KubernetesVersion.MinSupported != nil {
+		if _, err := version.ParseGeneric(*config.KubernetesVersion.MinSupported); err != nil {
+			errors = append(errors, field.Invalid(field.NewPath("kubernetesVersion", "minSupported"), *config.KubernetesVersion.MinSupported, fmt.Sprintf("invalid Kubernetes version: %v", err)))
+		}
+	}
+
+	if config.Offline != nil {
+		errors = append(errors, validateOffline(field.NewPath("offline"), *config.Offline)...)
+	}
+
+	if config.SharedIndex != nil {
+		errors = append(errors, validateSharedIndex(field.NewPath("sharedIndex"), *config.SharedIndex)...)
+	}
+
+	errors = append(errors, validateExcludePatterns(field.NewPath("excludePatterns"), config.ExcludePatterns)...)
+
+	if config.SearchConcurrency != nil && *config.SearchConcurrency < 1 {
+		errors = append(errors, field.Invalid(field.NewPath("searchConcurrency"), *config.SearchConcurrency, "at least one kubeconfig store has to be searched at a time"))
+	}
+
+	if config.SearchBatchSize != nil && *config.SearchBatchSize < 1 {
+		errors = append(errors, field.Invalid(field.NewPath("searchBatchSize"), *config.SearchBatchSize, "the batch size has to be at least one context"))
+	}
+
+	if config.SearchTimeout != nil && *config.SearchTimeout <= 0 {
+		errors = append(errors, field.Invalid(field.NewPath("searchTimeout"), config.SearchTimeout.String(), "the timeout has to be positive"))
+	}
+
+	if config.CircuitBreaker != nil {
+		path := field.NewPath("circuitBreaker")
+		if config.CircuitBreaker.Failures != nil && *config.CircuitBreaker.Failures < 1 {
+			errors = append(errors, field.Invalid(path.Child("failures"), *config.CircuitBreaker.Failures, "at least one failed search is required to skip a kubeconfig store"))
+		}
+		if config.CircuitBreaker.Cooldown != nil && *config.CircuitBreaker.Cooldown <= 0 {
+			errors = append(errors, field.Invalid(path.Child("cooldown"), config.CircuitBreaker.Cooldown.String(), "the cooldown has to be positive"))
+		}
+	}
+
+	if config.LogLevel != nil {
+		errors = append(errors, validateLogLevel(field.NewPath("logLevel"), *config.LogLevel)...)
+	}
+
+	if config.LogFormat != nil && *config.LogFormat != types.LogFormatConsole && *config.LogFormat != types.LogFormatJSON {
+		errors = append(errors, field.NotSupported(field.NewPath("logFormat"), *config.LogFormat, []string{string(types.LogFormatConsole), string(types.LogFormatJSON)}))
+	}
+
+	if config.HTTPTransport != nil {
+		errors = append(errors, validateHTTPTransport(field.NewPath("httpTransport"), *config.HTTPTransport)...)
+	}
+
+	if config.EncryptIndex != nil {
+		errors = append(errors, validateEncryptionKey(field.NewPath("encryptIndex"), *config.EncryptIndex)...)
+	}
+
+	if config.TemporaryKubeconfigEncryptionKey != nil {
+		errors = append(errors, validateEncryptionKey(field.NewPath("temporaryKubeconfigEncryptionKey"), *config.TemporaryKubeconfigEncryptionKey)...)
+	}
+
+	if config.Dashboard != nil {
+		errors = append(errors, validateDashboard(field.NewPath("dashboard"), *config.Dashboard)...)
+	}
+
+	if len(config.Keybindings) > 0 {
+		errors = append(errors, validateKeybindings(field.NewPath("keybindings"), config.Keybindings)...)
+	}
+
+	errors = append(errors, validateAliasRules(field.NewPath("aliasRules"), config)...)
+
+	for kind := range config.StoreIcons {
+		if !types.ValidStoreKinds.Has(string(kind)) {
+			errors = append(errors, field.Invalid(field.NewPath("storeIcons").Key(string(kind)), kind, fmt.Sprintf("kind %q of kubeconfig store is unknown. Valid kinds are %q", kind, types.ValidStoreKinds)))
+		}
+	}
+
+	for i, pattern := range config.ProtectedContexts {
+		if len(strings.TrimSpace(pattern)) == 0 {
+			errors = append(errors, field.Required(field.NewPath("protectedContexts").Index(i), "the pattern of a protected context must not be empty"))
+		}
+	}
+
+	if config.KubeconfigPolicy != nil {
+		errors = append(errors, validateKubeconfigPolicy(field.NewPath("kubeconfigPolicy"), config.KubeconfigPolicy)...)
+	}
+
+	for i, repositoryContext := range config.RepositoryContexts {
+		path := field.NewPath("repositoryContexts").Index(i)
+		if repositoryContext.Remote == nil && repositoryContext.Path == nil {
+			errors = append(errors, field.Required(path, "either the remote or the path of the repository has to be provided"))
+		}
+		if len(repositoryContext.Context) == 0 {
+			errors = append(errors, field.Required(path.Child("context"), "the context of the repository has to be provided"))
+		}
+	}
+
+	if len(config.Environments) > 0 {
+		errors = append(errors, validateEnvironments(field.NewPath("environments"), config.Environments)...)
+	}
+
+	for i, proxy := range config.Proxies {
+		path := field.NewPath("proxies").Index(i)
+		if len(proxy.Contexts) == 0 {
+			errors = append(errors, field.Required(path.Child("contexts"), "at least one pattern of the contexts using the proxy has to be provided"))
+		}
+		errors = append(errors, validateProxy(path, proxy)...)
+	}
+
+	for i, target := range config.BoundaryTargets {
+		errors = append(errors, validateBoundaryTarget(field.NewPath("boundaryTargets").Index(i), target)...)
+	}
+
+	return errors
+}
+
+// validateBoundaryTarget validates that the target matches contexts and is given by ID or by name and scope
+func validateBoundaryTarget(path *field.Path, target types.BoundaryTarget) field.ErrorList {
+	var errors field.ErrorList
+
+	if len(target.Contexts) == 0 {
+		errors = append(errors, field.Required(path.Child("contexts"), "at least one pattern of the contexts using the Boundary target has to be provided"))
+	}
+
+	switch {
+	case len(target.TargetID) > 0 && len(target.TargetName) > 0:
+		errors = append(errors, field.Forbidden(path.Child("targetName"), "the target is given either by ID or by name"))
+	case len(target.TargetID) == 0 && len(target.TargetName) == 0:
+		errors = append(errors, field.Required(path.Child("targetID"), "either the target ID or the target name has to be provided"))
+	case len(target.TargetName) > 0 && len(target.TargetScopeID) == 0 && len(target.TargetScopeName) == 0:
+		errors = append(errors, field.Required(path.Child("targetScopeID"), "the target name requires the target scope ID or name"))
+	}
+
+	if target.StartTimeout != nil && *target.StartTimeout <= 0 {
+		errors = append(errors, field.Invalid(path.Child("startTimeout"), target.StartTimeout.String(), "the timeout has to be positive"))
+	}
+	return errors
+}
+
+// validateProxyURL validates that the proxy URL is a http, https or socks5 URL with a host
+func validateProxyURL(path *field.Path, value string) field.ErrorList {
+	var errors field.ErrorList
+
+	proxyURL, err := url.Parse(value)
+	switch {
+	case err != nil:
+		errors = append(errors, field.Invalid(path, value, err.Error()))
+	case proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5":
+		errors = append(errors, field.Invalid(path, value, "the scheme of the proxy URL has to be http, https or socks5"))
+	case len(proxyURL.Host) == 0:
+		errors = append(errors, field.Invalid(path, value, "the proxy URL has to contain a host"))
+	}
+	return errors
+}
+
+// validateProxy validates the proxy URL and that the SSH or cloudflared tunnel provides a local SOCKS5 proxy
+func validateProxy(path *field.Path, proxy types.Proxy) field.ErrorList {
+	var errors = validateProxyURL(path.Child("url"), proxy.URL)
+	if (proxy.SSH == nil && proxy.Cloudflared == nil) || len(errors) > 0 {
+		return errors
+	}
+
+	if proxy.SSH != nil && proxy.Cloudflared != nil {
+		return append(errors, field.Forbidden(path.Child("cloudflared"), "a proxy is provided either by an SSH tunnel or a cloudflared tunnel"))
+	}
+	if proxyURL, _ := url.Parse(proxy.URL); proxyURL.Scheme != "socks5" || len(proxyURL.Port()) == 0 {
+		errors = append(errors, field.Invalid(path.Child("url"), proxy.URL, "the tunnel provides a SOCKS5 proxy, the proxy URL has to be socks5://<host>:<port>"))
+	}
+
+	if proxy.SSH != nil {
+		if len(proxy.SSH.Host) == 0 {
+			errors = append(errors, field.Required(path.Child("ssh", "host"), "the jump host of the SSH tunnel has to be provided"))
+		}
+		if proxy.SSH.StartTimeout != nil && *proxy.SSH.StartTimeout <= 0 {
+			errors = append(errors, field.Invalid(path.Child("ssh", "startTimeout"), proxy.SSH.StartTimeout.String(), "the timeout has to be positive"))
+		}
+		return errors
+	}
+
+	if len(proxy.Cloudflared.Hostname) == 0 {
+		errors = append(errors, field.Required(path.Child("cloudflared", "hostname"), "the hostname of the Cloudflare Access application has to be provided"))
+	}
+	if proxy.Cloudflared.StartTimeout != nil && *proxy.Cloudflared.StartTimeout <= 0 {
+		errors = append(errors, field.Invalid(path.Child("cloudflared", "startTimeout"), proxy.Cloudflared.StartTimeout.String(), "the timeout has to be positive"))
+	}
+	return errors
+}
+
+// validateKubeconfigPolicy validates the proxy URL and the maximum credentials TTL of the kubeconfig policy
+func validateKubeconfigPolicy(path *field.Path, policy *types.KubeconfigPolicy) field.ErrorList {
+	var errors field.ErrorList
+
+	if policy.ProxyURL != nil {
+		errors = append(errors, validateProxyURL(path.Child("proxyURL"), *policy.ProxyURL)...)
+	}
+
+	if policy.MaxCredentialsTTL != nil && *policy.MaxCredentialsTTL <= 0 {
+		errors = append(errors, field.Invalid(path.Child("maxCredentialsTTL"), policy.MaxCredentialsTTL.String(), "the maximum credentials TTL has to be positive"))
+	}
+	return errors
+}
+
+// validateEnvironments validates that each environment has a unique name, a valid color and matches contexts
+func validateEnvironments(path *field.Path, environments []types.Environment) field.ErrorList {
+	var (
+		errors = field.ErrorList{}
+		names  = sets.New[string]()
+	)
+
+	for i, environment := range environments {
+		if len(environment.Name) == 0 {
+			errors = append(errors, field.Required(path.Index(i).Child("name"), "the name of the environment has to be provided"))
+		} else if names.Has(environment.Name) {
+			errors = append(errors, field.Duplicate(path.Index(i).Child("name"), environment.Name))
+		}
+		names.Insert(environment.Name)
+
+		if !theme.IsValidColor(environment.Color) {
+			errors = append(errors, field.Invalid(path.Index(i).Child("color"), environment.Color, "the color has to be a color name, an ANSI 256 color code or a hex color"))
+		}
+
+		if len(environment.Contexts) == 0 && len(environment.Tags) == 0 {
+			errors = append(errors, field.Required(path.Index(i), "either context patterns or tags have to be provided"))
+		}
+	}
+	return errors
+}
+
+// validateAliasRules validates that each alias rule has a valid template and only references configured kubeconfig stores
+func validateAliasRules(path *field.Path, config *types.Config) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	for i, rule := range config.AliasRules {
+		if len(strings.TrimSpace(rule.Template)) == 0 {
+			errors = append(errors, field.Required(path.Index(i).Child("template"), "the template generating the alias has to be provided"))
+		} else if err := switchconfig.ValidateTemplate(rule.Template); err != nil {
+			errors = append(errors, field.Invalid(path.Index(i).Child("template"), rule.Template, fmt.Sprintf("Template cannot be parsed: %v", err)))
+		}
+
+		for j, pattern := range rule.Contexts {
+			if len(strings.TrimSpace(pattern)) == 0 {
+				errors = append(errors, field.Required(path.Index(i).Child("contexts").Index(j), "the pattern must not be empty"))
+			}
+		}
+
+		errors = append(errors, validateStoreReferences(path.Index(i).Child("stores"), config, rule.Stores)...)
+	}
+	return errors
+}
+
+// keybindingTemplateError is returned for keybinding commands containing templates
+const keybindingTemplateError = "commands are passed to the shell as is and cannot contain templates. Use the environment variables instead, e.g. \"$KUBESWITCH_CONTEXT\""
+
+// validateKeybindings validates that each key is bound once, either to a valid action, a command or a command to open
+func validateKeybindings(path *field.Path, keybindings []types.Keybinding) field.ErrorList {
+	var (
+		errors = field.ErrorList{}
+		keys   = sets.New[string]()
+	)
+
+	for i, keybinding := range keybindings {
+		if len(keybinding.Key) == 0 {
+			errors = append(errors, field.Required(path.Index(i).Child("key"), "the key has to be provided"))
+		} else if keys.Has(keybinding.Key) {
+			errors = append(errors, field.Duplicate(path.Index(i).Child("key"), keybinding.Key))
+		}
+		keys.Insert(keybinding.Key)
+
+		bound := 0
+		for _, set := range []bool{keybinding.Action != nil, keybinding.Command != nil, keybinding.Open != nil} {
+			if set {
+				bound++
+			}
+		}
+
+		switch {
+		case bound == 0:
+			errors = append(errors, field.Required(path.Index(i), "either an action, a command or a command to open has to be provided"))
+		case bound > 1:
+			errors = append(errors, field.Invalid(path.Index(i), keybinding.Key, "only one of action, command and open can be provided"))
+		case keybinding.Action != nil && !types.ValidPickerActions.Has(string(*keybinding.Action)):
+			errors = append(errors, field.Invalid(path.Index(i).Child("action"), *keybinding.Action, fmt.Sprintf("Unknown action. Valid actions are %q", types.ValidPickerActions)))
+		case keybinding.Command != nil && strings.Contains(*keybinding.Command, "{{"):
+			errors = append(errors, field.Invalid(path.Index(i).Child("command"), *keybinding.Command, keybindingTemplateError))
+		case keybinding.Open != nil && strings.Contains(*keybinding.Open, "{{"):
+			errors = append(errors, field.Invalid(path.Index(i).Child("open"), *keybinding.Open, keybindingTemplateError))
+		}
+	}
 	return errors
 }
 
 // validateHooks validates hook configuration
-func validateHooks(path *field.Path, hooks []types.Hook) field.ErrorList {
+func validateHooks(path *field.Path, config *types.Config) field.ErrorList {
 	var errors = field.ErrorList{}
 
-	for i, hook := range hooks {
+	for i, hook := range config.Hooks {
 		if !types.ValidHookTypes.Has(string(hook.Type)) {
 			errors = append(errors, field.Invalid(path.Index(i).Child("type"), hook.Type, fmt.Sprintf("Unknown hook type. Valid hook types are %q", types.ValidHookTypes)))
 		}
 
+		if len(hook.Trigger) > 0 && !types.ValidHookTriggers.Has(string(hook.Trigger)) {
+			errors = append(errors, field.Invalid(path.Index(i).Child("trigger"), hook.Trigger, fmt.Sprintf("Unknown hook trigger. Valid hook triggers are %q", types.ValidHookTriggers.List())))
+		}
+
+		if !hook.IsPreSearch() && hook.Execution != nil && hook.Execution.Interval != nil {
+			errors = append(errors, field.Forbidden(path.Index(i).Child("execution", "interval"), "an interval can only be set for hooks with trigger \"PreSearch\""))
+		}
+
+		if hook.Execution != nil {
+			if hook.Execution.Timeout != nil && *hook.Execution.Timeout <= 0 {
+				errors = append(errors, field.Invalid(path.Index(i).Child("execution", "timeout"), hook.Execution.Timeout.String(), "the timeout has to be positive"))
+			}
+
+			if hook.Execution.Retries != nil && *hook.Execution.Retries < 0 {
+				errors = append(errors, field.Invalid(path.Index(i).Child("execution", "retries"), *hook.Execution.Retries, "the number of retries must not be negative"))
+			}
+		}
+
+		errors = append(errors, validateStoreReferences(path.Index(i).Child("stores"), config, hook.Stores)...)
+
 		if hook.Type == types.HookTypeExecutable && hook.Path == nil {
 			errors = append(errors, field.Required(path.Index(i).Child("path"), "Path to the hook executable has to be provided"))
 		}
@@ -114,6 +575,612 @@ func validateHooks(path *field.Path, hooks []types.Hook) field.ErrorList {
 		if hook.Type == types.HookTypeInlineCommand && len(hook.Arguments) == 0 {
 			errors = append(errors, field.Required(path.Index(i).Child("arguments"), "arguments have to be provided for a hook with an inline command"))
 		}
+
+		if hook.Type == types.HookTypeInlineCommand {
+			for j, argument := range hook.Arguments {
+				if strings.Contains(argument, "{{") {
+					errors = append(errors, field.Invalid(path.Index(i).Child("arguments").Index(j), argument, "inline commands are passed to the shell as is and cannot contain templates. Use the environment variables instead, e.g. \"$KUBESWITCH_CONTEXT\""))
+				}
+			}
+		}
+
+		if hook.Shell != nil {
+			if hook.Type != types.HookTypeInlineCommand {
+				errors = append(errors, field.Forbidden(path.Index(i).Child("shell"), "a shell can only be set for hooks of type \"InlineCommand\""))
+			} else if !types.ValidHookShells.Has(string(*hook.Shell)) {
+				errors = append(errors, field.NotSupported(path.Index(i).Child("shell"), *hook.Shell, types.ValidHookShells.List()))
+			}
+		}
+
+		errors = append(errors, validateTerminalTitleHook(path.Index(i), hook)...)
+	}
+	return errors
+}
+
+// validateTerminalTitleHook validates that the title and the badge are only set for hooks of type "TerminalTitle",
+// which are executed after a switch
+func validateTerminalTitleHook(path *field.Path, hook types.Hook) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if hook.Type != types.HookTypeTerminalTitle {
+		if hook.Title != nil {
+			errors = append(errors, field.Forbidden(path.Child("title"), "a title can only be set for hooks of type \"TerminalTitle\""))
+		}
+		if hook.Badge != nil {
+			errors = append(errors, field.Forbidden(path.Child("badge"), "a badge can only be set for hooks of type \"TerminalTitle\""))
+		}
+		return errors
+	}
+
+	if !hook.IsPostSwitch() {
+		errors = append(errors, field.Invalid(path.Child("trigger"), hook.Trigger, "hooks of type \"TerminalTitle\" can only be executed after a switch (trigger \"PostSwitch\")"))
+	}
+
+	if hook.Path != nil {
+		errors = append(errors, field.Forbidden(path.Child("path"), "hooks of type \"TerminalTitle\" do not execute a command"))
+	}
+	if len(hook.Arguments) > 0 {
+		errors = append(errors, field.Forbidden(path.Child("arguments"), "hooks of type \"TerminalTitle\" do not execute a command"))
+	}
+
+	if hook.Title != nil {
+		if err := switchconfig.ValidateTemplate(*hook.Title); err != nil {
+			errors = append(errors, field.Invalid(path.Child("title"), *hook.Title, fmt.Sprintf("the template cannot be parsed: %v", err)))
+		}
+	}
+	if hook.Badge != nil {
+		if err := switchconfig.ValidateTemplate(*hook.Badge); err != nil {
+			errors = append(errors, field.Invalid(path.Child("badge"), *hook.Badge, fmt.Sprintf("the template cannot be parsed: %v", err)))
+		}
+	}
+	return errors
+}
+
+// validateProfiles validates that the profiles have unique names and only reference configured kubeconfig stores
+func validateProfiles(path *field.Path, config *types.Config) field.ErrorList {
+	var (
+		errors = field.ErrorList{}
+		names  = sets.New[string]()
+	)
+
+	for i, profile := range config.Profiles {
+		if len(profile.Name) == 0 {
+			errors = append(errors, field.Required(path.Index(i).Child("name"), "the name of the profile has to be provided"))
+		} else if names.Has(profile.Name) {
+			errors = append(errors, field.Duplicate(path.Index(i).Child("name"), profile.Name))
+		}
+		names.Insert(profile.Name)
+
+		errors = append(errors, validateStoreReferences(path.Index(i).Child("stores"), config, profile.Stores)...)
+	}
+	return errors
+}
+
+// validateStoreReferences validates that the references only reference configured kubeconfig stores
+func validateStoreReferences(path *field.Path, config *types.Config, references []string) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	// the referenced stores may be defined in an included file
+	if len(config.Includes) > 0 {
+		return errors
+	}
+
+	for i, reference := range references {
+		if !slices.ContainsFunc(config.KubeconfigStores, func(store types.KubeconfigStore) bool {
+			return switchconfig.StoreMatchesReference(store, reference)
+		}) {
+			errors = append(errors, field.NotFound(path.Index(i), reference))
+		}
+	}
+	return errors
+}
+
+// validateFailoverStores validates that the failover stores are configured and do not form nested chains
+func validateFailoverStores(path *field.Path, config *types.Config, store types.KubeconfigStore) field.ErrorList {
+	var errors = validateStoreReferences(path, config, store.FailoverStores)
+
+	for i, reference := range store.FailoverStores {
+		if switchconfig.StoreMatchesReference(store, reference) {
+			errors = append(errors, field.Invalid(path.Index(i), reference, "a kubeconfig store cannot be its own failover store"))
+			continue
+		}
+		for _, failover := range config.KubeconfigStores {
+			if switchconfig.StoreMatchesReference(failover, reference) && len(failover.FailoverStores) > 0 {
+				errors = append(errors, field.Invalid(path.Index(i), reference, "a failover store cannot have failover stores itself"))
+			}
+		}
+	}
+	return errors
+}
+
+// validateKubeconfigProvider validates that the store can mint short-lived credentials if the kubeconfigs call the exec credential plugin
+func validateKubeconfigProvider(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors field.ErrorList
+
+	provider := *store.KubeconfigProvider
+	if !types.ValidKubeconfigProviders.Has(string(provider)) {
+		return append(errors, field.Invalid(path, provider, fmt.Sprintf("kubeconfig provider %q is unknown. Valid providers are %q", provider, types.ValidKubeconfigProviders)))
+	}
+
+	if provider != types.KubeconfigProviderExec {
+		return errors
+	}
+
+	if store.Kind != types.StoreKindExoscale {
+		errors = append(errors, field.Forbidden(path, fmt.Sprintf("the kubeconfig provider %q is only supported by the %q store", provider, types.StoreKindExoscale)))
+	}
+
+	if store.OIDC != nil || store.CloudflareAccess != nil {
+		errors = append(errors, field.Forbidden(path, fmt.Sprintf("the kubeconfig provider %q cannot be combined with the OIDC or the Cloudflare Access login", provider)))
+	}
+	return errors
+}
+
+// validateCloudTags validates that the store reads the tags of its clusters and the keys are neither empty nor duplicated
+func validateCloudTags(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	switch store.Kind {
+	case types.StoreKindEKS, types.StoreKindGKE, types.StoreKindAzure, types.StoreKindExoscale:
+	default:
+		errors = append(errors, field.Forbidden(path, fmt.Sprintf("cloud tags are only supported by the %q, %q, %q and %q stores", types.StoreKindEKS, types.StoreKindGKE, types.StoreKindAzure, types.StoreKindExoscale)))
+	}
+
+	keys := sets.NewString()
+	for i, key := range store.CloudTags {
+		if len(strings.TrimSpace(key)) == 0 {
+			errors = append(errors, field.Required(path.Index(i), "the key of the cloud tag must not be empty"))
+			continue
+		}
+		if keys.Has(strings.ToLower(key)) {
+			errors = append(errors, field.Duplicate(path.Index(i), key))
+		}
+		keys.Insert(strings.ToLower(key))
+	}
+	return errors
+}
+
+// validateOffline validates the probe address and timeout detecting a missing network connection
+func validateOffline(path *field.Path, offline types.OfflineConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if offline.ProbeAddress != nil {
+		if host, _, err := net.SplitHostPort(*offline.ProbeAddress); err != nil || len(host) == 0 {
+			errors = append(errors, field.Invalid(path.Child("probeAddress"), *offline.ProbeAddress, "the probe address has to be a TCP address (host:port)"))
+		}
+	}
+
+	if offline.ProbeTimeout != nil && *offline.ProbeTimeout <= 0 {
+		errors = append(errors, field.Invalid(path.Child("probeTimeout"), offline.ProbeTimeout.String(), "the timeout has to be positive"))
+	}
+	return errors
+}
+
+// validateExecStore validates that the command of the exec store is configured
+func validateExecStore(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	config, _ := store.Config.(map[interface{}]interface{})
+	if command, _ := config["command"].(string); len(command) == 0 {
+		errors = append(errors, field.Required(path.Child("command"), "the command implementing the exec store protocol has to be provided"))
+	}
+	return errors
+}
+
+// validateMockStore validates the number of fake clusters, the server and the latency of the mock store
+func validateMockStore(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	config, _ := store.Config.(map[interface{}]interface{})
+	if clusters, ok := config["clusters"]; ok {
+		if number, isInt := clusters.(int); !isInt || number < 0 {
+			errors = append(errors, field.Invalid(path.Child("clusters"), clusters, "the number of clusters must not be negative"))
+		}
+	}
+
+	if server, ok := config["server"]; ok {
+		value, _ := server.(string)
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			errors = append(errors, field.Invalid(path.Child("server"), server, "the server has to be an HTTP(S) URL"))
+		}
+	}
+
+	if latency, ok := config["latency"]; ok {
+		value, _ := latency.(string)
+		if duration, err := time.ParseDuration(value); err != nil || duration < 0 {
+			errors = append(errors, field.Invalid(path.Child("latency"), latency, "the latency has to be a non-negative duration, e.g. 500ms"))
+		}
+	}
+	return errors
+}
+
+// validateExoscaleStore validates the zones of the Exoscale store and the client certificates of its kubeconfigs
+func validateExoscaleStore(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if store.Config == nil {
+		return errors
+	}
+
+	config := &types.StoreConfigExoscale{}
+	buf, err := yaml.Marshal(store.Config)
+	if err == nil {
+		err = yaml.Unmarshal(buf, config)
+	}
+	if err != nil {
+		return append(errors, field.Invalid(path, store.Config, fmt.Sprintf("invalid Exoscale store configuration: %v", err)))
+	}
+
+	zones := sets.NewString()
+	for i, zone := range config.Zones {
+		if len(strings.TrimSpace(zone)) == 0 {
+			errors = append(errors, field.Required(path.Child("zones").Index(i), "the zone must not be empty"))
+		} else if zones.Has(zone) {
+			errors = append(errors, field.Duplicate(path.Child("zones").Index(i), zone))
+		}
+		zones.Insert(zone)
+	}
+
+	kubeconfig := config.Kubeconfig
+	if kubeconfig == nil {
+		return errors
+	}
+
+	if kubeconfig.User != nil && len(strings.TrimSpace(*kubeconfig.User)) == 0 {
+		errors = append(errors, field.Required(path.Child("kubeconfig", "user"), "the user must not be empty"))
+	}
+
+	for i, group := range kubeconfig.Groups {
+		if len(strings.TrimSpace(group)) == 0 {
+			errors = append(errors, field.Required(path.Child("kubeconfig", "groups").Index(i), "the group must not be empty"))
+		}
+	}
+
+	if kubeconfig.TTLSeconds != nil && *kubeconfig.TTLSeconds <= 0 {
+		errors = append(errors, field.Invalid(path.Child("kubeconfig", "ttlSeconds"), *kubeconfig.TTLSeconds, "the validity of the client certificates has to be positive"))
+	}
+	return errors
+}
+
+// validateSecretReferences validates the syntax of secret references (env://, file:// and cmd://) in the secret fields of the store configuration
+func validateSecretReferences(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	config, ok := store.Config.(map[interface{}]interface{})
+	if !ok {
+		return errors
+	}
+
+	for _, secretField := range credentials.SecretFields[store.Kind] {
+		value, ok := config[secretField].(string)
+		if !ok || !credentials.IsReference(value) {
+			continue
+		}
+
+		if err := credentials.ValidateReference(value); err != nil {
+			errors = append(errors, field.Invalid(path.Child(secretField), value, err.Error()))
+		}
+	}
+	return errors
+}
+
+// validateAudit validates the rotation of the audit log
+func validateAudit(path *field.Path, audit types.AuditConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if audit.MaxSize != nil {
+		quantity, err := resource.ParseQuantity(*audit.MaxSize)
+		if err != nil {
+			errors = append(errors, field.Invalid(path.Child("maxSize"), *audit.MaxSize, fmt.Sprintf("max size is not a valid quantity: %v", err)))
+		} else if quantity.Sign() <= 0 {
+			errors = append(errors, field.Invalid(path.Child("maxSize"), *audit.MaxSize, "max size has to be positive"))
+		}
+	}
+
+	if audit.MaxBackups != nil && *audit.MaxBackups < 0 {
+		errors = append(errors, field.Invalid(path.Child("maxBackups"), *audit.MaxBackups, "max backups must not be negative"))
+	}
+	return errors
+}
+
+// validateWebhook validates the URL, the payload format and the contexts of a webhook.
+// Secret references are only validated syntactically, as resolving them can have side effects.
+func validateWebhook(path *field.Path, webhook types.WebhookConfig, hasProtectedContexts bool) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	switch {
+	case len(webhook.URL) == 0:
+		errors = append(errors, field.Required(path.Child("url"), "the URL of the webhook is required"))
+	case credentials.IsReference(webhook.URL):
+		if err := credentials.ValidateReference(webhook.URL); err != nil {
+			errors = append(errors, field.Invalid(path.Child("url"), webhook.URL, err.Error()))
+		}
+	default:
+		if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			errors = append(errors, field.Invalid(path.Child("url"), webhook.URL, "the URL of the webhook has to be an HTTP(S) URL"))
+		}
+	}
+
+	if webhook.Format != nil && !types.ValidWebhookFormats.Has(string(*webhook.Format)) {
+		errors = append(errors, field.Invalid(path.Child("format"), *webhook.Format, fmt.Sprintf("Webhook format %q is unknown. Valid formats are %q", *webhook.Format, types.ValidWebhookFormats)))
+	}
+
+	for name, value := range webhook.Headers {
+		if len(strings.TrimSpace(name)) == 0 {
+			errors = append(errors, field.Required(path.Child("headers"), "the name of a header must not be empty"))
+			continue
+		}
+		if credentials.IsReference(value) {
+			if err := credentials.ValidateReference(value); err != nil {
+				errors = append(errors, field.Invalid(path.Child("headers").Key(name), value, err.Error()))
+			}
+		}
+	}
+
+	if len(webhook.Contexts) == 0 && !hasProtectedContexts {
+		errors = append(errors, field.Required(path.Child("contexts"), "the webhook requires context patterns if no protected contexts are configured"))
+	}
+	for i, pattern := range webhook.Contexts {
+		if len(strings.TrimSpace(pattern)) == 0 {
+			errors = append(errors, field.Required(path.Child("contexts").Index(i), "the context pattern must not be empty"))
+		}
+	}
+
+	if webhook.Timeout != nil && *webhook.Timeout <= 0 {
+		errors = append(errors, field.Invalid(path.Child("timeout"), webhook.Timeout.String(), "the timeout has to be positive"))
+	}
+	return errors
+}
+
+// validateSync validates the backend the user state is synchronized with
+func validateSync(path *field.Path, sync types.SyncConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if !types.ValidSyncKinds.Has(string(sync.Kind)) {
+		errors = append(errors, field.Invalid(path.Child("kind"), sync.Kind, fmt.Sprintf("Sync kind %q is unknown. Valid kinds are %q", sync.Kind, types.ValidSyncKinds)))
+	}
+
+	switch {
+	case len(sync.URL) == 0:
+		errors = append(errors, field.Required(path.Child("url"), "the URL of the sync backend is required"))
+	case sync.Kind == types.SyncKindS3 && !strings.HasPrefix(sync.URL, "s3://"):
+		errors = append(errors, field.Invalid(path.Child("url"), sync.URL, "the URL of the S3 sync backend has to start with \"s3://\""))
+	case sync.Kind == types.SyncKindGCS && !strings.HasPrefix(sync.URL, "gs://"):
+		errors = append(errors, field.Invalid(path.Child("url"), sync.URL, "the URL of the GCS sync backend has to start with \"gs://\""))
+	}
+
+	if sync.Path != nil && sync.Kind != types.SyncKindGit {
+		errors = append(errors, field.Forbidden(path.Child("path"), "the path can only be configured for the git sync backend"))
+	}
+	return errors
+}
+
+// validateAccessCheck validates the mode and the cache duration of the access check
+func validateAccessCheck(path *field.Path, accessCheck types.AccessCheckConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if accessCheck.Mode != nil && !types.ValidAccessCheckModes.Has(string(*accessCheck.Mode)) {
+		errors = append(errors, field.Invalid(path.Child("mode"), *accessCheck.Mode, fmt.Sprintf("Access check mode %q is unknown. Valid modes are %q", *accessCheck.Mode, types.ValidAccessCheckModes)))
+	}
+
+	if accessCheck.CacheDuration != nil && *accessCheck.CacheDuration <= 0 {
+		errors = append(errors, field.Invalid(path.Child("cacheDuration"), accessCheck.CacheDuration.String(), "the cache duration has to be positive"))
+	}
+	return errors
+}
+
+// validateSharedIndex validates the backend storing the index shared within a team and the published kubeconfig stores
+func validateSharedIndex(path *field.Path, sharedIndex types.SharedIndexConfig) field.ErrorList {
+	errors := validateSync(path, types.SyncConfig{
+		Kind: sharedIndex.Kind,
+		URL:  sharedIndex.URL,
+		Path: sharedIndex.Path,
+	})
+
+	storeIDs := sets.NewString()
+	for i, storeID := range sharedIndex.Stores {
+		storePath := path.Child("stores").Index(i)
+		switch {
+		case len(storeID) == 0:
+			errors = append(errors, field.Required(storePath, "the ID of the kubeconfig store is required"))
+		case storeIDs.Has(storeID):
+			errors = append(errors, field.Duplicate(storePath, storeID))
+		}
+		storeIDs.Insert(storeID)
+	}
+	return errors
+}
+
+// validateOIDC validates that the issuer is an HTTPS URL with a client ID and that the grant type is supported
+func validateOIDC(path *field.Path, oidc types.OIDCConfig) field.ErrorList {
+	var errors field.ErrorList
+
+	if oidc.IssuerURL != nil {
+		if issuer, err := url.Parse(*oidc.IssuerURL); err != nil || issuer.Scheme != "https" || len(issuer.Host) == 0 {
+			errors = append(errors, field.Invalid(path.Child("issuerURL"), *oidc.IssuerURL, "the issuer has to be an HTTPS URL"))
+		}
+		if oidc.ClientID == nil || len(*oidc.ClientID) == 0 {
+			errors = append(errors, field.Required(path.Child("clientID"), "the client ID is required if the issuer is configured"))
+		}
+	}
+
+	if oidc.GrantType != nil && *oidc.GrantType != types.OIDCGrantTypeBrowser && *oidc.GrantType != types.OIDCGrantTypeDeviceCode {
+		errors = append(errors, field.NotSupported(path.Child("grantType"), *oidc.GrantType, []string{string(types.OIDCGrantTypeBrowser), string(types.OIDCGrantTypeDeviceCode)}))
+	}
+
+	if oidc.ListenAddress != nil {
+		if _, _, err := net.SplitHostPort(*oidc.ListenAddress); err != nil {
+			errors = append(errors, field.Invalid(path.Child("listenAddress"), *oidc.ListenAddress, err.Error()))
+		}
+	}
+	return errors
+}
+
+// validateLogLevel validates that the log level is known to logrus
+func validateLogLevel(path *field.Path, level string) field.ErrorList {
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return field.ErrorList{field.Invalid(path, level, err.Error())}
+	}
+	return nil
+}
+
+// validateHTTPTransport validates that the connection limits and the idle timeout are not negative
+func validateHTTPTransport(path *field.Path, transport types.HTTPTransport) field.ErrorList {
+	var errors field.ErrorList
+
+	if transport.MaxIdleConns != nil && *transport.MaxIdleConns < 0 {
+		errors = append(errors, field.Invalid(path.Child("maxIdleConns"), *transport.MaxIdleConns, "the maximum number of idle connections cannot be negative"))
+	}
+
+	if transport.MaxIdleConnsPerHost != nil && *transport.MaxIdleConnsPerHost < 0 {
+		errors = append(errors, field.Invalid(path.Child("maxIdleConnsPerHost"), *transport.MaxIdleConnsPerHost, "the maximum number of idle connections per host cannot be negative"))
+	}
+
+	if transport.MaxConnsPerHost != nil && *transport.MaxConnsPerHost < 0 {
+		errors = append(errors, field.Invalid(path.Child("maxConnsPerHost"), *transport.MaxConnsPerHost, "the maximum number of connections per host cannot be negative"))
+	}
+
+	if transport.IdleConnTimeout != nil && *transport.IdleConnTimeout < 0 {
+		errors = append(errors, field.Invalid(path.Child("idleConnTimeout"), transport.IdleConnTimeout.String(), "the idle connection timeout cannot be negative"))
+	}
+
+	return errors
+}
+
+// validateRateLimit validates that the rate limit allows requests and the number of retries is not negative
+func validateRateLimit(path *field.Path, rateLimit types.RateLimit) field.ErrorList {
+	var errors field.ErrorList
+
+	if rateLimit.RequestsPerSecond != nil && *rateLimit.RequestsPerSecond <= 0 {
+		errors = append(errors, field.Invalid(path.Child("requestsPerSecond"), *rateLimit.RequestsPerSecond, "the requests per second have to be positive"))
+	}
+
+	if rateLimit.Burst != nil && *rateLimit.Burst < 1 {
+		errors = append(errors, field.Invalid(path.Child("burst"), *rateLimit.Burst, "the burst has to allow at least one request"))
+	}
+
+	if rateLimit.MaxRetries != nil && *rateLimit.MaxRetries < 0 {
+		errors = append(errors, field.Invalid(path.Child("maxRetries"), *rateLimit.MaxRetries, "the number of retries cannot be negative"))
+	}
+
+	return errors
+}
+
+// validateHTTPClient validates that the store sends requests with its own HTTP client, the proxy URL
+// and that the client certificate is configured with its private key
+func validateHTTPClient(path *field.Path, store types.KubeconfigStore) field.ErrorList {
+	var errors field.ErrorList
+
+	switch store.Kind {
+	case types.StoreKindAkamai, types.StoreKindAzure, types.StoreKindDigitalOcean, types.StoreKindEKS, types.StoreKindExoscale,
+		types.StoreKindGKE, types.StoreKindOVH, types.StoreKindScaleway, types.StoreKindRancher, types.StoreKindVault:
+	default:
+		errors = append(errors, field.Forbidden(path, fmt.Sprintf("the HTTP client is only supported by the stores of cloud providers and the %q and %q stores", types.StoreKindRancher, types.StoreKindVault)))
+	}
+
+	client := store.HTTPClient
+	if client.Proxy != nil {
+		errors = append(errors, validateProxyURL(path.Child("proxy"), *client.Proxy)...)
+	}
+
+	files := []struct {
+		name string
+		path *string
+	}{{"caFile", client.CAFile}, {"certFile", client.CertFile}, {"keyFile", client.KeyFile}}
+	for _, file := range files {
+		if file.path != nil && len(*file.path) == 0 {
+			errors = append(errors, field.Required(path.Child(file.name), "the path must not be empty"))
+		}
+	}
+
+	switch {
+	case client.CertFile != nil && client.KeyFile == nil:
+		errors = append(errors, field.Required(path.Child("keyFile"), "the private key of the client certificate has to be provided"))
+	case client.CertFile == nil && client.KeyFile != nil:
+		errors = append(errors, field.Required(path.Child("certFile"), "the client certificate of the private key has to be provided"))
+	}
+
+	return errors
+}
+
+// validateExcludePatterns validates that the exclude patterns prefixed with "regex:" are valid regular expressions
+func validateExcludePatterns(path *field.Path, patterns []string) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	for i, pattern := range patterns {
+		if len(pattern) == 0 {
+			errors = append(errors, field.Invalid(path.Index(i), pattern, "exclude pattern must not be empty"))
+			continue
+		}
+
+		expression, isRegex := strings.CutPrefix(pattern, types.ExcludePatternRegexPrefix)
+		if !isRegex {
+			continue
+		}
+		if _, err := regexp.Compile(expression); err != nil {
+			errors = append(errors, field.Invalid(path.Index(i), pattern, fmt.Sprintf("invalid regular expression: %v", err)))
+		}
+	}
+	return errors
+}
+
+// validateEncryptionKey validates where an encryption key is stored
+func validateEncryptionKey(path *field.Path, encryption types.EncryptionKeyConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if encryption.KeySource != nil && !types.ValidEncryptionKeySources.Has(string(*encryption.KeySource)) {
+		errors = append(errors, field.Invalid(path.Child("keySource"), *encryption.KeySource, fmt.Sprintf("Key source %q is unknown. Valid key sources are %q", *encryption.KeySource, types.ValidEncryptionKeySources)))
+	}
+
+	if encryption.KeySource != nil && *encryption.KeySource == types.EncryptionKeySourceAge && (encryption.AgeIdentity == nil || len(*encryption.AgeIdentity) == 0) {
+		errors = append(errors, field.Required(path.Child("ageIdentity"), "an age identity is required for the key source \"age\""))
+	}
+	return errors
+}
+
+// validateDashboard validates the configuration of the local dashboard
+func validateDashboard(path *field.Path, dashboard types.DashboardConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if dashboard.Tool != nil && dashboard.Command != nil {
+		errors = append(errors, field.Invalid(path, dashboard, "only one of tool and command can be provided"))
+	}
+
+	if dashboard.Tool != nil && !types.ValidDashboardTools.Has(string(*dashboard.Tool)) {
+		errors = append(errors, field.Invalid(path.Child("tool"), *dashboard.Tool, fmt.Sprintf("Dashboard tool %q is unknown. Valid tools are %q", *dashboard.Tool, types.ValidDashboardTools)))
+	}
+
+	if dashboard.Command != nil {
+		if err := switchconfig.ValidateTemplate(*dashboard.Command); err != nil {
+			errors = append(errors, field.Invalid(path.Child("command"), *dashboard.Command, fmt.Sprintf("Command cannot be parsed: %v", err)))
+		}
+	}
+
+	if dashboard.Port != nil && (*dashboard.Port < 1 || *dashboard.Port > 65535) {
+		errors = append(errors, field.Invalid(path.Child("port"), *dashboard.Port, "the port has to be between 1 and 65535"))
+	}
+	return errors
+}
+
+// validateClean validates the garbage collection configuration for temporary kubeconfig files
+func validateClean(path *field.Path, clean types.CleanConfig) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if clean.MaxAge != nil && *clean.MaxAge <= 0 {
+		errors = append(errors, field.Invalid(path.Child("maxAge"), clean.MaxAge.String(), "max age has to be a positive duration"))
+	}
+
+	if clean.MaxTotalSize != nil {
+		quantity, err := resource.ParseQuantity(*clean.MaxTotalSize)
+		if err != nil {
+			errors = append(errors, field.Invalid(path.Child("maxTotalSize"), *clean.MaxTotalSize, fmt.Sprintf("max total size is not a valid quantity: %v", err)))
+		} else if quantity.Sign() < 0 {
+			errors = append(errors, field.Invalid(path.Child("maxTotalSize"), *clean.MaxTotalSize, "max total size must not be negative"))
+		}
+	}
+
+	if clean.Auto != nil && *clean.Auto && clean.MaxAge == nil && clean.MaxTotalSize == nil {
+		errors = append(errors, field.Required(path.Child("maxAge"), "automatic cleanup requires either a max age or a max total size"))
 	}
 	return errors
 }
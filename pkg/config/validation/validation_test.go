@@ -93,6 +93,141 @@ var _ = Describe("ValidateConfig", func() {
 		))
 	})
 
+	validateCases([]validationCase{
+		{
+			description: "should throw error - invalid picker",
+			config: &types.Config{
+				Version: "v1alpha1",
+				Picker:  ptr.To(types.Picker("my-picker")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "picker"},
+			},
+		},
+		{
+			description: "should throw error - unknown sort order",
+			config: &types.Config{
+				Version:   "v1alpha1",
+				SortOrder: ptr.To(types.SortOrder("recent")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "sortOrder"},
+			},
+		},
+		{
+			description: "should throw error - unknown results view",
+			config: &types.Config{
+				Version:     "v1alpha1",
+				ResultsView: ptr.To(types.ResultsView("grid")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "resultsView"},
+			},
+		},
+		{
+			description: "should throw error - unknown matching algorithm and case sensitivity",
+			config: &types.Config{
+				Version:         "v1alpha1",
+				Matching:        ptr.To(types.MatchingAlgorithm("regex")),
+				CaseSensitivity: ptr.To(types.CaseSensitivity("ignore")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "matching"},
+				{field.ErrorTypeInvalid, "caseSensitivity"},
+			},
+		},
+		{
+			description: "should throw error - negative number of prefetched kubeconfigs",
+			config: &types.Config{
+				Version:             "v1alpha1",
+				PrefetchKubeconfigs: ptr.To(-1),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "prefetchKubeconfigs"},
+			},
+		},
+		{
+			description: "should throw error - unknown collision suffix",
+			config: &types.Config{
+				Version:         "v1alpha1",
+				CollisionSuffix: ptr.To(types.CollisionSuffix("cluster")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "collisionSuffix"},
+			},
+		},
+		{
+			description: "should throw error - unknown duplicate clusters mode",
+			config: &types.Config{
+				Version:           "v1alpha1",
+				DuplicateClusters: ptr.To(types.DuplicateClusters("hide")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "duplicateClusters"},
+			},
+		},
+		{
+			description: "should throw error - unknown notify mode",
+			config: &types.Config{
+				Version: "v1alpha1",
+				Notify:  ptr.To(types.NotifyMode("sometimes")),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "notify"},
+			},
+		},
+		{
+			description: "should throw error - invalid preview template",
+			config: &types.Config{
+				Version:         "v1alpha1",
+				PreviewTemplate: ptr.To("{{ .Context "),
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "previewTemplate"},
+			},
+		},
+		{
+			description: "should throw error - invalid context name template of the kubeconfig store",
+			config: &types.Config{
+				Version: "v1alpha1",
+				KubeconfigStores: []types.KubeconfigStore{
+					{
+						Kind:                types.StoreKindFilesystem,
+						Paths:               []string{"~/.kube"},
+						ContextNameTemplate: ptr.To("{{ .Account }-{{ .Cluster }}"),
+					},
+				},
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "kubeconfigStores[0].contextNameTemplate"},
+			},
+		},
+		{
+			description: "should throw error - staleWhileRevalidate requires an index",
+			config: &types.Config{
+				Version: "v1alpha1",
+				KubeconfigStores: []types.KubeconfigStore{
+					{
+						Kind:                 types.StoreKindVault,
+						Paths:                []string{"ab"},
+						ID:                   ptr.To("id-one"),
+						StaleWhileRevalidate: ptr.To(true),
+					},
+					{
+						Kind:                 types.StoreKindVault,
+						RefreshIndexAfter:    ptr.To(time.Minute),
+						Paths:                []string{"ab"},
+						ID:                   ptr.To("id-two"),
+						StaleWhileRevalidate: ptr.To(true),
+					},
+				},
+			},
+			errors: []expectedError{
+				{field.ErrorTypeInvalid, "kubeconfigStores[0].staleWhileRevalidate"},
+			},
+		},
+	})
+
 	It("should throw error - no paths are configured for the kubeconfig store", func() {
 		config := &types.Config{
 			Version: "v1alpha1",
@@ -372,6 +507,133 @@ var _ = Describe("ValidateConfig", func() {
 		})
 	})
 
+	Context("Exec store", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the exec store",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindExec,
+							Config: map[interface{}]interface{}{
+								"command": "kubeswitch-netbox",
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - command is required",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindExec,
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "kubeconfigStores[0].config.command"},
+				},
+			},
+		})
+	})
+
+	Context("Mock store", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the mock store",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindMock,
+							Config: map[interface{}]interface{}{
+								"clusters": 5000,
+								"server":   "https://127.0.0.1:6443",
+								"latency":  "500ms",
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid clusters, server and latency",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindMock,
+							Config: map[interface{}]interface{}{
+								"clusters": -1,
+								"server":   "127.0.0.1:6443",
+								"latency":  "soon",
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].config.clusters"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].config.server"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].config.latency"},
+				},
+			},
+		})
+	})
+
+	Context("Exoscale store", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the Exoscale store",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindExoscale,
+							Config: map[interface{}]interface{}{
+								"exoscaleAPIKey":    "EXOAPIKEY",
+								"exoscaleSecretKey": "THEAPISECRET",
+								"zones":             []interface{}{"ch-gva-2", "de-fra-1"},
+								"kubeconfig": map[interface{}]interface{}{
+									"user":       "team-a",
+									"groups":     []interface{}{"team-a-viewers"},
+									"ttlSeconds": 86400,
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid zones and kubeconfig",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindExoscale,
+							Config: map[interface{}]interface{}{
+								"zones": []interface{}{"ch-gva-2", "", "ch-gva-2"},
+								"kubeconfig": map[interface{}]interface{}{
+									"user":       "",
+									"groups":     []interface{}{"viewers", " "},
+									"ttlSeconds": 0,
+								},
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "kubeconfigStores[0].config.zones[1]"},
+					{field.ErrorTypeDuplicate, "kubeconfigStores[0].config.zones[2]"},
+					{field.ErrorTypeRequired, "kubeconfigStores[0].config.kubeconfig.user"},
+					{field.ErrorTypeRequired, "kubeconfigStores[0].config.kubeconfig.groups[1]"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].config.kubeconfig.ttlSeconds"},
+				},
+			},
+		})
+	})
+
 	Context("Hooks", func() {
 		It("should successfully validate hooks", func() {
 			config := &types.Config{
@@ -448,5 +710,1626 @@ var _ = Describe("ValidateConfig", func() {
 				})),
 			))
 		})
+
+		validateCases([]validationCase{
+			{
+				description: "should throw error - invalid hook trigger",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Trigger:   "unknown-trigger",
+							Arguments: []string{"echo"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "hooks[0].trigger"},
+				},
+			},
+			{
+				description: "should throw error - interval is forbidden for post-switch hooks",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Trigger:   types.HookTriggerPostSwitch,
+							Arguments: []string{"echo ${KUBESWITCH_CONTEXT}"},
+							Execution: &types.HookExecution{
+								Interval: ptr.To(time.Hour),
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeForbidden, "hooks[0].execution.interval"},
+				},
+			},
+			{
+				description: "should throw error - invalid timeout and retries",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Arguments: []string{"echo"},
+							Execution: &types.HookExecution{
+								Timeout: ptr.To(time.Duration(0)),
+								Retries: ptr.To(-1),
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "hooks[0].execution.timeout"},
+					{field.ErrorTypeInvalid, "hooks[0].execution.retries"},
+				},
+			},
+			{
+				description: "should throw error - hook is scoped to an unknown store",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindEKS,
+							ID:   ptr.To("prod"),
+						},
+					},
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Trigger:   types.HookTriggerStoreFailure,
+							Stores:    []string{"eks.prod", "gke"},
+							Arguments: []string{"echo ${KUBESWITCH_STORE_ERROR}"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeNotFound, "hooks[0].stores[1]"},
+				},
+			},
+			{
+				description: "should successfully validate the shell of inline commands",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Shell:     ptr.To(types.HookShellPowerShell),
+							Arguments: []string{"Write-Output $env:KUBESWITCH_CONTEXT"},
+						},
+						{
+							Type:      types.HookTypeInlineCommand,
+							Shell:     ptr.To(types.HookShellCmd),
+							Arguments: []string{"echo %KUBESWITCH_CONTEXT%"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - templates in inline commands",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Trigger:   types.HookTriggerPostSwitch,
+							Arguments: []string{"echo {{ .Context }}"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "hooks[0].arguments[0]"},
+				},
+			},
+			{
+				description: "should throw error - unknown shell and shell of an executable",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeInlineCommand,
+							Shell:     ptr.To(types.HookShell("zsh")),
+							Arguments: []string{"echo hello"},
+						},
+						{
+							Type:  types.HookTypeExecutable,
+							Path:  ptr.To("/usr/local/bin/hook"),
+							Shell: ptr.To(types.HookShellBash),
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeNotSupported, "hooks[0].shell"},
+					{field.ErrorTypeForbidden, "hooks[1].shell"},
+				},
+			},
+			{
+				description: "should throw error - invalid terminal title hooks",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Hooks: []types.Hook{
+						{
+							Type:      types.HookTypeTerminalTitle,
+							Trigger:   types.HookTriggerPreSearch,
+							Arguments: []string{"echo hello"},
+							Title:     ptr.To("{{ .Context "),
+						},
+						{
+							Type:      types.HookTypeInlineCommand,
+							Arguments: []string{"echo hello"},
+							Badge:     ptr.To("{{ .Context }}"),
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "hooks[0].trigger"},
+					{field.ErrorTypeForbidden, "hooks[0].arguments"},
+					{field.ErrorTypeInvalid, "hooks[0].title"},
+					{field.ErrorTypeForbidden, "hooks[1].badge"},
+				},
+			},
+		})
+
+		It("should successfully validate terminal title hooks", func() {
+			config := &types.Config{
+				Version: "v1alpha1",
+				Hooks: []types.Hook{
+					{
+						Name: "title",
+						Type: types.HookTypeTerminalTitle,
+					},
+					{
+						Name:    "badge",
+						Type:    types.HookTypeTerminalTitle,
+						Trigger: types.HookTriggerPostSwitch,
+						Title:   ptr.To("k8s: {{ .Context }}"),
+						Badge:   ptr.To(""),
+					},
+				},
+			}
+
+			errorList := validation.ValidateConfig(config)
+			Expect(errorList).To(BeEmpty())
+			Expect(config.Hooks[0].IsPostSwitch()).To(BeTrue())
+		})
 	})
-})
+
+	Context("Clean", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the clean configuration",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Clean: &types.CleanConfig{
+						MaxAge:       ptr.To(24 * time.Hour),
+						MaxTotalSize: ptr.To("10Mi"),
+						Auto:         ptr.To(true),
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid max total size",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Clean: &types.CleanConfig{
+						MaxTotalSize: ptr.To("ten megabytes"),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "clean.maxTotalSize"},
+				},
+			},
+			{
+				description: "should throw error - automatic cleanup without limits",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Clean: &types.CleanConfig{
+						Auto: ptr.To(true),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "clean.maxAge"},
+				},
+			},
+		})
+	})
+
+	Context("Audit", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the audit configuration",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Audit: &types.AuditConfig{
+						Enabled:    ptr.To(true),
+						MaxSize:    ptr.To("1Mi"),
+						MaxBackups: ptr.To(3),
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid max size and negative max backups",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Audit: &types.AuditConfig{
+						MaxSize:    ptr.To("0"),
+						MaxBackups: ptr.To(-1),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "audit.maxSize"},
+					{field.ErrorTypeInvalid, "audit.maxBackups"},
+				},
+			},
+		})
+	})
+
+	Context("Webhooks", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate webhooks",
+				config: &types.Config{
+					Version:           "v1alpha1",
+					ProtectedContexts: []string{"*prod*"},
+					Webhooks: []types.WebhookConfig{
+						{
+							URL:     "https://hooks.example.com/kubeswitch",
+							Headers: map[string]string{"Authorization": "env://WEBHOOK_TOKEN"},
+							Timeout: ptr.To(time.Second),
+						},
+						{
+							URL:      "env://SLACK_WEBHOOK_URL",
+							Format:   ptr.To(types.WebhookFormatSlack),
+							Contexts: []string{"*admin*"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid URL, format and timeout",
+				config: &types.Config{
+					Version:           "v1alpha1",
+					ProtectedContexts: []string{"*prod*"},
+					Webhooks: []types.WebhookConfig{
+						{
+							URL:     "hooks.example.com",
+							Format:  ptr.To(types.WebhookFormat("teams")),
+							Timeout: ptr.To(time.Duration(0)),
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "webhooks[0].url"},
+					{field.ErrorTypeInvalid, "webhooks[0].format"},
+					{field.ErrorTypeInvalid, "webhooks[0].timeout"},
+				},
+			},
+			{
+				description: "should throw error - missing URL and no contexts to notify for",
+				config: &types.Config{
+					Version:  "v1alpha1",
+					Webhooks: []types.WebhookConfig{{}},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "webhooks[0].url"},
+					{field.ErrorTypeRequired, "webhooks[0].contexts"},
+				},
+			},
+		})
+	})
+
+	Context("Sync", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate a git sync backend",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Sync: &types.SyncConfig{
+						Kind: types.SyncKindGit,
+						URL:  "git@github.com:user/kubeswitch-state.git",
+						Path: ptr.To("state/kubeswitch.yaml"),
+					},
+				},
+			},
+			{
+				description: "should throw error - unknown kind and missing url",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Sync: &types.SyncConfig{
+						Kind: "ftp",
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "sync.kind"},
+					{field.ErrorTypeRequired, "sync.url"},
+				},
+			},
+			{
+				description: "should throw error - bucket url with wrong scheme and path for s3",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Sync: &types.SyncConfig{
+						Kind: types.SyncKindS3,
+						URL:  "gs://bucket/state.yaml",
+						Path: ptr.To("state.yaml"),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "sync.url"},
+					{field.ErrorTypeForbidden, "sync.path"},
+				},
+			},
+		})
+	})
+
+	Context("Access check", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the access check",
+				config: &types.Config{
+					Version: "v1alpha1",
+					AccessCheck: &types.AccessCheckConfig{
+						Mode:          ptr.To(types.AccessCheckModeHide),
+						CacheDuration: ptr.To(time.Hour),
+					},
+				},
+			},
+			{
+				description: "should throw error - unknown mode and negative cache duration",
+				config: &types.Config{
+					Version: "v1alpha1",
+					AccessCheck: &types.AccessCheckConfig{
+						Mode:          ptr.To(types.AccessCheckMode("blur")),
+						CacheDuration: ptr.To(-time.Minute),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "accessCheck.mode"},
+					{field.ErrorTypeInvalid, "accessCheck.cacheDuration"},
+				},
+			},
+		})
+	})
+
+	Context("Kubernetes version", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the minimum supported Kubernetes version",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubernetesVersion: &types.KubernetesVersionConfig{
+						Show:         ptr.To(true),
+						MinSupported: ptr.To("v1.28"),
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid minimum supported Kubernetes version",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubernetesVersion: &types.KubernetesVersionConfig{
+						MinSupported: ptr.To("latest"),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubernetesVersion.minSupported"},
+				},
+			},
+		})
+	})
+
+	Context("Offline", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the detection of the offline mode",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Offline: &types.OfflineConfig{
+						Detect:       ptr.To(true),
+						ProbeAddress: ptr.To("vault.corp.example.com:8200"),
+						ProbeTimeout: ptr.To(500 * time.Millisecond),
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid probe address and timeout",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Offline: &types.OfflineConfig{
+						Detect:       ptr.To(true),
+						ProbeAddress: ptr.To("vault.corp.example.com"),
+						ProbeTimeout: ptr.To(time.Duration(0)),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "offline.probeAddress"},
+					{field.ErrorTypeInvalid, "offline.probeTimeout"},
+				},
+			},
+		})
+	})
+
+	Context("Shared index", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate a shared index in a bucket",
+				config: &types.Config{
+					Version: "v1alpha1",
+					SharedIndex: &types.SharedIndexConfig{
+						Kind:   types.SyncKindS3,
+						URL:    "s3://bucket/kubeswitch/index.yaml",
+						Stores: []string{"vault.default", "gardener.landscape"},
+					},
+				},
+			},
+			{
+				description: "should throw error - missing url, empty and duplicate store IDs",
+				config: &types.Config{
+					Version: "v1alpha1",
+					SharedIndex: &types.SharedIndexConfig{
+						Kind:   types.SyncKindGit,
+						Stores: []string{"vault.default", "", "vault.default"},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "sharedIndex.url"},
+					{field.ErrorTypeRequired, "sharedIndex.stores[1]"},
+					{field.ErrorTypeDuplicate, "sharedIndex.stores[2]"},
+				},
+			},
+		})
+	})
+
+	Context("Exclude patterns", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the exclude patterns",
+				config: &types.Config{
+					Version:         "v1alpha1",
+					ExcludePatterns: []string{"*-deprecated", "regex:^gke_.*_sandbox$"},
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:            types.StoreKindFilesystem,
+							Paths:           []string{"~/.kube/config"},
+							ExcludePatterns: []string{"*/old/*"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - empty pattern and invalid regular expression",
+				config: &types.Config{
+					Version:         "v1alpha1",
+					ExcludePatterns: []string{""},
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:            types.StoreKindFilesystem,
+							Paths:           []string{"~/.kube/config"},
+							ExcludePatterns: []string{"*-old", "regex:^gke_(.*"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "excludePatterns[0]"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].excludePatterns[1]"},
+				},
+			},
+		})
+	})
+
+	Context("Search concurrency and timeouts", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the search concurrency and timeouts",
+				config: &types.Config{
+					Version:           "v1alpha1",
+					SearchConcurrency: ptr.To(4),
+					SearchBatchSize:   ptr.To(1000),
+					SearchTimeout:     ptr.To(10 * time.Second),
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:           types.StoreKindFilesystem,
+							Paths:          []string{"~/.kube/config"},
+							SearchTimeout:  ptr.To(time.Minute),
+							MaxConcurrency: ptr.To(2),
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - no concurrency and non-positive timeouts",
+				config: &types.Config{
+					Version:           "v1alpha1",
+					SearchConcurrency: ptr.To(0),
+					SearchBatchSize:   ptr.To(0),
+					SearchTimeout:     ptr.To(time.Duration(0)),
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:           types.StoreKindFilesystem,
+							Paths:          []string{"~/.kube/config"},
+							SearchTimeout:  ptr.To(-time.Second),
+							MaxConcurrency: ptr.To(0),
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "searchConcurrency"},
+					{field.ErrorTypeInvalid, "searchBatchSize"},
+					{field.ErrorTypeInvalid, "searchTimeout"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].searchTimeout"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].maxConcurrency"},
+				},
+			},
+		})
+	})
+
+	Context("Circuit breaker", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the circuit breaker",
+				config: &types.Config{
+					Version: "v1alpha1",
+					CircuitBreaker: &types.CircuitBreaker{
+						Failures: ptr.To(2),
+						Cooldown: ptr.To(5 * time.Minute),
+					},
+				},
+			},
+			{
+				description: "should throw error - no failures and no cooldown",
+				config: &types.Config{
+					Version: "v1alpha1",
+					CircuitBreaker: &types.CircuitBreaker{
+						Failures: ptr.To(0),
+						Cooldown: ptr.To(time.Duration(0)),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "circuitBreaker.failures"},
+					{field.ErrorTypeInvalid, "circuitBreaker.cooldown"},
+				},
+			},
+		})
+	})
+
+	Context("HTTP transport", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the HTTP transport",
+				config: &types.Config{
+					Version: "v1alpha1",
+					HTTPTransport: &types.HTTPTransport{
+						MaxIdleConns:        ptr.To(0),
+						MaxIdleConnsPerHost: ptr.To(20),
+						MaxConnsPerHost:     ptr.To(50),
+						IdleConnTimeout:     ptr.To(time.Minute),
+						DisableHTTP2:        ptr.To(true),
+					},
+				},
+			},
+			{
+				description: "should throw error - negative connection limits and idle timeout",
+				config: &types.Config{
+					Version: "v1alpha1",
+					HTTPTransport: &types.HTTPTransport{
+						MaxIdleConns:        ptr.To(-1),
+						MaxIdleConnsPerHost: ptr.To(-1),
+						MaxConnsPerHost:     ptr.To(-1),
+						IdleConnTimeout:     ptr.To(-time.Second),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "httpTransport.maxIdleConns"},
+					{field.ErrorTypeInvalid, "httpTransport.maxIdleConnsPerHost"},
+					{field.ErrorTypeInvalid, "httpTransport.maxConnsPerHost"},
+					{field.ErrorTypeInvalid, "httpTransport.idleConnTimeout"},
+				},
+			},
+		})
+	})
+
+	Context("Logging", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the log level and format",
+				config: &types.Config{
+					Version:   "v1alpha1",
+					LogLevel:  ptr.To("warn"),
+					LogFormat: ptr.To(types.LogFormatJSON),
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:     types.StoreKindFilesystem,
+							Paths:    []string{"~/.kube/config"},
+							LogLevel: ptr.To("trace"),
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - unknown log levels and format",
+				config: &types.Config{
+					Version:   "v1alpha1",
+					LogLevel:  ptr.To("verbose"),
+					LogFormat: ptr.To(types.LogFormat("xml")),
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:     types.StoreKindFilesystem,
+							Paths:    []string{"~/.kube/config"},
+							LogLevel: ptr.To("loud"),
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "logLevel"},
+					{field.ErrorTypeNotSupported, "logFormat"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].logLevel"},
+				},
+			},
+		})
+	})
+
+	Context("OIDC", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the OIDC login",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							ID:    ptr.To("issuer"),
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							OIDC: &types.OIDCConfig{
+								IssuerURL:     ptr.To("https://login.example.com"),
+								ClientID:      ptr.To("kubernetes"),
+								ExtraScopes:   []string{"groups"},
+								GrantType:     ptr.To(types.OIDCGrantTypeDeviceCode),
+								ListenAddress: ptr.To("127.0.0.1:18000"),
+							},
+						},
+						{
+							ID:    ptr.To("kubelogin"),
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/other"},
+							OIDC:  &types.OIDCConfig{},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - insecure issuer without client ID, unknown grant type and invalid listen address",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							OIDC: &types.OIDCConfig{
+								IssuerURL:     ptr.To("http://login.example.com"),
+								GrantType:     ptr.To(types.OIDCGrantType("password")),
+								ListenAddress: ptr.To("8000"),
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].oidc.issuerURL"},
+					{field.ErrorTypeRequired, "kubeconfigStores[0].oidc.clientID"},
+					{field.ErrorTypeNotSupported, "kubeconfigStores[0].oidc.grantType"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].oidc.listenAddress"},
+				},
+			},
+		})
+	})
+
+	Context("Rate limit", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the rate limit",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindEKS,
+							RateLimit: &types.RateLimit{
+								RequestsPerSecond: ptr.To(2.5),
+								Burst:             ptr.To(5),
+								MaxRetries:        ptr.To(0),
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid rate limit",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindEKS,
+							RateLimit: &types.RateLimit{
+								RequestsPerSecond: ptr.To(0.0),
+								Burst:             ptr.To(0),
+								MaxRetries:        ptr.To(-1),
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].rateLimit.requestsPerSecond"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].rateLimit.burst"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].rateLimit.maxRetries"},
+				},
+			},
+		})
+	})
+
+	Context("Store HTTP client", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the HTTP client of a store",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindEKS,
+							HTTPClient: &types.HTTPClient{
+								Proxy:    ptr.To("http://proxy.example.com:3128"),
+								CAFile:   ptr.To("~/certs/proxy-ca.pem"),
+								CertFile: ptr.To("~/certs/client.pem"),
+								KeyFile:  ptr.To("~/certs/client-key.pem"),
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid HTTP client",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindRancher,
+							HTTPClient: &types.HTTPClient{
+								Proxy:    ptr.To("ftp://proxy.example.com"),
+								CAFile:   ptr.To(""),
+								CertFile: ptr.To("~/certs/client.pem"),
+							},
+						},
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							HTTPClient: &types.HTTPClient{
+								KeyFile: ptr.To("~/certs/client-key.pem"),
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].httpClient.proxy"},
+					{field.ErrorTypeRequired, "kubeconfigStores[0].httpClient.caFile"},
+					{field.ErrorTypeRequired, "kubeconfigStores[0].httpClient.keyFile"},
+					{field.ErrorTypeForbidden, "kubeconfigStores[1].httpClient"},
+					{field.ErrorTypeRequired, "kubeconfigStores[1].httpClient.certFile"},
+				},
+			},
+		})
+	})
+
+	Context("Alias rules", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the alias rules",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							ID:        ptr.To("prod"),
+							Kind:      types.StoreKindEKS,
+							CloudTags: []string{"team", "env"},
+						},
+					},
+					AliasRules: []types.AliasRule{
+						{
+							Template: "{{ .CloudTags.team }}-{{ .CloudTags.env }}",
+							Stores:   []string{"prod"},
+							Contexts: []string{"*arn:aws:eks:*"},
+							Tags:     map[string]string{"tag:managed": "true"},
+						},
+						{
+							Template: "{{ .Account }}-{{ .Cluster }}",
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid alias rules",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube"},
+						},
+					},
+					AliasRules: []types.AliasRule{
+						{
+							Template: "{{ .CloudTags.team }-{{ .Cluster }}",
+							Stores:   []string{"eks"},
+						},
+						{
+							Contexts: []string{" "},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "aliasRules[0].template"},
+					{field.ErrorTypeNotFound, "aliasRules[0].stores[0]"},
+					{field.ErrorTypeRequired, "aliasRules[1].template"},
+					{field.ErrorTypeRequired, "aliasRules[1].contexts[0]"},
+				},
+			},
+		})
+	})
+
+	Context("Cloud tags", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the cloud tags",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:      types.StoreKindEKS,
+							CloudTags: []string{"owner", "team", "cost-center"},
+						},
+						{
+							Kind:      types.StoreKindAzure,
+							CloudTags: []string{"owner"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid cloud tags",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:      types.StoreKindExoscale,
+							CloudTags: []string{"owner", "", "Owner"},
+						},
+						{
+							Kind:      types.StoreKindFilesystem,
+							Paths:     []string{"~/.kube"},
+							CloudTags: []string{"owner"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "kubeconfigStores[0].cloudTags[1]"},
+					{field.ErrorTypeDuplicate, "kubeconfigStores[0].cloudTags[2]"},
+					{field.ErrorTypeForbidden, "kubeconfigStores[1].cloudTags"},
+				},
+			},
+		})
+	})
+
+	Context("Kubeconfig provider", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the kubeconfig provider",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:               types.StoreKindExoscale,
+							KubeconfigProvider: ptr.To(types.KubeconfigProviderExec),
+						},
+						{
+							Kind:               types.StoreKindFilesystem,
+							Paths:              []string{"~/.kube"},
+							KubeconfigProvider: ptr.To(types.KubeconfigProviderStatic),
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid kubeconfig provider",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:               types.StoreKindExoscale,
+							KubeconfigProvider: ptr.To(types.KubeconfigProvider("plugin")),
+						},
+						{
+							Kind:               types.StoreKindRancher,
+							KubeconfigProvider: ptr.To(types.KubeconfigProviderExec),
+							CloudflareAccess:   &types.CloudflareAccess{},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].kubeconfigProvider"},
+					{field.ErrorTypeForbidden, "kubeconfigStores[1].kubeconfigProvider"},
+					{field.ErrorTypeForbidden, "kubeconfigStores[1].kubeconfigProvider"},
+				},
+			},
+		})
+	})
+
+	Context("Index encryption", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the index encryption",
+				config: &types.Config{
+					Version: "v1alpha1",
+					EncryptIndex: &types.EncryptionKeyConfig{
+						KeySource:   ptr.To(types.EncryptionKeySourceAge),
+						AgeIdentity: ptr.To("~/.config/age/key.txt"),
+					},
+				},
+			},
+			{
+				description: "should throw error - unknown key source",
+				config: &types.Config{
+					Version: "v1alpha1",
+					EncryptIndex: &types.EncryptionKeyConfig{
+						KeySource: ptr.To(types.EncryptionKeySource("vault")),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "encryptIndex.keySource"},
+				},
+			},
+			{
+				description: "should throw error - age key source without identity",
+				config: &types.Config{
+					Version: "v1alpha1",
+					EncryptIndex: &types.EncryptionKeyConfig{
+						KeySource: ptr.To(types.EncryptionKeySourceAge),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "encryptIndex.ageIdentity"},
+				},
+			},
+			{
+				description: "should throw error - unknown key source of the temporary kubeconfig encryption key",
+				config: &types.Config{
+					Version: "v1alpha1",
+					TemporaryKubeconfigEncryptionKey: &types.EncryptionKeyConfig{
+						KeySource: ptr.To(types.EncryptionKeySource("vault")),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "temporaryKubeconfigEncryptionKey.keySource"},
+				},
+			},
+		})
+	})
+
+	Context("Dashboard", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the dashboard",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Dashboard: &types.DashboardConfig{
+						Tool: ptr.To(types.DashboardToolOctant),
+						Port: ptr.To(8080),
+					},
+				},
+			},
+			{
+				description: "should throw error - unknown tool, command and invalid port",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Dashboard: &types.DashboardConfig{
+						Tool:    ptr.To(types.DashboardTool("lens")),
+						Command: ptr.To("lens --kubeconfig {{ .Kubeconfig "),
+						Port:    ptr.To(70000),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "dashboard"},
+					{field.ErrorTypeInvalid, "dashboard.tool"},
+					{field.ErrorTypeInvalid, "dashboard.command"},
+					{field.ErrorTypeInvalid, "dashboard.port"},
+				},
+			},
+		})
+	})
+
+	Context("Secret references", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate secret references",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindExoscale,
+							Config: map[interface{}]interface{}{
+								"exoscaleAPIKey":    "env://EXOSCALE_API_KEY",
+								"exoscaleSecretKey": "cmd://op read op://vault/exoscale/secret",
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - empty secret reference",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind: types.StoreKindRancher,
+							Config: map[interface{}]interface{}{
+								"rancherToken": "file://",
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].config.rancherToken"},
+				},
+			},
+		})
+	})
+
+	Context("Profiles", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate profiles",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+						},
+						{
+							ID:    ptr.To("prod"),
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/prod"},
+						},
+					},
+					Profiles: []types.Profile{
+						{
+							Name:   "work",
+							Stores: []string{"filesystem", "filesystem.prod"},
+						},
+						{
+							Name:   "oncall",
+							Stores: []string{"prod"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - duplicate profile name and unknown store",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+						},
+					},
+					Profiles: []types.Profile{
+						{
+							Name:   "work",
+							Stores: []string{"filesystem"},
+						},
+						{
+							Name:   "work",
+							Stores: []string{"gke"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeDuplicate, "profiles[1].name"},
+					{field.ErrorTypeNotFound, "profiles[1].stores[0]"},
+				},
+			},
+			{
+				description: "should not validate store references of profiles if the config includes other files",
+				config: &types.Config{
+					Version:  "v1alpha1",
+					Includes: []string{"conf.d/*.yaml"},
+					Profiles: []types.Profile{
+						{
+							Name:   "work",
+							Stores: []string{"gke"},
+						},
+					},
+				},
+			},
+		})
+	})
+
+	Context("Proxies", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate proxies",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							Proxy: &types.Proxy{
+								URL: "http://proxy.example.com:3128",
+							},
+						},
+					},
+					Proxies: []types.Proxy{
+						{
+							Contexts: []string{"*-private"},
+							URL:      "socks5://localhost:1080",
+							SSH: &types.SSHTunnel{
+								Host:         "user@bastion.example.com",
+								Args:         []string{"-i", "~/.ssh/bastion"},
+								StartTimeout: ptr.To(5 * time.Second),
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - missing patterns, invalid URLs and SSH tunnels without SOCKS5 port",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							Proxy: &types.Proxy{
+								URL: "ftp://proxy.example.com",
+							},
+						},
+					},
+					Proxies: []types.Proxy{
+						{
+							URL: "socks5://localhost:1080",
+						},
+						{
+							Contexts: []string{"*-private"},
+							URL:      "http://localhost:1080",
+							SSH: &types.SSHTunnel{
+								StartTimeout: ptr.To(time.Duration(0)),
+							},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].proxy.url"},
+					{field.ErrorTypeRequired, "proxies[0].contexts"},
+					{field.ErrorTypeRequired, "proxies[1].ssh.host"},
+					{field.ErrorTypeInvalid, "proxies[1].url"},
+					{field.ErrorTypeInvalid, "proxies[1].ssh.startTimeout"},
+				},
+			},
+		})
+	})
+
+	Context("Boundary targets", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate Boundary targets",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+						},
+					},
+					BoundaryTargets: []types.BoundaryTarget{
+						{
+							Contexts: []string{"prod-*"},
+							TargetID: "ttcp_1234567890",
+							Addr:     ptr.To("https://boundary.example.com"),
+						},
+						{
+							Contexts:        []string{"dev-*"},
+							TargetName:      "kube-apiserver",
+							TargetScopeName: "dev",
+							StartTimeout:    ptr.To(time.Minute),
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - missing patterns, missing or ambiguous targets and invalid timeouts",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+						},
+					},
+					BoundaryTargets: []types.BoundaryTarget{
+						{
+							StartTimeout: ptr.To(time.Duration(0)),
+						},
+						{
+							Contexts:   []string{"prod-*"},
+							TargetID:   "ttcp_1234567890",
+							TargetName: "kube-apiserver",
+						},
+						{
+							Contexts:   []string{"dev-*"},
+							TargetName: "kube-apiserver",
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "boundaryTargets[0].contexts"},
+					{field.ErrorTypeRequired, "boundaryTargets[0].targetID"},
+					{field.ErrorTypeInvalid, "boundaryTargets[0].startTimeout"},
+					{field.ErrorTypeForbidden, "boundaryTargets[1].targetName"},
+					{field.ErrorTypeRequired, "boundaryTargets[2].targetScopeID"},
+				},
+			},
+		})
+	})
+
+	Context("Cloudflare Access", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the Cloudflare Access login and cloudflared tunnels",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							CloudflareAccess: &types.CloudflareAccess{
+								Application: ptr.To("https://k8s.example.com"),
+							},
+						},
+					},
+					Proxies: []types.Proxy{
+						{
+							Contexts: []string{"*-zero-trust"},
+							URL:      "socks5://127.0.0.1:1234",
+							Cloudflared: &types.CloudflaredTunnel{
+								Hostname:     "k8s.example.com",
+								StartTimeout: ptr.To(5 * time.Second),
+							},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - combined with OIDC, invalid application and invalid cloudflared tunnels",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/config"},
+							OIDC:  &types.OIDCConfig{},
+							CloudflareAccess: &types.CloudflareAccess{
+								Application: ptr.To("k8s.example.com"),
+							},
+						},
+					},
+					Proxies: []types.Proxy{
+						{
+							Contexts: []string{"*-zero-trust"},
+							URL:      "socks5://127.0.0.1",
+							Cloudflared: &types.CloudflaredTunnel{
+								StartTimeout: ptr.To(time.Duration(0)),
+							},
+						},
+						{
+							Contexts:    []string{"*-private"},
+							URL:         "socks5://127.0.0.1:1080",
+							SSH:         &types.SSHTunnel{Host: "bastion.example.com"},
+							Cloudflared: &types.CloudflaredTunnel{Hostname: "k8s.example.com"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeForbidden, "kubeconfigStores[0].cloudflareAccess"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].cloudflareAccess.application"},
+					{field.ErrorTypeInvalid, "proxies[0].url"},
+					{field.ErrorTypeRequired, "proxies[0].cloudflared.hostname"},
+					{field.ErrorTypeInvalid, "proxies[0].cloudflared.startTimeout"},
+					{field.ErrorTypeForbidden, "proxies[1].cloudflared"},
+				},
+			},
+		})
+	})
+
+	Context("Failover stores", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate failover stores",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							ID:             ptr.To("hub"),
+							Kind:           types.StoreKindVault,
+							Paths:          []string{"kubeconfigs"},
+							FailoverStores: []string{"mirror"},
+						},
+						{
+							ID:    ptr.To("mirror"),
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/mirror"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - unknown, own and nested failover stores",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigStores: []types.KubeconfigStore{
+						{
+							ID:             ptr.To("hub"),
+							Kind:           types.StoreKindVault,
+							Paths:          []string{"kubeconfigs"},
+							FailoverStores: []string{"gke", "vault.hub", "mirror"},
+						},
+						{
+							ID:             ptr.To("mirror"),
+							Kind:           types.StoreKindFilesystem,
+							Paths:          []string{"~/.kube/mirror"},
+							FailoverStores: []string{"backup"},
+						},
+						{
+							ID:    ptr.To("backup"),
+							Kind:  types.StoreKindFilesystem,
+							Paths: []string{"~/.kube/backup"},
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeNotFound, "kubeconfigStores[0].failoverStores[0]"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].failoverStores[1]"},
+					{field.ErrorTypeInvalid, "kubeconfigStores[0].failoverStores[2]"},
+				},
+			},
+		})
+	})
+
+	Context("Keybindings", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate keybindings",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Keybindings: []types.Keybinding{
+						{
+							Key:    "ctrl+j",
+							Action: ptr.To(types.PickerActionDown),
+						},
+						{
+							Key:         "ctrl+y",
+							Command:     ptr.To(`printf %s "$KUBESWITCH_PATH" | pbcopy`),
+							Description: ptr.To("copy path"),
+						},
+						{
+							Key:         "ctrl+k",
+							Open:        ptr.To("k9s --readonly"),
+							Description: ptr.To("k9s"),
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid keybindings",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Keybindings: []types.Keybinding{
+						{
+							Key:    "ctrl+j",
+							Action: ptr.To(types.PickerAction("jump")),
+						},
+						{
+							Key:     "ctrl+j",
+							Command: ptr.To("echo {{ .Path }}"),
+						},
+						{
+							Key: "ctrl+o",
+						},
+						{
+							Key:     "ctrl+k",
+							Command: ptr.To("echo $KUBESWITCH_PATH"),
+							Open:    ptr.To("k9s"),
+						},
+						{
+							Key:  "ctrl+l",
+							Open: ptr.To("k9s --context {{ .Context }}"),
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "keybindings[0].action"},
+					{field.ErrorTypeDuplicate, "keybindings[1].key"},
+					{field.ErrorTypeInvalid, "keybindings[1].command"},
+					{field.ErrorTypeRequired, "keybindings[2]"},
+					{field.ErrorTypeInvalid, "keybindings[3]"},
+					{field.ErrorTypeInvalid, "keybindings[4].open"},
+				},
+			},
+		})
+	})
+
+	Context("Store icons", func() {
+		validateCases([]validationCase{
+			{
+				description: "should throw error - unknown store kind",
+				config: &types.Config{
+					Version: "v1alpha1",
+					StoreIcons: map[types.StoreKind]string{
+						types.StoreKindEKS: "aws",
+						"aks":              "azure",
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "storeIcons[aks]"},
+				},
+			},
+		})
+	})
+
+	Context("Protected contexts", func() {
+		validateCases([]validationCase{
+			{
+				description: "should throw error - empty pattern",
+				config: &types.Config{
+					Version:           "v1alpha1",
+					ProtectedContexts: []string{"*prod*", " "},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "protectedContexts[1]"},
+				},
+			},
+		})
+	})
+
+	Context("Repository contexts", func() {
+		validateCases([]validationCase{
+			{
+				description: "should throw error - neither remote nor path and missing context",
+				config: &types.Config{
+					Version: "v1alpha1",
+					RepositoryContexts: []types.RepositoryContext{
+						{Remote: ptr.To("github.com/acme/*"), Context: "dev"},
+						{Context: "dev"},
+						{Remote: ptr.To("github.com/acme/*")},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeRequired, "repositoryContexts[1]"},
+					{field.ErrorTypeRequired, "repositoryContexts[2].context"},
+				},
+			},
+		})
+	})
+
+	Context("Environments", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate environments",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Environments: []types.Environment{
+						{
+							Name:     "production",
+							Color:    "red",
+							Contexts: []string{"*prod*"},
+						},
+						{
+							Name:  "staging",
+							Color: "#ffaf00",
+							Tags:  map[string]string{"env": "staging"},
+						},
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid environments",
+				config: &types.Config{
+					Version: "v1alpha1",
+					Environments: []types.Environment{
+						{
+							Name:     "production",
+							Color:    "crimson",
+							Contexts: []string{"*prod*"},
+						},
+						{
+							Name:  "production",
+							Color: "256",
+						},
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "environments[0].color"},
+					{field.ErrorTypeDuplicate, "environments[1].name"},
+					{field.ErrorTypeInvalid, "environments[1].color"},
+					{field.ErrorTypeRequired, "environments[1]"},
+				},
+			},
+		})
+	})
+
+	Context("Kubeconfig policy", func() {
+		validateCases([]validationCase{
+			{
+				description: "should successfully validate the kubeconfig policy",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigPolicy: &types.KubeconfigPolicy{
+						StripInsecureSkipTLSVerify:             ptr.To(true),
+						ForbidStaticTokensForProtectedContexts: ptr.To(true),
+						ProxyURL:                               ptr.To("http://proxy.example.com:3128"),
+						MaxCredentialsTTL:                      ptr.To(12 * time.Hour),
+					},
+				},
+			},
+			{
+				description: "should throw error - invalid proxy URL and TTL",
+				config: &types.Config{
+					Version: "v1alpha1",
+					KubeconfigPolicy: &types.KubeconfigPolicy{
+						ProxyURL:          ptr.To("ftp://proxy.example.com"),
+						MaxCredentialsTTL: ptr.To(time.Duration(0)),
+					},
+				},
+				errors: []expectedError{
+					{field.ErrorTypeInvalid, "kubeconfigPolicy.proxyURL"},
+					{field.ErrorTypeInvalid, "kubeconfigPolicy.maxCredentialsTTL"},
+				},
+			},
+		})
+	})
+})
+
+// validationCase is a config and the errors expected when validating it. A case without errors expects a valid config.
+type validationCase struct {
+	description string
+	config      *types.Config
+	errors      []expectedError
+}
+
+// expectedError is the type and field of an expected validation error
+type expectedError struct {
+	errorType field.ErrorType
+	field     string
+}
+
+// validateCases adds a spec for each case, which validates its config
+func validateCases(cases []validationCase) {
+	for _, c := range cases {
+		c := c
+		It(c.description, func() {
+			errorList := validation.ValidateConfig(c.config)
+			if len(c.errors) == 0 {
+				Expect(errorList).To(BeEmpty())
+				return
+			}
+
+			matchers := make([]interface{}, 0, len(c.errors))
+			for _, expected := range c.errors {
+				matchers = append(matchers, PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type":  Equal(expected.errorType),
+					"Field": Equal(expected.field),
+				})))
+			}
+			Expect(errorList).To(ConsistOf(matchers...))
+		})
+	}
+}
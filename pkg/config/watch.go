@@ -0,0 +1,124 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// WatchInterval is how often the SwitchConfig files are checked for changes
+const WatchInterval = 2 * time.Second
+
+// Watch loads the SwitchConfig files in the given interval until the context is cancelled
+// and calls onChange with the description of the changes whenever the loaded configuration changes.
+// Files are compared after merging, so the includes and the project SwitchConfig are watched as well.
+// If the files cannot be loaded, onChange is called once with the error until they can be loaded again.
+func Watch(ctx context.Context, userConfigPath string, interval time.Duration, onChange func(changes []string, err error)) {
+	current, err := LoadConfig(userConfigPath)
+	if err != nil {
+		onChange(nil, err)
+	}
+	failed := err != nil
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		config, err := LoadConfig(userConfigPath)
+		if err != nil {
+			if !failed {
+				onChange(nil, err)
+			}
+			failed = true
+			continue
+		}
+		failed = false
+
+		if changes := Diff(current, config); len(changes) > 0 {
+			current = config
+			onChange(changes, nil)
+		}
+	}
+}
+
+// Diff describes the differences between both configurations: the added, removed and changed kubeconfig stores
+// and the other changed top-level fields by their name in the SwitchConfig
+func Diff(old, new *types.Config) []string {
+	if old == nil {
+		old = &types.Config{}
+	}
+	if new == nil {
+		new = &types.Config{}
+	}
+
+	oldStores := make(map[string]types.KubeconfigStore, len(old.KubeconfigStores))
+	for _, store := range old.KubeconfigStores {
+		oldStores[storeKey(store)] = store
+	}
+
+	var changes []string
+	newStores := make(map[string]bool, len(new.KubeconfigStores))
+	for _, store := range new.KubeconfigStores {
+		key := storeKey(store)
+		newStores[key] = true
+
+		oldStore, ok := oldStores[key]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("added store %s", key))
+		case !reflect.DeepEqual(oldStore, store):
+			changes = append(changes, fmt.Sprintf("changed store %s", key))
+		}
+	}
+	for _, store := range old.KubeconfigStores {
+		if key := storeKey(store); !newStores[key] {
+			changes = append(changes, fmt.Sprintf("removed store %s", key))
+		}
+	}
+
+	oldValue, newValue := reflect.ValueOf(*old), reflect.ValueOf(*new)
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if len(name) == 0 || name == "-" || name == "kubeconfigStores" {
+			continue
+		}
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			changes = append(changes, fmt.Sprintf("changed %s", name))
+		}
+	}
+	return changes
+}
+
+// storeKey identifies the kubeconfig store like the reference "<kind>.<id>"
+func storeKey(store types.KubeconfigStore) string {
+	id := "default"
+	if store.ID != nil && len(*store.ID) > 0 {
+		id = *store.ID
+	}
+	return fmt.Sprintf("%s.%s", store.Kind, id)
+}
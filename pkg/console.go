@@ -0,0 +1,58 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+)
+
+// GetConsoleURL returns the URL of the cluster in the web console of the cloud provider of the kubeconfig store
+func GetConsoleURL(kubeconfigStore storetypes.KubeconfigStore, path string, tags map[string]string) (string, error) {
+	notSupported := fmt.Errorf("kubeconfig stores of kind %q do not have a web console", kubeconfigStore.GetKind())
+
+	linker, ok := kubeconfigStore.(storetypes.ConsoleLinker)
+	if !ok {
+		return "", notSupported
+	}
+
+	url, err := linker.GetConsoleURL(path, tags)
+	if errors.Is(err, storetypes.ErrConsoleNotSupported) {
+		return "", notSupported
+	}
+	return url, err
+}
+
+// openConsole opens the page of the cluster of the context in the web console of the cloud provider
+func openConsole(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) (string, error) {
+	path := readFromContextToPathMapping(contextName)
+	kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(path)]
+	if !ok {
+		return "", fmt.Errorf("unknown kubeconfig store")
+	}
+
+	url, err := GetConsoleURL(kubeconfigStore, path, readFromPathToTagsMapping(path))
+	if err != nil {
+		return "", err
+	}
+
+	if err := util.OpenInBrowser(url); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("opened %s", url), nil
+}
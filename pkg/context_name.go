@@ -0,0 +1,305 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/becheran/wildmatch-go"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	aliasutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// contextNameData is passed to the context name template of a kubeconfig store
+type contextNameData struct {
+	// StoreID is the configured ID of the kubeconfig store containing the context
+	StoreID string
+	// StoreKind is the kind of the kubeconfig store containing the context
+	StoreKind string
+	// Path is the path of the kubeconfig in the kubeconfig store
+	Path string
+	// Prefix is the store specific prefix of the context name
+	Prefix string
+	// Name is the context name including the prefix
+	Name string
+	// Context is the context name as found in the kubeconfig
+	Context string
+	// Account is the cloud account, project or subscription of the cluster (if known to the store)
+	Account string
+	// Region is the region or zone of the cluster (if known to the store)
+	Region string
+	// Cluster is the name of the cluster. Defaults to the context name.
+	Cluster string
+	// Tags are the tags of the kubeconfig in the kubeconfig store
+	Tags map[string]string
+	// CloudTags are the cloud tags (labels) of the cluster without the "tag:" prefix, e.g. the team owning the cluster
+	CloudTags map[string]string
+}
+
+// getContextAlias returns the alias defined for the context name.
+// If there is none, the context name is generated from the context name template of the store (if configured)
+// or the first matching alias rule.
+func getContextAlias(store storetypes.KubeconfigStore, path, contextName string, tags map[string]string, contextToAliasMapping map[string]string, aliasRules []types.AliasRule) string {
+	if alias := aliasutil.GetContextForAlias(contextName, contextToAliasMapping); len(alias) > 0 {
+		return alias
+	}
+
+	name, err := getTemplatedContextName(store, path, contextName, tags)
+	if err != nil {
+		store.GetLogger().Warnf("failed to generate the name of context %q: %v", contextName, err)
+		return ""
+	}
+	if len(name) == 0 {
+		name = getRuleAlias(store, path, contextName, tags, aliasRules)
+	}
+
+	// no need for an alias if the generated name does not differ
+	if name == contextName {
+		return ""
+	}
+	return name
+}
+
+// getTemplatedContextName renders the context name template of the store for the context.
+// Returns an empty string if the store does not configure a context name template.
+func getTemplatedContextName(store storetypes.KubeconfigStore, path, contextName string, tags map[string]string) (string, error) {
+	storeConfig := store.GetStoreConfig()
+	if storeConfig.ContextNameTemplate == nil || len(*storeConfig.ContextNameTemplate) == 0 {
+		return "", nil
+	}
+
+	name, err := switchconfig.ExpandString(*storeConfig.ContextNameTemplate, newContextNameData(store, path, contextName, tags), nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(name), nil
+}
+
+// getRuleAlias returns the alias generated by the first alias rule matching the context.
+// Rules whose template uses a tag that is not set are skipped.
+// Returns an empty string if no rule generates an alias.
+func getRuleAlias(store storetypes.KubeconfigStore, path, contextName string, tags map[string]string, aliasRules []types.AliasRule) string {
+	for i, rule := range aliasRules {
+		if !aliasRuleMatches(rule, store, contextName, tags) {
+			continue
+		}
+
+		alias, err := switchconfig.ExpandString(rule.Template, newContextNameData(store, path, contextName, tags), nil)
+		if err != nil {
+			store.GetLogger().Debugf("alias rule %d does not apply to context %q: %v", i, contextName, err)
+			continue
+		}
+		if alias = strings.TrimSpace(alias); len(alias) > 0 {
+			return alias
+		}
+	}
+	return ""
+}
+
+// aliasRuleMatches returns true if the alias rule applies to the context of the kubeconfig store
+func aliasRuleMatches(rule types.AliasRule, store storetypes.KubeconfigStore, contextName string, tags map[string]string) bool {
+	if len(rule.Stores) > 0 && !slices.ContainsFunc(rule.Stores, func(reference string) bool {
+		return switchconfig.StoreMatchesReference(store.GetStoreConfig(), reference)
+	}) {
+		return false
+	}
+
+	if len(rule.Contexts) > 0 && !slices.ContainsFunc(rule.Contexts, func(pattern string) bool {
+		return wildmatch.NewWildMatch(pattern).IsMatch(contextName)
+	}) {
+		return false
+	}
+
+	for key, value := range rule.Tags {
+		if tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// newContextNameData returns the fields of the context name templates and the alias rules for the context
+func newContextNameData(store storetypes.KubeconfigStore, path, contextName string, tags map[string]string) contextNameData {
+	prefix := store.GetContextPrefix(path)
+	data := contextNameData{
+		StoreID:   configuredStoreID(store),
+		StoreKind: string(store.GetKind()),
+		Path:      path,
+		Prefix:    prefix,
+		Name:      contextName,
+		Context:   strings.TrimPrefix(contextName, fmt.Sprintf("%s/", prefix)),
+		Account:   AccountOf(tags),
+		Region:    RegionOf(tags),
+		Cluster:   ClusterOf(tags),
+		Tags:      tags,
+		CloudTags: storetypes.CloudTags(tags),
+	}
+	if len(prefix) == 0 {
+		data.Context = contextName
+	}
+	if len(data.Cluster) == 0 {
+		data.Cluster = data.Context
+	}
+	return data
+}
+
+// configuredStoreID returns the ID of the kubeconfig store as configured by the user
+func configuredStoreID(store storetypes.KubeconfigStore) string {
+	if id := store.GetStoreConfig().ID; id != nil && len(*id) > 0 {
+		return *id
+	}
+	return store.GetID()
+}
+
+// AccountOf returns the cloud account, project or subscription tagged by the kubeconfig store
+func AccountOf(tags map[string]string) string {
+	return firstTag(tags, "account", "project", "subscription")
+}
+
+// RegionOf returns the region or zone tagged by the kubeconfig store
+func RegionOf(tags map[string]string) string {
+	return firstTag(tags, "region", "zone", "location")
+}
+
+// ClusterOf returns the cluster name tagged by the kubeconfig store
+func ClusterOf(tags map[string]string) string {
+	return firstTag(tags, "cluster", "name")
+}
+
+// firstTag returns the value of the first of the given tags that is set
+func firstTag(tags map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := tags[key]; len(value) > 0 {
+			return value
+		}
+	}
+	return ""
+}
+
+// contextNameResolver disambiguates context names that are found in more than one kubeconfig.
+// The context resolved first keeps its name, the others get the configured suffix.
+// The contexts are resolved in a fixed order (see orderedResults), so that the names do not depend on which kubeconfig store answers first.
+type contextNameResolver struct {
+	lock   sync.Mutex
+	suffix types.CollisionSuffix
+	warn   func(format string, args ...interface{})
+	// owners maps a context name (or alias) to the context it was given to
+	owners map[string]DiscoveredContext
+	// resolved maps a context of a kubeconfig to the name it was given.
+	// The same context can be found more than once (e.g. from the index and the store).
+	resolved map[string]string
+}
+
+func newContextNameResolver(config *types.Config, warn func(format string, args ...interface{})) *contextNameResolver {
+	suffix := types.CollisionSuffixStoreID
+	if config != nil && config.CollisionSuffix != nil {
+		suffix = *config.CollisionSuffix
+	}
+
+	return &contextNameResolver{
+		suffix:   suffix,
+		warn:     warn,
+		owners:   make(map[string]DiscoveredContext),
+		resolved: make(map[string]string),
+	}
+}
+
+// resolve sets the alias of the discovered context if its name was already given to another context
+func (r *contextNameResolver) resolve(discoveredContext DiscoveredContext) DiscoveredContext {
+	store := *discoveredContext.Store
+	key := contextKey(discoveredContext)
+
+	name := discoveredContext.Name
+	if len(discoveredContext.Alias) > 0 {
+		name = discoveredContext.Alias
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if resolved, ok := r.resolved[key]; ok {
+		if resolved != discoveredContext.Name {
+			discoveredContext.Alias = resolved
+		}
+		return discoveredContext
+	}
+
+	owner, taken := r.owners[name]
+	if !taken {
+		r.owners[name] = discoveredContext
+		r.resolved[key] = name
+		return discoveredContext
+	}
+
+	unique := r.uniqueName(name, discoveredContext)
+	r.owners[unique] = discoveredContext
+	r.resolved[key] = unique
+	discoveredContext.Alias = unique
+
+	// the same cluster found in another kubeconfig store is expected to have the same context name
+	if len(discoveredContext.DuplicateOf) == 0 {
+		r.warn("context %q of kubeconfig store %q (path %q) is also found in kubeconfig store %q (path %q) and was renamed to %q",
+			name, store.GetID(), discoveredContext.Path, (*owner.Store).GetID(), owner.Path, unique)
+	}
+	return discoveredContext
+}
+
+// nameOf returns the name given to the discovered context.
+// Falls back to its alias or name if it has not been resolved yet.
+func (r *contextNameResolver) nameOf(discoveredContext DiscoveredContext) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if resolved, ok := r.resolved[contextKey(discoveredContext)]; ok {
+		return resolved
+	}
+	if len(discoveredContext.Alias) > 0 {
+		return discoveredContext.Alias
+	}
+	return discoveredContext.Name
+}
+
+// contextKey identifies a context of a kubeconfig in a kubeconfig store
+func contextKey(discoveredContext DiscoveredContext) string {
+	return fmt.Sprintf("%s/%s/%s", (*discoveredContext.Store).GetID(), discoveredContext.Path, discoveredContext.Name)
+}
+
+// uniqueName appends the configured suffix to the context name
+func (r *contextNameResolver) uniqueName(name string, discoveredContext DiscoveredContext) string {
+	var suffix string
+	switch r.suffix {
+	case types.CollisionSuffixAccount:
+		suffix = AccountOf(discoveredContext.Tags)
+	case types.CollisionSuffixRegion:
+		suffix = RegionOf(discoveredContext.Tags)
+	}
+	if len(suffix) == 0 {
+		suffix = configuredStoreID(*discoveredContext.Store)
+	}
+
+	unique := fmt.Sprintf("%s@%s", name, suffix)
+	for i := 2; ; i++ {
+		if _, taken := r.owners[unique]; !taken {
+			return unique
+		}
+		unique = fmt.Sprintf("%s@%s-%d", name, suffix, i)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// service is the service name under which all secrets are stored in the OS keychain
+	service = "kubeswitch"
+	// encryptionKeyAccount is the account under which the key encrypting the temporary kubeconfig files
+	// and the search index is stored in the OS keychain
+	encryptionKeyAccount = "encryption-key"
+)
+
+// ErrNotFound is returned if the OS keychain does not contain the requested secret
+var ErrNotFound = errors.New("secret not found in the OS keychain")
+
+// SecretFields are the configuration fields of each kubeconfig store that contain secrets
+// and can therefore be read from the OS keychain
+var SecretFields = map[types.StoreKind][]string{
+	types.StoreKindVault:    {"vaultToken"},
+	types.StoreKindExoscale: {"exoscaleAPIKey", "exoscaleSecretKey"},
+	types.StoreKindRancher:  {"rancherToken"},
+	types.StoreKindOVH:      {"application_key", "application_secret", "consumer_key"},
+	types.StoreKindScaleway: {"access_key", "secret_key"},
+	types.StoreKindAkamai:   {"linode_token"},
+}
+
+// StoreID returns the ID of the kubeconfig store used to identify its secrets in the OS keychain
+func StoreID(store types.KubeconfigStore) string {
+	id := "default"
+	if store.ID != nil && len(*store.ID) > 0 {
+		id = *store.ID
+	}
+	return fmt.Sprintf("%s.%s", store.Kind, id)
+}
+
+func account(storeID, field string) string {
+	return fmt.Sprintf("%s/%s", storeID, field)
+}
+
+// Get reads the secret of the given store field from the OS keychain
+func Get(storeID, field string) (string, error) {
+	return keychainGet(account(storeID, field))
+}
+
+// Set writes the secret of the given store field to the OS keychain
+func Set(storeID, field, secret string) error {
+	return keychainSet(account(storeID, field), secret)
+}
+
+// Delete removes the secret of the given store field from the OS keychain
+func Delete(storeID, field string) error {
+	return keychainDelete(account(storeID, field))
+}
+
+// GetEncryptionKey reads the encryption key of the temporary kubeconfig files and the search index from the OS keychain
+func GetEncryptionKey() (string, error) {
+	return keychainGet(encryptionKeyAccount)
+}
+
+// SetEncryptionKey writes the encryption key of the temporary kubeconfig files and the search index to the OS keychain
+func SetEncryptionKey(key string) error {
+	return keychainSet(encryptionKeyAccount, key)
+}
+
+// InjectSecrets sets the secret fields of the kubeconfig store configuration that are not configured in the SwitchConfig
+// to the secrets stored in the OS keychain.
+// Secrets configured in the SwitchConfig take precedence.
+func InjectSecrets(store *types.KubeconfigStore) error {
+	fields, ok := SecretFields[store.Kind]
+	if !ok {
+		return nil
+	}
+
+	config, ok := store.Config.(map[interface{}]interface{})
+	if !ok {
+		if store.Config != nil {
+			return fmt.Errorf("unexpected configuration format of kubeconfig store %q", StoreID(*store))
+		}
+		config = map[interface{}]interface{}{}
+	}
+
+	storeID := StoreID(*store)
+	injected := false
+	for _, field := range fields {
+		if value, ok := config[field]; ok && value != nil && value != "" {
+			continue
+		}
+
+		secret, err := Get(storeID, field)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			// the keychain may not be available (e.g. headless systems). Stores fail later with a descriptive error if a secret is missing.
+			logrus.Debugf("failed to read secret %q of store %q from the OS keychain: %v", field, storeID, err)
+			return nil
+		}
+
+		config[field] = secret
+		injected = true
+	}
+
+	if injected {
+		store.Config = config
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// security exits with 44 if the item could not be found
+const securityErrItemNotFound = 44
+
+// the macOS keychain is accessed via the security CLI
+func keychainGet(account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", securityError(err, stderr.String())
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keychainSet(account, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return fmt.Errorf("failed to access the macOS keychain: secrets must not contain line breaks")
+	}
+
+	// the command is read from stdin in interactive mode so that the secret does not show up in the process list.
+	// -U updates an existing item
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", securityQuote(service), securityQuote(account), securityQuote(secret)))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return securityError(err, stderr.String())
+	}
+	// the interactive mode does not exit with the status of the failed command
+	if stderr.Len() > 0 {
+		return securityError(errors.New("add-generic-password failed"), stderr.String())
+	}
+	return nil
+}
+
+func keychainDelete(account string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "delete-generic-password", "-s", service, "-a", account)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return securityError(err, stderr.String())
+	}
+	return nil
+}
+
+// securityQuote quotes the argument of a command read by `security -i`
+func securityQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func securityError(err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityErrItemNotFound {
+		return ErrNotFound
+	}
+	if stderr = strings.TrimSpace(stderr); len(stderr) > 0 {
+		return fmt.Errorf("failed to access the macOS keychain: %v: %s", err, stderr)
+	}
+	return fmt.Errorf("failed to access the macOS keychain: %v", err)
+}
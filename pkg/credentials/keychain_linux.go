@@ -0,0 +1,67 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// libsecret (e.g. GNOME Keyring, KWallet) is accessed via the secret-tool CLI
+func keychainGet(account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits with 1 and without output if the secret could not be found
+		if _, isExitErr := err.(*exec.ExitError); isExitErr && stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", secretToolError(err, stderr.String())
+	}
+	return string(out), nil
+}
+
+func keychainSet(account, secret string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "store", "--label", fmt.Sprintf("%s %s", service, account), "service", service, "account", account)
+	// the secret is read from stdin so that it does not show up in the process list
+	cmd.Stdin = strings.NewReader(secret)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return secretToolError(err, stderr.String())
+	}
+	return nil
+}
+
+func keychainDelete(account string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "clear", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return secretToolError(err, stderr.String())
+	}
+	return nil
+}
+
+func secretToolError(err error, stderr string) error {
+	if stderr = strings.TrimSpace(stderr); len(stderr) > 0 {
+		return fmt.Errorf("failed to access the secret service via secret-tool (libsecret): %v: %s", err, stderr)
+	}
+	return fmt.Errorf("failed to access the secret service via secret-tool (libsecret): %v", err)
+}
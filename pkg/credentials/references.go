@@ -0,0 +1,133 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// referencePrefixEnv references a secret in an environment variable, e.g. env://EXOSCALE_API_KEY
+	referencePrefixEnv = "env://"
+	// referencePrefixFile references a secret in a file, e.g. file:///path/to/secret
+	referencePrefixFile = "file://"
+	// referencePrefixCmd references a secret printed to stdout by a shell command, e.g. cmd://op read op://vault/item/field
+	referencePrefixCmd = "cmd://"
+)
+
+// IsReference returns true if the value is a secret reference
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, referencePrefixEnv) ||
+		strings.HasPrefix(value, referencePrefixFile) ||
+		strings.HasPrefix(value, referencePrefixCmd)
+}
+
+// ValidateReference validates the syntax of the secret reference without resolving it
+func ValidateReference(reference string) error {
+	for _, prefix := range []string{referencePrefixEnv, referencePrefixFile, referencePrefixCmd} {
+		if target, found := strings.CutPrefix(reference, prefix); found {
+			if len(strings.TrimSpace(target)) == 0 {
+				return fmt.Errorf("secret reference %q does not reference anything", reference)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown secret reference %q", reference)
+}
+
+// ResolveReferences replaces the secret references (env://, file:// and cmd://) in the secret fields
+// of the kubeconfig store configuration with the referenced secrets
+func ResolveReferences(store *types.KubeconfigStore) error {
+	fields, ok := SecretFields[store.Kind]
+	if !ok {
+		return nil
+	}
+
+	config, ok := store.Config.(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, field := range fields {
+		value, ok := config[field].(string)
+		if !ok || !IsReference(value) {
+			continue
+		}
+
+		secret, err := ResolveReference(value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret reference of field %q of kubeconfig store %q: %v", field, StoreID(*store), err)
+		}
+		config[field] = secret
+	}
+	return nil
+}
+
+// ResolveReference returns the secret referenced by the given secret reference
+func ResolveReference(reference string) (string, error) {
+	var secret string
+	switch {
+	case strings.HasPrefix(reference, referencePrefixEnv):
+		name := strings.TrimPrefix(reference, referencePrefixEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set", name)
+		}
+		secret = value
+
+	case strings.HasPrefix(reference, referencePrefixFile):
+		path := util.ExpandEnv(strings.TrimPrefix(reference, referencePrefixFile))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %v", err)
+		}
+		secret = string(content)
+
+	case strings.HasPrefix(reference, referencePrefixCmd):
+		command := strings.TrimPrefix(reference, referencePrefixCmd)
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("command %q failed: %v: %s", command, err, strings.TrimSpace(stderr.String()))
+		}
+		secret = string(out)
+
+	default:
+		return "", fmt.Errorf("unknown secret reference %q", reference)
+	}
+
+	// files and command outputs usually end with a newline
+	secret = strings.TrimRight(secret, "\r\n")
+	if len(secret) == 0 {
+		return "", fmt.Errorf("the referenced secret is empty")
+	}
+	return secret, nil
+}
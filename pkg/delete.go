@@ -0,0 +1,61 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+)
+
+// deleteContextsFromIndex removes the contexts from the search index of their kubeconfig stores and
+// evicts their kubeconfigs from the cache of the stores, like "switch delete-context"
+func deleteContextsFromIndex(storeIDToStore map[string]storetypes.KubeconfigStore, stateDir string, contextNames []string) (string, error) {
+	if dryrun.Enabled() {
+		return fmt.Sprintf("would remove %d contexts from the index", len(contextNames)), nil
+	}
+
+	for _, contextName := range contextNames {
+		path := readFromContextToPathMapping(contextName)
+		kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(path)]
+		if !ok {
+			return "", fmt.Errorf("unknown kubeconfig store of context %q", contextName)
+		}
+
+		// the index contains the context names without aliases
+		name := contextName
+		if original := readFromAliasToContext(contextName); len(original) > 0 {
+			name = original
+		}
+
+		searchIndex, err := index.New(kubeconfigStore.GetLogger(), kubeconfigStore.GetKind(), stateDir, kubeconfigStore.GetID())
+		if err != nil {
+			return "", err
+		}
+		if _, err := searchIndex.RemoveContext(name); err != nil {
+			return "", fmt.Errorf("failed to remove context %q from the index of store %q: %v", name, kubeconfigStore.GetID(), err)
+		}
+
+		if evictable, ok := kubeconfigStore.(cache.Evictable); ok {
+			if _, err := evictable.Evict(path); err != nil {
+				return "", err
+			}
+		}
+	}
+	return fmt.Sprintf("removed %d contexts from the index", len(contextNames)), nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// clusterDeduplicator detects contexts of the same cluster that are found in more than one kubeconfig store,
+// e.g. a cluster exported to a kubeconfig file that is also discovered via the API of the cloud provider.
+type clusterDeduplicator struct {
+	lock sync.Mutex
+	mode *types.DuplicateClusters
+	// owners maps the identity of a cluster to the first context resolved for it (see orderedResults)
+	owners map[string]DiscoveredContext
+}
+
+func newClusterDeduplicator(config *types.Config) *clusterDeduplicator {
+	var mode *types.DuplicateClusters
+	if config != nil {
+		mode = config.DuplicateClusters
+	}
+
+	return &clusterDeduplicator{
+		mode:   mode,
+		owners: make(map[string]DiscoveredContext),
+	}
+}
+
+// ownerOf returns the context of another kubeconfig store that was resolved first for the cluster of the discovered context.
+// Returns nil if the discovered context is the first context resolved for its cluster, or if duplicate clusters are not detected.
+// Contexts of the same kubeconfig store are never duplicates, e.g. contexts of the same cluster with different users.
+func (d *clusterDeduplicator) ownerOf(discoveredContext DiscoveredContext) *DiscoveredContext {
+	if d.mode == nil {
+		return nil
+	}
+
+	identity := clusterIdentity(discoveredContext.Server, discoveredContext.CAHash)
+	if len(identity) == 0 {
+		return nil
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	owner, ok := d.owners[identity]
+	if !ok {
+		d.owners[identity] = discoveredContext
+		return nil
+	}
+	if (*owner.Store).GetID() == (*discoveredContext.Store).GetID() {
+		return nil
+	}
+	return &owner
+}
+
+// clusterIdentity identifies a cluster by the URL of its API server and the hash of its certificate authority.
+// Returns an empty string if the API server is not known.
+func clusterIdentity(server, caHash string) string {
+	if len(server) == 0 {
+		return ""
+	}
+
+	if u, err := url.Parse(server); err == nil && len(u.Host) > 0 {
+		server = fmt.Sprintf("%s://%s%s", strings.ToLower(u.Scheme), strings.ToLower(u.Host), strings.TrimSuffix(u.Path, "/"))
+	}
+	return fmt.Sprintf("%s#%s", server, caHash)
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Encrypt encrypts the plaintext with AES-GCM and returns the base64 encoded nonce and ciphertext
+func Encrypt(key, plaintext []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decrypt decrypts the base64 encoded data created by Encrypt
+func Decrypt(key []byte, data string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted data: %v", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data. Has the encryption key changed?: %v", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptKubeconfigCredentials replaces the static credentials of all users in the kubeconfig with
+// an exec credential plugin calling the switcher binary with the encrypted credentials as argument.
+// This way, the kubeconfig written to disk does not contain any plaintext credentials.
+func EncryptKubeconfigCredentials(kubeconfig *kubeconfigutil.Kubeconfig, keyConfig *types.EncryptionKeyConfig, stateDir string) error {
+	key, err := LoadKey(keyConfig, stateDir)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine path of the switcher binary: %v", err)
+	}
+
+	return kubeconfig.ReplaceUserCredentials(func(_ string, credentials kubeconfigutil.UserCredentials) (*kubeconfigutil.ExecConfig, error) {
+		plaintext, err := json.Marshal(credentials)
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted, err := Encrypt(key, plaintext)
+		if err != nil {
+			return nil, err
+		}
+
+		return &kubeconfigutil.ExecConfig{
+			APIVersion:      kubeconfigutil.ExecCredentialAPIVersion,
+			Command:         executable,
+			Args:            append([]string{kubeconfigutil.ExecCredentialCommand, "--state-directory", stateDir, "--encrypted-data", encrypted}, keyArgs(keyConfig)...),
+			InteractiveMode: "Never",
+		}, nil
+	})
+}
+
+// GetExecCredential decrypts the credentials created by EncryptKubeconfigCredentials and returns them as ExecCredential JSON
+func GetExecCredential(keyConfig *types.EncryptionKeyConfig, stateDir, encrypted string) ([]byte, error) {
+	key, err := LoadKey(keyConfig, stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := Decrypt(key, encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := kubeconfigutil.UserCredentials{}
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decrypted credentials: %v", err)
+	}
+
+	return credentials.ExecCredential(nil)
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/encryption"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Encryption", func() {
+	var (
+		stateDir  string
+		key       []byte
+		fileKey   *types.EncryptionKeyConfig
+		oldEnvKey string
+		hadEnvKey bool
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = os.MkdirTemp("", "encryption")
+		Expect(err).ToNot(HaveOccurred())
+
+		key = bytes.Repeat([]byte{1}, 32)
+		fileKey = &types.EncryptionKeyConfig{KeySource: ptr.To(types.EncryptionKeySourceFile)}
+
+		oldEnvKey, hadEnvKey = os.LookupEnv(encryption.EnvEncryptionKey)
+		Expect(os.Unsetenv(encryption.EnvEncryptionKey)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		if hadEnvKey {
+			Expect(os.Setenv(encryption.EnvEncryptionKey, oldEnvKey)).To(Succeed())
+		} else {
+			Expect(os.Unsetenv(encryption.EnvEncryptionKey)).To(Succeed())
+		}
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+	})
+
+	Context("Encrypt and Decrypt", func() {
+		It("should decrypt the encrypted data", func() {
+			encrypted, err := encryption.Encrypt(key, []byte("secret"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encrypted).ToNot(ContainSubstring("secret"))
+
+			Expect(encryption.Decrypt(key, encrypted)).To(Equal([]byte("secret")))
+		})
+
+		It("should use a new nonce for every encryption", func() {
+			first, err := encryption.Encrypt(key, []byte("secret"))
+			Expect(err).ToNot(HaveOccurred())
+			second, err := encryption.Encrypt(key, []byte("secret"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(first).ToNot(Equal(second))
+		})
+
+		It("should fail to decrypt with another key", func() {
+			encrypted, err := encryption.Encrypt(key, []byte("secret"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = encryption.Decrypt(bytes.Repeat([]byte{2}, 32), encrypted)
+			Expect(err).To(MatchError(ContainSubstring("Has the encryption key changed?")))
+		})
+
+		It("should fail to decrypt tampered data", func() {
+			encrypted, err := encryption.Encrypt(key, []byte("secret"))
+			Expect(err).ToNot(HaveOccurred())
+			data, err := base64.StdEncoding.DecodeString(encrypted)
+			Expect(err).ToNot(HaveOccurred())
+			data[len(data)-1] ^= 1
+
+			_, err = encryption.Decrypt(key, base64.StdEncoding.EncodeToString(data))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail to decrypt invalid data", func() {
+			_, err := encryption.Decrypt(key, "not base64!")
+			Expect(err).To(MatchError(ContainSubstring("failed to decode encrypted data")))
+
+			_, err = encryption.Decrypt(key, base64.StdEncoding.EncodeToString([]byte("short")))
+			Expect(err).To(MatchError("encrypted data is too short"))
+		})
+	})
+
+	Context("LoadKey", func() {
+		It("should prefer the key of the environment variable", func() {
+			Expect(os.Setenv(encryption.EnvEncryptionKey, base64.StdEncoding.EncodeToString(key))).To(Succeed())
+
+			Expect(encryption.LoadKey(fileKey, stateDir)).To(Equal(key))
+			Expect(filepath.Join(stateDir, "switch.encryption.key")).ToNot(BeAnExistingFile())
+		})
+
+		It("should reject a key of the environment variable with the wrong size", func() {
+			Expect(os.Setenv(encryption.EnvEncryptionKey, base64.StdEncoding.EncodeToString([]byte("short")))).To(Succeed())
+
+			_, err := encryption.LoadKey(fileKey, stateDir)
+			Expect(err).To(MatchError(ContainSubstring("must be 32 bytes long")))
+		})
+
+		It("should generate the key file readable only by the user and reuse it", func() {
+			first, err := encryption.LoadKey(fileKey, stateDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first).To(HaveLen(32))
+
+			info, err := os.Stat(filepath.Join(stateDir, "switch.encryption.key"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			Expect(encryption.LoadKey(fileKey, stateDir)).To(Equal(first))
+		})
+
+		It("should reject a corrupt key file", func() {
+			Expect(os.WriteFile(filepath.Join(stateDir, "switch.encryption.key"), []byte("short"), 0600)).To(Succeed())
+
+			_, err := encryption.LoadKey(fileKey, stateDir)
+			Expect(err).To(MatchError(ContainSubstring("is corrupt")))
+		})
+
+		It("should require an age identity for the key source age", func() {
+			_, err := encryption.LoadKey(&types.EncryptionKeyConfig{KeySource: ptr.To(types.EncryptionKeySourceAge)}, stateDir)
+			Expect(err).To(MatchError(ContainSubstring("an age identity is required")))
+		})
+
+		It("should reject an unknown key source", func() {
+			_, err := encryption.LoadKey(&types.EncryptionKeyConfig{KeySource: ptr.To(types.EncryptionKeySource("vault"))}, stateDir)
+			Expect(err).To(MatchError(`unknown key source "vault"`))
+		})
+	})
+
+	Context("EncryptKubeconfigCredentials", func() {
+		It("should replace the credentials with the exec credential plugin returning the decrypted credentials", func() {
+			kubeconfig, err := kubeconfigutil.NewKubeconfig([]byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+users:
+- name: admin
+  user:
+    token: secret-token
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(encryption.EncryptKubeconfigCredentials(kubeconfig, fileKey, stateDir)).To(Succeed())
+
+			data, err := kubeconfig.GetBytes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).ToNot(ContainSubstring("secret-token"))
+
+			credentials, err := kubeconfig.GetUserCredentials("admin")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credentials.Token).To(BeEmpty())
+
+			var encrypted string
+			Expect(kubeconfig.ReplaceUserExecConfigs(func(_ string, current *kubeconfigutil.ExecConfig) (*kubeconfigutil.ExecConfig, error) {
+				Expect(current.Args).To(ContainElements("--state-directory", stateDir, "--key-source", "file"))
+				for i, arg := range current.Args {
+					if arg == "--encrypted-data" {
+						encrypted = current.Args[i+1]
+					}
+				}
+				return nil, nil
+			})).To(Succeed())
+			Expect(encrypted).ToNot(BeEmpty())
+
+			execCredential, err := encryption.GetExecCredential(fileKey, stateDir, encrypted)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(execCredential)).To(ContainSubstring(`"token":"secret-token"`))
+		})
+	})
+})
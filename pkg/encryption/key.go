@@ -0,0 +1,210 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/credentials"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// keyFileName is the name of the file in the state directory containing the plaintext encryption key
+	// of the key source "file"
+	keyFileName = "switch.encryption.key"
+	// ageKeyFileName is the name of the file in the state directory containing the encryption key
+	// encrypted with an age identity
+	ageKeyFileName = "switch.encryption.key.age"
+	// EnvEncryptionKey is the environment variable that can contain the base64 encoded encryption key
+	// Takes precedence over the configured key source
+	EnvEncryptionKey = "SWITCH_ENCRYPTION_KEY"
+	// keySize is the size of the AES-256 key
+	keySize = 32
+)
+
+// LoadKey returns the encryption key.
+// The key is read from the environment variable SWITCH_ENCRYPTION_KEY or from the configured key source (defaults to the OS keychain).
+// If the key source does not contain a key yet, a new key is generated and stored in the key source.
+func LoadKey(config *types.EncryptionKeyConfig, stateDir string) ([]byte, error) {
+	if encoded := os.Getenv(EnvEncryptionKey); len(encoded) > 0 {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key from environment variable %s: %v", EnvEncryptionKey, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key from environment variable %s must be %d bytes long", EnvEncryptionKey, keySize)
+		}
+		return key, nil
+	}
+
+	keySource := types.EncryptionKeySourceKeychain
+	if config != nil && config.KeySource != nil {
+		keySource = *config.KeySource
+	}
+
+	switch keySource {
+	case types.EncryptionKeySourceKeychain:
+		return loadKeychainKey()
+	case types.EncryptionKeySourceAge:
+		if config.AgeIdentity == nil || len(*config.AgeIdentity) == 0 {
+			return nil, fmt.Errorf("an age identity is required for the key source %q", keySource)
+		}
+		return loadAgeKey(util.ExpandEnv(*config.AgeIdentity), stateDir)
+	case types.EncryptionKeySourceFile:
+		return loadFileKey(stateDir)
+	default:
+		return nil, fmt.Errorf("unknown key source %q", keySource)
+	}
+}
+
+// keyArgs returns the arguments of the exec credential plugin that select the configured key source
+func keyArgs(config *types.EncryptionKeyConfig) []string {
+	if config == nil || config.KeySource == nil {
+		return nil
+	}
+
+	args := []string{"--key-source", string(*config.KeySource)}
+	if *config.KeySource == types.EncryptionKeySourceAge && config.AgeIdentity != nil {
+		args = append(args, "--age-identity", *config.AgeIdentity)
+	}
+	return args
+}
+
+// loadKeychainKey reads the base64 encoded key from the OS keychain
+func loadKeychainKey() ([]byte, error) {
+	encoded, err := credentials.GetEncryptionKey()
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("encryption key in the OS keychain is corrupt")
+		}
+		return key, nil
+	}
+	if !errors.Is(err, credentials.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read the encryption key from the OS keychain. Configure the key source %q or set the environment variable %s: %v", types.EncryptionKeySourceAge, EnvEncryptionKey, err)
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := credentials.SetEncryptionKey(base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to store encryption key in the OS keychain. Configure the key source %q or set the environment variable %s: %v", types.EncryptionKeySourceAge, EnvEncryptionKey, err)
+	}
+	return key, nil
+}
+
+// loadAgeKey decrypts the key file in the state directory with the age identity.
+// The key is only decrypted in memory.
+func loadAgeKey(identity, stateDir string) ([]byte, error) {
+	keyFilepath := filepath.Join(stateDir, ageKeyFileName)
+	if _, err := os.Stat(keyFilepath); err == nil {
+		key, err := runAge(nil, "--decrypt", "--identity", identity, keyFilepath)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key file %q is corrupt", keyFilepath)
+		}
+		return key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read encryption key file %q: %v", keyFilepath, err)
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := permissions.MkdirAll(stateDir); err != nil {
+		return nil, err
+	}
+
+	// the key is encrypted to the recipients of the identity
+	if _, err := runAge(key, "--encrypt", "--identity", identity, "--output", keyFilepath); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// loadFileKey reads the plaintext key file in the state directory.
+// Only used if the key source "file" is configured explicitly, as the key is stored next to the data it encrypts.
+func loadFileKey(stateDir string) ([]byte, error) {
+	keyFilepath := filepath.Join(stateDir, keyFileName)
+	key, err := os.ReadFile(keyFilepath)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key file %q is corrupt", keyFilepath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read encryption key file %q: %v", keyFilepath, err)
+	}
+
+	key, err = generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := permissions.MkdirAll(stateDir); err != nil {
+		return nil, err
+	}
+
+	if err := filelock.WriteFile(keyFilepath, key, permissions.FileMode); err != nil {
+		return nil, fmt.Errorf("failed to write encryption key file %q: %v", keyFilepath, err)
+	}
+	logrus.Warnf("The encryption key is stored unencrypted in %q. Anyone who can read it can decrypt the encrypted data. Use the key source %q or %q to protect it.", keyFilepath, types.EncryptionKeySourceKeychain, types.EncryptionKeySourceAge)
+	return key, nil
+}
+
+// generateKey returns a new random AES-256 key
+func generateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %v", err)
+	}
+	return key, nil
+}
+
+func runAge(stdin []byte, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			return nil, fmt.Errorf("failed to run age: %v: %s", err, msg)
+		}
+		return nil, fmt.Errorf("failed to run age: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Exclusions match the contexts that are never shown in the search results
+type Exclusions struct {
+	wildcards []*wildmatch.WildMatch
+	regexps   []*regexp.Regexp
+}
+
+// NewExclusions creates the exclusions for the given exclude patterns.
+// Patterns prefixed with "regex:" are regular expressions, all other patterns are wildcard patterns.
+// Invalid regular expressions are rejected by the validation of the SwitchConfig and ignored here.
+func NewExclusions(patterns ...[]string) Exclusions {
+	var e Exclusions
+	for _, p := range patterns {
+		for _, pattern := range p {
+			if expression, isRegex := strings.CutPrefix(pattern, types.ExcludePatternRegexPrefix); isRegex {
+				if re, err := regexp.Compile(expression); err == nil {
+					e.regexps = append(e.regexps, re)
+				}
+				continue
+			}
+			if len(pattern) > 0 {
+				e.wildcards = append(e.wildcards, wildmatch.NewWildMatch(pattern))
+			}
+		}
+	}
+	return e
+}
+
+// storeExclusions returns the global exclusions and the exclusions of the kubeconfig store
+func storeExclusions(config *types.Config, storeConfig types.KubeconfigStore) Exclusions {
+	var global []string
+	if config != nil {
+		global = config.ExcludePatterns
+	}
+	return NewExclusions(global, storeConfig.ExcludePatterns)
+}
+
+// Excludes returns true if any pattern matches the kubeconfig path or one of the names of the context, e.g. its name and alias
+func (e Exclusions) Excludes(path string, names ...string) bool {
+	for _, text := range append([]string{path}, names...) {
+		if len(text) == 0 {
+			continue
+		}
+		for _, w := range e.wildcards {
+			if w.IsMatch(text) {
+				return true
+			}
+		}
+		for _, re := range e.regexps {
+			if re.MatchString(text) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fzf implements the picker using an external fzf binary.
+package fzf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// exitNoMatch is the exit code of fzf if there is no match
+	exitNoMatch = 1
+	// exitInterrupted is the exit code of fzf if the selection was aborted with ctrl-c or esc
+	exitInterrupted = 130
+	// fieldDelimiter separates the icon, the context name and the metadata of a line
+	fieldDelimiter = "\t"
+)
+
+// Picker pipes the context names to fzf while the search is still running.
+// fzf is configured by the user, e.g. via the environment variable FZF_DEFAULT_OPTS.
+type Picker struct {
+	mutex  sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout bytes.Buffer
+	closed bool
+	// icons configures if the context names are prefixed with an icon separated by a tab
+	icons bool
+	// metadata configures if the context names are followed by their metadata separated by a tab
+	metadata bool
+}
+
+// New starts fzf. If icons is true, each context name is shown with an icon that is excluded from the search.
+// If metadata is true, each context name is followed by its metadata that is searched as well.
+// The optional matching algorithm and case sensitivity override the options of the user.
+// fzf has no word matching, hence the "exact" matching algorithm matches substrings like "substring".
+// Returns an error if fzf is not installed.
+func New(icons, metadata bool, algorithm *types.MatchingAlgorithm, caseSensitivity *types.CaseSensitivity) (*Picker, error) {
+	path, err := exec.LookPath("fzf")
+	if err != nil {
+		return nil, fmt.Errorf("picker \"fzf\" requires fzf to be installed: %v", err)
+	}
+
+	// context names may be colored by environment
+	args := []string{"--ansi"}
+	if icons || metadata {
+		args = append(args, "--delimiter="+fieldDelimiter)
+	}
+	if icons {
+		args = append(args, "--nth=2..", "--tabstop=3")
+	}
+	if algorithm != nil && *algorithm != types.MatchingAlgorithmFuzzy {
+		args = append(args, "--exact")
+	}
+	if caseSensitivity != nil {
+		switch *caseSensitivity {
+		case types.CaseSensitivitySensitive:
+			args = append(args, "+i")
+		case types.CaseSensitivityInsensitive:
+			args = append(args, "-i")
+		}
+	}
+
+	p := &Picker{icons: icons, metadata: metadata}
+	p.cmd = exec.Command(path, args...)
+	// fzf reads the keyboard input from the terminal and renders on stderr,
+	// as stdout is read by the shell integration
+	p.cmd.Stdout = &p.stdout
+	p.cmd.Stderr = os.Stderr
+
+	p.stdin, err = p.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start fzf: %v", err)
+	}
+	return p, nil
+}
+
+// Add adds a context name with its icon and metadata to fzf. The context name may contain ANSI color codes.
+func (p *Picker) Add(contextName, icon, metadata string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	line := contextName
+	if p.icons {
+		line = icon + fieldDelimiter + line
+	}
+	if p.metadata {
+		// the metadata is dimmed
+		line += fieldDelimiter + "\x1b[2m" + metadata + "\x1b[0m"
+	}
+
+	// fails if fzf already exited, e.g. after a selection
+	if _, err := fmt.Fprintln(p.stdin, line); err != nil {
+		p.close()
+	}
+}
+
+// SearchDone signals fzf that all context names have been added
+func (p *Picker) SearchDone() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.close()
+}
+
+func (p *Picker) close() {
+	if !p.closed {
+		p.closed = true
+		_ = p.stdin.Close()
+	}
+}
+
+// Run waits for the selection in fzf and returns the selected context name.
+// Returns fuzzyfinder.ErrAbort if the selection was aborted, like the default picker.
+func (p *Picker) Run() (string, error) {
+	err := p.cmd.Wait()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && (exitError.ExitCode() == exitNoMatch || exitError.ExitCode() == exitInterrupted) {
+		return "", fuzzyfinder.ErrAbort
+	}
+	if err != nil {
+		return "", fmt.Errorf("fzf failed: %v", err)
+	}
+
+	// only the first selection is used, e.g. if --multi is set in FZF_DEFAULT_OPTS
+	selected, _, _ := strings.Cut(strings.TrimSpace(p.stdout.String()), "\n")
+	if p.icons {
+		if _, contextName, found := strings.Cut(selected, fieldDelimiter); found {
+			selected = contextName
+		}
+	}
+	if p.metadata {
+		selected, _, _ = strings.Cut(selected, fieldDelimiter)
+	}
+	if len(selected) == 0 {
+		return "", fuzzyfinder.ErrAbort
+	}
+	return selected, nil
+}
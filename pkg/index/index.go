@@ -15,56 +15,141 @@
 package index
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
-	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
 	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/database"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
 const (
-	// indexStateFileName is the filename of the index state file containing the last time a Kind index has been updated
-	// located at the root of the given kubeconfigDirectory
-	indexStateFileName = "index.state"
-	// indexFileName is the filename of the file containing a pre-computed context -> kubeconfig path mapping
-	// located at the root of the given kubeconfigDirectory
-	indexFileName = "index"
+	// databaseFileName is the filename of the database containing the search index of all kubeconfig stores
+	// located in the state directory
+	databaseFileName = "switch.index.db"
+	// encryptedDatabaseFileName is the filename of the search index if it is encrypted at rest
+	encryptedDatabaseFileName = "switch.index.encrypted.db"
+	// legacyIndexStateFileName is the filename of the index state file written by previous versions
+	// containing the last time a Kind index has been updated
+	legacyIndexStateFileName = "index.state"
+	// legacyIndexFileName is the filename of the index file written by previous versions
+	// containing a pre-computed context -> kubeconfig path mapping
+	legacyIndexFileName = "index"
+	// revalidationTimeout is the time after which another background refresh of the index can be started
+	revalidationTimeout = time.Minute
+)
+
+var (
+	// storesBucket contains a bucket per kubeconfig store ID
+	storesBucket = []byte("stores")
+	// contextsBucket contains the entries of a kubeconfig store by context name
+	contextsBucket = []byte("contexts")
+	// stagingBucket contains a bucket per running search with the contexts found so far (see Writer)
+	stagingBucket = []byte("staging")
+	// stateKey is the key of the state of the index of a kubeconfig store
+	stateKey = []byte("state")
 )
 
+// Entry is a context in the search index of a kubeconfig store
+type Entry struct {
+	// Name is the name of the context. Stored in the entry, as the keys of an encrypted index are hashed.
+	Name string `json:"name"`
+	// Path is the path of the kubeconfig containing the context in the kubeconfig store
+	Path string `json:"path"`
+	// Tags is the metadata the kubeconfig store associated with the context
+	Tags map[string]string `json:"tags,omitempty"`
+	// Server is the API server URL of the context
+	Server string `json:"server,omitempty"`
+	// CAHash is the hash of the certificate authority of the cluster of the context
+	CAHash string `json:"caHash,omitempty"`
+	// DiscoveredAt is the time the context has been added to the index
+	DiscoveredAt time.Time `json:"discoveredAt"`
+	// Hash is the hash of the path and tags. Entries are only rewritten if their hash changes.
+	Hash string `json:"hash"`
+	// Uses is the number of switches to the context
+	Uses int `json:"uses,omitempty"`
+	// LastUsed is the time of the last switch to the context
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+	// Access is the result of the last check whether the cluster of the context accepts the credentials of the user
+	Access *Access `json:"access,omitempty"`
+}
+
+// Access is the result of checking whether the cluster of a context accepts the credentials of the user
+type Access struct {
+	// Denied is true if the cluster rejected the credentials
+	Denied bool `json:"denied,omitempty"`
+	// CheckedAt is the time of the check
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// state is the state of the index of a kubeconfig store
+type state struct {
+	types.IndexState
+	// RevalidationTime is the time a background refresh of the index has been started
+	RevalidationTime *time.Time `json:"revalidationTime,omitempty"`
+	// Failures is the number of consecutive failed searches of the kubeconfig store
+	Failures int `json:"failures,omitempty"`
+	// DegradedUntil is the end of the cooldown of the kubeconfig store after repeatedly failed searches
+	DegradedUntil *time.Time `json:"degradedUntil,omitempty"`
+}
+
 type SearchIndex struct {
 	log                 *logrus.Entry
-	indexFilepath       string
-	indexStateFilepath  string
+	databaseFilepath    string
+	storeID             string
 	kubeconfigStoreKind types.StoreKind
 	content             *types.Index
 }
 
-// New creates a new SearchIndex
+// New creates a new SearchIndex.
+// Imports the index files of the kubeconfig store written by previous versions into the database.
 func New(log *logrus.Entry, storeKind types.StoreKind, stateDirectory string, storeID string) (*SearchIndex, error) {
 	if _, err := os.Stat(stateDirectory); os.IsNotExist(err) {
-		if err := os.Mkdir(stateDirectory, 0755); err != nil {
+		if err := permissions.MkdirAll(stateDirectory); err != nil {
 			return nil, err
 		}
 	}
 
-	indexStateFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, storeID, indexStateFileName)
-	indexFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, storeID, indexFileName)
-
 	i := SearchIndex{
 		log:                 log,
-		indexFilepath:       indexFilepath,
-		indexStateFilepath:  indexStateFilepath,
+		databaseFilepath:    databaseFilepath(stateDirectory),
+		storeID:             storeID,
 		kubeconfigStoreKind: storeKind,
 	}
 
-	indexFromFile, err := i.loadFromFile()
-	if err != nil && !os.IsNotExist(err) {
+	if database.Encrypted() {
+		// the plaintext index would reveal the contexts of the kubeconfig stores
+		if err := os.Remove(filepath.Join(stateDirectory, databaseFileName)); err != nil && !os.IsNotExist(err) {
+			log.Debugf("failed to remove the plaintext index: %v", err)
+		}
+	}
+
+	if err := i.migrateLegacyFiles(stateDirectory); err != nil {
+		// the index is rebuilt with the next search
+		log.Debugf("failed to import the legacy index files of store %s: %v", storeID, err)
+	}
+
+	content, err := i.load()
+	if errors.Is(err, database.ErrDecryption) {
+		// e.g. the encryption key has changed. The index is rebuilt with the next search.
+		log.Debugf("discarding index of store %s: %v", storeID, err)
+		content, err = nil, i.Delete()
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	i.content = indexFromFile
+	i.content = content
 	return &i, nil
 }
 
@@ -83,28 +168,151 @@ func (i *SearchIndex) GetContent() (map[string]string, map[string]map[string]str
 	return i.content.ContextToPathMapping, i.content.ContextToTags
 }
 
-// LoadIndexFromFile takes a filename and de-serializes the contents into an SearchIndex object.
-func (i *SearchIndex) loadFromFile() (*types.Index, error) {
-	// an index file is not required. Its ok if it does not exist.
-	if _, err := os.Stat(i.indexFilepath); err != nil {
-		return nil, err
+// GetServers returns the API server URL by context name
+func (i *SearchIndex) GetServers() map[string]string {
+	if i.content == nil {
+		return nil
 	}
+	return i.content.ContextToServer
+}
 
-	bytes, err := os.ReadFile(i.indexFilepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read index file from %q. File corrupt?: %v", i.indexFilepath, err)
+// GetCAHashes returns the hash of the certificate authority of the cluster by context name
+func (i *SearchIndex) GetCAHashes() map[string]string {
+	if i.content == nil {
+		return nil
+	}
+	return i.content.ContextToCAHash
+}
+
+// GetEntries returns the entries of the index with their metadata by context name
+func (i *SearchIndex) GetEntries() (map[string]Entry, error) {
+	entries := make(map[string]Entry)
+	err := i.view(func(store *bolt.Bucket) error {
+		return forEachEntry(store, func(name string, entry Entry) {
+			entries[name] = entry
+		})
+	})
+	return entries, err
+}
+
+// LoadAll reads the index of all kubeconfig stores without checking whether they are up to date.
+// Used where searching the stores is too slow, e.g. for shell completion.
+// The indexes are sorted by the ID of their kubeconfig store.
+func LoadAll(log *logrus.Entry, stateDirectory string) ([]types.Index, error) {
+	var indexes []types.Index
+	err := database.With(databaseFilepath(stateDirectory), true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			stores := tx.Bucket(storesBucket)
+			if stores == nil {
+				return nil
+			}
+
+			// buckets are iterated in byte-sorted order of their key
+			return stores.ForEachBucket(func(storeID []byte) error {
+				content, err := readContent(stores.Bucket(storeID))
+				if err != nil {
+					// an unreadable index should not hide the contexts of the other stores
+					log.Debugf("skipping index of store %q: %v", storeID, err)
+					return nil
+				}
+				if content != nil {
+					indexes = append(indexes, *content)
+				}
+				return nil
+			})
+		})
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
 	}
+	return indexes, err
+}
 
-	index := &types.Index{}
-	if len(bytes) == 0 {
-		return index, nil
+// RecordUsage counts a switch to the context in the index of the kubeconfig store.
+// Does nothing if the context is not contained in the index.
+func RecordUsage(stateDirectory, storeID, contextName string) error {
+	i := SearchIndex{
+		databaseFilepath: databaseFilepath(stateDirectory),
+		storeID:          storeID,
 	}
 
-	err = yaml.Unmarshal(bytes, &index)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal index file with path '%s': %v", i.indexFilepath, err)
+	return i.update(func(store *bolt.Bucket) error {
+		contexts := store.Bucket(contextsBucket)
+		if contexts == nil {
+			return nil
+		}
+
+		entry, err := decodeEntry(contexts.Get(database.Key(contextName)))
+		if err != nil || entry == nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		entry.Uses++
+		entry.LastUsed = &now
+		return putJSON(contexts, database.Key(contextName), entry)
+	})
+}
+
+// RecordAccess stores the result of the access check of the context in the index of the kubeconfig store.
+// Does nothing if the context is not contained in the index.
+func RecordAccess(stateDirectory, storeID, contextName string, access Access) error {
+	i := SearchIndex{
+		databaseFilepath: databaseFilepath(stateDirectory),
+		storeID:          storeID,
 	}
-	return index, nil
+
+	return i.update(func(store *bolt.Bucket) error {
+		contexts := store.Bucket(contextsBucket)
+		if contexts == nil {
+			return nil
+		}
+
+		entry, err := decodeEntry(contexts.Get(database.Key(contextName)))
+		if err != nil || entry == nil {
+			return err
+		}
+
+		entry.Access = &access
+		return putJSON(contexts, database.Key(contextName), entry)
+	})
+}
+
+// LoadAccess returns the results of the access checks of the contexts in the index of the kubeconfig stores
+// by store ID and context name. Contexts that have not been checked are omitted.
+func LoadAccess(stateDirectory string, storeIDs []string) (map[string]map[string]Access, error) {
+	storeToAccess := make(map[string]map[string]Access)
+	err := database.With(databaseFilepath(stateDirectory), true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			stores := tx.Bucket(storesBucket)
+			if stores == nil {
+				return nil
+			}
+
+			for _, storeID := range storeIDs {
+				store := stores.Bucket(database.Key(storeID))
+				if store == nil {
+					continue
+				}
+				if err := forEachEntry(store, func(name string, entry Entry) {
+					if entry.Access == nil {
+						return
+					}
+					if _, ok := storeToAccess[storeID]; !ok {
+						storeToAccess[storeID] = make(map[string]Access)
+					}
+					storeToAccess[storeID][name] = *entry.Access
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return storeToAccess, nil
+	}
+	return storeToAccess, err
 }
 
 // ShouldBeUsed checks if the index file with pre-computed mappings should be used
@@ -114,7 +322,7 @@ func (i *SearchIndex) ShouldBeUsed(config *types.Config, storeLocalRefreshIndexA
 		return false, fmt.Errorf("failed to get index state: %v", err)
 	}
 
-	// do not read from existing index if there is no index state file
+	// do not read from existing index if there is no index state
 	// we do not know when the index last has been refreshed
 	if indexState == nil || indexState.Kind != i.kubeconfigStoreKind {
 		return false, nil
@@ -135,93 +343,382 @@ func (i *SearchIndex) ShouldBeUsed(config *types.Config, storeLocalRefreshIndexA
 	return time.Now().UTC().Before(indexState.LastUpdateTime.UTC().Add(*refreshAfter)), nil
 }
 
+// GetLastUpdateTime returns when the index has been refreshed the last time.
+// Returns nil if the index has not been written yet.
+func (i *SearchIndex) GetLastUpdateTime() (*time.Time, error) {
+	indexState, err := i.getIndexState()
+	if err != nil || indexState == nil || indexState.Kind != i.kubeconfigStoreKind {
+		return nil, err
+	}
+	return &indexState.LastUpdateTime, nil
+}
+
+// StartRevalidation marks that the index is refreshed in the background.
+// Returns false if another process started to refresh the index recently, so that concurrent searches
+// (e.g. from the shell completion) do not refresh the same index at the same time.
+func (i *SearchIndex) StartRevalidation() (bool, error) {
+	start := false
+	err := i.update(func(store *bolt.Bucket) error {
+		s, err := decodeState(store.Get(stateKey))
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			s = &state{}
+		}
+
+		if s.RevalidationTime != nil && time.Since(*s.RevalidationTime) < revalidationTimeout {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		s.RevalidationTime = &now
+		start = true
+		return putJSON(store, stateKey, s)
+	})
+	return start, err
+}
+
+// RecordSearch counts the consecutive failed searches of the kubeconfig store. A successful search resets the count.
+// Once the given number of searches failed, the kubeconfig store is degraded for the cooldown.
+// Returns true if the kubeconfig store has been degraded.
+func (i *SearchIndex) RecordSearch(failed bool, failures int, cooldown time.Duration) (bool, error) {
+	degraded := false
+	err := i.update(func(store *bolt.Bucket) error {
+		s, err := decodeState(store.Get(stateKey))
+		if err != nil || s == nil {
+			s = &state{}
+		}
+
+		if !failed {
+			if s.Failures == 0 && s.DegradedUntil == nil {
+				return nil
+			}
+			s.Failures = 0
+			s.DegradedUntil = nil
+			return putJSON(store, stateKey, s)
+		}
+
+		s.Failures++
+		if s.Failures >= failures {
+			until := time.Now().UTC().Add(cooldown)
+			s.DegradedUntil = &until
+			degraded = true
+		}
+		return putJSON(store, stateKey, s)
+	})
+	return degraded, err
+}
+
+// GetDegradedUntil returns the end of the cooldown of the kubeconfig store after repeatedly failed searches.
+// Returns nil if the kubeconfig store is not degraded.
+func (i *SearchIndex) GetDegradedUntil() (*time.Time, error) {
+	var degradedUntil *time.Time
+	err := i.view(func(store *bolt.Bucket) error {
+		s, err := decodeState(store.Get(stateKey))
+		if err != nil || s == nil {
+			return err
+		}
+		if s.DegradedUntil != nil && time.Now().Before(*s.DegradedUntil) {
+			degradedUntil = s.DegradedUntil
+		}
+		return nil
+	})
+	return degradedUntil, err
+}
+
 func (i *SearchIndex) WriteState(toWrite types.IndexState) error {
-	// creates or truncate/clean the existing state file (only state is last execution anyways atm.)
-	file, err := os.Create(i.indexStateFilepath)
-	if err != nil {
-		return err
+	return i.update(func(store *bolt.Bucket) error {
+		s, err := decodeState(store.Get(stateKey))
+		if err != nil || s == nil {
+			s = &state{}
+		}
+		s.IndexState = toWrite
+		return putJSON(store, stateKey, s)
+	})
+}
+
+// RemoveContext removes the context name from the index while keeping the index state.
+// Returns false if the index does not contain the context name.
+func (i *SearchIndex) RemoveContext(contextName string) (bool, error) {
+	removed := false
+	err := i.update(func(store *bolt.Bucket) error {
+		contexts := store.Bucket(contextsBucket)
+		if contexts == nil || contexts.Get(database.Key(contextName)) == nil {
+			return nil
+		}
+		removed = true
+		return contexts.Delete(database.Key(contextName))
+	})
+	if err != nil || !removed {
+		return false, err
 	}
-	defer file.Close()
 
-	output, err := yaml.Marshal(toWrite)
-	if err != nil {
-		return err
+	if i.content != nil {
+		delete(i.content.ContextToPathMapping, contextName)
+		delete(i.content.ContextToTags, contextName)
+		delete(i.content.ContextToServer, contextName)
+		delete(i.content.ContextToCAHash, contextName)
 	}
+	return true, nil
+}
 
-	_, err = file.Write(output)
-	if err != nil {
-		return err
+// Delete removes the index of the kubeconfig store
+func (i *SearchIndex) Delete() error {
+	if _, err := os.Stat(i.databaseFilepath); os.IsNotExist(err) {
+		return nil
 	}
 
-	return nil
+	return database.With(i.databaseFilepath, false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			stores := tx.Bucket(storesBucket)
+			if stores == nil || stores.Bucket(database.Key(i.storeID)) == nil {
+				return nil
+			}
+			return stores.DeleteBucket(database.Key(i.storeID))
+		})
+	})
 }
 
-func (i *SearchIndex) Write(toWrite types.Index) error {
-	// creates or truncate/clean the existing file
-	file, err := os.Create(i.indexFilepath)
+// migrateLegacyFiles imports the index files of the kubeconfig store written by previous versions into the database
+// and removes them. The files are removed even if they cannot be imported, as the index is rebuilt with the next search.
+func (i *SearchIndex) migrateLegacyFiles(stateDirectory string) error {
+	indexFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, i.storeID, legacyIndexFileName)
+	indexStateFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, i.storeID, legacyIndexStateFileName)
+
+	indexBytes, err := os.ReadFile(indexFilepath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	defer func() {
+		_ = os.Remove(indexFilepath)
+		_ = os.Remove(indexStateFilepath)
+		_ = os.Remove(fmt.Sprintf("%s/switch.%s.index.revalidation", stateDirectory, i.storeID))
+	}()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	output, err := yaml.Marshal(toWrite)
-	if err != nil {
+	legacyIndex := types.Index{}
+	if err := yaml.Unmarshal(indexBytes, &legacyIndex); err != nil {
+		return fmt.Errorf("could not unmarshal index file with path '%s': %v", indexFilepath, err)
+	}
+	writer := i.NewWriter(len(legacyIndex.ContextToPathMapping))
+	writer.kind = legacyIndex.Kind
+	for name, path := range legacyIndex.ContextToPathMapping {
+		if err := writer.Add(Entry{Name: name, Path: path, Tags: legacyIndex.ContextToTags[name]}); err != nil {
+			return err
+		}
+	}
+	if err := writer.Commit(); err != nil {
 		return err
 	}
 
-	_, err = file.Write(output)
+	stateBytes, err := os.ReadFile(indexStateFilepath)
 	if err != nil {
 		return err
 	}
-
-	return nil
+	legacyState := types.IndexState{}
+	if err := yaml.Unmarshal(stateBytes, &legacyState); err != nil {
+		return fmt.Errorf("could not unmarshal index state file with path '%s': %v", indexStateFilepath, err)
+	}
+	return i.WriteState(legacyState)
 }
 
-func (i *SearchIndex) Delete() error {
-	if _, err := os.Stat(i.indexStateFilepath); err != nil {
-		if os.IsNotExist(err) {
-			// occurs during first execution of the hook
-			return nil
+// getIndexState reads the state of the index
+func (i *SearchIndex) getIndexState() (*types.IndexState, error) {
+	var indexState *types.IndexState
+	err := i.view(func(store *bolt.Bucket) error {
+		s, err := decodeState(store.Get(stateKey))
+		if err != nil || s == nil {
+			return err
 		}
-		return err
+		indexState = &s.IndexState
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := os.Remove(i.indexFilepath); err != nil {
-		return err
+	if indexState == nil || indexState.LastUpdateTime.IsZero() {
+		// occurs during first execution
+		i.log.Warnf("SearchIndex state not found for store: %q", i.storeID)
+		return nil, nil
 	}
+	return indexState, nil
+}
 
-	if err := os.Remove(i.indexStateFilepath); err != nil {
+// load reads the content of the index. Returns nil if there is no index for the kubeconfig store.
+func (i *SearchIndex) load() (*types.Index, error) {
+	var content *types.Index
+	err := i.view(func(store *bolt.Bucket) error {
+		var err error
+		content, err = readContent(store)
 		return err
+	})
+	return content, err
+}
+
+// view runs the function in a read-only transaction with the bucket of the kubeconfig store.
+// The function is not called if the index of the kubeconfig store does not exist.
+func (i *SearchIndex) view(fn func(store *bolt.Bucket) error) error {
+	err := database.With(i.databaseFilepath, true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			stores := tx.Bucket(storesBucket)
+			if stores == nil {
+				return nil
+			}
+			store := stores.Bucket(database.Key(i.storeID))
+			if store == nil {
+				return nil
+			}
+			return fn(store)
+		})
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
 	}
+	return err
+}
 
-	return nil
+// update runs the function in a read-write transaction with the bucket of the kubeconfig store.
+// Creates the database and the bucket if they do not exist.
+func (i *SearchIndex) update(fn func(store *bolt.Bucket) error) error {
+	return database.With(i.databaseFilepath, false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			stores, err := tx.CreateBucketIfNotExists(storesBucket)
+			if err != nil {
+				return err
+			}
+			store, err := stores.CreateBucketIfNotExists(database.Key(i.storeID))
+			if err != nil {
+				return err
+			}
+			return fn(store)
+		})
+	})
 }
 
-// getIndexState loads and unmarshalls an index state file
-func (i *SearchIndex) getIndexState() (*types.IndexState, error) {
-	if _, err := os.Stat(i.indexStateFilepath); err != nil {
-		if os.IsNotExist(err) {
-			// occurs during first execution of the hook
-			i.log.Warnf("SearchIndex state file not found under path: %q", i.indexStateFilepath)
-			return nil, nil
-		}
+// readContent reads the content of the index from the bucket of the kubeconfig store.
+// Returns nil if the index has not been written yet.
+func readContent(store *bolt.Bucket) (*types.Index, error) {
+	s, err := decodeState(store.Get(stateKey))
+	if err != nil || s == nil || store.Bucket(contextsBucket) == nil {
 		return nil, err
 	}
 
-	bytes, err := os.ReadFile(i.indexStateFilepath)
-	if err != nil {
-		return nil, err
+	content := &types.Index{
+		Kind:                 s.Kind,
+		ContextToPathMapping: make(map[string]string),
+		ContextToTags:        make(map[string]map[string]string),
+		ContextToServer:      make(map[string]string),
+		ContextToCAHash:      make(map[string]string),
+	}
+	err = forEachEntry(store, func(name string, entry Entry) {
+		content.ContextToPathMapping[name] = entry.Path
+		if len(entry.Tags) > 0 {
+			content.ContextToTags[name] = entry.Tags
+		}
+		if len(entry.Server) > 0 {
+			content.ContextToServer[name] = entry.Server
+		}
+		if len(entry.CAHash) > 0 {
+			content.ContextToCAHash[name] = entry.CAHash
+		}
+	})
+	return content, err
+}
+
+func forEachEntry(store *bolt.Bucket, fn func(name string, entry Entry)) error {
+	contexts := store.Bucket(contextsBucket)
+	if contexts == nil {
+		return nil
 	}
 
-	state := &types.IndexState{}
-	if len(bytes) == 0 {
-		return state, nil
+	return contexts.ForEach(func(key, value []byte) error {
+		entry, err := decodeEntry(value)
+		if err != nil {
+			return fmt.Errorf("index entry %q is corrupt: %w", key, err)
+		}
+		fn(entryName(key, entry), *entry)
+		return nil
+	})
+}
+
+// entryName returns the context name of the entry.
+// Entries written before the name was part of the entry are stored by their plaintext context name.
+func entryName(key []byte, entry *Entry) string {
+	if len(entry.Name) > 0 {
+		return entry.Name
 	}
+	return string(key)
+}
+
+func decodeEntry(value []byte) (*Entry, error) {
+	if value == nil {
+		return nil, nil
+	}
+	entry := &Entry{}
+	return entry, decodeJSON(value, entry)
+}
+
+func decodeState(value []byte) (*state, error) {
+	if value == nil {
+		return nil, nil
+	}
+	s := &state{}
+	return s, decodeJSON(value, s)
+}
 
-	err = yaml.Unmarshal(bytes, &state)
+// decodeJSON decrypts the value if the index is encrypted and unmarshals it
+func decodeJSON(value []byte, into interface{}) error {
+	bytes, err := database.DecryptValue(value)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal index state file with path '%s': %v", i.indexStateFilepath, err)
+		return err
+	}
+	return json.Unmarshal(bytes, into)
+}
+
+// putJSON marshals the value and encrypts it if the index is encrypted
+func putJSON(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	bytes, err = database.EncryptValue(bytes)
+	if err != nil {
+		return err
 	}
+	return bucket.Put(key, bytes)
+}
+
+// FilePath returns the path of the database containing the search index of all kubeconfig stores
+func FilePath(stateDirectory string) string {
+	return databaseFilepath(stateDirectory)
+}
 
-	return state, nil
+// databaseFilepath returns the path of the database containing the search index.
+// An encrypted index is stored in a separate database, so that it is never mixed with plaintext entries.
+func databaseFilepath(stateDirectory string) string {
+	if database.Encrypted() {
+		return filepath.Join(stateDirectory, encryptedDatabaseFileName)
+	}
+	return filepath.Join(stateDirectory, databaseFileName)
+}
+
+// hashEntry returns a hash of the path, the API server URL, the certificate authority hash and the sorted tags of a context
+func hashEntry(path, server, caHash string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(path))
+	fmt.Fprintf(h, "\x00%s\x00%s", server, caHash)
+	for _, key := range keys {
+		fmt.Fprintf(h, "\x00%s=%s", key, tags[key])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
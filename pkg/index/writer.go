@@ -0,0 +1,243 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/database"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// stagingTimeout is the time after which the staged contexts of a search that has neither been committed nor discarded
+// (e.g. because the process was killed) are removed
+const stagingTimeout = time.Hour
+
+// Writer writes the contexts found by the search of a kubeconfig store to the index in batches,
+// so that the contexts of large kubeconfig stores are not kept in memory until the search is complete.
+// The contexts are staged until the search is committed, so that an incomplete search does not change the index.
+type Writer struct {
+	index     *SearchIndex
+	kind      types.StoreKind
+	id        []byte
+	batchSize int
+	batch     []Entry
+	count     int
+}
+
+// NewWriter returns a writer for the index of the kubeconfig store.
+// Writes the staged contexts whenever the batch size is reached.
+func (i *SearchIndex) NewWriter(batchSize int) *Writer {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &Writer{
+		index:     i,
+		kind:      i.kubeconfigStoreKind,
+		id:        []byte(fmt.Sprintf("%d-%d", time.Now().UnixNano(), os.Getpid())),
+		batchSize: batchSize,
+		batch:     make([]Entry, 0, batchSize),
+	}
+}
+
+// Add stages the context. Only the name, path, tags, server and CA hash of the entry are written.
+// A context added more than once is written with the values added last.
+func (w *Writer) Add(entry Entry) error {
+	w.batch = append(w.batch, entry)
+	w.count++
+	if len(w.batch) < w.batchSize {
+		return nil
+	}
+	return w.flush()
+}
+
+// Count returns the number of contexts added to the writer
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// Commit replaces the content of the index with the staged contexts in a single transaction,
+// so that concurrent readers either read the old or the new index.
+// Only changed entries are rewritten. The discovery time and usage of unchanged contexts are kept.
+func (w *Writer) Commit() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	return w.index.update(func(store *bolt.Bucket) error {
+		staged := w.staged(store)
+
+		contexts, err := store.CreateBucketIfNotExists(contextsBucket)
+		if err != nil {
+			return err
+		}
+
+		// remove the contexts that are not found anymore
+		var removed [][]byte
+		if err := contexts.ForEach(func(key, _ []byte) error {
+			if staged == nil || staged.Get(key) == nil {
+				removed = append(removed, key)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, key := range removed {
+			if err := contexts.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		if staged != nil {
+			now := time.Now().UTC()
+			if err := staged.ForEach(func(key, value []byte) error {
+				found, err := decodeEntry(value)
+				if err != nil {
+					return err
+				}
+				hash := hashEntry(found.Path, found.Server, found.CAHash, found.Tags)
+
+				entry, err := decodeEntry(contexts.Get(key))
+				if err != nil || entry == nil {
+					entry = &Entry{DiscoveredAt: now}
+				} else if entry.Hash == hash && entry.Name == found.Name {
+					return nil
+				}
+
+				entry.Name = found.Name
+				entry.Path = found.Path
+				entry.Tags = found.Tags
+				entry.Server = found.Server
+				entry.CAHash = found.CAHash
+				entry.Hash = hash
+				return putJSON(contexts, key, entry)
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := removeStaged(store, w.id); err != nil {
+			return err
+		}
+
+		// the kind of the index is part of its state
+		s, err := decodeState(store.Get(stateKey))
+		if err != nil || s == nil {
+			s = &state{}
+		}
+		s.Kind = w.kind
+		return putJSON(store, stateKey, s)
+	})
+}
+
+// Discard removes the staged contexts without changing the index
+func (w *Writer) Discard() error {
+	w.batch = w.batch[:0]
+	if w.count == 0 {
+		return nil
+	}
+
+	return w.index.update(func(store *bolt.Bucket) error {
+		return removeStaged(store, w.id)
+	})
+}
+
+// flush writes the batch to the staged contexts
+func (w *Writer) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	err := w.index.update(func(store *bolt.Bucket) error {
+		staging, err := store.CreateBucketIfNotExists(stagingBucket)
+		if err != nil {
+			return err
+		}
+		staged, err := staging.CreateBucketIfNotExists(w.id)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range w.batch {
+			if err := putJSON(staged, database.Key(entry.Name), Entry{
+				Name:   entry.Name,
+				Path:   entry.Path,
+				Tags:   entry.Tags,
+				Server: entry.Server,
+				CAHash: entry.CAHash,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stage %d contexts: %w", len(w.batch), err)
+	}
+
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// staged returns the bucket with the staged contexts of the writer. Returns nil if no context has been staged.
+func (w *Writer) staged(store *bolt.Bucket) *bolt.Bucket {
+	staging := store.Bucket(stagingBucket)
+	if staging == nil {
+		return nil
+	}
+	return staging.Bucket(w.id)
+}
+
+// removeStaged removes the staged contexts of the writer with the given id
+// and the staged contexts of searches that have neither been committed nor discarded in time
+func removeStaged(store *bolt.Bucket, id []byte) error {
+	staging := store.Bucket(stagingBucket)
+	if staging == nil {
+		return nil
+	}
+
+	var removed [][]byte
+	if err := staging.ForEach(func(key, _ []byte) error {
+		if string(key) == string(id) || stagedBefore(key, time.Now().Add(-stagingTimeout)) {
+			removed = append(removed, key)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, key := range removed {
+		if err := staging.DeleteBucket(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stagedBefore returns true if the writer with the given id has been created before the given time.
+// The id of a writer starts with its creation time.
+func stagedBefore(id []byte, before time.Time) bool {
+	nanos, _, _ := strings.Cut(string(id), "-")
+	created, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return true
+	}
+	return time.Unix(0, created).Before(before)
+}
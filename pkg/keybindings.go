@@ -0,0 +1,172 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/verify"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tui"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// invalidEnvCharacters matches the characters of tag keys which cannot be used in environment variable names
+var invalidEnvCharacters = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// getPickerKeybindings converts the configured keybindings to the keys of the built-in actions
+// and the custom commands of the picker
+func getPickerKeybindings(storeIDToStore map[string]storetypes.KubeconfigStore, config *types.Config, stateDir string, keybindings []types.Keybinding) (map[types.PickerAction][]string, []tui.Command) {
+	var (
+		actions  = make(map[types.PickerAction][]string)
+		commands []tui.Command
+	)
+
+	for _, keybinding := range keybindings {
+		if keybinding.Action != nil {
+			actions[*keybinding.Action] = append(actions[*keybinding.Action], keybinding.Key)
+			continue
+		}
+		command := tui.Command{Key: keybinding.Key}
+		switch {
+		case keybinding.Command != nil:
+			command.Run = func(item tui.Item) (string, error) {
+				return runKeybindingCommand(storeIDToStore, *keybinding.Command, item.Name)
+			}
+		case keybinding.Open != nil:
+			command.Open = func(item tui.Item) (*exec.Cmd, func(), error) {
+				return prepareOpenCommand(storeIDToStore, config, stateDir, *keybinding.Open, item.Name)
+			}
+			command.Confirm = func(item tui.Item) bool {
+				return confirmOpenCommand(storeIDToStore, config, item.Name)
+			}
+		default:
+			continue
+		}
+		if keybinding.Description != nil {
+			command.Description = *keybinding.Description
+		}
+		commands = append(commands, command)
+	}
+	return actions, commands
+}
+
+// runKeybindingCommand executes the command for the context
+func runKeybindingCommand(storeIDToStore map[string]storetypes.KubeconfigStore, command, contextName string) (string, error) {
+	cmd := newKeybindingCommand(storeIDToStore, command, contextName)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("command %q failed: %v: %s", cmd.Args[len(cmd.Args)-1], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// prepareOpenCommand prepares the command for the context and writes a temporary kubeconfig for the context.
+// The temporary kubeconfig is materialized like when switching to the context.
+// The returned command runs with KUBECONFIG set to the temporary kubeconfig, which is removed by the returned cleanup function.
+func prepareOpenCommand(storeIDToStore map[string]storetypes.KubeconfigStore, config *types.Config, stateDir, command, contextName string) (*exec.Cmd, func(), error) {
+	cmd := newKeybindingCommand(storeIDToStore, command, contextName)
+
+	options, err := materializeOptionsForContext(storeIDToStore, contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeconfig, err := MaterializeKubeconfig(config, stateDir, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
+	}
+
+	cmd.Env = append(cmd.Env, fmt.Sprintf("KUBECONFIG=%s", kubeconfigPath))
+	cleanup := func() {
+		_ = os.Remove(kubeconfigPath)
+	}
+	return cmd, cleanup, nil
+}
+
+// confirmOpenCommand asks for the context name before opening a protected context, like when switching to it
+func confirmOpenCommand(storeIDToStore map[string]storetypes.KubeconfigStore, config *types.Config, contextName string) bool {
+	if config == nil {
+		return true
+	}
+
+	options, err := materializeOptionsForContext(storeIDToStore, contextName)
+	if err != nil || !verify.IsProtected(config.ProtectedContexts, contextName, options.ContextName) {
+		return true
+	}
+	return verify.ConfirmProtected(contextName, theme.New(config.Environments).Colorize(contextName, nil))
+}
+
+// newKeybindingCommand returns the shell command running the configured command for the context.
+// The command is passed to the shell as is. The context is provided in environment variables,
+// so that its values are never interpreted by the shell.
+func newKeybindingCommand(storeIDToStore map[string]storetypes.KubeconfigStore, command, contextName string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = util.CmdCommand(context.Background(), command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), keybindingEnv(storeIDToStore, contextName)...)
+	return cmd
+}
+
+// keybindingEnv returns the environment variables describing the context to custom keybinding commands
+func keybindingEnv(storeIDToStore map[string]storetypes.KubeconfigStore, contextName string) []string {
+	path := readFromContextToPathMapping(contextName)
+	storeID := readFromPathToStoreID(path)
+
+	var storeKind string
+	if kubeconfigStore, ok := storeIDToStore[storeID]; ok {
+		storeKind = string(kubeconfigStore.GetKind())
+	}
+
+	env := []string{
+		"KUBESWITCH_CONTEXT=" + contextName,
+		"KUBESWITCH_STORE_ID=" + storeID,
+		"KUBESWITCH_STORE_KIND=" + storeKind,
+		"KUBESWITCH_PATH=" + path,
+	}
+
+	tags := readFromPathToTagsMapping(path)
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := invalidEnvCharacters.ReplaceAllString(strings.ToUpper(key), "_")
+		env = append(env, fmt.Sprintf("KUBESWITCH_TAG_%s=%s", name, tags[key]))
+	}
+	return env
+}
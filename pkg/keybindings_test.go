@@ -0,0 +1,169 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tui"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("keybindings", func() {
+	const (
+		contextName = "mock/dev-eu-west-1-0001"
+		path        = "dev-eu-west-1-0001"
+	)
+
+	var (
+		storeIDToStore map[string]storetypes.KubeconfigStore
+		stateDir       string
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = os.MkdirTemp("", "keybindings")
+		Expect(err).ToNot(HaveOccurred())
+
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{Kind: types.StoreKindMock})
+		Expect(err).ToNot(HaveOccurred())
+		storeIDToStore = map[string]storetypes.KubeconfigStore{mockStore.GetID(): mockStore}
+
+		writeToContextToPathMapping(contextName, path)
+		writeToPathToStoreID(path, mockStore.GetID())
+		writeToPathToTagsMapping(path, map[string]string{
+			"region":                   "eu-west-1",
+			"cloud.google.com/project": "demo",
+		})
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+
+		contextToPathMappingLock.Lock()
+		delete(contextToPathMapping, contextName)
+		contextToPathMappingLock.Unlock()
+
+		pathToStoreLock.Lock()
+		delete(pathToStoreID, path)
+		pathToStoreLock.Unlock()
+
+		pathToTagsMappingLock.Lock()
+		delete(pathToTagsMapping, path)
+		pathToTagsMappingLock.Unlock()
+	})
+
+	Describe("getPickerKeybindings", func() {
+		It("should map actions to their keys and skip keybindings without an action or command", func() {
+			var (
+				selectAction = types.PickerActionSelect
+				command      = "echo"
+				open         = "k9s"
+				description  = "k9s"
+			)
+
+			actions, commands := getPickerKeybindings(storeIDToStore, nil, "", []types.Keybinding{
+				{Key: "ctrl+s", Action: &selectAction},
+				{Key: "enter", Action: &selectAction},
+				{Key: "ctrl+e", Command: &command},
+				{Key: "ctrl+k", Open: &open, Description: &description},
+				{Key: "ctrl+n"},
+			})
+
+			Expect(actions).To(Equal(map[types.PickerAction][]string{types.PickerActionSelect: {"ctrl+s", "enter"}}))
+			Expect(commands).To(HaveLen(2))
+			Expect(commands[0].Key).To(Equal("ctrl+e"))
+			Expect(commands[0].Run).ToNot(BeNil())
+			Expect(commands[0].Open).To(BeNil())
+			Expect(commands[1].Key).To(Equal("ctrl+k"))
+			Expect(commands[1].Description).To(Equal("k9s"))
+			Expect(commands[1].Open).ToNot(BeNil())
+			Expect(commands[1].Confirm(tui.Item{Name: contextName})).To(BeTrue())
+		})
+	})
+
+	Describe("keybindingEnv", func() {
+		It("should describe the context and its tags in sorted environment variables", func() {
+			Expect(keybindingEnv(storeIDToStore, contextName)).To(Equal([]string{
+				"KUBESWITCH_CONTEXT=" + contextName,
+				"KUBESWITCH_STORE_ID=mock.default",
+				"KUBESWITCH_STORE_KIND=mock",
+				"KUBESWITCH_PATH=" + path,
+				"KUBESWITCH_TAG_CLOUD_GOOGLE_COM_PROJECT=demo",
+				"KUBESWITCH_TAG_REGION=eu-west-1",
+			}))
+		})
+
+		It("should leave the store empty for unknown contexts", func() {
+			Expect(keybindingEnv(storeIDToStore, "unknown")).To(Equal([]string{
+				"KUBESWITCH_CONTEXT=unknown",
+				"KUBESWITCH_STORE_ID=",
+				"KUBESWITCH_STORE_KIND=",
+				"KUBESWITCH_PATH=",
+			}))
+		})
+	})
+
+	Describe("runKeybindingCommand", func() {
+		It("should pass the context in environment variables instead of the command line", func() {
+			out, err := runKeybindingCommand(storeIDToStore, `printf '%s %s' "$KUBESWITCH_CONTEXT" "$KUBESWITCH_TAG_REGION"`, contextName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(contextName + " eu-west-1"))
+		})
+
+		It("should return the error output of failed commands", func() {
+			_, err := runKeybindingCommand(storeIDToStore, "echo denied >&2; exit 3", contextName)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("exit status 3: denied"))
+		})
+	})
+
+	Describe("prepareOpenCommand", func() {
+		It("should run the command with a temporary kubeconfig of the context", func() {
+			cmd, cleanup, err := prepareOpenCommand(storeIDToStore, &types.Config{}, stateDir, "k9s", contextName)
+			Expect(err).ToNot(HaveOccurred())
+
+			var kubeconfigPath string
+			for _, env := range cmd.Env {
+				if strings.HasPrefix(env, "KUBECONFIG=") {
+					kubeconfigPath = strings.TrimPrefix(env, "KUBECONFIG=")
+				}
+			}
+			Expect(filepath.IsAbs(kubeconfigPath)).To(BeTrue())
+			Expect(cmd.Env).To(ContainElement("KUBESWITCH_CONTEXT=" + contextName))
+
+			kubeconfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kubeconfig.CurrentContext).To(Equal(path))
+
+			cleanup()
+			_, err = os.Stat(kubeconfigPath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should fail for contexts of unknown stores", func() {
+			_, _, err := prepareOpenCommand(storeIDToStore, &types.Config{}, stateDir, "k9s", "unknown")
+			Expect(err).To(MatchError(`unknown kubeconfig store of context "unknown"`))
+		})
+	})
+})
@@ -0,0 +1,121 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// KubernetesVersionRange restricts the search results to the contexts of clusters with a Kubernetes version in the range.
+// Set by the flags --min-version and --max-version of the command line.
+var KubernetesVersionRange *VersionRange
+
+// VersionRange is a range of Kubernetes versions. Both bounds are inclusive and optional.
+type VersionRange struct {
+	min *version.Version
+	max *version.Version
+}
+
+// NewVersionRange returns the range of Kubernetes versions between min and max, e.g. "1.28" and "1.30".
+// An empty bound is not checked.
+func NewVersionRange(min, max string) (*VersionRange, error) {
+	r := &VersionRange{}
+	if len(min) > 0 {
+		v, err := version.ParseGeneric(min)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum Kubernetes version %q: %v", min, err)
+		}
+		r.min = v
+	}
+	if len(max) > 0 {
+		v, err := version.ParseGeneric(max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum Kubernetes version %q: %v", max, err)
+		}
+		r.max = v
+	}
+	return r, nil
+}
+
+// Contains returns true if the Kubernetes version found in the tags of a context is in the range.
+// Contexts of clusters with an unknown version are not contained.
+func (r VersionRange) Contains(tags map[string]string) bool {
+	v := KubernetesVersion(tags)
+	if v == nil {
+		return false
+	}
+	if r.min != nil && compareVersions(v, r.min) < 0 {
+		return false
+	}
+	if r.max != nil && compareVersions(v, r.max) > 0 {
+		return false
+	}
+	return true
+}
+
+// KubernetesVersion returns the Kubernetes version of the cluster of a context set in the tags by the kubeconfig store,
+// or nil if the version is unknown
+func KubernetesVersion(tags map[string]string) *version.Version {
+	value, ok := tags[storetypes.TagKubernetesVersion]
+	if !ok || len(value) == 0 {
+		return nil
+	}
+	v, err := version.ParseGeneric(value)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// BelowSupportedVersion returns the minimum supported Kubernetes version configured in the SwitchConfig
+// if the cluster of a context runs an older Kubernetes version
+func BelowSupportedVersion(config *types.Config, tags map[string]string) (string, bool) {
+	if config.KubernetesVersion == nil || config.KubernetesVersion.MinSupported == nil {
+		return "", false
+	}
+	minSupported, err := version.ParseGeneric(*config.KubernetesVersion.MinSupported)
+	if err != nil {
+		return "", false
+	}
+	v := KubernetesVersion(tags)
+	if v == nil || compareVersions(v, minSupported) >= 0 {
+		return "", false
+	}
+	return *config.KubernetesVersion.MinSupported, true
+}
+
+// compareVersions compares the version with the bound up to the components of the bound,
+// so that 1.28.5 is neither below nor above the bound 1.28
+func compareVersions(v, bound *version.Version) int {
+	components := v.Components()
+	for i, b := range bound.Components() {
+		var c uint
+		if i < len(components) {
+			c = components[i]
+		}
+		switch {
+		case c < b:
+			return -1
+		case c > b:
+			return 1
+		}
+	}
+	return 0
+}
@@ -15,7 +15,10 @@
 package pkg
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 	"sync"
 	"time"
@@ -23,17 +26,29 @@ import (
 	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
 	"github.com/hashicorp/go-multierror"
 	"github.com/ktr0731/go-fuzzyfinder"
-	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg/fzf"
 	"github.com/danielfoehrkn/kubeswitch/pkg/index"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
-	aliasutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/util"
+	aliasstate "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/hooks"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/notes"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tracing"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tui"
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
+// maxCachedKubeconfigs is the maximum number of kubeconfigs cached in memory.
+// Evicted kubeconfigs are read from their kubeconfig store again when required for the preview.
+const maxCachedKubeconfigs = 1000
+
 var (
 	// need mutex for all maps because multiple stores with multiple go routines write to the map simultaneously
 	// in addition the fuzzy search reads from the maps during hot reload
@@ -46,8 +61,11 @@ var (
 	pathToTagsMapping     = make(map[string]map[string]string)
 	pathToTagsMappingLock = sync.RWMutex{}
 
-	pathToKubeconfig     = make(map[string]string)
-	pathToKubeconfigLock = sync.RWMutex{}
+	// the kubeconfigs read during the search are cached for the preview.
+	// The cache is bounded, as a search may read tens of thousands of kubeconfigs.
+	pathToKubeconfig      = make(map[string]string)
+	pathToKubeconfigOrder []string
+	pathToKubeconfigLock  = sync.RWMutex{}
 
 	pathToStoreID   = make(map[string]string)
 	pathToStoreLock = sync.RWMutex{}
@@ -55,47 +73,148 @@ var (
 	aliasToContext     = make(map[string]string)
 	aliasToContextLock = sync.RWMutex{}
 
+	contextToMetadata     = make(map[string]string)
+	contextToMetadataLock = sync.RWMutex{}
+
 	hotReloadLock sync.RWMutex
 
 	// aggregated errors that were suppressed during the search
 	// are logged on exit
-	searchError error
+	searchError     error
+	searchErrorLock sync.Mutex
 
-	logger = logrus.New()
+	logger = logging.New()
 )
 
-func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, showPreview bool) (*string, *string, error) {
-	c, err := DoSearch(stores, config, stateDir, noIndex)
+func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, showPreview, appendToHistory bool) (*string, *string, error) {
+	// remember the store for later kubeconfig retrieval
+	var kindToStore = map[string]storetypes.KubeconfigStore{}
+	for _, s := range stores {
+		kindToStore[s.GetID()] = s
+	}
+
+	var preview func(contextName string) string
+	if showPreview {
+		preview = func(contextName string) string {
+			return getPreview(kindToStore, config.PreviewTemplate, stateDir, contextName)
+		}
+	}
+
+	// the contexts of clusters that rejected the credentials in previous access checks are dimmed or hidden
+	contextAccess := loadContextAccess(config, stateDir, kindToStore)
+
+	var picker *tui.Picker
+	if config.Picker != nil && *config.Picker == types.PickerTUI {
+		picker = newPicker(kindToStore, preview, config, stateDir, contextAccess)
+	}
+
+	// the results of the picker can be ranked by the frecency of the contexts in the history
+	var frecency map[string]float64
+	if picker != nil {
+		scores, err := historyutil.FrecencyScores()
+		if err != nil {
+			logger.Debugf("failed to read the history to rank the contexts: %v", err)
+		}
+		frecency = scores
+	}
+
+	// the notes attached to the contexts are searched in addition to the context names
+	contextNotes, err := notes.GetNotes(stateDir)
+	if err != nil {
+		logger.Warnf("failed to read the notes of the contexts: %v", err)
+	}
+
+	var storeDone func(store storetypes.KubeconfigStore)
+	if picker != nil {
+		storeDone = func(store storetypes.KubeconfigStore) {
+			picker.StoreDone(store.GetID())
+		}
+	}
+
+	// warnings are shown after the selection screen
+	warn := func(format string, args ...interface{}) {
+		appendToSearchError(fmt.Errorf(format, args...))
+	}
+
+	c, err := DoSearchWithProgress(stores, config, stateDir, noIndex, storeDone, warn)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	var (
+		fzfPicker      *fzf.Picker
+		contextsTheme  = theme.New(config.Environments)
+		showStoreIcons = config.ShowStoreIcons != nil && *config.ShowStoreIcons
+		searchMetadata = config.SearchMetadata != nil && *config.SearchMetadata
+		cloudTags      = showCloudTags(config)
+		showVersion    = config.KubernetesVersion != nil && config.KubernetesVersion.Show != nil && *config.KubernetesVersion.Show
+		// duplicate clusters, clusters below the supported Kubernetes version and contexts read in offline mode are marked in the metadata
+		showMetadata = searchMetadata || cloudTags || showVersion || offline.Enabled() ||
+			(config.KubernetesVersion != nil && config.KubernetesVersion.MinSupported != nil) ||
+			(config.DuplicateClusters != nil && *config.DuplicateClusters == types.DuplicateClustersGroup)
+	)
+	if config.Picker != nil && *config.Picker == types.PickerFZF {
+		if fzfPicker, err = fzf.New(showStoreIcons, showMetadata, config.Matching, config.CaseSensitivity); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// here we asynchronously read from the result channel until the wait group is done (call wg.Done for all stores)
 	go func(channel chan DiscoveredContext) {
-		// read from result channel until
-		for discoveredContext := range channel {
+		// the contexts are added to the search results in batches to not contend for the locks with the pickers
+		var (
+			names []string
+			items []tui.Item
+		)
+		flush := func() {
+			appendToAllKubeconfigContextNames(names...)
+			if picker != nil {
+				picker.Add(items...)
+			}
+			names, items = names[:0], items[:0]
+		}
+
+		add := func(discoveredContext DiscoveredContext) {
 			if discoveredContext.Error != nil {
 				// aggregate the errors during the search to show after the selection screen
 				logger.Debugf("%v", discoveredContext.Error)
-				searchError = multierror.Append(searchError, discoveredContext.Error)
-				continue
+				appendToSearchError(discoveredContext.Error)
+				if picker != nil && discoveredContext.Store != nil {
+					if errors.Is(discoveredContext.Error, ErrStoreDegraded) {
+						picker.StoreDegraded((*discoveredContext.Store).GetID())
+					} else {
+						picker.AddError((*discoveredContext.Store).GetID())
+					}
+				}
+				return
 			}
 
 			if discoveredContext.Store == nil {
 				// this should not happen
 				logger.Debugf("store returned from search is nil. This should not happen")
-				continue
+				return
 			}
 			kubeconfigStore := *discoveredContext.Store
 
+			if discoveredContext.Merged(config) {
+				logger.Debugf("context %q of store %q is merged into context %q with the same cluster", discoveredContext.Name, kubeconfigStore.GetID(), discoveredContext.DuplicateOf)
+				return
+			}
+
+			inaccessible := contextAccess != nil && AccessDenied(config, contextAccess[kubeconfigStore.GetID()][discoveredContext.Name])
+			if inaccessible && accessCheckMode(config) == types.AccessCheckModeHide {
+				logger.Debugf("context %q of store %q is hidden, as its cluster rejected the credentials", discoveredContext.Name, kubeconfigStore.GetID())
+				return
+			}
+
 			contextName := discoveredContext.Name
 			if len(discoveredContext.Alias) > 0 {
 				contextName = discoveredContext.Alias
 				writeToAliasToContext(discoveredContext.Alias, discoveredContext.Name)
 			}
 
-			// write to global map that is polled by the fuzzy search
-			appendToAllKubeconfigContextNames(contextName)
+			// buffer for the global slice that is polled by the fuzzy search
+			names = append(names, contextName)
 			// add to global contextToPath map
 			// required to map back from selected context -> path
 			writeToContextToPathMapping(contextName, discoveredContext.Path)
@@ -104,18 +223,110 @@ func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 			// associate (path -> store)
 			// required to map back from selected context -> path -> store -> store.getKubeconfig(path)
 			writeToPathToStoreID(discoveredContext.Path, kubeconfigStore.GetID())
+
+			var icon string
+			if showStoreIcons {
+				icon = theme.StoreIcon(kubeconfigStore.GetKind(), config.StoreIcons)
+			}
+
+			var metadata string
+			if searchMetadata {
+				metadata = contextMetadata(discoveredContext.Server, discoveredContext.Tags)
+			}
+			if cloudTags {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s %s", metadata, cloudTagColumns(discoveredContext.Tags)))
+			}
+			if showVersion && !searchMetadata {
+				// the version is already part of the searched metadata
+				metadata = strings.TrimSpace(fmt.Sprintf("%s %s", metadata, discoveredContext.Tags[storetypes.TagKubernetesVersion]))
+			}
+			minSupportedVersion, outdated := BelowSupportedVersion(config, discoveredContext.Tags)
+			if outdated {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s (below Kubernetes %s)", metadata, minSupportedVersion))
+			}
+			// group the contexts of the same cluster found in more than one kubeconfig store
+			if len(discoveredContext.DuplicateOf) > 0 {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s (same cluster as %s)", metadata, discoveredContext.DuplicateOf))
+			}
+			// warn about credentials that expire soon. Expired credentials are retrieved again when switching to the context.
+			if expiry := discoveredContext.CredentialsExpiry; expiry != nil && time.Until(*expiry) < util.CredentialsExpiryWarning {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s (%s)", metadata, util.DescribeCredentialsExpiry(*expiry)))
+			}
+			if inaccessible {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s (%v)", metadata, ErrAccessDenied))
+			}
+			if discoveredContext.Offline {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s (offline, credentials may be stale)", metadata))
+			}
+			// the other pickers can only search the note if it is shown next to the context name
+			if note := contextNotes[contextName]; len(note) > 0 && picker == nil {
+				metadata = strings.TrimSpace(fmt.Sprintf("%s (%s)", metadata, strings.Join(strings.Fields(note), " ")))
+			}
+			if len(metadata) > 0 {
+				// required by the default picker to show and search the metadata
+				writeToContextToMetadata(contextName, metadata)
+			}
+
+			if picker != nil {
+				items = append(items, tui.Item{
+					Name:         contextName,
+					StoreID:      kubeconfigStore.GetID(),
+					Tags:         discoveredContext.Tags,
+					Icon:         icon,
+					Metadata:     metadata,
+					Note:         contextNotes[contextName],
+					Frecency:     frecency[contextName],
+					Inaccessible: inaccessible,
+					Outdated:     outdated,
+					Stale:        discoveredContext.Offline,
+				})
+			}
+			if fzfPicker != nil {
+				name := contextsTheme.Colorize(contextName, discoveredContext.Tags)
+				if inaccessible {
+					name = theme.Dim(contextName)
+				}
+				fzfPicker.Add(name, icon, metadata)
+			}
 		}
-	}(*c)
 
-	// remember the store for later kubeconfig retrieval
-	var kindToStore = map[string]storetypes.KubeconfigStore{}
-	for _, s := range stores {
-		kindToStore[s.GetID()] = s
-	}
+		// read from result channel until the search is complete.
+		// The buffered contexts are added once the batch is full or no further context is available yet.
+		for discoveredContext := range channel {
+			add(discoveredContext)
+			if len(names) >= searchBatchSize(config) || len(channel) == 0 {
+				flush()
+			}
+		}
+		flush()
+
+		if picker != nil {
+			picker.SearchDone()
+		}
+		if fzfPicker != nil {
+			fzfPicker.SearchDone()
+		}
+	}(*c)
 
 	defer logSearchErrors()
 
-	kubeconfigPath, selectedContext, err := showFuzzySearch(kindToStore, showPreview)
+	// the time waiting for the selection of the user
+	selectSpan := tracing.Start("select context", "")
+	var kubeconfigPath, selectedContext string
+	if picker != nil {
+		kubeconfigPath, selectedContext, err = showPicker(picker)
+	} else if fzfPicker != nil {
+		kubeconfigPath, selectedContext, err = showFZF(fzfPicker)
+	} else {
+		var storeIcon func(contextName string) string
+		if showStoreIcons {
+			storeIcon = func(contextName string) string {
+				return getStoreIcon(kindToStore, config.StoreIcons, contextName)
+			}
+		}
+		kubeconfigPath, selectedContext, err = showFuzzySearch(preview, storeIcon, config.CaseSensitivity)
+	}
+	tracing.End(selectSpan, err)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -133,17 +344,12 @@ func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 	// get the tags associated with the selected kubeconfig path
 	tags := readFromPathToTagsMapping(kubeconfigPath)
 
-	// use the store to get the kubeconfig for the selected kubeconfig path
-	kubeconfigData, err := store.GetKubeconfigForPath(kubeconfigPath, tags)
+	// use the store to get the kubeconfig for the selected kubeconfig path. It might have been prefetched already.
+	kubeconfigData, err := prefetchedKubeconfigs.get(store, kubeconfigPath, tags)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	kubeconfig, err := kubeconfigutil.NewKubeconfig(kubeconfigData)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse selected kubeconfig. Please check if this file is a valid kubeconfig: %v", err)
-	}
-
 	// save the original selected context for the history
 	contextForHistory := selectedContext
 
@@ -153,20 +359,37 @@ func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 		selectedContext = strings.TrimPrefix(selectedContext, fmt.Sprintf("%s/", store.GetContextPrefix(kubeconfigPath)))
 	}
 
-	if err := kubeconfig.SetContext(selectedContext, aliasutil.GetContextForAlias(selectedContext, aliasToContext), store.GetContextPrefix(kubeconfigPath)); err != nil {
-		return nil, nil, err
+	options := MaterializeOptions{
+		Store:       store,
+		Path:        kubeconfigPath,
+		Tags:        tags,
+		Data:        kubeconfigData,
+		ContextName: contextForHistory,
+	}
+	if original := readFromAliasToContext(contextForHistory); len(original) > 0 {
+		options.ContextName, options.Alias = original, contextForHistory
 	}
 
-	if err := kubeconfig.SetKubeswitchContext(contextForHistory); err != nil {
+	kubeconfig, err := MaterializeKubeconfig(config, stateDir, options)
+	if err != nil {
 		return nil, nil, err
 	}
 
+	if dryrun.Enabled() {
+		tempKubeconfigPath := kubeconfig.FilePath()
+		return &tempKubeconfigPath, &selectedContext, PrintSwitch(kubeconfig, config, contextForHistory, store.GetID(), readFromAliasToContext(contextForHistory), stateDir, appendToHistory)
+	}
+
 	// write a temporary kubeconfig file and return the path
 	tempKubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
 	}
 
+	if !appendToHistory {
+		return &tempKubeconfigPath, &selectedContext, nil
+	}
+
 	// get namespace for current context
 	ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
 	if err != nil {
@@ -175,19 +398,46 @@ func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 		logger.Warnf("failed to append context to history file: %v", err)
 	}
 
+	// the index contains the context names before applying the alias
+	contextName := contextForHistory
+	if original := readFromAliasToContext(contextForHistory); len(original) > 0 {
+		contextName = original
+	}
+	if err := index.RecordUsage(stateDir, store.GetID(), contextName); err != nil {
+		logger.Debugf("failed to record the usage of the context in the index: %v", err)
+	}
+
 	return &tempKubeconfigPath, &selectedContext, nil
 }
 
-// writeIndex tries to write the Index file for the kubeconfig store
-// if it fails to do so, it logs a warning, but does not panic
-func writeIndex(store storetypes.KubeconfigStore, searchIndex *index.SearchIndex, ctxToPathMapping map[string]string, ctxToTagsMapping map[string]map[string]string) {
-	index := types.Index{
-		Kind:                 store.GetKind(),
-		ContextToPathMapping: ctxToPathMapping,
-		ContextToTags:        ctxToTagsMapping,
+// PrintSwitch prints the files the switch to the context of the given kubeconfig would write
+// and the post-switch hooks that would be executed
+func PrintSwitch(kubeconfig *kubeconfigutil.Kubeconfig, config *types.Config, context, storeID, contextName, stateDir string, appendToHistory bool) error {
+	kubeconfigPath := kubeconfig.FilePath()
+	dryrun.Printf("write the temporary kubeconfig of context %q to %q", context, kubeconfigPath)
+
+	if appendToHistory {
+		ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
+		if err != nil {
+			return fmt.Errorf("failed to get namespace of current context: %v", err)
+		}
+		dryrun.Printf("append %q to the history file %q", historyutil.FormatHistoryEntry(context, ns), os.ExpandEnv(historyutil.HistoryFilePath))
+
+		// the index contains the context names before applying the alias
+		if len(contextName) == 0 {
+			contextName = context
+		}
+		dryrun.Printf("record the usage of context %q of store %q in the index %q", contextName, storeID, index.FilePath(stateDir))
 	}
 
-	if err := searchIndex.Write(index); err != nil {
+	dryrun.Printf("set KUBECONFIG=%s", kubeconfigPath)
+	return hooks.PrintPostSwitchHooks(config, kubeconfig, kubeconfigPath)
+}
+
+// writeIndex tries to write the Index file for the kubeconfig store
+// if it fails to do so, it logs a warning, but does not panic
+func writeIndex(store storetypes.KubeconfigStore, searchIndex *index.SearchIndex, indexWriter *index.Writer) {
+	if err := indexWriter.Commit(); err != nil {
 		store.GetLogger().Warnf("failed to write kubeconfig store index file: %v", err)
 		return
 	}
@@ -202,14 +452,24 @@ func writeIndex(store storetypes.KubeconfigStore, searchIndex *index.SearchIndex
 	}
 }
 
-func showFuzzySearch(storeIDToStore map[string]storetypes.KubeconfigStore, showPreview bool) (string, string, error) {
+// The store icon and the case sensitivity are optional.
+func showFuzzySearch(preview func(contextName string) string, storeIcon func(contextName string) string, caseSensitivity *types.CaseSensitivity) (string, string, error) {
 	// display selection dialog for all kubeconfig context names
 	idx, err := fuzzyfinder.Find(
 		&allKubeconfigContextNames,
 		func(i int) string {
-			return readFromAllKubeconfigContextNames(i)
+			contextName := readFromAllKubeconfigContextNames(i)
+			item := contextName
+			if storeIcon != nil {
+				item = storeIcon(contextName) + " " + item
+			}
+			// the metadata is only known if it should be searched or the cluster is found in more than one store
+			if metadata := readFromContextToMetadata(contextName); len(metadata) > 0 {
+				item += "  " + metadata
+			}
+			return item
 		},
-		getFuzzyFinderOptions(storeIDToStore, showPreview)...,
+		getFuzzyFinderOptions(preview, caseSensitivity)...,
 	)
 
 	if err != nil {
@@ -223,14 +483,138 @@ func showFuzzySearch(storeIDToStore map[string]storetypes.KubeconfigStore, showP
 	return kubeconfigPath, selectedContext, nil
 }
 
-// getFuzzyFinderOptions returns a list of fuzzy finder options
-func getFuzzyFinderOptions(storeIDToStore map[string]storetypes.KubeconfigStore, showPreview bool) []fuzzyfinder.Option {
+// getStoreIcon returns the icon of the kind of the kubeconfig store containing the context
+func getStoreIcon(storeIDToStore map[string]storetypes.KubeconfigStore, icons map[types.StoreKind]string, contextName string) string {
+	kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(readFromContextToPathMapping(contextName))]
+	if !ok {
+		return " "
+	}
+	return theme.StoreIcon(kubeconfigStore.GetKind(), icons)
+}
+
+// showPicker displays the terminal UI picker and maps the selection back to the kubeconfig path
+func showPicker(picker *tui.Picker) (string, string, error) {
+	item, err := picker.Run()
+	if err != nil {
+		return "", "", err
+	}
+
+	return readFromContextToPathMapping(item.Name), item.Name, nil
+}
+
+// showFZF waits for the selection in fzf and maps the selection back to the kubeconfig path
+func showFZF(picker *fzf.Picker) (string, string, error) {
+	selectedContext, err := picker.Run()
+	if err != nil {
+		return "", "", err
+	}
+
+	return readFromContextToPathMapping(selectedContext), selectedContext, nil
+}
+
+// newPicker creates the terminal UI picker for the kubeconfig stores
+// The preview is optional.
+func newPicker(storeIDToStore map[string]storetypes.KubeconfigStore, preview func(contextName string) string, config *types.Config, stateDir string, contextAccess map[string]map[string]index.Access) *tui.Picker {
+	// the clusters are not contacted in offline mode
+	var (
+		showClusterInfo  = config.ShowClusterInfo != nil && *config.ShowClusterInfo && !offline.Enabled()
+		showReachability = config.ShowReachability != nil && *config.ShowReachability && !offline.Enabled()
+		checkAccess      = config.AccessCheck != nil && !offline.Enabled()
+	)
+
+	var storeIDs []string
+	for id := range storeIDToStore {
+		storeIDs = append(storeIDs, id)
+	}
+
+	options := tui.Options{Offline: offline.Enabled()}
+	if preview != nil {
+		options.Preview = func(item tui.Item) string {
+			return preview(item.Name)
+		}
+	}
+	if showClusterInfo {
+		options.ClusterInfo = func(item tui.Item) (string, error) {
+			return getClusterInfo(storeIDToStore, item.Name)
+		}
+	}
+	if showReachability {
+		options.Probe = func(item tui.Item) (time.Duration, error) {
+			return probeCluster(storeIDToStore, item.Name)
+		}
+	}
+	if checkAccess {
+		options.HideInaccessible = accessCheckMode(config) == types.AccessCheckModeHide
+		options.CheckAccess = func(item tui.Item) bool {
+			return checkItemAccess(storeIDToStore, config, stateDir, contextAccess, item)
+		}
+	}
+
+	if config.PrefetchKubeconfigs != nil && *config.PrefetchKubeconfigs > 0 {
+		options.PrefetchCount = *config.PrefetchKubeconfigs
+		options.Prefetch = func(item tui.Item) {
+			path := readFromContextToPathMapping(item.Name)
+			if kubeconfigStore, ok := storeIDToStore[readFromPathToStoreID(path)]; ok {
+				prefetchedKubeconfigs.prefetch(kubeconfigStore, path, readFromPathToTagsMapping(path))
+			}
+		}
+	}
+
+	options.Theme = theme.New(config.Environments)
+	if config.SortOrder != nil {
+		options.SortOrder = *config.SortOrder
+	}
+	if config.ResultsView != nil {
+		options.ResultsView = *config.ResultsView
+	}
+	if config.Matching != nil {
+		options.Matching = *config.Matching
+	}
+	if config.CaseSensitivity != nil {
+		options.CaseSensitivity = *config.CaseSensitivity
+	}
+	options.Keybindings, options.Commands = getPickerKeybindings(storeIDToStore, config, stateDir, config.Keybindings)
+	options.CopyKubeconfig = func(items []tui.Item) (string, error) {
+		return copyKubeconfigToClipboard(storeIDToStore, config, stateDir, itemNames(items))
+	}
+	options.DeleteContexts = func(items []tui.Item) (string, error) {
+		return deleteContextsFromIndex(storeIDToStore, stateDir, itemNames(items))
+	}
+	options.EditNote = func(item tui.Item) (*exec.Cmd, func() (string, error), error) {
+		return notes.EditCommand(stateDir, item.Name)
+	}
+	options.OpenConsole = func(item tui.Item) (string, error) {
+		return openConsole(storeIDToStore, item.Name)
+	}
+
+	return tui.New(storeIDs, options)
+}
+
+func itemNames(items []tui.Item) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// getFuzzyFinderOptions returns a list of fuzzy finder options.
+// The preview and the case sensitivity are optional.
+func getFuzzyFinderOptions(preview func(contextName string) string, caseSensitivity *types.CaseSensitivity) []fuzzyfinder.Option {
 	options := []fuzzyfinder.Option{fuzzyfinder.WithHotReloadLock(hotReloadLock.RLocker())}
 
-	if showPreview {
-		log := logrus.New()
+	if caseSensitivity != nil {
+		switch *caseSensitivity {
+		case types.CaseSensitivitySensitive:
+			options = append(options, fuzzyfinder.WithMode(fuzzyfinder.ModeCaseSensitive))
+		case types.CaseSensitivityInsensitive:
+			options = append(options, fuzzyfinder.WithMode(fuzzyfinder.ModeCaseInsensitive))
+		}
+	}
+
+	if preview != nil {
 		withPreviewWindow := fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
-			if !showPreview || i == -1 {
+			if i == -1 {
 				return ""
 			}
 
@@ -239,37 +623,7 @@ func getFuzzyFinderOptions(storeIDToStore map[string]storetypes.KubeconfigStore,
 			currentContextName := readFromAllKubeconfigContextNames(i)
 			hotReloadLock.RUnlock()
 
-			path := readFromContextToPathMapping(currentContextName)
-			tags := readFromPathToTagsMapping(path)
-			storeID := readFromPathToStoreID(path)
-			kubeconfigStore := storeIDToStore[storeID]
-
-			var storeSpecificPreview *string
-			previewer, ok := kubeconfigStore.(storetypes.Previewer)
-			if ok {
-				pr, err := previewer.GetSearchPreview(path, tags)
-				if err != nil {
-					log.Debugf("failed to get preview for store %s: %v", kubeconfigStore.GetID(), err)
-					return ""
-				}
-				storeSpecificPreview = &pr
-			}
-
-			preview, err := getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore, path, tags)
-			if err != nil {
-				log.Debugf("failed to get kubeconfig preview: %v", err)
-				return ""
-			}
-
-			if storeSpecificPreview != nil {
-				separators := make([]string, 20)
-				for i := 0; i < 20; i++ {
-					separators[i] = "-"
-				}
-				preview = fmt.Sprintf("%s \n %s \n \n %s", preview, strings.Join(separators, "-"), *storeSpecificPreview)
-			}
-
-			return preview
+			return preview(currentContextName)
 		})
 
 		options = append(options, withPreviewWindow)
@@ -278,6 +632,63 @@ func getFuzzyFinderOptions(storeIDToStore map[string]storetypes.KubeconfigStore,
 	return options
 }
 
+// getPreview returns the sanitized kubeconfig of the context and the store specific preview.
+// If a preview template is configured, the preview is rendered using the template instead.
+func getPreview(storeIDToStore map[string]storetypes.KubeconfigStore, previewTemplate *string, stateDir, contextName string) string {
+	path := readFromContextToPathMapping(contextName)
+	tags := readFromPathToTagsMapping(path)
+	storeID := readFromPathToStoreID(path)
+	kubeconfigStore := storeIDToStore[storeID]
+
+	var storeSpecificPreview *string
+	previewer, ok := kubeconfigStore.(storetypes.Previewer)
+	if ok {
+		pr, err := previewer.GetSearchPreview(path, tags)
+		if err != nil {
+			logger.Debugf("failed to get preview for store %s: %v", kubeconfigStore.GetID(), err)
+			return ""
+		}
+		storeSpecificPreview = &pr
+	}
+
+	preview, err := getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore, path, tags)
+	if err != nil {
+		logger.Debugf("failed to get kubeconfig preview: %v", err)
+		return ""
+	}
+
+	if previewTemplate != nil {
+		data := previewData{
+			Context:    contextName,
+			StoreID:    storeID,
+			StoreKind:  string(kubeconfigStore.GetKind()),
+			Path:       path,
+			Tags:       tags,
+			Region:     tags["region"],
+			Account:    tags["account"],
+			CacheAge:   getCacheAge(kubeconfigStore, stateDir),
+			Kubeconfig: preview,
+		}
+		if note, err := notes.GetNote(stateDir, contextName); err == nil {
+			data.Note = note
+		}
+		if storeSpecificPreview != nil {
+			data.StorePreview = *storeSpecificPreview
+		}
+		return renderPreview(*previewTemplate, data)
+	}
+
+	if storeSpecificPreview != nil {
+		separators := make([]string, 20)
+		for i := 0; i < 20; i++ {
+			separators[i] = "-"
+		}
+		preview = fmt.Sprintf("%s \n %s \n \n %s", preview, strings.Join(separators, "-"), *storeSpecificPreview)
+	}
+
+	return preview
+}
+
 func getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore storetypes.KubeconfigStore, path string, tags map[string]string) (string, error) {
 	// during first run without index, the files are already read in the getContextsForKubeconfigPath and saved in-memory
 	kubeconfig := readFromPathToKubeconfig(path)
@@ -285,7 +696,7 @@ func getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore storetypes.Kubeconf
 		return kubeconfig, nil
 	}
 
-	data, err := kubeconfigStore.GetKubeconfigForPath(path, tags)
+	data, err := prefetchedKubeconfigs.get(kubeconfigStore, path, tags)
 	if err != nil {
 		return "", fmt.Errorf("could not read kubeconfig with path '%s': %v", path, err)
 	}
@@ -360,20 +771,94 @@ func readFromPathToKubeconfig(key string) string {
 	return pathToKubeconfig[key]
 }
 
+// writeToPathToKubeconfig caches the kubeconfig. Evicts the kubeconfig cached first if the cache is full.
 func writeToPathToKubeconfig(key, value string) {
 	pathToKubeconfigLock.Lock()
 	defer pathToKubeconfigLock.Unlock()
+
+	if _, ok := pathToKubeconfig[key]; !ok {
+		pathToKubeconfigOrder = append(pathToKubeconfigOrder, key)
+		if len(pathToKubeconfigOrder) > maxCachedKubeconfigs {
+			delete(pathToKubeconfig, pathToKubeconfigOrder[0])
+			pathToKubeconfigOrder = pathToKubeconfigOrder[1:]
+		}
+	}
 	pathToKubeconfig[key] = value
 }
 
+func readFromAliasToContext(key string) string {
+	aliasToContextLock.RLock()
+	defer aliasToContextLock.RUnlock()
+	return aliasToContext[key]
+}
+
 func writeToAliasToContext(key, value string) {
 	aliasToContextLock.Lock()
 	defer aliasToContextLock.Unlock()
 	aliasToContext[key] = value
 }
 
+func readFromContextToMetadata(key string) string {
+	contextToMetadataLock.RLock()
+	defer contextToMetadataLock.RUnlock()
+	return contextToMetadata[key]
+}
+
+func writeToContextToMetadata(key, value string) {
+	contextToMetadataLock.Lock()
+	defer contextToMetadataLock.Unlock()
+	contextToMetadata[key] = value
+}
+
 // logSearchErrors logs errors that were suppressed during the search
+// SetAliasNamespace sets the namespace of the current context if the alias defines one
+func SetAliasNamespace(kubeconfig *kubeconfigutil.Kubeconfig, stateDir, alias string) error {
+	namespace, err := aliasstate.GetNamespaceForAlias(stateDir, alias)
+	if err != nil {
+		return err
+	}
+	if len(namespace) == 0 {
+		return nil
+	}
+	return kubeconfig.SetNamespaceForCurrentContext(namespace)
+}
+
+// WarnExpiringCredentials warns if the credentials of the current context expire soon or are expired even though
+// the kubeconfig has just been retrieved, so that requests failing with "Unauthorized" do not come as a surprise
+func WarnExpiringCredentials(kubeconfig *kubeconfigutil.Kubeconfig) {
+	data, err := kubeconfig.GetBytes()
+	if err != nil {
+		return
+	}
+	contextToExpiry, err := util.GetContextCredentialsExpiry(data, "")
+	if err != nil {
+		logger.Debugf("failed to get the expiry of the credentials: %v", err)
+		return
+	}
+	if expiry, ok := contextToExpiry[kubeconfig.GetCurrentContext()]; ok && time.Until(expiry) < util.CredentialsExpiryWarning {
+		logger.Warnf("Context %q: %s", kubeconfig.GetCurrentContext(), util.DescribeCredentialsExpiry(expiry))
+	}
+}
+
+// ValidateKubeconfig validates the kubeconfig retrieved from the kubeconfig store before switching to one of its contexts.
+// The error lists all problems found, pointing at the store and path of the kubeconfig.
+func ValidateKubeconfig(store storetypes.KubeconfigStore, path string, kubeconfigData []byte) error {
+	problems := util.ValidateKubeconfig(kubeconfigData)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("kubeconfig %q of store %q is invalid:\n  - %s", path, store.GetID(), strings.Join(problems, "\n  - "))
+}
+
+func appendToSearchError(err error) {
+	searchErrorLock.Lock()
+	defer searchErrorLock.Unlock()
+	searchError = multierror.Append(searchError, err)
+}
+
 func logSearchErrors() {
+	searchErrorLock.Lock()
+	defer searchErrorLock.Unlock()
 	if searchError != nil {
 		logger.Warnf("Supressed warnings during the search: %v", searchError.Error())
 	}
@@ -0,0 +1,176 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/boundary"
+	"github.com/danielfoehrkn/kubeswitch/pkg/encryption"
+	"github.com/danielfoehrkn/kubeswitch/pkg/policy"
+	"github.com/danielfoehrkn/kubeswitch/pkg/proxy"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	cloudflaretoken "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/cloudflare-token"
+	execcredential "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/exec-credential"
+	oidctoken "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/oidc-token"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tailscale"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// MaterializeOptions describe the context whose kubeconfig is materialized
+type MaterializeOptions struct {
+	// Store is the kubeconfig store containing the context
+	Store storetypes.KubeconfigStore
+	// Path is the path of the kubeconfig in the kubeconfig store
+	Path string
+	// Tags are the tags of the kubeconfig in the kubeconfig store
+	Tags map[string]string
+	// Data is the kubeconfig if it has already been retrieved from the store, e.g. prefetched by the picker.
+	// Otherwise, the kubeconfig is retrieved from the store.
+	Data []byte
+	// ContextName is the name of the context as discovered by the search, including the prefix of the store.
+	// Defaults to the current context of the kubeconfig.
+	ContextName string
+	// Alias is the alias of the context, if any. The context is renamed to its alias.
+	Alias string
+	// Portable skips the steps tying the kubeconfig to this machine, for kubeconfigs used elsewhere (e.g. exported or copied to the clipboard):
+	// local proxies and tunnels, credential plugins calling the switcher binary, thin kubeconfigs and encrypted credentials
+	Portable bool
+}
+
+// DisplayName returns the name of the context shown in the search, which is the alias if the context has one
+func (o MaterializeOptions) DisplayName() string {
+	if len(o.Alias) > 0 {
+		return o.Alias
+	}
+	return o.ContextName
+}
+
+// MaterializeKubeconfig retrieves the kubeconfig of the context from its kubeconfig store and prepares it like when switching to the context.
+// Every command handing out the kubeconfig of a context uses this function, so that the kubeconfig policy of the SwitchConfig is always enforced.
+// The kubeconfig is not written.
+func MaterializeKubeconfig(config *types.Config, stateDir string, options MaterializeOptions) (*kubeconfigutil.Kubeconfig, error) {
+	store := options.Store
+	storeConfig := store.GetStoreConfig()
+	prefix := store.GetContextPrefix(options.Path)
+
+	data := options.Data
+	if data == nil {
+		var err error
+		if data, err = store.GetKubeconfigForPath(options.Path, options.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if config != nil && config.ValidateKubeconfigs != nil && *config.ValidateKubeconfigs {
+		if err := ValidateKubeconfig(store, options.Path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig. Please check if this file is a valid kubeconfig: %v", err)
+	}
+
+	if len(options.ContextName) == 0 {
+		options.ContextName = kubeconfig.GetCurrentContext()
+		if len(prefix) > 0 {
+			options.ContextName = fmt.Sprintf("%s/%s", prefix, options.ContextName)
+		}
+	}
+
+	// the context name in the kubeconfig file is not prefixed
+	contextWithoutPrefix := options.ContextName
+	if len(prefix) > 0 {
+		contextWithoutPrefix = strings.TrimPrefix(contextWithoutPrefix, fmt.Sprintf("%s/", prefix))
+	}
+
+	// rename the context to its alias like when selecting the context in the picker
+	currentContext, originalContextBeforeAlias := contextWithoutPrefix, ""
+	if len(options.Alias) > 0 {
+		currentContext, originalContextBeforeAlias = options.Alias, contextWithoutPrefix
+	}
+	if err := kubeconfig.SetContext(currentContext, originalContextBeforeAlias, prefix); err != nil {
+		return nil, err
+	}
+
+	if len(options.Alias) > 0 {
+		if err := SetAliasNamespace(kubeconfig, stateDir, options.Alias); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := kubeconfig.SetKubeswitchContext(options.DisplayName()); err != nil {
+		return nil, err
+	}
+
+	if err := kubeconfig.SetKubeswitchStore(string(store.GetKind()), store.GetID()); err != nil {
+		return nil, err
+	}
+
+	if impersonate := storeConfig.Impersonate; impersonate != nil {
+		if err := kubeconfig.SetImpersonation(impersonate.User, impersonate.Groups); err != nil {
+			return nil, fmt.Errorf("failed to configure impersonation: %v", err)
+		}
+	}
+
+	if !options.Portable {
+		if err := proxy.Apply(kubeconfig, config, storeConfig, stateDir, options.DisplayName(), options.ContextName); err != nil {
+			return nil, fmt.Errorf("failed to configure proxy: %v", err)
+		}
+
+		if err := tailscale.Apply(kubeconfig, config, options.DisplayName(), options.ContextName); err != nil {
+			return nil, err
+		}
+
+		if err := boundary.Apply(kubeconfig, config, stateDir, options.DisplayName(), options.ContextName); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := policy.Apply(kubeconfig, config, options.DisplayName()); err != nil {
+		return nil, err
+	}
+
+	if options.Portable {
+		return kubeconfig, nil
+	}
+
+	WarnExpiringCredentials(kubeconfig)
+
+	if err := oidctoken.ReplaceKubeloginUsers(kubeconfig, storeConfig.OIDC, stateDir); err != nil {
+		return nil, fmt.Errorf("failed to configure OIDC login: %v", err)
+	}
+
+	if err := cloudflaretoken.ReplaceUsers(kubeconfig, storeConfig.CloudflareAccess); err != nil {
+		return nil, fmt.Errorf("failed to configure Cloudflare Access login: %v", err)
+	}
+
+	if config != nil && config.ThinKubeconfigs != nil && *config.ThinKubeconfigs {
+		if err := execcredential.Thin(kubeconfig, store.GetID(), options.Path, options.Tags, stateDir, options.ContextName, options.Alias); err != nil {
+			return nil, fmt.Errorf("failed to remove credentials from temporary kubeconfig: %v", err)
+		}
+	}
+
+	if config != nil && config.EncryptTemporaryKubeconfigs != nil && *config.EncryptTemporaryKubeconfigs {
+		if err := encryption.EncryptKubeconfigCredentials(kubeconfig, config.TemporaryKubeconfigEncryptionKey, stateDir); err != nil {
+			return nil, fmt.Errorf("failed to encrypt credentials of temporary kubeconfig: %v", err)
+		}
+	}
+	return kubeconfig, nil
+}
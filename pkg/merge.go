@@ -0,0 +1,93 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// AddContextToKubeconfig adds the context of the kubeconfig in the kubeconfig store
+// with its cluster and user to the merged kubeconfig. The context, cluster and user are named like the given name.
+// The kubeconfig is materialized like when switching to the context, so that the kubeconfig policy is enforced.
+// Referenced files (e.g. certificates) are embedded.
+func AddContextToKubeconfig(merged *clientcmdapi.Config, name string, config *types.Config, stateDir string, options MaterializeOptions) error {
+	options.Portable = true
+	materialized, err := MaterializeKubeconfig(config, stateDir, options)
+	if err != nil {
+		return err
+	}
+
+	kubeconfigData, err := materialized.GetBytes()
+	if err != nil {
+		return err
+	}
+	store, path := options.Store, options.Path
+
+	kubeconfig, err := clientcmd.Load(kubeconfigData)
+	if err != nil {
+		return fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+
+	// relative file references of kubeconfigs on the filesystem are relative to the kubeconfig file
+	if store.GetKind() == types.StoreKindFilesystem {
+		for _, cluster := range kubeconfig.Clusters {
+			cluster.LocationOfOrigin = path
+		}
+		for _, authInfo := range kubeconfig.AuthInfos {
+			authInfo.LocationOfOrigin = path
+		}
+		if err := clientcmd.ResolveLocalPaths(kubeconfig); err != nil {
+			return err
+		}
+	}
+
+	if err := clientcmdapi.FlattenConfig(kubeconfig); err != nil {
+		return fmt.Errorf("failed to embed the referenced files: %v", err)
+	}
+
+	// the materialized kubeconfig points to the context, which is renamed to its alias if it has one
+	contextName := kubeconfig.CurrentContext
+	context, ok := kubeconfig.Contexts[contextName]
+	if !ok {
+		return fmt.Errorf("context %q not found in kubeconfig %q", contextName, path)
+	}
+
+	cluster, ok := kubeconfig.Clusters[context.Cluster]
+	if !ok {
+		return fmt.Errorf("cluster %q of context %q not found", context.Cluster, contextName)
+	}
+
+	exported := context.DeepCopy()
+	exported.LocationOfOrigin = ""
+	exported.Cluster = name
+	merged.Clusters[name] = cluster
+	cluster.LocationOfOrigin = ""
+
+	// contexts without a user use the default credentials
+	exported.AuthInfo = ""
+	if authInfo, ok := kubeconfig.AuthInfos[context.AuthInfo]; ok {
+		authInfo.LocationOfOrigin = ""
+		exported.AuthInfo = name
+		merged.AuthInfos[name] = authInfo
+	}
+
+	merged.Contexts[name] = exported
+	return nil
+}
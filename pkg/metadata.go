@@ -0,0 +1,79 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// contextMetadata returns the metadata of a context that is searched in addition to its name:
+// the host of the API server and the values of the tags sorted by their key, e.g. the account and region.
+func contextMetadata(server string, tags map[string]string) string {
+	var metadata []string
+	if len(server) > 0 {
+		if u, err := url.Parse(server); err == nil && len(u.Host) > 0 {
+			server = u.Host
+		}
+		metadata = append(metadata, server)
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		// the cloud tags are shown in columns of their own
+		if !strings.HasPrefix(key, storetypes.CloudTagPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if value := tags[key]; len(value) > 0 {
+			metadata = append(metadata, value)
+		}
+	}
+	return strings.Join(metadata, " ")
+}
+
+// cloudTagColumns returns the cloud tags of a context (e.g. owner and team) as "<key>=<value>" columns sorted by their key
+func cloudTagColumns(tags map[string]string) string {
+	cloudTags := storetypes.CloudTags(tags)
+	keys := make([]string, 0, len(cloudTags))
+	for key := range cloudTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		columns = append(columns, fmt.Sprintf("%s=%s", key, cloudTags[key]))
+	}
+	return strings.Join(columns, " ")
+}
+
+// showCloudTags returns true if any kubeconfig store adds cloud tags to the metadata of its contexts
+func showCloudTags(config *types.Config) bool {
+	for _, store := range config.KubeconfigStores {
+		if len(store.CloudTags) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy enforces the security baselines of the kubeconfig policy in the SwitchConfig
+// on the kubeconfigs of all kubeconfig stores before the temporary kubeconfig is written.
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/verify"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const fieldInsecureSkipTLSVerify = "insecure-skip-tls-verify"
+
+var logger = logging.New()
+
+// Apply enforces the kubeconfig policy of the SwitchConfig on the kubeconfig whose current context is switched to.
+// The context name is the name shown in the search (or the alias), used to determine if the context is protected.
+// Modifies the clusters of the kubeconfig and fails if the credentials of the current context violate the policy.
+func Apply(kubeconfig *kubeconfigutil.Kubeconfig, config *types.Config, contextName string) error {
+	if config == nil || config.KubeconfigPolicy == nil {
+		return nil
+	}
+	policy := config.KubeconfigPolicy
+
+	if policy.StripInsecureSkipTLSVerify != nil && *policy.StripInsecureSkipTLSVerify {
+		for _, cluster := range kubeconfig.RemoveClusterField(fieldInsecureSkipTLSVerify) {
+			logger.Debugf("Removed %s from cluster %q as required by the kubeconfig policy", fieldInsecureSkipTLSVerify, cluster)
+		}
+	}
+
+	if policy.ProxyURL != nil {
+		kubeconfig.SetClusterField("proxy-url", *policy.ProxyURL)
+	}
+
+	forbidStaticTokens := policy.ForbidStaticTokensForProtectedContexts != nil && *policy.ForbidStaticTokensForProtectedContexts
+	if !forbidStaticTokens && policy.MaxCredentialsTTL == nil {
+		return nil
+	}
+
+	data, err := kubeconfig.GetBytes()
+	if err != nil {
+		return err
+	}
+	currentContext := kubeconfig.GetCurrentContext()
+
+	if forbidStaticTokens && verify.IsProtected(config.ProtectedContexts, contextName, currentContext) {
+		if err := checkStaticToken(data, currentContext); err != nil {
+			return fmt.Errorf("context %q violates the kubeconfig policy: %v", contextName, err)
+		}
+	}
+
+	if policy.MaxCredentialsTTL != nil {
+		contextToExpiry, err := util.GetContextCredentialsExpiry(data, "")
+		if err != nil {
+			return fmt.Errorf("failed to get the expiry of the credentials: %v", err)
+		}
+		if expiry, ok := contextToExpiry[currentContext]; ok && time.Until(expiry) > *policy.MaxCredentialsTTL {
+			return fmt.Errorf("context %q violates the kubeconfig policy: the credentials are valid for %s, longer than the maximum of %s",
+				contextName, time.Until(expiry).Round(time.Minute), *policy.MaxCredentialsTTL)
+		}
+	}
+	return nil
+}
+
+// checkStaticToken fails if the user of the context authenticates with a static token
+func checkStaticToken(data []byte, contextName string) error {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+
+	context, ok := config.Contexts[contextName]
+	if !ok {
+		return fmt.Errorf("context %q not found", contextName)
+	}
+	user, ok := config.AuthInfos[context.AuthInfo]
+	if !ok {
+		return nil
+	}
+
+	if len(user.Token) > 0 || len(user.TokenFile) > 0 {
+		return fmt.Errorf("protected contexts must not use static tokens, but user %q does", context.AuthInfo)
+	}
+	return nil
+}
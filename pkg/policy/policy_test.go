@@ -0,0 +1,145 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/policy"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// newKubeconfig returns a kubeconfig whose user authenticates with the given credentials
+func newKubeconfig(user string) *kubeconfigutil.Kubeconfig {
+	kubeconfig, err := kubeconfigutil.NewKubeconfig([]byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+    insecure-skip-tls-verify: true
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+users:
+- name: admin
+  user:
+%s
+`, user)))
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	return kubeconfig
+}
+
+// token returns a JWT expiring at the given time. The signature is not verified.
+func token(expiry time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return fmt.Sprintf("%s.%s.%s", encode([]byte(`{"alg":"none"}`)), encode([]byte(fmt.Sprintf(`{"exp":%d}`, expiry.Unix()))), encode([]byte("signature")))
+}
+
+func kubeconfigString(kubeconfig *kubeconfigutil.Kubeconfig) string {
+	data, err := kubeconfig.GetBytes()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	return string(data)
+}
+
+var _ = Describe("Apply", func() {
+	var config *types.Config
+
+	BeforeEach(func() {
+		config = &types.Config{
+			ProtectedContexts: []string{"*prod*"},
+			KubeconfigPolicy:  &types.KubeconfigPolicy{},
+		}
+	})
+
+	It("should not modify the kubeconfig without a policy", func() {
+		kubeconfig := newKubeconfig("    token: static")
+		before := kubeconfigString(kubeconfig)
+
+		Expect(policy.Apply(kubeconfig, &types.Config{ProtectedContexts: []string{"*prod*"}}, "prod")).To(Succeed())
+		Expect(kubeconfigString(kubeconfig)).To(Equal(before))
+	})
+
+	It("should strip insecure-skip-tls-verify and set the proxy URL of the clusters", func() {
+		config.KubeconfigPolicy.StripInsecureSkipTLSVerify = ptr.To(true)
+		config.KubeconfigPolicy.ProxyURL = ptr.To("socks5://localhost:1080")
+		kubeconfig := newKubeconfig("    token: static")
+
+		Expect(policy.Apply(kubeconfig, config, "dev")).To(Succeed())
+
+		Expect(kubeconfigString(kubeconfig)).ToNot(ContainSubstring("insecure-skip-tls-verify"))
+		Expect(kubeconfig.GetClusterFields("proxy-url")).To(Equal(map[string]string{"prod": "socks5://localhost:1080"}))
+	})
+
+	Context("static tokens", func() {
+		BeforeEach(func() {
+			config.KubeconfigPolicy.ForbidStaticTokensForProtectedContexts = ptr.To(true)
+		})
+
+		It("should reject a static token for a protected context", func() {
+			err := policy.Apply(newKubeconfig("    token: static"), config, "prod")
+			Expect(err).To(MatchError(ContainSubstring(`protected contexts must not use static tokens, but user "admin" does`)))
+		})
+
+		It("should determine protected contexts by the context name shown in the search", func() {
+			err := policy.Apply(newKubeconfig("    token: static"), &types.Config{
+				ProtectedContexts: []string{"live"},
+				KubeconfigPolicy:  config.KubeconfigPolicy,
+			}, "live")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should allow a static token for an unprotected context", func() {
+			config.ProtectedContexts = []string{"live"}
+			Expect(policy.Apply(newKubeconfig("    token: static"), config, "dev")).To(Succeed())
+		})
+
+		It("should allow other credentials for a protected context", func() {
+			Expect(policy.Apply(newKubeconfig("    exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: kubelogin"), config, "prod")).To(Succeed())
+		})
+	})
+
+	Context("maximum credentials TTL", func() {
+		BeforeEach(func() {
+			config.KubeconfigPolicy.MaxCredentialsTTL = ptr.To(time.Hour)
+		})
+
+		It("should reject credentials valid for longer than the maximum", func() {
+			kubeconfig := newKubeconfig("    token: " + token(time.Now().Add(24*time.Hour)))
+
+			err := policy.Apply(kubeconfig, config, "prod")
+			Expect(err).To(MatchError(ContainSubstring("longer than the maximum of 1h0m0s")))
+		})
+
+		It("should allow credentials valid for less than the maximum", func() {
+			kubeconfig := newKubeconfig("    token: " + token(time.Now().Add(30*time.Minute)))
+			Expect(policy.Apply(kubeconfig, config, "prod")).To(Succeed())
+		})
+
+		It("should allow credentials without a known expiry", func() {
+			Expect(policy.Apply(newKubeconfig("    token: static"), config, "prod")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,111 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+)
+
+const (
+	// maxPrefetches is the maximum number of kubeconfigs retrieved in the background at the same time
+	maxPrefetches = 4
+	// prefetchMaxAge is the age after which a prefetched kubeconfig is retrieved again, as its credentials may have expired
+	prefetchMaxAge = 5 * time.Minute
+)
+
+// prefetchedKubeconfigs are the kubeconfigs retrieved in the background for the top results of the picker
+var prefetchedKubeconfigs = newKubeconfigPrefetcher()
+
+// prefetchedKubeconfig is a kubeconfig that is being retrieved in the background
+type prefetchedKubeconfig struct {
+	// done is closed once the kubeconfig has been retrieved
+	done      chan struct{}
+	data      []byte
+	err       error
+	fetchedAt time.Time
+}
+
+// kubeconfigPrefetcher retrieves kubeconfigs from the kubeconfig stores in the background
+type kubeconfigPrefetcher struct {
+	lock        sync.Mutex
+	kubeconfigs map[string]*prefetchedKubeconfig
+	slots       chan struct{}
+}
+
+func newKubeconfigPrefetcher() *kubeconfigPrefetcher {
+	return &kubeconfigPrefetcher{
+		kubeconfigs: make(map[string]*prefetchedKubeconfig),
+		slots:       make(chan struct{}, maxPrefetches),
+	}
+}
+
+// prefetch starts to retrieve the kubeconfig for the path in the background, unless it is already retrieved
+func (p *kubeconfigPrefetcher) prefetch(store storetypes.KubeconfigStore, path string, tags map[string]string) {
+	key := fmt.Sprintf("%s/%s", store.GetID(), path)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if kubeconfig, ok := p.kubeconfigs[key]; ok && !kubeconfig.expired() {
+		return
+	}
+
+	kubeconfig := &prefetchedKubeconfig{done: make(chan struct{})}
+	p.kubeconfigs[key] = kubeconfig
+
+	go func() {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+
+		kubeconfig.data, kubeconfig.err = store.GetKubeconfigForPath(path, tags)
+		kubeconfig.fetchedAt = time.Now()
+		if kubeconfig.err != nil {
+			store.GetLogger().Debugf("failed to prefetch kubeconfig with path %q: %v", path, kubeconfig.err)
+		}
+		close(kubeconfig.done)
+	}()
+}
+
+// get returns the kubeconfig for the path. Waits for a prefetch in progress.
+// The kubeconfig is retrieved from the kubeconfig store if it has not been prefetched, the prefetch failed or the prefetched kubeconfig expired.
+func (p *kubeconfigPrefetcher) get(store storetypes.KubeconfigStore, path string, tags map[string]string) ([]byte, error) {
+	key := fmt.Sprintf("%s/%s", store.GetID(), path)
+
+	p.lock.Lock()
+	kubeconfig, ok := p.kubeconfigs[key]
+	p.lock.Unlock()
+
+	if ok {
+		<-kubeconfig.done
+		if kubeconfig.err == nil && !kubeconfig.expired() {
+			return kubeconfig.data, nil
+		}
+	}
+	return store.GetKubeconfigForPath(path, tags)
+}
+
+// expired returns true if the kubeconfig has been retrieved longer than the maximum age ago
+func (k *prefetchedKubeconfig) expired() bool {
+	select {
+	case <-k.done:
+		return time.Since(k.fetchedAt) > prefetchMaxAge
+	default:
+		return false
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+)
+
+var (
+	// indexUpdateTimes caches the last update time of the search index by store ID
+	indexUpdateTimes     = make(map[string]*time.Time)
+	indexUpdateTimesLock = sync.Mutex{}
+)
+
+// previewData is passed to the preview template
+type previewData struct {
+	// Context is the name (or alias) of the context
+	Context string
+	// StoreID is the ID of the kubeconfig store containing the context
+	StoreID string
+	// StoreKind is the kind of the kubeconfig store containing the context
+	StoreKind string
+	// Path is the path of the kubeconfig in the kubeconfig store
+	Path string
+	// Tags are the tags of the kubeconfig in the kubeconfig store
+	Tags map[string]string
+	// Region is the region of the cluster, if the kubeconfig store tags the kubeconfig with a "region"
+	Region string
+	// Account is the cloud account of the cluster, if the kubeconfig store tags the kubeconfig with an "account"
+	Account string
+	// CacheAge is the time since the search index of the kubeconfig store has been refreshed.
+	// Zero if the kubeconfig store does not use a search index.
+	CacheAge time.Duration
+	// Kubeconfig is the sanitized kubeconfig
+	Kubeconfig string
+	// StorePreview is the preview provided by the kubeconfig store, e.g. the Gardener shoot status
+	StorePreview string
+	// Note is the note attached to the context with "switch note"
+	Note string
+}
+
+// renderPreview renders the preview template. Errors are shown in the preview.
+func renderPreview(previewTemplate string, data previewData) string {
+	preview, err := switchconfig.ExpandString(previewTemplate, data, nil)
+	if err != nil {
+		return fmt.Sprintf("failed to render preview template: %v", err)
+	}
+	return preview
+}
+
+// getCacheAge returns the time since the search index of the store has been refreshed
+func getCacheAge(store storetypes.KubeconfigStore, stateDir string) time.Duration {
+	indexUpdateTimesLock.Lock()
+	defer indexUpdateTimesLock.Unlock()
+
+	lastUpdateTime, ok := indexUpdateTimes[store.GetID()]
+	if !ok {
+		searchIndex, err := index.New(store.GetLogger(), store.GetKind(), stateDir, store.GetID())
+		if err == nil {
+			lastUpdateTime, err = searchIndex.GetLastUpdateTime()
+		}
+		if err != nil {
+			logger.Debugf("failed to get last update time of search index for store %s: %v", store.GetID(), err)
+		}
+		indexUpdateTimes[store.GetID()] = lastUpdateTime
+	}
+
+	if lastUpdateTime == nil {
+		return 0
+	}
+	return time.Since(*lastUpdateTime).Round(time.Second)
+}
@@ -0,0 +1,271 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxy routes the traffic to the API servers through the proxies configured for the kubeconfig stores and contexts
+// and manages the tunnels providing these proxies, i.e. SSH tunnels to jump hosts and Cloudflare Access TCP tunnels.
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// tunnelDirectory is the directory in the state directory containing the tunnels started by kubeswitch
+	tunnelDirectory = "tunnels"
+	// defaultCloudflared is the default executable of cloudflared
+	defaultCloudflared = "cloudflared"
+	// defaultStartTimeout is the default maximum duration to wait for a tunnel to accept connections
+	defaultStartTimeout = 10 * time.Second
+	// dialTimeout is the timeout of checking if a proxy accepts connections
+	dialTimeout = 500 * time.Millisecond
+)
+
+var logger = logging.New()
+
+// Tunnel is a tunnel started by kubeswitch
+type Tunnel struct {
+	// URL is the URL of the SOCKS5 proxy provided by the tunnel
+	URL string `json:"url"`
+	// Host is the jump host or the hostname of the Cloudflare Access application
+	Host string `json:"host"`
+	// PID is the process ID of ssh or cloudflared
+	PID int `json:"pid"`
+	// StartedAt is the time the tunnel has been started
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Select returns the proxy of the context: the first proxy of the SwitchConfig whose patterns match one of the context names
+// (e.g. the alias and the name in the kubeconfig), otherwise the proxy of the kubeconfig store. Returns nil if no proxy is configured.
+func Select(config *types.Config, store types.KubeconfigStore, contextNames ...string) *types.Proxy {
+	if config != nil {
+		for i, proxy := range config.Proxies {
+			for _, pattern := range proxy.Contexts {
+				m := wildmatch.NewWildMatch(pattern)
+				for _, contextName := range contextNames {
+					if len(contextName) > 0 && m.IsMatch(contextName) {
+						return &config.Proxies[i]
+					}
+				}
+			}
+		}
+	}
+	return store.Proxy
+}
+
+// Apply sets the proxy-url of all clusters of the kubeconfig to the proxy of the context
+// and starts the tunnel providing the proxy if it is not running yet.
+func Apply(kubeconfig *kubeconfigutil.Kubeconfig, config *types.Config, store types.KubeconfigStore, stateDir string, contextNames ...string) error {
+	proxy := Select(config, store, contextNames...)
+	if proxy == nil {
+		return nil
+	}
+	kubeconfig.SetClusterField("proxy-url", proxy.URL)
+
+	if proxy.SSH == nil && proxy.Cloudflared == nil {
+		return nil
+	}
+	if dryrun.Enabled() {
+		if !Running(proxy.URL) {
+			_, _, destination, _ := tunnelCommand(*proxy, "")
+			dryrun.Printf("start a tunnel to %q providing the proxy %q", destination, proxy.URL)
+		}
+		return nil
+	}
+	return EnsureTunnel(*proxy, stateDir)
+}
+
+// Running returns true if the proxy accepts connections
+func Running(proxyURL string) bool {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", u.Host, dialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// EnsureTunnel starts the tunnel of the proxy in the background unless the proxy already accepts connections.
+// Waits until the tunnel accepts connections, fails if the tunnel exits or is not ready within the start timeout.
+func EnsureTunnel(proxy types.Proxy, stateDir string) error {
+	if (proxy.SSH == nil && proxy.Cloudflared == nil) || Running(proxy.URL) {
+		return nil
+	}
+
+	u, err := url.Parse(proxy.URL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %v", proxy.URL, err)
+	}
+
+	executable, args, destination, startTimeout := tunnelCommand(proxy, u.Host)
+
+	// the process outlives kubeswitch, hence logs to a file instead of a pipe.
+	// It must not inherit stdout, as the shell integration reads stdout until it is closed.
+	if err := permissions.MkdirAll(filepath.Join(stateDir, tunnelDirectory)); err != nil {
+		return err
+	}
+	logFile := strings.TrimSuffix(tunnelFile(stateDir, proxy.URL), ".json") + ".log"
+	stderr, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, permissions.FileMode)
+	if err != nil {
+		return err
+	}
+	defer stderr.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stderr = stderr
+	logger.Debugf("Starting tunnel: %s %s", executable, strings.Join(args, " "))
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the tunnel to %q: %v", destination, err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	timeout := defaultStartTimeout
+	if startTimeout != nil {
+		timeout = *startTimeout
+	}
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !Running(proxy.URL) {
+		select {
+		case err := <-exited:
+			output, _ := os.ReadFile(logFile)
+			return fmt.Errorf("the tunnel to %q exited: %v: %s", destination, err, strings.TrimSpace(string(output)))
+		case <-deadline:
+			_ = cmd.Process.Kill()
+			return fmt.Errorf("the tunnel to %q did not accept connections on %s within %s", destination, u.Host, timeout)
+		case <-ticker.C:
+		}
+	}
+
+	tunnel := Tunnel{
+		URL:       proxy.URL,
+		Host:      destination,
+		PID:       cmd.Process.Pid,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := writeTunnel(stateDir, tunnel); err != nil {
+		logger.Warnf("failed to record the tunnel to %q, it has to be stopped manually (PID %d): %v", destination, tunnel.PID, err)
+	}
+	logger.Infof("Started tunnel to %q providing the proxy %s", destination, proxy.URL)
+	return nil
+}
+
+// tunnelCommand returns the executable and arguments starting the tunnel of the proxy listening on the given address,
+// the destination of the tunnel and its start timeout
+func tunnelCommand(proxy types.Proxy, listenAddress string) (string, []string, string, *time.Duration) {
+	if proxy.Cloudflared != nil {
+		executable := defaultCloudflared
+		if proxy.Cloudflared.Executable != nil && len(*proxy.Cloudflared.Executable) > 0 {
+			executable = *proxy.Cloudflared.Executable
+		}
+		args := []string{"access", "tcp", "--hostname", proxy.Cloudflared.Hostname, "--url", listenAddress}
+		return executable, args, proxy.Cloudflared.Hostname, proxy.Cloudflared.StartTimeout
+	}
+
+	args := []string{"-N", "-D", listenAddress, "-o", "ExitOnForwardFailure=yes", "-o", "ServerAliveInterval=30", "-o", "BatchMode=yes"}
+	args = append(args, proxy.SSH.Args...)
+	args = append(args, proxy.SSH.Host)
+	return "ssh", args, proxy.SSH.Host, proxy.SSH.StartTimeout
+}
+
+// ListTunnels returns the tunnels started by kubeswitch, sorted by URL
+func ListTunnels(stateDir string) ([]Tunnel, error) {
+	entries, err := os.ReadDir(filepath.Join(stateDir, tunnelDirectory))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tunnels []Tunnel
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(stateDir, tunnelDirectory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var tunnel Tunnel
+		if err := json.Unmarshal(data, &tunnel); err != nil {
+			return nil, fmt.Errorf("failed to read tunnel %q: %v", entry.Name(), err)
+		}
+		tunnels = append(tunnels, tunnel)
+	}
+	sort.Slice(tunnels, func(i, j int) bool { return tunnels[i].URL < tunnels[j].URL })
+	return tunnels, nil
+}
+
+// StopTunnel stops the tunnel started by kubeswitch and removes it from the state directory
+func StopTunnel(stateDir string, tunnel Tunnel) error {
+	if Running(tunnel.URL) {
+		process, err := os.FindProcess(tunnel.PID)
+		if err == nil {
+			err = process.Kill()
+		}
+		if err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("failed to stop the tunnel to %q (PID %d): %v", tunnel.Host, tunnel.PID, err)
+		}
+	}
+
+	file := tunnelFile(stateDir, tunnel.URL)
+	_ = os.Remove(strings.TrimSuffix(file, ".json") + ".log")
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeTunnel records the tunnel in the state directory
+func writeTunnel(stateDir string, tunnel Tunnel) error {
+	data, err := json.Marshal(tunnel)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tunnelFile(stateDir, tunnel.URL), data, permissions.FileMode)
+}
+
+// tunnelFile returns the file recording the tunnel providing the proxy URL
+func tunnelFile(stateDir, proxyURL string) string {
+	name := strings.NewReplacer(":", "_", "/", "_", "[", "", "]", "").Replace(strings.TrimPrefix(proxyURL, "socks5://"))
+	return filepath.Join(stateDir, tunnelDirectory, name+".json")
+}
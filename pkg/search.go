@@ -15,17 +15,24 @@
 package pkg
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
 	"github.com/danielfoehrkn/kubeswitch/pkg/index"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	aliasstate "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
-	aliasutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/hooks"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/stats"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tracing"
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -39,15 +46,63 @@ type DiscoveredContext struct {
 	// Tags contains the additional metadata that the store wants to associate with a context name.
 	// This metadata is later handed over in the getKubeconfigForPath() function when retrieving the kubeconfig bytes for the path
 	Tags map[string]string
+	// Server is the API server URL of the cluster of the context
+	Server string
+	// CAHash is the hash of the certificate authority of the cluster of the context
+	CAHash string
+	// CredentialsExpiry is when the credentials of the user of the context expire.
+	// Only known if the kubeconfig has been retrieved during the search, i.e. not for contexts read from the index.
+	CredentialsExpiry *time.Time
+	// DuplicateOf is the name of the context of another kubeconfig store configured before for the same cluster
+	DuplicateOf string
+	// Offline is true if the context was read from the index of a kubeconfig store that is not called in offline mode.
+	// Its kubeconfig is only available if cached and its credentials may be stale.
+	Offline bool
 	// Store is a reference to the backing store that contains the kubeconfig
 	Store *storetypes.KubeconfigStore
 	// Error is an error that occured during the search
 	Error error
 }
 
+// Merged returns true if the context should not be shown, as its cluster is merged into the context of another kubeconfig store configured before.
+// The context can still be switched to by name.
+func (c DiscoveredContext) Merged(config *types.Config) bool {
+	return len(c.DuplicateOf) > 0 && config != nil && config.DuplicateClusters != nil && *config.DuplicateClusters == types.DuplicateClustersMerge
+}
+
+const (
+	// defaultCircuitBreakerFailures is the default number of consecutive failed searches after which a kubeconfig store is skipped
+	defaultCircuitBreakerFailures = 3
+	// defaultCircuitBreakerCooldown is the default duration a kubeconfig store is skipped
+	defaultCircuitBreakerCooldown = 10 * time.Minute
+	// defaultSearchBatchSize is the default number of found contexts that are buffered
+	defaultSearchBatchSize = 500
+)
+
+var (
+	// errSearchTimeout is returned for a kubeconfig store whose search took longer than its search timeout
+	errSearchTimeout = errors.New("search timed out")
+	// ErrStoreDegraded is returned for a kubeconfig store that is skipped after repeatedly failed searches
+	ErrStoreDegraded = errors.New("store degraded")
+)
+
+// RevalidateIndex refreshes the search index of the kubeconfig store in the background.
+// Called for kubeconfig stores with "staleWhileRevalidate" when their outdated index is read instead of searching the store.
+// Set by the command line, as the index has to be refreshed by a process that outlives the current command.
+var RevalidateIndex func(store storetypes.KubeconfigStore) error
+
 // DoSearch executes a concurrent search over the given kubeconfig stores
 // returns results from all stores on the return channel
 func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*chan DiscoveredContext, error) {
+	return DoSearchWithProgress(stores, config, stateDir, noIndex, nil, nil)
+}
+
+// DoSearchWithProgress executes a concurrent search over the given kubeconfig stores like DoSearch.
+// The contexts of a kubeconfig store are returned once its search and the searches of the stores configured before are complete.
+// The optional storeDone function is called when the search of a kubeconfig store is complete.
+// The optional warn function is called for context names that are found in more than one kubeconfig.
+// Defaults to logging a warning.
+func DoSearchWithProgress(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, storeDone func(store storetypes.KubeconfigStore), warn func(format string, args ...interface{})) (*chan DiscoveredContext, error) {
 	// Silence STDOUT during search to not interfere with the search selection screen
 	// restore after search is over
 	originalSTDOUT := os.Stdout
@@ -68,20 +123,93 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 		contextToAliasMapping = alias.Content.ContextToAliasMapping
 	}
 
-	resultChannel := make(chan DiscoveredContext)
+	// aliases not defined with "switch alias" may be generated by rules
+	var aliasRules []types.AliasRule
+	if config != nil {
+		aliasRules = config.AliasRules
+	}
+
+	if warn == nil {
+		warn = logrus.Warnf
+	}
+	// context names found in more than one kubeconfig are disambiguated
+	resolver := newContextNameResolver(config, warn)
+	// clusters found in more than one kubeconfig store are detected to be merged or grouped
+	deduplicator := newClusterDeduplicator(config)
+
+	// the buffer bounds the contexts held in memory if the results are consumed slower than they are found
+	batchSize := searchBatchSize(config)
+	resultChannel := make(chan DiscoveredContext, batchSize)
+
+	// the names are resolved in the order of the kubeconfig stores, not in the order in which the stores answer
+	results := newOrderedResults(len(stores), func(discoveredContext DiscoveredContext) {
+		if owner := deduplicator.ownerOf(discoveredContext); owner != nil {
+			discoveredContext.DuplicateOf = resolver.nameOf(*owner)
+		}
+		resultChannel <- resolver.resolve(discoveredContext)
+	})
+
+	// send writes the discovered context of the kubeconfig store at the position with a unique name to the result channel
+	send := func(position int, discoveredContext DiscoveredContext) {
+		if KubernetesVersionRange != nil && !KubernetesVersionRange.Contains(discoveredContext.Tags) {
+			return
+		}
+		if ContextTagSelector != nil && !ContextTagSelector.Matches(discoveredContext.Tags) {
+			return
+		}
+		results.add(position, discoveredContext)
+	}
 	wgResultChannel := sync.WaitGroup{}
 	wgResultChannel.Add(len(stores))
 
-	for _, kubeconfigStore := range stores {
+	// the performance metrics of each kubeconfig store are recorded once the search is complete
+	var (
+		runs     []stats.Run
+		runsLock sync.Mutex
+	)
+	recordRun := func(store storetypes.KubeconfigStore, started time.Time, contexts, failed int, fromIndex bool) {
+		run := stats.Run{
+			Timestamp: started.UTC(),
+			StoreID:   store.GetID(),
+			StoreKind: store.GetKind(),
+			Duration:  time.Since(started),
+			Contexts:  contexts,
+			Errors:    failed,
+			FromIndex: fromIndex,
+		}
+		run.CacheHits, run.CacheMisses = cache.Stats(store.GetID())
+
+		runsLock.Lock()
+		defer runsLock.Unlock()
+		runs = append(runs, run)
+	}
+
+	// limits the number of kubeconfig stores searched at the same time
+	var searchSlots chan struct{}
+	if config != nil && config.SearchConcurrency != nil {
+		searchSlots = make(chan struct{}, *config.SearchConcurrency)
+	}
+
+	// searchDone marks the search of the store at the position as complete
+	searchDone := func(position int, store storetypes.KubeconfigStore) {
+		results.complete(position)
+		if storeDone != nil {
+			storeDone(store)
+		}
+		wgResultChannel.Done()
+	}
+
+	for position, kubeconfigStore := range stores {
 		logger := kubeconfigStore.GetLogger()
 
-		if err := kubeconfigStore.VerifyKubeconfigPaths(); err != nil {
-			// Required defines if errors when initializing this store should be logged
-			if kubeconfigStore.GetStoreConfig().Required != nil && !*kubeconfigStore.GetStoreConfig().Required {
-				continue
-			}
+		// in offline mode, the contexts of kubeconfig stores requiring the network are read from the index regardless of its age
+		storeOffline := offline.Enabled() && offline.RequiresNetwork(kubeconfigStore.GetKind())
 
-			return nil, err
+		// hooks scoped to the store, e.g. to authenticate prior to the search
+		if !storeOffline {
+			if err := hooks.StoreHooks(logger, config, stateDir, kubeconfigStore); err != nil {
+				return nil, err
+			}
 		}
 
 		searchIndex, err := index.New(logger, kubeconfigStore.GetKind(), stateDir, kubeconfigStore.GetID())
@@ -89,72 +217,165 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 			return nil, err
 		}
 
+		// the index contains the excluded contexts, so that changing the exclude patterns does not require refreshing the index
+		exclusions := storeExclusions(config, kubeconfigStore.GetStoreConfig())
+
 		// do not use index if explicitly disabled via command line flag --no-index
 		var readFromIndex bool
-		if noIndex {
+		if storeOffline {
+			readFromIndex = true
+		} else if noIndex {
 			readFromIndex = false
 		} else {
 			readFromIndex, err = shouldReadFromIndex(searchIndex, kubeconfigStore, config)
 			if err != nil {
 				return nil, err
 			}
+
+			if !readFromIndex {
+				readFromIndex = revalidateIndex(searchIndex, kubeconfigStore, config)
+			}
+		}
+
+		// skip kubeconfig stores whose last searches failed until their cooldown is over.
+		// The contexts of a previous successful search are still read from the index.
+		var degradedErr error
+		if degradedUntil := getDegradedUntil(searchIndex, kubeconfigStore, config); !noIndex && !readFromIndex && degradedUntil != nil {
+			degradedErr = fmt.Errorf("%w: skipping store %q until %s after repeatedly failed searches", ErrStoreDegraded, kubeconfigStore.GetID(), degradedUntil.Local().Format(time.Kitchen))
+			readFromIndex = true
 		}
 
 		if readFromIndex {
 			logrus.Debugf("Reading from index for store %s with kind %s", kubeconfigStore.GetID(), kubeconfigStore.GetKind())
 
-			go func(store storetypes.KubeconfigStore, index index.SearchIndex) {
+			go func(position int, store storetypes.KubeconfigStore, index index.SearchIndex, degradedErr error) {
 				// reading from this store is finished, decrease wait counter
-				defer wgResultChannel.Done()
+				defer searchDone(position, store)
+
+				started := time.Now()
+				endSpan := tracing.StartSearch(store.GetID(), started, attribute.String("switch.store.kind", string(store.GetKind())), attribute.Bool("switch.store.index", true))
+				contexts := 0
+				defer func() {
+					failed := 0
+					if degradedErr != nil {
+						failed = 1
+					}
+					recordRun(store, started, contexts, failed, true)
+					endSpan(degradedErr, attribute.Int("switch.store.contexts", contexts))
+				}()
+
+				// Required defines if errors when initializing this store should be logged
+				if degradedErr != nil && (store.GetStoreConfig().Required == nil || *store.GetStoreConfig().Required) {
+					resultChannel <- DiscoveredContext{
+						Store: &store,
+						Error: degradedErr,
+					}
+				}
+
+				if !index.HasContent() || !index.HasKind(store.GetKind()) {
+					if storeOffline {
+						store.GetLogger().Debugf("No index of store %s to read in offline mode", store.GetID())
+					}
+					return
+				}
 
 				// directly set from pre-computed index
 				content, tags := index.GetContent()
+				servers := index.GetServers()
+				caHashes := index.GetCAHashes()
 				for contextName, path := range content {
 					tagsForContextName := make(map[string]string)
 					if tagsForCtx, ok := tags[contextName]; ok {
 						tagsForContextName = tagsForCtx
 					}
 
-					resultChannel <- DiscoveredContext{
-						Path:  path,
-						Name:  contextName,
-						Tags:  tagsForContextName,
-						Alias: aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
-						Store: &store,
-						Error: nil,
+					alias := getContextAlias(store, path, contextName, tagsForContextName, contextToAliasMapping, aliasRules)
+					if exclusions.Excludes(path, contextName, alias) {
+						continue
 					}
+
+					contexts++
+					send(position, DiscoveredContext{
+						Path:    path,
+						Name:    contextName,
+						Tags:    tagsForContextName,
+						Server:  servers[contextName],
+						CAHash:  caHashes[contextName],
+						Alias:   alias,
+						Offline: storeOffline,
+						Store:   &store,
+						Error:   nil,
+					})
 				}
-			}(kubeconfigStore, *searchIndex)
+			}(position, kubeconfigStore, *searchIndex, degradedErr)
 
 			continue
 		}
 
+		// the kubeconfig store is only initialized and verified if it is searched
+		verifyStarted := time.Now()
+		span := tracing.Start("verify store", kubeconfigStore.GetID())
+		err = kubeconfigStore.VerifyKubeconfigPaths()
+		tracing.End(span, err)
+		if err != nil {
+			hooks.StoreFailureHooks(logger, config, kubeconfigStore, err)
+
+			// Required defines if errors when initializing this store should be logged
+			if kubeconfigStore.GetStoreConfig().Required != nil && !*kubeconfigStore.GetStoreConfig().Required {
+				recordRun(kubeconfigStore, verifyStarted, 0, 1, false)
+				searchDone(position, kubeconfigStore)
+				continue
+			}
+
+			return nil, err
+		}
+
 		// otherwise, we need to query the backing store for the kubeconfig files
-		c := make(chan storetypes.SearchResult)
-		go func(store storetypes.KubeconfigStore, channel chan storetypes.SearchResult) {
-			// only close when directory search is over, otherwise send on closed resultChannel
-			defer close(channel)
-			store.GetLogger().Debugf("Starting search for store: %s", store.GetKind())
-			store.StartSearch(channel)
-		}(kubeconfigStore, c)
-
-		go func(store storetypes.KubeconfigStore, storeSearchChannel chan storetypes.SearchResult, index index.SearchIndex) {
-			// remember the context to kubeconfig path mapping for this store
-			// to write it to the index. Do not use the global "ContextToPathMapping"
-			// as this contains contexts names from all stores combined
-			localContextToPathMapping := make(map[string]string)
-			// remember additional metadata tags that a store wants to associate with a discovered context name
-			// also written to the index file
-			localContextToTagsMapping := make(map[string]map[string]string)
+		// the duration of the search does not include waiting for other kubeconfig stores
+		var (
+			started   time.Time
+			endSpan   func(err error, attributes ...attribute.KeyValue)
+			storeKind = attribute.String("switch.store.kind", string(kubeconfigStore.GetKind()))
+		)
+		c := searchStore(kubeconfigStore, searchSlots, getSearchTimeout(config, kubeconfigStore), func() {
+			started = time.Now()
+			endSpan = tracing.StartSearch(kubeconfigStore.GetID(), started, storeKind, attribute.Bool("switch.store.index", false))
+		})
+
+		go func(position int, store storetypes.KubeconfigStore, storeSearchChannel <-chan storetypes.SearchResult, searchIndex index.SearchIndex) {
+			// stage the contexts of this store in batches to write the index once the search is complete.
+			// Do not keep the contexts in memory, as a store may contain tens of thousands of contexts.
+			indexWriter := searchIndex.NewWriter(batchSize)
+			// the index is not written if a batch could not be staged
+			var indexErr error
+			// only execute the failure hooks once per store
+			failureHooksExecuted := false
+			// the index is not written for an incomplete search
+			timedOut := false
+			searchErrors := 0
+			found := 0
+			// the first error is recorded in the span of the search
+			var searchErr error
 
 			for channelResult := range storeSearchChannel {
 				if channelResult.Error != nil {
+					timedOut = timedOut || errors.Is(channelResult.Error, errSearchTimeout)
+					searchErrors++
+					if searchErr == nil {
+						searchErr = channelResult.Error
+					}
+					if !failureHooksExecuted {
+						hooks.StoreFailureHooks(store.GetLogger(), config, store, channelResult.Error)
+						failureHooksExecuted = true
+					}
+
 					// Required defines if errors when initializing this store should be logged
 					if store.GetStoreConfig().Required != nil && !*store.GetStoreConfig().Required {
 						continue
 					}
 
 					resultChannel <- DiscoveredContext{
+						Store: &store,
 						Error: fmt.Errorf("store %q returned an error during the search: %v", store.GetID(), channelResult.Error),
 					}
 					continue
@@ -173,6 +394,7 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 				if err != nil {
 					store.GetLogger().Debugf("failed to get kubeconfig context names for kubeconfig with path %q: %v", channelResult.KubeconfigPath, err)
 					resultChannel <- DiscoveredContext{
+						Store: &store,
 						Error: fmt.Errorf("failed to get kubeconfig context names for kubeconfig with path %q: %v", channelResult.KubeconfigPath, err),
 					}
 					// do not throw Error, try to parse the other files
@@ -182,45 +404,212 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 				// save kubeconfig content to in-memory map to avoid duplicate read operation in getSanitizedKubeconfigForKubeconfigPath
 				writeToPathToKubeconfig(channelResult.KubeconfigPath, *kubeconfigString)
 
+				clusters, err := util.GetContextClusters(bytes, store.GetContextPrefix(channelResult.KubeconfigPath))
+				if err != nil {
+					store.GetLogger().Debugf("failed to get the clusters for kubeconfig with path %q: %v", channelResult.KubeconfigPath, err)
+				}
+
+				credentialsExpiry, err := util.GetContextCredentialsExpiry(bytes, store.GetContextPrefix(channelResult.KubeconfigPath))
+				if err != nil {
+					store.GetLogger().Debugf("failed to get the expiry of the credentials for kubeconfig with path %q: %v", channelResult.KubeconfigPath, err)
+				}
+
 				for _, contextName := range contexts {
-					// write to result channel
-					resultChannel <- DiscoveredContext{
-						Path:  channelResult.KubeconfigPath,
-						Name:  contextName,
-						Tags:  channelResult.Tags,
-						Alias: aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
-						Store: &store,
-						Error: nil,
+					alias := getContextAlias(store, channelResult.KubeconfigPath, contextName, channelResult.Tags, contextToAliasMapping, aliasRules)
+					cluster := clusters[contextName]
+					caHash := util.HashCertificateAuthority(cluster.CertificateAuthorityData)
+					if !exclusions.Excludes(channelResult.KubeconfigPath, contextName, alias) {
+						found++
+						discoveredContext := DiscoveredContext{
+							Path:   channelResult.KubeconfigPath,
+							Name:   contextName,
+							Tags:   channelResult.Tags,
+							Server: cluster.Server,
+							CAHash: caHash,
+							Alias:  alias,
+							Store:  &store,
+							Error:  nil,
+						}
+						if expiry, ok := credentialsExpiry[contextName]; ok {
+							discoveredContext.CredentialsExpiry = &expiry
+						}
+						// write to result channel
+						send(position, discoveredContext)
 					}
-					// add to local contextToPath map to write the index for this store only
-					localContextToPathMapping[contextName] = channelResult.KubeconfigPath
-					if len(channelResult.Tags) > 0 {
-						localContextToTagsMapping[contextName] = channelResult.Tags
+					// stage for the index of this store only
+					if indexErr == nil {
+						indexErr = indexWriter.Add(index.Entry{
+							Name:   contextName,
+							Path:   channelResult.KubeconfigPath,
+							Tags:   channelResult.Tags,
+							Server: cluster.Server,
+							CAHash: caHash,
+						})
 					}
 				}
 			}
 
 			// write store index file now that the path discovery is complete
-			if len(localContextToPathMapping) > 0 {
-				writeIndex(store, &index, localContextToPathMapping, localContextToTagsMapping)
+			if indexWriter.Count() > 0 && !timedOut && indexErr == nil {
+				writeIndex(store, &searchIndex, indexWriter)
+			} else {
+				if indexErr != nil {
+					store.GetLogger().Warnf("failed to write kubeconfig store index file: %v", indexErr)
+				}
+				if err := indexWriter.Discard(); err != nil {
+					store.GetLogger().Debugf("failed to discard the staged contexts of the index: %v", err)
+				}
 			}
 
+			recordSearch(&searchIndex, store, config, timedOut || (searchErrors > 0 && indexWriter.Count() == 0))
+			recordRun(store, started, found, searchErrors, false)
+			endSpan(searchErr, attribute.Int("switch.store.contexts", found), attribute.Int("switch.store.errors", searchErrors))
+
 			// reading from this store is finished, decrease wait counter
-			wgResultChannel.Done()
-		}(kubeconfigStore, c, *searchIndex)
+			searchDone(position, store)
+		}(position, kubeconfigStore, c, *searchIndex)
 	}
 
 	go func() {
 		defer close(resultChannel)
 		wgResultChannel.Wait()
+
+		if err := stats.Record(stateDir, runs); err != nil {
+			logrus.Debugf("failed to record the performance metrics of the search: %v", err)
+		}
 	}()
 
 	return &resultChannel, nil
 }
 
+// searchStore starts the search of the kubeconfig store and returns the channel with the search results.
+// If the number of concurrent searches is limited, the search waits for a free slot first.
+// After the optional timeout, the search returns errSearchTimeout and the channel is closed.
+// Stores cannot be cancelled, hence their remaining results are discarded in the background.
+// The started function is called once the search starts. It happens before the channel is closed.
+func searchStore(store storetypes.KubeconfigStore, slots chan struct{}, timeout *time.Duration, started func()) <-chan storetypes.SearchResult {
+	results := make(chan storetypes.SearchResult)
+	go func() {
+		defer close(results)
+
+		if slots != nil {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+		}
+		started()
+
+		// only close when the search of the store is over, otherwise the store sends on a closed channel
+		c := make(chan storetypes.SearchResult)
+		go func() {
+			defer close(c)
+			store.GetLogger().Debugf("Starting search for store: %s", store.GetKind())
+			store.StartSearch(c)
+		}()
+
+		var deadline <-chan time.Time
+		if timeout != nil {
+			timer := time.NewTimer(*timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		abort := func() {
+			store.GetLogger().Debugf("Aborting search for store %s after %s", store.GetID(), *timeout)
+			go func() {
+				for range c {
+				}
+			}()
+			results <- storetypes.SearchResult{Error: fmt.Errorf("%w after %s", errSearchTimeout, *timeout)}
+		}
+
+		for {
+			select {
+			case result, ok := <-c:
+				if !ok {
+					return
+				}
+				select {
+				case results <- result:
+				case <-deadline:
+					abort()
+					return
+				}
+			case <-deadline:
+				abort()
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// getDegradedUntil returns the end of the cooldown of a kubeconfig store that is skipped after repeatedly failed searches.
+// Returns nil if the kubeconfig store should be searched.
+func getDegradedUntil(searchIndex *index.SearchIndex, store storetypes.KubeconfigStore, config *types.Config) *time.Time {
+	if config == nil || config.CircuitBreaker == nil || !Indexed(store) {
+		return nil
+	}
+
+	degradedUntil, err := searchIndex.GetDegradedUntil()
+	if err != nil {
+		store.GetLogger().Debugf("failed to read the failed searches of the store: %v", err)
+		return nil
+	}
+	return degradedUntil
+}
+
+// recordSearch counts the consecutive failed searches of the kubeconfig store if the circuit breaker is enabled
+func recordSearch(searchIndex *index.SearchIndex, store storetypes.KubeconfigStore, config *types.Config, failed bool) {
+	if config == nil || config.CircuitBreaker == nil || !Indexed(store) {
+		return
+	}
+
+	failures := defaultCircuitBreakerFailures
+	if config.CircuitBreaker.Failures != nil {
+		failures = *config.CircuitBreaker.Failures
+	}
+	cooldown := defaultCircuitBreakerCooldown
+	if config.CircuitBreaker.Cooldown != nil {
+		cooldown = *config.CircuitBreaker.Cooldown
+	}
+
+	degraded, err := searchIndex.RecordSearch(failed, failures, cooldown)
+	if err != nil {
+		store.GetLogger().Debugf("failed to record the search of the store: %v", err)
+		return
+	}
+	if degraded {
+		store.GetLogger().Debugf("Skipping store %s for %s after %d failed searches", store.GetID(), cooldown, failures)
+	}
+}
+
+// searchBatchSize returns the maximum number of found contexts that are buffered
+func searchBatchSize(config *types.Config) int {
+	if config != nil && config.SearchBatchSize != nil {
+		return *config.SearchBatchSize
+	}
+	return defaultSearchBatchSize
+}
+
+// getSearchTimeout returns the search timeout of the kubeconfig store or the global default
+func getSearchTimeout(config *types.Config, store storetypes.KubeconfigStore) *time.Duration {
+	if timeout := store.GetStoreConfig().SearchTimeout; timeout != nil {
+		return timeout
+	}
+	if config != nil {
+		return config.SearchTimeout
+	}
+	return nil
+}
+
+// Indexed returns false for the kubeconfig store from the environment and the --kubeconfig-path flag, which never uses an index
+func Indexed(store storetypes.KubeconfigStore) bool {
+	return store.GetID() != fmt.Sprintf("%s.%s", types.StoreKindFilesystem, "env-and-flag")
+}
+
 func shouldReadFromIndex(searchIndex *index.SearchIndex, kubeconfigStore storetypes.KubeconfigStore, config *types.Config) (bool, error) {
 	// never write an index for the store from env variables and --kubeconfig-path command line falg
-	if kubeconfigStore.GetID() == fmt.Sprintf("%s.%s", types.StoreKindFilesystem, "env-and-flag") {
+	if !Indexed(kubeconfigStore) {
 		return false, nil
 	}
 
@@ -235,3 +624,48 @@ func shouldReadFromIndex(searchIndex *index.SearchIndex, kubeconfigStore storety
 	}
 	return false, nil
 }
+
+// revalidateIndex starts to refresh the outdated index of a kubeconfig store with "staleWhileRevalidate" in the background.
+// Returns true if the outdated index should be read instead of searching the kubeconfig store.
+func revalidateIndex(searchIndex *index.SearchIndex, kubeconfigStore storetypes.KubeconfigStore, config *types.Config) bool {
+	if !Indexed(kubeconfigStore) {
+		return false
+	}
+
+	var staleWhileRevalidate *bool
+	if config != nil {
+		staleWhileRevalidate = config.StaleWhileRevalidate
+	}
+	if kubeconfigStore.GetStoreConfig().StaleWhileRevalidate != nil {
+		staleWhileRevalidate = kubeconfigStore.GetStoreConfig().StaleWhileRevalidate
+	}
+	if staleWhileRevalidate == nil || !*staleWhileRevalidate || RevalidateIndex == nil {
+		return false
+	}
+
+	// an index without state has never been completely written
+	if !searchIndex.HasContent() || !searchIndex.HasKind(kubeconfigStore.GetKind()) {
+		return false
+	}
+	if lastUpdate, err := searchIndex.GetLastUpdateTime(); err != nil || lastUpdate == nil {
+		return false
+	}
+
+	logger := kubeconfigStore.GetLogger()
+	start, err := searchIndex.StartRevalidation()
+	if err != nil {
+		logger.Debugf("failed to mark the index as refreshed in the background: %v", err)
+		return false
+	}
+	if !start {
+		logger.Debugf("Reading outdated index for store %s. The index is already refreshed in the background", kubeconfigStore.GetID())
+		return true
+	}
+
+	if err := RevalidateIndex(kubeconfigStore); err != nil {
+		logger.Debugf("failed to refresh the index in the background: %v", err)
+		return false
+	}
+	logger.Debugf("Reading outdated index for store %s. Refreshing the index in the background", kubeconfigStore.GetID())
+	return true
+}
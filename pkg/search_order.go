@@ -0,0 +1,74 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// orderedResults releases the contexts found by the concurrently searched kubeconfig stores in a fixed order:
+// the kubeconfig stores in the order of the configuration and the contexts of a store sorted by path and name.
+// Context names and duplicate clusters are resolved in this order, so that the same context keeps its name
+// regardless of which kubeconfig store answers first.
+// The contexts of a kubeconfig store are held back until its search and the searches of all stores before it are complete.
+type orderedResults struct {
+	lock sync.Mutex
+	// release is called for each context in order
+	release func(discoveredContext DiscoveredContext)
+	// pending holds the contexts of each kubeconfig store that are not released yet
+	pending [][]DiscoveredContext
+	// done marks the kubeconfig stores whose search is complete
+	done []bool
+	// next is the position of the first kubeconfig store whose contexts are not released yet
+	next int
+}
+
+func newOrderedResults(stores int, release func(discoveredContext DiscoveredContext)) *orderedResults {
+	return &orderedResults{
+		release: release,
+		pending: make([][]DiscoveredContext, stores),
+		done:    make([]bool, stores),
+	}
+}
+
+// add holds back the context of the kubeconfig store at the position until it can be released
+func (o *orderedResults) add(position int, discoveredContext DiscoveredContext) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.pending[position] = append(o.pending[position], discoveredContext)
+}
+
+// complete marks the search of the kubeconfig store at the position as complete
+// and releases the contexts of all kubeconfig stores whose preceding stores are complete as well
+func (o *orderedResults) complete(position int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.done[position] = true
+	for ; o.next < len(o.done) && o.done[o.next]; o.next++ {
+		contexts := o.pending[o.next]
+		o.pending[o.next] = nil
+
+		slices.SortStableFunc(contexts, func(a, b DiscoveredContext) int {
+			return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Name, b.Name))
+		})
+		for _, discoveredContext := range contexts {
+			o.release(discoveredContext)
+		}
+	}
+}
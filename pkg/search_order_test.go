@@ -0,0 +1,71 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("orderedResults", func() {
+	var (
+		first, second storetypes.KubeconfigStore
+		resolver      *contextNameResolver
+		released      []string
+		results       *orderedResults
+	)
+
+	newStore := func(id string) storetypes.KubeconfigStore {
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{Kind: types.StoreKindMock, ID: &id})
+		Expect(err).ToNot(HaveOccurred())
+		return mockStore
+	}
+
+	contextOf := func(store *storetypes.KubeconfigStore, path, name string) DiscoveredContext {
+		return DiscoveredContext{Store: store, Path: path, Name: name}
+	}
+
+	BeforeEach(func() {
+		first, second = newStore("first"), newStore("second")
+		resolver = newContextNameResolver(nil, func(string, ...interface{}) {})
+		released = nil
+		results = newOrderedResults(2, func(discoveredContext DiscoveredContext) {
+			resolved := resolver.resolve(discoveredContext)
+			released = append(released, resolver.nameOf(resolved)+" "+resolved.Path)
+		})
+	})
+
+	It("holds back the contexts of a store until the stores configured before are complete", func() {
+		results.add(1, contextOf(&second, "b", "dev"))
+		results.complete(1)
+		Expect(released).To(BeEmpty())
+
+		results.add(0, contextOf(&first, "a", "dev"))
+		results.complete(0)
+		Expect(released).To(Equal([]string{"dev a", "dev@second b"}))
+	})
+
+	It("resolves the contexts of a store sorted by path", func() {
+		results.add(0, contextOf(&first, "z", "dev"))
+		results.add(0, contextOf(&first, "a", "dev"))
+		results.complete(0)
+		results.complete(1)
+		Expect(released).To(Equal([]string{"dev a", "dev@first z"}))
+	})
+})
@@ -0,0 +1,43 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"sort"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+)
+
+// SelectContexts shows the context names in the fuzzy finder and returns the sorted names selected with Tab,
+// or the highlighted name if none is selected
+func SelectContexts(names []string, header string) ([]string, error) {
+	indices, err := fuzzyfinder.FindMulti(
+		names,
+		func(i int) string {
+			return names[i]
+		},
+		fuzzyfinder.WithHeader(header),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, i := range indices {
+		selected = append(selected, names[i])
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
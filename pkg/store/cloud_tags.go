@@ -0,0 +1,35 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"strings"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// addCloudTags adds the cloud tags (labels) of a cluster selected by the cloudTags of the store configuration
+// to the tags of its search result. The keys are matched case-insensitively and prefixed with storetypes.CloudTagPrefix.
+func addCloudTags(storeConfig types.KubeconfigStore, tags map[string]string, clusterTags map[string]string) {
+	for _, key := range storeConfig.CloudTags {
+		for clusterKey, value := range clusterTags {
+			if strings.EqualFold(key, clusterKey) && len(value) > 0 {
+				tags[storetypes.CloudTagPrefix+key] = value
+				break
+			}
+		}
+	}
+}
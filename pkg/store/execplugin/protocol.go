@@ -0,0 +1,76 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package execplugin defines the protocol between kubeswitch and exec store plugins.
+//
+// An exec store plugin is an executable that is called once per operation.
+// kubeswitch writes a Request as JSON to the standard input of the executable
+// and expects the response for the operation as JSON on the standard output.
+// A non-zero exit code marks a failed operation; the standard error is used as error message.
+package execplugin
+
+// APIVersion is the version of the exec store protocol
+const APIVersion = "kubeswitch.io/v1alpha1"
+
+// Operation is an operation of the exec store protocol
+type Operation string
+
+const (
+	// OperationList lists the kubeconfigs of the store. The response is a ListResponse.
+	OperationList Operation = "list"
+	// OperationGetKubeconfig returns the kubeconfig for a path returned by the list operation.
+	// The response is a GetKubeconfigResponse.
+	OperationGetKubeconfig Operation = "get-kubeconfig"
+)
+
+// Request is written to the standard input of the exec store plugin
+type Request struct {
+	// APIVersion is the version of the exec store protocol
+	APIVersion string `json:"apiVersion"`
+	// Operation is the requested operation
+	Operation Operation `json:"operation"`
+	// StoreID is the ID of the kubeconfig store, e.g. "exec.netbox"
+	StoreID string `json:"storeID"`
+	// Options are the options of the kubeconfig store in the SwitchConfig
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Path is the path of the kubeconfig. Only set for the operation "get-kubeconfig"
+	Path string `json:"path,omitempty"`
+	// Tags are the tags of the kubeconfig returned by the list operation. Only set for the operation "get-kubeconfig"
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ListResponse is the response of the list operation
+type ListResponse struct {
+	// APIVersion is the version of the exec store protocol
+	APIVersion string `json:"apiVersion"`
+	// Kubeconfigs are the kubeconfigs of the store
+	Kubeconfigs []Kubeconfig `json:"kubeconfigs"`
+}
+
+// Kubeconfig is a kubeconfig of the store
+type Kubeconfig struct {
+	// Path uniquely identifies the kubeconfig in the store
+	Path string `json:"path"`
+	// Tags are additional metadata passed back to the plugin with the get-kubeconfig operation
+	// + optional
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// GetKubeconfigResponse is the response of the get-kubeconfig operation
+type GetKubeconfigResponse struct {
+	// APIVersion is the version of the exec store protocol
+	APIVersion string `json:"apiVersion"`
+	// Kubeconfig is the kubeconfig in YAML or JSON
+	Kubeconfig string `json:"kubeconfig"`
+}
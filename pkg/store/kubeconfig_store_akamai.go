@@ -19,6 +19,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"time"
@@ -30,6 +31,9 @@ import (
 	"github.com/sirupsen/logrus"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -48,7 +52,7 @@ func NewAkamaiStore(store types.KubeconfigStore) (*AkamaiStore, error) {
 	}
 
 	return &AkamaiStore{
-		Logger:          logrus.New().WithField("store", types.StoreKindAkamai),
+		Logger:          logging.New().WithField("store", types.StoreKindAkamai),
 		KubeconfigStore: store,
 		Config:          akamaiStoreConfig,
 	}, nil
@@ -68,13 +72,21 @@ func (s *AkamaiStore) InitializeAkamaiStore() error {
 
 	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 
+	transport, err := httptransport.ForStore(s.KubeconfigStore)
+	if err != nil {
+		return err
+	}
+
 	oauth2Client := &http.Client{
 		Transport: &oauth2.Transport{
 			Source: tokenSource,
+			Base:   ratelimit.NewTransport(transport, s.KubeconfigStore.RateLimit),
 		},
 	}
 
 	linodeClient := linodego.NewClient(oauth2Client)
+	// throttled requests are retried by the rate limited transport instead of the client
+	linodeClient.SetRetryCount(0)
 
 	s.Client = &linodeClient
 
@@ -135,8 +147,9 @@ func (s *AkamaiStore) StartSearch(channel chan storetypes.SearchResult) {
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: instance.Label,
 			Tags: map[string]string{
-				"clusterID": strconv.Itoa(instance.ID),
-				"region":    instance.Region,
+				"clusterID":                     strconv.Itoa(instance.ID),
+				"region":                        instance.Region,
+				storetypes.TagKubernetesVersion: instance.K8sVersion,
 			},
 		}
 	}
@@ -172,3 +185,12 @@ func (s *AkamaiStore) GetKubeconfigForPath(path string, tags map[string]string)
 
 	return kubeconfig, nil
 }
+
+// GetConsoleURL returns the URL of the LKE cluster in the Akamai Cloud Manager
+func (s *AkamaiStore) GetConsoleURL(_ string, tags map[string]string) (string, error) {
+	clusterID, ok := tags["clusterID"]
+	if !ok {
+		return "", fmt.Errorf("the ID of the LKE cluster is unknown")
+	}
+	return fmt.Sprintf("https://cloud.linode.com/kubernetes/clusters/%s/summary", url.PathEscape(clusterID)), nil
+}
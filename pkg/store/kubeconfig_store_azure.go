@@ -17,10 +17,12 @@ package store
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/disiqueira/gotree"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -30,6 +32,9 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -53,7 +58,7 @@ func NewAzureStore(store types.KubeconfigStore, stateDir string) (*AzureStore, e
 	}
 
 	return &AzureStore{
-		Logger:             logrus.New().WithField("store", types.StoreKindAzure),
+		Logger:             logging.New().WithField("store", types.StoreKindAzure),
 		KubeconfigStore:    store,
 		Config:             storeConfig,
 		StateDirectory:     stateDir,
@@ -82,7 +87,16 @@ func (s *AzureStore) InitializeAzureStore() error {
 		endpoint = *s.Config.Endpoint
 	}
 
-	con := arm.NewConnection(endpoint, cred, nil)
+	transport, err := httptransport.ForStore(s.KubeconfigStore)
+	if err != nil {
+		return err
+	}
+
+	con := arm.NewConnection(endpoint, cred, &arm.ConnectionOptions{
+		HTTPClient: ratelimit.NewClient(transport, s.KubeconfigStore.RateLimit),
+		// throttled requests are retried by the rate limited client instead of the SDK
+		Retry: policy.RetryOptions{MaxRetries: -1},
+	})
 	s.AksClient = armcontainerservice.NewManagedClustersClient(con, *s.Config.SubscriptionID)
 
 	s.Logger.Debugf("Authenticated to subscription %s", *s.Config.SubscriptionID)
@@ -191,8 +205,28 @@ func (s *AzureStore) returnSearchResultsForClusters(channel chan storetypes.Sear
 		kubeconfigPath := getAzureKubeconfigPath(*resourceGroup, *cluster.Name)
 		s.insertIntoClusterCache(kubeconfigPath, cluster)
 
+		tags := map[string]string{
+			"account": *s.Config.SubscriptionID,
+			"cluster": *cluster.Name,
+		}
+		if cluster.Location != nil {
+			tags["region"] = *cluster.Location
+		}
+		if cluster.Properties != nil && cluster.Properties.KubernetesVersion != nil {
+			tags[storetypes.TagKubernetesVersion] = *cluster.Properties.KubernetesVersion
+		}
+
+		clusterTags := make(map[string]string, len(cluster.Tags))
+		for key, value := range cluster.Tags {
+			if value != nil {
+				clusterTags[key] = *value
+			}
+		}
+		addCloudTags(s.GetStoreConfig(), tags, clusterTags)
+
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: kubeconfigPath,
+			Tags:           tags,
 			Error:          nil,
 		}
 	}
@@ -376,3 +410,59 @@ func (s *AzureStore) insertIntoClusterCache(key string, value *armcontainerservi
 	defer s.DiscoveredClustersMutex.Unlock()
 	s.DiscoveredClusters[key] = value
 }
+
+// Login obtains an access token for the Azure management API and returns its expiry.
+// If no token can be obtained, runs `az login`.
+func (s *AzureStore) Login() (*time.Time, error) {
+	expiry, err := s.CheckCredentials()
+	if err == nil {
+		return expiry, nil
+	}
+
+	s.Logger.Infof("Failed to obtain Azure credentials. Running `az login`: %v", err)
+	if err := runLoginCommand("az", "login"); err != nil {
+		return nil, err
+	}
+	return s.CheckCredentials()
+}
+
+// CheckCredentials obtains an access token for the Azure management API and returns its expiry
+func (s *AzureStore) CheckCredentials() (*time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("obtaining Azure credentials failed: %v", err)
+	}
+
+	endpoint := "https://management.azure.com/"
+	if s.Config.Endpoint != nil {
+		endpoint = *s.Config.Endpoint
+	}
+
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{strings.TrimSuffix(endpoint, "/") + "/.default"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("obtaining Azure access token failed: %v", err)
+	}
+	return &token.ExpiresOn, nil
+}
+
+// GetConsoleURL returns the URL of the AKS cluster in the Azure portal
+func (s *AzureStore) GetConsoleURL(path string, tags map[string]string) (string, error) {
+	resourceGroup, clusterName, err := parseAzureIdentifier(path)
+	if err != nil {
+		return "", err
+	}
+
+	subscriptionID := tags["account"]
+	if len(subscriptionID) == 0 && s.Config.SubscriptionID != nil {
+		subscriptionID = *s.Config.SubscriptionID
+	}
+	if len(subscriptionID) == 0 {
+		return "", fmt.Errorf("the subscription of the AKS cluster %q is unknown", clusterName)
+	}
+	return fmt.Sprintf("https://portal.azure.com/#resource/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s/overview", subscriptionID, url.PathEscape(resourceGroup), url.PathEscape(clusterName)), nil
+}
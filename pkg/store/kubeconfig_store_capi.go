@@ -20,6 +20,7 @@ import (
 	"time"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -49,7 +50,7 @@ func NewCapiStore(store types.KubeconfigStore, stateDir string) (*CapiStore, err
 
 	return &CapiStore{
 		KubeconfigStore: store,
-		Logger:          logrus.New().WithField("store", types.StoreKindCapi),
+		Logger:          logging.New().WithField("store", types.StoreKindCapi),
 		Config:          storeConfig,
 	}, nil
 }
@@ -157,13 +158,19 @@ func (s *CapiStore) StartSearch(channel chan storetypes.SearchResult) {
 	for _, cluster := range clusters.Items {
 		s.Logger.Debug("CAPI: found cluster", "name", cluster.Name, "namespace", cluster.Namespace)
 
+		tags := map[string]string{
+			"namespace": cluster.Namespace,
+			"name":      cluster.Name,
+		}
+		// the version is only known for clusters managed by a ClusterClass
+		if cluster.Spec.Topology != nil {
+			tags[storetypes.TagKubernetesVersion] = cluster.Spec.Topology.Version
+		}
+
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: fmt.Sprintf("%s-%s", cluster.Namespace, cluster.Name),
 			Error:          nil,
-			Tags: map[string]string{
-				"namespace": cluster.Namespace,
-				"name":      cluster.Name,
-			},
+			Tags:           tags,
 		}
 	}
 }
@@ -17,12 +17,18 @@ package store
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/store/doks"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/limiter"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/disiqueira/gotree"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -41,7 +47,7 @@ const (
 	// tagDOKSClusterID is the tag that contains the region of the DOKS cluster
 	tagRegion = "region"
 	// tagVersion is the tag that contains the K8s version of the DOKS cluster
-	tagVersion = "version"
+	tagVersion = storetypes.TagKubernetesVersion
 	// tagNodePools is the tag that contains the node pools of the DOKS cluster
 	tagNodePools = "pools"
 	// tagDOKSClusterID is the tag that contains the non-identifying DOKS cluster name
@@ -61,7 +67,7 @@ func NewDigitalOceanStore(store types.KubeconfigStore) (*DigitalOceanStore, erro
 	}
 
 	return &DigitalOceanStore{
-		Logger:          logrus.New().WithField("store", types.StoreKindDigitalOcean),
+		Logger:          logging.New().WithField("store", types.StoreKindDigitalOcean),
 		KubeconfigStore: store,
 		Config:          *doctlConfig,
 	}, nil
@@ -70,8 +76,14 @@ func NewDigitalOceanStore(store types.KubeconfigStore) (*DigitalOceanStore, erro
 // InitializeDigitalOceanStore initializes the DigitalOcean store with digital ocean clients
 func (d *DigitalOceanStore) InitializeDigitalOceanStore() error {
 	contextToKubernetesService := make(map[string]do.KubernetesService)
+	transport, err := httptransport.ForStore(d.KubeconfigStore)
+	if err != nil {
+		return err
+	}
+	// the clients of all contexts share the rate limit of the store
+	httpClient := ratelimit.NewClient(transport, d.KubeconfigStore.RateLimit)
 	accessToken := d.Config.DefaultAuthContextAccessToken
-	defaultContextClient, err := d.getDoClient(accessToken)
+	defaultContextClient, err := d.getDoClient(httpClient, accessToken)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to intialize the client for the default digital ocean account/context (context: %s)", d.Config.DefaultContextName))
 	}
@@ -81,7 +93,7 @@ func (d *DigitalOceanStore) InitializeDigitalOceanStore() error {
 
 	// if there are multiple contexts configured
 	for doctlContextName, token := range d.Config.AuthContexts {
-		doClient, err := d.getDoClient(token)
+		doClient, err := d.getDoClient(httpClient, token)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("failed to intialize digital ocean client (context: %s)", d.Config.DefaultContextName))
 		}
@@ -93,11 +105,11 @@ func (d *DigitalOceanStore) InitializeDigitalOceanStore() error {
 	return nil
 }
 
-// getDoClient creates the digital ocean client for a given access token
+// getDoClient creates the digital ocean client for a given access token sending the requests with the given HTTP client
 // inspired by: https://github.com/digitalocean/doctl/blob/7f1c9db38d19cd1104dc96537c00c6436768955a/doit.go#L235
-func (d *DigitalOceanStore) getDoClient(accessToken string) (*godo.Client, error) {
+func (d *DigitalOceanStore) getDoClient(httpClient *http.Client, accessToken string) (*godo.Client, error) {
 	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
-	oauthClient := oauth2.NewClient(context.Background(), tokenSource)
+	oauthClient := oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, httpClient), tokenSource)
 
 	args := []godo.ClientOpt{
 		godo.SetUserAgent("kubeswitch-client"),
@@ -145,7 +157,10 @@ func (d *DigitalOceanStore) StartSearch(channel chan storetypes.SearchResult) {
 			defer wgResultChannel.Done()
 
 			d.Logger.Debugf("Digital Ocean: Start listing clusters for context %q", doctlCtxName)
+			l := limiter.ForStore(d.GetID(), d.KubeconfigStore.MaxConcurrency)
+			l.Acquire()
 			clusters, err := svc.List()
+			l.Release()
 			if err != nil {
 				channel <- storetypes.SearchResult{
 					Error: fmt.Errorf("error listing DOKS clusters for context %s: %w", doctlCtxName, err),
@@ -325,3 +340,12 @@ func (d *DigitalOceanStore) GetSearchPreview(_ string, tags map[string]string) (
 
 	return asciTree.Print(), nil
 }
+
+// GetConsoleURL returns the URL of the DOKS cluster in the DigitalOcean control panel
+func (d *DigitalOceanStore) GetConsoleURL(_ string, tags map[string]string) (string, error) {
+	clusterID, ok := tags[tagDOKSClusterID]
+	if !ok {
+		return "", fmt.Errorf("the ID of the DOKS cluster is unknown")
+	}
+	return fmt.Sprintf("https://cloud.digitalocean.com/kubernetes/clusters/%s", url.PathEscape(clusterID)), nil
+}
@@ -17,6 +17,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -26,6 +27,9 @@ import (
 	awsekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/aws/smithy-go/logging"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	switchlogging "github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/disiqueira/gotree"
 	"github.com/sirupsen/logrus"
@@ -90,8 +94,16 @@ func (s *EKSStore) InitializeEKSStore() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	transport, err := httptransport.ForStore(s.KubeconfigStore)
+	if err != nil {
+		return err
+	}
+
 	optFns := []func(*awsconfig.LoadOptions) error{
 		awsconfig.WithLogger(AWSLogrusBridgeLogger{Logger: s.GetLogger()}),
+		// throttled requests are retried by the rate limited client instead of the SDK
+		awsconfig.WithHTTPClient(ratelimit.NewClient(transport, s.KubeconfigStore.RateLimit)),
+		awsconfig.WithRetryMaxAttempts(1),
 	}
 
 	optFns = append(optFns, awsconfig.WithRegion(*s.Config.Region))
@@ -131,7 +143,7 @@ func (s *EKSStore) GetStoreConfig() types.KubeconfigStore {
 
 func (s *EKSStore) GetLogger() *logrus.Entry {
 	if s.Logger == nil {
-		s.Logger = logrus.WithField("store", s.GetID())
+		s.Logger = switchlogging.New().WithField("store", s.GetID())
 	}
 	return s.Logger
 }
@@ -178,8 +190,30 @@ func (s *EKSStore) StartSearch(channel chan storetypes.SearchResult) {
 			// eks_<profile>--<region>--<eks-cluster-name>
 			kubeconfigPath := fmt.Sprintf("eks_%s--%s--%s", s.Config.Profile, *s.Config.Region, clusterName)
 
+			tags := map[string]string{
+				"account": s.Config.Profile,
+				"region":  *s.Config.Region,
+				"cluster": clusterName,
+			}
+
+			// the tags and the version of the cluster are not returned when listing the clusters
+			if len(s.GetStoreConfig().CloudTags) > 0 {
+				resp, err := s.Client.DescribeCluster(ctx, &awseks.DescribeClusterInput{Name: &clusterName})
+				if err != nil {
+					s.GetLogger().Warnf("failed to describe EKS cluster %q to read its tags: %v", clusterName, err)
+				} else {
+					// cache for when getting the kubeconfig for the unique path later
+					s.DiscoveredClusters[kubeconfigPath] = resp.Cluster
+					addCloudTags(s.GetStoreConfig(), tags, resp.Cluster.Tags)
+					if resp.Cluster.Version != nil {
+						tags[storetypes.TagKubernetesVersion] = *resp.Cluster.Version
+					}
+				}
+			}
+
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: kubeconfigPath,
+				Tags:           tags,
 				Error:          nil,
 			}
 		}
@@ -350,3 +384,52 @@ func (s AWSLogrusBridgeLogger) Logf(classification logging.Classification, forma
 	}
 	s.Logger.Logf(level, format, v...)
 }
+
+// Login retrieves the AWS credentials of the configured profile and returns their expiry.
+// If the credentials cannot be retrieved (e.g. the SSO session expired), runs `aws sso login` for the profile.
+func (s *EKSStore) Login() (*time.Time, error) {
+	expiry, err := s.CheckCredentials()
+	if err == nil {
+		return expiry, nil
+	}
+
+	s.GetLogger().Infof("Failed to retrieve AWS credentials for profile %q. Running `aws sso login`: %v", s.Config.Profile, err)
+	if err := runLoginCommand("aws", "sso", "login", "--profile", s.Config.Profile); err != nil {
+		return nil, err
+	}
+	return s.CheckCredentials()
+}
+
+// CheckCredentials retrieves the AWS credentials of the configured profile and returns their expiry
+func (s *EKSStore) CheckCredentials() (*time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithLogger(AWSLogrusBridgeLogger{Logger: s.GetLogger()}),
+		awsconfig.WithRegion(*s.Config.Region),
+		awsconfig.WithSharedConfigProfile(s.Config.Profile),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials for profile %q: %v", s.Config.Profile, err)
+	}
+
+	if !credentials.CanExpire {
+		return nil, nil
+	}
+	return &credentials.Expires, nil
+}
+
+// GetConsoleURL returns the URL of the EKS cluster in the AWS console
+func (s *EKSStore) GetConsoleURL(path string, _ map[string]string) (string, error) {
+	_, region, clusterName, err := parseEksIdentifier(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/eks/home?region=%s#/clusters/%s", region, region, url.PathEscape(clusterName)), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store/execplugin"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultExecTimeout is the default maximum duration of a single call of the exec store plugin
+const defaultExecTimeout = 30 * time.Second
+
+func NewExecStore(store types.KubeconfigStore) (*ExecStore, error) {
+	execStoreConfig := &types.StoreConfigExec{}
+	if store.Config != nil {
+		buf, err := yaml.Marshal(store.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process exec store config: %w", err)
+		}
+
+		err = yaml.Unmarshal(buf, execStoreConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal exec store config: %w", err)
+		}
+	}
+
+	if len(execStoreConfig.Command) == 0 {
+		return nil, fmt.Errorf("the command of the exec store has to be configured")
+	}
+
+	return &ExecStore{
+		Logger:          logging.New().WithField("store", types.StoreKindExec),
+		KubeconfigStore: store,
+		Config:          execStoreConfig,
+	}, nil
+}
+
+// GetID returns the unique store ID
+func (s *ExecStore) GetID() string {
+	id := "default"
+
+	if s.KubeconfigStore.ID != nil {
+		id = *s.KubeconfigStore.ID
+	}
+
+	return fmt.Sprintf("%s.%s", types.StoreKindExec, id)
+}
+
+func (s *ExecStore) GetKind() types.StoreKind {
+	return types.StoreKindExec
+}
+
+func (s *ExecStore) GetContextPrefix(path string) string {
+	if s.GetStoreConfig().ShowPrefix != nil && !*s.GetStoreConfig().ShowPrefix {
+		return ""
+	}
+
+	return path
+}
+
+func (s *ExecStore) VerifyKubeconfigPaths() error {
+	if _, err := exec.LookPath(s.Config.Command); err != nil {
+		return fmt.Errorf("command %q of the exec store cannot be found: %v", s.Config.Command, err)
+	}
+	return nil
+}
+
+func (s *ExecStore) GetStoreConfig() types.KubeconfigStore {
+	return s.KubeconfigStore
+}
+
+func (s *ExecStore) GetLogger() *logrus.Entry {
+	return s.Logger
+}
+
+func (s *ExecStore) StartSearch(channel chan storetypes.SearchResult) {
+	s.Logger.Debug("Exec: start search")
+
+	response := &execplugin.ListResponse{}
+	if err := s.call(execplugin.Request{Operation: execplugin.OperationList}, response); err != nil {
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: "",
+			Error:          err,
+		}
+		return
+	}
+
+	for _, kubeconfig := range response.Kubeconfigs {
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: kubeconfig.Path,
+			Tags:           kubeconfig.Tags,
+		}
+	}
+}
+
+func (s *ExecStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	s.Logger.Debugf("Exec: get kubeconfig for path %s", path)
+
+	response := &execplugin.GetKubeconfigResponse{}
+	if err := s.call(execplugin.Request{
+		Operation: execplugin.OperationGetKubeconfig,
+		Path:      path,
+		Tags:      tags,
+	}, response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Kubeconfig) == 0 {
+		return nil, fmt.Errorf("exec store returned an empty kubeconfig for path %q", path)
+	}
+	return []byte(response.Kubeconfig), nil
+}
+
+// call executes the command of the exec store with the request on stdin and decodes the response from stdout
+func (s *ExecStore) call(request execplugin.Request, response interface{}) error {
+	request.APIVersion = execplugin.APIVersion
+	request.StoreID = s.GetID()
+	request.Options = s.Config.Options
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exec store request: %w", err)
+	}
+
+	timeout := defaultExecTimeout
+	if s.Config.Timeout != nil {
+		timeout = *s.Config.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Config.Command, s.Config.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+	for key, value := range s.Config.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("exec store operation %q timed out after %s", request.Operation, timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+			return fmt.Errorf("exec store operation %q failed: %s", request.Operation, message)
+		}
+		return fmt.Errorf("exec store operation %q failed: %v", request.Operation, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), response); err != nil {
+		return fmt.Errorf("failed to decode response of exec store operation %q: %v", request.Operation, err)
+	}
+	return nil
+}
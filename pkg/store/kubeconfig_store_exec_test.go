@@ -0,0 +1,165 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	"github.com/danielfoehrkn/kubeswitch/pkg/store/execplugin"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// search returns the results of the search of the kubeconfig store
+func search(kubeconfigStore storetypes.KubeconfigStore) []storetypes.SearchResult {
+	channel := make(chan storetypes.SearchResult)
+	go func() {
+		defer close(channel)
+		kubeconfigStore.StartSearch(channel)
+	}()
+
+	var results []storetypes.SearchResult
+	for result := range channel {
+		results = append(results, result)
+	}
+	return results
+}
+
+// execPlugin records the request in $REQUEST_FILE and answers the operations of the exec store protocol.
+// The first argument selects a failure mode.
+const execPlugin = `#!/bin/sh
+request=$(cat)
+printf '%s' "$request" > "$REQUEST_FILE"
+case "$1" in
+fail) echo "cluster inventory unavailable" >&2; exit 1 ;;
+hang) exec sleep 10 ;;
+garbage) echo "not json"; exit 0 ;;
+esac
+case "$request" in
+*'"operation":"list"'*) echo '{"kubeconfigs":[{"path":"netbox/a","tags":{"site":"fra"}},{"path":"netbox/b"}]}' ;;
+*'"path":"netbox/empty"'*) echo '{"kubeconfig":""}' ;;
+*) printf '%s\n' '{"kubeconfig":"apiVersion: v1\nkind: Config\n"}' ;;
+esac
+`
+
+var _ = Describe("ExecStore", func() {
+	var (
+		dir         string
+		requestFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "exec-store")
+		Expect(err).ToNot(HaveOccurred())
+		requestFile = filepath.Join(dir, "request.json")
+		Expect(os.WriteFile(filepath.Join(dir, "plugin"), []byte(execPlugin), 0700)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	newExecStore := func(args ...string) *store.ExecStore {
+		id := "netbox"
+		execStore, err := store.NewExecStore(types.KubeconfigStore{
+			ID:   &id,
+			Kind: types.StoreKindExec,
+			Config: map[string]interface{}{
+				"command": filepath.Join(dir, "plugin"),
+				"args":    args,
+				"env":     map[string]string{"REQUEST_FILE": requestFile},
+				"options": map[string]interface{}{"tenant": "platform"},
+				"timeout": "500ms",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return execStore
+	}
+
+	lastRequest := func() execplugin.Request {
+		data, err := os.ReadFile(requestFile)
+		Expect(err).ToNot(HaveOccurred())
+
+		request := execplugin.Request{}
+		Expect(json.Unmarshal(data, &request)).To(Succeed())
+		return request
+	}
+
+	It("should require a command", func() {
+		_, err := store.NewExecStore(types.KubeconfigStore{Kind: types.StoreKindExec})
+		Expect(err).To(MatchError("the command of the exec store has to be configured"))
+	})
+
+	It("should verify that the command exists", func() {
+		Expect(newExecStore().VerifyKubeconfigPaths()).To(Succeed())
+
+		execStore := newExecStore()
+		execStore.Config.Command = filepath.Join(dir, "missing")
+		Expect(execStore.VerifyKubeconfigPaths()).To(HaveOccurred())
+	})
+
+	It("should list the kubeconfigs of the plugin", func() {
+		Expect(search(newExecStore())).To(Equal([]storetypes.SearchResult{
+			{KubeconfigPath: "netbox/a", Tags: map[string]string{"site": "fra"}},
+			{KubeconfigPath: "netbox/b"},
+		}))
+		Expect(lastRequest()).To(Equal(execplugin.Request{
+			APIVersion: execplugin.APIVersion,
+			Operation:  execplugin.OperationList,
+			StoreID:    "exec.netbox",
+			Options:    map[string]interface{}{"tenant": "platform"},
+		}))
+	})
+
+	It("should get the kubeconfig for the path and tags", func() {
+		kubeconfig, err := newExecStore().GetKubeconfigForPath("netbox/a", map[string]string{"site": "fra"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(kubeconfig)).To(Equal("apiVersion: v1\nkind: Config\n"))
+
+		request := lastRequest()
+		Expect(request.Operation).To(Equal(execplugin.OperationGetKubeconfig))
+		Expect(request.Path).To(Equal("netbox/a"))
+		Expect(request.Tags).To(Equal(map[string]string{"site": "fra"}))
+	})
+
+	It("should reject empty kubeconfigs", func() {
+		_, err := newExecStore().GetKubeconfigForPath("netbox/empty", nil)
+		Expect(err).To(MatchError(`exec store returned an empty kubeconfig for path "netbox/empty"`))
+	})
+
+	It("should return the standard error of failed operations", func() {
+		results := search(newExecStore("fail"))
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Error).To(MatchError(`exec store operation "list" failed: cluster inventory unavailable`))
+	})
+
+	It("should fail on invalid responses", func() {
+		_, err := newExecStore("garbage").GetKubeconfigForPath("netbox/a", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(`failed to decode response of exec store operation "get-kubeconfig"`))
+	})
+
+	It("should stop operations after the timeout", func() {
+		_, err := newExecStore("hang").GetKubeconfigForPath("netbox/a", nil)
+		Expect(err).To(MatchError(`exec store operation "get-kubeconfig" timed out after 500ms`))
+	})
+})
@@ -18,19 +18,55 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/disiqueira/gotree"
 	v3 "github.com/exoscale/egoscale/v3"
 	"github.com/exoscale/egoscale/v3/credentials"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	execcredential "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/exec-credential"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/limiter"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-func NewExoscaleStore(store types.KubeconfigStore) (*ExoscaleStore, error) {
+const (
+	// tagSKSClusterID is the tag containing the ID of the SKS cluster
+	tagSKSClusterID = "id"
+	// tagZone is the tag containing the name of the zone of the SKS cluster
+	tagZone = "zone"
+	// tagZoneAPIEndpoint is the tag containing the API endpoint of the zone of the SKS cluster
+	tagZoneAPIEndpoint = "zoneEndpoint"
+	// tagSKSClusterLevel is the tag containing the service level of the SKS cluster, e.g. "starter" or "pro"
+	tagSKSClusterLevel = "level"
+)
+
+// searchWorkersExoscale is the maximum number of zones listed in parallel, further limited by the maxConcurrency of the store
+const searchWorkersExoscale = 8
+
+const (
+	// defaultKubeconfigUser is the default user of the client certificates
+	defaultKubeconfigUser = "default"
+	// defaultKubeconfigGroup is the default group of the client certificates, granting cluster-admin access
+	defaultKubeconfigGroup = "system:masters"
+	// staticCredentialTTL is the default validity in seconds of the client certificates embedded in the kubeconfigs (30 days)
+	staticCredentialTTL = 2592000
+	// execCredentialTTL is the default validity in seconds of the client certificates minted by the exec credential plugin (1 hour)
+	execCredentialTTL = 3600
+)
+
+func NewExoscaleStore(store types.KubeconfigStore, stateDir string) (*ExoscaleStore, error) {
 	exoscaleStoreConfig := &types.StoreConfigExoscale{}
 	if store.Config != nil {
 		buf, err := yaml.Marshal(store.Config)
@@ -44,7 +80,7 @@ func NewExoscaleStore(store types.KubeconfigStore) (*ExoscaleStore, error) {
 		}
 	}
 
-	logger := logrus.New().WithField("store", types.StoreKindExoscale)
+	logger := logging.New().WithField("store", types.StoreKindExoscale)
 
 	exoscaleAPIKey := exoscaleStoreConfig.ExoscaleAPIKey
 	if len(exoscaleAPIKey) == 0 {
@@ -57,7 +93,11 @@ func NewExoscaleStore(store types.KubeconfigStore) (*ExoscaleStore, error) {
 	}
 
 	creds := credentials.NewStaticCredentials(exoscaleAPIKey, exoscaleSecretKey)
-	client, err := v3.NewClient(creds)
+	transport, err := httptransport.ForStore(store)
+	if err != nil {
+		return nil, err
+	}
+	client, err := v3.NewClient(creds, v3.ClientOptWithHTTPClient(ratelimit.NewClient(transport, store.RateLimit)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Exoscale client due to error: %w", err)
 	}
@@ -66,6 +106,8 @@ func NewExoscaleStore(store types.KubeconfigStore) (*ExoscaleStore, error) {
 		Logger:             logger,
 		KubeconfigStore:    store,
 		Client:             client,
+		Config:             exoscaleStoreConfig,
+		StateDirectory:     stateDir,
 		DiscoveredClusters: make(map[v3.UUID]ExoscaleKube),
 	}, nil
 }
@@ -112,6 +154,7 @@ func (s *ExoscaleStore) GetLogger() *logrus.Entry {
 
 // StartSearch queries *all* Exoscale zones, discovers SKS clusters
 // in each zone, and publishes the cluster names prefixed with <zoneName>/.
+// The zones are listed in parallel by a bounded number of workers and the clusters are published as soon as their zone is listed.
 func (s *ExoscaleStore) StartSearch(channel chan storetypes.SearchResult) {
 	s.Logger.Debug("Exoscale: start search")
 
@@ -132,77 +175,252 @@ func (s *ExoscaleStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 
 	// 2. For each zone, list SKS clusters
-	for _, zone := range zonesResp.Zones {
-		zoneClient := s.Client.WithEndpoint(zone.APIEndpoint)
+	zonesToSearch := s.filterZones(zonesResp.Zones)
+	workers := searchWorkersExoscale
+	if maxConcurrency := s.KubeconfigStore.MaxConcurrency; maxConcurrency != nil && *maxConcurrency > 0 {
+		workers = min(workers, *maxConcurrency)
+	}
 
-		clusters, err := zoneClient.ListSKSClusters(ctx)
-		if err != nil {
-			// If a single zone fails, report it but continue with the others
-			s.Logger.WithError(err).Warnf("Failed to list SKS clusters for zone %s", zone.Name)
+	zones := make(chan v3.Zone)
+	wg := sync.WaitGroup{}
+	for i := 0; i < min(workers, len(zonesToSearch)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for zone := range zones {
+				s.searchZone(ctx, zone, channel)
+			}
+		}()
+	}
+
+	for _, zone := range zonesToSearch {
+		zones <- zone
+	}
+	close(zones)
+	wg.Wait()
+}
+
+// filterZones returns the zones configured for the discovery, or all zones if none are configured
+func (s *ExoscaleStore) filterZones(zones []v3.Zone) []v3.Zone {
+	if len(s.Config.Zones) == 0 {
+		return zones
+	}
+
+	var filtered []v3.Zone
+	for _, name := range s.Config.Zones {
+		index := slices.IndexFunc(zones, func(zone v3.Zone) bool {
+			return string(zone.Name) == name
+		})
+		if index < 0 {
+			s.Logger.Warnf("Configured zone %q does not exist in Exoscale", name)
 			continue
 		}
+		filtered = append(filtered, zones[index])
+	}
+	return filtered
+}
 
-		if len(clusters.SKSClusters) == 0 {
-			s.Logger.Debugf("No SKS clusters found in zone %s", zone.Name)
-			continue
+// searchZone lists the SKS clusters of the zone and publishes them
+func (s *ExoscaleStore) searchZone(ctx context.Context, zone v3.Zone, channel chan storetypes.SearchResult) {
+	zoneClient := s.Client.WithEndpoint(zone.APIEndpoint)
+
+	l := limiter.ForStore(s.GetID(), s.KubeconfigStore.MaxConcurrency)
+	l.Acquire()
+	clusters, err := zoneClient.ListSKSClusters(ctx)
+	l.Release()
+	if err != nil {
+		// If a single zone fails, report it but continue with the others
+		s.Logger.WithError(err).Warnf("Failed to list SKS clusters for zone %s", zone.Name)
+		return
+	}
+
+	if len(clusters.SKSClusters) == 0 {
+		s.Logger.Debugf("No SKS clusters found in zone %s", zone.Name)
+		return
+	}
+
+	for _, cluster := range clusters.SKSClusters {
+		// Record the cluster in memory
+		s.DiscoveredClustersMutex.Lock()
+		s.DiscoveredClusters[cluster.ID] = ExoscaleKube{
+			ID:           cluster.ID,
+			Name:         cluster.Name,
+			ZoneName:     zone.Name,
+			ZoneEndpoint: zone.APIEndpoint,
 		}
+		s.DiscoveredClustersMutex.Unlock()
 
-		for _, cluster := range clusters.SKSClusters {
-			// Record the cluster in memory
-			s.DiscoveredClusters[cluster.ID] = ExoscaleKube{
-				ID:           cluster.ID,
-				Name:         cluster.Name,
-				ZoneName:     zone.Name,
-				ZoneEndpoint: zone.APIEndpoint,
-			}
+		s.Logger.Debugf("Discovered SKS cluster name: %s and id: %s in zone %s", cluster.Name, cluster.ID, zone.Name)
 
-			s.Logger.Debugf("Discovered SKS cluster name: %s and id: %s in zone %s", cluster.Name, cluster.ID, zone.Name)
+		// The path we present back to kubeswitch:
+		// e.g. "ch-gva-2/my-cluster"
+		kubeconfigPath := fmt.Sprintf("%s/%s", zone.Name, cluster.Name)
 
-			// The path we present back to kubeswitch:
-			// e.g. "ch-gva-2/my-cluster"
-			kubeconfigPath := fmt.Sprintf("%s/%s", zone.Name, cluster.Name)
+		// Send the discovered path.
+		// The tags are persisted in the search index and identify the cluster without a search in later invocations.
+		tags := map[string]string{
+			tagSKSClusterID:    cluster.ID.String(),
+			tagZone:            string(zone.Name),
+			tagZoneAPIEndpoint: string(zone.APIEndpoint),
+		}
+		if len(cluster.Version) > 0 {
+			tags[storetypes.TagKubernetesVersion] = cluster.Version
+		}
+		if len(cluster.State) > 0 {
+			tags[storetypes.TagClusterState] = string(cluster.State)
+		}
+		if len(cluster.Level) > 0 {
+			tags[tagSKSClusterLevel] = string(cluster.Level)
+		}
+		addCloudTags(s.GetStoreConfig(), tags, cluster.Labels)
 
-			// Send the discovered path
-			channel <- storetypes.SearchResult{
-				KubeconfigPath: kubeconfigPath,
-				Error:          nil,
-			}
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: kubeconfigPath,
+			Tags:           tags,
+			Error:          nil,
 		}
 	}
 }
 
 // GetKubeconfigForPath expects path like "zoneName/clusterName",
 // finds that cluster, and returns the decoded YAML kubeconfig.
-func (s *ExoscaleStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+// With the kubeconfig provider "exec", the kubeconfig contains no credentials,
+// but an exec credential plugin minting short-lived credentials via MintKubeconfigForPath.
+func (s *ExoscaleStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	ctx := context.Background()
+	match, clusterName, err := s.findCluster(ctx, path, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if execcredential.MintsCredentials(s.GetStoreConfig()) {
+		return s.getExecKubeconfig(ctx, match, clusterName, path, tags)
+	}
+
+	cfg, err := s.generateKubeconfig(ctx, match, clusterName, path, s.credentialTTL(staticCredentialTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	// Finally, write the config back to YAML.
+	modifiedBytes, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal updated kubeconfig: %w", err)
+	}
+
+	return modifiedBytes, nil
+}
+
+// MintKubeconfigForPath returns the kubeconfig for the path with a newly generated client certificate of the user,
+// which expires after the configured TTL, but at most after the execCredentialTTL, so that minted credentials stay short-lived.
+// The user is named after the cluster, like the user of the exec kubeconfig.
+func (s *ExoscaleStore) MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	ctx := context.Background()
+	match, clusterName, err := s.findCluster(ctx, path, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.generateKubeconfig(ctx, match, clusterName, path, min(s.credentialTTL(execCredentialTTL), execCredentialTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	if kubeContext, ok := cfg.Contexts[clusterName]; ok && kubeContext.AuthInfo != clusterName {
+		if authInfo, ok := cfg.AuthInfos[kubeContext.AuthInfo]; ok {
+			cfg.AuthInfos[clusterName] = authInfo
+			delete(cfg.AuthInfos, kubeContext.AuthInfo)
+		}
+		kubeContext.AuthInfo = clusterName
+	}
+
+	minted, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal minted kubeconfig: %w", err)
+	}
+	return minted, nil
+}
+
+// credentialTTL returns the configured validity of the client certificates in seconds, or the given default TTL
+func (s *ExoscaleStore) credentialTTL(defaultTTL int64) int64 {
+	if kubeconfig := s.Config.Kubeconfig; kubeconfig != nil && kubeconfig.TTLSeconds != nil {
+		return *kubeconfig.TTLSeconds
+	}
+	return defaultTTL
+}
+
+// findCluster returns the cluster for a path like "zoneName/clusterName" and the name of the cluster.
+// Clusters that have not been discovered by a search in this process, e.g. when called by the exec credential plugin
+// or when the search index is used, are identified by the cluster ID in the tags.
+func (s *ExoscaleStore) findCluster(ctx context.Context, path string, tags map[string]string) (*ExoscaleKube, string, error) {
 	// Split path into zoneName/clusterName
 	parts := strings.SplitN(path, "/", 2)
 	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid cluster path %q (expected 'zoneName/clusterName')", path)
+		return nil, "", fmt.Errorf("invalid cluster path %q (expected 'zoneName/clusterName')", path)
 	}
 	zoneName := parts[0]
 	clusterName := parts[1]
 
 	// Find the stored cluster that matches both zone and cluster name
-	var match *ExoscaleKube
+	s.DiscoveredClustersMutex.RLock()
 	for _, c := range s.DiscoveredClusters {
 		if string(c.ZoneName) == zoneName && c.Name == clusterName {
-			match = &c
-			break
+			s.DiscoveredClustersMutex.RUnlock()
+			return &c, clusterName, nil
+		}
+	}
+	s.DiscoveredClustersMutex.RUnlock()
+
+	clusterID, ok := tags[tagSKSClusterID]
+	if !ok {
+		return nil, "", fmt.Errorf("no cluster found for %q", path)
+	}
+
+	id, err := v3.ParseUUID(clusterID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid ID %q of cluster %q: %w", clusterID, path, err)
+	}
+
+	// the endpoint is missing in the tags of search indexes written by older versions
+	endpoint := v3.Endpoint(tags[tagZoneAPIEndpoint])
+	if len(endpoint) == 0 {
+		if endpoint, err = s.Client.GetZoneAPIEndpoint(ctx, v3.ZoneName(zoneName)); err != nil {
+			return nil, "", fmt.Errorf("failed to determine the API endpoint of zone %q: %w", zoneName, err)
 		}
 	}
-	if match == nil {
-		return nil, fmt.Errorf("no cluster found for %q", path)
+
+	cluster := ExoscaleKube{
+		ID:           id,
+		Name:         clusterName,
+		ZoneName:     v3.ZoneName(zoneName),
+		ZoneEndpoint: endpoint,
 	}
+	s.DiscoveredClustersMutex.Lock()
+	s.DiscoveredClusters[id] = cluster
+	s.DiscoveredClustersMutex.Unlock()
+	return &cluster, clusterName, nil
+}
 
+// generateKubeconfig generates a kubeconfig with a client certificate of the configured user and groups
+// and renames its cluster and context to the name of the cluster.
+// The certificate is valid for the given TTL in seconds.
+func (s *ExoscaleStore) generateKubeconfig(ctx context.Context, match *ExoscaleKube, clusterName, path string, ttl int64) (*clientcmdapi.Config, error) {
 	// Prepare client targeting the cluster's zone
 	zoneClient := s.Client.WithEndpoint(match.ZoneEndpoint)
 
 	req := v3.SKSKubeconfigRequest{
-		Groups: []string{"system:masters"},
-		User:   "default",
-		Ttl:    2592000, // 30 days
+		Groups: []string{defaultKubeconfigGroup},
+		User:   defaultKubeconfigUser,
+		Ttl:    ttl,
+	}
+	if kubeconfig := s.Config.Kubeconfig; kubeconfig != nil {
+		if kubeconfig.User != nil {
+			req.User = *kubeconfig.User
+		}
+		if kubeconfig.Groups != nil {
+			req.Groups = kubeconfig.Groups
+		}
 	}
-	ctx := context.Background()
 
 	resp, err := zoneClient.GenerateSKSClusterKubeconfig(ctx, match.ID, req)
 	if err != nil {
@@ -260,15 +478,109 @@ func (s *ExoscaleStore) GetKubeconfigForPath(path string, _ map[string]string) (
 		cfg.CurrentContext = clusterName
 	}
 
-	// Finally, write the config back to YAML.
-	modifiedBytes, err := clientcmd.Write(*cfg)
+	return cfg, nil
+}
+
+// getExecKubeconfig returns a kubeconfig for the cluster without credentials.
+// The user calls the exec credential plugin of kubeswitch, which mints short-lived credentials when the client needs them.
+func (s *ExoscaleStore) getExecKubeconfig(ctx context.Context, match *ExoscaleKube, clusterName, path string, tags map[string]string) ([]byte, error) {
+	zoneClient := s.Client.WithEndpoint(match.ZoneEndpoint)
+
+	cluster, err := zoneClient.GetSKSCluster(ctx, match.ID)
 	if err != nil {
-		return nil, fmt.Errorf("marshal updated kubeconfig: %w", err)
+		return nil, fmt.Errorf("failed to get cluster %q: %w", path, err)
 	}
 
-	return modifiedBytes, nil
+	authority, err := zoneClient.GetSKSClusterAuthorityCert(ctx, match.ID, v3.GetSKSClusterAuthorityCertAuthorityControlPlane)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the certificate authority of cluster %q: %w", path, err)
+	}
+
+	// The certificate authority is base64-encoded PEM
+	certificateAuthority, err := base64.StdEncoding.DecodeString(authority.Cacert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the certificate authority of cluster %q: %w", path, err)
+	}
+
+	execConfig, err := execcredential.ExecConfig(s.GetID(), path, tags, s.StateDirectory, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[clusterName] = &clientcmdapi.Cluster{
+		Server:                   cluster.Endpoint,
+		CertificateAuthorityData: certificateAuthority,
+	}
+	cfg.AuthInfos[clusterName] = &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{
+			APIVersion:      execConfig.APIVersion,
+			Command:         execConfig.Command,
+			Args:            execConfig.Args,
+			InteractiveMode: clientcmdapi.ExecInteractiveMode(execConfig.InteractiveMode),
+		},
+	}
+	cfg.Contexts[clusterName] = &clientcmdapi.Context{
+		Cluster:  clusterName,
+		AuthInfo: clusterName,
+	}
+	cfg.CurrentContext = clusterName
+
+	kubeconfig, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal kubeconfig: %w", err)
+	}
+	return kubeconfig, nil
 }
 
 func (r *ExoscaleStore) VerifyKubeconfigPaths() error {
 	return nil
 }
+
+// GetSearchPreview shows the metadata of the SKS cluster stored in the tags of the search result (no API requests are being performed)
+func (s *ExoscaleStore) GetSearchPreview(path string, tags map[string]string) (string, error) {
+	asciTree := gotree.New(fmt.Sprintf("SKS: %s", path))
+
+	if id, ok := tags[tagSKSClusterID]; ok {
+		asciTree.Add(fmt.Sprintf("ID: %s", id))
+	}
+
+	if zone, ok := tags[tagZone]; ok {
+		asciTree.Add(fmt.Sprintf("Zone: %s", zone))
+	}
+
+	if version, ok := tags[storetypes.TagKubernetesVersion]; ok {
+		asciTree.Add(fmt.Sprintf("Kubernetes Version: %s", version))
+	}
+
+	if state, ok := tags[storetypes.TagClusterState]; ok {
+		asciTree.Add(fmt.Sprintf("State: %s", state))
+	}
+
+	if level, ok := tags[tagSKSClusterLevel]; ok {
+		asciTree.Add(fmt.Sprintf("Level: %s", level))
+	}
+
+	if cloudTags := storetypes.CloudTags(tags); len(cloudTags) > 0 {
+		labels := asciTree.Add("Labels")
+		keys := make([]string, 0, len(cloudTags))
+		for key := range cloudTags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			labels.Add(fmt.Sprintf("%s: %s", key, cloudTags[key]))
+		}
+	}
+
+	return asciTree.Print(), nil
+}
+
+// GetConsoleURL returns the URL of the SKS cluster in the Exoscale portal
+func (s *ExoscaleStore) GetConsoleURL(_ string, tags map[string]string) (string, error) {
+	clusterID, ok := tags[tagSKSClusterID]
+	if !ok {
+		return "", fmt.Errorf("the ID of the SKS cluster is unknown")
+	}
+	return fmt.Sprintf("https://portal.exoscale.com/compute/kubernetes/%s", url.PathEscape(clusterID)), nil
+}
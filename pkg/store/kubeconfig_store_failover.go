@@ -0,0 +1,269 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// FailoverStore is a chain of kubeconfig stores for the same clusters, e.g. a kubeconfig store and its mirror.
+// The first kubeconfig store is used as long as it is available. If it cannot be searched or fails to return a kubeconfig,
+// the other kubeconfig stores are tried in order.
+// The chain has the ID of the first kubeconfig store, hence shares its index, cache and context names.
+type FailoverStore struct {
+	Logger *logrus.Entry
+	stores []storetypes.KubeconfigStore
+	// origin is the index of the kubeconfig store that found the path during the search
+	origin     map[string]int
+	originLock sync.RWMutex
+}
+
+// NewFailoverStore returns a kubeconfig store trying the failover stores in order if the primary store fails
+func NewFailoverStore(primary storetypes.KubeconfigStore, failover ...storetypes.KubeconfigStore) *FailoverStore {
+	return &FailoverStore{
+		Logger: primary.GetLogger(),
+		stores: append([]storetypes.KubeconfigStore{primary}, failover...),
+		origin: map[string]int{},
+	}
+}
+
+// ordered returns the kubeconfig stores in the order they are tried for the path.
+// The kubeconfig store that found the path during the search is tried first.
+func (s *FailoverStore) ordered(path string) []storetypes.KubeconfigStore {
+	s.originLock.RLock()
+	origin, ok := s.origin[path]
+	s.originLock.RUnlock()
+	if !ok || origin == 0 {
+		return s.stores
+	}
+
+	stores := []storetypes.KubeconfigStore{s.stores[origin]}
+	for i, store := range s.stores {
+		if i != origin {
+			stores = append(stores, store)
+		}
+	}
+	return stores
+}
+
+func (s *FailoverStore) GetID() string {
+	return s.stores[0].GetID()
+}
+
+func (s *FailoverStore) GetKind() types.StoreKind {
+	return s.stores[0].GetKind()
+}
+
+// GetContextPrefix returns the prefix of the kubeconfig store that found the path or the first kubeconfig store that is available
+func (s *FailoverStore) GetContextPrefix(path string) string {
+	for _, store := range s.ordered(path) {
+		if prefix := store.GetContextPrefix(path); len(prefix) > 0 {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// VerifyKubeconfigPaths verifies the paths of all kubeconfig stores of the chain, as stores prepare their search while verifying.
+// Succeeds if the paths of at least one kubeconfig store are valid.
+func (s *FailoverStore) VerifyKubeconfigPaths() error {
+	var errs []error
+	for _, store := range s.stores {
+		if err := store.VerifyKubeconfigPaths(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", store.GetID(), err))
+		}
+	}
+	if len(errs) == len(s.stores) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// StartSearch searches the kubeconfig stores in order until a search finds contexts or completes without errors.
+// The errors of a failed search are only returned if there is no other kubeconfig store to fail over to.
+func (s *FailoverStore) StartSearch(channel chan storetypes.SearchResult) {
+	for i, store := range s.stores {
+		found, errs := s.search(i, channel)
+		if found > 0 || len(errs) == 0 || i == len(s.stores)-1 {
+			for _, err := range errs {
+				channel <- storetypes.SearchResult{Error: err}
+			}
+			return
+		}
+		s.Logger.Warnf("Failed to search kubeconfig store %s, failing over to %s: %v", store.GetID(), s.stores[i+1].GetID(), errors.Join(errs...))
+	}
+}
+
+// search forwards the contexts found in the kubeconfig store and returns their number and the errors of the search.
+// The search is abandoned after the searchTimeout of the kubeconfig store.
+func (s *FailoverStore) search(i int, channel chan storetypes.SearchResult) (int, []error) {
+	store := s.stores[i]
+
+	// only close when the search of the store is over, otherwise the store sends on a closed channel
+	c := make(chan storetypes.SearchResult)
+	go func() {
+		defer close(c)
+		store.StartSearch(c)
+	}()
+
+	var deadline <-chan time.Time
+	if timeout := store.GetStoreConfig().SearchTimeout; timeout != nil {
+		timer := time.NewTimer(*timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var (
+		found int
+		errs  []error
+	)
+	for {
+		select {
+		case result, ok := <-c:
+			if !ok {
+				return found, errs
+			}
+			if result.Error != nil {
+				errs = append(errs, result.Error)
+				continue
+			}
+			found++
+			s.originLock.Lock()
+			s.origin[result.KubeconfigPath] = i
+			s.originLock.Unlock()
+			channel <- result
+		case <-deadline:
+			// drain the abandoned search, so that the store does not block forever
+			go func() {
+				for range c {
+				}
+			}()
+			return found, append(errs, fmt.Errorf("search timed out after %s", *store.GetStoreConfig().SearchTimeout))
+		}
+	}
+}
+
+// GetKubeconfigForPath returns the kubeconfig of the first kubeconfig store that returns the kubeconfig for the path.
+// The kubeconfig store that found the path during the search is tried first.
+func (s *FailoverStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	var (
+		errs   []error
+		stores = s.ordered(path)
+	)
+	for i, store := range stores {
+		kubeconfig, err := store.GetKubeconfigForPath(path, tags)
+		if err == nil {
+			return kubeconfig, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", store.GetID(), err))
+		if i < len(stores)-1 {
+			s.Logger.Warnf("Failed to get kubeconfig %q from kubeconfig store %s, failing over to %s: %v", path, store.GetID(), stores[i+1].GetID(), err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (s *FailoverStore) GetLogger() *logrus.Entry {
+	return s.Logger
+}
+
+// GetStoreConfig returns the configuration of the first kubeconfig store.
+// The search of the chain times out after the sum of the searchTimeouts of all kubeconfig stores.
+func (s *FailoverStore) GetStoreConfig() types.KubeconfigStore {
+	config := s.stores[0].GetStoreConfig()
+
+	var timeout time.Duration
+	for _, store := range s.stores {
+		storeTimeout := store.GetStoreConfig().SearchTimeout
+		if storeTimeout == nil {
+			config.SearchTimeout = nil
+			return config
+		}
+		timeout += *storeTimeout
+	}
+	config.SearchTimeout = &timeout
+	return config
+}
+
+func (s *FailoverStore) GetSearchPreview(path string, optionalTags map[string]string) (string, error) {
+	var err error
+	for _, store := range s.ordered(path) {
+		previewer, ok := store.(storetypes.Previewer)
+		if !ok {
+			continue
+		}
+		var preview string
+		if preview, err = previewer.GetSearchPreview(path, optionalTags); err == nil {
+			return preview, nil
+		}
+	}
+	return "", err
+}
+
+func (s *FailoverStore) GetConsoleURL(path string, tags map[string]string) (string, error) {
+	err := storetypes.ErrConsoleNotSupported
+	for _, store := range s.ordered(path) {
+		linker, ok := store.(storetypes.ConsoleLinker)
+		if !ok {
+			continue
+		}
+		var url string
+		if url, err = linker.GetConsoleURL(path, tags); err == nil {
+			return url, nil
+		}
+	}
+	return "", err
+}
+
+func (s *FailoverStore) MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	err := storetypes.ErrMintingNotSupported
+	for _, store := range s.ordered(path) {
+		minter, ok := store.(storetypes.CredentialMinter)
+		if !ok {
+			continue
+		}
+		var kubeconfig []byte
+		if kubeconfig, err = minter.MintKubeconfigForPath(path, tags); err == nil {
+			return kubeconfig, nil
+		}
+	}
+	return nil, err
+}
+
+// Login logs in to the first kubeconfig store
+func (s *FailoverStore) Login() (*time.Time, error) {
+	authenticator, ok := s.stores[0].(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+	return authenticator.Login()
+}
+
+// CheckCredentials checks the credentials of the first kubeconfig store
+func (s *FailoverStore) CheckCredentials() (*time.Time, error) {
+	authenticator, ok := s.stores[0].(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+	return authenticator.CheckCredentials()
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+)
+
+var _ = Describe("FailoverStore", func() {
+	var (
+		primary *fakeStore
+		mirror  *fakeStore
+	)
+
+	BeforeEach(func() {
+		primary = &fakeStore{
+			id:          "primary",
+			paths:       []string{"a", "b"},
+			kubeconfigs: map[string]string{"a": "primary-a"},
+		}
+		mirror = &fakeStore{
+			id:          "mirror",
+			paths:       []string{"a", "b", "c"},
+			kubeconfigs: map[string]string{"a": "mirror-a", "b": "mirror-b", "c": "mirror-c"},
+		}
+	})
+
+	paths := func(results []storetypes.SearchResult) []string {
+		var paths []string
+		for _, result := range results {
+			Expect(result.Error).ToNot(HaveOccurred())
+			paths = append(paths, result.KubeconfigPath)
+		}
+		return paths
+	}
+
+	It("should have the identity of the primary store", func() {
+		failoverStore := store.NewFailoverStore(primary, mirror)
+		Expect(failoverStore.GetID()).To(Equal("primary"))
+		Expect(failoverStore.GetContextPrefix("a")).To(Equal("primary"))
+	})
+
+	It("should only search the primary store if it is available", func() {
+		failoverStore := store.NewFailoverStore(primary, mirror)
+		Expect(paths(search(failoverStore))).To(Equal([]string{"a", "b"}))
+	})
+
+	It("should keep partial results of the primary store without failing over", func() {
+		primary.searchErr = errors.New("page 2 failed")
+		failoverStore := store.NewFailoverStore(primary, mirror)
+
+		results := search(failoverStore)
+		Expect(results).To(HaveLen(3))
+		Expect(results[2].Error).To(MatchError("page 2 failed"))
+	})
+
+	It("should fail over to the next store if the search fails", func() {
+		primary.paths = nil
+		primary.searchErr = errors.New("unavailable")
+		failoverStore := store.NewFailoverStore(primary, mirror)
+
+		Expect(paths(search(failoverStore))).To(Equal([]string{"a", "b", "c"}))
+		Expect(failoverStore.GetContextPrefix("c")).To(Equal("mirror"))
+	})
+
+	It("should fail over to the next store if the search times out", func() {
+		timeout := 20 * time.Millisecond
+		primary.searchDelay = time.Second
+		primary.searchTimeout = &timeout
+		failoverStore := store.NewFailoverStore(primary, mirror)
+
+		Expect(paths(search(failoverStore))).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("should return the errors of the last store", func() {
+		primary.paths, primary.searchErr = nil, errors.New("unavailable")
+		mirror.paths, mirror.searchErr = nil, errors.New("mirror unavailable")
+		failoverStore := store.NewFailoverStore(primary, mirror)
+
+		results := search(failoverStore)
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Error).To(MatchError("mirror unavailable"))
+	})
+
+	It("should get kubeconfigs from the next store if the primary store fails", func() {
+		failoverStore := store.NewFailoverStore(primary, mirror)
+		search(failoverStore)
+
+		Expect(failoverStore.GetKubeconfigForPath("a", nil)).To(Equal([]byte("primary-a")))
+		Expect(failoverStore.GetKubeconfigForPath("b", nil)).To(Equal([]byte("mirror-b")))
+
+		_, err := failoverStore.GetKubeconfigForPath("d", nil)
+		Expect(err).To(MatchError("primary: \"d\" not found\nmirror: \"d\" not found"))
+	})
+
+	It("should first try the store that found the path", func() {
+		primary.paths, primary.searchErr = nil, errors.New("unavailable")
+		failoverStore := store.NewFailoverStore(primary, mirror)
+		search(failoverStore)
+
+		Expect(failoverStore.GetKubeconfigForPath("a", nil)).To(Equal([]byte("mirror-a")))
+	})
+
+	It("should succeed verification if any store is valid", func() {
+		primary.verifyErr = errors.New("invalid credentials")
+		Expect(store.NewFailoverStore(primary, mirror).VerifyKubeconfigPaths()).To(Succeed())
+
+		mirror.verifyErr = errors.New("invalid path")
+		Expect(store.NewFailoverStore(primary, mirror).VerifyKubeconfigPaths()).To(MatchError("primary: invalid credentials\nmirror: invalid path"))
+	})
+
+	It("should time out after the search timeouts of all stores", func() {
+		primaryTimeout, mirrorTimeout := time.Second, 2*time.Second
+		primary.searchTimeout = &primaryTimeout
+		Expect(store.NewFailoverStore(primary, mirror).GetStoreConfig().SearchTimeout).To(BeNil())
+
+		mirror.searchTimeout = &mirrorTimeout
+		Expect(*store.NewFailoverStore(primary, mirror).GetStoreConfig().SearchTimeout).To(Equal(3 * time.Second))
+	})
+
+	It("should not support minting credentials without a minting store", func() {
+		_, err := store.NewFailoverStore(primary, mirror).MintKubeconfigForPath("a", nil)
+		Expect(err).To(MatchError(storetypes.ErrMintingNotSupported))
+	})
+})
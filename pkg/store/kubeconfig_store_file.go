@@ -26,6 +26,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -34,7 +35,7 @@ func NewFilesystemStore(
 	kubeconfigStore types.KubeconfigStore,
 ) (*FilesystemStore, error) {
 	return &FilesystemStore{
-		Logger:          logrus.New().WithField("store", types.StoreKindFilesystem),
+		Logger:          logging.New().WithField("store", types.StoreKindFilesystem),
 		KubeconfigStore: kubeconfigStore,
 		KubeconfigName:  kubeconfigName,
 	}, nil
@@ -40,6 +40,9 @@ import (
 	gardenerstore "github.com/danielfoehrkn/kubeswitch/pkg/store/gardener"
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -85,7 +88,7 @@ func NewGardenerStore(store types.KubeconfigStore, stateDir string) (*GardenerSt
 	}
 
 	return &GardenerStore{
-		Logger:                   logrus.New().WithField("store", types.StoreKindGardener),
+		Logger:                   logging.New().WithField("store", types.StoreKindGardener),
 		KubeconfigStore:          store,
 		Config:                   config,
 		LandscapeName:            landscapeName,
@@ -189,23 +192,11 @@ func getGardenloginConfig(path string) (*GardenloginConfig, error) {
 
 // writeGardenloginConfig writes the given gardenlogin config to path
 func writeGardenloginConfig(path string, config *GardenloginConfig) error {
-	// creates or truncate/clean the existing file
-	file, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
 	output, err := yaml.Marshal(config)
 	if err != nil {
 		return err
 	}
-
-	_, err = file.Write(output)
-	if err != nil {
-		return err
-	}
-	return nil
+	return filelock.WriteFile(path, output, permissions.FileMode)
 }
 
 // StartSearch starts the search for Shoots and Managed Seeds
@@ -638,7 +629,10 @@ func (s *GardenerStore) sendKubeconfigPaths(channel chan storetypes.SearchResult
 
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: kubeconfigPath,
-			Error:          nil,
+			Tags: map[string]string{
+				storetypes.TagKubernetesVersion: shoot.Spec.Kubernetes.Version,
+			},
+			Error: nil,
 		}
 	}
 
@@ -17,12 +17,18 @@ package store
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/container/v1"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -30,6 +36,9 @@ import (
 	apiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"google.golang.org/api/cloudresourcemanager/v1"
 )
@@ -68,7 +77,7 @@ func NewGKEStore(store types.KubeconfigStore, stateDir string) (*GKEStore, error
 	// validate by invoking gcloud auth list --format json that the correct account is ACTIVE
 
 	return &GKEStore{
-		Logger:             logrus.New().WithField("store", types.StoreKindGKE),
+		Logger:             logging.New().WithField("store", types.StoreKindGKE),
 		KubeconfigStore:    store,
 		Config:             gkeStoreConfig,
 		StateDirectory:     stateDir,
@@ -91,7 +100,13 @@ func (s *GKEStore) InitializeGKEStore() error {
 	// Later, also support API keys provided with the store configuration
 	// please see: https://pkg.go.dev/google.golang.org/api/container/v1
 	// and: https://cloud.google.com/docs/authentication/production#automatically
-	client, err := container.NewService(ctx)
+	// The clients share the rate limit of the store.
+	transport, err := httptransport.ForStore(s.KubeconfigStore)
+	if err != nil {
+		return err
+	}
+	base := ratelimit.NewTransport(transport, s.KubeconfigStore.RateLimit)
+	httpClient, err := newGoogleHTTPClient(ctx, base)
 	if err != nil {
 		// this can happen when there are no application-default credentials available on the local disk
 		// try to re-authenticate using local gcloud installation
@@ -108,12 +123,17 @@ func (s *GKEStore) InitializeGKEStore() error {
 		s.Logger.Infof("Sucessfully obtained application default credentials.")
 
 		// try again with obtained credentials
-		client, err = container.NewService(ctx)
+		httpClient, err = newGoogleHTTPClient(ctx, base)
 		if err != nil {
 			return fmt.Errorf("failed to create Google Kubernetes Engine client: %w", err)
 		}
 	}
 
+	client, err := container.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return fmt.Errorf("failed to create Google Kubernetes Engine client: %w", err)
+	}
+
 	if s.Config.GCPAccount != nil {
 		isActive, err := isAccountActive(*s.Config.GCPAccount)
 		if err != nil {
@@ -130,7 +150,7 @@ func (s *GKEStore) InitializeGKEStore() error {
 	// Discover projects in this account
 	allowedProjectIDs := sets.NewString(s.Config.ProjectIDs...)
 
-	cloudResourceManagerService, err := cloudresourcemanager.NewService(ctx)
+	cloudResourceManagerService, err := cloudresourcemanager.NewService(ctx, option.WithHTTPClient(httpClient))
 	if err != nil {
 		return fmt.Errorf("failed to create cloud resource manager client: %w", err)
 	}
@@ -169,6 +189,16 @@ func (s *GKEStore) InitializeGKEStore() error {
 	return nil
 }
 
+// newGoogleHTTPClient returns an HTTP client authenticated with the application default credentials
+// sending the requests with the given base transport
+func newGoogleHTTPClient(ctx context.Context, base http.RoundTripper) (*http.Client, error) {
+	transport, err := htransport.NewTransport(ctx, base, option.WithScopes(container.CloudPlatformScope))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
 func (s *GKEStore) StartSearch(channel chan storetypes.SearchResult) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -200,8 +230,19 @@ func (s *GKEStore) StartSearch(channel chan storetypes.SearchResult) {
 			// cache for when getting the kubeconfig for the unique path later
 			s.DiscoveredClusters[kubeconfigPath] = f
 
+			tags := map[string]string{
+				"project": projectName,
+				"zone":    f.Location,
+				"cluster": f.Name,
+			}
+			if len(f.CurrentMasterVersion) > 0 {
+				tags[storetypes.TagKubernetesVersion] = f.CurrentMasterVersion
+			}
+			addCloudTags(s.GetStoreConfig(), tags, f.ResourceLabels)
+
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: kubeconfigPath,
+				Tags:           tags,
 				Error:          nil,
 			}
 		}
@@ -433,3 +474,61 @@ type gcloudAccount struct {
 	Account string `json:"account"`
 	Status  string `json:"status"`
 }
+
+// Login obtains the Google application default credentials and returns the expiry of the access token.
+// If no valid credentials are available, runs `gcloud auth application-default login`.
+func (s *GKEStore) Login() (*time.Time, error) {
+	if _, err := getGoogleAccessToken(context.Background()); err != nil {
+		if len(gcloudBinaryPath) == 0 {
+			return nil, fmt.Errorf("failed to obtain Google application default credentials. Please check that the `gcloud` CLI is installed: %w", err)
+		}
+
+		s.Logger.Infof("Failed to obtain Google application default credentials. Running `gcloud auth application-default login`: %v", err)
+		if err := runLoginCommand(gcloudBinaryPath, "auth", "application-default", "login"); err != nil {
+			return nil, err
+		}
+	}
+	return s.CheckCredentials()
+}
+
+// CheckCredentials obtains an access token with the Google application default credentials and returns its expiry
+func (s *GKEStore) CheckCredentials() (*time.Time, error) {
+	token, err := getGoogleAccessToken(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain Google application default credentials: %w", err)
+	}
+
+	if s.Config.GCPAccount != nil {
+		isActive, err := isAccountActive(*s.Config.GCPAccount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if Google Cloud account %q is active: %w", *s.Config.GCPAccount, err)
+		}
+
+		if !isActive {
+			return nil, fmt.Errorf("google cloud account %q is not active. Please use `gcloud config set account %s` to activate the account", *s.Config.GCPAccount, *s.Config.GCPAccount)
+		}
+	}
+
+	if token.Expiry.IsZero() {
+		return nil, nil
+	}
+	return &token.Expiry, nil
+}
+
+func getGoogleAccessToken(ctx context.Context) (*oauth2.Token, error) {
+	tokenSource, err := google.DefaultTokenSource(ctx, container.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	return tokenSource.Token()
+}
+
+// GetConsoleURL returns the URL of the GKE cluster in the Google Cloud console
+func (s *GKEStore) GetConsoleURL(path string, _ map[string]string) (string, error) {
+	project, location, clusterName, err := parseIdentifier(path)
+	if err != nil {
+		return "", err
+	}
+	project = strings.TrimPrefix(project, "gke_")
+	return fmt.Sprintf("https://console.cloud.google.com/kubernetes/clusters/details/%s/%s/details?project=%s", url.PathEscape(location), url.PathEscape(clusterName), url.QueryEscape(project)), nil
+}
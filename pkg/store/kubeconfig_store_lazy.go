@@ -0,0 +1,235 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tracing"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/limiter"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/offline"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// NewLazyStore returns a kubeconfig store that calls create on first use, i.e. when the store is searched or a kubeconfig is retrieved.
+// Errors of create are returned when the store is used.
+func NewLazyStore(store types.KubeconfigStore, create func() (storetypes.KubeconfigStore, error)) *LazyStore {
+	return &LazyStore{
+		Logger:          logging.New().WithField("store", store.Kind),
+		KubeconfigStore: store,
+		create:          create,
+	}
+}
+
+// get returns the wrapped kubeconfig store and creates it on first use
+func (s *LazyStore) get() (storetypes.KubeconfigStore, error) {
+	s.once.Do(func() {
+		s.Logger.Debugf("Initializing store %s", s.configuredID())
+		span := tracing.Start("initialize store", s.configuredID())
+		s.store, s.err = s.create()
+		if s.err == nil && s.store == nil {
+			s.err = fmt.Errorf("kubeconfig store %q is not available", s.configuredID())
+		}
+		tracing.End(span, s.err)
+	})
+	return s.store, s.err
+}
+
+func (s *LazyStore) GetID() string {
+	switch s.KubeconfigStore.Kind {
+	case types.StoreKindGardener, types.StoreKindAkamai, types.StoreKindPlugin:
+		// these stores derive the ID from their configuration or the plugin
+		if store, err := s.get(); err == nil {
+			return store.GetID()
+		}
+	}
+	return s.configuredID()
+}
+
+// offline returns true if the API of the kubeconfig store must not be called in offline mode
+func (s *LazyStore) offline() bool {
+	return offline.Enabled() && offline.RequiresNetwork(s.KubeconfigStore.Kind)
+}
+
+// configuredID returns the ID of the kubeconfig store from its configuration
+func (s *LazyStore) configuredID() string {
+	id := "default"
+	if s.KubeconfigStore.ID != nil {
+		id = *s.KubeconfigStore.ID
+	}
+	return fmt.Sprintf("%s.%s", s.KubeconfigStore.Kind, id)
+}
+
+func (s *LazyStore) GetKind() types.StoreKind {
+	return s.KubeconfigStore.Kind
+}
+
+func (s *LazyStore) GetContextPrefix(path string) string {
+	store, err := s.get()
+	if err != nil {
+		return ""
+	}
+	return store.GetContextPrefix(path)
+}
+
+func (s *LazyStore) VerifyKubeconfigPaths() error {
+	store, err := s.get()
+	if err != nil {
+		return err
+	}
+	return store.VerifyKubeconfigPaths()
+}
+
+func (s *LazyStore) StartSearch(channel chan storetypes.SearchResult) {
+	if s.offline() {
+		channel <- storetypes.SearchResult{Error: fmt.Errorf("%w: the store %q cannot be searched", offline.ErrOffline, s.configuredID())}
+		return
+	}
+
+	store, err := s.get()
+	if err != nil {
+		channel <- storetypes.SearchResult{Error: err}
+		return
+	}
+	store.StartSearch(channel)
+}
+
+func (s *LazyStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	// kubeconfigs are only served from the cache of the store
+	if s.offline() {
+		return nil, fmt.Errorf("%w: the kubeconfig %q of store %q is not cached", offline.ErrOffline, path, s.configuredID())
+	}
+
+	store, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	// e.g. prefetching kubeconfigs must not exceed the maximum concurrency of the store
+	l := limiter.ForStore(s.configuredID(), s.KubeconfigStore.MaxConcurrency)
+	l.Acquire()
+	span := tracing.Start("get kubeconfig", store.GetID(), attribute.String("switch.kubeconfig.path", path))
+	kubeconfig, err := store.GetKubeconfigForPath(path, tags)
+	tracing.End(span, err)
+	l.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	// some stores return kubeconfigs concatenated as multiple YAML documents
+	merged, err := kubeconfigutil.MergeDocuments(kubeconfig)
+	if err != nil {
+		// invalid kubeconfigs are reported when they are parsed
+		s.Logger.Debugf("failed to merge the YAML documents of kubeconfig %q: %v", path, err)
+		return kubeconfig, nil
+	}
+	return merged, nil
+}
+
+func (s *LazyStore) GetLogger() *logrus.Entry {
+	return s.Logger
+}
+
+func (s *LazyStore) GetStoreConfig() types.KubeconfigStore {
+	return s.KubeconfigStore
+}
+
+func (s *LazyStore) GetSearchPreview(path string, optionalTags map[string]string) (string, error) {
+	if s.offline() {
+		return "", nil
+	}
+
+	store, err := s.get()
+	if err != nil {
+		return "", err
+	}
+
+	previewer, ok := store.(storetypes.Previewer)
+	if !ok {
+		// if the wrapped store is not a previewer, simply return an empty string, hence causing no visual distortion
+		return "", nil
+	}
+	return previewer.GetSearchPreview(path, optionalTags)
+}
+
+func (s *LazyStore) GetConsoleURL(path string, tags map[string]string) (string, error) {
+	store, err := s.get()
+	if err != nil {
+		return "", err
+	}
+
+	linker, ok := store.(storetypes.ConsoleLinker)
+	if !ok {
+		return "", storetypes.ErrConsoleNotSupported
+	}
+	return linker.GetConsoleURL(path, tags)
+}
+
+func (s *LazyStore) MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+	if s.offline() {
+		return nil, fmt.Errorf("%w: credentials for the kubeconfig %q of store %q cannot be minted", offline.ErrOffline, path, s.configuredID())
+	}
+
+	store, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	minter, ok := store.(storetypes.CredentialMinter)
+	if !ok {
+		return nil, storetypes.ErrMintingNotSupported
+	}
+
+	l := limiter.ForStore(s.configuredID(), s.KubeconfigStore.MaxConcurrency)
+	l.Acquire()
+	span := tracing.Start("mint credentials", store.GetID(), attribute.String("switch.kubeconfig.path", path))
+	kubeconfig, err := minter.MintKubeconfigForPath(path, tags)
+	tracing.End(span, err)
+	l.Release()
+	return kubeconfig, err
+}
+
+func (s *LazyStore) Login() (*time.Time, error) {
+	store, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator, ok := store.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+	return authenticator.Login()
+}
+
+func (s *LazyStore) CheckCredentials() (*time.Time, error) {
+	store, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator, ok := store.(storetypes.Authenticator)
+	if !ok {
+		return nil, storetypes.ErrLoginNotSupported
+	}
+	return authenticator.CheckCredentials()
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// fakeStore is a kubeconfig store serving the kubeconfigs of the paths
+type fakeStore struct {
+	id            string
+	paths         []string
+	searchErr     error
+	searchDelay   time.Duration
+	searchTimeout *time.Duration
+	verifyErr     error
+	// kubeconfigs are the kubeconfigs by path. Paths without kubeconfig cannot be retrieved.
+	kubeconfigs map[string]string
+}
+
+func (s *fakeStore) GetID() string                  { return s.id }
+func (s *fakeStore) GetKind() types.StoreKind       { return types.StoreKindMock }
+func (s *fakeStore) GetContextPrefix(string) string { return s.id }
+func (s *fakeStore) VerifyKubeconfigPaths() error   { return s.verifyErr }
+
+func (s *fakeStore) GetLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logrus.NewEntry(logger)
+}
+
+func (s *fakeStore) GetStoreConfig() types.KubeconfigStore {
+	return types.KubeconfigStore{Kind: types.StoreKindMock, SearchTimeout: s.searchTimeout}
+}
+
+func (s *fakeStore) StartSearch(channel chan storetypes.SearchResult) {
+	time.Sleep(s.searchDelay)
+	for _, path := range s.paths {
+		channel <- storetypes.SearchResult{KubeconfigPath: path}
+	}
+	if s.searchErr != nil {
+		channel <- storetypes.SearchResult{Error: s.searchErr}
+	}
+}
+
+func (s *fakeStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+	kubeconfig, ok := s.kubeconfigs[path]
+	if !ok {
+		return nil, fmt.Errorf("%q not found", path)
+	}
+	return []byte(kubeconfig), nil
+}
+
+var _ = Describe("LazyStore", func() {
+	var (
+		created   int
+		wrapped   *fakeStore
+		createErr error
+	)
+
+	BeforeEach(func() {
+		created, createErr = 0, nil
+		wrapped = &fakeStore{
+			id:          "mock.default",
+			paths:       []string{"a"},
+			kubeconfigs: map[string]string{"a": "apiVersion: v1\nkind: Config\n"},
+		}
+	})
+
+	newLazyStore := func() *store.LazyStore {
+		return store.NewLazyStore(types.KubeconfigStore{Kind: types.StoreKindMock}, func() (storetypes.KubeconfigStore, error) {
+			created++
+			if createErr != nil {
+				return nil, createErr
+			}
+			return wrapped, nil
+		})
+	}
+
+	It("should only create the store on first use", func() {
+		lazyStore := newLazyStore()
+		Expect(lazyStore.GetID()).To(Equal("mock.default"))
+		Expect(lazyStore.GetKind()).To(Equal(types.StoreKindMock))
+		Expect(created).To(Equal(0))
+
+		Expect(search(lazyStore)).To(Equal([]storetypes.SearchResult{{KubeconfigPath: "a"}}))
+		Expect(lazyStore.GetKubeconfigForPath("a", nil)).ToNot(BeEmpty())
+		Expect(created).To(Equal(1))
+	})
+
+	It("should return the error of the creation whenever the store is used", func() {
+		createErr = errors.New("missing credentials")
+		lazyStore := newLazyStore()
+
+		results := search(lazyStore)
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Error).To(MatchError("missing credentials"))
+
+		_, err := lazyStore.GetKubeconfigForPath("a", nil)
+		Expect(err).To(MatchError("missing credentials"))
+		Expect(lazyStore.VerifyKubeconfigPaths()).To(MatchError("missing credentials"))
+		Expect(created).To(Equal(1))
+	})
+
+	It("should fail if no store is created", func() {
+		lazyStore := store.NewLazyStore(types.KubeconfigStore{Kind: types.StoreKindMock}, func() (storetypes.KubeconfigStore, error) {
+			return nil, nil
+		})
+		Expect(lazyStore.VerifyKubeconfigPaths()).To(MatchError(`kubeconfig store "mock.default" is not available`))
+	})
+
+	It("should merge kubeconfigs returned as multiple YAML documents", func() {
+		wrapped.kubeconfigs["a"] = `apiVersion: v1
+kind: Config
+clusters:
+- name: a
+  cluster:
+    server: https://a.invalid
+---
+apiVersion: v1
+kind: Config
+clusters:
+- name: b
+  cluster:
+    server: https://b.invalid
+`
+		data, err := newLazyStore().GetKubeconfigForPath("a", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		kubeconfig, err := clientcmd.Load(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kubeconfig.Clusters).To(HaveKey("a"))
+		Expect(kubeconfig.Clusters).To(HaveKey("b"))
+	})
+
+	It("should not support optional capabilities the store does not implement", func() {
+		lazyStore := newLazyStore()
+
+		_, err := lazyStore.MintKubeconfigForPath("a", nil)
+		Expect(err).To(MatchError(storetypes.ErrMintingNotSupported))
+		_, err = lazyStore.GetConsoleURL("a", nil)
+		Expect(err).To(MatchError(storetypes.ErrConsoleNotSupported))
+		_, err = lazyStore.Login()
+		Expect(err).To(MatchError(storetypes.ErrLoginNotSupported))
+		Expect(lazyStore.GetSearchPreview("a", nil)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,184 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultMockClusters is the default number of fake clusters of the mock store
+const defaultMockClusters = 10
+
+var (
+	// mockEnvironments, mockRegions and mockVersions are cycled through to generate the fake clusters
+	mockEnvironments = []string{"dev", "staging", "prod"}
+	mockRegions      = []string{"eu-west-1", "eu-central-1", "us-east-1", "us-west-2", "ap-southeast-1"}
+	mockVersions     = []string{"1.29.10", "1.30.6", "1.31.2"}
+)
+
+func NewMockStore(store types.KubeconfigStore) (*MockStore, error) {
+	mockStoreConfig := &types.StoreConfigMock{}
+	if store.Config != nil {
+		buf, err := yaml.Marshal(store.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process mock store config: %w", err)
+		}
+
+		err = yaml.Unmarshal(buf, mockStoreConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal mock store config: %w", err)
+		}
+	}
+
+	if mockStoreConfig.Clusters == nil {
+		clusters := defaultMockClusters
+		mockStoreConfig.Clusters = &clusters
+	}
+
+	return &MockStore{
+		Logger:          logging.New().WithField("store", types.StoreKindMock),
+		KubeconfigStore: store,
+		Config:          mockStoreConfig,
+	}, nil
+}
+
+// GetID returns the unique store ID
+func (s *MockStore) GetID() string {
+	id := "default"
+
+	if s.KubeconfigStore.ID != nil {
+		id = *s.KubeconfigStore.ID
+	}
+
+	return fmt.Sprintf("%s.%s", types.StoreKindMock, id)
+}
+
+func (s *MockStore) GetKind() types.StoreKind {
+	return types.StoreKindMock
+}
+
+func (s *MockStore) GetContextPrefix(path string) string {
+	if s.GetStoreConfig().ShowPrefix != nil && !*s.GetStoreConfig().ShowPrefix {
+		return ""
+	}
+
+	if s.GetStoreConfig().ID != nil {
+		return *s.GetStoreConfig().ID
+	}
+
+	return string(types.StoreKindMock)
+}
+
+func (s *MockStore) VerifyKubeconfigPaths() error {
+	return nil
+}
+
+func (s *MockStore) GetStoreConfig() types.KubeconfigStore {
+	return s.KubeconfigStore
+}
+
+func (s *MockStore) GetLogger() *logrus.Entry {
+	return s.Logger
+}
+
+// StartSearch sends the fake clusters. The same configuration always yields the same clusters.
+func (s *MockStore) StartSearch(channel chan storetypes.SearchResult) {
+	s.Logger.Debugf("Mock: start search of %d clusters", *s.Config.Clusters)
+	s.simulateLatency()
+
+	for i := 0; i < *s.Config.Clusters; i++ {
+		environment, region, version := mockCluster(i)
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: mockClusterName(i),
+			Tags: map[string]string{
+				"environment":                   environment,
+				"region":                        region,
+				storetypes.TagKubernetesVersion: version,
+			},
+		}
+	}
+}
+
+// GetKubeconfigForPath generates the kubeconfig of the fake cluster with a single context named like the cluster
+func (s *MockStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+	index, err := mockClusterIndex(path)
+	if err != nil || index >= *s.Config.Clusters {
+		return nil, fmt.Errorf("no mock cluster found for %q", path)
+	}
+	s.simulateLatency()
+
+	server := fmt.Sprintf("https://api.%s.mock.invalid", path)
+	if s.Config.Server != nil {
+		server = *s.Config.Server
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[path] = &clientcmdapi.Cluster{
+		Server:                server,
+		InsecureSkipTLSVerify: true,
+	}
+	config.AuthInfos[path] = &clientcmdapi.AuthInfo{
+		Token: fmt.Sprintf("mock-token-%s", path),
+	}
+	config.Contexts[path] = &clientcmdapi.Context{
+		Cluster:   path,
+		AuthInfo:  path,
+		Namespace: "default",
+	}
+	config.CurrentContext = path
+
+	return clientcmd.Write(*config)
+}
+
+// simulateLatency waits for the configured latency of the mock store
+func (s *MockStore) simulateLatency() {
+	if s.Config.Latency != nil {
+		time.Sleep(*s.Config.Latency)
+	}
+}
+
+// mockCluster returns the environment, region and Kubernetes version of the fake cluster with the given index
+func mockCluster(index int) (string, string, string) {
+	return mockEnvironments[index%len(mockEnvironments)],
+		mockRegions[(index/len(mockEnvironments))%len(mockRegions)],
+		mockVersions[(index/2)%len(mockVersions)]
+}
+
+// mockClusterName returns the name of the fake cluster with the given index, e.g. "prod-eu-west-1-0003"
+func mockClusterName(index int) string {
+	environment, region, _ := mockCluster(index)
+	return fmt.Sprintf("%s-%s-%04d", environment, region, index+1)
+}
+
+// mockClusterIndex returns the index of the fake cluster with the given name
+func mockClusterIndex(name string) (int, error) {
+	number, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	if err != nil || number < 1 || mockClusterName(number-1) != name {
+		return 0, fmt.Errorf("invalid mock cluster name %q", name)
+	}
+	return number - 1, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("MockStore", func() {
+	It("should default to ten clusters", func() {
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{Kind: types.StoreKindMock})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mockStore.GetID()).To(Equal("mock.default"))
+		Expect(search(mockStore)).To(HaveLen(10))
+	})
+
+	It("should generate the same clusters for the same configuration", func() {
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{
+			Kind:   types.StoreKindMock,
+			Config: map[string]interface{}{"clusters": 4},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		results := search(mockStore)
+		Expect(results).To(Equal(search(mockStore)))
+		Expect(results).To(HaveLen(4))
+		Expect(results[3]).To(Equal(storetypes.SearchResult{
+			KubeconfigPath: "dev-eu-central-1-0004",
+			Tags: map[string]string{
+				"environment":                   "dev",
+				"region":                        "eu-central-1",
+				storetypes.TagKubernetesVersion: "1.30.6",
+			},
+		}))
+	})
+
+	It("should return the kubeconfig of the fake clusters", func() {
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{
+			Kind:   types.StoreKindMock,
+			Config: map[string]interface{}{"clusters": 4, "server": "https://127.0.0.1:6443"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := mockStore.GetKubeconfigForPath("dev-eu-central-1-0004", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		kubeconfig, err := clientcmd.Load(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kubeconfig.CurrentContext).To(Equal("dev-eu-central-1-0004"))
+		Expect(kubeconfig.Clusters["dev-eu-central-1-0004"].Server).To(Equal("https://127.0.0.1:6443"))
+	})
+
+	It("should not return kubeconfigs of unknown clusters", func() {
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{
+			Kind:   types.StoreKindMock,
+			Config: map[string]interface{}{"clusters": 4},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, path := range []string{"dev-eu-west-1-0005", "prod-eu-west-1-0001", "dev", "dev-eu-west-1-0000"} {
+			_, err := mockStore.GetKubeconfigForPath(path, nil)
+			Expect(err).To(MatchError(`no mock cluster found for "` + path + `"`))
+		}
+	})
+
+	It("should simulate the configured latency", func() {
+		mockStore, err := store.NewMockStore(types.KubeconfigStore{
+			Kind:   types.StoreKindMock,
+			Config: map[string]interface{}{"clusters": 1, "latency": "50ms"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		start := time.Now()
+		_, err = mockStore.GetKubeconfigForPath("dev-eu-west-1-0001", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+	})
+})
@@ -22,6 +22,9 @@ import (
 	"gopkg.in/yaml.v3"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -60,9 +63,14 @@ func NewOVHStore(store types.KubeconfigStore) (*OVHStore, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Failed to initialize OVH client due to error: %w", err)
 	}
+	transport, err := httptransport.ForStore(store)
+	if err != nil {
+		return nil, err
+	}
+	ovhClient.Client.Transport = ratelimit.NewTransport(transport, store.RateLimit)
 
 	return &OVHStore{
-		Logger:          logrus.New().WithField("store", types.StoreKindOVH),
+		Logger:          logging.New().WithField("store", types.StoreKindOVH),
 		KubeconfigStore: store,
 		Client:          ovhClient,
 		OVHKubeCache:    make(map[string]OVHKube),
@@ -72,6 +80,7 @@ func NewOVHStore(store types.KubeconfigStore) (*OVHStore, error) {
 type OVHKube struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
+	Version string `json:"version"`
 	Project string
 }
 
@@ -148,7 +157,10 @@ func (r *OVHStore) StartSearch(channel chan storetypes.SearchResult) {
 
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: kube.Name,
-				Error:          nil,
+				Tags: map[string]string{
+					storetypes.TagKubernetesVersion: kube.Version,
+				},
+				Error: nil,
 			}
 		}
 
@@ -17,14 +17,17 @@ package store
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/store/plugins"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -43,48 +46,78 @@ func NewPluginStore(store types.KubeconfigStore) (*PluginStore, error) {
 	}
 
 	return &PluginStore{
-		Logger:          logrus.New().WithField("store", types.StoreKindPlugin),
+		Logger:          logging.New().WithField("store", types.StoreKindPlugin),
 		KubeconfigStore: store,
 		Config:          storePlugin,
 	}, nil
 }
 
-// InitializePluginStore initializes the plugin store
+// InitializePluginStore starts the plugin and connects to it.
+// The plugin is only started once and stopped with CleanupPlugins.
 func (s *PluginStore) InitializePluginStore() error {
+	s.initLock.Lock()
+	defer s.initLock.Unlock()
+
+	if s.Client != nil {
+		return nil
+	}
+
+	level := hclog.Warn
+	if s.Logger.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		level = hclog.Debug
+	}
 
 	client := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig: plugins.Handshake,
-		Plugins:         plugins.PluginMap,
-		Cmd:             exec.Command(s.Config.CmdPath, s.Config.Args...),
-		AllowedProtocols: []plugin.Protocol{
-			plugin.ProtocolNetRPC, plugin.ProtocolGRPC},
+		HandshakeConfig:  plugins.Handshake,
+		VersionedPlugins: plugins.VersionedPlugins,
+		Cmd:              exec.Command(s.Config.CmdPath, s.Config.Args...),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		Managed:          true,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:   "plugin",
+			Output: os.Stderr,
+			Level:  level,
+		}),
 	})
 
 	// Connect via RPC
 	rpcClient, err := client.Client()
 	if err != nil {
-		return err
+		client.Kill()
+		return fmt.Errorf("failed to start plugin %q: %w", s.Config.CmdPath, err)
 	}
 
 	plugin, err := rpcClient.Dispense("store")
 	if err != nil {
+		client.Kill()
 		return fmt.Errorf("failed to dispense plugin: %w", err)
 	}
 
 	c, ok := plugin.(plugins.Store)
 	if !ok {
+		client.Kill()
 		return fmt.Errorf("plugin does not implement Store interface")
 	}
 
+	s.Logger.Debugf("Plugin %q uses protocol version %d", s.Config.CmdPath, client.NegotiatedVersion())
 	s.Client = c
 
 	return nil
 }
 
+// CleanupPlugins stops all started store plugins
+func CleanupPlugins() {
+	plugin.CleanupClients()
+}
+
 // GetID returns the unique store ID
 func (s *PluginStore) GetID() string {
 	ctx := context.Background()
 
+	if err := s.InitializePluginStore(); err != nil {
+		return fmt.Sprintf("%s.default", s.GetKind())
+	}
+
 	id, err := s.Client.GetID(ctx)
 	if err != nil {
 		return fmt.Sprintf("%s.default", s.GetKind())
@@ -99,6 +132,10 @@ func (s *PluginStore) GetKind() types.StoreKind {
 func (s *PluginStore) GetContextPrefix(path string) string {
 	ctx := context.Background()
 
+	if err := s.InitializePluginStore(); err != nil {
+		return fmt.Sprintf("%s/%s", s.GetKind(), path)
+	}
+
 	prefix, err := s.Client.GetContextPrefix(ctx, path)
 	if err != nil {
 		return fmt.Sprintf("%s/%s", s.GetKind(), path)
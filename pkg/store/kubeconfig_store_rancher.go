@@ -16,12 +16,17 @@ package store
 
 import (
 	"fmt"
+	"net/http"
+	"os"
 
 	"github.com/rancher/norman/clientbase"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	managementClient "github.com/rancher/rancher/pkg/client/generated/management/v3"
 )
@@ -51,7 +56,7 @@ func NewRancherStore(store types.KubeconfigStore) (*RancherStore, error) {
 	}
 
 	return &RancherStore{
-		Logger:          logrus.New().WithField("store", types.StoreKindRancher),
+		Logger:          logging.New().WithField("store", types.StoreKindRancher),
 		KubeconfigStore: store,
 		ClientOpts: &clientbase.ClientOpts{
 			URL:      rancherAPIAddress,
@@ -95,10 +100,32 @@ func (r *RancherStore) initClient() error {
 		return nil
 	}
 
+	var transport *http.Transport
+	if httpClient := r.KubeconfigStore.HTTPClient; httpClient != nil {
+		var err error
+		if transport, err = httptransport.ForStore(r.KubeconfigStore); err != nil {
+			return err
+		}
+
+		// the Rancher client discovers the API with its own transport, which only supports certificate authorities
+		if httpClient.CAFile != nil {
+			caCerts, err := os.ReadFile(util.ExpandEnv(*httpClient.CAFile))
+			if err != nil {
+				return fmt.Errorf("failed to read the certificate authorities: %w", err)
+			}
+			r.ClientOpts.CACerts = string(caCerts)
+		}
+		r.ClientOpts.HTTPClient = &http.Client{}
+	}
+
 	client, err := managementClient.NewClient(r.ClientOpts)
 	if err != nil {
 		return fmt.Errorf("failed to create Rancher client: %w", err)
 	}
+	if transport != nil {
+		// all further requests use the proxy and the TLS configuration of the store
+		r.ClientOpts.HTTPClient.Transport = transport
+	}
 
 	r.Client = client
 	return nil
@@ -24,6 +24,9 @@ import (
 	"gopkg.in/yaml.v3"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/ratelimit"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -40,7 +43,7 @@ func NewScalewayStore(store types.KubeconfigStore) (*ScalewayStore, error) {
 			return nil, fmt.Errorf("failed to unmarshal Scaleway config: %w", err)
 		}
 	}
-	logger := logrus.New().WithField("store", types.StoreKindScaleway)
+	logger := logging.New().WithField("store", types.StoreKindScaleway)
 
 	scalewayAccessKey := scalewayStoreConfig.ScalewayAccessKey
 	if len(scalewayAccessKey) == 0 {
@@ -60,10 +63,16 @@ func NewScalewayStore(store types.KubeconfigStore) (*ScalewayStore, error) {
 		scalewayRegion = "fr-par"
 	}
 
+	transport, err := httptransport.ForStore(store)
+	if err != nil {
+		return nil, err
+	}
+
 	client, err := scw.NewClient(
 		scw.WithDefaultOrganizationID(scalewayOrganizationID),
 		scw.WithAuth(scalewayAccessKey, scalewaySecretKey),
 		scw.WithDefaultRegion(scw.Region(scalewayRegion)),
+		scw.WithHTTPClient(ratelimit.NewClient(transport, store.RateLimit)),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to initialize Scaleway client due to error: %w", err)
@@ -164,7 +173,10 @@ func (s *ScalewayStore) StartSearch(channel chan storetypes.SearchResult) {
 			s.DiscoveredClusters[cluster.ID] = ScalewayKube{ID: cluster.ID, Name: cluster.Name, Project: project.ID}
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: cluster.Name,
-				Error:          nil,
+				Tags: map[string]string{
+					storetypes.TagKubernetesVersion: cluster.Version,
+				},
+				Error: nil,
 			}
 		}
 	}
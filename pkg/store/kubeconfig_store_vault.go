@@ -19,6 +19,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path"
 	paths "path"
@@ -26,6 +27,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	vaultapi "github.com/hashicorp/vault/api"
@@ -33,6 +35,9 @@ import (
 	"gopkg.in/yaml.v3"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/limiter"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -69,7 +74,7 @@ func NewVaultStore(vaultAPIAddressFromFlag, vaultTokenFileName, kubeconfigName s
 		return nil, err
 	}
 
-	var vaultToken string
+	vaultToken := vaultStoreConfig.VaultToken
 
 	// https://www.vaultproject.io/docs/commands/token-helper
 	tokenBytes, _ := os.ReadFile(fmt.Sprintf("%s/%s", home, vaultTokenFileName))
@@ -83,7 +88,7 @@ func NewVaultStore(vaultAPIAddressFromFlag, vaultTokenFileName, kubeconfigName s
 	}
 
 	if len(vaultToken) == 0 {
-		return nil, fmt.Errorf("when using the vault kubeconfig store, a vault API token must be provided. Per default, the token file in \"~.vault-token\" is used. The default token can be overriden via the environment variable \"VAULT_TOKEN\"")
+		return nil, fmt.Errorf("when using the vault kubeconfig store, a vault API token must be provided. Per default, the token file in \"~.vault-token\" is used. The default token can be overriden via the environment variable \"VAULT_TOKEN\". Alternatively, set \"vaultToken\" in the SwitchConfig or store it in the OS keychain with \"switch credentials set\"")
 	}
 
 	engineversion := vaultStoreConfig.VaultEngineVersion
@@ -96,9 +101,19 @@ func NewVaultStore(vaultAPIAddressFromFlag, vaultTokenFileName, kubeconfigName s
 		vaultKeyKubeconfig = "config"
 	}
 
-	vaultConfig := &vaultapi.Config{
-		Address: vaultAPI,
+	// start from the default configuration to keep the TLS configuration read from the Vault environment variables
+	vaultConfig := vaultapi.DefaultConfig()
+	if vaultConfig.Error != nil {
+		return nil, vaultConfig.Error
 	}
+	vaultConfig.Address = vaultAPI
+	httptransport.Apply(vaultConfig.HttpClient.Transport.(*http.Transport))
+	if kubeconfigStore.HTTPClient != nil {
+		if err := httptransport.ApplyClient(vaultConfig.HttpClient.Transport.(*http.Transport), *kubeconfigStore.HTTPClient); err != nil {
+			return nil, err
+		}
+	}
+
 	client, err := vaultapi.NewClient(vaultConfig)
 	if err != nil {
 		return nil, err
@@ -106,7 +121,7 @@ func NewVaultStore(vaultAPIAddressFromFlag, vaultTokenFileName, kubeconfigName s
 	client.SetToken(vaultToken)
 
 	return &VaultStore{
-		Logger:             logrus.New().WithField("store", types.StoreKindVault),
+		Logger:             logging.New().WithField("store", types.StoreKindVault),
 		KubeconfigName:     kubeconfigName,
 		KubeconfigStore:    kubeconfigStore,
 		VaultKeyKubeconfig: vaultKeyKubeconfig,
@@ -151,7 +166,10 @@ func (s *VaultStore) GetLogger() *logrus.Entry {
 func (s *VaultStore) recursivePathTraversal(wg *sync.WaitGroup, ctx context.Context, client *api.Client, path string, visit func(path string, directory bool) error) {
 	defer wg.Done()
 
+	l := limiter.ForStore(s.GetID(), s.KubeconfigStore.MaxConcurrency)
+	l.Acquire()
 	resp, err := client.Logical().ListWithContext(ctx, path)
+	l.Release()
 	if err != nil {
 		s.Logger.Errorf("could not list %q path: %s", path, err)
 		return
@@ -394,3 +412,30 @@ func shimKvV2ListPath(rawPath, mountPath string) string {
 func shimKVv2Metadata(path string) string {
 	return strings.Replace(path, "metadata/", "", -1)
 }
+
+// Login verifies that the Vault token is valid and returns its expiry.
+// A new token has to be obtained with the Vault CLI, as the login depends on the configured auth method.
+func (s *VaultStore) Login() (*time.Time, error) {
+	return s.CheckCredentials()
+}
+
+// CheckCredentials verifies that the Vault token is valid and returns its expiry
+func (s *VaultStore) CheckCredentials() (*time.Time, error) {
+	secret, err := s.Client.Auth().Token().LookupSelf()
+	if err != nil {
+		return nil, fmt.Errorf("the Vault token is invalid or expired. Please login with `vault login`: %v", err)
+	}
+
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the TTL of the Vault token: %v", err)
+	}
+
+	// tokens without TTL (e.g. root tokens) do not expire
+	if ttl == 0 {
+		return nil, nil
+	}
+
+	expiry := time.Now().Add(ttl)
+	return &expiry, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runLoginCommand runs the interactive login command of a provider CLI (e.g. "aws sso login").
+// The output is written to stderr, as stdout is captured by the shell integration.
+func runLoginCommand(binary string, args ...string) error {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return fmt.Errorf("the %q CLI is required to login: %v", binary, err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s failed: %v", binary, strings.Join(args, " "), err)
+	}
+	return nil
+}
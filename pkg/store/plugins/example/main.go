@@ -7,7 +7,6 @@ import (
 	"context"
 
 	"github.com/hashicorp/go-hclog"
-	"github.com/hashicorp/go-plugin"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/store/plugins"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
@@ -69,15 +68,5 @@ contexts:
 }
 
 func main() {
-	logger := hclog.Default()
-	plugin.Serve(&plugin.ServeConfig{
-		HandshakeConfig: plugins.Handshake,
-		Plugins: map[string]plugin.Plugin{
-			"store": &plugins.StorePlugin{Impl: &Store{Logger: logger}},
-		},
-		Logger: logger,
-
-		// A non-nil value here enables gRPC serving for this plugin...
-		GRPCServer: plugin.DefaultGRPCServer,
-	})
+	plugins.Serve(&Store{Logger: hclog.Default()})
 }
@@ -16,7 +16,9 @@ package plugins
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	kubeconfigstorev1 "github.com/danielfoehrkn/kubeswitch/pkg/store/plugins/kubeconfigstore/v1"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
@@ -60,21 +62,30 @@ func (m *GRPCClient) StartSearch(ctx context.Context, channel chan storetypes.Se
 
 	for {
 		resp, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
 		if err != nil {
-			close(channel)
+			channel <- storetypes.SearchResult{
+				KubeconfigPath: "",
+				Error:          fmt.Errorf("failed to receive search results from plugin: %w", err),
+			}
 			return
 		}
 
-		channel <- storetypes.SearchResult{
+		result := storetypes.SearchResult{
 			KubeconfigPath: resp.KubeconfigPath,
 			Tags:           resp.Tags,
-			Error:          err,
 		}
+		if len(resp.ErrorMessage) > 0 {
+			result.Error = errors.New(resp.ErrorMessage)
+		}
+		channel <- result
 	}
 }
 
 func (m *GRPCClient) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
-	resp, err := m.client.GetKubeconfigForPath(ctx, &kubeconfigstorev1.GetKubeconfigForPathRequest{Path: path})
+	resp, err := m.client.GetKubeconfigForPath(ctx, &kubeconfigstorev1.GetKubeconfigForPathRequest{Path: path, Tags: tags})
 	if err != nil {
 		return nil, err
 	}
@@ -123,9 +134,23 @@ func (m *GRPCServer) StartSearch(
 
 	ctx := stream.Context()
 
-	go m.Impl.StartSearch(ctx, ch)
+	go func() {
+		defer close(ch)
+		m.Impl.StartSearch(ctx, ch)
+	}()
+
 	for v := range ch {
-		if err := stream.Send(&kubeconfigstorev1.StartSearchResponse{KubeconfigPath: v.KubeconfigPath, Tags: v.Tags}); err != nil {
+		resp := &kubeconfigstorev1.StartSearchResponse{KubeconfigPath: v.KubeconfigPath, Tags: v.Tags}
+		if v.Error != nil {
+			resp.ErrorMessage = v.Error.Error()
+		}
+
+		if err := stream.Send(resp); err != nil {
+			// unblock the store implementation
+			go func() {
+				for range ch {
+				}
+			}()
 			return err
 		}
 	}
@@ -24,16 +24,25 @@ import (
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 )
 
+// ProtocolVersion is the version of the store plugin protocol.
+// It is incremented on incompatible changes of the KubeconfigStoreService.
+const ProtocolVersion = 1
+
+// Handshake is used by kubeswitch and the plugin to verify that the plugin is a kubeswitch store plugin.
+// It is not a security measure.
 var Handshake = plugin.HandshakeConfig{
-	ProtocolVersion:  1,
+	ProtocolVersion:  ProtocolVersion,
 	MagicCookieKey:   "KUBESWITCH_PLUGIN",
 	MagicCookieValue: "kubeswitch",
 }
 
+// Store is the interface implemented by store plugins
 type Store interface {
 	GetID(ctx context.Context) (string, error)
 	GetContextPrefix(ctx context.Context, path string) (string, error)
 	VerifyKubeconfigPaths(ctx context.Context) error
+	// StartSearch sends the search results to the channel and returns once the search is done.
+	// The channel must not be closed by the implementation.
 	StartSearch(ctx context.Context, channel chan storetypes.SearchResult)
 	GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error)
 }
@@ -43,6 +52,26 @@ var PluginMap = map[string]plugin.Plugin{
 	"store": &StorePlugin{},
 }
 
+// VersionedPlugins are the plugins we can dispense per protocol version.
+// The highest protocol version supported by both kubeswitch and the plugin is negotiated during the handshake.
+var VersionedPlugins = map[int]plugin.PluginSet{
+	ProtocolVersion: PluginMap,
+}
+
+// Serve serves the store implementation as kubeswitch store plugin.
+// It is called from the main function of the plugin and blocks until kubeswitch terminates the plugin.
+func Serve(impl Store) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		VersionedPlugins: map[int]plugin.PluginSet{
+			ProtocolVersion: {
+				"store": &StorePlugin{Impl: impl},
+			},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
+	})
+}
+
 // StorePlugin is the implementation of plugin.Plugin so we can serve/consume this.
 type StorePlugin struct {
 	plugin.NetRPCUnsupportedPlugin
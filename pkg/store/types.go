@@ -20,6 +20,7 @@ import (
 	"github.com/danielfoehrkn/kubeswitch/pkg/store/doks"
 	gardenclient "github.com/danielfoehrkn/kubeswitch/pkg/store/gardener/copied_gardenctlv2"
 	"github.com/danielfoehrkn/kubeswitch/pkg/store/plugins"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/types"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice"
@@ -120,10 +121,14 @@ type AzureStore struct {
 }
 
 type ExoscaleStore struct {
-	Logger             *logrus.Entry
-	KubeconfigStore    types.KubeconfigStore
-	Client             *exoscale.Client
-	DiscoveredClusters map[exoscale.UUID]ExoscaleKube
+	Logger          *logrus.Entry
+	KubeconfigStore types.KubeconfigStore
+	Client          *exoscale.Client
+	Config          *types.StoreConfigExoscale
+	StateDirectory  string
+	// DiscoveredClustersMutex synchronizes the writes of the zones searched in parallel to the DiscoveredClusters map
+	DiscoveredClustersMutex sync.RWMutex
+	DiscoveredClusters      map[exoscale.UUID]ExoscaleKube
 }
 
 type RancherStore struct {
@@ -173,9 +178,35 @@ type CapiStore struct {
 	Config          *types.StoreConfigCapi
 }
 
+type ExecStore struct {
+	Logger          *logrus.Entry
+	KubeconfigStore types.KubeconfigStore
+	Config          *types.StoreConfigExec
+}
+
+type MockStore struct {
+	Logger          *logrus.Entry
+	KubeconfigStore types.KubeconfigStore
+	Config          *types.StoreConfigMock
+}
+
 type PluginStore struct {
 	Logger          *logrus.Entry
 	KubeconfigStore types.KubeconfigStore
 	Config          *types.StoreConfigPlugin
 	Client          plugins.Store
+	// initLock serializes the start of the plugin, as the store is used by concurrent searches
+	initLock sync.Mutex
+}
+
+// LazyStore creates the wrapped kubeconfig store on first use,
+// so that the clients of kubeconfig stores that are not searched are never initialized
+type LazyStore struct {
+	Logger          *logrus.Entry
+	KubeconfigStore types.KubeconfigStore
+	// create creates the wrapped kubeconfig store
+	create func() (storetypes.KubeconfigStore, error)
+	once   sync.Once
+	store  storetypes.KubeconfigStore
+	err    error
 }
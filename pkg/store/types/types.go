@@ -15,6 +15,10 @@
 package types
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/danielfoehrkn/kubeswitch/types"
 
 	"github.com/sirupsen/logrus"
@@ -35,6 +39,32 @@ type SearchResult struct {
 	Error error
 }
 
+// TagKubernetesVersion is the tag of a search result containing the Kubernetes version of the cluster,
+// if returned by the API of the kubeconfig store during the search
+const TagKubernetesVersion = "version"
+
+// TagClusterState is the tag of a search result containing the state of the cluster, e.g. "running" or "upgrading",
+// if returned by the API of the kubeconfig store during the search
+const TagClusterState = "state"
+
+// CloudTagPrefix is the prefix of the keys of the cloud tags (labels) of a cluster in the tags of a search result
+const CloudTagPrefix = "tag:"
+
+// CloudTags returns the cloud tags of a cluster contained in the tags of its search result, without the CloudTagPrefix
+func CloudTags(tags map[string]string) map[string]string {
+	var cloudTags map[string]string
+	for key, value := range tags {
+		if !strings.HasPrefix(key, CloudTagPrefix) {
+			continue
+		}
+		if cloudTags == nil {
+			cloudTags = map[string]string{}
+		}
+		cloudTags[strings.TrimPrefix(key, CloudTagPrefix)] = value
+	}
+	return cloudTags
+}
+
 type KubeconfigStore interface {
 	// GetID returns the unique store ID
 	// should be
@@ -74,3 +104,39 @@ type KubeconfigStore interface {
 type Previewer interface {
 	GetSearchPreview(path string, optionalTags map[string]string) (string, error)
 }
+
+// ConsoleLinker can be optionally implemented by stores of cloud providers to link to the page
+// of a cluster in the web console of the provider
+type ConsoleLinker interface {
+	// GetConsoleURL returns the URL of the cluster in the web console using the path and tags of the search result
+	GetConsoleURL(path string, tags map[string]string) (string, error)
+}
+
+// ErrConsoleNotSupported is returned by the ConsoleLinker methods if the store does not have a web console
+var ErrConsoleNotSupported = errors.New("the kubeconfig store does not have a web console")
+
+// ErrLoginNotSupported is returned by the Authenticator methods if the store does not require an authentication flow
+var ErrLoginNotSupported = errors.New("the kubeconfig store does not require a login")
+
+// ErrMintingNotSupported is returned by the CredentialMinter methods if the store cannot mint short-lived credentials
+var ErrMintingNotSupported = errors.New("the kubeconfig store cannot mint short-lived credentials")
+
+// CredentialMinter can be optionally implemented by stores that can mint short-lived credentials for a cluster.
+// With the kubeconfig provider "exec", GetKubeconfigForPath returns kubeconfigs without credentials,
+// whose users call the exec credential plugin of kubeswitch, which calls MintKubeconfigForPath when the client needs credentials.
+type CredentialMinter interface {
+	// MintKubeconfigForPath returns the kubeconfig for the path with newly minted, short-lived credentials.
+	// The names of the users have to match the users of the kubeconfig returned by GetKubeconfigForPath.
+	MintKubeconfigForPath(path string, tags map[string]string) ([]byte, error)
+}
+
+// Authenticator can be optionally implemented by stores that require the user to authenticate
+// against the backing provider (e.g. via an SSO device flow)
+type Authenticator interface {
+	// Login runs the authentication flow of the store if the credentials are missing or expired
+	// and returns when the credentials expire. The expiry is nil if it is unknown or the credentials do not expire.
+	Login() (*time.Time, error)
+	// CheckCredentials returns when the current credentials expire without running an (interactive) authentication flow.
+	// Fails if the credentials are missing or invalid.
+	CheckCredentials() (*time.Time, error)
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package access
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// maxConcurrentChecks is the maximum number of clusters checked in parallel
+const maxConcurrentChecks = 8
+
+var logger = logging.New()
+
+// result is the result of the access check of a context
+type result struct {
+	name string
+	err  error
+}
+
+// Check checks whether the clusters of the contexts matching one of the patterns (all contexts without patterns) accept the credentials of the user.
+// The results are stored in the search index, so that the pickers dim or hide the contexts of clusters rejecting the credentials (see "accessCheck").
+// Prints the result per context.
+func Check(patterns []string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	// the same context can be returned more than once (e.g. from the index and the store), only check it once
+	nameToContext := map[string]pkg.DiscoveredContext{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot check all contexts. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Store == nil || !matches(patterns, discoveredContext) {
+			continue
+		}
+		nameToContext[fmt.Sprintf("%s %s", (*discoveredContext.Store).GetID(), discoveredContext.Name)] = discoveredContext
+	}
+
+	if len(nameToContext) == 0 {
+		return fmt.Errorf("no contexts found")
+	}
+
+	var (
+		results []result
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		slots   = make(chan struct{}, maxConcurrentChecks)
+	)
+	for name, discoveredContext := range nameToContext {
+		wg.Add(1)
+		go func(name string, discoveredContext pkg.DiscoveredContext) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			err := pkg.CheckAccess(*discoveredContext.Store, discoveredContext.Path, discoveredContext.Tags, discoveredContext.Name, stateDir)
+
+			lock.Lock()
+			defer lock.Unlock()
+			results = append(results, result{name: name, err: err})
+		}(name, discoveredContext)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].name < results[j].name
+	})
+
+	var denied, failed int
+	for _, r := range results {
+		switch {
+		case r.err == nil:
+			fmt.Printf("[✓] %s\n", r.name)
+		case errors.Is(r.err, pkg.ErrAccessDenied):
+			denied++
+			fmt.Printf("[✗] %s: %v\n", r.name, r.err)
+		default:
+			failed++
+			fmt.Printf("[?] %s: %v\n", r.name, r.err)
+		}
+	}
+
+	fmt.Printf("Checked %d context(s): %d accessible, %d rejected the credentials, %d could not be checked\n", len(results), len(results)-denied-failed, denied, failed)
+	return nil
+}
+
+// matches returns true if there are no patterns or the context name or alias matches one of them
+func matches(patterns []string, discoveredContext pkg.DiscoveredContext) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		m := wildmatch.NewWildMatch(pattern)
+		if m.IsMatch(discoveredContext.Name) || (len(discoveredContext.Alias) > 0 && m.IsMatch(discoveredContext.Alias)) {
+			return true
+		}
+	}
+	return false
+}
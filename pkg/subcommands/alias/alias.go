@@ -20,19 +20,21 @@ import (
 	"strings"
 
 	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/sirupsen/logrus"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-var logger = logrus.New()
+var logger = logging.New()
 
 func GetAliases(stateDir string) ([]string, error) {
 	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
-		if err := os.Mkdir(stateDir, 0755); err != nil {
+		if err := permissions.MkdirAll(stateDir); err != nil {
 			return nil, err
 		}
 	}
@@ -55,7 +57,7 @@ func GetAliases(stateDir string) ([]string, error) {
 
 func ListAliases(stateDir string) error {
 	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
-		if err := os.Mkdir(stateDir, 0755); err != nil {
+		if err := permissions.MkdirAll(stateDir); err != nil {
 			return err
 		}
 	}
@@ -72,15 +74,15 @@ func ListAliases(stateDir string) error {
 
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"Alias", "Context"})
+	t.AppendHeader(table.Row{"Alias", "Context", "Namespace"})
 
 	for ctx, alias := range a.Content.ContextToAliasMapping {
 		t.AppendRows([]table.Row{
-			{alias, ctx},
+			{alias, ctx, a.Content.AliasToNamespaceMapping[alias]},
 		})
 	}
 	t.AppendSeparator()
-	t.AppendFooter(table.Row{"Total", len(a.Content.ContextToAliasMapping)})
+	t.AppendFooter(table.Row{"Total", len(a.Content.ContextToAliasMapping), ""})
 	t.Render()
 
 	return nil
@@ -88,7 +90,7 @@ func ListAliases(stateDir string) error {
 
 func RemoveAlias(aliasToRemove, stateDir string) error {
 	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
-		if err := os.Mkdir(stateDir, 0755); err != nil {
+		if err := permissions.MkdirAll(stateDir); err != nil {
 			return err
 		}
 	}
@@ -117,7 +119,13 @@ func RemoveAlias(aliasToRemove, stateDir string) error {
 		return fmt.Errorf("alias with name %q does not exist", aliasToRemove)
 	}
 
+	if dryrun.Enabled() {
+		dryrun.Printf("remove alias %q from %q", aliasToRemove, a.FilePath())
+		return nil
+	}
+
 	a.Content.ContextToAliasMapping = newAliases
+	a.SetNamespace(aliasToRemove, "")
 	if err := a.WriteAllAliases(); err != nil {
 		return fmt.Errorf("failed to write aliases: %v", err)
 	}
@@ -129,14 +137,15 @@ func RemoveAlias(aliasToRemove, stateDir string) error {
 // Alias just maintains an alias record in the switch
 // state folder instead of renaming a context in the kubeconfig
 // this works independent of the backing store
-func Alias(aliasName, ctxNameToBeAliased string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+// the optional namespace is set when switching to the alias
+func Alias(aliasName, ctxNameToBeAliased, namespace string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
 	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
-		if err := os.Mkdir(stateDir, 0755); err != nil {
+		if err := permissions.MkdirAll(stateDir); err != nil {
 			return err
 		}
 	}
 
-	log := logrus.New().WithField("alias", aliasName)
+	log := logging.New().WithField("alias", aliasName)
 	log.Debugf("Writing alias %s for context name %s", aliasName, ctxNameToBeAliased)
 
 	aliasStore, err := state.GetDefaultAlias(stateDir)
@@ -171,17 +180,29 @@ func Alias(aliasName, ctxNameToBeAliased string, stores []storetypes.KubeconfigS
 
 		if ctxNameToBeAliased == discoveredContext.Name || ctxNameToBeAliased == contextWithoutPrefix {
 			// write the context like returned from the store (with or without prefix)
-			replacedContextName, err := aliasStore.WriteAlias(aliasName, discoveredContext.Name)
-			if err != nil {
-				return err
-			}
+			replacedContextName := aliasStore.SetAlias(aliasName, discoveredContext.Name)
+			aliasStore.SetNamespace(aliasName, namespace)
 
 			var replacedContext string
 			if replacedContextName != nil {
 				replacedContext = fmt.Sprintf(" replacing existing alias for context with name %q", *replacedContextName)
 			}
 
-			if _, err = fmt.Printf("Set alias %q for context %q%s.\n", aliasName, discoveredContext.Name, replacedContext); err != nil {
+			var withNamespace string
+			if len(namespace) > 0 {
+				withNamespace = fmt.Sprintf(" and namespace %q", namespace)
+			}
+
+			if dryrun.Enabled() {
+				dryrun.Printf("set alias %q for context %q%s%s in %q", aliasName, discoveredContext.Name, withNamespace, replacedContext, aliasStore.FilePath())
+				return nil
+			}
+
+			if err := aliasStore.WriteAllAliases(); err != nil {
+				return err
+			}
+
+			if _, err = fmt.Printf("Set alias %q for context %q%s%s.\n", aliasName, discoveredContext.Name, withNamespace, replacedContext); err != nil {
 				return err
 			}
 
@@ -0,0 +1,226 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alias
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// aliasFile is the format of exported and imported aliases
+type aliasFile struct {
+	// Aliases maps the alias to the context name
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+	// Namespaces maps the alias to the namespace to switch to
+	Namespaces map[string]string `yaml:"namespaces,omitempty"`
+	// Rules create aliases for all discovered contexts matching a rule when imported
+	Rules []RewriteRule `yaml:"rules,omitempty"`
+}
+
+// RewriteRule creates aliases for context names by replacing the matches of the regex with the replacement.
+// The replacement can reference capture groups of the regex, e.g. "$1".
+type RewriteRule struct {
+	Regex       string `yaml:"regex"`
+	Replacement string `yaml:"replacement"`
+}
+
+// ExportAliases writes all aliases as YAML to the writer
+func ExportAliases(stateDir string, out io.Writer) error {
+	a, err := state.GetDefaultAlias(stateDir)
+	if err != nil {
+		return err
+	}
+
+	file := aliasFile{
+		Aliases:    map[string]string{},
+		Namespaces: a.Content.AliasToNamespaceMapping,
+	}
+	for ctx, alias := range a.Content.ContextToAliasMapping {
+		file.Aliases[alias] = ctx
+	}
+
+	output, err := yaml.Marshal(file)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(output)
+	return err
+}
+
+// ImportAliases sets the aliases of the YAML file created with ExportAliases.
+// The rewrite rules of the file are applied to the discovered contexts. Use "-" to read from stdin.
+func ImportAliases(path string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, dryRun bool) error {
+	var (
+		bytes []byte
+		err   error
+	)
+	if path == "-" {
+		bytes, err = io.ReadAll(os.Stdin)
+	} else {
+		bytes, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read aliases: %v", err)
+	}
+
+	file := aliasFile{}
+	if err := yaml.UnmarshalStrict(bytes, &file); err != nil {
+		return fmt.Errorf("failed to parse aliases from %q: %v", path, err)
+	}
+
+	aliases := file.Aliases
+	if aliases == nil {
+		aliases = map[string]string{}
+	}
+	if len(file.Rules) > 0 {
+		rewritten, err := rewriteContextNames(file.Rules, stores, config, stateDir, noIndex)
+		if err != nil {
+			return err
+		}
+		// explicitly defined aliases take precedence over the rules
+		for alias, ctx := range rewritten {
+			if _, ok := aliases[alias]; !ok {
+				aliases[alias] = ctx
+			}
+		}
+	}
+
+	return setAliases(aliases, file.Namespaces, stateDir, dryRun)
+}
+
+// RewriteAliases creates aliases for all discovered contexts matching one of the rewrite rules
+func RewriteAliases(rules []RewriteRule, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, dryRun bool) error {
+	aliases, err := rewriteContextNames(rules, stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	if len(aliases) == 0 {
+		fmt.Println("No context matches the rewrite rules")
+		return nil
+	}
+	return setAliases(aliases, nil, stateDir, dryRun)
+}
+
+// rewriteContextNames applies the first matching rewrite rule to the discovered context names
+// returns the aliases mapped to the context names
+func rewriteContextNames(rules []RewriteRule, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (map[string]string, error) {
+	regexes := make([]*regexp.Regexp, 0, len(rules))
+	for _, rule := range rules {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", rule.Regex, err)
+		}
+		regexes = append(regexes, regex)
+	}
+
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	contextNames := map[string]struct{}{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot list contexts. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		contextNames[discoveredContext.Name] = struct{}{}
+	}
+
+	// sort to create the same aliases for the same contexts on every run
+	var sortedContextNames []string
+	for contextName := range contextNames {
+		sortedContextNames = append(sortedContextNames, contextName)
+	}
+	sort.Strings(sortedContextNames)
+
+	aliases := map[string]string{}
+	for _, contextName := range sortedContextNames {
+		for i, regex := range regexes {
+			if !regex.MatchString(contextName) {
+				continue
+			}
+
+			alias := regex.ReplaceAllString(contextName, rules[i].Replacement)
+			if len(alias) > 0 && alias != contextName {
+				if ctx, ok := aliases[alias]; ok {
+					logger.Warnf("skipping alias %q for context %q: already created for context %q", alias, contextName, ctx)
+				} else {
+					aliases[alias] = contextName
+				}
+			}
+			break
+		}
+	}
+	return aliases, nil
+}
+
+// setAliases writes the aliases mapped to the context names and the namespaces of the aliases to the alias state file
+func setAliases(aliases, namespaces map[string]string, stateDir string, dryRun bool) error {
+	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
+		if err := permissions.MkdirAll(stateDir); err != nil {
+			return err
+		}
+	}
+
+	aliasStore, err := state.GetDefaultAlias(stateDir)
+	if err != nil {
+		return err
+	}
+
+	var sortedAliases []string
+	for alias := range aliases {
+		sortedAliases = append(sortedAliases, alias)
+	}
+	sort.Strings(sortedAliases)
+
+	for _, alias := range sortedAliases {
+		var withNamespace string
+		if namespace := namespaces[alias]; len(namespace) > 0 {
+			withNamespace = fmt.Sprintf(" and namespace %q", namespace)
+		}
+
+		if dryRun {
+			fmt.Printf("Would set alias %q for context %q%s.\n", alias, aliases[alias], withNamespace)
+			continue
+		}
+
+		aliasStore.SetAlias(alias, aliases[alias])
+		aliasStore.SetNamespace(alias, namespaces[alias])
+		fmt.Printf("Set alias %q for context %q%s.\n", alias, aliases[alias], withNamespace)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if err := aliasStore.WriteAllAliases(); err != nil {
+		return fmt.Errorf("failed to write aliases: %v", err)
+	}
+	fmt.Printf("There are now %d alias(es) defined.\n", len(aliasStore.Content.ContextToAliasMapping))
+	return nil
+}
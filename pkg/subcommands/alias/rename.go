@@ -0,0 +1,144 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alias
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Rename renames a context without changing its kubeconfig store.
+// The new name is recorded as alias, so that it is used in the search results and in the kubeconfigs written when switching.
+// Renaming an alias renames the alias and keeps its namespace.
+// The history entries of the old name are rewritten to the new name.
+func Rename(oldName, newName string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
+		if err := permissions.MkdirAll(stateDir); err != nil {
+			return err
+		}
+	}
+
+	aliasStore, err := state.GetDefaultAlias(stateDir)
+	if err != nil {
+		return err
+	}
+
+	if existing := aliasStore.ContainsAlias(newName); existing != nil {
+		return fmt.Errorf("%q is already the name of context %q", newName, *existing)
+	}
+
+	contextName, err := findContext(oldName, newName, stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	var namespace string
+	if aliasStore.ContainsAlias(oldName) != nil {
+		namespace = aliasStore.Content.AliasToNamespaceMapping[oldName]
+		aliasStore.SetNamespace(oldName, "")
+	}
+
+	aliasStore.SetAlias(newName, contextName)
+	aliasStore.SetNamespace(newName, namespace)
+
+	if dryrun.Enabled() {
+		entries, err := historyutil.ReadHistoryEntries()
+		if err != nil {
+			return err
+		}
+		dryrun.Printf("set alias %q for context %q in %q", newName, contextName, aliasStore.FilePath())
+		dryrun.Printf("rename context %q to %q in %d history entries of %q", oldName, newName, renameHistoryEntries(entries, oldName, newName), os.ExpandEnv(historyutil.HistoryFilePath))
+		return nil
+	}
+
+	if err := aliasStore.WriteAllAliases(); err != nil {
+		return fmt.Errorf("failed to write aliases: %v", err)
+	}
+
+	renamed := 0
+	if err := historyutil.UpdateHistory(func(entries []string) []string {
+		renamed = renameHistoryEntries(entries, oldName, newName)
+		return entries
+	}); err != nil {
+		return fmt.Errorf("failed to rename the context in the history: %v", err)
+	}
+
+	fmt.Printf("Renamed context %q to %q (%d history entries updated).\n", oldName, newName, renamed)
+	return nil
+}
+
+// renameHistoryEntries replaces the old context name of the history entries with the new name.
+// Returns the number of renamed entries.
+func renameHistoryEntries(entries []string, oldName, newName string) int {
+	renamed := 0
+	for i, entry := range entries {
+		context, ns, err := historyutil.ParseHistoryEntry(entry)
+		if err != nil || *context != oldName {
+			continue
+		}
+
+		var entryNamespace string
+		if ns != nil {
+			entryNamespace = *ns
+		}
+		entries[i] = historyutil.FormatHistoryEntry(newName, entryNamespace)
+		renamed++
+	}
+	return renamed
+}
+
+// findContext returns the name of the discovered context shown with the old name, i.e. its name or alias.
+// Fails if a discovered context is already shown with the new name.
+func findContext(oldName, newName string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (string, error) {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return "", err
+	}
+
+	var found string
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot list contexts. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Store == nil {
+			continue
+		}
+
+		name := discoveredContext.Name
+		if len(discoveredContext.Alias) > 0 {
+			name = discoveredContext.Alias
+		}
+		switch name {
+		case newName:
+			return "", fmt.Errorf("%q is already the name of a context", newName)
+		case oldName:
+			found = discoveredContext.Name
+		}
+	}
+
+	if len(found) == 0 {
+		return "", fmt.Errorf("context with name %q not found", oldName)
+	}
+	return found, nil
+}
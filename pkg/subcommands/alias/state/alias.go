@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"gopkg.in/yaml.v2"
 )
@@ -77,6 +79,25 @@ func (a *Alias) loadFromFile() error {
 // returns the name of the overwritten context name in case there already exited a mapping context -> alias
 // or returns nil
 func (a *Alias) WriteAlias(aliasName, contextName string) (*string, error) {
+	lock, err := filelock.Acquire(a.aliasFilepath)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	// the aliases might have been changed by another terminal since they have been loaded
+	if err := a.loadFromFile(); err != nil {
+		return nil, err
+	}
+
+	contextAlreadyMappedToAlias := a.SetAlias(aliasName, contextName)
+	return contextAlreadyMappedToAlias, a.WriteAllAliases()
+}
+
+// SetAlias sets the alias for the context name without writing the alias state file
+// returns the name of the overwritten context name in case there already exited a mapping context -> alias
+// or returns nil
+func (a *Alias) SetAlias(aliasName, contextName string) *string {
 	if a.Content.ContextToAliasMapping == nil {
 		a.Content.ContextToAliasMapping = make(map[string]string, 1)
 	}
@@ -89,8 +110,30 @@ func (a *Alias) WriteAlias(aliasName, contextName string) (*string, error) {
 
 	// add new context -> alias mapping
 	a.Content.ContextToAliasMapping[contextName] = aliasName
+	return contextAlreadyMappedToAlias
+}
 
-	return contextAlreadyMappedToAlias, a.WriteAllAliases()
+// SetNamespace sets the namespace to switch to when switching to the alias without writing the alias state file.
+// An empty namespace removes the namespace of the alias.
+func (a *Alias) SetNamespace(aliasName, namespace string) {
+	if len(namespace) == 0 {
+		delete(a.Content.AliasToNamespaceMapping, aliasName)
+		return
+	}
+
+	if a.Content.AliasToNamespaceMapping == nil {
+		a.Content.AliasToNamespaceMapping = make(map[string]string, 1)
+	}
+	a.Content.AliasToNamespaceMapping[aliasName] = namespace
+}
+
+// GetNamespaceForAlias returns the namespace to switch to when switching to the alias or an empty string
+func GetNamespaceForAlias(stateDir, aliasName string) (string, error) {
+	a, err := GetDefaultAlias(stateDir)
+	if err != nil {
+		return "", err
+	}
+	return a.Content.AliasToNamespaceMapping[aliasName], nil
 }
 
 // ContainsAlias checks if the given alias already exists
@@ -104,24 +147,18 @@ func (a *Alias) ContainsAlias(alias string) *string {
 	return nil
 }
 
+// FilePath returns the path of the alias state file
+func (a *Alias) FilePath() string {
+	return a.aliasFilepath
+}
+
 // WriteAllAliases overwrites the alias state file with new Content
 func (a *Alias) WriteAllAliases() error {
-	// overwrite the existing state file (only state is last execution anyways atm.)
-	file, err := os.Create(a.aliasFilepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
 	output, err := yaml.Marshal(a.Content)
 	if err != nil {
 		return err
 	}
 
-	_, err = file.Write(output)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// replace the existing state file atomically, as other terminals may read it concurrently
+	return filelock.WriteFile(a.aliasFilepath, output, permissions.FileMode)
 }
@@ -0,0 +1,309 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/becheran/wildmatch-go"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultFileName is the filename of the audit log located in the state directory
+	defaultFileName = "switch.audit.jsonl"
+	// defaultMaxSize is the size after which the audit log is rotated
+	defaultMaxSize = "10Mi"
+	// defaultMaxBackups is the number of rotated audit log files that are kept
+	defaultMaxBackups = 5
+)
+
+// Entry is a single context switch recorded in the audit log
+type Entry struct {
+	// Timestamp is the time of the context switch
+	Timestamp time.Time `json:"timestamp"`
+	// User is the name of the operating system user
+	User string `json:"user"`
+	// Hostname is the hostname of the machine
+	Hostname string `json:"hostname"`
+	// TTY is the terminal the switch was run in
+	TTY string `json:"tty,omitempty"`
+	// ShellPID is the process ID of the shell the switch was run in
+	ShellPID int `json:"shellPID"`
+	// PreviousContext is the context of the terminal session before the switch
+	PreviousContext string `json:"previousContext,omitempty"`
+	// PreviousNamespace is the namespace of the terminal session before the switch
+	PreviousNamespace string `json:"previousNamespace,omitempty"`
+	// Context is the new context
+	Context string `json:"context"`
+	// Namespace is the namespace of the new context
+	Namespace string `json:"namespace,omitempty"`
+	// StoreKind is the kind of the kubeconfig store of the new context
+	StoreKind string `json:"storeKind,omitempty"`
+	// StoreID is the ID of the kubeconfig store of the new context
+	StoreID string `json:"storeID,omitempty"`
+	// KubeconfigPath is the path of the temporary kubeconfig of the new context
+	KubeconfigPath string `json:"kubeconfigPath"`
+}
+
+// Filter selects entries of the audit log
+type Filter struct {
+	// Since only selects entries recorded after the given time
+	Since time.Time
+	// ContextPattern only selects entries whose new context matches the wildcard pattern
+	ContextPattern string
+	// User only selects entries of the given user
+	User string
+	// Limit only selects the given number of most recent entries
+	Limit int
+}
+
+// IsEnabled checks if context switches should be recorded
+func IsEnabled(config *types.Config) bool {
+	return config != nil && config.Audit != nil && config.Audit.Enabled != nil && *config.Audit.Enabled
+}
+
+// GetPath returns the path of the audit log file
+func GetPath(config *types.Config, stateDirectory string) string {
+	if config != nil && config.Audit != nil && config.Audit.Path != nil {
+		return os.ExpandEnv(*config.Audit.Path)
+	}
+	return filepath.Join(stateDirectory, defaultFileName)
+}
+
+// NewEntry returns an entry for the current user and terminal session with the given timestamp
+func NewEntry(timestamp time.Time) Entry {
+	entry := Entry{
+		Timestamp: timestamp.UTC(),
+		ShellPID:  os.Getppid(),
+		TTY:       util.Terminal(),
+	}
+
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		entry.Hostname = hostname
+	}
+	return entry
+}
+
+// Record appends the entry to the audit log.
+// The audit log is rotated once it exceeds the configured max size.
+func Record(config *types.Config, stateDirectory string, entry Entry) error {
+	maxSize := defaultMaxSize
+	maxBackups := defaultMaxBackups
+	if config != nil && config.Audit != nil {
+		if config.Audit.MaxSize != nil {
+			maxSize = *config.Audit.MaxSize
+		}
+		if config.Audit.MaxBackups != nil {
+			maxBackups = *config.Audit.MaxBackups
+		}
+	}
+
+	quantity, err := resource.ParseQuantity(maxSize)
+	if err != nil {
+		return fmt.Errorf("invalid max size %q: %v", maxSize, err)
+	}
+
+	path := GetPath(config, stateDirectory)
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	// concurrent switches in other terminals append to and rotate the same audit log
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := rotate(path, quantity.Value(), maxBackups); err != nil {
+		return fmt.Errorf("failed to rotate audit log %q: %v", path, err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, permissions.FileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Query returns the entries of the audit log including the rotated files matching the filter, oldest first
+func Query(path string, filter Filter) ([]Entry, error) {
+	var matcher *wildmatch.WildMatch
+	if len(filter.ContextPattern) > 0 {
+		matcher = wildmatch.NewWildMatch(filter.ContextPattern)
+	}
+
+	var entries []Entry
+	for _, file := range logFiles(path) {
+		fileEntries, err := readEntries(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, entry := range fileEntries {
+			if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+				continue
+			}
+			if matcher != nil && !matcher.IsMatch(entry.Context) {
+				continue
+			}
+			if len(filter.User) > 0 && entry.User != filter.User {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// Print writes the entries either as table or as one JSON object per line
+func Print(w io.Writer, entries []Entry, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "TIME\tUSER\tTTY\tFROM\tTO\tSTORE")
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Timestamp.Local().Format(time.DateTime),
+			entry.User,
+			entry.TTY,
+			withNamespace(entry.PreviousContext, entry.PreviousNamespace),
+			withNamespace(entry.Context, entry.Namespace),
+			entry.StoreID)
+	}
+	return writer.Flush()
+}
+
+// rotate renames the audit log to <path>.1 if it exceeds the max size.
+// Existing rotated files are shifted by one and the oldest file is deleted.
+func rotate(path string, maxSize int64, maxBackups int) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if info.Size() < maxSize {
+		return nil
+	}
+
+	if maxBackups == 0 {
+		return os.Remove(path)
+	}
+
+	if err := os.Remove(backupPath(path, maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, backupPath(path, 1))
+}
+
+// logFiles returns the paths of the rotated audit log files and the current audit log, oldest first
+func logFiles(path string) []string {
+	matches, _ := filepath.Glob(path + ".*")
+
+	var backups int
+	for _, match := range matches {
+		var i int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(match, path+"."), "%d", &i); err == nil && backupPath(path, i) == match && i > backups {
+			backups = i
+		}
+	}
+
+	var files []string
+	for i := backups; i > 0; i-- {
+		files = append(files, backupPath(path, i))
+	}
+	return append(files, path)
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// readEntries reads the entries of a single audit log file. Lines that cannot be parsed are skipped.
+func readEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func withNamespace(context, namespace string) string {
+	if len(namespace) == 0 {
+		return context
+	}
+	return fmt.Sprintf("%s/%s", context, namespace)
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/credentials"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultWebhookTimeout is the maximum duration of a webhook request
+	defaultWebhookTimeout = 3 * time.Second
+	// webhookEvent is the event of the generic payload
+	webhookEvent = "switch"
+)
+
+// webhookPayload is the generic payload posted to a webhook.
+// Does not contain the kubeconfig path, as it is only meaningful on the machine of the user.
+type webhookPayload struct {
+	Event             string    `json:"event"`
+	Timestamp         time.Time `json:"timestamp"`
+	User              string    `json:"user"`
+	Hostname          string    `json:"hostname"`
+	TTY               string    `json:"tty,omitempty"`
+	Context           string    `json:"context"`
+	Namespace         string    `json:"namespace,omitempty"`
+	PreviousContext   string    `json:"previousContext,omitempty"`
+	PreviousNamespace string    `json:"previousNamespace,omitempty"`
+	StoreKind         string    `json:"storeKind,omitempty"`
+	StoreID           string    `json:"storeID,omitempty"`
+}
+
+// slackPayload is the payload of Slack-compatible incoming webhooks
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// WebhookPatterns returns the wildcard patterns of the contexts the webhook is notified for
+func WebhookPatterns(webhook types.WebhookConfig, protectedContexts []string) []string {
+	if len(webhook.Contexts) > 0 {
+		return webhook.Contexts
+	}
+	return protectedContexts
+}
+
+// WebhookHost returns the host of the webhook URL for messages, as the full URL can contain a secret.
+// Returns the secret reference if the URL is not resolved.
+func WebhookHost(webhook types.WebhookConfig) string {
+	if credentials.IsReference(webhook.URL) {
+		return webhook.URL
+	}
+	if u, err := url.Parse(webhook.URL); err == nil && len(u.Host) > 0 {
+		return u.Host
+	}
+	return webhook.URL
+}
+
+// NotifyWebhooks posts the context switch to the given webhooks concurrently.
+// Returns after all requests completed or timed out, joining the errors of the failed requests.
+func NotifyWebhooks(webhooks []types.WebhookConfig, entry Entry) error {
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs []error
+	)
+
+	for _, webhook := range webhooks {
+		wg.Add(1)
+		go func(webhook types.WebhookConfig) {
+			defer wg.Done()
+			if err := notifyWebhook(webhook, entry); err != nil {
+				lock.Lock()
+				errs = append(errs, fmt.Errorf("webhook %q: %w", WebhookHost(webhook), err))
+				lock.Unlock()
+			}
+		}(webhook)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func notifyWebhook(webhook types.WebhookConfig, entry Entry) error {
+	address, err := resolve(webhook.URL)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(newPayload(webhook, entry))
+	if err != nil {
+		return err
+	}
+
+	timeout := defaultWebhookTimeout
+	if webhook.Timeout != nil {
+		timeout = *webhook.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "kubeswitch")
+	for name, value := range webhook.Headers {
+		value, err := resolve(value)
+		if err != nil {
+			return fmt.Errorf("header %q: %w", name, err)
+		}
+		request.Header.Set(name, value)
+	}
+
+	client := &http.Client{Transport: httptransport.Shared()}
+	response, err := client.Do(request)
+	if err != nil {
+		// do not leak the URL, which can contain a secret
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return nil
+}
+
+// newPayload returns the payload of the context switch in the format of the webhook
+func newPayload(webhook types.WebhookConfig, entry Entry) interface{} {
+	if webhook.Format != nil && *webhook.Format == types.WebhookFormatSlack {
+		return slackPayload{Text: slackMessage(entry)}
+	}
+
+	return webhookPayload{
+		Event:             webhookEvent,
+		Timestamp:         entry.Timestamp,
+		User:              entry.User,
+		Hostname:          entry.Hostname,
+		TTY:               entry.TTY,
+		Context:           entry.Context,
+		Namespace:         entry.Namespace,
+		PreviousContext:   entry.PreviousContext,
+		PreviousNamespace: entry.PreviousNamespace,
+		StoreKind:         entry.StoreKind,
+		StoreID:           entry.StoreID,
+	}
+}
+
+// slackMessage formats the context switch as Slack message, e.g.
+// ":warning: *alice*@laptop switched to context `prod-eu` (from `dev-eu`) at 2025-01-02T15:04:05Z"
+func slackMessage(entry Entry) string {
+	var message strings.Builder
+	fmt.Fprintf(&message, ":warning: *%s*@%s switched to context `%s`", entry.User, entry.Hostname, entry.Context)
+	if len(entry.Namespace) > 0 {
+		fmt.Fprintf(&message, " (namespace `%s`)", entry.Namespace)
+	}
+	if len(entry.PreviousContext) > 0 {
+		fmt.Fprintf(&message, " from `%s`", entry.PreviousContext)
+	}
+	fmt.Fprintf(&message, " at %s", entry.Timestamp.Format(time.RFC3339))
+	return message.String()
+}
+
+// resolve returns the secret referenced by the value, or the value itself if it is not a secret reference
+func resolve(value string) (string, error) {
+	if !credentials.IsReference(value) {
+		return value, nil
+	}
+	return credentials.ResolveReference(value)
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// DirectoryConfigFileName is the name of the file declaring the context of a directory and its subdirectories
+const DirectoryConfigFileName = ".kubeswitch"
+
+// ErrNoTarget is returned if no context is declared for the current working directory
+var ErrNoTarget = fmt.Errorf("neither a %s file in the current directory or its parent directories nor a repository context of the SwitchConfig matches", DirectoryConfigFileName)
+
+// DirectoryConfig is the content of a ".kubeswitch" file
+type DirectoryConfig struct {
+	// Context is the name of the context to switch to
+	Context string `yaml:"context"`
+	// Namespace is the optional namespace to set for the context
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// Target is the context (and namespace) declared for the current working directory
+type Target struct {
+	// Source is the path of the ".kubeswitch" file or the root directory of the Git repository declaring the context
+	Source string
+	// Context is the name of the context to switch to
+	Context string
+	// Namespace is the optional namespace to set for the context
+	Namespace string
+	// content is the content of the ".kubeswitch" file, which has to be allowed before switching
+	content []byte
+}
+
+// FindTarget returns the context declared for the current working directory.
+// A ".kubeswitch" file in the current working directory or its parent directories takes precedence
+// over the repository contexts of the SwitchConfig. Returns nil if no context is declared.
+func FindTarget(config *types.Config) (*Target, error) {
+	path, err := FindDirectoryConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) > 0 {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		directoryConfig, err := parseDirectoryConfig(path, content)
+		if err != nil {
+			return nil, err
+		}
+		return &Target{
+			Source:    path,
+			Context:   directoryConfig.Context,
+			Namespace: directoryConfig.Namespace,
+			content:   content,
+		}, nil
+	}
+
+	if config == nil || len(config.RepositoryContexts) == 0 {
+		return nil, nil
+	}
+	return findRepositoryTarget(config.RepositoryContexts)
+}
+
+// FindDirectoryConfig searches the ".kubeswitch" file in the current working directory and its parent directories.
+// Returns an empty path if no file is found.
+func FindDirectoryConfig() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, DirectoryConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// parseDirectoryConfig parses and validates the content of the ".kubeswitch" file with the given path
+func parseDirectoryConfig(path string, data []byte) (*DirectoryConfig, error) {
+	directoryConfig := &DirectoryConfig{}
+	if err := yaml.Unmarshal(data, directoryConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %v", path, err)
+	}
+
+	if len(directoryConfig.Context) == 0 {
+		return nil, fmt.Errorf("%q does not declare a context", path)
+	}
+	return directoryConfig, nil
+}
+
+// Auto switches to the context declared for the current working directory, see FindTarget.
+// As a ".kubeswitch" file can be part of any cloned repository, its content has to be allowed with "switch auto allow" first.
+// Returns the path to the temporary kubeconfig and the context name.
+func Auto(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
+	target, err := FindTarget(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target == nil {
+		return nil, nil, ErrNoTarget
+	}
+
+	if target.content != nil {
+		trusted, err := isTrusted(stateDir, target.Source, target.content)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !trusted {
+			return nil, nil, fmt.Errorf("%q is not allowed to switch the context. Review its content and run 'switch auto allow' to trust it", target.Source)
+		}
+	}
+
+	// switching automatically when entering a directory should not clutter the history
+	kubeconfigPath, contextName, err := setcontext.SetContextExact(target.Context, stores, config, stateDir, noIndex, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to switch to the context of %q: %w", target.Source, err)
+	}
+
+	if len(target.Namespace) > 0 {
+		if err := setNamespace(*kubeconfigPath, target.Namespace); err != nil {
+			os.Remove(*kubeconfigPath)
+			return nil, nil, fmt.Errorf("failed to set namespace %q of the context of %q: %v", target.Namespace, target.Source, err)
+		}
+	}
+	return kubeconfigPath, contextName, nil
+}
+
+// setNamespace sets the namespace of the current context in the temporary kubeconfig
+func setNamespace(kubeconfigPath, namespace string) error {
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	if err := kubeconfig.SetNamespaceForCurrentContext(namespace); err != nil {
+		return err
+	}
+	_, err = kubeconfig.WriteKubeconfigFile()
+	return err
+}
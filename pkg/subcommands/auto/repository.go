@@ -0,0 +1,123 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// findRepositoryTarget returns the first repository context matching the Git repository of the current working directory.
+// Returns nil if the current working directory is not part of a Git repository or no repository context matches.
+func findRepositoryTarget(repositoryContexts []types.RepositoryContext) (*Target, error) {
+	root, remotes, err := gitRepository()
+	if err != nil || len(root) == 0 {
+		return nil, err
+	}
+
+	for _, repositoryContext := range repositoryContexts {
+		if !matchesRepository(repositoryContext, root, remotes) {
+			continue
+		}
+
+		target := &Target{
+			Source:  root,
+			Context: repositoryContext.Context,
+		}
+		if repositoryContext.Namespace != nil {
+			target.Namespace = *repositoryContext.Namespace
+		}
+		return target, nil
+	}
+	return nil, nil
+}
+
+// matchesRepository checks if the remote or path pattern of the repository context matches the repository
+func matchesRepository(repositoryContext types.RepositoryContext, root string, remotes []string) bool {
+	if repositoryContext.Path != nil {
+		pattern := filepath.Clean(util.ExpandEnv(*repositoryContext.Path))
+		if wildmatch.NewWildMatch(pattern).IsMatch(root) {
+			return true
+		}
+	}
+
+	if repositoryContext.Remote != nil {
+		pattern := wildmatch.NewWildMatch(normalizeRemote(*repositoryContext.Remote))
+		for _, remote := range remotes {
+			if pattern.IsMatch(normalizeRemote(remote)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gitRepository returns the root directory and the remote URLs of the Git repository of the current working directory.
+// Returns an empty root directory if the current working directory is not part of a Git repository.
+func gitRepository() (string, []string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", nil, nil
+	}
+
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		// not a Git repository
+		return "", nil, nil
+	}
+	root := filepath.Clean(strings.TrimSpace(string(output)))
+
+	// exits with code 1 if the repository has no remotes
+	output, _ = exec.Command("git", "-C", root, "config", "--get-regexp", `^remote\..*\.url$`).Output()
+
+	var remotes []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		// "remote.origin.url git@github.com:acme/payments.git"
+		if _, url, found := strings.Cut(scanner.Text(), " "); found {
+			remotes = append(remotes, strings.TrimSpace(url))
+		}
+	}
+	return root, remotes, scanner.Err()
+}
+
+// normalizeRemote strips the scheme, the user and the ".git" suffix from a Git remote URL.
+// For instance, both "git@github.com:acme/payments.git" and "https://github.com/acme/payments" become "github.com/acme/payments"
+func normalizeRemote(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(remote), "/"), ".git")
+
+	if _, withoutScheme, found := strings.Cut(remote, "://"); found {
+		remote = withoutScheme
+	} else if host, path, found := strings.Cut(remote, ":"); found && !strings.Contains(host, "/") {
+		// scp-like syntax "user@host:path"
+		remote = host + "/" + path
+	}
+
+	host, path, _ := strings.Cut(remote, "/")
+	if _, withoutUser, found := strings.Cut(host, "@"); found {
+		host = withoutUser
+	}
+	if len(path) == 0 {
+		return host
+	}
+	return host + "/" + path
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// trustFileName is the filename of the state file that contains the allowed ".kubeswitch" files
+const trustFileName = "switch.auto.trusted"
+
+// Allow trusts the current content of the ".kubeswitch" file, so that "switch auto" switches to its context.
+// Changing the file requires allowing it again.
+func Allow(stateDir, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := parseDirectoryConfig(path, content); err != nil {
+		return err
+	}
+	return updateTrusted(stateDir, path, hash(content))
+}
+
+// Deny revokes the trust in the ".kubeswitch" file
+func Deny(stateDir, path string) error {
+	return updateTrusted(stateDir, path, "")
+}
+
+// isTrusted checks if the content of the ".kubeswitch" file has been allowed
+func isTrusted(stateDir, path string, content []byte) (bool, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+
+	trusted, err := getTrusted(stateDir)
+	if err != nil {
+		return false, err
+	}
+	allowedHash, ok := trusted[absolutePath]
+	return ok && allowedHash == hash(content), nil
+}
+
+// getTrusted returns the hashes of the allowed ".kubeswitch" files by path.
+// Returns no files if the state file does not exist yet.
+func getTrusted(stateDir string) (map[string]string, error) {
+	path := filepath.Join(stateDir, trustFileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trusted files %q: %v", path, err)
+	}
+
+	trusted := types.TrustedDirectoryConfigs{}
+	if err := yaml.Unmarshal(content, &trusted); err != nil {
+		return nil, fmt.Errorf("could not unmarshal trusted files %q: %v", path, err)
+	}
+	return trusted.PathToHash, nil
+}
+
+// updateTrusted stores the hash of the allowed content of the ".kubeswitch" file. An empty hash removes the file.
+func updateTrusted(stateDir, path, contentHash string) error {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if err := permissions.MkdirAll(stateDir); err != nil {
+		return err
+	}
+
+	trustPath := filepath.Join(stateDir, trustFileName)
+	lock, err := filelock.Acquire(trustPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// the trusted files might have been changed by another terminal
+	trusted, err := getTrusted(stateDir)
+	if err != nil {
+		return err
+	}
+	if trusted == nil {
+		trusted = make(map[string]string, 1)
+	}
+
+	if len(contentHash) == 0 {
+		delete(trusted, absolutePath)
+	} else {
+		trusted[absolutePath] = contentHash
+	}
+
+	output, err := yaml.Marshal(types.TrustedDirectoryConfigs{PathToHash: trusted})
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(trustPath, output, permissions.FileMode)
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -15,23 +15,40 @@
 package clean
 
 import (
+	"errors"
 	"fmt"
-	"os"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
-	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-func Clean(stores []storetypes.KubeconfigStore) error {
+// Clean deletes the temporary kubeconfig files and flushes the caches of all stores.
+// If a policy is given, only the temporary kubeconfig files matching the policy are deleted and the caches are kept.
+// Temporary kubeconfig files given as keep (e.g. the targets of the symlinks) are never deleted.
+// If the temporary kubeconfig files used by other terminal sessions cannot be detected, they are only deleted with force.
+// In dry-run mode, the files are only printed.
+func Clean(stores []storetypes.KubeconfigStore, policy *types.CleanConfig, keep []string, force bool) error {
+	if policy != nil {
+		deleted, err := GarbageCollect(*policy, keep, force)
+		if errors.Is(err, ErrInUseUndetectable) {
+			return fmt.Errorf("%w. Use --force to delete them anyway", err)
+		}
+		printCleaned(deleted)
+		return err
+	}
+
 	// cleanup temporary kubeconfig files
-	tempDir := os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir)
-	files, _ := os.ReadDir(tempDir)
-	err := os.RemoveAll(tempDir)
-	if err != nil {
+	deleted, err := cleanTemporaryKubeconfigs(keep, force)
+	switch {
+	case errors.Is(err, ErrInUseUndetectable):
+		fmt.Printf("Skipped the temporary kubeconfig directory: %v. Use --force to delete the files anyway.\n", err)
+	case err != nil:
 		return err
+	default:
+		printCleaned(deleted)
 	}
-	fmt.Printf("Cleaned %d files from temporary kubeconfig directory.\n", len(files))
 
 	//cleanup the caches of the stores
 	for _, store := range stores {
@@ -39,6 +56,10 @@ func Clean(stores []storetypes.KubeconfigStore) error {
 		if !flushable {
 			continue
 		}
+		if dryrun.Enabled() {
+			dryrun.Printf("flush the cache of store %q", store.GetID())
+			continue
+		}
 		deleted, err := c.Flush()
 		fmt.Printf("Cleaned %d files of %s cache\n", deleted, store.GetID())
 		if err != nil {
@@ -47,3 +68,11 @@ func Clean(stores []storetypes.KubeconfigStore) error {
 	}
 	return nil
 }
+
+func printCleaned(deleted int) {
+	if dryrun.Enabled() {
+		dryrun.Printf("clean %d files from temporary kubeconfig directory.", deleted)
+		return
+	}
+	fmt.Printf("Cleaned %d files from temporary kubeconfig directory.\n", deleted)
+}
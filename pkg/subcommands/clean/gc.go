@@ -0,0 +1,235 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clean
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// ErrInUseUndetectable is returned if the temporary kubeconfig files used by other terminal sessions cannot be detected,
+// hence no temporary kubeconfig file can be deleted safely
+var ErrInUseUndetectable = errors.New("temporary kubeconfig files used by other terminal sessions cannot be detected on this system")
+
+// temporaryKubeconfig is a file in the temporary kubeconfig directory
+type temporaryKubeconfig struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// GarbageCollect deletes temporary kubeconfig files according to the given policy.
+// First, all files older than the configured max age are deleted.
+// Then, the oldest files are deleted until the directory does not exceed the configured max total size.
+// Temporary kubeconfig files that are referenced by a running process via the KUBECONFIG environment variable
+// or given as keep (e.g. the targets of the symlinks) are never deleted.
+// Returns ErrInUseUndetectable on systems without /proc, unless force is set.
+// Returns the number of deleted files.
+func GarbageCollect(policy types.CleanConfig, keep []string, force bool) (int, error) {
+	var maxTotalSize *int64
+	if policy.MaxTotalSize != nil {
+		quantity, err := resource.ParseQuantity(*policy.MaxTotalSize)
+		if err != nil {
+			return 0, fmt.Errorf("invalid max total size %q: %v", *policy.MaxTotalSize, err)
+		}
+		size := quantity.Value()
+		maxTotalSize = &size
+	}
+
+	tempDir := os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir)
+	kubeconfigs, err := listTemporaryKubeconfigs(tempDir)
+	if err != nil || len(kubeconfigs) == 0 {
+		return 0, err
+	}
+
+	inUse, err := getKubeconfigsInUse(tempDir, force)
+	if err != nil {
+		return 0, err
+	}
+	inUse.Insert(keep...)
+
+	// oldest first
+	sort.Slice(kubeconfigs, func(i, j int) bool {
+		return kubeconfigs[i].modTime.Before(kubeconfigs[j].modTime)
+	})
+
+	var (
+		deleted   int
+		totalSize int64
+		remaining []temporaryKubeconfig
+	)
+	for _, kubeconfig := range kubeconfigs {
+		if policy.MaxAge != nil && time.Since(kubeconfig.modTime) > *policy.MaxAge && !inUse.Has(kubeconfig.path) {
+			if err := removeTemporaryKubeconfig(kubeconfig.path); err != nil {
+				return deleted, err
+			}
+			deleted++
+			continue
+		}
+		totalSize += kubeconfig.size
+		remaining = append(remaining, kubeconfig)
+	}
+
+	if maxTotalSize == nil {
+		return deleted, nil
+	}
+
+	for _, kubeconfig := range remaining {
+		if totalSize <= *maxTotalSize {
+			break
+		}
+		if inUse.Has(kubeconfig.path) {
+			continue
+		}
+		if err := removeTemporaryKubeconfig(kubeconfig.path); err != nil {
+			return deleted, err
+		}
+		totalSize -= kubeconfig.size
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// cleanTemporaryKubeconfigs deletes all temporary kubeconfig files that are not in use by a running process or given as keep.
+// Returns ErrInUseUndetectable on systems without /proc, unless force is set.
+func cleanTemporaryKubeconfigs(keep []string, force bool) (int, error) {
+	tempDir := os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir)
+	kubeconfigs, err := listTemporaryKubeconfigs(tempDir)
+	if err != nil || len(kubeconfigs) == 0 {
+		return 0, err
+	}
+
+	inUse, err := getKubeconfigsInUse(tempDir, force)
+	if err != nil {
+		return 0, err
+	}
+	inUse.Insert(keep...)
+
+	deleted := 0
+	for _, kubeconfig := range kubeconfigs {
+		if inUse.Has(kubeconfig.path) {
+			continue
+		}
+		if err := removeTemporaryKubeconfig(kubeconfig.path); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// removeTemporaryKubeconfig deletes the temporary kubeconfig file. In dry-run mode, the file is only printed.
+func removeTemporaryKubeconfig(path string) error {
+	if dryrun.Enabled() {
+		dryrun.Printf("delete temporary kubeconfig %q", path)
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete temporary kubeconfig %q: %w", path, err)
+	}
+	return nil
+}
+
+func listTemporaryKubeconfigs(tempDir string) ([]temporaryKubeconfig, error) {
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var kubeconfigs []temporaryKubeconfig
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// deleted in the meantime
+			continue
+		}
+		kubeconfigs = append(kubeconfigs, temporaryKubeconfig{
+			path:    filepath.Join(tempDir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return kubeconfigs, nil
+}
+
+// CanDetectKubeconfigsInUse returns true if the environment of running processes can be read from /proc.
+// This is required to detect the temporary kubeconfig files used by other terminal sessions, e.g. it is not possible on macOS and Windows.
+func CanDetectKubeconfigsInUse() bool {
+	_, err := os.Stat("/proc/self/environ")
+	return err == nil
+}
+
+// getKubeconfigsInUse returns the temporary kubeconfig files referenced by the KUBECONFIG environment variable of running processes.
+// Reads the environment of all processes of the current user from /proc.
+// On systems without /proc, ErrInUseUndetectable is returned. If force is set, only the KUBECONFIG environment variable of the calling shell is considered instead.
+func getKubeconfigsInUse(tempDir string, force bool) (sets.Set[string], error) {
+	inUse := sets.New[string]()
+	addKubeconfigPaths(inUse, tempDir, os.Getenv("KUBECONFIG"))
+
+	if !CanDetectKubeconfigsInUse() {
+		if !force {
+			return nil, ErrInUseUndetectable
+		}
+		return inUse, nil
+	}
+
+	environFiles, err := filepath.Glob("/proc/[0-9]*/environ")
+	if err != nil {
+		return inUse, nil
+	}
+
+	for _, environFile := range environFiles {
+		// fails for processes of other users
+		environ, err := os.ReadFile(environFile)
+		if err != nil {
+			continue
+		}
+
+		for _, variable := range bytes.Split(environ, []byte{0}) {
+			if value, found := strings.CutPrefix(string(variable), "KUBECONFIG="); found {
+				addKubeconfigPaths(inUse, tempDir, value)
+			}
+		}
+	}
+	return inUse, nil
+}
+
+func addKubeconfigPaths(inUse sets.Set[string], tempDir, kubeconfigEnv string) {
+	for _, path := range filepath.SplitList(kubeconfigEnv) {
+		if strings.HasPrefix(path, tempDir) {
+			inUse.Insert(filepath.Clean(path))
+		}
+	}
+}
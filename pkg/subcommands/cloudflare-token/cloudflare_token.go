@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudflaretoken
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+)
+
+const (
+	// Command is the name of the switcher subcommand used as exec credential plugin for the Cloudflare Access login
+	Command = "cloudflare-token"
+	// DefaultCloudflared is the default executable of cloudflared
+	DefaultCloudflared = "cloudflared"
+
+	// execInfoEnv is the environment variable containing the cluster passed to exec credential plugins
+	execInfoEnv = "KUBERNETES_EXEC_INFO"
+	// expiryDelta is how long before its expiry a token is considered expired
+	expiryDelta = time.Minute
+)
+
+// Options configure the Cloudflare Access application to obtain the token for
+type Options struct {
+	// Application is the URL of the Cloudflare Access application. Taken from the cluster passed by the client if empty.
+	Application string
+	// Cloudflared is the executable of cloudflared
+	Cloudflared string
+}
+
+// execInfo is the subset of the ExecCredential passed to exec credential plugins in KUBERNETES_EXEC_INFO used by kubeswitch
+type execInfo struct {
+	Spec struct {
+		Cluster *struct {
+			Server string `json:"server"`
+		} `json:"cluster"`
+	} `json:"spec"`
+}
+
+// GetExecCredential returns the Cloudflare Access token of the application as ExecCredential JSON.
+// The token is cached by cloudflared. If there is no valid token, cloudflared logs in with the browser.
+func GetExecCredential(options Options) ([]byte, error) {
+	application, err := options.application()
+	if err != nil {
+		return nil, err
+	}
+	cloudflared := options.Cloudflared
+	if len(cloudflared) == 0 {
+		cloudflared = DefaultCloudflared
+	}
+
+	token, err := accessToken(cloudflared, application)
+	if err != nil || !isValid(token) {
+		// the login prints instructions and the login URL, which must not end up in the ExecCredential on stdout
+		login := exec.Command(cloudflared, "access", "login", application)
+		login.Stdin = os.Stdin
+		login.Stdout = os.Stderr
+		login.Stderr = os.Stderr
+		if err := login.Run(); err != nil {
+			return nil, fmt.Errorf("failed to log in to the Cloudflare Access application %q: %v", application, err)
+		}
+
+		if token, err = accessToken(cloudflared, application); err != nil {
+			return nil, err
+		}
+	}
+
+	return kubeconfigutil.UserCredentials{Token: token}.ExecCredential(util.TokenExpiry(token))
+}
+
+// application returns the configured application or the API server URL of the cluster passed by the client
+func (o Options) application() (string, error) {
+	if len(o.Application) > 0 {
+		return o.Application, nil
+	}
+
+	info := execInfo{}
+	if data := os.Getenv(execInfoEnv); len(data) > 0 {
+		if err := json.Unmarshal([]byte(data), &info); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %v", execInfoEnv, err)
+		}
+	}
+	if info.Spec.Cluster == nil || len(info.Spec.Cluster.Server) == 0 {
+		return "", fmt.Errorf("the flag --application is required if the client does not provide the cluster (provideClusterInfo)")
+	}
+	return info.Spec.Cluster.Server, nil
+}
+
+// accessToken returns the token of the application cached by cloudflared
+func accessToken(cloudflared, application string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(cloudflared, "access", "token", "-app="+application)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to get the Cloudflare Access token of application %q: %v: %s", application, err, strings.TrimSpace(stderr.String()))
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if len(token) == 0 {
+		return "", fmt.Errorf("cloudflared returned no Cloudflare Access token for application %q", application)
+	}
+	return token, nil
+}
+
+// isValid returns true if the token does not expire within the expiry delta
+func isValid(token string) bool {
+	expiry := util.TokenExpiry(token)
+	return expiry == nil || time.Until(*expiry) > expiryDelta
+}
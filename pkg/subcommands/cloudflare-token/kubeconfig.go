@@ -0,0 +1,68 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudflaretoken
+
+import (
+	"fmt"
+	"os"
+
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// ReplaceUsers replaces the credentials of all users in the kubeconfig with an exec credential plugin
+// calling the switcher binary, which obtains the Cloudflare Access token with cloudflared.
+// Without a configured application, the API server URL of the cluster is passed to the plugin as application.
+func ReplaceUsers(kubeconfig *kubeconfigutil.Kubeconfig, config *types.CloudflareAccess) error {
+	if config == nil {
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine path of the switcher binary: %v", err)
+	}
+
+	options := Options{}
+	if config.Application != nil {
+		options.Application = *config.Application
+	}
+	if config.Executable != nil {
+		options.Cloudflared = *config.Executable
+	}
+
+	return kubeconfig.ReplaceUserExecConfigs(func(_ string, _ *kubeconfigutil.ExecConfig) (*kubeconfigutil.ExecConfig, error) {
+		return &kubeconfigutil.ExecConfig{
+			APIVersion: kubeconfigutil.ExecCredentialAPIVersion,
+			Command:    executable,
+			Args:       options.args(),
+			// the user might have to log in
+			InteractiveMode:    "IfAvailable",
+			ProvideClusterInfo: len(options.Application) == 0,
+		}, nil
+	})
+}
+
+// args returns the arguments of the exec credential plugin
+func (o Options) args() []string {
+	args := []string{Command}
+	if len(o.Application) > 0 {
+		args = append(args, "--application", o.Application)
+	}
+	if len(o.Cloudflared) > 0 {
+		args = append(args, "--cloudflared", o.Cloudflared)
+	}
+	return args
+}
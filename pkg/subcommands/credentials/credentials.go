@@ -0,0 +1,141 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	keychain "github.com/danielfoehrkn/kubeswitch/pkg/credentials"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Set prompts for the secrets of the given kubeconfig store and writes them to the OS keychain.
+// If no fields are given, prompts for all secret fields of the store.
+func Set(config *types.Config, storeID string, fields []string) error {
+	store, err := getStore(config, storeID)
+	if err != nil {
+		return err
+	}
+
+	fields, err = getFields(*store, fields)
+	if err != nil {
+		return err
+	}
+
+	id := keychain.StoreID(*store)
+	reader := bufio.NewReader(os.Stdin)
+	for _, field := range fields {
+		secret, err := readSecret(reader, fmt.Sprintf("%s %s: ", id, field))
+		if err != nil {
+			return err
+		}
+
+		if len(secret) == 0 {
+			fmt.Fprintf(os.Stderr, "skipped %q: empty input\n", field)
+			continue
+		}
+
+		if err := keychain.Set(id, field, secret); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "stored %q of store %q in the OS keychain\n", field, id)
+	}
+	return nil
+}
+
+// Delete removes the secrets of the given kubeconfig store from the OS keychain.
+// If no fields are given, removes all secret fields of the store.
+func Delete(config *types.Config, storeID string, fields []string) error {
+	store, err := getStore(config, storeID)
+	if err != nil {
+		return err
+	}
+
+	fields, err = getFields(*store, fields)
+	if err != nil {
+		return err
+	}
+
+	id := keychain.StoreID(*store)
+	for _, field := range fields {
+		if err := keychain.Delete(id, field); err != nil && err != keychain.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// getStore returns the kubeconfig store from the SwitchConfig with the given ID.
+// The ID is either the configured ID of the store, its kind (if the store has no ID) or "<kind>.<id>".
+func getStore(config *types.Config, storeID string) (*types.KubeconfigStore, error) {
+	if config == nil {
+		return nil, fmt.Errorf("no SwitchConfig found")
+	}
+
+	var available []string
+	for i, store := range config.KubeconfigStores {
+		if switchconfig.StoreMatchesReference(store, storeID) {
+			return &config.KubeconfigStores[i], nil
+		}
+		available = append(available, keychain.StoreID(store))
+	}
+	return nil, fmt.Errorf("kubeconfig store %q not found in the SwitchConfig. Available stores: %s", storeID, strings.Join(available, ", "))
+}
+
+// getFields validates the requested fields against the secret fields of the kubeconfig store
+func getFields(store types.KubeconfigStore, fields []string) ([]string, error) {
+	secretFields, ok := keychain.SecretFields[store.Kind]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig store kind %q does not support secrets in the OS keychain", store.Kind)
+	}
+
+	if len(fields) == 0 {
+		return secretFields, nil
+	}
+
+	for _, field := range fields {
+		if !slices.Contains(secretFields, field) {
+			return nil, fmt.Errorf("unknown secret field %q for kubeconfig store kind %q. Valid fields are %q", field, store.Kind, secretFields)
+		}
+	}
+	return fields, nil
+}
+
+// readSecret reads a secret from stdin without echoing it if stdin is a terminal
+func readSecret(reader *bufio.Reader, prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		secret, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %v", err)
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && len(line) == 0 {
+		return "", fmt.Errorf("failed to read secret from stdin: %v", err)
+	}
+	return strings.TrimSpace(line), nil
+}
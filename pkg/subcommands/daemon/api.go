@@ -0,0 +1,74 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import "time"
+
+const (
+	// EnvSocket is the environment variable pointing the CLI to the Unix socket of a running daemon
+	EnvSocket = "SWITCH_DAEMON_SOCKET"
+	// SocketName is the name of the Unix socket in the state directory
+	SocketName = "daemon.sock"
+)
+
+// Context is a kubeconfig context discovered by the daemon
+type Context struct {
+	// Name is the context name including the store prefix
+	Name string `json:"name"`
+	// Alias is the alias of the context, if defined
+	Alias string `json:"alias,omitempty"`
+	// StoreKind is the kind of the kubeconfig store containing the context
+	StoreKind string `json:"storeKind"`
+	// StoreID is the ID of the kubeconfig store containing the context
+	StoreID string `json:"storeID"`
+	// Path is the path of the kubeconfig in the store
+	Path string `json:"path"`
+	// Tags are the tags of the kubeconfig in the store
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// SetContextRequest is the body of a set-context request
+type SetContextRequest struct {
+	// Context is the context name or alias to switch to
+	Context string `json:"context"`
+	// Exact requires the context to match exactly one discovered context.
+	// Otherwise, the first matching context is used.
+	Exact bool `json:"exact,omitempty"`
+	// StoreID optionally limits the context to the kubeconfig store with this ID
+	StoreID string `json:"storeID,omitempty"`
+}
+
+// SetContextResponse is the response of a set-context request
+type SetContextResponse struct {
+	// KubeconfigPath is the path to the temporary kubeconfig file of the context
+	KubeconfigPath string `json:"kubeconfigPath"`
+	// Context is the name of the context
+	Context string `json:"context"`
+}
+
+// Status is the response of a status request
+type Status struct {
+	// Contexts is the number of discovered contexts
+	Contexts int `json:"contexts"`
+	// LastRefresh is the time of the last completed search over all kubeconfig stores
+	LastRefresh time.Time `json:"lastRefresh"`
+	// Errors are the errors of the last search
+	Errors []string `json:"errors,omitempty"`
+}
+
+// errorResponse is returned for failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
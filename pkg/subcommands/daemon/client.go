@@ -0,0 +1,186 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+)
+
+// Client queries a running daemon via its Unix socket
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the daemon serving on the given Unix socket
+func NewClient(socketPath string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+			// set-context requests might retrieve the kubeconfig from a remote kubeconfig store
+			Timeout: time.Minute,
+		},
+	}
+}
+
+// Available checks if the daemon is running and responds
+func (c *Client) Available() bool {
+	_, err := c.Status()
+	return err == nil
+}
+
+// Status returns the status of the daemon
+func (c *Client) Status() (*Status, error) {
+	status := &Status{}
+	if err := c.do(http.MethodGet, "/v1/status", nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Refresh searches the kubeconfig stores again and returns the status of the daemon afterwards
+func (c *Client) Refresh() (*Status, error) {
+	status := &Status{}
+	if err := c.do(http.MethodPost, "/v1/refresh", nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ListContexts returns the contexts matching the wildcard pattern
+func (c *Client) ListContexts(pattern string) ([]Context, error) {
+	var contexts []Context
+	if err := c.do(http.MethodGet, "/v1/contexts?pattern="+url.QueryEscape(pattern), nil, &contexts); err != nil {
+		return nil, err
+	}
+	return contexts, nil
+}
+
+// SetContext writes a temporary kubeconfig for the context and returns its path and the context name.
+// Returns setcontext.ErrContextNotFound or setcontext.ErrContextAmbiguous like a local switch.
+func (c *Client) SetContext(contextName string, exact bool) (*string, *string, error) {
+	response, err := c.SetContextWithRequest(SetContextRequest{Context: contextName, Exact: exact})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &response.KubeconfigPath, &response.Context, nil
+}
+
+// SetContextWithRequest writes a temporary kubeconfig for the context of the request
+func (c *Client) SetContextWithRequest(request SetContextRequest) (*SetContextResponse, error) {
+	response := &SetContextResponse{}
+	if err := c.do(http.MethodPost, "/v1/set-context", request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetKubeconfig returns the kubeconfig containing the context from the kubeconfig store.
+// The store ID is optional and limits the context to the kubeconfig store with this ID.
+func (c *Client) GetKubeconfig(contextName, storeID string) ([]byte, error) {
+	query := url.Values{"context": {contextName}}
+	if len(storeID) > 0 {
+		query.Set("store", storeID)
+	}
+
+	response, err := c.request(http.MethodGet, "/v1/kubeconfig?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	return io.ReadAll(response.Body)
+}
+
+func (c *Client) do(method, path string, body interface{}, result interface{}) error {
+	response, err := c.request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// request sends the request to the daemon. The body of a successful response has to be closed by the caller.
+func (c *Client) request(method, path string, body interface{}) (*http.Response, error) {
+	var requestBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		requestBody = bytes.NewReader(buf)
+	}
+
+	// the host is ignored when dialing the Unix socket
+	request, err := http.NewRequest(method, "http://kubeswitch"+path, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+
+	if response.StatusCode == http.StatusOK {
+		return response, nil
+	}
+	defer response.Body.Close()
+
+	errResponse := errorResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&errResponse); err != nil {
+		return nil, fmt.Errorf("daemon returned status %d", response.StatusCode)
+	}
+
+	switch response.StatusCode {
+	case http.StatusNotFound:
+		return nil, &apiError{message: errResponse.Error, err: setcontext.ErrContextNotFound}
+	case http.StatusConflict:
+		return nil, &apiError{message: errResponse.Error, err: setcontext.ErrContextAmbiguous}
+	default:
+		return nil, fmt.Errorf("daemon returned an error: %s", errResponse.Error)
+	}
+}
+
+// apiError is an error returned by the daemon. It unwraps to the sentinel error of the status code
+// without repeating it in the message.
+type apiError struct {
+	message string
+	err     error
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
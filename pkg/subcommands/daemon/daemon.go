@@ -0,0 +1,465 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/becheran/wildmatch-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/refresh"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Options contains the configuration of the daemon
+type Options struct {
+	// SocketPath is the path of the Unix socket the API is served on
+	SocketPath string
+	// StateDirectory is the directory containing the internal state (e.g. the search index)
+	StateDirectory string
+	// RefreshInterval is the interval in which the kubeconfig stores are searched again
+	RefreshInterval time.Duration
+	// NoIndex disables reading from the index for the initial search
+	NoIndex bool
+	// RefreshIndex refreshes the search index of each kubeconfig store on its own schedule in the background.
+	// The contexts kept in memory are updated after each refresh.
+	RefreshIndex bool
+	Stores       []storetypes.KubeconfigStore
+	Config       *types.Config
+	// WatchConfig optionally watches the SwitchConfig until the context is cancelled and calls onReload with the
+	// kubeconfig stores and the configuration whenever it changes. The daemon then applies them without restarting.
+	WatchConfig func(ctx context.Context, onReload func(stores []storetypes.KubeconfigStore, config *types.Config))
+}
+
+type daemon struct {
+	options Options
+	log     *logrus.Entry
+
+	// refreshMutex serializes searches over the kubeconfig stores
+	refreshMutex sync.Mutex
+
+	mutex  sync.RWMutex
+	stores []storetypes.KubeconfigStore
+	config *types.Config
+	// cancelRefreshIndex stops refreshing the search index of the previous kubeconfig stores after a reload
+	cancelRefreshIndex context.CancelFunc
+	contexts           []pkg.DiscoveredContext
+	errors             []string
+	lastRefresh        time.Time
+}
+
+// Run searches the kubeconfig stores, keeps the discovered contexts in memory and serves them on a Unix socket
+// until the process receives SIGINT or SIGTERM.
+// The kubeconfig stores are searched again in the configured refresh interval to keep the contexts and the search index warm.
+func Run(options Options) error {
+	d := &daemon{
+		options: options,
+		log:     logging.New().WithField("component", "daemon"),
+		stores:  options.Stores,
+		config:  options.Config,
+	}
+
+	listener, err := listen(options.SocketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(options.SocketPath)
+
+	if err := d.refresh(options.NoIndex); err != nil {
+		listener.Close()
+		return err
+	}
+	d.log.Infof("Discovered %d contexts. Serving on %s", len(d.contexts), options.SocketPath)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	go d.refreshPeriodically(ctx)
+	if options.RefreshIndex {
+		d.startRefreshIndex(ctx)
+	}
+	if options.WatchConfig != nil {
+		go options.WatchConfig(ctx, func(stores []storetypes.KubeconfigStore, config *types.Config) {
+			d.reload(ctx, stores, config)
+		})
+	}
+
+	server := &http.Server{Handler: d.handler()}
+	go func() {
+		<-ctx.Done()
+		d.log.Info("Shutting down")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listen creates the Unix socket. Fails if another daemon is already serving on the socket.
+func listen(socketPath string) (net.Listener, error) {
+	if err := permissions.MkdirAll(filepath.Dir(socketPath)); err != nil {
+		return nil, fmt.Errorf("failed to create directory for socket: %w", err)
+	}
+
+	if _, err := os.Stat(socketPath); err == nil {
+		if NewClient(socketPath).Available() {
+			return nil, fmt.Errorf("daemon is already running on socket %s", socketPath)
+		}
+		// remove stale socket of a daemon that did not shut down gracefully
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", socketPath, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on socket %s: %w", socketPath, err)
+	}
+
+	// the API exposes kubeconfigs including credentials. Only the current user may connect.
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions of socket %s: %w", socketPath, err)
+	}
+	return listener, nil
+}
+
+func (d *daemon) refreshPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(d.options.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.refresh(true); err != nil {
+				d.log.Warnf("failed to refresh contexts: %v", err)
+			}
+		}
+	}
+}
+
+// reload replaces the kubeconfig stores and the configuration and searches the kubeconfig stores again
+func (d *daemon) reload(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config) {
+	d.mutex.Lock()
+	d.stores, d.config = stores, config
+	d.mutex.Unlock()
+
+	if d.options.RefreshIndex {
+		d.startRefreshIndex(ctx)
+	}
+
+	if err := d.refresh(false); err != nil {
+		d.log.Warnf("failed to refresh contexts after reloading the SwitchConfig: %v", err)
+		return
+	}
+	d.log.Infof("Reloaded the SwitchConfig. Discovered %d contexts", len(d.contextsOfStore("")))
+}
+
+// startRefreshIndex refreshes the search index of the current kubeconfig stores in the background
+// and stops refreshing the index of the previous kubeconfig stores
+func (d *daemon) startRefreshIndex(ctx context.Context) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.cancelRefreshIndex != nil {
+		d.cancelRefreshIndex()
+	}
+	refreshCtx, cancel := context.WithCancel(ctx)
+	d.cancelRefreshIndex = cancel
+	go d.refreshIndex(refreshCtx, d.stores, d.config)
+}
+
+// refreshIndex refreshes the search index of each kubeconfig store on its own schedule
+// and reads the contexts kept in memory from the refreshed index
+func (d *daemon) refreshIndex(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config) {
+	err := refresh.Watch(ctx, stores, config, d.options.StateDirectory, func(result refresh.Result) {
+		if result.Err != nil {
+			d.log.Warnf("failed to refresh the index of store %s: %v", result.Store.GetID(), result.Err)
+			return
+		}
+		d.log.Debugf("Refreshed the index of store %s: %d contexts in %s", result.Store.GetID(), result.Contexts, result.Duration)
+		if len(result.PrunedAliases) > 0 {
+			d.log.Infof("Removed the aliases %s of removed contexts of store %s", strings.Join(result.PrunedAliases, ", "), result.Store.GetID())
+		}
+
+		if err := d.refresh(false); err != nil {
+			d.log.Warnf("failed to refresh contexts: %v", err)
+		}
+	})
+	if err != nil {
+		d.log.Warnf("not refreshing the search index in the background: %v", err)
+	}
+}
+
+// refresh searches all kubeconfig stores and replaces the contexts kept in memory
+func (d *daemon) refresh(noIndex bool) error {
+	d.refreshMutex.Lock()
+	defer d.refreshMutex.Unlock()
+
+	d.mutex.RLock()
+	stores, config := d.stores, d.config
+	d.mutex.RUnlock()
+
+	d.log.Debug("Searching kubeconfig stores")
+	c, err := pkg.DoSearch(stores, config, d.options.StateDirectory, noIndex)
+	if err != nil {
+		return err
+	}
+
+	var (
+		contexts []pkg.DiscoveredContext
+		errs     []string
+	)
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			d.log.Warnf("error returned from search: %v", discoveredContext.Error)
+			errs = append(errs, discoveredContext.Error.Error())
+			continue
+		}
+		contexts = append(contexts, discoveredContext)
+	}
+
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.contexts = contexts
+	d.errors = errs
+	d.lastRefresh = time.Now()
+	return nil
+}
+
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", d.handleStatus)
+	mux.HandleFunc("POST /v1/refresh", d.handleRefresh)
+	mux.HandleFunc("GET /v1/contexts", d.handleListContexts)
+	mux.HandleFunc("GET /v1/search", d.handleSearch)
+	mux.HandleFunc("GET /v1/kubeconfig", d.handleGetKubeconfig)
+	mux.HandleFunc("POST /v1/set-context", d.handleSetContext)
+	return mux
+}
+
+func (d *daemon) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	writeJSON(w, http.StatusOK, Status{
+		Contexts:    len(d.contexts),
+		LastRefresh: d.lastRefresh,
+		Errors:      d.errors,
+	})
+}
+
+func (d *daemon) handleRefresh(w http.ResponseWriter, _ *http.Request) {
+	if err := d.refresh(true); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	d.handleStatus(w, nil)
+}
+
+// handleListContexts returns the contexts matching the wildcard pattern of the query parameter "pattern" (defaults to all contexts)
+func (d *daemon) handleListContexts(w http.ResponseWriter, r *http.Request) {
+	pattern := r.URL.Query().Get("pattern")
+	if len(pattern) == 0 {
+		pattern = "*"
+	}
+	m := wildmatch.NewWildMatch(pattern)
+
+	writeJSON(w, http.StatusOK, d.filter(func(name string) bool {
+		return m.IsMatch(name)
+	}))
+}
+
+// handleSearch returns the contexts containing all whitespace separated terms of the query parameter "query" (case-insensitive)
+func (d *daemon) handleSearch(w http.ResponseWriter, r *http.Request) {
+	terms := strings.Fields(strings.ToLower(r.URL.Query().Get("query")))
+
+	writeJSON(w, http.StatusOK, d.filter(func(name string) bool {
+		name = strings.ToLower(name)
+		for _, term := range terms {
+			if !strings.Contains(name, term) {
+				return false
+			}
+		}
+		return true
+	}))
+}
+
+// handleGetKubeconfig returns the kubeconfig of the context of the query parameter "context", materialized like when exporting the context.
+// The optional query parameter "store" limits the context to the kubeconfig store with this ID.
+func (d *daemon) handleGetKubeconfig(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("context")
+	if len(name) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("query parameter \"context\" is required"))
+		return
+	}
+
+	discoveredContext, ok := d.find(name, r.URL.Query().Get("store"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("context with name %q not found: %w", name, setcontext.ErrContextNotFound))
+		return
+	}
+
+	d.mutex.RLock()
+	config := d.config
+	d.mutex.RUnlock()
+
+	kubeconfig, err := pkg.MaterializeKubeconfig(config, d.options.StateDirectory, pkg.MaterializeOptions{
+		Store:       *discoveredContext.Store,
+		Path:        discoveredContext.Path,
+		Tags:        discoveredContext.Tags,
+		ContextName: discoveredContext.Name,
+		Alias:       discoveredContext.Alias,
+		Portable:    true,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	data, err := kubeconfig.GetBytes()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(data)
+}
+
+// handleSetContext writes a temporary kubeconfig for the requested context and returns its path
+func (d *daemon) handleSetContext(w http.ResponseWriter, r *http.Request) {
+	request := SetContextRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	contexts := d.contextsOfStore(request.StoreID)
+	d.mutex.RLock()
+	config := d.config
+	d.mutex.RUnlock()
+
+	setContext := setcontext.SetContextFromResults
+	if request.Exact {
+		setContext = setcontext.SetContextExactFromResults
+	}
+
+	kubeconfigPath, contextName, err := setContext(request.Context, contexts, config, d.options.StateDirectory, true)
+	switch {
+	case errors.Is(err, setcontext.ErrContextNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, setcontext.ErrContextAmbiguous):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, SetContextResponse{
+			KubeconfigPath: *kubeconfigPath,
+			Context:        *contextName,
+		})
+	}
+}
+
+// filter returns the contexts whose name or alias matches
+func (d *daemon) filter(matches func(name string) bool) []Context {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	result := []Context{}
+	for _, discoveredContext := range d.contexts {
+		if !matches(discoveredContext.Name) && (len(discoveredContext.Alias) == 0 || !matches(discoveredContext.Alias)) {
+			continue
+		}
+
+		store := *discoveredContext.Store
+		result = append(result, Context{
+			Name:      discoveredContext.Name,
+			Alias:     discoveredContext.Alias,
+			StoreKind: string(store.GetKind()),
+			StoreID:   store.GetID(),
+			Path:      discoveredContext.Path,
+			Tags:      discoveredContext.Tags,
+		})
+	}
+	return result
+}
+
+// find returns the context with the given name or alias
+func (d *daemon) find(name, storeID string) (pkg.DiscoveredContext, bool) {
+	for _, discoveredContext := range d.contextsOfStore(storeID) {
+		if discoveredContext.Name == name || discoveredContext.Alias == name {
+			return discoveredContext, true
+		}
+	}
+	return pkg.DiscoveredContext{}, false
+}
+
+// contextsOfStore returns the contexts of the kubeconfig store with the given ID or all contexts if the ID is empty
+func (d *daemon) contextsOfStore(storeID string) []pkg.DiscoveredContext {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if len(storeID) == 0 {
+		return d.contexts
+	}
+
+	var contexts []pkg.DiscoveredContext
+	for _, discoveredContext := range d.contexts {
+		if (*discoveredContext.Store).GetID() == storeID {
+			contexts = append(contexts, discoveredContext)
+		}
+	}
+	return contexts
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultPort is the local port of the dashboard if not configured otherwise
+	defaultPort = 7777
+	// shutdownTimeout is how long the dashboard may take to exit after being interrupted before it is killed
+	shutdownTimeout = 10 * time.Second
+)
+
+var logger = logging.New()
+
+// commandData is passed to the template of a custom dashboard command
+type commandData struct {
+	// Kubeconfig is the path of the kubeconfig the dashboard is pointed at
+	Kubeconfig string
+	// Context is the name (or alias) of the context
+	Context string
+	// Port is the local port the dashboard listens on
+	Port int
+}
+
+// Start starts the configured dashboard pointed at the kubeconfig and blocks until the dashboard exits.
+// If the command is interrupted, the dashboard is interrupted as well and killed if it does not exit in time.
+func Start(config *types.DashboardConfig, kubeconfigPath, contextName string, openBrowser bool) error {
+	if config == nil {
+		config = &types.DashboardConfig{}
+	}
+
+	port := defaultPort
+	if config.Port != nil {
+		port = *config.Port
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cmd, url, err := command(ctx, *config, kubeconfigPath, contextName, port, openBrowser)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", kubeconfigPath))
+	cmd.Stdin = os.Stdin
+	// stdout is reserved for the output captured by the shell function
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Cancel = func() error {
+		if runtime.GOOS == "windows" {
+			// interrupting a process is not implemented on Windows
+			return cmd.Process.Kill()
+		}
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = shutdownTimeout
+
+	if len(url) > 0 {
+		logger.Infof("Starting the dashboard for context %q on %s. Press Ctrl+C to stop it.", contextName, url)
+	} else {
+		logger.Infof("Starting the dashboard for context %q. Press Ctrl+C to stop it.", contextName)
+	}
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		// the dashboard has been stopped on purpose
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("dashboard exited: %w", err)
+	}
+	return nil
+}
+
+// command returns the command starting the dashboard and the URL it is served on, if known
+func command(ctx context.Context, config types.DashboardConfig, kubeconfigPath, contextName string, port int, openBrowser bool) (*exec.Cmd, string, error) {
+	if config.Command != nil {
+		rendered, err := switchconfig.ExpandString(*config.Command, commandData{
+			Kubeconfig: kubeconfigPath,
+			Context:    contextName,
+			Port:       port,
+		}, nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to render dashboard command: %v", err)
+		}
+		if runtime.GOOS == "windows" {
+			return exec.CommandContext(ctx, "cmd", "/C", rendered), "", nil
+		}
+		return exec.CommandContext(ctx, "sh", "-c", rendered), "", nil
+	}
+
+	tool := types.DashboardToolHeadlamp
+	if config.Tool != nil {
+		tool = *config.Tool
+	}
+	if !types.ValidDashboardTools.Has(string(tool)) {
+		return nil, "", fmt.Errorf("dashboard %q is unknown. Valid dashboards are %q", tool, types.ValidDashboardTools.List())
+	}
+
+	binary, err := exec.LookPath(string(tool))
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, "", fmt.Errorf("dashboard %q is not installed or not in the PATH", tool)
+		}
+		return nil, "", err
+	}
+
+	switch tool {
+	case types.DashboardToolHeadlamp:
+		// the Headlamp desktop application reads the kubeconfig from KUBECONFIG and opens its own window
+		return exec.CommandContext(ctx, binary), "", nil
+	case types.DashboardToolOctant:
+		listenerAddress := fmt.Sprintf("127.0.0.1:%d", port)
+		args := []string{"--kubeconfig", kubeconfigPath, "--listener-addr", listenerAddress}
+		if !openBrowser {
+			args = append(args, "--disable-open-browser")
+		}
+		return exec.CommandContext(ctx, binary, args...), fmt.Sprintf("http://%s", listenerAddress), nil
+	}
+	return nil, "", fmt.Errorf("dashboard %q is unknown", tool)
+}
@@ -12,28 +12,205 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package setcontext
+package deletecontext
 
 import (
 	"fmt"
 	"os"
+	"sort"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-func DeleteContext(desiredContext string) error {
-	kcPath := os.Getenv("KUBECONFIG")
-	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kcPath)
+var logger = logging.New()
+
+// maxBackups is the number of backups kept per kubeconfig file.
+// The latest backup has the suffix ".bak", older backups are rotated to ".bak.1" and so on.
+const maxBackups = 5
+
+// DeleteContext removes the desired context from the search index of its kubeconfig store and
+// evicts the kubeconfig of the context from the cache of the store.
+// If fromFile is set, the context is also removed from the kubeconfig file of a filesystem store.
+// The original kubeconfig file is kept as a backup with the suffix ".bak". Previous backups are rotated.
+// Without desired context, the contexts to delete are selected interactively.
+func DeleteContext(desiredContext string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, fromFile bool) error {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
 	if err != nil {
 		return err
 	}
-	if err := kubeconfig.RemoveContext(desiredContext); err != nil {
+
+	var discoveredContexts []pkg.DiscoveredContext
+	for discoveredContext := range *c {
+		discoveredContexts = append(discoveredContexts, discoveredContext)
+	}
+
+	if len(desiredContext) > 0 {
+		return deleteContext(desiredContext, discoveredContexts, stateDir, fromFile)
+	}
+
+	selected, err := pkg.SelectContexts(contextNames(discoveredContexts), "Select the contexts to delete with Tab")
+	if err != nil {
+		return err
+	}
+	for _, name := range selected {
+		if err := deleteContext(name, discoveredContexts, stateDir, fromFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contextNames returns the sorted names (or aliases) of the discovered contexts
+func contextNames(discoveredContexts []pkg.DiscoveredContext) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, discoveredContext := range discoveredContexts {
+		if discoveredContext.Error != nil || discoveredContext.Store == nil {
+			continue
+		}
+		name := discoveredContext.Name
+		if len(discoveredContext.Alias) > 0 {
+			name = discoveredContext.Alias
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func deleteContext(desiredContext string, discoveredContexts []pkg.DiscoveredContext, stateDir string, fromFile bool) error {
+	match, err := setcontext.FindContextExact(desiredContext, discoveredContexts)
+	if err != nil {
+		return err
+	}
+
+	store := *match.Store
+	if fromFile && store.GetKind() != types.StoreKindFilesystem {
+		return fmt.Errorf("context %q is stored in store %q of kind %q: only contexts of filesystem stores can be removed from their kubeconfig file", desiredContext, store.GetID(), store.GetKind())
+	}
+
+	if dryrun.Enabled() {
+		printDeleteContext(*match, fromFile)
+		return nil
+	}
+
+	searchIndex, err := index.New(logger.WithField("store", store.GetID()), store.GetKind(), stateDir, store.GetID())
+	if err != nil {
+		return err
+	}
+
+	removed, err := searchIndex.RemoveContext(match.Name)
+	if err != nil {
+		return fmt.Errorf("failed to remove context %q from the index of store %q: %v", match.Name, store.GetID(), err)
+	}
+	if removed {
+		fmt.Printf("Removed context %q from the index of store %q\n", match.Name, store.GetID())
+	}
+
+	if evictable, ok := store.(cache.Evictable); ok {
+		evicted, err := evictable.Evict(match.Path)
+		if err != nil {
+			return err
+		}
+		if evicted {
+			fmt.Printf("Removed kubeconfig %q from the cache of store %q\n", match.Path, store.GetID())
+		}
+	}
+
+	if !fromFile {
+		if store.GetKind() == types.StoreKindFilesystem {
+			fmt.Printf("Context %q is still contained in kubeconfig file %q. Use --from-file to remove it from the file.\n", match.Name, match.Path)
+		}
+		return nil
+	}
+
+	backupPath, err := removeContextFromFile(match.Path, setcontext.ContextWithoutPrefix(*match))
+	if err != nil {
 		return err
 	}
+	fmt.Printf("Removed context %q from kubeconfig file %q (backup: %q)\n", match.Name, match.Path, backupPath)
+	return nil
+}
+
+// printDeleteContext prints the changes deleting the context would make
+func printDeleteContext(match pkg.DiscoveredContext, fromFile bool) {
+	store := *match.Store
+	dryrun.Printf("remove context %q from the index of store %q", match.Name, store.GetID())
+	if _, ok := store.(cache.Evictable); ok {
+		dryrun.Printf("remove kubeconfig %q from the cache of store %q", match.Path, store.GetID())
+	}
+	if fromFile {
+		dryrun.Printf("write backup %q of kubeconfig file %q", backupPath(match.Path, 0), match.Path)
+		dryrun.Printf("remove context %q from kubeconfig file %q", setcontext.ContextWithoutPrefix(match), match.Path)
+	}
+}
+
+// removeContextFromFile writes a backup of the kubeconfig file and removes the context from the file.
+// Returns the path of the backup.
+func removeContextFromFile(path string, contextName string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubeconfig file: %v", err)
+	}
+
+	if err := rotateBackups(path); err != nil {
+		return "", fmt.Errorf("failed to rotate the backups of kubeconfig file: %v", err)
+	}
+	backup := backupPath(path, 0)
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write backup of kubeconfig file: %v", err)
+	}
+
+	kubeconfig, err := kubeconfigutil.New(data, path, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse kubeconfig file: %v", err)
+	}
+
+	if err := kubeconfig.RemoveContext(contextName); err != nil {
+		return "", err
+	}
 
 	if _, err := kubeconfig.WriteKubeconfigFile(); err != nil {
-		return fmt.Errorf("failed to write kubeconfig file: %v", err)
+		return "", fmt.Errorf("failed to write kubeconfig file: %v", err)
+	}
+	return backup, nil
+}
+
+// rotateBackups shifts the existing backups of the kubeconfig file by one, so that the latest backup is not overwritten.
+// The oldest backup is deleted.
+func rotateBackups(path string) error {
+	if err := os.Remove(backupPath(path, maxBackups-1)); err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
+	for i := maxBackups - 2; i >= 0; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	return nil
 }
+
+// backupPath returns the path of the i-th backup of the kubeconfig file, the latest backup being 0
+func backupPath(path string, i int) string {
+	if i == 0 {
+		return fmt.Sprintf("%s.bak", path)
+	}
+	return fmt.Sprintf("%s.bak.%d", path, i)
+}
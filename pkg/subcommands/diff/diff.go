@@ -0,0 +1,279 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var logger = logging.New()
+
+const redacted = "<redacted>"
+
+// secretPattern matches names of exec arguments and environment variables whose values are redacted
+var secretPattern = regexp.MustCompile(`(?i)(token|secret|password|passwd|credential|key)`)
+
+// field is a setting of the effective configuration of a context
+type field struct {
+	name  string
+	value string
+}
+
+// Diff fetches the kubeconfigs of both contexts like when switching to them and prints the differences of
+// their effective configuration: server, certificate authority, namespace and authentication.
+// Secrets like tokens and client keys are never printed, tokens are compared by a short fingerprint.
+func Diff(contextA, contextB string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	var discoveredContexts []pkg.DiscoveredContext
+	for discoveredContext := range *c {
+		discoveredContexts = append(discoveredContexts, discoveredContext)
+	}
+
+	fieldsA, err := effectiveConfig(contextA, discoveredContexts, config, stateDir)
+	if err != nil {
+		return err
+	}
+	fieldsB, err := effectiveConfig(contextB, discoveredContexts, config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", contextA, contextB)
+	fmt.Print(format(fieldsA, fieldsB))
+	return nil
+}
+
+// effectiveConfig writes the temporary kubeconfig of the context and returns the settings of its current context
+func effectiveConfig(contextName string, discoveredContexts []pkg.DiscoveredContext, config *types.Config, stateDir string) ([]field, error) {
+	match, err := setcontext.FindContextExact(contextName, discoveredContexts)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfigPath, _, err := setcontext.SetContextExactFromResults(contextName, discoveredContexts, config, stateDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig of context %q: %v", contextName, err)
+	}
+	defer func() {
+		if err := os.Remove(*kubeconfigPath); err != nil {
+			logger.Debugf("failed to remove temporary kubeconfig %q: %v", *kubeconfigPath, err)
+		}
+	}()
+
+	kubeconfig, err := clientcmd.LoadFromFile(*kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig of context %q: %v", contextName, err)
+	}
+
+	store := *match.Store
+	return append([]field{{"store", fmt.Sprintf("%s (%s)", store.GetID(), store.GetKind())}}, fields(kubeconfig)...), nil
+}
+
+// fields returns the settings of the current context of the kubeconfig
+func fields(kubeconfig *clientcmdapi.Config) []field {
+	context, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]
+	if !ok {
+		return []field{{"context", fmt.Sprintf("%q not found", kubeconfig.CurrentContext)}}
+	}
+
+	namespace := context.Namespace
+	if len(namespace) == 0 {
+		namespace = "default"
+	}
+
+	result := []field{{"namespace", namespace}}
+	if cluster, ok := kubeconfig.Clusters[context.Cluster]; ok {
+		result = append(result,
+			field{"server", cluster.Server},
+			field{"tls-server-name", cluster.TLSServerName},
+			field{"proxy-url", cluster.ProxyURL},
+			field{"insecure-skip-tls-verify", fmt.Sprintf("%t", cluster.InsecureSkipTLSVerify)},
+			field{"certificate-authority", certificate(cluster.CertificateAuthorityData, cluster.CertificateAuthority, "system trust store")},
+		)
+	} else {
+		result = append(result, field{"cluster", fmt.Sprintf("%q not found", context.Cluster)})
+	}
+
+	authInfo, ok := kubeconfig.AuthInfos[context.AuthInfo]
+	if !ok {
+		return append(result, field{"auth", "none"})
+	}
+
+	result = append(result,
+		field{"auth", authMethod(authInfo)},
+		field{"client-certificate", certificate(authInfo.ClientCertificateData, authInfo.ClientCertificate, "")},
+		field{"token", secret(authInfo.Token, authInfo.TokenFile)},
+		field{"username", authInfo.Username},
+		field{"impersonate", authInfo.Impersonate},
+		field{"impersonate-groups", strings.Join(authInfo.ImpersonateGroups, ", ")},
+	)
+	if authInfo.AuthProvider != nil {
+		result = append(result, field{"auth-provider", authInfo.AuthProvider.Name})
+	}
+	if exec := authInfo.Exec; exec != nil {
+		var env []string
+		for _, e := range exec.Env {
+			value := e.Value
+			if secretPattern.MatchString(e.Name) {
+				value = redacted
+			}
+			env = append(env, fmt.Sprintf("%s=%s", e.Name, value))
+		}
+		result = append(result,
+			field{"exec-api-version", exec.APIVersion},
+			field{"exec-command", exec.Command},
+			field{"exec-args", strings.Join(redactArgs(exec.Args), " ")},
+			field{"exec-env", strings.Join(env, " ")},
+		)
+	}
+	return result
+}
+
+// authMethod describes how the user authenticates against the API server
+func authMethod(authInfo *clientcmdapi.AuthInfo) string {
+	var methods []string
+	if authInfo.Exec != nil {
+		methods = append(methods, "exec plugin")
+	}
+	if authInfo.AuthProvider != nil {
+		methods = append(methods, "auth provider")
+	}
+	if len(authInfo.ClientCertificateData) > 0 || len(authInfo.ClientCertificate) > 0 {
+		methods = append(methods, "client certificate")
+	}
+	if len(authInfo.Token) > 0 || len(authInfo.TokenFile) > 0 {
+		methods = append(methods, "token")
+	}
+	if len(authInfo.Username) > 0 {
+		methods = append(methods, "basic auth")
+	}
+	if len(methods) == 0 {
+		return "none"
+	}
+	return strings.Join(methods, ", ")
+}
+
+// certificate describes the PEM encoded certificate by its subject and fingerprint, or by its file
+func certificate(data []byte, file, fallback string) string {
+	if len(data) == 0 {
+		if len(file) > 0 {
+			return file
+		}
+		return fallback
+	}
+
+	fingerprint := sha256.Sum256(data)
+	description := "sha256:" + hex.EncodeToString(fingerprint[:8])
+	if block, _ := pem.Decode(data); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			description = fmt.Sprintf("%s (%s, expires %s)", description, cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		}
+	}
+	return description
+}
+
+// secret shows a short fingerprint of the secret, so that different secrets can be told apart without revealing them,
+// or its file
+func secret(value, file string) string {
+	if len(value) > 0 {
+		fingerprint := sha256.Sum256([]byte(value))
+		return fmt.Sprintf("%s (sha256:%s)", redacted, hex.EncodeToString(fingerprint[:4]))
+	}
+	return file
+}
+
+// redactArgs redacts the values of arguments named like secrets, e.g. "--token=abc" or "--client-secret abc"
+func redactArgs(args []string) []string {
+	result := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		result[i] = arg
+		if !strings.HasPrefix(arg, "-") || !secretPattern.MatchString(arg) {
+			continue
+		}
+
+		if name, _, ok := strings.Cut(arg, "="); ok {
+			result[i] = name + "=" + redacted
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			result[i] = redacted
+		}
+	}
+	return result
+}
+
+// format shows the fields of both contexts in the unified diff format. Fields unset for both contexts are omitted.
+func format(fieldsA, fieldsB []field) string {
+	valuesB := make(map[string]string, len(fieldsB))
+	for _, f := range fieldsB {
+		valuesB[f.name] = f.value
+	}
+
+	var (
+		out       strings.Builder
+		seen      = make(map[string]bool, len(fieldsA))
+		different bool
+	)
+	write := func(name, valueA, valueB string) {
+		switch {
+		case valueA == valueB && len(valueA) == 0:
+		case valueA == valueB:
+			fmt.Fprintf(&out, "  %s: %s\n", name, valueA)
+		default:
+			different = true
+			if len(valueA) > 0 {
+				fmt.Fprintf(&out, "- %s: %s\n", name, valueA)
+			}
+			if len(valueB) > 0 {
+				fmt.Fprintf(&out, "+ %s: %s\n", name, valueB)
+			}
+		}
+	}
+
+	for _, f := range fieldsA {
+		seen[f.name] = true
+		write(f.name, f.value, valuesB[f.name])
+	}
+	for _, f := range fieldsB {
+		if !seen[f.name] {
+			write(f.name, "", f.value)
+		}
+	}
+
+	if !different {
+		out.WriteString("The effective configuration of both contexts is identical.\n")
+	}
+	return out.String()
+}
@@ -0,0 +1,334 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/config/schema"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// storeTimeout is the maximum duration to wait for a kubeconfig store to respond
+	storeTimeout = 20 * time.Second
+	// credentialExpiryWarning is the duration before the expiry of credentials that causes a warning
+	credentialExpiryWarning = time.Hour
+	// maxTemporaryKubeconfigs is the number of temporary kubeconfig files that causes a warning
+	maxTemporaryKubeconfigs = 1000
+)
+
+type status string
+
+const (
+	statusOK      status = "✓"
+	statusWarning status = "!"
+	statusFailed  status = "✗"
+)
+
+// result is the result of a single check
+type result struct {
+	name    string
+	status  status
+	message string
+	// fix is an actionable suggestion how to fix a warning or failure
+	fix string
+}
+
+// Options contains the configuration of the diagnostics
+type Options struct {
+	// ConfigPath is the path to the user SwitchConfig
+	ConfigPath string
+	// StateDirectory is the directory containing the internal state (e.g. the search index)
+	StateDirectory string
+	// Initialize loads the SwitchConfig and creates the kubeconfig stores
+	Initialize func() ([]storetypes.KubeconfigStore, *types.Config, error)
+}
+
+// Doctor checks the installation and configuration of kubeswitch and prints actionable fixes for problems.
+// Fails if at least one check failed.
+func Doctor(options Options) error {
+	var results []result
+	report := func(r result) {
+		printResult(r)
+		results = append(results, r)
+	}
+
+	report(checkBinary())
+	report(checkShellIntegration())
+	for _, path := range []string{switchconfig.SystemConfigPath(), options.ConfigPath} {
+		if _, err := os.Stat(path); err == nil {
+			report(checkConfig(path))
+		}
+	}
+	report(checkTemporaryKubeconfigDirectory())
+	report(checkStateDirectory(options.StateDirectory))
+
+	stores, config, err := options.Initialize()
+	if err != nil {
+		report(result{name: "kubeconfig stores", status: statusFailed, message: err.Error(), fix: "fix the SwitchConfig or the credentials of the kubeconfig stores"})
+	} else {
+		for _, store := range stores {
+			report(checkStore(store))
+			if r := checkCredentials(store); r != nil {
+				report(*r)
+			}
+			if r := checkIndex(store, config, options.StateDirectory); r != nil {
+				report(*r)
+			}
+		}
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.status == statusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func printResult(r result) {
+	fmt.Fprintf(os.Stdout, "[%s] %s: %s\n", r.status, r.name, r.message)
+	if r.status != statusOK && len(r.fix) > 0 {
+		fmt.Fprintf(os.Stdout, "    fix: %s\n", r.fix)
+	}
+}
+
+func checkBinary() result {
+	path, err := exec.LookPath("switcher")
+	if err != nil {
+		return result{name: "switcher binary", status: statusWarning, message: "the switcher binary is not on the PATH, but the shell integration calls it", fix: "move the switcher binary to a directory on the PATH"}
+	}
+	return result{name: "switcher binary", status: statusOK, message: path}
+}
+
+// checkShellIntegration checks if the shell configuration file of the current shell sources the shell integration (which contains the completion as well)
+func checkShellIntegration() result {
+	name := "shell integration"
+	shell := filepath.Base(os.Getenv("SHELL"))
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return result{name: name, status: statusWarning, message: fmt.Sprintf("cannot determine the home directory: %v", err)}
+	}
+
+	var rcFiles []string
+	var fix string
+	switch shell {
+	case "zsh":
+		rcFiles = []string{filepath.Join(home, ".zshrc")}
+		fix = "echo 'source <(switcher init zsh)' >> ~/.zshrc"
+	case "bash":
+		rcFiles = []string{filepath.Join(home, ".bashrc"), filepath.Join(home, ".bash_profile")}
+		fix = "echo 'source <(switcher init bash)' >> ~/.bashrc"
+	case "fish":
+		rcFiles = []string{filepath.Join(home, ".config", "fish", "config.fish")}
+		fix = "echo 'switcher init fish | source' >> ~/.config/fish/config.fish"
+	default:
+		return result{name: name, status: statusWarning, message: fmt.Sprintf("cannot check the shell integration for shell %q", shell), fix: "source the output of `switcher init <shell>` in your shell configuration"}
+	}
+
+	for _, rcFile := range rcFiles {
+		content, err := os.ReadFile(rcFile)
+		if err != nil {
+			continue
+		}
+		if strings.Contains(string(content), "switcher init") || strings.Contains(string(content), "switch.sh") {
+			return result{name: name, status: statusOK, message: fmt.Sprintf("%s sources the shell integration and completion", rcFile)}
+		}
+	}
+	return result{name: name, status: statusWarning, message: fmt.Sprintf("the shell integration and completion is not sourced in %s", strings.Join(rcFiles, " or ")), fix: fix}
+}
+
+func checkConfig(path string) result {
+	name := fmt.Sprintf("config %s", path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result{name: name, status: statusFailed, message: err.Error()}
+	}
+
+	issues, err := schema.Validate(data)
+	if err != nil {
+		return result{name: name, status: statusFailed, message: err.Error()}
+	}
+
+	if len(issues) > 0 {
+		return result{name: name, status: statusFailed, message: fmt.Sprintf("%d error(s), e.g. %s", len(issues), issues[0]), fix: fmt.Sprintf("run `switch config validate --config-path %s`", path)}
+	}
+	return result{name: name, status: statusOK, message: "valid"}
+}
+
+func checkTemporaryKubeconfigDirectory() result {
+	name := "temporary kubeconfig directory"
+	dir := os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir)
+
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return result{name: name, status: statusOK, message: fmt.Sprintf("%s does not exist yet", dir)}
+	} else if err != nil {
+		return result{name: name, status: statusFailed, message: err.Error()}
+	}
+
+	if err := checkWritable(dir); err != nil {
+		return result{name: name, status: statusFailed, message: fmt.Sprintf("%s is not writable: %v", dir, err), fix: fmt.Sprintf("chown -R $USER %s", dir)}
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return result{name: name, status: statusWarning, message: fmt.Sprintf("%s is accessible by other users (%s)", dir, info.Mode().Perm()), fix: fmt.Sprintf("chmod 700 %s", dir)}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return result{name: name, status: statusFailed, message: err.Error()}
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+
+	message := fmt.Sprintf("%d file(s), %s", len(entries), formatSize(size))
+	if len(entries) > maxTemporaryKubeconfigs {
+		return result{name: name, status: statusWarning, message: message, fix: "run `switch clean` or enable the automatic cleanup with `clean.auto` in the SwitchConfig"}
+	}
+	return result{name: name, status: statusOK, message: message}
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func checkStateDirectory(dir string) result {
+	name := "state directory"
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return result{name: name, status: statusOK, message: fmt.Sprintf("%s does not exist yet", dir)}
+	}
+
+	if err := checkWritable(dir); err != nil {
+		return result{name: name, status: statusFailed, message: fmt.Sprintf("%s is not writable: %v", dir, err), fix: fmt.Sprintf("chown -R $USER %s", dir)}
+	}
+	return result{name: name, status: statusOK, message: dir}
+}
+
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// checkStore checks that the kubeconfig store is reachable and correctly configured
+func checkStore(store storetypes.KubeconfigStore) result {
+	name := fmt.Sprintf("store %s", store.GetID())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- store.VerifyKubeconfigPaths()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return result{name: name, status: statusFailed, message: err.Error(), fix: "check the configuration of the kubeconfig store in the SwitchConfig"}
+		}
+		return result{name: name, status: statusOK, message: "reachable"}
+	case <-time.After(storeTimeout):
+		return result{name: name, status: statusFailed, message: fmt.Sprintf("no response within %s", storeTimeout), fix: "check the network connectivity to the backing provider"}
+	}
+}
+
+// checkCredentials checks when the credentials of the kubeconfig store expire. Returns nil for stores without credentials.
+func checkCredentials(store storetypes.KubeconfigStore) *result {
+	authenticator, ok := store.(storetypes.Authenticator)
+	if !ok {
+		return nil
+	}
+
+	name := fmt.Sprintf("credentials %s", store.GetID())
+	fix := fmt.Sprintf("run `switch login %s`", store.GetID())
+
+	expiry, err := authenticator.CheckCredentials()
+	switch {
+	case errors.Is(err, storetypes.ErrLoginNotSupported):
+		return nil
+	case err != nil:
+		return &result{name: name, status: statusFailed, message: err.Error(), fix: fix}
+	case expiry == nil:
+		return &result{name: name, status: statusOK, message: "valid, no expiry"}
+	case time.Until(*expiry) <= 0:
+		return &result{name: name, status: statusFailed, message: fmt.Sprintf("expired at %s", expiry.Local().Format(time.RFC3339)), fix: fix}
+	case time.Until(*expiry) < credentialExpiryWarning:
+		return &result{name: name, status: statusWarning, message: fmt.Sprintf("expire in %s", time.Until(*expiry).Round(time.Minute)), fix: fix}
+	default:
+		return &result{name: name, status: statusOK, message: fmt.Sprintf("valid until %s", expiry.Local().Format(time.RFC3339))}
+	}
+}
+
+// checkIndex checks the freshness of the search index of the kubeconfig store. Returns nil for stores without index.
+func checkIndex(store storetypes.KubeconfigStore, config *types.Config, stateDir string) *result {
+	refreshAfter := store.GetStoreConfig().RefreshIndexAfter
+	if refreshAfter == nil && config != nil {
+		refreshAfter = config.RefreshIndexAfter
+	}
+	if refreshAfter == nil {
+		return nil
+	}
+
+	name := fmt.Sprintf("index %s", store.GetID())
+	searchIndex, err := index.New(store.GetLogger(), store.GetKind(), stateDir, store.GetID())
+	if err != nil {
+		return &result{name: name, status: statusFailed, message: err.Error()}
+	}
+
+	lastUpdate, err := searchIndex.GetLastUpdateTime()
+	switch {
+	case err != nil:
+		return &result{name: name, status: statusFailed, message: err.Error(), fix: "run `switch --no-index` to rebuild the index"}
+	case lastUpdate == nil:
+		return &result{name: name, status: statusWarning, message: "not built yet", fix: "run `switch` once to build the index"}
+	case time.Since(*lastUpdate) > *refreshAfter:
+		return &result{name: name, status: statusWarning, message: fmt.Sprintf("outdated, last refreshed %s ago", time.Since(*lastUpdate).Round(time.Minute)), fix: "the index is refreshed with the next search"}
+	default:
+		return &result{name: name, status: statusOK, message: fmt.Sprintf("last refreshed %s ago", time.Since(*lastUpdate).Round(time.Minute))}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execcredential
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// configPath is the path of the switch configuration file the exec credential plugin of thin kubeconfigs reads the kubeconfig stores from
+var configPath string
+
+// SetConfigPath sets the path of the switch configuration file passed to the exec credential plugin of thin kubeconfigs
+func SetConfigPath(path string) {
+	// kubectl may call the plugin from another working directory
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	configPath = path
+}
+
+// Thin replaces the static credentials of all users in the kubeconfig with an exec credential plugin calling the switcher binary.
+// The plugin retrieves the kubeconfig with the given path from the kubeconfig store and returns the credentials of the user
+// only when the client needs them, and again once they expired.
+// The context name (and alias) allow the plugin to enforce the kubeconfig policy like when switching to the context.
+func Thin(kubeconfig *kubeconfigutil.Kubeconfig, storeID, path string, tags map[string]string, stateDir, contextName, alias string) error {
+	return kubeconfig.ReplaceUserCredentials(func(userName string, _ kubeconfigutil.UserCredentials) (*kubeconfigutil.ExecConfig, error) {
+		execConfig, err := ExecConfig(storeID, path, tags, stateDir, userName)
+		if err != nil {
+			return nil, err
+		}
+
+		execConfig.Args = append(execConfig.Args, "--context", contextName)
+		if len(alias) > 0 {
+			execConfig.Args = append(execConfig.Args, "--alias", alias)
+		}
+		return execConfig, nil
+	})
+}
+
+// ExecConfig returns the exec credential plugin calling the switcher binary, which retrieves the credentials of the user
+// of the kubeconfig with the given path from the kubeconfig store.
+// Without a state directory, the plugin uses the default state directory.
+func ExecConfig(storeID, path string, tags map[string]string, stateDir, userName string) (*kubeconfigutil.ExecConfig, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine path of the switcher binary: %v", err)
+	}
+
+	args := []string{kubeconfigutil.ExecCredentialCommand}
+	if len(stateDir) > 0 {
+		args = append(args, "--state-directory", stateDir)
+	}
+	args = append(args,
+		"--store", storeID,
+		"--path", path,
+	)
+	if len(configPath) > 0 {
+		args = append(args, "--config-path", configPath)
+	}
+
+	// sorted for a stable kubeconfig
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--tag", fmt.Sprintf("%s=%s", key, tags[key]))
+	}
+
+	return &kubeconfigutil.ExecConfig{
+		APIVersion:      kubeconfigutil.ExecCredentialAPIVersion,
+		Command:         executable,
+		Args:            append(args, "--user", userName),
+		InteractiveMode: "Never",
+	}, nil
+}
+
+// MintsCredentials returns true if the kubeconfigs of the store call the exec credential plugin,
+// which mints short-lived credentials with the kubeconfig store
+func MintsCredentials(store types.KubeconfigStore) bool {
+	return store.KubeconfigProvider != nil && *store.KubeconfigProvider == types.KubeconfigProviderExec
+}
+
+// Materializer prepares the kubeconfig retrieved from the kubeconfig store like when switching to its context,
+// so that the kubeconfig policy is enforced before the credentials are handed out
+type Materializer func(data []byte) ([]byte, error)
+
+// GetExecCredential retrieves the kubeconfig with the given path from the kubeconfig store
+// and returns the credentials of the user as ExecCredential JSON.
+// The credentials expire with the client certificate or token, so that the client calls the plugin again afterwards.
+// If the store mints short-lived credentials, they are cached in the state directory encrypted with the cache key until they expire.
+func GetExecCredential(store storetypes.KubeconfigStore, path string, tags map[string]string, userName, stateDir string, cacheKey []byte, materialize Materializer) ([]byte, error) {
+	if MintsCredentials(store.GetStoreConfig()) {
+		return mintExecCredential(store, path, tags, userName, stateDir, cacheKey, materialize)
+	}
+
+	data, err := store.GetKubeconfigForPath(path, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve kubeconfig %q from store %q: %w", path, store.GetID(), err)
+	}
+
+	execCredential, _, err := toExecCredential(store, path, data, userName, materialize)
+	return execCredential, err
+}
+
+// toExecCredential returns the credentials of the user in the materialized kubeconfig as ExecCredential JSON and their expiry, if known
+func toExecCredential(store storetypes.KubeconfigStore, path string, data []byte, userName string, materialize Materializer) ([]byte, *time.Time, error) {
+	data, err := materialize(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfig(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse kubeconfig %q of store %q: %v", path, store.GetID(), err)
+	}
+
+	credentials, err := kubeconfig.GetUserCredentials(userName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kubeconfig %q of store %q: %v", path, store.GetID(), err)
+	}
+
+	var expiry *time.Time
+	if userToExpiry, err := util.GetUserCredentialsExpiry(data); err == nil {
+		if userExpiry, ok := userToExpiry[userName]; ok {
+			expiry = &userExpiry
+		}
+	}
+
+	execCredential, err := credentials.ExecCredential(expiry)
+	return execCredential, expiry, err
+}
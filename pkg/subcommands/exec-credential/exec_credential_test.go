@@ -0,0 +1,289 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execcredential_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	execcredential "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/exec-credential"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// fakeStore returns the kubeconfig from GetKubeconfigForPath and mints the next kubeconfig from MintKubeconfigForPath
+type fakeStore struct {
+	config     types.KubeconfigStore
+	kubeconfig []byte
+	minted     [][]byte
+	mintCalls  int
+}
+
+func (s *fakeStore) GetID() string                            { return "fake.default" }
+func (s *fakeStore) GetKind() types.StoreKind                 { return "fake" }
+func (s *fakeStore) GetContextPrefix(string) string           { return "fake" }
+func (s *fakeStore) VerifyKubeconfigPaths() error             { return nil }
+func (s *fakeStore) StartSearch(chan storetypes.SearchResult) {}
+func (s *fakeStore) GetLogger() *logrus.Entry                 { return logrus.NewEntry(logrus.New()) }
+func (s *fakeStore) GetStoreConfig() types.KubeconfigStore    { return s.config }
+func (s *fakeStore) GetKubeconfigForPath(string, map[string]string) ([]byte, error) {
+	return s.kubeconfig, nil
+}
+
+// minterStore is a fakeStore that can mint credentials
+type minterStore struct {
+	fakeStore
+}
+
+func (s *minterStore) MintKubeconfigForPath(string, map[string]string) ([]byte, error) {
+	kubeconfig := s.minted[s.mintCalls]
+	s.mintCalls++
+	return kubeconfig, nil
+}
+
+// token returns a JWT expiring at the given time. The signature is not verified.
+func token(expiry time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return fmt.Sprintf("%s.%s.%s", encode([]byte(`{"alg":"none"}`)), encode([]byte(fmt.Sprintf(`{"exp":%d}`, expiry.Unix()))), encode([]byte("signature")))
+}
+
+func kubeconfigWithToken(token string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+users:
+- name: admin
+  user:
+    token: %s
+`, token))
+}
+
+// execCredentialStatus is the status of the ExecCredential JSON returned to the client
+type execCredentialStatus struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Status     struct {
+		ExpirationTimestamp *time.Time `json:"expirationTimestamp"`
+		Token               string     `json:"token"`
+	} `json:"status"`
+}
+
+func parseExecCredential(data []byte) execCredentialStatus {
+	var execCredential execCredentialStatus
+	ExpectWithOffset(1, json.Unmarshal(data, &execCredential)).To(Succeed())
+	return execCredential
+}
+
+func identity(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+var _ = Describe("GetExecCredential", func() {
+	var (
+		stateDir string
+		expiry   time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = os.MkdirTemp("", "exec-credential")
+		Expect(err).ToNot(HaveOccurred())
+		expiry = time.Now().Add(time.Hour).Truncate(time.Second)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+	})
+
+	Context("static credentials", func() {
+		It("should return the token of the user with its expiry", func() {
+			store := &fakeStore{kubeconfig: kubeconfigWithToken(token(expiry))}
+
+			data, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, nil, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			execCredential := parseExecCredential(data)
+			Expect(execCredential.APIVersion).To(Equal("client.authentication.k8s.io/v1"))
+			Expect(execCredential.Kind).To(Equal("ExecCredential"))
+			Expect(execCredential.Status.Token).To(Equal(token(expiry)))
+			Expect(execCredential.Status.ExpirationTimestamp).ToNot(BeNil())
+			Expect(execCredential.Status.ExpirationTimestamp.Equal(expiry)).To(BeTrue())
+		})
+
+		It("should omit the expiry of credentials that do not expire", func() {
+			store := &fakeStore{kubeconfig: kubeconfigWithToken("static")}
+
+			data, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, nil, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			execCredential := parseExecCredential(data)
+			Expect(execCredential.Status.Token).To(Equal("static"))
+			Expect(execCredential.Status.ExpirationTimestamp).To(BeNil())
+		})
+
+		It("should fail for an unknown user", func() {
+			store := &fakeStore{kubeconfig: kubeconfigWithToken("static")}
+
+			_, err := execcredential.GetExecCredential(store, "dev", nil, "unknown", stateDir, nil, identity)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return the credentials of the materialized kubeconfig", func() {
+			store := &fakeStore{kubeconfig: kubeconfigWithToken("static")}
+			materialize := func([]byte) ([]byte, error) {
+				return kubeconfigWithToken("materialized"), nil
+			}
+
+			data, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, nil, materialize)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parseExecCredential(data).Status.Token).To(Equal("materialized"))
+		})
+
+		It("should fail if the materializer rejects the kubeconfig", func() {
+			store := &fakeStore{kubeconfig: kubeconfigWithToken("static")}
+			materialize := func([]byte) ([]byte, error) {
+				return nil, errors.New("forbidden by the kubeconfig policy")
+			}
+
+			_, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, nil, materialize)
+			Expect(err).To(MatchError("forbidden by the kubeconfig policy"))
+		})
+	})
+
+	Context("minted credentials", func() {
+		var (
+			config   types.KubeconfigStore
+			cacheKey = bytes.Repeat([]byte{1}, 32)
+		)
+
+		BeforeEach(func() {
+			provider := types.KubeconfigProviderExec
+			config = types.KubeconfigStore{KubeconfigProvider: &provider}
+		})
+
+		It("should fail if the store cannot mint credentials", func() {
+			store := &fakeStore{config: config}
+
+			_, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(errors.Is(err, storetypes.ErrMintingNotSupported)).To(BeTrue())
+		})
+
+		It("should cache the minted credentials until they expire", func() {
+			store := &minterStore{fakeStore{config: config}}
+			store.minted = [][]byte{kubeconfigWithToken(token(expiry))}
+
+			first, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.mintCalls).To(Equal(1))
+			Expect(second).To(Equal(first))
+			Expect(parseExecCredential(first).Status.Token).To(Equal(token(expiry)))
+
+			cached, err := filepath.Glob(filepath.Join(stateDir, "exec-credential", "*.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cached).To(HaveLen(1))
+
+			data, err := os.ReadFile(cached[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).ToNot(ContainSubstring(token(expiry)))
+		})
+
+		It("should not cache the minted credentials without a cache key", func() {
+			store := &minterStore{fakeStore{config: config}}
+			store.minted = [][]byte{kubeconfigWithToken(token(expiry)), kubeconfigWithToken(token(expiry))}
+
+			_, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, nil, identity)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, nil, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.mintCalls).To(Equal(2))
+			Expect(filepath.Join(stateDir, "exec-credential")).ToNot(BeADirectory())
+		})
+
+		It("should mint new credentials if the cached credentials are about to expire", func() {
+			expiring := time.Now().Add(30 * time.Second)
+			store := &minterStore{fakeStore{config: config}}
+			store.minted = [][]byte{kubeconfigWithToken(token(expiring)), kubeconfigWithToken(token(expiry))}
+
+			_, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.mintCalls).To(Equal(2))
+			Expect(parseExecCredential(data).Status.Token).To(Equal(token(expiry)))
+		})
+
+		It("should not cache credentials without expiry", func() {
+			store := &minterStore{fakeStore{config: config}}
+			store.minted = [][]byte{kubeconfigWithToken("first"), kubeconfigWithToken("second")}
+
+			_, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := execcredential.GetExecCredential(store, "dev", nil, "admin", stateDir, cacheKey, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.mintCalls).To(Equal(2))
+			Expect(parseExecCredential(data).Status.Token).To(Equal("second"))
+		})
+	})
+})
+
+var _ = Describe("ExecConfig", func() {
+	It("should call the plugin with the state directory, the store, the path, the sorted tags and the user", func() {
+		execConfig, err := execcredential.ExecConfig("gardener.default", "garden/dev", map[string]string{"project": "dev", "landscape": "live"}, "/tmp/state", "admin")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(execConfig.APIVersion).To(Equal("client.authentication.k8s.io/v1"))
+		Expect(execConfig.InteractiveMode).To(Equal("Never"))
+		Expect(execConfig.Args).To(Equal([]string{
+			"exec-credential",
+			"--state-directory", "/tmp/state",
+			"--store", "gardener.default",
+			"--path", "garden/dev",
+			"--tag", "landscape=live",
+			"--tag", "project=dev",
+			"--user", "admin",
+		}))
+	})
+
+	It("should omit the state directory if not set", func() {
+		execConfig, err := execcredential.ExecConfig("gardener.default", "garden/dev", nil, "", "admin")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(execConfig.Args).ToNot(ContainElement("--state-directory"))
+	})
+})
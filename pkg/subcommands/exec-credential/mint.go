@@ -0,0 +1,133 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execcredential
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/encryption"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+)
+
+// expiryDelta is how long before their expiry cached credentials are minted again
+const expiryDelta = time.Minute
+
+// cachedCredential is a minted credential cached encrypted in the state directory until it expires
+type cachedCredential struct {
+	Expiry         time.Time       `json:"expiry"`
+	ExecCredential json.RawMessage `json:"execCredential"`
+}
+
+// mintExecCredential returns the credentials of the user minted by the kubeconfig store as ExecCredential JSON.
+// Every kubectl call runs the plugin, hence the credentials are cached until they expire instead of minting new credentials for every call.
+// The cache is encrypted with the cache key. Without a cache key, the credentials are not cached and minted for every call.
+func mintExecCredential(store storetypes.KubeconfigStore, path string, tags map[string]string, userName, stateDir string, cacheKey []byte, materialize Materializer) ([]byte, error) {
+	minter, ok := store.(storetypes.CredentialMinter)
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig store %q: %w", store.GetID(), storetypes.ErrMintingNotSupported)
+	}
+
+	mint := func() ([]byte, *time.Time, error) {
+		data, err := minter.MintKubeconfigForPath(path, tags)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to mint credentials for kubeconfig %q of store %q: %w", path, store.GetID(), err)
+		}
+		return toExecCredential(store, path, data, userName, materialize)
+	}
+
+	cache := cachePath(stateDir, store.GetID(), path, userName)
+	if cacheKey == nil {
+		// remove credentials cached in plaintext by previous versions
+		if err := os.Remove(cache); err != nil && !os.IsNotExist(err) {
+			store.GetLogger().Debugf("failed to remove cache of minted credentials %q: %v", cache, err)
+		}
+		execCredential, _, err := mint()
+		return execCredential, err
+	}
+
+	var execCredential []byte
+	// hold the lock while minting, so that concurrent kubectl calls do not mint credentials twice
+	err := filelock.WithLock(cache, func() error {
+		if cached := readCache(cache, cacheKey); cached != nil && time.Now().Add(expiryDelta).Before(cached.Expiry) {
+			execCredential = cached.ExecCredential
+			return nil
+		}
+
+		var (
+			expiry *time.Time
+			err    error
+		)
+		execCredential, expiry, err = mint()
+		if err != nil || expiry == nil {
+			return err
+		}
+		return writeCache(cache, cacheKey, cachedCredential{Expiry: *expiry, ExecCredential: execCredential})
+	})
+	return execCredential, err
+}
+
+// cachePath returns the path of the cached credentials of the user in the state directory
+func cachePath(stateDir, storeID, path, userName string) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{storeID, path, userName}, "|")))
+	return filepath.Join(stateDir, "exec-credential", hex.EncodeToString(hash[:])+".json")
+}
+
+// readCache reads and decrypts the cached credentials.
+// Returns nil if there are none or the cache is corrupt or not encrypted with the key.
+func readCache(path string, key []byte) *cachedCredential {
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	data, err := encryption.Decrypt(key, string(encrypted))
+	if err != nil {
+		return nil
+	}
+
+	cached := &cachedCredential{}
+	if err := json.Unmarshal(data, cached); err != nil {
+		return nil
+	}
+	return cached
+}
+
+// writeCache writes the credentials encrypted with the key and readable only by the user
+func writeCache(path string, key []byte, cached cachedCredential) error {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encryption.Encrypt(key, data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt cache of minted credentials: %w", err)
+	}
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if err := filelock.WriteFile(path, []byte(encrypted), permissions.FileMode); err != nil {
+		return fmt.Errorf("failed to write cache of minted credentials: %w", err)
+	}
+	return nil
+}
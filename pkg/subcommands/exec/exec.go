@@ -15,6 +15,7 @@
 package exec
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -22,14 +23,18 @@ import (
 	"github.com/sirupsen/logrus"
 	easy "github.com/t-tomalak/logrus-easy-formatter"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	list_contexts "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/list-contexts"
 	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-func ExecuteCommand(pattern string, command []string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, showDebugLogs bool) error {
-	contexts, err := list_contexts.ListContexts(pattern, stores, config, stateDir, noIndex)
+// ExecuteCommand executes the command for each context matching the pattern.
+// Without pattern, the contexts are selected interactively.
+func ExecuteCommand(pattern string, command []string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	contexts, err := selectContexts(pattern, stores, config, stateDir, noIndex)
 	if err != nil {
 		return err
 	}
@@ -45,12 +50,8 @@ func ExecuteCommand(pattern string, command []string, stores []storetypes.Kubeco
 		LogFormat: "%msg%",
 	})
 
-	// uses the standard plaintext logger
-	standardLogger := logrus.New()
-
-	if showDebugLogs {
-		standardLogger.SetLevel(logrus.DebugLevel)
-	}
+	// uses the configured log level and format
+	standardLogger := logging.New()
 
 	for _, context := range contexts {
 		tmpKubeconfigFile, _, err := setcontext.SetContext(context, stores, config, stateDir, noIndex, false)
@@ -123,3 +124,19 @@ func ExecuteCommand(pattern string, command []string, stores []storetypes.Kubeco
 	}
 	return nil
 }
+
+// selectContexts returns the contexts matching the pattern, or the contexts selected in the fuzzy finder if no pattern is given
+func selectContexts(pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]string, error) {
+	if len(pattern) > 0 {
+		return list_contexts.ListContexts(pattern, stores, config, stateDir, noIndex)
+	}
+
+	contexts, err := list_contexts.ListContexts("*", stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		return nil, errors.New("no contexts found")
+	}
+	return pkg.SelectContexts(contexts, "Select the contexts to execute the command on with Tab")
+}
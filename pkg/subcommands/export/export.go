@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/becheran/wildmatch-go"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var logger = logging.New()
+
+// Export merges the kubeconfigs of the contexts matching one of the patterns into a single flattened kubeconfig file.
+// Without patterns, the contexts are selected interactively.
+// The contexts are named like in the search, their clusters and users are named like the contexts.
+func Export(patterns []string, outputPath string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	// the same context can be returned more than once (e.g. from the index and the store), only export it once
+	nameToContext := map[string]pkg.DiscoveredContext{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot export contexts. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Store == nil {
+			continue
+		}
+		nameToContext[displayName(discoveredContext)] = discoveredContext
+	}
+
+	var names []string
+	for name := range nameToContext {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	selected, err := selectContexts(names, patterns)
+	if err != nil {
+		return err
+	}
+
+	merged := clientcmdapi.NewConfig()
+	for _, name := range selected {
+		if err := addContext(merged, name, nameToContext[name], config, stateDir); err != nil {
+			return fmt.Errorf("failed to export context %q: %v", name, err)
+		}
+	}
+	merged.CurrentContext = selected[0]
+
+	if err := clientcmd.WriteToFile(*merged, outputPath); err != nil {
+		return fmt.Errorf("failed to write kubeconfig file %q: %v", outputPath, err)
+	}
+
+	fmt.Printf("Exported %d context(s) to %q.\n", len(selected), outputPath)
+	return nil
+}
+
+// selectContexts returns the context names matching one of the patterns
+// or the context names selected in the fuzzy finder if no pattern is given
+func selectContexts(names, patterns []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no contexts found")
+	}
+
+	if len(patterns) == 0 {
+		return pkg.SelectContexts(names, "Select the contexts to export with Tab")
+	}
+
+	var selected []string
+	for _, name := range names {
+		for _, pattern := range patterns {
+			if wildmatch.NewWildMatch(pattern).IsMatch(name) {
+				selected = append(selected, name)
+				break
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no context matches %q", strings.Join(patterns, ", "))
+	}
+	return selected, nil
+}
+
+// addContext adds the context with its cluster and user from the kubeconfig store to the merged kubeconfig
+func addContext(merged *clientcmdapi.Config, name string, discoveredContext pkg.DiscoveredContext, config *types.Config, stateDir string) error {
+	return pkg.AddContextToKubeconfig(merged, name, config, stateDir, pkg.MaterializeOptions{
+		Store:       *discoveredContext.Store,
+		Path:        discoveredContext.Path,
+		Tags:        discoveredContext.Tags,
+		ContextName: discoveredContext.Name,
+		Alias:       discoveredContext.Alias,
+	})
+}
+
+// displayName returns the name the discovered context is shown with in the search
+func displayName(discoveredContext pkg.DiscoveredContext) string {
+	if len(discoveredContext.Alias) > 0 {
+		return discoveredContext.Alias
+	}
+	return discoveredContext.Name
+}
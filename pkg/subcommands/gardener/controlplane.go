@@ -19,13 +19,12 @@ import (
 	"os"
 	"strings"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/danielfoehrkn/kubeswitch/pkg/store"
 	gardenerstore "github.com/danielfoehrkn/kubeswitch/pkg/store/gardener"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -35,7 +34,7 @@ const (
 )
 
 var (
-	logger                = logrus.New()
+	logger                = logging.New()
 	kubeconfigPathFromEnv = os.Getenv("KUBECONFIG")
 )
 
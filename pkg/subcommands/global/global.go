@@ -0,0 +1,276 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package global
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultPath is the default kubeconfig used by tools ignoring the KUBECONFIG environment variable
+	defaultPath = "~/.kube/config"
+	// backupSuffix is the suffix of the backup of the default kubeconfig written before every modification
+	backupSuffix = ".kubeswitch.bak"
+	// maxBackups is the number of backups of the default kubeconfig kept
+	maxBackups = 5
+	// managedExtension is the extension marking the contexts, clusters and users merged into the default kubeconfig by kubeswitch
+	managedExtension = "kubeswitch"
+	// managedPrefix is the prefix of the names of the clusters and users merged into the default kubeconfig,
+	// so that they do not replace clusters and users of the same name
+	managedPrefix = "kubeswitch/"
+)
+
+// IsEnabled returns true if every switch sets the current context of the default kubeconfig
+func IsEnabled(config *types.Config) bool {
+	return config != nil && config.GlobalMode != nil && config.GlobalMode.Enabled != nil && *config.GlobalMode.Enabled
+}
+
+// GetPath returns the path of the default kubeconfig
+func GetPath(config *types.Config) string {
+	if config != nil && config.GlobalMode != nil && config.GlobalMode.Path != nil {
+		return util.ExpandEnv(*config.GlobalMode.Path)
+	}
+	return util.ExpandEnv(defaultPath)
+}
+
+// BackupPath returns the path of the latest backup of the default kubeconfig
+func BackupPath(path string) string {
+	return backupPath(path, 0)
+}
+
+// backupPath returns the path of the i-th backup of the default kubeconfig, the latest backup being 0
+func backupPath(path string, i int) string {
+	if i == 0 {
+		return path + backupSuffix
+	}
+	return fmt.Sprintf("%s%s.%d", path, backupSuffix, i)
+}
+
+// Write sets the current context of the default kubeconfig to the current context of the temporary kubeconfig.
+// If the default kubeconfig does not contain the context with the same API server, the context is merged into it
+// named like the given context name, with its cluster and user named with the prefix "kubeswitch/".
+// Fails instead of replacing a context, cluster or user of the same name that has not been merged by kubeswitch.
+// At most one merged context is kept, the context merged by the previous switch is removed.
+// The default kubeconfig is backed up before it is modified and restored if the new content cannot be written or read.
+// Returns the name of the new current context.
+func Write(kubeconfigPath, contextName, path string) (string, error) {
+	temporary, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read temporary kubeconfig: %v", err)
+	}
+	if err := clientcmd.ResolveLocalPaths(temporary); err != nil {
+		return "", err
+	}
+
+	context, ok := temporary.Contexts[temporary.CurrentContext]
+	if !ok {
+		return "", fmt.Errorf("the temporary kubeconfig does not contain its current context %q", temporary.CurrentContext)
+	}
+	cluster, user := temporary.Clusters[context.Cluster], temporary.AuthInfos[context.AuthInfo]
+	if cluster == nil || user == nil {
+		return "", fmt.Errorf("the temporary kubeconfig does not contain the cluster and user of context %q", temporary.CurrentContext)
+	}
+
+	var currentContext string
+	err = filelock.WithLock(path, func() error {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		kubeconfig := clientcmdapi.NewConfig()
+		if len(data) > 0 {
+			if kubeconfig, err = clientcmd.Load(data); err != nil {
+				return fmt.Errorf("failed to parse %q: %v", path, err)
+			}
+		}
+
+		currentContext = existingContext(kubeconfig, temporary.CurrentContext, cluster.Server)
+		removeManagedContexts(kubeconfig, currentContext)
+
+		if len(currentContext) == 0 {
+			managedName := managedPrefix + contextName
+			if err := checkUnmanaged(kubeconfig, path, contextName, managedName); err != nil {
+				return err
+			}
+
+			currentContext = contextName
+			merged := context.DeepCopy()
+			merged.Cluster, merged.AuthInfo = managedName, managedName
+			merged.Extensions = managedExtensions()
+			mergedCluster := cluster.DeepCopy()
+			mergedCluster.Extensions = managedExtensions()
+			mergedUser := user.DeepCopy()
+			mergedUser.Extensions = managedExtensions()
+
+			kubeconfig.Contexts[contextName] = merged
+			kubeconfig.Clusters[managedName] = mergedCluster
+			kubeconfig.AuthInfos[managedName] = mergedUser
+		}
+		kubeconfig.CurrentContext = currentContext
+
+		return write(path, data, *kubeconfig)
+	})
+	return currentContext, err
+}
+
+// Rollback restores the backup of the default kubeconfig written by the last switch.
+// The replaced content becomes the new backup, so that a rollback can be undone with another rollback.
+func Rollback(path string) error {
+	return filelock.WithLock(path, func() error {
+		backup, err := os.ReadFile(BackupPath(path))
+		if os.IsNotExist(err) {
+			return fmt.Errorf("there is no backup %q of the default kubeconfig", BackupPath(path))
+		}
+		if err != nil {
+			return err
+		}
+
+		current, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := filelock.WriteFile(path, backup, permissions.FileMode); err != nil {
+			return err
+		}
+		return filelock.WriteFile(BackupPath(path), current, permissions.FileMode)
+	})
+}
+
+// write backs up the previous content of the default kubeconfig and writes the new content.
+// Restores the previous content if the new content cannot be written or read.
+func write(path string, previous []byte, kubeconfig clientcmdapi.Config) error {
+	data, err := clientcmd.Write(kubeconfig)
+	if err != nil {
+		return err
+	}
+	// keep the backup of the last change, e.g. when switching to the same context again
+	if bytes.Equal(data, previous) {
+		return nil
+	}
+
+	if previous != nil {
+		if err := rotateBackups(path); err != nil {
+			return fmt.Errorf("failed to rotate the backups of %q: %v", path, err)
+		}
+		if err := filelock.WriteFile(BackupPath(path), previous, permissions.FileMode); err != nil {
+			return fmt.Errorf("failed to back up %q: %v", path, err)
+		}
+	} else if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	err = filelock.WriteFile(path, data, permissions.FileMode)
+	if err == nil {
+		_, err = clientcmd.LoadFromFile(path)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if previous == nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write %q: %v", path, err)
+	}
+	if rollbackErr := filelock.WriteFile(path, previous, permissions.FileMode); rollbackErr != nil {
+		return fmt.Errorf("failed to write %q: %v. Restoring the backup %q failed as well: %v", path, err, BackupPath(path), rollbackErr)
+	}
+	return fmt.Errorf("failed to write %q, restored the previous content: %v", path, err)
+}
+
+// rotateBackups shifts the existing backups of the default kubeconfig by one, so that the latest backup is not overwritten.
+// The oldest backup is deleted.
+func rotateBackups(path string) error {
+	if err := os.Remove(backupPath(path, maxBackups-1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := maxBackups - 2; i >= 0; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUnmanaged returns an error if the default kubeconfig contains a context with the context name
+// or a cluster or user with the managed name that has not been merged by kubeswitch
+func checkUnmanaged(kubeconfig *clientcmdapi.Config, path, contextName, managedName string) error {
+	if _, ok := kubeconfig.Contexts[contextName]; ok {
+		return fmt.Errorf("%q already contains a context %q with another API server. Rename it to switch to the context in global mode", path, contextName)
+	}
+	if cluster, ok := kubeconfig.Clusters[managedName]; ok && !isManaged(cluster.Extensions) {
+		return fmt.Errorf("%q already contains a cluster %q not merged by kubeswitch", path, managedName)
+	}
+	if user, ok := kubeconfig.AuthInfos[managedName]; ok && !isManaged(user.Extensions) {
+		return fmt.Errorf("%q already contains a user %q not merged by kubeswitch", path, managedName)
+	}
+	return nil
+}
+
+// existingContext returns the name of the context of the default kubeconfig with the given name and API server
+// that has not been merged by kubeswitch, or "" if there is none
+func existingContext(kubeconfig *clientcmdapi.Config, name, server string) string {
+	context, ok := kubeconfig.Contexts[name]
+	if !ok || isManaged(context.Extensions) {
+		return ""
+	}
+	if cluster, ok := kubeconfig.Clusters[context.Cluster]; !ok || cluster.Server != server {
+		return ""
+	}
+	return name
+}
+
+// removeManagedContexts removes the contexts merged by kubeswitch, except the given one.
+// Their clusters and users are only removed if they have been merged by kubeswitch as well.
+func removeManagedContexts(kubeconfig *clientcmdapi.Config, keep string) {
+	for name, context := range kubeconfig.Contexts {
+		if name == keep || !isManaged(context.Extensions) {
+			continue
+		}
+		delete(kubeconfig.Contexts, name)
+		if cluster, ok := kubeconfig.Clusters[context.Cluster]; ok && isManaged(cluster.Extensions) {
+			delete(kubeconfig.Clusters, context.Cluster)
+		}
+		if user, ok := kubeconfig.AuthInfos[context.AuthInfo]; ok && isManaged(user.Extensions) {
+			delete(kubeconfig.AuthInfos, context.AuthInfo)
+		}
+	}
+}
+
+// managedExtensions returns the extensions marking a context, cluster or user as merged by kubeswitch
+func managedExtensions() map[string]runtime.Object {
+	return map[string]runtime.Object{
+		managedExtension: &runtime.Unknown{Raw: []byte(`{"managed":true}`), ContentType: runtime.ContentTypeJSON},
+	}
+}
+
+// isManaged returns true if the extensions mark a context, cluster or user as merged into the default kubeconfig by kubeswitch
+func isManaged(extensions map[string]runtime.Object) bool {
+	_, ok := extensions[managedExtension]
+	return ok
+}
@@ -19,16 +19,16 @@ import (
 	"fmt"
 
 	"github.com/ktr0731/go-fuzzyfinder"
-	"github.com/sirupsen/logrus"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
 	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-var logger = logrus.New()
+var logger = logging.New()
 
 func SwitchToHistory(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
 	history, err := util.ReadHistory()
@@ -166,6 +166,30 @@ func SetPreviousContext(stores []storetypes.KubeconfigStore, config *types.Confi
 	return tmpKubeconfigFile, context, setNamespace(*ns, *tmpKubeconfigFile)
 }
 
+// SetRecentContext sets the n-th most recently used context from the history, see util.RecentContext
+func SetRecentContext(n int, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
+	context, ns, err := util.RecentContext(n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpKubeconfigFile, _, err := setcontext.SetContext(*context, stores, config, stateDir, noIndex, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// old history entry that does not include a namespace
+	if ns == nil {
+		return tmpKubeconfigFile, context, nil
+	}
+
+	if err := setNamespace(*ns, *tmpKubeconfigFile); err != nil {
+		return tmpKubeconfigFile, nil, err
+	}
+
+	return tmpKubeconfigFile, context, util.AppendToHistory(*context, *ns)
+}
+
 // SetLastContext sets the last used context from the history (position 0)
 // does not add a history entry
 func SetLastContext(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
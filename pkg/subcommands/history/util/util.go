@@ -18,16 +18,20 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 )
 
-// historyFilePath is a constant for the filename storing the history of namespaces
-const historyFilePath = "$HOME/.kube/.switch_history"
+// HistoryFilePath is a constant for the filename storing the history of namespaces
+const HistoryFilePath = "$HOME/.kube/.switch_history"
 
 // ReadHistory reads the context history from the state file
 func ReadHistory() ([]string, error) {
-	fileName := os.ExpandEnv(historyFilePath)
+	fileName := os.ExpandEnv(HistoryFilePath)
 	file, err := os.Open(fileName)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -46,17 +50,74 @@ func ReadHistory() ([]string, error) {
 	return lines, scanner.Err()
 }
 
+// ReadHistoryEntries reads the entries of the history file, oldest first.
+// Returns no entries if the history file does not exist yet.
+func ReadHistoryEntries() ([]string, error) {
+	content, err := os.ReadFile(os.ExpandEnv(HistoryFilePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []string
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		if len(scanner.Text()) > 0 {
+			entries = append(entries, scanner.Text())
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// UpdateHistory replaces the entries of the history file with the entries returned by the update function.
+// The update function is called with the current entries, oldest first, while other terminals are prevented from appending to the history.
+func UpdateHistory(update func(entries []string) []string) error {
+	path := os.ExpandEnv(HistoryFilePath)
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	entries, err := ReadHistoryEntries()
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	for _, entry := range update(entries) {
+		content.WriteString(entry)
+		content.WriteString("\n")
+	}
+	return filelock.WriteFile(path, []byte(content.String()), permissions.FileMode)
+}
+
+// FormatHistoryEntry returns the history entry for the given context and namespace
+func FormatHistoryEntry(context, namespace string) string {
+	return fmt.Sprintf("%s:: %s", context, namespace)
+}
+
 // AppendToHistory appends the given context: namespace to the history file
 func AppendToHistory(context, namespace string) error {
-	filepath := os.ExpandEnv(historyFilePath)
+	filepath := os.ExpandEnv(HistoryFilePath)
+
+	// concurrent switches in other terminals append to the same history file
+	lock, err := filelock.Acquire(filepath)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	f, err := os.OpenFile(filepath,
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, permissions.FileMode)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	historyEntry := fmt.Sprintf("%s:: %s\n", context, namespace)
+	historyEntry := FormatHistoryEntry(context, namespace) + "\n"
 
 	lastHistoryEntry, err := getLastLineWithSeek(filepath)
 	if err != nil {
@@ -68,11 +129,98 @@ func AppendToHistory(context, namespace string) error {
 		return nil
 	}
 
+	// the last entry is incomplete if a previous switch was interrupted while appending it.
+	// Start a new line so that only the incomplete entry is skipped when reading the history.
+	if len(lastHistoryEntry) > 0 && !strings.HasSuffix(lastHistoryEntry, "\n") {
+		historyEntry = "\n" + historyEntry
+	}
+
 	if _, err := f.WriteString(historyEntry); err != nil {
 		return err
 	}
 
-	return nil
+	// flush to disk, so that a crash does not lose or truncate the entry
+	return f.Sync()
+}
+
+// PreviousNamespace returns the most recently used namespace of the context in the history that differs from the current namespace.
+// Returns an empty string if the history does not contain another namespace of the context.
+func PreviousNamespace(context, currentNamespace string) (string, error) {
+	entries, err := ReadHistoryEntries()
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entryContext, namespace, err := ParseHistoryEntry(entries[i])
+		if err != nil || namespace == nil || len(*namespace) == 0 {
+			continue
+		}
+		if *entryContext == context && *namespace != currentNamespace {
+			return *namespace, nil
+		}
+	}
+	return "", nil
+}
+
+// RecentContexts returns the last history entry of each context in the history, most recently used context first
+func RecentContexts() ([]string, error) {
+	history, err := ReadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entries []string
+		seen    = make(map[string]bool)
+	)
+	for _, entry := range history {
+		context, _, err := ParseHistoryEntry(entry)
+		if err != nil || seen[*context] {
+			continue
+		}
+		seen[*context] = true
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// RecentContext returns the n-th most recently used context of the history and the namespace it was used with last.
+// Each context counts once, the most recently used context is at position 0.
+func RecentContext(n int) (*string, *string, error) {
+	entries, err := RecentContexts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if n >= len(entries) {
+		return nil, nil, fmt.Errorf("the history only contains %d contexts", len(entries))
+	}
+	return ParseHistoryEntry(entries[n])
+}
+
+// frecencyHalfLife is the number of later history entries after which a history entry only counts half
+const frecencyHalfLife = 20
+
+// FrecencyScores returns the frecency score of each context in the history.
+// Every history entry of a context adds to its score, the more recent the entry the more it adds.
+// Hence, frequently and recently used contexts have the highest scores.
+func FrecencyScores() (map[string]float64, error) {
+	entries, err := ReadHistoryEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64)
+	for i, entry := range entries {
+		context, _, err := ParseHistoryEntry(entry)
+		if err != nil {
+			continue
+		}
+		age := len(entries) - 1 - i
+		scores[*context] += math.Pow(0.5, float64(age)/frecencyHalfLife)
+	}
+	return scores, nil
 }
 
 // ParseHistoryEntry takes a history entry as argument and returns the context as first, and the namespace as seconds
@@ -0,0 +1,108 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"fmt"
+
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+)
+
+// Event contains information about the event that triggered a hook, e.g. a switch to a context or a failure of a kubeconfig store.
+// Passed to the hook as environment variables and template fields (e.g. {{ .Context }})
+type Event struct {
+	// Context is the name of the context that has been switched to
+	Context string
+	// PreviousContext is the name of the context before the switch
+	PreviousContext string
+	// Namespace is the namespace of the context
+	Namespace string
+	// ClusterServer is the API server URL of the cluster
+	ClusterServer string
+	// StoreKind is the kind of the kubeconfig store the kubeconfig has been retrieved from
+	StoreKind string
+	// StoreID is the ID of the kubeconfig store the kubeconfig has been retrieved from
+	StoreID string
+	// Kubeconfig is the path to the temporary kubeconfig file
+	Kubeconfig string
+	// Error is the error of the failed kubeconfig store
+	Error string
+}
+
+// newSwitchEvent reads the information about the switch from the kubeconfig with the given path.
+// The current kubeconfig (KUBECONFIG environment variable) is expected to still point to the previous context.
+func newSwitchEvent(kubeconfigPath string) (*Event, error) {
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return newSwitchEventForKubeconfig(kubeconfig, kubeconfigPath)
+}
+
+// newSwitchEventForKubeconfig reads the information about the switch from the given kubeconfig that is (or would be) written to the given path
+func newSwitchEventForKubeconfig(kubeconfig *kubeconfigutil.Kubeconfig, kubeconfigPath string) (*Event, error) {
+	var err error
+	event := &Event{
+		Context:    kubeconfig.GetKubeswitchContext(),
+		StoreKind:  kubeconfig.GetKubeswitchStoreKind(),
+		StoreID:    kubeconfig.GetKubeswitchStoreID(),
+		Kubeconfig: kubeconfigPath,
+	}
+
+	currentContext := kubeconfig.GetCurrentContext()
+	if len(event.Context) == 0 {
+		event.Context = currentContext
+	}
+
+	if event.Namespace, err = kubeconfig.NamespaceOfContext(currentContext); err != nil {
+		return nil, fmt.Errorf("failed to get namespace of context %q: %v", currentContext, err)
+	}
+
+	if event.ClusterServer, err = kubeconfig.ServerOfContext(currentContext); err != nil {
+		return nil, fmt.Errorf("failed to get cluster server of context %q: %v", currentContext, err)
+	}
+
+	// there is no previous context, e.g., if KUBECONFIG is not set and there is no default kubeconfig
+	if previousKubeconfig, err := kubeconfigutil.LoadCurrentKubeconfig(); err == nil {
+		event.PreviousContext = previousKubeconfig.GetKubeswitchContext()
+		if len(event.PreviousContext) == 0 {
+			event.PreviousContext = previousKubeconfig.GetCurrentContext()
+		}
+	}
+
+	return event, nil
+}
+
+// environment returns the environment variables passed to the hook
+func (e *Event) environment() map[string]string {
+	env := map[string]string{
+		"KUBESWITCH_STORE_KIND": e.StoreKind,
+		"KUBESWITCH_STORE_ID":   e.StoreID,
+	}
+
+	if len(e.Kubeconfig) > 0 {
+		env["KUBECONFIG"] = e.Kubeconfig
+		env["KUBESWITCH_CONTEXT"] = e.Context
+		env["KUBESWITCH_PREVIOUS_CONTEXT"] = e.PreviousContext
+		env["KUBESWITCH_NAMESPACE"] = e.Namespace
+		env["KUBESWITCH_CLUSTER_SERVER"] = e.ClusterServer
+		env["KUBESWITCH_KUBECONFIG"] = e.Kubeconfig
+	}
+
+	if len(e.Error) > 0 {
+		env["KUBESWITCH_STORE_ERROR"] = e.Error
+	}
+	return env
+}
@@ -16,20 +16,34 @@ package hooks
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/pkg/credentials"
 	"github.com/danielfoehrkn/kubeswitch/pkg/state"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tracing"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func ListHooks(log *logrus.Entry, configPath, stateDir string) error {
-	config, err := switchconfig.LoadConfigFromFile(configPath)
+	config, err := switchconfig.LoadConfig(configPath)
 	if err != nil {
 		return err
 	}
@@ -41,12 +55,23 @@ func ListHooks(log *logrus.Entry, configPath, stateDir string) error {
 
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"Name", "Type", "Interval", "Next Execution"})
+	t.AppendHeader(table.Row{"Name", "Type", "Trigger", "Interval", "Next Execution"})
 
 	for _, hook := range config.Hooks {
+		trigger := string(hook.GetTrigger())
+		if len(hook.Stores) > 0 {
+			trigger = fmt.Sprintf("%s (%s)", trigger, strings.Join(hook.Stores, ", "))
+		}
+
 		execution := "OnDemand"
 		nextExecution := "OnDemand"
-		if hook.Execution != nil {
+		if hook.IsPostSwitch() {
+			execution = "-"
+			nextExecution = "AfterSwitch"
+		} else if hook.IsStoreFailure() {
+			execution = "-"
+			nextExecution = "OnStoreFailure"
+		} else if hook.Execution != nil {
 			execution = hook.Execution.Interval.String()
 
 			stateFileName := getHookStateFileName(hook.Name, stateDir)
@@ -64,7 +89,7 @@ func ListHooks(log *logrus.Entry, configPath, stateDir string) error {
 		}
 
 		t.AppendRows([]table.Row{
-			{hook.Name, hook.Type, execution, nextExecution},
+			{hook.Name, hook.Type, trigger, execution, nextExecution},
 		})
 	}
 	t.AppendSeparator()
@@ -74,8 +99,10 @@ func ListHooks(log *logrus.Entry, configPath, stateDir string) error {
 	return nil
 }
 
-func Hooks(log *logrus.Entry, configPath string, stateDirectory string, flagHookName string, runImmediately bool) error {
-	config, err := switchconfig.LoadConfigFromFile(configPath)
+// Hooks executes the hooks with the given name, all pre-search hooks (runImmediately) or the pre-search hooks that are due.
+// In dry-run mode, the hooks that would be executed are only printed.
+func Hooks(log *logrus.Entry, configPath string, stateDirectory string, flagHookName string, runImmediately bool, dryRun bool) error {
+	config, err := switchconfig.LoadConfig(configPath)
 	if err != nil {
 		return err
 	}
@@ -87,13 +114,18 @@ func Hooks(log *logrus.Entry, configPath string, stateDirectory string, flagHook
 	}
 
 	if config == nil || len(config.Hooks) == 0 {
+		if dryRun {
+			return printHooks(nil)
+		}
 		return nil
 	}
 
-	// create hook state directory
-	err = os.Mkdir(stateDirectory, 0700)
-	if err != nil && !os.IsExist(err) {
-		return err
+	if !dryRun {
+		// create hook state directory
+		err = permissions.MkdirAll(stateDirectory)
+		if err != nil {
+			return err
+		}
 	}
 
 	var hooksToBeExecuted []types.Hook
@@ -104,28 +136,199 @@ func Hooks(log *logrus.Entry, configPath string, stateDirectory string, flagHook
 		}
 		hooksToBeExecuted = append(hooksToBeExecuted, *hook)
 	} else if runImmediately {
-		hooksToBeExecuted = config.Hooks
+		for _, hook := range config.Hooks {
+			if hook.IsPreSearch() {
+				hooksToBeExecuted = append(hooksToBeExecuted, hook)
+			}
+		}
 	} else {
-		hooksToBeExecuted = getHooksToBeExecuted(log, config.Hooks, stateDirectory)
+		// hooks scoped to kubeconfig stores are executed prior to the search of the store
+		var unscopedHooks []types.Hook
+		for _, hook := range config.Hooks {
+			if len(hook.Stores) == 0 {
+				unscopedHooks = append(unscopedHooks, hook)
+			}
+		}
+		hooksToBeExecuted = getHooksToBeExecuted(log, unscopedHooks, stateDirectory)
+	}
+
+	var executions []hookExecution
+	for _, hook := range hooksToBeExecuted {
+		var event *Event
+		if hook.IsPostSwitch() {
+			// post-switch hooks run on demand get the information about the current context
+			kubeconfigPath := os.Getenv("KUBECONFIG")
+			if len(kubeconfigPath) == 0 {
+				return fmt.Errorf("cannot run post-switch hook %q: KUBECONFIG is not set", hook.Name)
+			}
+
+			if event, err = newSwitchEvent(kubeconfigPath); err != nil {
+				return fmt.Errorf("cannot run post-switch hook %q: %v", hook.Name, err)
+			}
+		}
+		executions = append(executions, hookExecution{hook: hook, event: event})
 	}
 
-	if len(hooksToBeExecuted) == 0 {
+	if dryRun {
+		return printHooks(executions)
+	}
+
+	if len(executions) == 0 {
 		log.Debug("No hooks need to be executed.")
 		return nil
 	}
 
-	for _, hook := range hooksToBeExecuted {
+	for _, execution := range executions {
+		stateFileName := getHookStateFileName(execution.hook.Name, stateDirectory)
+		if err := state.UpdateHookState(execution.hook.Name, stateFileName); err != nil {
+			return err
+		}
+	}
+
+	executeHooks(log, executions)
+	return nil
+}
+
+// PostSwitchHooks executes the hooks with trigger "PostSwitch" after a successful switch to the context
+// of the kubeconfig with the given path
+func PostSwitchHooks(log *logrus.Entry, configPath string, kubeconfigPath string) error {
+	config, err := switchconfig.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	executions, err := postSwitchExecutions(config, func() (*Event, error) {
+		return newSwitchEvent(kubeconfigPath)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run post-switch hooks: %v", err)
+	}
+
+	executeHooks(log, executions)
+	return nil
+}
+
+// PrintPostSwitchHooks prints the hooks with trigger "PostSwitch" that would be executed after the switch to the context
+// of the given kubeconfig. The kubeconfig is not expected to be written to the given path yet.
+func PrintPostSwitchHooks(config *types.Config, kubeconfig *kubeconfigutil.Kubeconfig, kubeconfigPath string) error {
+	if config == nil {
+		return nil
+	}
+
+	executions, err := postSwitchExecutions(config, func() (*Event, error) {
+		return newSwitchEventForKubeconfig(kubeconfig, kubeconfigPath)
+	})
+	if err != nil || len(executions) == 0 {
+		return err
+	}
+	return printHooks(executions)
+}
+
+// postSwitchExecutions returns the post-switch hooks of the configuration applicable to the store of the new context
+func postSwitchExecutions(config *types.Config, newEvent func() (*Event, error)) ([]hookExecution, error) {
+	var (
+		event      *Event
+		executions []hookExecution
+		err        error
+	)
+	for _, hook := range config.Hooks {
+		if !hook.IsPostSwitch() {
+			continue
+		}
+
+		if event == nil {
+			if event, err = newEvent(); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(hook.Stores) > 0 && !slices.ContainsFunc(config.KubeconfigStores, func(store types.KubeconfigStore) bool {
+			return credentials.StoreID(store) == event.StoreID && hookMatchesStore(hook, store)
+		}) {
+			continue
+		}
+
+		executions = append(executions, hookExecution{hook: hook, event: event})
+	}
+	return executions, nil
+}
+
+// StoreHooks executes the due pre-search hooks that are scoped to the kubeconfig store prior to the search of the store
+func StoreHooks(log *logrus.Entry, config *types.Config, stateDirectory string, store storetypes.KubeconfigStore) error {
+	if config == nil {
+		return nil
+	}
+
+	var scopedHooks []types.Hook
+	for _, hook := range config.Hooks {
+		if hook.IsPreSearch() && len(hook.Stores) > 0 && hookMatchesStore(hook, store.GetStoreConfig()) {
+			scopedHooks = append(scopedHooks, hook)
+		}
+	}
+
+	if len(scopedHooks) == 0 {
+		return nil
+	}
+
+	err := permissions.MkdirAll(stateDirectory)
+	if err != nil {
+		return err
+	}
+
+	event := &Event{
+		StoreKind: string(store.GetKind()),
+		StoreID:   store.GetID(),
+	}
+
+	var executions []hookExecution
+	for _, hook := range getHooksToBeExecuted(log, scopedHooks, stateDirectory) {
 		stateFileName := getHookStateFileName(hook.Name, stateDirectory)
 		if err := state.UpdateHookState(hook.Name, stateFileName); err != nil {
 			return err
 		}
+		executions = append(executions, hookExecution{hook: hook, event: event})
+	}
 
-		if err := executeHook(log, hook); err != nil {
-			log.Error(err)
+	executeHooks(log, executions)
+	return nil
+}
+
+// StoreFailureHooks executes the hooks with trigger "StoreFailure" that match the failed kubeconfig store
+func StoreFailureHooks(log *logrus.Entry, config *types.Config, store storetypes.KubeconfigStore, storeErr error) {
+	if config == nil {
+		return
+	}
+
+	event := &Event{
+		StoreKind: string(store.GetKind()),
+		StoreID:   store.GetID(),
+		Error:     storeErr.Error(),
+	}
+
+	var executions []hookExecution
+	for _, hook := range config.Hooks {
+		if !hook.IsStoreFailure() {
+			continue
+		}
+
+		if len(hook.Stores) > 0 && !hookMatchesStore(hook, store.GetStoreConfig()) {
+			continue
 		}
+		executions = append(executions, hookExecution{hook: hook, event: event})
 	}
 
-	return nil
+	executeHooks(log, executions)
+}
+
+// hookMatchesStore returns true if the hook is scoped to the given kubeconfig store
+func hookMatchesStore(hook types.Hook, store types.KubeconfigStore) bool {
+	return slices.ContainsFunc(hook.Stores, func(reference string) bool {
+		return switchconfig.StoreMatchesReference(store, reference)
+	})
 }
 
 func getHookForName(c *types.Config, name string) *types.Hook {
@@ -144,7 +347,7 @@ func getHooksToBeExecuted(log *logrus.Entry, hooks []types.Hook, stateDir string
 			continue
 		}
 
-		if hook.Execution == nil || hook.Execution.Interval == nil {
+		if !hook.IsPreSearch() || hook.Execution == nil || hook.Execution.Interval == nil {
 			// hooks without an interval are executed on demand
 			continue
 		}
@@ -176,44 +379,268 @@ func getHookStateFileName(hookName string, stateDir string) string {
 	return stateFileName
 }
 
-func executeHook(log *logrus.Entry, hook types.Hook) error {
-	log.Infof("Executing hook %q...", hook.Name)
+// hookExecution is a hook to be executed together with the event that triggered it
+type hookExecution struct {
+	hook types.Hook
+	// event is not set for hooks executed on demand or prior to the search
+	event *Event
+}
 
-	var cmd *exec.Cmd
-	if hook.Type == types.HookTypeInlineCommand {
-		arguments := []string{"-c"}
-		arguments = append(arguments, hook.Arguments...)
-		cmd = exec.Command("bash", arguments...)
-	} else {
-		// HookTypeExecutable
-		if hook.Path == nil || len(*hook.Path) == 0 {
-			return fmt.Errorf("cannot execute hook %q - no executable path set", hook.Name)
+// executeHooks executes the hooks. Parallel hooks are executed concurrently, all other hooks sequentially in order.
+func executeHooks(log *logrus.Entry, executions []hookExecution) {
+	var wg sync.WaitGroup
+	for _, execution := range executions {
+		if !execution.hook.IsParallel() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(execution hookExecution) {
+			defer wg.Done()
+			if err := executeHook(log, execution.hook, execution.event); err != nil {
+				log.Error(err)
+			}
+		}(execution)
+	}
+
+	for _, execution := range executions {
+		if execution.hook.IsParallel() {
+			continue
+		}
+
+		if err := executeHook(log, execution.hook, execution.event); err != nil {
+			log.Error(err)
 		}
+	}
+	wg.Wait()
+}
+
+// printHooks prints the hooks that would be executed, including their expanded command
+func printHooks(executions []hookExecution) error {
+	if len(executions) == 0 {
+		fmt.Println("No hooks would be executed.")
+		return nil
+	}
+
+	for _, execution := range executions {
+		if execution.hook.Type == types.HookTypeTerminalTitle {
+			title, _, err := resolveTitle(execution.hook, execution.event)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Would execute hook %q (trigger %s):\n", execution.hook.Name, execution.hook.GetTrigger())
+			fmt.Printf("  set the terminal title to %q\n", title)
+			continue
+		}
+
+		path, arguments, _, err := resolveCommand(execution.hook, execution.event)
+		if err != nil {
+			return err
+		}
+		path, arguments = interpreterCommand(execution.hook, path, arguments)
+
+		settings := []string{fmt.Sprintf("trigger %s", execution.hook.GetTrigger())}
+		if timeout := execution.hook.GetTimeout(); timeout != nil {
+			settings = append(settings, fmt.Sprintf("timeout %s", *timeout))
+		}
+		if retries := execution.hook.GetRetries(); retries > 0 {
+			settings = append(settings, fmt.Sprintf("%d retries", retries))
+		}
+		if execution.hook.IsParallel() {
+			settings = append(settings, "parallel")
+		}
+
+		fmt.Printf("Would execute hook %q (%s):\n", execution.hook.Name, strings.Join(settings, ", "))
+		// the arguments of cmd.exe are passed verbatim
+		if !util.IsCmd(path) {
+			arguments = quote(arguments)
+		}
+		fmt.Printf("  %s\n", strings.Join(append([]string{path}, arguments...), " "))
+	}
+	return nil
+}
+
+// quote quotes the arguments containing whitespace or quotes
+func quote(arguments []string) []string {
+	quoted := make([]string, 0, len(arguments))
+	for _, argument := range arguments {
+		if len(argument) == 0 || strings.ContainsAny(argument, " \t\n\"'") {
+			argument = strconv.Quote(argument)
+		}
+		quoted = append(quoted, argument)
+	}
+	return quoted
+}
+
+// resolveCommand returns the expanded executable, arguments and additional environment variables of the hook.
+// The executable is empty for inline commands.
+// Inline commands are not expanded, as values like the context name must not be interpreted by the shell.
+// They get the information about the switch from the environment variables instead.
+func resolveCommand(hook types.Hook, event *Event) (string, []string, map[string]string, error) {
+	// the template fields are empty for hooks without event
+	data := Event{}
+	var env map[string]string
+	if event != nil {
+		data = *event
+		env = event.environment()
+	}
+
+	if hook.Type == types.HookTypeInlineCommand {
+		return "", hook.Arguments, env, nil
+	}
 
-		if _, err := os.Stat(*hook.Path); err != nil {
-			return fmt.Errorf("cannot find executable for hook with name %q. File does not exist: %q", hook.Name, *hook.Path)
+	var arguments []string
+	for _, argument := range hook.Arguments {
+		expanded, err := switchconfig.ExpandString(argument, data, env)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to expand arguments of hook %q: %v", hook.Name, err)
 		}
-		cmd = exec.Command(*hook.Path, hook.Arguments...)
+		arguments = append(arguments, expanded)
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	// HookTypeExecutable
+	if hook.Path == nil || len(*hook.Path) == 0 {
+		return "", nil, nil, fmt.Errorf("cannot execute hook %q - no executable path set", hook.Name)
+	}
+
+	path, err := switchconfig.ExpandString(*hook.Path, data, env)
 	if err != nil {
-		fmt.Println(err)
+		return "", nil, nil, fmt.Errorf("failed to expand path of hook %q: %v", hook.Name, err)
+	}
+	return path, arguments, env, nil
+}
+
+// executeHook executes the hook and retries failed executions
+func executeHook(log *logrus.Entry, hook types.Hook, event *Event) error {
+	// the built-in hook is executed silently on every switch
+	if hook.Type == types.HookTypeTerminalTitle {
+		return setTerminalTitle(log, hook, event)
 	}
 
-	err = cmd.Start()
+	log.Infof("Executing hook %q...", hook.Name)
+
+	path, arguments, env, err := resolveCommand(hook, event)
 	if err != nil {
-		return fmt.Errorf("error running hook %q: %+v", hook.Name, err)
+		return err
+	}
+
+	if hook.Type == types.HookTypeExecutable {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("cannot find executable for hook with name %q. File does not exist: %q", hook.Name, path)
+		}
+	}
+
+	path, arguments = interpreterCommand(hook, path, arguments)
+
+	span := tracing.Start("hook "+hook.Name, "", attribute.String("switch.hook.type", string(hook.Type)))
+	retries := hook.GetRetries()
+	for attempt := 0; ; attempt++ {
+		err = runCommand(log, hook, path, arguments, env)
+		if err == nil || attempt >= retries {
+			span.SetAttributes(attribute.Int("switch.hook.attempts", attempt+1))
+			tracing.End(span, err)
+			return err
+		}
+		log.Warnf("%v. Retrying (%d/%d)...", err, attempt+1, retries)
+	}
+}
+
+// interpreterCommand returns the executable and arguments running the hook.
+// Inline commands are run by the shell of the hook and PowerShell and batch scripts by their interpreter,
+// as they cannot be executed directly.
+func interpreterCommand(hook types.Hook, path string, arguments []string) (string, []string) {
+	if hook.Type == types.HookTypeInlineCommand {
+		shell := defaultShell()
+		if hook.Shell != nil {
+			shell = *hook.Shell
+		}
+		return shellCommand(shell, arguments)
+	}
+
+	switch {
+	case strings.EqualFold(filepath.Ext(path), ".ps1"):
+		shell := types.HookShellPwsh
+		if runtime.GOOS == "windows" {
+			shell = types.HookShellPowerShell
+		}
+		return string(shell), append(powershellFlags("-File", path), arguments...)
+	case util.IsBatchFile(path):
+		commandLine := []string{util.QuoteCmdPath(path)}
+		for _, argument := range arguments {
+			commandLine = append(commandLine, util.QuoteCmdArgument(argument, true))
+		}
+		return "cmd", util.CmdArguments(strings.Join(commandLine, " "))
+	}
+	return path, arguments
+}
+
+// shellCommand returns the executable and arguments running the inline command with the shell
+func shellCommand(shell types.HookShell, arguments []string) (string, []string) {
+	switch shell {
+	case types.HookShellPowerShell, types.HookShellPwsh:
+		return string(shell), powershellFlags("-Command", strings.Join(arguments, "\n"))
+	case types.HookShellCmd:
+		return string(shell), util.CmdArguments(strings.Join(arguments, " & "))
+	default:
+		// the arguments after the command are its positional parameters
+		return string(shell), append([]string{"-c"}, arguments...)
+	}
+}
+
+// powershellFlags returns the flags of PowerShell to run the script or command non-interactively,
+// without the profile of the user and regardless of the execution policy
+func powershellFlags(mode, script string) []string {
+	return []string{"-NoLogo", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", mode, script}
+}
+
+// defaultShell returns the shell running inline commands of hooks without a configured shell
+func defaultShell() types.HookShell {
+	if runtime.GOOS == "windows" {
+		return types.HookShellPowerShell
+	}
+	return types.HookShellBash
+}
+
+// runCommand runs the command of the hook once and logs its output
+func runCommand(log *logrus.Entry, hook types.Hook, path string, arguments []string, env map[string]string) error {
+	ctx := context.Background()
+	if timeout := hook.GetTimeout(); timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	cmd := util.Command(ctx, path, arguments...)
+	// processes started by the hook may keep the output open after the hook has been killed
+	cmd.WaitDelay = time.Second
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 	}
 
 	// print the output of the subprocess
-	scanner := bufio.NewScanner(stdout)
-	for scanner.Scan() {
-		m := scanner.Text()
-		log.Info(m)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			log.Info(scanner.Text())
+		}
+		// drain the output in case of too long lines
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	err := cmd.Run()
+	writer.Close()
+	<-done
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("hook %q timed out after %s", hook.Name, *hook.GetTimeout())
 	}
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("error waiting for hook %q: %+v", hook.Name, err)
+	if err != nil {
+		return fmt.Errorf("error running hook %q: %+v", hook.Name, err)
 	}
 	return nil
 }
@@ -0,0 +1,349 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Hooks", func() {
+	var (
+		log   *logrus.Entry
+		event *Event
+	)
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		log = logrus.NewEntry(logger)
+
+		event = &Event{
+			Context:         "dev/eu",
+			PreviousContext: "prod/eu",
+			Namespace:       "kube-system",
+			ClusterServer:   "https://dev.example.com",
+			StoreKind:       "filesystem",
+			StoreID:         "filesystem.default",
+			Kubeconfig:      "/tmp/kubeconfig",
+		}
+	})
+
+	Context("environment", func() {
+		It("should pass the information about the switch", func() {
+			Expect(event.environment()).To(Equal(map[string]string{
+				"KUBECONFIG":                  "/tmp/kubeconfig",
+				"KUBESWITCH_KUBECONFIG":       "/tmp/kubeconfig",
+				"KUBESWITCH_CONTEXT":          "dev/eu",
+				"KUBESWITCH_PREVIOUS_CONTEXT": "prod/eu",
+				"KUBESWITCH_NAMESPACE":        "kube-system",
+				"KUBESWITCH_CLUSTER_SERVER":   "https://dev.example.com",
+				"KUBESWITCH_STORE_KIND":       "filesystem",
+				"KUBESWITCH_STORE_ID":         "filesystem.default",
+			}))
+		})
+
+		It("should only pass the store and its error for store failures", func() {
+			event := &Event{StoreKind: "eks", StoreID: "eks.prod", Error: "access denied"}
+			Expect(event.environment()).To(Equal(map[string]string{
+				"KUBESWITCH_STORE_KIND":  "eks",
+				"KUBESWITCH_STORE_ID":    "eks.prod",
+				"KUBESWITCH_STORE_ERROR": "access denied",
+			}))
+		})
+	})
+
+	Context("resolveCommand", func() {
+		It("should expand the templates of executables", func() {
+			hook := types.Hook{
+				Name:      "notify",
+				Type:      types.HookTypeExecutable,
+				Path:      ptr.To("/usr/bin/{{ .StoreKind }}"),
+				Arguments: []string{"--context", "{{ .Context }}"},
+			}
+
+			path, arguments, env, err := resolveCommand(hook, event)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/usr/bin/filesystem"))
+			Expect(arguments).To(Equal([]string{"--context", "dev/eu"}))
+			Expect(env).To(HaveKeyWithValue("KUBESWITCH_CONTEXT", "dev/eu"))
+		})
+
+		It("should not expand inline commands", func() {
+			hook := types.Hook{
+				Type:      types.HookTypeInlineCommand,
+				Arguments: []string{"echo {{ .Context }}"},
+			}
+
+			path, arguments, _, err := resolveCommand(hook, event)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(BeEmpty())
+			Expect(arguments).To(Equal([]string{"echo {{ .Context }}"}))
+		})
+
+		It("should fail for executables without path", func() {
+			_, _, _, err := resolveCommand(types.Hook{Name: "notify", Type: types.HookTypeExecutable}, event)
+			Expect(err).To(MatchError(`cannot execute hook "notify" - no executable path set`))
+		})
+	})
+
+	Context("interpreterCommand", func() {
+		It("should run inline commands with the configured shell", func() {
+			hook := types.Hook{Type: types.HookTypeInlineCommand, Shell: ptr.To(types.HookShellSh)}
+
+			path, arguments := interpreterCommand(hook, "", []string{"echo $0", "arg"})
+			Expect(path).To(Equal("sh"))
+			Expect(arguments).To(Equal([]string{"-c", "echo $0", "arg"}))
+		})
+
+		It("should run inline commands with PowerShell non-interactively", func() {
+			hook := types.Hook{Type: types.HookTypeInlineCommand, Shell: ptr.To(types.HookShellPwsh)}
+
+			path, arguments := interpreterCommand(hook, "", []string{"Write-Host a", "Write-Host b"})
+			Expect(path).To(Equal("pwsh"))
+			Expect(arguments).To(Equal([]string{"-NoLogo", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-Command", "Write-Host a\nWrite-Host b"}))
+		})
+
+		It("should run PowerShell scripts with their interpreter", func() {
+			path, arguments := interpreterCommand(types.Hook{Type: types.HookTypeExecutable}, "hook.PS1", []string{"arg"})
+			Expect(path).To(BeElementOf("pwsh", "powershell"))
+			Expect(arguments).To(HaveLen(8))
+			Expect(arguments[6:]).To(Equal([]string{"hook.PS1", "arg"}))
+		})
+
+		It("should run other executables directly", func() {
+			path, arguments := interpreterCommand(types.Hook{Type: types.HookTypeExecutable}, "/usr/bin/notify", []string{"arg"})
+			Expect(path).To(Equal("/usr/bin/notify"))
+			Expect(arguments).To(Equal([]string{"arg"}))
+		})
+	})
+
+	Context("quote", func() {
+		It("should quote empty arguments and arguments with whitespace or quotes", func() {
+			Expect(quote([]string{"plain", "", "with space", `"quoted"`})).To(Equal([]string{"plain", `""`, `"with space"`, `"\"quoted\""`}))
+		})
+	})
+
+	Context("executeHook", func() {
+		var dir string
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("the specs run inline commands with sh")
+			}
+
+			var err error
+			dir, err = os.MkdirTemp("", "hooks")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should pass the event in environment variables", func() {
+			output := filepath.Join(dir, "output")
+			hook := types.Hook{
+				Name:      "record",
+				Type:      types.HookTypeInlineCommand,
+				Shell:     ptr.To(types.HookShellSh),
+				Arguments: []string{`printf '%s %s' "$KUBESWITCH_CONTEXT" "$KUBESWITCH_NAMESPACE" > "$0"`, output},
+			}
+
+			Expect(executeHook(log, hook, event)).To(Succeed())
+			Expect(os.ReadFile(output)).To(Equal([]byte("dev/eu kube-system")))
+		})
+
+		It("should retry failed executions", func() {
+			attempts := filepath.Join(dir, "attempts")
+			hook := types.Hook{
+				Name:      "flaky",
+				Type:      types.HookTypeInlineCommand,
+				Shell:     ptr.To(types.HookShellSh),
+				Arguments: []string{`echo attempt >> "$0"; [ "$(wc -l < "$0")" -ge 3 ]`, attempts},
+				Execution: &types.HookExecution{Retries: ptr.To(3)},
+			}
+
+			Expect(executeHook(log, hook, event)).To(Succeed())
+			Expect(os.ReadFile(attempts)).To(Equal([]byte("attempt\nattempt\nattempt\n")))
+		})
+
+		It("should fail after the last retry", func() {
+			hook := types.Hook{
+				Name:      "failing",
+				Type:      types.HookTypeInlineCommand,
+				Shell:     ptr.To(types.HookShellSh),
+				Arguments: []string{"exit 1"},
+				Execution: &types.HookExecution{Retries: ptr.To(1)},
+			}
+
+			Expect(executeHook(log, hook, event)).To(MatchError(ContainSubstring(`error running hook "failing"`)))
+		})
+
+		It("should stop hooks that exceed the timeout", func() {
+			hook := types.Hook{
+				Name:      "slow",
+				Type:      types.HookTypeInlineCommand,
+				Shell:     ptr.To(types.HookShellSh),
+				Arguments: []string{"sleep 10"},
+				Execution: &types.HookExecution{Timeout: ptr.To(100 * time.Millisecond)},
+			}
+
+			start := time.Now()
+			Expect(executeHook(log, hook, event)).To(MatchError(`hook "slow" timed out after 100ms`))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should fail if the executable does not exist", func() {
+			hook := types.Hook{Name: "missing", Type: types.HookTypeExecutable, Path: ptr.To(filepath.Join(dir, "missing"))}
+			Expect(executeHook(log, hook, event)).To(MatchError(ContainSubstring("File does not exist")))
+		})
+	})
+
+	Context("getHooksToBeExecuted", func() {
+		var stateDir string
+
+		BeforeEach(func() {
+			var err error
+			stateDir, err = os.MkdirTemp("", "hooks")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(stateDir)).To(Succeed())
+		})
+
+		It("should return the pre-search hooks whose interval elapsed", func() {
+			hourly := types.Hook{
+				Name:      "hourly",
+				Type:      types.HookTypeInlineCommand,
+				Arguments: []string{"true"},
+				Execution: &types.HookExecution{Interval: ptr.To(time.Hour)},
+			}
+			onDemand := types.Hook{Name: "on-demand", Type: types.HookTypeInlineCommand, Arguments: []string{"true"}}
+
+			Expect(getHooksToBeExecuted(log, []types.Hook{hourly, onDemand}, stateDir)).To(Equal([]types.Hook{hourly}))
+
+			Expect(os.WriteFile(getHookStateFileName("hourly", stateDir), []byte("hookName: hourly\nlastExecutionTime: "+time.Now().UTC().Format(time.RFC3339)+"\n"), 0600)).To(Succeed())
+			Expect(getHooksToBeExecuted(log, []types.Hook{hourly}, stateDir)).To(BeEmpty())
+
+			Expect(os.WriteFile(getHookStateFileName("hourly", stateDir), []byte("hookName: hourly\nlastExecutionTime: "+time.Now().UTC().Add(-2*time.Hour).Format(time.RFC3339)+"\n"), 0600)).To(Succeed())
+			Expect(getHooksToBeExecuted(log, []types.Hook{hourly}, stateDir)).To(Equal([]types.Hook{hourly}))
+		})
+	})
+
+	Context("postSwitchExecutions", func() {
+		It("should return the post-switch hooks scoped to the store of the new context", func() {
+			config := &types.Config{
+				KubeconfigStores: []types.KubeconfigStore{
+					{Kind: types.StoreKindFilesystem},
+					{Kind: types.StoreKindEKS, ID: ptr.To("prod")},
+				},
+				Hooks: []types.Hook{
+					{Name: "all", Type: types.HookTypeTerminalTitle},
+					{Name: "filesystem", Type: types.HookTypeInlineCommand, Trigger: types.HookTriggerPostSwitch, Stores: []string{"filesystem"}},
+					{Name: "eks", Type: types.HookTypeInlineCommand, Trigger: types.HookTriggerPostSwitch, Stores: []string{"prod"}},
+					{Name: "pre-search", Type: types.HookTypeInlineCommand},
+				},
+			}
+
+			executions, err := postSwitchExecutions(config, func() (*Event, error) {
+				return event, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			var names []string
+			for _, execution := range executions {
+				Expect(execution.event).To(BeIdenticalTo(event))
+				names = append(names, execution.hook.Name)
+			}
+			Expect(names).To(Equal([]string{"all", "filesystem"}))
+		})
+
+		It("should not read the event without post-switch hooks", func() {
+			config := &types.Config{Hooks: []types.Hook{{Name: "pre-search", Type: types.HookTypeInlineCommand}}}
+
+			executions, err := postSwitchExecutions(config, func() (*Event, error) {
+				Fail("the event must not be read")
+				return nil, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executions).To(BeEmpty())
+		})
+	})
+
+	Context("terminal title", func() {
+		// the terminal is detected by the environment variables
+		var environment map[string]string
+
+		BeforeEach(func() {
+			environment = map[string]string{}
+			for _, key := range []string{"TERM_PROGRAM", "LC_TERMINAL", "TMUX"} {
+				if value, ok := os.LookupEnv(key); ok {
+					environment[key] = value
+				}
+				Expect(os.Unsetenv(key)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			for _, key := range []string{"TERM_PROGRAM", "LC_TERMINAL", "TMUX"} {
+				Expect(os.Unsetenv(key)).To(Succeed())
+				if value, ok := environment[key]; ok {
+					Expect(os.Setenv(key, value)).To(Succeed())
+				}
+			}
+		})
+
+		It("should default the title to the context and namespace", func() {
+			title, badge, err := resolveTitle(types.Hook{Type: types.HookTypeTerminalTitle}, event)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(title).To(Equal("dev/eu (kube-system)"))
+			Expect(*badge).To(Equal(title))
+		})
+
+		It("should expand the configured title and badge", func() {
+			hook := types.Hook{Type: types.HookTypeTerminalTitle, Title: ptr.To("k8s: {{ .Context }}"), Badge: ptr.To("")}
+
+			title, badge, err := resolveTitle(hook, event)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(title).To(Equal("k8s: dev/eu"))
+			Expect(*badge).To(BeEmpty())
+		})
+
+		It("should remove control characters from the title", func() {
+			Expect(terminalSequences("dev\007\033]0;evil", nil, event)).To(Equal("\033]0;dev]0;evil\007"))
+		})
+
+		It("should set the badge and user variables in iTerm2", func() {
+			Expect(os.Setenv("LC_TERMINAL", "iTerm2")).To(Succeed())
+
+			sequences := terminalSequences("dev/eu", ptr.To("dev"), event)
+			Expect(sequences).To(HavePrefix("\033]0;dev/eu\007"))
+			Expect(sequences).To(ContainSubstring("\033]1337;SetUserVar=kubeswitchContext=ZGV2L2V1\007"))
+			Expect(sequences).To(ContainSubstring("\033]1337;SetBadgeFormat=ZGV2\007"))
+		})
+	})
+})
@@ -0,0 +1,158 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/utils/ptr"
+
+	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultTitle is the title set by hooks of type "TerminalTitle" without a configured title
+const defaultTitle = "{{ .Context }}{{ with .Namespace }} ({{ . }}){{ end }}"
+
+// ClearTerminalTitle clears the terminal title and the iTerm2 badge set by hooks of type "TerminalTitle",
+// e.g. when the context is unset or the shell exits. Does nothing if no such hook is configured.
+func ClearTerminalTitle(log *logrus.Entry, configPath string) error {
+	config, err := switchconfig.LoadConfig(configPath)
+	if err != nil || config == nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(config.Hooks, func(hook types.Hook) bool {
+		return hook.Type == types.HookTypeTerminalTitle
+	}) {
+		return nil
+	}
+	return writeTerminal(log, terminalSequences("", ptr.To(""), nil))
+}
+
+// setTerminalTitle sets the title of the terminal to the new context.
+// In iTerm2, it additionally sets the badge and the user variables "kubeswitchContext" and "kubeswitchNamespace",
+// which can be referenced in the title and badge of an iTerm2 profile, e.g. \(user.kubeswitchContext).
+func setTerminalTitle(log *logrus.Entry, hook types.Hook, event *Event) error {
+	title, badge, err := resolveTitle(hook, event)
+	if err != nil {
+		return err
+	}
+	return writeTerminal(log, terminalSequences(title, badge, event))
+}
+
+// resolveTitle returns the expanded title and badge of the hook
+func resolveTitle(hook types.Hook, event *Event) (string, *string, error) {
+	data := Event{}
+	var env map[string]string
+	if event != nil {
+		data = *event
+		env = event.environment()
+	}
+
+	titleTemplate := defaultTitle
+	if hook.Title != nil {
+		titleTemplate = *hook.Title
+	}
+	title, err := switchconfig.ExpandString(titleTemplate, data, env)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to expand the title of hook %q: %v", hook.Name, err)
+	}
+
+	badge := &title
+	if hook.Badge != nil {
+		expanded, err := switchconfig.ExpandString(*hook.Badge, data, env)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to expand the badge of hook %q: %v", hook.Name, err)
+		}
+		badge = &expanded
+	}
+	return title, badge, nil
+}
+
+// terminalSequences returns the escape sequences setting the title and, in iTerm2, the badge and the user variables of the event.
+// The iTerm2 sequences are passed through tmux, which requires "set -g allow-passthrough on".
+func terminalSequences(title string, badge *string, event *Event) string {
+	// OSC 0 sets the title of the window and the tab
+	sequences := fmt.Sprintf("\033]0;%s\007", sanitizeTitle(title))
+
+	if !isITerm2() {
+		return sequences
+	}
+
+	var context, namespace string
+	if event != nil {
+		context, namespace = event.Context, event.Namespace
+	}
+
+	iterm := []string{
+		iTerm2Sequence("SetUserVar=kubeswitchContext=" + base64.StdEncoding.EncodeToString([]byte(context))),
+		iTerm2Sequence("SetUserVar=kubeswitchNamespace=" + base64.StdEncoding.EncodeToString([]byte(namespace))),
+	}
+	if badge != nil {
+		iterm = append(iterm, iTerm2Sequence("SetBadgeFormat="+base64.StdEncoding.EncodeToString([]byte(*badge))))
+	}
+	return sequences + strings.Join(iterm, "")
+}
+
+// iTerm2Sequence returns the proprietary escape sequence (OSC 1337) of iTerm2, wrapped for the passthrough of tmux
+func iTerm2Sequence(command string) string {
+	sequence := fmt.Sprintf("\033]1337;%s\007", command)
+	if len(os.Getenv("TMUX")) == 0 {
+		return sequence
+	}
+	return "\033Ptmux;" + strings.ReplaceAll(sequence, "\033", "\033\033") + "\033\\"
+}
+
+// isITerm2 returns true if the terminal is iTerm2. LC_TERMINAL is also forwarded by SSH.
+func isITerm2() bool {
+	return os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("LC_TERMINAL") == "iTerm2"
+}
+
+// sanitizeTitle removes the control characters, which would end the escape sequence
+func sanitizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7f && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, title)
+}
+
+// writeTerminal writes the escape sequences to the terminal.
+// The standard output is read by the shell function, hence the terminal is opened directly.
+// Does nothing if the process is not attached to a terminal, e.g. when running in the background.
+func writeTerminal(log *logrus.Entry, sequences string) error {
+	device := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		device = "CONOUT$"
+	}
+
+	terminal, err := os.OpenFile(device, os.O_WRONLY, 0)
+	if err != nil {
+		log.Debugf("cannot set the terminal title: %v", err)
+		return nil
+	}
+	defer terminal.Close()
+
+	_, err = terminal.WriteString(sequences)
+	return err
+}
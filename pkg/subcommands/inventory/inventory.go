@@ -0,0 +1,221 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// FormatCSV prints one line per cluster with a header
+	FormatCSV = "csv"
+	// FormatJSON prints the clusters as JSON array
+	FormatJSON = "json"
+)
+
+var logger = logging.New()
+
+// providers are the cloud providers of the kubeconfig store kinds discovering managed clusters
+var providers = map[types.StoreKind]string{
+	types.StoreKindEKS:          "aws",
+	types.StoreKindGKE:          "gcp",
+	types.StoreKindAzure:        "azure",
+	types.StoreKindExoscale:     "exoscale",
+	types.StoreKindOVH:          "ovh",
+	types.StoreKindScaleway:     "scaleway",
+	types.StoreKindDigitalOcean: "digitalocean",
+	types.StoreKindAkamai:       "akamai",
+}
+
+// Cluster is a cluster discovered in a kubeconfig store with the contexts pointing to it
+type Cluster struct {
+	// Name is the cluster name tagged by the kubeconfig store. Defaults to the first context name.
+	Name string `json:"name"`
+	// Contexts are the names (or aliases) of the contexts of the cluster
+	Contexts []string `json:"contexts"`
+	// Store is the ID of the kubeconfig store of the cluster
+	Store string `json:"store"`
+	// StoreKind is the kind of the kubeconfig store of the cluster
+	StoreKind types.StoreKind `json:"storeKind"`
+	// Provider is the cloud provider of the cluster, if known from the store kind
+	Provider string `json:"provider,omitempty"`
+	// Account is the cloud account, project or subscription of the cluster, if known to the store
+	Account string `json:"account,omitempty"`
+	// Region is the region or zone of the cluster, if known to the store
+	Region string `json:"region,omitempty"`
+	// KubernetesVersion is the Kubernetes version of the cluster, if returned by the kubeconfig store
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// Server is the URL of the API server, if known
+	Server string `json:"server,omitempty"`
+	// Tags are the other tags of the kubeconfig store, e.g. the cluster ID
+	Tags map[string]string `json:"tags,omitempty"`
+	// CloudTags are the cloud tags of the cluster selected by the "cloudTags" of the kubeconfig store, e.g. owner and team
+	CloudTags map[string]string `json:"cloudTags,omitempty"`
+}
+
+// Inventory returns the clusters with at least one context matching the wildcard pattern, sorted by store and name.
+// Contexts of the same kubeconfig store with the same API server belong to one cluster.
+// Errors of kubeconfig stores are logged, so that the inventory contains the clusters of the other stores.
+func Inventory(pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]Cluster, error) {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create inventory: %v", err)
+	}
+
+	var (
+		match = wildmatch.NewWildMatch(pattern)
+		// the discovered contexts of each cluster by name (or alias)
+		clusterContexts = map[string]map[string]pkg.DiscoveredContext{}
+	)
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("incomplete inventory. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Merged(config) {
+			continue
+		}
+
+		name := discoveredContext.Name
+		if len(discoveredContext.Alias) > 0 {
+			name = discoveredContext.Alias
+		}
+		if len(pattern) > 0 && !match.IsMatch(name) {
+			continue
+		}
+
+		storeID := (*discoveredContext.Store).GetID()
+		// the API server is unknown for contexts of invalid kubeconfigs
+		key := fmt.Sprintf("%s\x00%s", storeID, discoveredContext.Server)
+		if len(discoveredContext.Server) == 0 {
+			key = fmt.Sprintf("%s\x00\x00%s", storeID, name)
+		}
+
+		if _, ok := clusterContexts[key]; !ok {
+			clusterContexts[key] = map[string]pkg.DiscoveredContext{}
+		}
+		clusterContexts[key][name] = discoveredContext
+	}
+
+	result := make([]Cluster, 0, len(clusterContexts))
+	for _, contexts := range clusterContexts {
+		names := make([]string, 0, len(contexts))
+		for name := range contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		// the metadata of the cluster is taken from its first context, as the search results are not ordered
+		cluster := newCluster(contexts[names[0]])
+		cluster.Contexts = names
+		if len(cluster.Name) == 0 {
+			cluster.Name = names[0]
+		}
+		result = append(result, cluster)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Store != result[j].Store {
+			return result[i].Store < result[j].Store
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// newCluster returns the cluster of the discovered context without contexts
+func newCluster(discoveredContext pkg.DiscoveredContext) Cluster {
+	store := *discoveredContext.Store
+	tags := discoveredContext.Tags
+	cluster := Cluster{
+		Name:              pkg.ClusterOf(tags),
+		Store:             store.GetID(),
+		StoreKind:         store.GetKind(),
+		Provider:          providers[store.GetKind()],
+		Account:           pkg.AccountOf(tags),
+		Region:            pkg.RegionOf(tags),
+		KubernetesVersion: tags[storetypes.TagKubernetesVersion],
+		Server:            discoveredContext.Server,
+		CloudTags:         storetypes.CloudTags(tags),
+	}
+
+	for key, value := range tags {
+		if key == storetypes.TagKubernetesVersion || strings.HasPrefix(key, storetypes.CloudTagPrefix) {
+			continue
+		}
+		if cluster.Tags == nil {
+			cluster.Tags = map[string]string{}
+		}
+		cluster.Tags[key] = value
+	}
+	return cluster
+}
+
+// Print writes the clusters in the given format
+func Print(w io.Writer, clusters []Cluster, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(clusters)
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"name", "contexts", "store", "store_kind", "provider", "account", "region", "kubernetes_version", "server", "tags", "cloud_tags"}); err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			if err := writer.Write([]string{
+				cluster.Name,
+				strings.Join(cluster.Contexts, ";"),
+				cluster.Store,
+				string(cluster.StoreKind),
+				cluster.Provider,
+				cluster.Account,
+				cluster.Region,
+				cluster.KubernetesVersion,
+				cluster.Server,
+				joinTags(cluster.Tags),
+				joinTags(cluster.CloudTags),
+			}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown output format %q. Valid formats are %q and %q", format, FormatCSV, FormatJSON)
+	}
+}
+
+// joinTags returns the tags as "key=value" pairs sorted by key and separated by semicolons
+func joinTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
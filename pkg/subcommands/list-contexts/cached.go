@@ -0,0 +1,99 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list_contexts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+)
+
+// CachedContext is a context name or alias read from the local state
+type CachedContext struct {
+	// Name is the context name or alias
+	Name string
+	// Description summarizes where the context comes from, e.g. the store kind and tags
+	Description string
+}
+
+// ListCachedContexts returns the context names and aliases starting with the given prefix.
+// In contrast to ListContexts, the kubeconfig stores are not searched. The contexts are read from the index files
+// in the state directory instead, so that the result is returned fast enough for shell completion, but might be outdated.
+// As the index does not know the kubeconfig stores, only the given (global) exclusions apply.
+func ListCachedContexts(prefix, stateDir string, exclusions pkg.Exclusions) ([]CachedContext, error) {
+	indexes, err := index.LoadAll(logrus.NewEntry(logger), stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := map[string]string{}
+	for _, i := range indexes {
+		for contextName, path := range i.ContextToPathMapping {
+			if !strings.HasPrefix(contextName, prefix) || exclusions.Excludes(path, contextName) {
+				continue
+			}
+			if _, ok := descriptions[contextName]; ok {
+				// the same context name is indexed by multiple stores
+				continue
+			}
+			descriptions[contextName] = describe(string(i.Kind), i.ContextToTags[contextName])
+		}
+	}
+
+	a, err := state.GetDefaultAlias(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	for contextName, alias := range a.Content.ContextToAliasMapping {
+		if !strings.HasPrefix(alias, prefix) || exclusions.Excludes("", contextName, alias) {
+			continue
+		}
+		description := fmt.Sprintf("alias for %s", contextName)
+		if namespace := a.Content.AliasToNamespaceMapping[alias]; len(namespace) > 0 {
+			description = fmt.Sprintf("%s (namespace %s)", description, namespace)
+		}
+		descriptions[alias] = description
+	}
+
+	contexts := make([]CachedContext, 0, len(descriptions))
+	for name, description := range descriptions {
+		contexts = append(contexts, CachedContext{Name: name, Description: description})
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
+	return contexts, nil
+}
+
+// describe returns the store kind followed by the tags of the context sorted by key
+func describe(kind string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{kind}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, tags[key]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -19,40 +19,88 @@ import (
 	"sort"
 
 	"github.com/becheran/wildmatch-go"
-	"github.com/sirupsen/logrus"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-var logger = logrus.New()
+var logger = logging.New()
 
+// ListContexts returns the sorted names (or aliases) of the discovered contexts matching the wildcard pattern
 func ListContexts(pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]string, error) {
+	return ListContextsMatching(wildmatch.NewWildMatch(pattern).IsMatch, stores, config, stateDir, noIndex)
+}
+
+// ListContextsMatching returns the sorted names (or aliases) of the discovered contexts the match function returns true for
+func ListContextsMatching(match func(name string) bool, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]string, error) {
+	contexts, err := ListContextDetailsMatching(match, stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		names = append(names, context.Name)
+	}
+	return names, nil
+}
+
+// Context is a discovered context as printed by "switch list-contexts -o json"
+type Context struct {
+	// Name is the context name or alias
+	Name string `json:"name"`
+	// Store is the ID of the kubeconfig store of the context
+	Store string `json:"store"`
+	// Server is the URL of the API server, if known
+	Server string `json:"server,omitempty"`
+	// KubernetesVersion is the Kubernetes version of the cluster, if returned by the kubeconfig store
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// CloudTags are the cloud tags of the cluster selected by the "cloudTags" of the kubeconfig store, e.g. owner and team
+	CloudTags map[string]string `json:"cloudTags,omitempty"`
+	// Offline is true if the context was read from the search index in offline mode, as its credentials may be stale
+	Offline bool `json:"offline,omitempty"`
+}
+
+// ListContextDetailsMatching returns the discovered contexts sorted by name (or alias) the match function returns true for
+func ListContextDetailsMatching(match func(name string) bool, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]Context, error) {
 	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
 	if err != nil {
 		return nil, fmt.Errorf("cannot list contexts: %v", err)
 	}
 
-	m := wildmatch.NewWildMatch(pattern)
-	var contexts []string
+	hidden := pkg.HiddenByAccessCheck(config, stateDir, stores)
+
+	var contexts []Context
 	for discoveredKubeconfig := range *c {
 		if discoveredKubeconfig.Error != nil {
 			logger.Warnf("cannot list contexts. Error returned from search: %v", discoveredKubeconfig.Error)
 			continue
 		}
+		if discoveredKubeconfig.Merged(config) || (hidden != nil && hidden(discoveredKubeconfig)) {
+			continue
+		}
 
 		name := discoveredKubeconfig.Name
 		if len(discoveredKubeconfig.Alias) > 0 {
 			name = discoveredKubeconfig.Alias
 		}
-		result := m.IsMatch(name)
-		if result {
-			contexts = append(contexts, name)
+		if match(name) {
+			contexts = append(contexts, Context{
+				Name:              name,
+				Store:             (*discoveredKubeconfig.Store).GetID(),
+				Server:            discoveredKubeconfig.Server,
+				KubernetesVersion: discoveredKubeconfig.Tags[storetypes.TagKubernetesVersion],
+				CloudTags:         storetypes.CloudTags(discoveredKubeconfig.Tags),
+				Offline:           discoveredKubeconfig.Offline,
+			})
 		}
 	}
 	// Sort alphabetically
-	sort.Strings(contexts)
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
 
 	return contexts, nil
 }
@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package login
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+)
+
+// Login runs the authentication flow of each kubeconfig store and reports when the obtained credentials expire.
+// Stores are processed sequentially, as authentication flows may be interactive.
+func Login(stores []storetypes.KubeconfigStore) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STORE\tSTATUS\tEXPIRES")
+
+	failed := 0
+	for _, store := range stores {
+		status, expires, err := login(store)
+		if err != nil {
+			failed++
+			status = fmt.Sprintf("failed: %v", err)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", store.GetID(), status, expires)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("login failed for %d kubeconfig store(s)", failed)
+	}
+	return nil
+}
+
+// login returns the status of the store and when its credentials expire
+func login(store storetypes.KubeconfigStore) (string, string, error) {
+	authenticator, ok := store.(storetypes.Authenticator)
+	if !ok {
+		return verify(store)
+	}
+
+	expiry, err := authenticator.Login()
+	if errors.Is(err, storetypes.ErrLoginNotSupported) {
+		return verify(store)
+	}
+	if err != nil {
+		return "", "-", err
+	}
+	return "ok", formatExpiry(expiry), nil
+}
+
+// verify verifies the configuration of stores without authentication flow (e.g. with static API keys)
+func verify(store storetypes.KubeconfigStore) (string, string, error) {
+	if err := store.VerifyKubeconfigPaths(); err != nil {
+		return "", "-", err
+	}
+	return "no login required", "-", nil
+}
+
+func formatExpiry(expiry *time.Time) string {
+	if expiry == nil {
+		return "never"
+	}
+
+	remaining := time.Until(*expiry).Round(time.Minute)
+	if remaining <= 0 {
+		return fmt.Sprintf("expired (%s)", expiry.Local().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("in %s (%s)", remaining, expiry.Local().Format(time.RFC3339))
+}
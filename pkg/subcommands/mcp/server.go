@@ -0,0 +1,173 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mcp serves the capabilities of kubeswitch as Model Context Protocol (MCP) server on stdio,
+// so that AI assistants can enumerate and target clusters via the configured kubeconfig stores.
+// See https://modelcontextprotocol.io/specification for the protocol.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// protocolVersion is the implemented version of the Model Context Protocol
+const protocolVersion = "2024-11-05"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Options contains the configuration of the MCP server
+type Options struct {
+	// Version is the version of kubeswitch reported to the client
+	Version        string
+	StateDirectory string
+	NoIndex        bool
+	Stores         []storetypes.KubeconfigStore
+	Config         *types.Config
+	// ReadOnly only exposes tools that do not write temporary kubeconfigs or modify namespaces
+	ReadOnly bool
+	// OnSwitch is called with the path of the temporary kubeconfig after a context switch, e.g. to run post-switch hooks
+	OnSwitch func(kubeconfigPath string)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type server struct {
+	options Options
+	log     *logrus.Entry
+	tools   []tool
+}
+
+// Serve reads JSON-RPC messages from stdin and writes the responses to stdout until stdin is closed.
+// Logs are written to stderr, as stdout is reserved for the protocol.
+func Serve(options Options) error {
+	// the search temporarily redirects os.Stdout, hence keep a reference to the original file
+	return serve(options, os.Stdin, os.Stdout)
+}
+
+func serve(options Options, in io.Reader, out io.Writer) error {
+	s := &server{
+		options: options,
+		log:     logging.New().WithField("component", "mcp"),
+	}
+	s.log.Logger.SetOutput(os.Stderr)
+	s.tools = s.availableTools()
+
+	encoder := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	// tool calls might contain large arguments
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		req := request{}
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := encoder.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, rpcErr := s.handle(req)
+
+		// notifications do not have an ID and must not be answered
+		if len(req.ID) == 0 {
+			continue
+		}
+
+		if err := encoder.Encode(response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *server) handle(req request) (interface{}, *rpcError) {
+	s.log.Debugf("Received request %q", req.Method)
+
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    "kubeswitch",
+				"version": s.options.Version,
+			},
+			"instructions": "Use kubeswitch to find Kubernetes clusters and to get a kubeconfig for them. " +
+				"Pass the returned kubeconfig path via the KUBECONFIG environment variable or the --kubeconfig flag to kubectl. " +
+				"Credentials are never returned.",
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		var definitions []toolDefinition
+		for _, t := range s.tools {
+			definitions = append(definitions, t.definition)
+		}
+		return map[string]interface{}{"tools": definitions}, nil
+	case "tools/call":
+		params := struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return s.callTool(params.Name, params.Arguments)
+	default:
+		if len(req.ID) == 0 {
+			// unknown notifications (e.g. "notifications/initialized") are ignored
+			return nil, nil
+		}
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
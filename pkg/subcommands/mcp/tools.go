@@ -0,0 +1,399 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/ns"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/verify"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+)
+
+// defaultHistoryLimit is the default number of returned history entries
+const defaultHistoryLimit = 20
+
+type toolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+type tool struct {
+	definition toolDefinition
+	// readOnly tools do not write temporary kubeconfigs or modify namespaces
+	readOnly bool
+	call     func(arguments json.RawMessage) (interface{}, error)
+}
+
+// clusterContext is a context returned by the list_contexts tool
+type clusterContext struct {
+	Name    string            `json:"name"`
+	Alias   string            `json:"alias,omitempty"`
+	StoreID string            `json:"store"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+// switchResult is returned by the switch_context tool
+type switchResult struct {
+	Context        string `json:"context"`
+	KubeconfigPath string `json:"kubeconfigPath"`
+	Namespace      string `json:"namespace,omitempty"`
+	ClusterServer  string `json:"clusterServer,omitempty"`
+}
+
+// historyEntry is an entry returned by the get_history tool
+type historyEntry struct {
+	Context   string `json:"context"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (s *server) availableTools() []tool {
+	tools := []tool{
+		{
+			definition: toolDefinition{
+				Name:        "list_contexts",
+				Description: "Lists the Kubernetes contexts of all clusters discovered in the configured kubeconfig stores. Optionally filtered by a wildcard pattern such as \"*prod*\".",
+				InputSchema: objectSchema(map[string]interface{}{
+					"pattern": stringProperty("wildcard pattern the context name or alias has to match, e.g. \"*-prod-*\". Defaults to all contexts."),
+				}),
+			},
+			readOnly: true,
+			call:     s.listContexts,
+		},
+		{
+			definition: toolDefinition{
+				Name:        "switch_context",
+				Description: "Writes a temporary kubeconfig for the context and returns its path. Use the path via the KUBECONFIG environment variable or the --kubeconfig flag of kubectl. The context has to match exactly one discovered context. Does not modify the kubeconfig of the user's shell.",
+				InputSchema: objectSchema(map[string]interface{}{
+					"context": stringProperty("name or alias of the context as returned by list_contexts"),
+				}, "context"),
+			},
+			call: s.switchContext,
+		},
+		{
+			definition: toolDefinition{
+				Name:        "list_namespaces",
+				Description: "Lists the namespaces of the cluster of a kubeconfig returned by switch_context.",
+				InputSchema: objectSchema(map[string]interface{}{
+					"kubeconfigPath": stringProperty("path of the kubeconfig returned by switch_context"),
+				}, "kubeconfigPath"),
+			},
+			readOnly: true,
+			call:     s.listNamespaces,
+		},
+		{
+			definition: toolDefinition{
+				Name:        "set_namespace",
+				Description: "Sets the default namespace in a kubeconfig returned by switch_context. The namespace has to exist.",
+				InputSchema: objectSchema(map[string]interface{}{
+					"kubeconfigPath": stringProperty("path of the kubeconfig returned by switch_context"),
+					"namespace":      stringProperty("the namespace"),
+				}, "kubeconfigPath", "namespace"),
+			},
+			call: s.setNamespace,
+		},
+		{
+			definition: toolDefinition{
+				Name:        "get_history",
+				Description: "Returns the recently used contexts and namespaces, most recent first.",
+				InputSchema: objectSchema(map[string]interface{}{
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("maximum number of entries. Defaults to %d.", defaultHistoryLimit),
+					},
+				}),
+			},
+			readOnly: true,
+			call:     s.getHistory,
+		},
+	}
+
+	if !s.options.ReadOnly {
+		return tools
+	}
+
+	var readOnlyTools []tool
+	for _, t := range tools {
+		if t.readOnly {
+			readOnlyTools = append(readOnlyTools, t)
+		}
+	}
+	return readOnlyTools
+}
+
+// callTool calls the tool. Errors of the tool are returned as tool result, so that the assistant can see and handle them.
+func (s *server) callTool(name string, arguments json.RawMessage) (interface{}, *rpcError) {
+	for _, t := range s.tools {
+		if t.definition.Name != name {
+			continue
+		}
+
+		if len(arguments) == 0 {
+			arguments = json.RawMessage("{}")
+		}
+
+		result, err := t.call(arguments)
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+
+		text, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(string(text), false), nil
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("tool %q not found", name)}
+}
+
+func (s *server) listContexts(arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		Pattern string `json:"pattern"`
+	}{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Pattern) == 0 {
+		args.Pattern = "*"
+	}
+	m := wildmatch.NewWildMatch(args.Pattern)
+
+	discoveredContexts, err := s.search()
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := []clusterContext{}
+	for _, discoveredContext := range discoveredContexts {
+		if discoveredContext.Error != nil {
+			s.log.Warnf("error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+
+		if !m.IsMatch(discoveredContext.Name) && (len(discoveredContext.Alias) == 0 || !m.IsMatch(discoveredContext.Alias)) {
+			continue
+		}
+
+		contexts = append(contexts, clusterContext{
+			Name:    discoveredContext.Name,
+			Alias:   discoveredContext.Alias,
+			StoreID: (*discoveredContext.Store).GetID(),
+			Tags:    discoveredContext.Tags,
+		})
+	}
+
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
+	return contexts, nil
+}
+
+func (s *server) switchContext(arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		Context string `json:"context"`
+	}{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Context) == 0 {
+		return nil, fmt.Errorf("argument \"context\" is required")
+	}
+
+	discoveredContexts, err := s.search()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfigPath, contextName, err := setcontext.SetContextExactFromResults(args.Context, discoveredContexts, s.options.Config, s.options.StateDirectory, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// protected contexts require typing the context name in the terminal
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(*kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if verify.IsProtected(s.options.Config.ProtectedContexts, *contextName, kubeconfig.GetCurrentContext()) {
+		_ = os.Remove(*kubeconfigPath)
+		return nil, fmt.Errorf("context %q is protected and can only be switched to interactively by the user", *contextName)
+	}
+
+	if s.options.OnSwitch != nil {
+		s.options.OnSwitch(*kubeconfigPath)
+	}
+
+	result := switchResult{
+		Context:        *contextName,
+		KubeconfigPath: *kubeconfigPath,
+	}
+
+	if namespace, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext()); err == nil {
+		result.Namespace = namespace
+	}
+	if server, err := kubeconfig.ServerOfContext(kubeconfig.GetCurrentContext()); err == nil {
+		result.ClusterServer = server
+	}
+	return result, nil
+}
+
+func (s *server) listNamespaces(arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		KubeconfigPath string `json:"kubeconfigPath"`
+	}{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := validateTemporaryKubeconfig(args.KubeconfigPath); err != nil {
+		return nil, err
+	}
+
+	return ns.ListNamespaces(args.KubeconfigPath, s.options.StateDirectory, s.options.NoIndex)
+}
+
+func (s *server) setNamespace(arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		KubeconfigPath string `json:"kubeconfigPath"`
+		Namespace      string `json:"namespace"`
+	}{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Namespace) == 0 {
+		return nil, fmt.Errorf("argument \"namespace\" is required")
+	}
+	if err := validateTemporaryKubeconfig(args.KubeconfigPath); err != nil {
+		return nil, err
+	}
+
+	if err := ns.SwitchToNamespace(args.Namespace, args.KubeconfigPath, true); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"kubeconfigPath": args.KubeconfigPath,
+		"namespace":      args.Namespace,
+	}, nil
+}
+
+func (s *server) getHistory(arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		Limit int `json:"limit"`
+	}{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Limit <= 0 {
+		args.Limit = defaultHistoryLimit
+	}
+
+	lines, err := historyutil.ReadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []historyEntry{}
+	for _, line := range lines {
+		if len(entries) == args.Limit {
+			break
+		}
+
+		context, namespace, err := historyutil.ParseHistoryEntry(line)
+		if err != nil {
+			continue
+		}
+
+		entry := historyEntry{Context: *context}
+		if namespace != nil {
+			entry.Namespace = *namespace
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// search returns the contexts of all kubeconfig stores
+func (s *server) search() ([]pkg.DiscoveredContext, error) {
+	c, err := pkg.DoSearch(s.options.Stores, s.options.Config, s.options.StateDirectory, s.options.NoIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var discoveredContexts []pkg.DiscoveredContext
+	for discoveredContext := range *c {
+		discoveredContexts = append(discoveredContexts, discoveredContext)
+	}
+	return discoveredContexts, nil
+}
+
+// validateTemporaryKubeconfig makes sure that the tools only read and modify temporary kubeconfigs written by kubeswitch,
+// but not other kubeconfig files of the user
+func validateTemporaryKubeconfig(kubeconfigPath string) error {
+	if len(kubeconfigPath) == 0 {
+		return fmt.Errorf("argument \"kubeconfigPath\" is required")
+	}
+
+	path, err := filepath.Abs(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir))
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return fmt.Errorf("%q is not a kubeconfig returned by switch_context", kubeconfigPath)
+	}
+	return nil
+}
+
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{"type": "text", "text": text},
+		},
+		"isError": isError,
+	}
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// notesFileName is the filename of the state file that contains the notes of all contexts
+const notesFileName = "switch.notes"
+
+// GetNotes returns the notes by context name.
+// Returns no notes if the state file does not exist yet.
+func GetNotes(stateDir string) (map[string]string, error) {
+	path := filePath(stateDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read notes file %q: %v", path, err)
+	}
+
+	notes := types.ContextNotes{}
+	if err := yaml.Unmarshal(content, &notes); err != nil {
+		return nil, fmt.Errorf("could not unmarshal notes file %q: %v", path, err)
+	}
+	return notes.ContextToNote, nil
+}
+
+// GetNote returns the note of the context or an empty string
+func GetNote(stateDir, contextName string) (string, error) {
+	notes, err := GetNotes(stateDir)
+	if err != nil {
+		return "", err
+	}
+	return notes[contextName], nil
+}
+
+// SetNote attaches the note to the context. An empty note removes the note of the context.
+func SetNote(stateDir, contextName, note string) error {
+	if err := permissions.MkdirAll(stateDir); err != nil {
+		return err
+	}
+
+	path := filePath(stateDir)
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// the notes might have been changed by another terminal
+	notes, err := GetNotes(stateDir)
+	if err != nil {
+		return err
+	}
+	if notes == nil {
+		notes = make(map[string]string, 1)
+	}
+
+	note = strings.TrimSpace(note)
+	if len(note) == 0 {
+		delete(notes, contextName)
+	} else {
+		notes[contextName] = note
+	}
+
+	output, err := yaml.Marshal(types.ContextNotes{ContextToNote: notes})
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(path, output, permissions.FileMode)
+}
+
+// ListNotes prints the notes of all contexts sorted by context name
+func ListNotes(stateDir string) error {
+	notes, err := GetNotes(stateDir)
+	if err != nil {
+		return err
+	}
+
+	if len(notes) == 0 {
+		fmt.Println("No notes attached to contexts")
+		return nil
+	}
+
+	contexts := make([]string, 0, len(notes))
+	for context := range notes {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Context", "Note"})
+	for _, context := range contexts {
+		t.AppendRow(table.Row{context, notes[context]})
+	}
+	t.AppendSeparator()
+	t.AppendFooter(table.Row{"Total", len(notes)})
+	t.Render()
+	return nil
+}
+
+// EditCommand returns the command opening the note of the context in the editor of the user ($VISUAL or $EDITOR).
+// The returned save function attaches the edited note to the context once the editor exits and returns the saved note.
+func EditCommand(stateDir, contextName string) (*exec.Cmd, func() (string, error), error) {
+	note, err := GetNote(stateDir, contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.CreateTemp("", "kubeswitch-note-*.txt")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary file for the note: %v", err)
+	}
+	defer file.Close()
+
+	if len(note) > 0 {
+		note += "\n"
+	}
+	if _, err := file.WriteString(note); err != nil {
+		_ = os.Remove(file.Name())
+		return nil, nil, fmt.Errorf("failed to write temporary file for the note: %v", err)
+	}
+
+	save := func() (string, error) {
+		defer os.Remove(file.Name())
+
+		content, err := os.ReadFile(file.Name())
+		if err != nil {
+			return "", fmt.Errorf("failed to read the edited note: %v", err)
+		}
+		edited := strings.TrimSpace(string(content))
+		return edited, SetNote(stateDir, contextName, edited)
+	}
+	return editorCommand(file.Name()), save, nil
+}
+
+// editorCommand returns the command opening the file in the editor of the user.
+// The editor may contain arguments, e.g. "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if runtime.GOOS == "windows" {
+		if len(editor) == 0 {
+			editor = "notepad"
+		}
+		return util.CmdCommand(context.Background(), fmt.Sprintf(`%s "%s"`, editor, path))
+	}
+
+	if len(editor) == 0 {
+		editor = "vi"
+	}
+	return exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+}
+
+func filePath(stateDir string) string {
+	return fmt.Sprintf("%s/%s", stateDir, notesFileName)
+}
@@ -18,14 +18,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/ktr0731/go-fuzzyfinder"
-	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,20 +35,20 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
-	defaultKubeconfigPath       = "$HOME/.kube/config"
-	linuxEnvKubeconfigSeperator = ":"
+	defaultKubeconfigPath = "$HOME/.kube/config"
 )
 
 var (
 	kubeconfigPathFromEnv = os.Getenv("KUBECONFIG")
 	// only use namespace cache for contexts switched to by the switch tool
 	cache         *NamespaceCache
-	logger        = logrus.New()
+	logger        = logging.New()
 	hotReloadLock sync.RWMutex
 
 	allNamespaces []string
@@ -55,13 +56,13 @@ var (
 
 // SwitchToNamespace takes a target namespace and - given that the namespace exists - sets it on the current kubeconfig file
 func SwitchToNamespace(targetNamespace, kubeconfigPathFromFlag string, checkExistence bool) error {
-	kubeconfigPath, err := getKubeconfigPath(kubeconfigPathFromFlag)
+	kubeconfigPaths, err := getKubeconfigPaths(kubeconfigPathFromFlag)
 	if err != nil {
 		return err
 	}
 
 	if checkExistence {
-		c, err := getClient(kubeconfigPath)
+		c, err := getClient(kubeconfigPaths)
 		if err != nil {
 			return fmt.Errorf("failed to retrieve current namespaces: %v", err)
 		}
@@ -78,21 +79,16 @@ func SwitchToNamespace(targetNamespace, kubeconfigPathFromFlag string, checkExis
 		}
 	}
 
-	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	kubeconfigFiles, err := kubeconfigutil.LoadKubeconfigFiles(kubeconfigPaths)
 	if err != nil {
 		return err
 	}
 
-	if err := kubeconfig.SetNamespaceForCurrentContext(targetNamespace); err != nil {
-		return fmt.Errorf("failed to set namespace %q: %v", targetNamespace, err)
-	}
-
-	// this updates the actual kubeconfif file (does not create a new tmp. kubeconfig to set namespace)
-	if _, err := kubeconfig.WriteKubeconfigFile(); err != nil {
-		return fmt.Errorf("failed to write kubeconfig file: %v", err)
+	if err := setNamespace(kubeconfigFiles, targetNamespace); err != nil {
+		return err
 	}
 
-	kubeswitchContext := kubeconfig.GetKubeswitchContext()
+	kubeswitchContext := kubeconfigFiles.CurrentContextFile().GetKubeswitchContext()
 	if err := historyutil.AppendToHistory(kubeswitchContext, targetNamespace); err != nil {
 		return fmt.Errorf("failed to write namespace history: %v", err)
 	}
@@ -100,22 +96,67 @@ func SwitchToNamespace(targetNamespace, kubeconfigPathFromFlag string, checkExis
 	return nil
 }
 
+// SwitchToPreviousNamespace changes to the namespace of the current context that was used before the current namespace.
+// The previous namespace is taken from the history, which only contains contexts switched to with kubeswitch.
+func SwitchToPreviousNamespace(kubeconfigPathFromFlag string, checkExistence bool) error {
+	kubeconfigPaths, err := getKubeconfigPaths(kubeconfigPathFromFlag)
+	if err != nil {
+		return err
+	}
+
+	kubeconfigFiles, err := kubeconfigutil.LoadKubeconfigFiles(kubeconfigPaths)
+	if err != nil {
+		return err
+	}
+
+	kubeswitchContext := kubeconfigFiles.CurrentContextFile().GetKubeswitchContext()
+	if len(kubeswitchContext) == 0 {
+		return fmt.Errorf("the previous namespace is only known for contexts switched to with kubeswitch")
+	}
+
+	currentContext := kubeconfigFiles.CurrentContext()
+	kubeconfig, err := kubeconfigFiles.ContextFile(currentContext)
+	if err != nil {
+		return err
+	}
+
+	currentNamespace, err := kubeconfig.NamespaceOfContext(currentContext)
+	if err != nil {
+		return err
+	}
+
+	previousNamespace, err := historyutil.PreviousNamespace(kubeswitchContext, currentNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to read the history: %v", err)
+	}
+	if len(previousNamespace) == 0 {
+		return fmt.Errorf("the history does not contain a previous namespace of context %q", kubeswitchContext)
+	}
+
+	if err := SwitchToNamespace(previousNamespace, kubeconfigPathFromFlag, checkExistence); err != nil {
+		return err
+	}
+
+	fmt.Printf("switched to namespace %q\n", previousNamespace)
+	return nil
+}
+
 // SwitchNamespace retrieves all available namespaces (either via API call or from local cache)
 // Then sets the selected namespace on the current kubeconfig file (does not create a new tmp. kubeconfig to set namespace)
 func SwitchNamespace(kubeconfigPathFromFlag, stateDir string, noIndex bool) error {
 	cachedNamespaces := sets.NewString()
 
-	kubeconfigPath, err := getKubeconfigPath(kubeconfigPathFromFlag)
+	kubeconfigPaths, err := getKubeconfigPaths(kubeconfigPathFromFlag)
 	if err != nil {
 		return err
 	}
 
-	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	kubeconfigFiles, err := kubeconfigutil.LoadKubeconfigFiles(kubeconfigPaths)
 	if err != nil {
 		return err
 	}
 
-	kubeswitchContext := kubeconfig.GetKubeswitchContext()
+	kubeswitchContext := kubeconfigFiles.CurrentContextFile().GetKubeswitchContext()
 
 	if len(kubeswitchContext) > 0 && !noIndex {
 		cache, err = NewNamespaceCache(stateDir, kubeswitchContext)
@@ -131,7 +172,7 @@ func SwitchNamespace(kubeconfigPathFromFlag, stateDir string, noIndex bool) erro
 
 		cachedNamespaces.Insert(allNamespaces...)
 
-		client, err := getClient(kubeconfigPath)
+		client, err := getClient(kubeconfigPaths)
 		if err != nil {
 			logger.Warnf("failed to retrieve current namespaces: %v", err)
 			return
@@ -183,14 +224,8 @@ func SwitchNamespace(kubeconfigPathFromFlag, stateDir string, noIndex bool) erro
 
 	selectedNamespace := allNamespaces[idx]
 
-	logger.Debugf("setting namespace %q to kubeconfig with path %q", selectedNamespace, kubeconfigPath)
-
-	if err := kubeconfig.SetNamespaceForCurrentContext(selectedNamespace); err != nil {
-		return fmt.Errorf("failed to set namespace %q: %v", selectedNamespace, err)
-	}
-
-	if _, err := kubeconfig.WriteKubeconfigFile(); err != nil {
-		return fmt.Errorf("failed to write kubeconfig file: %v", err)
+	if err := setNamespace(kubeconfigFiles, selectedNamespace); err != nil {
+		return err
 	}
 
 	if len(kubeswitchContext) == 0 {
@@ -204,20 +239,30 @@ func SwitchNamespace(kubeconfigPathFromFlag, stateDir string, noIndex bool) erro
 	return cache.Write(allNamespaces)
 }
 
+// ListCachedNamespaces returns the namespaces cached for the given context name without calling the API server.
+// The cache is filled when searching the namespaces of the context with "switch ns".
+func ListCachedNamespaces(stateDir, contextName string) ([]string, error) {
+	cache, err := NewNamespaceCache(stateDir, contextName)
+	if err != nil {
+		return nil, err
+	}
+	return cache.GetContent(), nil
+}
+
 // ListNamespaces retrieves all available namespaces (either via API call or from local cache)
 func ListNamespaces(kubeconfigPathFromFlag, stateDir string, noIndex bool) ([]string, error) {
 	cachedNamespaces := sets.NewString()
 
-	kubeconfigPath, err := getKubeconfigPath(kubeconfigPathFromFlag)
+	kubeconfigPaths, err := getKubeconfigPaths(kubeconfigPathFromFlag)
 	if err != nil {
 		return nil, err
 	}
-	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	kubeconfigFiles, err := kubeconfigutil.LoadKubeconfigFiles(kubeconfigPaths)
 	if err != nil {
 		return nil, err
 	}
 
-	kubeswitchContext := kubeconfig.GetKubeswitchContext()
+	kubeswitchContext := kubeconfigFiles.CurrentContextFile().GetKubeswitchContext()
 	if len(kubeswitchContext) == 0 {
 		// If no context return
 		return nil, nil
@@ -237,7 +282,7 @@ func ListNamespaces(kubeconfigPathFromFlag, stateDir string, noIndex bool) ([]st
 	cachedNamespaces.Insert(allNamespaces...)
 
 	// Build the Kubernetes client configuration
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	config, err := getRestConfig(kubeconfigPaths)
 	if err != nil {
 		return nil, err
 	}
@@ -286,36 +331,81 @@ func ListNamespaces(kubeconfigPathFromFlag, stateDir string, noIndex bool) ([]st
 	return allNamespaces, nil
 }
 
-func getKubeconfigPath(kubeconfigPathFromFlag string) (string, error) {
-	kubeconfigPath := kubeconfigPathFromFlag
+// setNamespace sets the namespace of the current context. Like kubectl, the namespace is set in the first kubeconfig file
+// defining the current context, which is not necessarily the file setting the current context.
+func setNamespace(kubeconfigFiles kubeconfigutil.KubeconfigFiles, namespace string) error {
+	currentContext := kubeconfigFiles.CurrentContext()
+	if len(currentContext) == 0 {
+		return fmt.Errorf("failed to set namespace %q: current-context is not set", namespace)
+	}
 
-	// kubeconfig path from flag is preferred over env (just not if it is only the default)
-	if (len(kubeconfigPath) == 0 || kubeconfigPath == os.ExpandEnv(defaultKubeconfigPath)) && len(kubeconfigPathFromEnv) > 0 {
-		if len(strings.Split(kubeconfigPathFromEnv, linuxEnvKubeconfigSeperator)) > 1 {
-			return "", fmt.Errorf("providing multiple kubeconfig files via environment variable KUBECONFIG is not supported for namespace switching")
-		}
+	kubeconfig, err := kubeconfigFiles.ContextFile(currentContext)
+	if err != nil {
+		return fmt.Errorf("failed to set namespace %q: %v", namespace, err)
+	}
 
-		kubeconfigPath = os.ExpandEnv(kubeconfigPathFromEnv)
+	if err := kubeconfig.SetNamespace(currentContext, namespace); err != nil {
+		return fmt.Errorf("failed to set namespace %q: %v", namespace, err)
 	}
 
-	if _, err := os.Stat(kubeconfigPath); err != nil {
-		return "", fmt.Errorf("unable to list namespaces. The kubeconfig file %q does not exist", kubeconfigPath)
+	// this updates the actual kubeconfig file (does not create a new tmp. kubeconfig to set namespace)
+	kubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to write kubeconfig file: %v", err)
 	}
-	return kubeconfigPath, nil
+
+	if len(kubeconfigFiles) > 1 {
+		logger.Infof("Set namespace %q of context %q in kubeconfig file %q", namespace, currentContext, kubeconfigPath)
+	} else {
+		logger.Debugf("set namespace %q of context %q in kubeconfig file %q", namespace, currentContext, kubeconfigPath)
+	}
+	return nil
 }
 
-func getClient(kubeconfigPath string) (client.Client, error) {
-	scheme := runtime.NewScheme()
-	utilruntime.Must(corev1.AddToScheme(scheme))
+// getKubeconfigPaths returns the paths of the kubeconfig files in the order of precedence.
+// The kubeconfig path from the flag is preferred over the KUBECONFIG environment variable, which may contain multiple files.
+func getKubeconfigPaths(kubeconfigPathFromFlag string) ([]string, error) {
+	kubeconfigPaths := []string{kubeconfigPathFromFlag}
+
+	// kubeconfig path from flag is preferred over env (just not if it is only the default)
+	if (len(kubeconfigPathFromFlag) == 0 || kubeconfigPathFromFlag == os.ExpandEnv(defaultKubeconfigPath)) && len(kubeconfigPathFromEnv) > 0 {
+		kubeconfigPaths = nil
+		for _, path := range filepath.SplitList(kubeconfigPathFromEnv) {
+			if len(path) > 0 {
+				kubeconfigPaths = append(kubeconfigPaths, os.ExpandEnv(path))
+			}
+		}
+	}
+
+	for _, kubeconfigPath := range kubeconfigPaths {
+		if _, err := os.Stat(kubeconfigPath); err == nil {
+			return kubeconfigPaths, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to list namespaces. The kubeconfig file %q does not exist", strings.Join(kubeconfigPaths, string(filepath.ListSeparator)))
+}
 
+// getRestConfig returns the rest config of the current context of the kubeconfig files, which are merged like by kubectl
+func getRestConfig(kubeconfigPaths []string) (*rest.Config, error) {
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
+		&clientcmd.ClientConfigLoadingRules{Precedence: kubeconfigPaths},
 		&clientcmd.ConfigOverrides{})
 
 	restConfig, err := clientConfig.ClientConfig()
 	if err != nil {
 		return nil, fmt.Errorf("unable to create rest config: %v", err)
 	}
+	return restConfig, nil
+}
+
+func getClient(kubeconfigPaths []string) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	restConfig, err := getRestConfig(kubeconfigPaths)
+	if err != nil {
+		return nil, err
+	}
 
 	// increase QPS and Burst to avoid rate limiting, these values are the same as kubectl uses
 	restConfig.QPS = 50.0
@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 )
 
 const (
@@ -36,7 +39,7 @@ type NamespaceCache struct {
 func NewNamespaceCache(stateDirectory string, contextName string) (*NamespaceCache, error) {
 	namespaceStateDirectory := fmt.Sprintf("%s/%s", stateDirectory, namespaceSubdirectory)
 	if _, err := os.Stat(namespaceStateDirectory); os.IsNotExist(err) {
-		if err := os.Mkdir(namespaceStateDirectory, 0755); err != nil {
+		if err := permissions.MkdirAll(namespaceStateDirectory); err != nil {
 			return nil, err
 		}
 	}
@@ -91,20 +94,13 @@ func (i *NamespaceCache) loadFromFile() ([]string, error) {
 }
 
 func (i *NamespaceCache) Write(toWrite []string) error {
-	// creates or truncate/clean the existing file
-	file, err := os.Create(i.cacheFilepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// print values to f, one per line
+	// one value per line
+	var content strings.Builder
 	for _, value := range toWrite {
-		_, err = fmt.Fprintln(file, value)
-		if err != nil {
-			return err
-		}
+		content.WriteString(value)
+		content.WriteString("\n")
 	}
 
-	return nil
+	// replaces the existing file atomically, as other terminals may read it concurrently
+	return filelock.WriteFile(i.cacheFilepath, []byte(content.String()), permissions.FileMode)
 }
@@ -0,0 +1,156 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidctoken
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// kubeloginGrantTypeDeviceCode is the kubelogin grant type of the device authorization flow.
+// All other grant types of kubelogin are replaced with the browser flow.
+const kubeloginGrantTypeDeviceCode = "device-code"
+
+// ReplaceKubeloginUsers replaces the credentials of the users in the kubeconfig with an exec credential plugin
+// calling the switcher binary, which runs the OIDC flow and caches and refreshes the tokens.
+// If the issuer URL is configured, all users are replaced. Otherwise, only users calling kubelogin are replaced
+// and the OIDC provider and client are taken from the arguments of kubelogin.
+func ReplaceKubeloginUsers(kubeconfig *kubeconfigutil.Kubeconfig, config *types.OIDCConfig, stateDir string) error {
+	if config == nil {
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine path of the switcher binary: %v", err)
+	}
+
+	return kubeconfig.ReplaceUserExecConfigs(func(_ string, current *kubeconfigutil.ExecConfig) (*kubeconfigutil.ExecConfig, error) {
+		var options *Options
+		if config.IssuerURL != nil {
+			options = &Options{IssuerURL: *config.IssuerURL}
+			if config.ClientID != nil {
+				options.ClientID = *config.ClientID
+			}
+		} else if current != nil {
+			options = parseKubeloginArgs(*current)
+		}
+		if options == nil {
+			return nil, nil
+		}
+
+		// the switch configuration takes precedence over the arguments of kubelogin
+		if config.ClientSecret != nil {
+			options.ClientSecret = *config.ClientSecret
+		}
+		if len(config.ExtraScopes) > 0 {
+			options.ExtraScopes = config.ExtraScopes
+		}
+		if config.GrantType != nil {
+			options.GrantType = *config.GrantType
+		}
+		if config.ListenAddress != nil {
+			options.ListenAddress = *config.ListenAddress
+		}
+
+		return &kubeconfigutil.ExecConfig{
+			APIVersion: kubeconfigutil.ExecCredentialAPIVersion,
+			Command:    executable,
+			Args:       options.args(stateDir),
+			// the user might have to log in
+			InteractiveMode: "IfAvailable",
+		}, nil
+	})
+}
+
+// args returns the arguments of the exec credential plugin
+func (o Options) args(stateDir string) []string {
+	args := []string{Command,
+		"--state-directory", stateDir,
+		"--issuer-url", o.IssuerURL,
+		"--client-id", o.ClientID,
+	}
+	if len(o.ClientSecret) > 0 {
+		args = append(args, "--client-secret", o.ClientSecret)
+	}
+	for _, scope := range o.ExtraScopes {
+		args = append(args, "--extra-scope", scope)
+	}
+	if len(o.GrantType) > 0 {
+		args = append(args, "--grant-type", string(o.GrantType))
+	}
+	if len(o.ListenAddress) > 0 {
+		args = append(args, "--listen-address", o.ListenAddress)
+	}
+	return args
+}
+
+// parseKubeloginArgs returns the OIDC provider and client of an exec credential plugin calling kubelogin,
+// either as "kubectl oidc-login get-token" or "kubelogin get-token".
+// Returns nil for other exec credential plugins.
+func parseKubeloginArgs(execConfig kubeconfigutil.ExecConfig) *Options {
+	command := strings.TrimSuffix(filepath.Base(execConfig.Command), ".exe")
+	args := execConfig.Args
+	switch {
+	case command == "kubectl" && len(args) > 1 && args[0] == "oidc-login" && args[1] == "get-token":
+		args = args[2:]
+	case (command == "kubelogin" || command == "kubectl-oidc_login") && len(args) > 0 && args[0] == "get-token":
+		args = args[1:]
+	default:
+		return nil
+	}
+
+	options := &Options{}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--oidc-issuer-url":
+			options.IssuerURL = value
+		case "--oidc-client-id":
+			options.ClientID = value
+		case "--oidc-client-secret":
+			options.ClientSecret = value
+		case "--oidc-extra-scope":
+			options.ExtraScopes = append(options.ExtraScopes, strings.Split(value, ",")...)
+		case "--grant-type":
+			if value == kubeloginGrantTypeDeviceCode {
+				options.GrantType = types.OIDCGrantTypeDeviceCode
+			} else {
+				options.GrantType = types.OIDCGrantTypeBrowser
+			}
+		case "--listen-address":
+			// kubelogin accepts several addresses and uses the first available one
+			if len(options.ListenAddress) == 0 {
+				options.ListenAddress = value
+			}
+		}
+	}
+
+	// e.g. the kubelogin of Azure, which does not use the OIDC flags
+	if len(options.IssuerURL) == 0 || len(options.ClientID) == 0 {
+		return nil
+	}
+	return options
+}
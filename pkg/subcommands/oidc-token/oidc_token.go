@@ -0,0 +1,357 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidctoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// Command is the name of the switcher subcommand used as exec credential plugin for the built-in OIDC login
+	Command = "oidc-token"
+	// DefaultListenAddress is the default address of the local server receiving the redirect of the browser flow
+	DefaultListenAddress = "127.0.0.1:8000"
+
+	// expiryDelta is how long before its expiry a cached ID token is refreshed
+	expiryDelta = time.Minute
+	// loginTimeout is how long to wait for the user to log in
+	loginTimeout = 5 * time.Minute
+)
+
+// Options configure the OIDC provider and client to obtain the ID token from
+type Options struct {
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	ExtraScopes   []string
+	GrantType     types.OIDCGrantType
+	ListenAddress string
+}
+
+// cachedTokens are the tokens cached in the state directory
+type cachedTokens struct {
+	IDToken      string `json:"idToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// providerMetadata is the subset of the OpenID provider metadata used by kubeswitch
+// see https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type providerMetadata struct {
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+// GetExecCredential returns the ID token of the OIDC provider as ExecCredential JSON.
+// The tokens are cached in the state directory. A cached ID token is returned until it expires,
+// then it is refreshed with the refresh token. Only if this fails, the user has to log in again.
+func GetExecCredential(options Options, stateDir string) ([]byte, error) {
+	idToken, err := getIDToken(options, stateDir)
+	if err != nil {
+		return nil, err
+	}
+	return kubeconfigutil.UserCredentials{Token: idToken}.ExecCredential(util.TokenExpiry(idToken))
+}
+
+// getIDToken returns a valid ID token from the cache, by refreshing the cached tokens or by logging in
+func getIDToken(options Options, stateDir string) (string, error) {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: httptransport.Shared()})
+
+	config, err := newOAuth2Config(ctx, options)
+	if err != nil {
+		return "", err
+	}
+
+	path := cachePath(options, stateDir)
+	var idToken string
+	// hold the lock while refreshing, so that concurrent kubectl calls do not use the same refresh token twice
+	err = filelock.WithLock(path, func() error {
+		cached, err := readCache(path)
+		if err != nil || cached == nil {
+			return err
+		}
+
+		if isValid(cached.IDToken) {
+			idToken = cached.IDToken
+			return nil
+		}
+
+		if len(cached.RefreshToken) == 0 {
+			return nil
+		}
+
+		tokens, err := refresh(ctx, config, cached.RefreshToken)
+		if err != nil {
+			// the refresh token expired or was revoked, the user has to log in again
+			return nil
+		}
+		idToken = tokens.IDToken
+		return writeCache(path, tokens)
+	})
+	if err != nil || len(idToken) > 0 {
+		return idToken, err
+	}
+
+	// do not hold the lock while waiting for the user to log in
+	var token *oauth2.Token
+	switch options.GrantType {
+	case types.OIDCGrantTypeDeviceCode:
+		token, err = deviceCodeLogin(ctx, config)
+	default:
+		token, err = browserLogin(ctx, config, options.ListenAddress)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to OIDC provider %q: %w", options.IssuerURL, err)
+	}
+
+	tokens, err := toCachedTokens(token)
+	if err != nil {
+		return "", err
+	}
+
+	err = filelock.WithLock(path, func() error {
+		return writeCache(path, tokens)
+	})
+	return tokens.IDToken, err
+}
+
+// newOAuth2Config discovers the endpoints of the OIDC provider
+func newOAuth2Config(ctx context.Context, options Options) (*oauth2.Config, error) {
+	discoveryURL := strings.TrimSuffix(options.IssuerURL, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Transport: httptransport.Shared(), Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %q: %w", options.IssuerURL, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover OIDC provider %q: %s", options.IssuerURL, response.Status)
+	}
+
+	var metadata providerMetadata
+	if err := json.NewDecoder(response.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata of OIDC provider %q: %w", options.IssuerURL, err)
+	}
+
+	return &oauth2.Config{
+		ClientID:     options.ClientID,
+		ClientSecret: options.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:       metadata.AuthorizationEndpoint,
+			TokenURL:      metadata.TokenEndpoint,
+			DeviceAuthURL: metadata.DeviceAuthorizationEndpoint,
+		},
+		Scopes: append([]string{"openid", "offline_access"}, options.ExtraScopes...),
+	}, nil
+}
+
+// refresh obtains new tokens with the refresh token
+func refresh(ctx context.Context, config *oauth2.Config, refreshToken string) (*cachedTokens, error) {
+	token, err := config.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: refreshToken,
+		// forces the refresh
+		Expiry: time.Unix(1, 0),
+	}).Token()
+	if err != nil {
+		return nil, err
+	}
+	return toCachedTokens(token)
+}
+
+// deviceCodeLogin runs the device authorization flow.
+// The user opens the verification URL on any device and enters the code.
+func deviceCodeLogin(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	if len(config.Endpoint.DeviceAuthURL) == 0 {
+		return nil, fmt.Errorf("the OIDC provider does not support the device authorization flow")
+	}
+
+	deviceAuth, err := config.DeviceAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// stdout is read by kubectl, talk to the user on stderr
+	if len(deviceAuth.VerificationURIComplete) > 0 {
+		fmt.Fprintf(os.Stderr, "To log in, open %s\n", deviceAuth.VerificationURIComplete)
+	} else {
+		fmt.Fprintf(os.Stderr, "To log in, open %s and enter the code %s\n", deviceAuth.VerificationURI, deviceAuth.UserCode)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+	return config.DeviceAccessToken(ctx, deviceAuth)
+}
+
+// browserLogin runs the authorization code flow with PKCE.
+// The browser is redirected to a local server receiving the authorization code.
+func browserLogin(ctx context.Context, config *oauth2.Config, listenAddress string) (*oauth2.Token, error) {
+	if len(listenAddress) == 0 {
+		listenAddress = DefaultListenAddress
+	}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %q for the redirect of the browser: %w", listenAddress, err)
+	}
+
+	config.RedirectURL = fmt.Sprintf("http://localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+
+	state, err := randomString()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			switch {
+			case query.Get("state") != state:
+				// e.g. the browser requesting the favicon
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			case len(query.Get("error")) > 0:
+				http.Error(w, "Login failed. You can close this window.", http.StatusUnauthorized)
+				results <- result{err: fmt.Errorf("%s: %s", query.Get("error"), query.Get("error_description"))}
+			default:
+				fmt.Fprintln(w, "Logged in. You can close this window.")
+				results <- result{code: query.Get("code")}
+			}
+		}),
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	authURL := config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+	fmt.Fprintf(os.Stderr, "To log in, open %s\n", authURL)
+	if err := util.OpenInBrowser(authURL); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for the login in the browser")
+	case r := <-results:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return config.Exchange(ctx, r.code, oauth2.VerifierOption(verifier))
+	}
+}
+
+// toCachedTokens returns the ID and refresh token of the token response
+func toCachedTokens(token *oauth2.Token) (*cachedTokens, error) {
+	idToken, _ := token.Extra("id_token").(string)
+	if len(idToken) == 0 {
+		return nil, errors.New("the token response of the OIDC provider does not contain an ID token")
+	}
+	return &cachedTokens{
+		IDToken:      idToken,
+		RefreshToken: token.RefreshToken,
+	}, nil
+}
+
+// isValid returns true if the ID token does not expire soon
+func isValid(idToken string) bool {
+	expiry := util.TokenExpiry(idToken)
+	return expiry != nil && time.Now().Add(expiryDelta).Before(*expiry)
+}
+
+// cachePath returns the path of the token cache of the OIDC client in the state directory
+func cachePath(options Options, stateDir string) string {
+	key := strings.Join(append([]string{options.IssuerURL, options.ClientID}, options.ExtraScopes...), "|")
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(stateDir, "oidc", hex.EncodeToString(hash[:])+".json")
+}
+
+// readCache reads the cached tokens. Returns nil if there are none.
+func readCache(path string) (*cachedTokens, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC token cache: %w", err)
+	}
+
+	tokens := &cachedTokens{}
+	if err := json.Unmarshal(data, tokens); err != nil {
+		// a corrupt cache requires a new login
+		return nil, nil
+	}
+	return tokens, nil
+}
+
+// writeCache writes the tokens readable only by the user
+func writeCache(path string, tokens *cachedTokens) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if err := filelock.WriteFile(path, data, permissions.FileMode); err != nil {
+		return fmt.Errorf("failed to write OIDC token cache: %w", err)
+	}
+	return nil
+}
+
+// randomString returns a random string used as state of the authorization request
+func randomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
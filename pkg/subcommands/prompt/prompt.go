@@ -0,0 +1,157 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/muesli/termenv"
+
+	show_path "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/show-path"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// DefaultFormat shows the context in the color of its environment followed by the namespace
+// and a warning sign if the credentials of the context expire soon
+const DefaultFormat = `{{envColor .Context}}{{if .Namespace}}:{{.Namespace}}{{end}}{{if .CredentialsExpiry}} {{color "yellow" "⚠"}}{{end}}`
+
+// Segment is the data available to the format of the prompt segment
+type Segment struct {
+	// Context is the current context
+	Context string
+	// Namespace is the namespace of the current context
+	Namespace string
+	// StoreKind is the kind of the kubeconfig store the context was switched to from
+	StoreKind string
+	// StoreID is the ID of the kubeconfig store the context was switched to from
+	StoreID string
+	// Environment is the name of the environment matching the current context
+	Environment string
+	// CredentialsExpiry describes when the credentials of the current context expire, e.g. "credentials expire in 25m".
+	// Empty unless the credentials expire within an hour or are expired.
+	CredentialsExpiry string
+}
+
+// Options configure the rendering of the prompt segment
+type Options struct {
+	// Format is a Go template rendering the Segment
+	Format string
+	// NoColor renders the colors of the format without ANSI escape sequences
+	NoColor bool
+	// Shell wraps the ANSI escape sequences for the prompt of the shell ("zsh" or "bash"), so that they are not counted
+	// towards the width of the prompt. Starship and powerlevel10k expect unwrapped escape sequences.
+	// For "tmux", the colors are rendered as styles of the tmux status line instead.
+	Shell string
+	// KubeconfigPath is the kubeconfig to show the context of.
+	// Defaults to the kubeconfig of the current terminal session.
+	// + optional
+	KubeconfigPath string
+}
+
+// Prompt renders the current context and namespace for a shell prompt.
+// Only reads the kubeconfig of the current terminal session and does not search the kubeconfig stores.
+// Returns an empty string if there is no current context.
+func Prompt(environments []types.Environment, options Options) (string, error) {
+	var (
+		session *show_path.Session
+		err     error
+	)
+	if len(options.KubeconfigPath) > 0 {
+		session, err = show_path.GetSessionForPath(options.KubeconfigPath)
+	} else {
+		session, err = show_path.GetSession()
+	}
+	if err != nil || len(session.Context) == 0 {
+		// no kubeconfig or no current context: show nothing instead of breaking the prompt
+		return "", nil
+	}
+
+	segment := Segment{
+		Context:   session.Context,
+		Namespace: session.Namespace,
+		StoreKind: session.StoreKind,
+		StoreID:   session.StoreID,
+	}
+	if expiry := session.CredentialsExpiry; expiry != nil && time.Until(*expiry) < util.CredentialsExpiryWarning {
+		segment.CredentialsExpiry = util.DescribeCredentialsExpiry(*expiry)
+	}
+
+	environment := theme.New(environments).Match(session.Context, nil)
+	if environment != nil {
+		segment.Environment = environment.Name
+	}
+
+	colorize := func(color, text string) (string, error) {
+		if options.NoColor || len(color) == 0 {
+			return text, nil
+		}
+		if !theme.IsValidColor(color) {
+			return "", fmt.Errorf("invalid color %q", color)
+		}
+		if options.Shell == "tmux" {
+			return tmuxStyle(color, text), nil
+		}
+		return wrap(termenv.CSI+termenv.ANSI256.Color(theme.Color(color)).Sequence(false)+"m", options.Shell) +
+			text +
+			wrap(termenv.CSI+termenv.ResetSeq+"m", options.Shell), nil
+	}
+
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{
+		"color": colorize,
+		"envColor": func(text string) (string, error) {
+			if environment == nil {
+				return text, nil
+			}
+			return colorize(environment.Color, text)
+		},
+	}).Parse(options.Format)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the format: %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, segment); err != nil {
+		return "", fmt.Errorf("failed to render the format: %v", err)
+	}
+	return output.String(), nil
+}
+
+// wrap marks the escape sequence as zero-width for the prompt of the shell
+func wrap(sequence, shell string) string {
+	switch shell {
+	case "zsh":
+		return "%{" + sequence + "%}"
+	case "bash":
+		// readline markers, as "\[" and "\]" are not interpreted in the output of a command substitution
+		return "\001" + sequence + "\002"
+	default:
+		return sequence
+	}
+}
+
+// tmuxStyle renders the text in the color using the styles of the tmux status line
+func tmuxStyle(color, text string) string {
+	code := theme.Color(color)
+	if !strings.HasPrefix(code, "#") {
+		code = "colour" + code
+	}
+	return fmt.Sprintf("#[fg=%s]%s#[default]", code, text)
+}
@@ -0,0 +1,313 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// retryInterval is the maximum time to wait before refreshing the index of a kubeconfig store again after a failed refresh
+const retryInterval = time.Minute
+
+// Result is the result of refreshing the search index of a kubeconfig store
+type Result struct {
+	Store storetypes.KubeconfigStore
+	// Contexts is the number of contexts found in the kubeconfig store
+	Contexts int
+	// Added are the context names added to the index, sorted by name
+	Added []string
+	// Removed are the context names removed from the index, sorted by name
+	Removed []string
+	// PrunedAliases are the aliases of the removed contexts removed from the alias state file, sorted by name
+	PrunedAliases []string
+	// Evicted are the kubeconfig paths of the removed contexts removed from the cache of the kubeconfig store, sorted by path
+	Evicted []string
+	// Duration is the time it took to search the kubeconfig store
+	Duration time.Duration
+	// Err contains the errors returned from the search
+	Err error
+}
+
+// Interval returns how often the search index of the kubeconfig store is refreshed in the background.
+// The index is refreshed after three quarters of its refreshIndexAfter, so that searches always find a current index.
+// Returns nil for kubeconfig stores that do not read from a search index.
+func Interval(store storetypes.KubeconfigStore, config *types.Config) *time.Duration {
+	if !pkg.Indexed(store) {
+		return nil
+	}
+
+	refreshAfter := store.GetStoreConfig().RefreshIndexAfter
+	if refreshAfter == nil && config != nil {
+		refreshAfter = config.RefreshIndexAfter
+	}
+	if refreshAfter == nil || *refreshAfter <= 0 {
+		return nil
+	}
+
+	interval := *refreshAfter * 3 / 4
+	return &interval
+}
+
+// Store searches the kubeconfig store without reading from its search index.
+// The index file is replaced atomically once the search is complete, so concurrent searches either read the old or the new index.
+// If the search succeeded, the contexts removed from the index are also pruned from the cache of the store and the aliases.
+func Store(store storetypes.KubeconfigStore, config *types.Config, stateDir string) Result {
+	result := Result{Store: store}
+	start := time.Now()
+
+	before, err := readIndex(store, stateDir)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	c, err := pkg.DoSearch([]storetypes.KubeconfigStore{store}, config, stateDir, true)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	var errs []error
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			errs = append(errs, discoveredContext.Error)
+			continue
+		}
+		result.Contexts++
+	}
+
+	result.Duration = time.Since(start)
+	result.Err = errors.Join(errs...)
+
+	// the index is not written if the search did not find any context
+	if result.Contexts == 0 {
+		return result
+	}
+
+	after, err := readIndex(store, stateDir)
+	if err != nil {
+		result.Err = errors.Join(result.Err, err)
+		return result
+	}
+	result.Added = difference(after, before)
+	result.Removed = difference(before, after)
+
+	// a failed search may not have found all contexts of the store, e.g. if a region could not be listed
+	if len(result.Removed) > 0 && result.Err == nil {
+		result.Evicted, result.PrunedAliases, result.Err = prune(store, before, after, result.Removed, stateDir)
+	}
+	return result
+}
+
+// prune removes the kubeconfigs of the removed contexts from the cache of the kubeconfig store
+// and the aliases of the removed contexts, e.g. of deleted clusters or removed kubeconfig files.
+// Returns the evicted kubeconfig paths and the removed aliases.
+func prune(store storetypes.KubeconfigStore, before, after map[string]string, removed []string, stateDir string) ([]string, []string, error) {
+	var evicted []string
+	if evictable, ok := store.(cache.Evictable); ok {
+		// kubeconfig files can contain other contexts that still exist
+		inUse := sets.New[string]()
+		for _, path := range after {
+			inUse.Insert(path)
+		}
+
+		for _, name := range removed {
+			path := before[name]
+			if inUse.Has(path) || slices.Contains(evicted, path) {
+				continue
+			}
+
+			ok, err := evictable.Evict(path)
+			if err != nil {
+				return evicted, nil, err
+			}
+			if ok {
+				evicted = append(evicted, path)
+			}
+		}
+		sort.Strings(evicted)
+	}
+
+	aliases, err := pruneAliases(store, removed, stateDir)
+	return evicted, aliases, err
+}
+
+// pruneAliases removes the aliases of the removed contexts from the alias state file.
+// Aliases are not bound to a kubeconfig store, hence aliases of context names found in the index of another kubeconfig store are kept.
+func pruneAliases(store storetypes.KubeconfigStore, removed []string, stateDir string) ([]string, error) {
+	aliasStore, err := state.GetDefaultAlias(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliasStore.Content.ContextToAliasMapping) == 0 {
+		return nil, nil
+	}
+
+	indexes, err := index.LoadAll(store.GetLogger(), stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, name := range removed {
+		alias, ok := aliasStore.Content.ContextToAliasMapping[name]
+		if !ok || slices.ContainsFunc(indexes, func(i types.Index) bool {
+			_, found := i.ContextToPathMapping[name]
+			return found
+		}) {
+			continue
+		}
+
+		delete(aliasStore.Content.ContextToAliasMapping, name)
+		aliasStore.SetNamespace(alias, "")
+		pruned = append(pruned, alias)
+	}
+
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+	sort.Strings(pruned)
+
+	if err := aliasStore.WriteAllAliases(); err != nil {
+		return nil, fmt.Errorf("failed to remove the aliases of removed contexts: %v", err)
+	}
+	return pruned, nil
+}
+
+// readIndex returns the context to path mapping of the search index of the kubeconfig store
+func readIndex(store storetypes.KubeconfigStore, stateDir string) (map[string]string, error) {
+	searchIndex, err := index.New(store.GetLogger(), store.GetKind(), stateDir, store.GetID())
+	if err != nil {
+		return nil, err
+	}
+	if !searchIndex.HasKind(store.GetKind()) {
+		return nil, nil
+	}
+	contextToPath, _ := searchIndex.GetContent()
+	return contextToPath, nil
+}
+
+// difference returns the sorted context names of a not contained in b
+func difference(a, b map[string]string) []string {
+	var names []string
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Watch refreshes the search index of each kubeconfig store on its own schedule (see Interval) until the context is cancelled.
+// Kubeconfig stores that do not read from a search index are skipped.
+// The optional onRefresh function is called after the index of a kubeconfig store has been refreshed.
+func Watch(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, onRefresh func(Result)) error {
+	intervals := make(map[string]time.Duration)
+	nextRefresh := make(map[string]time.Time)
+	var scheduled []storetypes.KubeconfigStore
+
+	for _, store := range stores {
+		interval := Interval(store, config)
+		if interval == nil {
+			store.GetLogger().Debugf("Not refreshing the index of store %s in the background: the store does not use an index (refreshIndexAfter)", store.GetID())
+			continue
+		}
+
+		next, err := firstRefresh(store, stateDir, *interval)
+		if err != nil {
+			return err
+		}
+
+		intervals[store.GetID()] = *interval
+		nextRefresh[store.GetID()] = next
+		scheduled = append(scheduled, store)
+	}
+
+	if len(scheduled) == 0 {
+		return fmt.Errorf("none of the kubeconfig stores uses a search index. Please set \"refreshIndexAfter\" in the SwitchConfig")
+	}
+
+	for {
+		next := nextRefresh[scheduled[0].GetID()]
+		for _, store := range scheduled[1:] {
+			if nextRefresh[store.GetID()].Before(next) {
+				next = nextRefresh[store.GetID()]
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		// stores are refreshed one after another to not search all remote stores at the same time
+		for _, store := range scheduled {
+			if time.Now().Before(nextRefresh[store.GetID()]) {
+				continue
+			}
+
+			result := Store(store, config, stateDir)
+			interval := intervals[store.GetID()]
+			if result.Err != nil {
+				nextRefresh[store.GetID()] = time.Now().Add(min(interval, retryInterval))
+			} else {
+				nextRefresh[store.GetID()] = time.Now().Add(interval)
+			}
+
+			if onRefresh != nil {
+				onRefresh(result)
+			}
+
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// firstRefresh returns when the index of the kubeconfig store should be refreshed the first time
+func firstRefresh(store storetypes.KubeconfigStore, stateDir string, interval time.Duration) (time.Time, error) {
+	searchIndex, err := index.New(store.GetLogger(), store.GetKind(), stateDir, store.GetID())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lastUpdate, err := searchIndex.GetLastUpdateTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if lastUpdate == nil {
+		return time.Now(), nil
+	}
+	return lastUpdate.Add(interval), nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revert
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+)
+
+// Command is the hidden command reverting the temporary kubeconfig in the background
+const Command = "revert-kubeconfig"
+
+// pollInterval is the interval in which the background process compares the wall clock with the revert time
+const pollInterval = 5 * time.Second
+
+// copyDirectory is the directory next to the temporary kubeconfigs holding the copies of the previous kubeconfigs.
+// The garbage collection only considers files directly in the temporary kubeconfig directory.
+const copyDirectory = "revert"
+
+// Schedule marks the new temporary kubeconfig to be reverted after the duration
+// and starts a background process that replaces its content with the previous kubeconfig of the terminal.
+// As the terminal keeps pointing to the temporary kubeconfig, this reverts the terminal to the previous context.
+// The content of the previous kubeconfig is copied now, as the shell integration removes the previous temporary kubeconfig on the switch.
+func Schedule(kubeconfigPath string, after time.Duration) error {
+	previousKubeconfigPath, err := previousKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	previous, err := os.ReadFile(previousKubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read previous kubeconfig %q to revert to: %v", previousKubeconfigPath, err)
+	}
+
+	copyDir := filepath.Join(filepath.Dir(kubeconfigPath), copyDirectory)
+	if err := permissions.MkdirAll(copyDir); err != nil {
+		return err
+	}
+	copyPath := filepath.Join(copyDir, filepath.Base(kubeconfigPath))
+	if err := filelock.WriteFile(copyPath, previous, permissions.FileMode); err != nil {
+		return fmt.Errorf("failed to write copy of the previous kubeconfig: %v", err)
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+
+	revertAt := time.Now().Add(after).Format(time.RFC3339)
+	if err := kubeconfig.ModifyKubeswitchRevertAt(revertAt); err != nil {
+		return err
+	}
+	if _, err := kubeconfig.WriteKubeconfigFile(); err != nil {
+		return fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// the process must not inherit stdout, as the shell integration reads stdout until it is closed
+	cmd := exec.Command(executable, Command, "--kubeconfig-path", kubeconfigPath, "--previous-kubeconfig-path", copyPath, "--revert-at", revertAt)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start background process to revert the kubeconfig: %v", err)
+	}
+	return cmd.Process.Release()
+}
+
+// Revert waits until the revert time and replaces the content of the temporary kubeconfig with the previous kubeconfig.
+// Does nothing if the temporary kubeconfig has been removed or is scheduled for another revert time.
+// Leaves the temporary kubeconfig untouched and fails if the copy of the previous kubeconfig cannot be read.
+func Revert(kubeconfigPath, previousKubeconfigPath, revertAt string) error {
+	at, err := time.Parse(time.RFC3339, revertAt)
+	if err != nil {
+		return fmt.Errorf("invalid revert time %q: %v", revertAt, err)
+	}
+	waitUntil(at)
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kubeconfigPath)
+	if err != nil || kubeconfig.GetKubeswitchRevertAt() != revertAt {
+		// a later revert of the same kubeconfig has overwritten the copy, so only remove it once the kubeconfig is gone
+		if _, statErr := os.Stat(kubeconfigPath); os.IsNotExist(statErr) {
+			_ = os.Remove(previousKubeconfigPath)
+		}
+		return nil
+	}
+
+	previous, err := os.ReadFile(previousKubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("nothing to revert the kubeconfig %q to: %v", kubeconfigPath, err)
+	}
+	if err := filelock.WriteFile(kubeconfigPath, previous, permissions.FileMode); err != nil {
+		return err
+	}
+	return os.Remove(previousKubeconfigPath)
+}
+
+// waitUntil blocks until the wall clock reaches the time.
+// Sleeping for the duration is not sufficient, as the monotonic clock does not advance while the machine is suspended.
+func waitUntil(at time.Time) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	// Round(0) strips the monotonic clock reading, so that the wall clock is compared
+	for time.Now().Round(0).Before(at) {
+		<-ticker.C
+	}
+}
+
+// Remaining returns the time until the kubeconfig is reverted
+// or false if the kubeconfig is not scheduled to be reverted
+func Remaining(kubeconfig *kubeconfigutil.Kubeconfig) (time.Duration, bool) {
+	revertAt := kubeconfig.GetKubeswitchRevertAt()
+	if len(revertAt) == 0 {
+		return 0, false
+	}
+
+	at, err := time.Parse(time.RFC3339, revertAt)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Until(at), 0), true
+}
+
+// previousKubeconfig returns the path of the kubeconfig the terminal uses before the switch
+func previousKubeconfig() (string, error) {
+	if kubeconfig := os.Getenv("KUBECONFIG"); len(kubeconfig) > 0 {
+		if len(filepath.SplitList(kubeconfig)) > 1 {
+			return "", fmt.Errorf("reverting to multiple files in KUBECONFIG is not supported")
+		}
+		return filepath.Abs(kubeconfig)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kube", "config"), nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
+)
+
+// EnvContext is set in the subshell to the context it is scoped to
+const EnvContext = "KUBESWITCH_RUN_CONTEXT"
+
+// Shell starts an interactive subshell with KUBECONFIG set to the temporary kubeconfig
+// and the context name in front of the prompt. Returns the exit code of the subshell when it is exited.
+// The subshell is attached to the terminal, as stdout is read by the shell integration.
+func Shell(kubeconfigPath, contextName string) (int, error) {
+	if current := os.Getenv(EnvContext); len(current) > 0 {
+		logrus.Warnf("already in a subshell scoped to context %q. Exit it to leave the scope of context %q.", current, contextName)
+	}
+
+	tempDir, err := os.MkdirTemp("", "kubeswitch-run-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary directory for the subshell: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	shell := userShell()
+	arguments, env, err := decoratePrompt(shell, contextName, tempDir)
+	if err != nil {
+		return 0, err
+	}
+
+	stdin, stdout, closeTerminal, err := terminal()
+	if err != nil {
+		return 0, err
+	}
+	defer closeTerminal()
+
+	cmd := exec.Command(shell, arguments...)
+	cmd.Env = append(os.Environ(), append(env,
+		"KUBECONFIG="+kubeconfigPath,
+		EnvContext+"="+contextName,
+	)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("failed to run shell %q: %v", shell, err)
+	}
+	return 0, nil
+}
+
+// userShell returns the login shell of the user, falling back to sh (cmd.exe on Windows)
+func userShell() string {
+	if runtime.GOOS == "windows" {
+		if shell := os.Getenv("COMSPEC"); len(shell) > 0 {
+			return shell
+		}
+		return "cmd.exe"
+	}
+	if shell := os.Getenv("SHELL"); len(shell) > 0 {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// decoratePrompt returns the arguments and environment variables of the shell
+// prefixing its prompt with the context name after the startup files of the user have been read
+func decoratePrompt(shell, contextName, tempDir string) ([]string, []string, error) {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe")
+	switch name {
+	case "bash":
+		rcfile := filepath.Join(tempDir, "bashrc")
+		script := fmt.Sprintf(`[ -f ~/.bashrc ] && . ~/.bashrc
+PS1="(\${%s}) $PS1"
+`, EnvContext)
+		if err := os.WriteFile(rcfile, []byte(script), 0600); err != nil {
+			return nil, nil, fmt.Errorf("failed to write startup file of the subshell: %v", err)
+		}
+		return []string{"--rcfile", rcfile, "-i"}, nil, nil
+	case "zsh":
+		// zsh reads its startup files from ZDOTDIR, which is restored before reading the files of the user
+		zdotdir := os.Getenv("ZDOTDIR")
+		if len(zdotdir) == 0 {
+			zdotdir = os.Getenv("HOME")
+		}
+		files := map[string]string{
+			".zshenv": `[ -f "$KUBESWITCH_RUN_ZDOTDIR/.zshenv" ] && ZDOTDIR="$KUBESWITCH_RUN_ZDOTDIR" . "$KUBESWITCH_RUN_ZDOTDIR/.zshenv"
+`,
+			".zshrc": fmt.Sprintf(`ZDOTDIR="$KUBESWITCH_RUN_ZDOTDIR"
+unset KUBESWITCH_RUN_ZDOTDIR
+[ -f "$ZDOTDIR/.zshrc" ] && . "$ZDOTDIR/.zshrc"
+PROMPT="(${%s//\%%/%%%%}) $PROMPT"
+`, EnvContext),
+		}
+		for file, script := range files {
+			if err := os.WriteFile(filepath.Join(tempDir, file), []byte(script), 0600); err != nil {
+				return nil, nil, fmt.Errorf("failed to write startup file of the subshell: %v", err)
+			}
+		}
+		return []string{"-i"}, []string{"ZDOTDIR=" + tempDir, "KUBESWITCH_RUN_ZDOTDIR=" + zdotdir}, nil
+	case "fish":
+		command := fmt.Sprintf(`functions -c fish_prompt __kubeswitch_run_prompt; function fish_prompt; printf '(%%s) ' $%s; __kubeswitch_run_prompt; end`, EnvContext)
+		return []string{"-i", "--init-command", command}, nil, nil
+	case "cmd":
+		return nil, []string{fmt.Sprintf("PROMPT=(%s) $P$G", contextName)}, nil
+	case "powershell", "pwsh":
+		command := fmt.Sprintf(`$kubeswitchRunPrompt = $function:prompt; function prompt { "($env:%s) " + (& $kubeswitchRunPrompt) }`, EnvContext)
+		return []string{"-NoLogo", "-NoExit", "-Command", command}, nil, nil
+	default:
+		// other shells only read PS1 from the environment
+		return []string{"-i"}, []string{fmt.Sprintf("PS1=(%s) $ ", contextName)}, nil
+	}
+}
+
+// terminal returns the input and output of the subshell.
+// If stdout is captured, e.g. by the shell integration, the subshell is attached to the controlling terminal instead.
+func terminal() (*os.File, *os.File, func(), error) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		return os.Stdin, os.Stdout, func() {}, nil
+	}
+
+	input, output := "/dev/tty", "/dev/tty"
+	if runtime.GOOS == "windows" {
+		input, output = "CONIN$", "CONOUT$"
+	}
+
+	stdin, err := os.OpenFile(input, os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open the terminal for the subshell: %v", err)
+	}
+	stdout, err := os.OpenFile(output, os.O_RDWR, 0)
+	if err != nil {
+		stdin.Close()
+		return nil, nil, nil, fmt.Errorf("failed to open the terminal for the subshell: %v", err)
+	}
+	return stdin, stdout, func() {
+		stdin.Close()
+		stdout.Close()
+	}, nil
+}
@@ -0,0 +1,297 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// sessionsFileName is the filename of the state file containing the named sessions
+const sessionsFileName = "switch.sessions"
+
+// RestoredSlot is a slot of a named session switched to its context
+type RestoredSlot struct {
+	types.SessionSlot
+	// KubeconfigPath is the path of the temporary kubeconfig of the slot
+	KubeconfigPath string
+}
+
+// CurrentSlot returns a slot with the context and namespace of the current terminal session.
+// The slot is named after the context if no name is given.
+func CurrentSlot(name string) (*types.SessionSlot, error) {
+	path, err := kubeconfigutil.CurrentKubeconfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	currentContext := kubeconfig.GetCurrentContext()
+	if len(currentContext) == 0 {
+		return nil, fmt.Errorf("the kubeconfig %q of the current terminal session does not have a current context", path)
+	}
+
+	namespace, err := kubeconfig.NamespaceOfContext(currentContext)
+	if err != nil {
+		return nil, err
+	}
+
+	// the name of the context including the prefix of the kubeconfig store, as shown in the search
+	slot := &types.SessionSlot{
+		Name:      name,
+		Context:   kubeconfig.GetKubeswitchContext(),
+		Namespace: namespace,
+	}
+	if len(slot.Context) == 0 {
+		slot.Context = currentContext
+	}
+	if len(slot.Name) == 0 {
+		slot.Name = slot.Context
+	}
+	return slot, nil
+}
+
+// Save adds the slot to the named session. An existing slot with the same name is replaced.
+func Save(stateDir, name string, slot types.SessionSlot) error {
+	return update(stateDir, func(sessions *types.NamedSessions) error {
+		session := sessions.Sessions[name]
+		for i, existing := range session.Slots {
+			if existing.Name == slot.Name {
+				session.Slots[i] = slot
+				sessions.Sessions[name] = session
+				return nil
+			}
+		}
+
+		session.Slots = append(session.Slots, slot)
+		sessions.Sessions[name] = session
+		return nil
+	})
+}
+
+// Delete deletes the named session or only the given slot of it
+func Delete(stateDir, name, slotName string) error {
+	return update(stateDir, func(sessions *types.NamedSessions) error {
+		session, ok := sessions.Sessions[name]
+		if !ok {
+			return fmt.Errorf("session %q not found", name)
+		}
+
+		if len(slotName) == 0 {
+			delete(sessions.Sessions, name)
+			return nil
+		}
+
+		var slots []types.SessionSlot
+		for _, slot := range session.Slots {
+			if slot.Name != slotName {
+				slots = append(slots, slot)
+			}
+		}
+		if len(slots) == len(session.Slots) {
+			return fmt.Errorf("slot %q not found in session %q", slotName, name)
+		}
+
+		if len(slots) == 0 {
+			delete(sessions.Sessions, name)
+		} else {
+			sessions.Sessions[name] = types.NamedSession{Slots: slots}
+		}
+		return nil
+	})
+}
+
+// GetSession returns the named session
+func GetSession(stateDir, name string) (*types.NamedSession, error) {
+	sessions, err := load(stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	session, ok := sessions.Sessions[name]
+	if !ok {
+		return nil, fmt.Errorf("session %q not found", name)
+	}
+	return &session, nil
+}
+
+// GetSessionNames returns the names of all named sessions, e.g. for shell completion
+func GetSessionNames(stateDir string) ([]string, error) {
+	sessions, err := load(stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedNames(sessions), nil
+}
+
+// ListSessions prints all named sessions with their slots
+func ListSessions(stateDir string) error {
+	sessions, err := load(stateDir)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions.Sessions) == 0 {
+		fmt.Println("No sessions saved")
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Session", "Slot", "Context", "Namespace"})
+	for _, name := range sortedNames(sessions) {
+		for _, slot := range sessions.Sessions[name].Slots {
+			t.AppendRow(table.Row{name, slot.Name, slot.Context, slot.Namespace})
+		}
+	}
+	t.Render()
+	return nil
+}
+
+// Restore creates a temporary kubeconfig for each slot of the named session, or only for the given slot.
+// The kubeconfig stores are searched once for all slots.
+func Restore(name, slotName string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]RestoredSlot, error) {
+	session, err := GetSession(stateDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []types.SessionSlot
+	for _, slot := range session.Slots {
+		if len(slotName) == 0 || slot.Name == slotName {
+			slots = append(slots, slot)
+		}
+	}
+	if len(slots) == 0 {
+		return nil, fmt.Errorf("slot %q not found in session %q", slotName, name)
+	}
+
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var discoveredContexts []pkg.DiscoveredContext
+	for discoveredContext := range *c {
+		discoveredContexts = append(discoveredContexts, discoveredContext)
+	}
+
+	var restored []RestoredSlot
+	for _, slot := range slots {
+		kubeconfigPath, err := restoreSlot(slot, discoveredContexts, config, stateDir)
+		if err != nil {
+			for _, r := range restored {
+				os.Remove(r.KubeconfigPath)
+			}
+			return nil, fmt.Errorf("failed to restore slot %q of session %q: %w", slot.Name, name, err)
+		}
+		restored = append(restored, RestoredSlot{SessionSlot: slot, KubeconfigPath: kubeconfigPath})
+	}
+	return restored, nil
+}
+
+// restoreSlot creates the temporary kubeconfig for the context of the slot and sets its namespace
+func restoreSlot(slot types.SessionSlot, discoveredContexts []pkg.DiscoveredContext, config *types.Config, stateDir string) (string, error) {
+	// restoring a session should not clutter the history
+	kubeconfigPath, _, err := setcontext.SetContextExactFromResults(slot.Context, discoveredContexts, config, stateDir, false)
+	if err != nil {
+		return "", err
+	}
+
+	if len(slot.Namespace) == 0 {
+		return *kubeconfigPath, nil
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(*kubeconfigPath)
+	if err == nil {
+		err = kubeconfig.SetNamespaceForCurrentContext(slot.Namespace)
+	}
+	if err == nil {
+		_, err = kubeconfig.WriteKubeconfigFile()
+	}
+	if err != nil {
+		os.Remove(*kubeconfigPath)
+		return "", fmt.Errorf("failed to set namespace %q: %v", slot.Namespace, err)
+	}
+	return *kubeconfigPath, nil
+}
+
+// update modifies the named sessions while holding the lock of the state file
+func update(stateDir string, modify func(sessions *types.NamedSessions) error) error {
+	path := sessionsFilePath(stateDir)
+	return filelock.WithLock(path, func() error {
+		sessions, err := load(stateDir)
+		if err != nil {
+			return err
+		}
+
+		if err := modify(sessions); err != nil {
+			return err
+		}
+
+		output, err := yaml.Marshal(sessions)
+		if err != nil {
+			return err
+		}
+		return filelock.WriteFile(path, output, permissions.FileMode)
+	})
+}
+
+func load(stateDir string) (*types.NamedSessions, error) {
+	sessions := &types.NamedSessions{}
+	content, err := os.ReadFile(sessionsFilePath(stateDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, sessions); err != nil {
+		return nil, fmt.Errorf("could not unmarshal sessions file with path '%s': %v", sessionsFilePath(stateDir), err)
+	}
+
+	if sessions.Sessions == nil {
+		sessions.Sessions = map[string]types.NamedSession{}
+	}
+	return sessions, nil
+}
+
+func sortedNames(sessions *types.NamedSessions) []string {
+	var names []string
+	for name := range sessions.Sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sessionsFilePath(stateDir string) string {
+	return fmt.Sprintf("%s/%s", stateDir, sessionsFileName)
+}
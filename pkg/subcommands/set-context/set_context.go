@@ -15,20 +15,31 @@
 package setcontext
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
-	"github.com/sirupsen/logrus"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
-	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-var logger = logrus.New()
+var (
+	logger = logging.New()
+
+	// ErrContextNotFound is returned if the desired context does not exist in any of the kubeconfig stores
+	ErrContextNotFound = errors.New("context not found")
+	// ErrContextAmbiguous is returned in exact mode if the desired context matches more than one discovered context
+	ErrContextAmbiguous = errors.New("context is ambiguous")
+)
 
 func SetContext(desiredContext string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, appendToHistory bool) (*string, *string, error) {
 	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
@@ -50,61 +61,224 @@ func SetContext(desiredContext string, stores []storetypes.KubeconfigStore, conf
 			continue
 		}
 
-		kubeconfigStore := *discoveredContext.Store
+		if matchesContext(desiredContext, discoveredContext) {
+			return switchToContext(desiredContext, discoveredContext, config, stateDir, appendToHistory)
+		}
+	}
+
+	if mError != nil {
+		return nil, nil, fmt.Errorf("context with name %q not found. Possibly due to errors: %v: %w", desiredContext, mError.Error(), ErrContextNotFound)
+	}
+
+	return nil, nil, fmt.Errorf("context with name %q not found: %w", desiredContext, ErrContextNotFound)
+}
+
+// SetContextFromResults behaves like SetContext, but uses the results of a completed search
+// instead of searching the kubeconfig stores, e.g. the contexts kept in memory by the daemon.
+func SetContextFromResults(desiredContext string, discoveredContexts []pkg.DiscoveredContext, config *types.Config, stateDir string, appendToHistory bool) (*string, *string, error) {
+	for _, discoveredContext := range discoveredContexts {
+		if discoveredContext.Error == nil && discoveredContext.Store != nil && matchesContext(desiredContext, discoveredContext) {
+			return switchToContext(desiredContext, discoveredContext, config, stateDir, appendToHistory)
+		}
+	}
+	return nil, nil, fmt.Errorf("context with name %q not found: %w", desiredContext, ErrContextNotFound)
+}
+
+// SetContextExact behaves like SetContext, but waits for the search over all stores to complete.
+// The desired context has to match exactly one discovered context, otherwise either ErrContextNotFound
+// or ErrContextAmbiguous is returned.
+// Intended for scripts and CI jobs that must not depend on the order in which stores return their results.
+func SetContextExact(desiredContext string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, appendToHistory bool) (*string, *string, error) {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var discoveredContexts []pkg.DiscoveredContext
+	for discoveredContext := range *c {
+		discoveredContexts = append(discoveredContexts, discoveredContext)
+	}
+
+	return SetContextExactFromResults(desiredContext, discoveredContexts, config, stateDir, appendToHistory)
+}
+
+// SetContextExactFromResults behaves like SetContextExact, but uses the results of a completed search
+// instead of searching the kubeconfig stores, e.g. the contexts kept in memory by the daemon.
+func SetContextExactFromResults(desiredContext string, discoveredContexts []pkg.DiscoveredContext, config *types.Config, stateDir string, appendToHistory bool) (*string, *string, error) {
+	match, err := FindContextExact(desiredContext, discoveredContexts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return switchToContext(desiredContext, *match, config, stateDir, appendToHistory)
+}
+
+// SetContextRegex behaves like SetContextExact, but the name (or alias) of exactly one discovered context
+// has to match the regular expression.
+func SetContextRegex(expression string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, appendToHistory bool) (*string, *string, error) {
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid regular expression %q: %v", expression, err)
+	}
+
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var discoveredContexts []pkg.DiscoveredContext
+	for discoveredContext := range *c {
+		discoveredContexts = append(discoveredContexts, discoveredContext)
+	}
+
+	match, err := FindContextRegex(re, discoveredContexts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return switchToContext(displayName(*match), *match, config, stateDir, appendToHistory)
+}
+
+// FindContextExact returns the discovered context matching the desired context.
+// The desired context has to match exactly one discovered context, otherwise either ErrContextNotFound
+// or ErrContextAmbiguous is returned.
+func FindContextExact(desiredContext string, discoveredContexts []pkg.DiscoveredContext) (*pkg.DiscoveredContext, error) {
+	return findContext(fmt.Sprintf("context with name %q", desiredContext), discoveredContexts, func(discoveredContext pkg.DiscoveredContext) bool {
+		return matchesContext(desiredContext, discoveredContext)
+	}, func(discoveredContext pkg.DiscoveredContext) bool {
+		// context names found in more than one kubeconfig are disambiguated during the search,
+		// prefer the context that is shown with the desired name
+		return displayName(discoveredContext) == desiredContext
+	})
+}
+
+// FindContextRegex returns the discovered context whose name (or alias) matches the regular expression.
+// The regular expression has to match exactly one discovered context, otherwise either ErrContextNotFound
+// or ErrContextAmbiguous is returned.
+func FindContextRegex(re *regexp.Regexp, discoveredContexts []pkg.DiscoveredContext) (*pkg.DiscoveredContext, error) {
+	return findContext(fmt.Sprintf("context matching %q", re.String()), discoveredContexts, func(discoveredContext pkg.DiscoveredContext) bool {
+		return re.MatchString(displayName(discoveredContext))
+	}, nil)
+}
+
+// findContext returns the single discovered context that matches.
+// If more than one context matches, the optional preferred function selects the context to use.
+func findContext(description string, discoveredContexts []pkg.DiscoveredContext, matches func(pkg.DiscoveredContext) bool, preferred func(pkg.DiscoveredContext) bool) (*pkg.DiscoveredContext, error) {
+	var (
+		mError *multierror.Error
+		found  []pkg.DiscoveredContext
+		// the same context can be returned more than once (e.g. from the index and the store), only count it once
+		seen = make(map[string]struct{})
+	)
+	for _, discoveredContext := range discoveredContexts {
+		if discoveredContext.Error != nil {
+			mError = multierror.Append(mError, discoveredContext.Error)
+			continue
+		}
+
+		if discoveredContext.Store == nil {
+			logger.Debugf("store returned from search is nil. This should not happen")
+			continue
+		}
+
+		if !matches(discoveredContext) {
+			continue
+		}
 
-		contextWithoutPrefix := discoveredContext.Name
-		if len(kubeconfigStore.GetContextPrefix(discoveredContext.Path)) > 0 && strings.HasPrefix(discoveredContext.Name, kubeconfigStore.GetContextPrefix(discoveredContext.Path)) {
-			contextWithoutPrefix = strings.TrimPrefix(discoveredContext.Name, fmt.Sprintf("%s/", kubeconfigStore.GetContextPrefix(discoveredContext.Path)))
+		key := fmt.Sprintf("%s/%s/%s", (*discoveredContext.Store).GetID(), discoveredContext.Path, discoveredContext.Name)
+		if _, ok := seen[key]; ok {
+			continue
 		}
+		seen[key] = struct{}{}
+		found = append(found, discoveredContext)
+	}
 
-		matchesContextWithoutPrefix := desiredContext == contextWithoutPrefix
-		if desiredContext == discoveredContext.Name || matchesContextWithoutPrefix || desiredContext == discoveredContext.Alias {
-			kubeconfigData, err := kubeconfigStore.GetKubeconfigForPath(discoveredContext.Path, discoveredContext.Tags)
-			if err != nil {
-				return nil, nil, err
-			}
-
-			kubeconfig, err := kubeconfigutil.NewKubeconfig(kubeconfigData)
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
-			}
-
-			originalContextBeforeAlias := ""
-			if len(discoveredContext.Alias) > 0 {
-				originalContextBeforeAlias = contextWithoutPrefix
-			}
-
-			if err := kubeconfig.SetContext(contextWithoutPrefix, originalContextBeforeAlias, kubeconfigStore.GetContextPrefix(discoveredContext.Path)); err != nil {
-				return nil, nil, err
-			}
-
-			if err := kubeconfig.SetKubeswitchContext(desiredContext); err != nil {
-				return nil, nil, err
-			}
-
-			tempKubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
-			}
-
-			if appendToHistory {
-				// get namespace for current context
-				ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
-				if err != nil {
-					return nil, nil, fmt.Errorf("failed to get namespace of current context: %v", err)
-				}
-
-				if err := historyutil.AppendToHistory(desiredContext, ns); err != nil {
-					logger.Warnf("failed to append context to history file: %v", err)
-				}
-			}
-			return &tempKubeconfigPath, &desiredContext, nil
+	if len(found) > 1 && preferred != nil {
+		if i := slices.IndexFunc(found, preferred); i >= 0 {
+			found = found[i : i+1]
 		}
 	}
 
-	if mError != nil {
-		return nil, nil, fmt.Errorf("context with name %q not found. Possibly due to errors: %v", desiredContext, mError.Error())
+	switch len(found) {
+	case 0:
+		if mError != nil {
+			return nil, fmt.Errorf("%s not found. Possibly due to errors: %v: %w", description, mError.Error(), ErrContextNotFound)
+		}
+		return nil, fmt.Errorf("%s not found: %w", description, ErrContextNotFound)
+	case 1:
+		return &found[0], nil
+	default:
+		var candidates []string
+		for _, match := range found {
+			candidates = append(candidates, fmt.Sprintf("%s (store %q, path %q)", displayName(match), (*match.Store).GetID(), match.Path))
+		}
+		return nil, fmt.Errorf("%s matches %d contexts: %s: %w", description, len(found), strings.Join(candidates, ", "), ErrContextAmbiguous)
+	}
+}
+
+// matchesContext checks if the desired context is either the discovered context name (with or without store prefix) or its alias
+func matchesContext(desiredContext string, discoveredContext pkg.DiscoveredContext) bool {
+	if desiredContext == discoveredContext.Name || desiredContext == discoveredContext.Alias {
+		return true
+	}
+	return desiredContext == ContextWithoutPrefix(discoveredContext)
+}
+
+// displayName returns the name the discovered context is shown with in the search
+func displayName(discoveredContext pkg.DiscoveredContext) string {
+	if len(discoveredContext.Alias) > 0 {
+		return discoveredContext.Alias
+	}
+	return discoveredContext.Name
+}
+
+// ContextWithoutPrefix returns the name of the discovered context as written in its kubeconfig
+func ContextWithoutPrefix(discoveredContext pkg.DiscoveredContext) string {
+	kubeconfigStore := *discoveredContext.Store
+	prefix := kubeconfigStore.GetContextPrefix(discoveredContext.Path)
+	if len(prefix) > 0 && strings.HasPrefix(discoveredContext.Name, prefix) {
+		return strings.TrimPrefix(discoveredContext.Name, fmt.Sprintf("%s/", prefix))
+	}
+	return discoveredContext.Name
+}
+
+// switchToContext writes a temporary kubeconfig for the discovered context and returns its path
+func switchToContext(desiredContext string, discoveredContext pkg.DiscoveredContext, config *types.Config, stateDir string, appendToHistory bool) (*string, *string, error) {
+	kubeconfigStore := *discoveredContext.Store
+
+	kubeconfig, err := pkg.MaterializeKubeconfig(config, stateDir, pkg.MaterializeOptions{
+		Store:       kubeconfigStore,
+		Path:        discoveredContext.Path,
+		Tags:        discoveredContext.Tags,
+		ContextName: discoveredContext.Name,
+		Alias:       discoveredContext.Alias,
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, nil, fmt.Errorf("context with name %q not found", desiredContext)
+	if dryrun.Enabled() {
+		tempKubeconfigPath := kubeconfig.FilePath()
+		return &tempKubeconfigPath, &desiredContext, pkg.PrintSwitch(kubeconfig, config, desiredContext, kubeconfigStore.GetID(), discoveredContext.Name, stateDir, appendToHistory)
+	}
+
+	tempKubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
+	}
+
+	if appendToHistory {
+		// get namespace for current context
+		ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get namespace of current context: %v", err)
+		}
+
+		if err := historyutil.AppendToHistory(desiredContext, ns); err != nil {
+			logger.Warnf("failed to append context to history file: %v", err)
+		}
+
+		if err := index.RecordUsage(stateDir, kubeconfigStore.GetID(), discoveredContext.Name); err != nil {
+			logger.Debugf("failed to record the usage of the context in the index: %v", err)
+		}
+	}
+	return &tempKubeconfigPath, &desiredContext, nil
 }
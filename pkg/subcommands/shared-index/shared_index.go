@@ -0,0 +1,297 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharedindex
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/statesync"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/dryrun"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// gitDirectoryName is the name of the directory in the state directory containing the clone of the repository of the shared index
+	gitDirectoryName = "switch.index.git"
+	// defaultGitPath is the path of the shared index in the Git repository
+	defaultGitPath = "kubeswitch-index.yaml"
+)
+
+var logger = logging.New()
+
+// Catalog is the index of the kubeconfig stores shared within a team.
+// It only contains the names and metadata of the contexts, never credentials.
+// Team members fetch the kubeconfigs from their kubeconfig stores with their own credentials.
+type Catalog struct {
+	// PublishedAt is the time the catalog has been published
+	PublishedAt time.Time `yaml:"publishedAt"`
+	// Stores are the published kubeconfig stores sorted by ID
+	Stores []Store `yaml:"stores"`
+}
+
+// Store is the published index of a kubeconfig store
+type Store struct {
+	// ID is the ID of the kubeconfig store. The contexts are merged into the index of the kubeconfig store with the same ID and kind.
+	ID string `yaml:"id"`
+	// Kind is the kind of the kubeconfig store
+	Kind types.StoreKind `yaml:"kind"`
+	// Contexts are the contexts of the kubeconfig store sorted by name
+	Contexts []Context `yaml:"contexts"`
+}
+
+// Context is a published context of a kubeconfig store
+type Context struct {
+	// Name is the name of the context
+	Name string `yaml:"name"`
+	// Path is the path of the kubeconfig containing the context in the kubeconfig store
+	Path string `yaml:"path"`
+	// Tags is the metadata the kubeconfig store needs to fetch the kubeconfig, e.g. the ID of the cluster
+	Tags map[string]string `yaml:"tags,omitempty"`
+	// Server is the API server URL of the context
+	Server string `yaml:"server,omitempty"`
+	// CAHash is the hash of the certificate authority of the cluster of the context
+	CAHash string `yaml:"caHash,omitempty"`
+}
+
+// Publish searches the kubeconfig stores and uploads the names, paths and metadata of their contexts to the shared index.
+// Contexts of filesystem stores are not published, as their paths only exist on the local machine.
+func Publish(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	backend, err := newBackend(config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	published := map[string]*Store{}
+	seen := map[string]bool{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot publish all contexts. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Store == nil {
+			continue
+		}
+
+		store := *discoveredContext.Store
+		if !shared(store, config.SharedIndex.Stores) {
+			continue
+		}
+
+		// the same context can be returned more than once (e.g. from the index and the store)
+		key := fmt.Sprintf("%s/%s", store.GetID(), discoveredContext.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if _, ok := published[store.GetID()]; !ok {
+			published[store.GetID()] = &Store{ID: store.GetID(), Kind: store.GetKind()}
+		}
+		published[store.GetID()].Contexts = append(published[store.GetID()].Contexts, Context{
+			Name:   discoveredContext.Name,
+			Path:   discoveredContext.Path,
+			Tags:   discoveredContext.Tags,
+			Server: discoveredContext.Server,
+			CAHash: discoveredContext.CAHash,
+		})
+	}
+
+	catalog := Catalog{PublishedAt: time.Now().UTC()}
+	contexts := 0
+	for _, store := range published {
+		sort.Slice(store.Contexts, func(i, j int) bool {
+			return store.Contexts[i].Name < store.Contexts[j].Name
+		})
+		catalog.Stores = append(catalog.Stores, *store)
+		contexts += len(store.Contexts)
+	}
+	sort.Slice(catalog.Stores, func(i, j int) bool {
+		return catalog.Stores[i].ID < catalog.Stores[j].ID
+	})
+
+	if len(catalog.Stores) == 0 {
+		return fmt.Errorf("no contexts to publish. Contexts of filesystem stores are never published")
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Printf("publish %d context(s) of %d kubeconfig store(s) to %q", contexts, len(catalog.Stores), config.SharedIndex.URL)
+		return nil
+	}
+
+	content, err := yaml.Marshal(catalog)
+	if err != nil {
+		return err
+	}
+	// the Git backend uploads from its clone of the repository, which is updated by downloading
+	if _, err := backend.Download(); err != nil {
+		return err
+	}
+	if err := backend.Upload(content); err != nil {
+		return err
+	}
+
+	fmt.Printf("Published %d context(s) of %d kubeconfig store(s) to %q.\n", contexts, len(catalog.Stores), config.SharedIndex.URL)
+	return nil
+}
+
+// Pull downloads the shared index and merges its contexts into the index of the configured kubeconfig stores with the same ID and kind.
+// Contexts already contained in the local index are kept. The contexts are listed without searching the kubeconfig stores
+// until the index is refreshed (see "refreshIndexAfter"), which replaces them with the contexts the kubeconfig store actually returns.
+func Pull(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string) error {
+	backend, err := newBackend(config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	content, err := backend.Download()
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		return fmt.Errorf("no index has been published to %q yet. Please publish it with \"switch index publish\"", config.SharedIndex.URL)
+	}
+
+	catalog := Catalog{}
+	if err := yaml.Unmarshal(content, &catalog); err != nil {
+		return fmt.Errorf("failed to parse the shared index %q: %v", config.SharedIndex.URL, err)
+	}
+
+	configured := map[string]storetypes.KubeconfigStore{}
+	for _, store := range stores {
+		if shared(store, nil) {
+			configured[store.GetID()] = store
+		}
+	}
+
+	for _, published := range catalog.Stores {
+		store, ok := configured[published.ID]
+		if !ok {
+			fmt.Printf("Skipping %d context(s) of kubeconfig store %q, which is not configured in the SwitchConfig.\n", len(published.Contexts), published.ID)
+			continue
+		}
+		if store.GetKind() != published.Kind {
+			fmt.Printf("Skipping %d context(s) of kubeconfig store %q, which is of kind %q instead of %q.\n", len(published.Contexts), published.ID, store.GetKind(), published.Kind)
+			continue
+		}
+
+		merged, err := merge(store, published, stateDir)
+		if err != nil {
+			return fmt.Errorf("failed to merge the shared index of kubeconfig store %q: %v", published.ID, err)
+		}
+		if merged == 0 || dryrun.Enabled() {
+			continue
+		}
+		fmt.Printf("Merged %d context(s) into the index of kubeconfig store %q.\n", merged, published.ID)
+	}
+	return nil
+}
+
+// merge adds the published contexts that are not contained in the index of the kubeconfig store.
+// Returns the number of added contexts.
+func merge(store storetypes.KubeconfigStore, published Store, stateDir string) (int, error) {
+	searchIndex, err := index.New(store.GetLogger(), store.GetKind(), stateDir, store.GetID())
+	if err != nil {
+		return 0, err
+	}
+
+	local := map[string]index.Entry{}
+	indexed := searchIndex.HasKind(store.GetKind())
+	if indexed {
+		if local, err = searchIndex.GetEntries(); err != nil {
+			return 0, err
+		}
+	}
+
+	var added []index.Entry
+	for _, context := range published.Contexts {
+		if _, ok := local[context.Name]; ok {
+			continue
+		}
+		added = append(added, index.Entry{
+			Name:   context.Name,
+			Path:   context.Path,
+			Tags:   context.Tags,
+			Server: context.Server,
+			CAHash: context.CAHash,
+		})
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Printf("merge %d context(s) into the index of kubeconfig store %q", len(added), store.GetID())
+		return len(added), nil
+	}
+
+	writer := searchIndex.NewWriter(len(local) + len(added))
+	for _, entry := range local {
+		if err := writer.Add(entry); err != nil {
+			return 0, err
+		}
+	}
+	for _, entry := range added {
+		if err := writer.Add(entry); err != nil {
+			return 0, err
+		}
+	}
+	if err := writer.Commit(); err != nil {
+		return 0, err
+	}
+
+	// an index without state is never read. The kubeconfig store is searched once the index should be refreshed.
+	if !indexed {
+		if err := searchIndex.WriteState(types.IndexState{Kind: store.GetKind(), LastUpdateTime: time.Now().UTC()}); err != nil {
+			return 0, err
+		}
+	}
+	return len(added), nil
+}
+
+// shared returns true if the contexts of the kubeconfig store can be shared.
+// If store IDs are given, the kubeconfig store has to be one of them.
+func shared(store storetypes.KubeconfigStore, storeIDs []string) bool {
+	if store.GetKind() == types.StoreKindFilesystem || !pkg.Indexed(store) {
+		return false
+	}
+	return len(storeIDs) == 0 || slices.Contains(storeIDs, store.GetID())
+}
+
+func newBackend(config *types.Config, stateDir string) (statesync.Backend, error) {
+	if config == nil || config.SharedIndex == nil {
+		return nil, fmt.Errorf("no shared index configured. Please configure \"sharedIndex\" in the SwitchConfig")
+	}
+
+	path := defaultGitPath
+	if config.SharedIndex.Path != nil {
+		path = *config.SharedIndex.Path
+	}
+	return statesync.NewBackend(config.SharedIndex.Kind, config.SharedIndex.URL, filepath.Join(stateDir, gitDirectoryName), path)
+}
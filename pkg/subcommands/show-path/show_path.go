@@ -0,0 +1,124 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package show_path
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+)
+
+// Session describes the kubeconfig of the current terminal session
+type Session struct {
+	// KubeconfigPath is the path of the current kubeconfig
+	KubeconfigPath string `json:"kubeconfigPath"`
+	// Temporary is true if the kubeconfig is a temporary kubeconfig written by kubeswitch
+	Temporary bool `json:"temporary"`
+	// Context is the current context
+	Context string `json:"context,omitempty"`
+	// Namespace is the namespace of the current context
+	Namespace string `json:"namespace,omitempty"`
+	// StoreKind is the kind of the kubeconfig store the context was switched to from
+	StoreKind string `json:"storeKind,omitempty"`
+	// StoreID is the ID of the kubeconfig store the context was switched to from
+	StoreID string `json:"storeID,omitempty"`
+	// CredentialsExpiry is when the credentials of the current context expire, if they expire
+	CredentialsExpiry *time.Time `json:"credentialsExpiry,omitempty"`
+}
+
+// GetSession returns the kubeconfig of the current terminal session
+func GetSession() (*Session, error) {
+	path, err := kubeconfigutil.CurrentKubeconfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return GetSessionForPath(path)
+}
+
+// GetSessionForPath returns the session of the kubeconfig with the given path, e.g. of another terminal
+func GetSessionForPath(path string) (*Session, error) {
+	session := &Session{
+		KubeconfigPath: path,
+		Temporary:      isTemporaryKubeconfig(path),
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Context = kubeconfig.GetCurrentContext()
+	if len(session.Context) > 0 {
+		if session.Namespace, err = kubeconfig.NamespaceOfContext(session.Context); err != nil {
+			return nil, err
+		}
+	}
+	session.StoreKind = kubeconfig.GetKubeswitchStoreKind()
+	session.StoreID = kubeconfig.GetKubeswitchStoreID()
+
+	if data, err := kubeconfig.GetBytes(); err == nil {
+		if contextToExpiry, err := util.GetContextCredentialsExpiry(data, ""); err == nil {
+			if expiry, ok := contextToExpiry[session.Context]; ok {
+				session.CredentialsExpiry = &expiry
+			}
+		}
+	}
+	return session, nil
+}
+
+// ShowPath prints the path of the current kubeconfig
+// with showContext, the current context and its namespace are printed separated by tabs
+// with asJSON, the whole session is printed as JSON
+func ShowPath(showContext, asJSON bool) error {
+	session, err := GetSession()
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		output, err := json.MarshalIndent(session, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if showContext {
+		fmt.Printf("%s\t%s\t%s\n", session.KubeconfigPath, session.Context, session.Namespace)
+		return nil
+	}
+	fmt.Println(session.KubeconfigPath)
+	return nil
+}
+
+// isTemporaryKubeconfig checks if the kubeconfig is located in the directory of the temporary kubeconfigs
+func isTemporaryKubeconfig(path string) bool {
+	tempDir, err := filepath.Abs(os.ExpandEnv(kubeconfigutil.TemporaryKubeconfigDir))
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(absPath, tempDir+string(filepath.Separator))
+}
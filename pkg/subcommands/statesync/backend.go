@@ -0,0 +1,198 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statesync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// gitDirectoryName is the name of the directory in the state directory containing the clone of the sync repository
+	gitDirectoryName = "switch.sync.git"
+	// defaultGitPath is the path of the state file in the sync repository
+	defaultGitPath = "kubeswitch-state.yaml"
+)
+
+func newBackend(config types.SyncConfig, stateDir string) (Backend, error) {
+	path := defaultGitPath
+	if config.Path != nil {
+		path = *config.Path
+	}
+	return NewBackend(config.Kind, config.URL, filepath.Join(stateDir, gitDirectoryName), path)
+}
+
+// NewBackend returns the backend of the given kind storing a file at the URL.
+// For Git, the repository is cloned into the directory and the file is stored at the path in the repository.
+func NewBackend(kind types.SyncKind, url, gitDirectory, gitPath string) (Backend, error) {
+	switch kind {
+	case types.SyncKindGit:
+		return &gitBackend{
+			url:       url,
+			directory: gitDirectory,
+			path:      gitPath,
+		}, nil
+	case types.SyncKindS3:
+		return &cliBackend{
+			download:  []string{"aws", "s3", "cp", url, "-"},
+			upload:    []string{"aws", "s3", "cp", "-", url},
+			notFound:  []string{"(404)", "NoSuchKey", "does not exist"},
+			objectURL: url,
+		}, nil
+	case types.SyncKindGCS:
+		return &cliBackend{
+			download:  []string{"gcloud", "storage", "cat", url},
+			upload:    []string{"gcloud", "storage", "cp", "-", url},
+			notFound:  []string{"No URLs matched", "404", "not found"},
+			objectURL: url,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sync kind %q", kind)
+	}
+}
+
+// gitBackend stores the state in a file of a Git repository.
+// The repository is cloned into the state directory.
+type gitBackend struct {
+	url       string
+	directory string
+	path      string
+}
+
+func (g *gitBackend) Download() ([]byte, error) {
+	if _, err := os.Stat(filepath.Join(g.directory, ".git")); os.IsNotExist(err) {
+		if _, err := runCommand(nil, "git", "clone", "--quiet", g.url, g.directory); err != nil {
+			return nil, err
+		}
+	} else if _, err := g.git("fetch", "--quiet", "origin"); err != nil {
+		return nil, err
+	}
+
+	branch, err := g.branch()
+	if err != nil {
+		return nil, err
+	}
+
+	// the repository is empty if the remote branch does not exist yet
+	if _, err := g.git("rev-parse", "--verify", "--quiet", "origin/"+branch); err == nil {
+		if _, err := g.git("reset", "--quiet", "--hard", "origin/"+branch); err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(g.directory, g.path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
+
+func (g *gitBackend) Upload(content []byte) error {
+	file := filepath.Join(g.directory, g.path)
+	if err := permissions.MkdirAll(filepath.Dir(file)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, content, permissions.FileMode); err != nil {
+		return err
+	}
+
+	if _, err := g.git("add", "--", g.path); err != nil {
+		return err
+	}
+
+	// nothing changed
+	if _, err := g.git("diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	host, _ := os.Hostname()
+	args := []string{"commit", "--quiet", "--message", fmt.Sprintf("Update %s from %s", g.path, host)}
+	if email, _ := g.git("config", "user.email"); len(email) == 0 {
+		// machines like jump hosts often do not have a Git identity configured
+		args = append([]string{"-c", "user.name=kubeswitch", "-c", fmt.Sprintf("user.email=kubeswitch@%s", host)}, args...)
+	}
+	if _, err := g.git(args...); err != nil {
+		return err
+	}
+
+	branch, err := g.branch()
+	if err != nil {
+		return err
+	}
+	_, err = g.git("push", "--quiet", "origin", "HEAD:"+branch)
+	return err
+}
+
+// branch returns the checked out branch of the clone, which is the default branch of the remote repository
+func (g *gitBackend) branch() (string, error) {
+	return g.git("symbolic-ref", "--short", "HEAD")
+}
+
+func (g *gitBackend) git(args ...string) (string, error) {
+	output, err := runCommand(nil, "git", append([]string{"-C", g.directory}, args...)...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// cliBackend stores the state in an object of a bucket using the CLI of the cloud provider
+type cliBackend struct {
+	download  []string
+	upload    []string
+	notFound  []string
+	objectURL string
+}
+
+func (c *cliBackend) Download() ([]byte, error) {
+	output, err := runCommand(nil, c.download[0], c.download[1:]...)
+	if err != nil {
+		for _, marker := range c.notFound {
+			if strings.Contains(err.Error(), marker) {
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to download %q: %v", c.objectURL, err)
+	}
+	return output, nil
+}
+
+func (c *cliBackend) Upload(content []byte) error {
+	if _, err := runCommand(content, c.upload[0], c.upload[1:]...); err != nil {
+		return fmt.Errorf("failed to upload %q: %v", c.objectURL, err)
+	}
+	return nil
+}
+
+// runCommand runs the command with the given standard input and returns its standard output.
+// The returned error contains the standard error of the command.
+func runCommand(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
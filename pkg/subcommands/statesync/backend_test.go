@@ -0,0 +1,94 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statesync_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/statesync"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Git backend", func() {
+	var (
+		dir    string
+		remote string
+	)
+
+	// newBackend returns a backend with its own clone of the remote repository, like on another machine
+	newBackend := func(machine string) statesync.Backend {
+		backend, err := statesync.NewBackend(types.SyncKindGit, remote, filepath.Join(dir, machine), "state/kubeswitch.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		return backend
+	}
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git is not installed")
+		}
+
+		var err error
+		dir, err = os.MkdirTemp("", "statesync")
+		Expect(err).ToNot(HaveOccurred())
+
+		remote = filepath.Join(dir, "remote.git")
+		Expect(exec.Command("git", "init", "--quiet", "--bare", "--initial-branch", "main", remote).Run()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should return no state for an empty repository", func() {
+		Expect(newBackend("laptop").Download()).To(BeNil())
+	})
+
+	It("should download the state uploaded by another machine", func() {
+		laptop := newBackend("laptop")
+		_, err := laptop.Download()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(laptop.Upload([]byte("aliases: {}\n"))).To(Succeed())
+
+		desktop := newBackend("desktop")
+		Expect(desktop.Download()).To(Equal([]byte("aliases: {}\n")))
+
+		Expect(desktop.Upload([]byte("history: []\n"))).To(Succeed())
+		Expect(laptop.Download()).To(Equal([]byte("history: []\n")))
+	})
+
+	It("should not commit an unchanged state", func() {
+		laptop := newBackend("laptop")
+		_, err := laptop.Download()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(laptop.Upload([]byte("aliases: {}\n"))).To(Succeed())
+		Expect(laptop.Upload([]byte("aliases: {}\n"))).To(Succeed())
+
+		output, err := exec.Command("git", "-C", remote, "rev-list", "--count", "main").Output()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal("1\n"))
+	})
+})
+
+var _ = Describe("NewBackend", func() {
+	It("should reject an unknown kind", func() {
+		_, err := statesync.NewBackend("ftp", "ftp://example.com/state.yaml", "", "")
+		Expect(err).To(MatchError(`unknown sync kind "ftp"`))
+	})
+})
@@ -0,0 +1,358 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statesync
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	aliasstate "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
+	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// syncStateFileName is the filename of the state file in the state directory containing the state of the last sync
+	syncStateFileName = "switch.sync.state"
+	// maxHistoryEntries is the number of most recent history entries that are synchronized
+	maxHistoryEntries = 1000
+	// maxAttempts is the number of attempts to synchronize if the remote state changed concurrently
+	maxAttempts = 3
+)
+
+// State is the user state that is synchronized across machines
+type State struct {
+	// Aliases are the alias records by alias name
+	Aliases map[string]AliasRecord `yaml:"aliases,omitempty"`
+	// History are the history records, oldest first
+	History []HistoryRecord `yaml:"history,omitempty"`
+}
+
+// AliasRecord is the last change of an alias.
+// Deleted aliases are kept as tombstones, so that the deletion is propagated to other machines.
+type AliasRecord struct {
+	Context   string    `yaml:"context,omitempty"`
+	Namespace string    `yaml:"namespace,omitempty"`
+	Deleted   bool      `yaml:"deleted,omitempty"`
+	Modified  time.Time `yaml:"modified"`
+}
+
+// HistoryRecord is an entry of the history recorded on a machine
+type HistoryRecord struct {
+	Timestamp time.Time `yaml:"timestamp"`
+	Host      string    `yaml:"host"`
+	Entry     string    `yaml:"entry"`
+}
+
+// localState is the state of the last sync stored in the state directory.
+// It is used to detect the changes made on this machine since the last sync.
+type localState struct {
+	State `yaml:",inline"`
+	// HistoryLines is the number of entries in the local history file after the last sync
+	HistoryLines int `yaml:"historyLines"`
+}
+
+// Backend stores the synchronized state
+type Backend interface {
+	// Download returns the stored state or nil if there is no state yet
+	Download() ([]byte, error)
+	// Upload stores the state
+	Upload(content []byte) error
+}
+
+// Sync merges the local history and aliases with the state stored in the configured sync backend
+// and writes the merged state both locally and to the backend.
+// Concurrent changes on different machines are merged: the most recent change of an alias wins and history entries are combined ordered by time.
+func Sync(config *types.Config, stateDir string, dryRun bool) error {
+	if config == nil || config.Sync == nil {
+		return fmt.Errorf("no sync backend configured. Please configure \"sync\" in the SwitchConfig")
+	}
+
+	backend, err := newBackend(*config.Sync, stateDir)
+	if err != nil {
+		return err
+	}
+
+	// syncs started in several terminals at the same time would upload conflicting states
+	lock, err := filelock.Acquire(localStatePath(stateDir))
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	for attempt := 1; ; attempt++ {
+		err = sync(backend, stateDir, dryRun)
+		if err == nil || attempt == maxAttempts {
+			return err
+		}
+		logrus.Debugf("retrying sync after failed attempt %d: %v", attempt, err)
+	}
+}
+
+func sync(backend Backend, stateDir string, dryRun bool) error {
+	now := time.Now().UTC()
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	last, err := loadLocalState(stateDir)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := aliasstate.GetDefaultAlias(stateDir)
+	if err != nil {
+		return err
+	}
+
+	localHistory, err := historyutil.ReadHistoryEntries()
+	if err != nil {
+		return err
+	}
+
+	content, err := backend.Download()
+	if err != nil {
+		return fmt.Errorf("failed to download the state from the sync backend: %v", err)
+	}
+
+	remote := State{}
+	if len(content) > 0 {
+		if err := yaml.Unmarshal(content, &remote); err != nil {
+			return fmt.Errorf("failed to parse the state of the sync backend: %v", err)
+		}
+	}
+
+	local := State{
+		Aliases: localAliasRecords(aliases.Content, last.Aliases, now),
+		History: localHistoryRecords(localHistory, last.HistoryLines, host, now),
+	}
+	merged := State{
+		Aliases: mergeAliases(local.Aliases, remote.Aliases),
+		History: mergeHistory(remote.History, local.History),
+	}
+
+	historyEntries := renderHistory(merged.History)
+	aliases.Content = renderAliases(merged.Aliases)
+	if dryRun {
+		fmt.Printf("Would synchronize %d aliases and %d history entries\n", len(aliases.Content.ContextToAliasMapping), len(historyEntries))
+		return nil
+	}
+
+	output, err := yaml.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	if err := backend.Upload(output); err != nil {
+		return fmt.Errorf("failed to upload the state to the sync backend: %v", err)
+	}
+
+	if err := aliases.WriteAllAliases(); err != nil {
+		return err
+	}
+
+	if err := historyutil.UpdateHistory(func(current []string) []string {
+		// entries appended by other terminals during the sync are kept and synchronized the next time
+		if len(current) > len(localHistory) {
+			return append(historyEntries, current[len(localHistory):]...)
+		}
+		return historyEntries
+	}); err != nil {
+		return err
+	}
+
+	if err := writeLocalState(stateDir, localState{
+		State:        State{Aliases: merged.Aliases},
+		HistoryLines: len(historyEntries),
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Synchronized %d aliases and %d history entries\n", len(aliases.Content.ContextToAliasMapping), len(historyEntries))
+	return nil
+}
+
+// localAliasRecords returns the alias records of this machine.
+// Aliases that changed since the last sync are recorded as modified now.
+func localAliasRecords(aliases types.ContextAlias, last map[string]AliasRecord, now time.Time) map[string]AliasRecord {
+	// aliases pointing to the same context as a more recent alias have not been written to the alias state file
+	written := renderAliases(last).ContextToAliasMapping
+
+	records := make(map[string]AliasRecord, len(last))
+	for alias, record := range last {
+		if !record.Deleted && written[record.Context] == alias {
+			// deleted locally since the last sync, unless still present below
+			record = AliasRecord{Deleted: true, Modified: now}
+		}
+		records[alias] = record
+	}
+
+	for context, alias := range aliases.ContextToAliasMapping {
+		record := AliasRecord{
+			Context:   context,
+			Namespace: aliases.AliasToNamespaceMapping[alias],
+			Modified:  now,
+		}
+
+		if previous, ok := last[alias]; ok && !previous.Deleted && previous.Context == record.Context && previous.Namespace == record.Namespace {
+			record.Modified = previous.Modified
+		}
+		records[alias] = record
+	}
+	return records
+}
+
+// localHistoryRecords returns the history entries added on this machine since the last sync.
+// As the history file does not contain timestamps, the entries are recorded as added now preserving their order.
+func localHistoryRecords(entries []string, lastLines int, host string, now time.Time) []HistoryRecord {
+	if lastLines > len(entries) {
+		// the history file has been truncated since the last sync
+		lastLines = 0
+	}
+
+	added := entries[lastLines:]
+	records := make([]HistoryRecord, 0, len(added))
+	for i, entry := range added {
+		records = append(records, HistoryRecord{
+			Timestamp: now.Add(time.Duration(i-len(added)) * time.Microsecond),
+			Host:      host,
+			Entry:     entry,
+		})
+	}
+	return records
+}
+
+// mergeAliases merges the alias records. The most recent change of an alias wins.
+func mergeAliases(local, remote map[string]AliasRecord) map[string]AliasRecord {
+	merged := make(map[string]AliasRecord, len(remote))
+	for alias, record := range remote {
+		merged[alias] = record
+	}
+
+	for alias, record := range local {
+		existing, ok := merged[alias]
+		if !ok || record.Modified.After(existing.Modified) {
+			merged[alias] = record
+		}
+	}
+	return merged
+}
+
+// mergeHistory combines the history records ordered by time and keeps the most recent entries
+func mergeHistory(remote, local []HistoryRecord) []HistoryRecord {
+	type key struct {
+		timestamp int64
+		host      string
+		entry     string
+	}
+
+	seen := make(map[key]struct{}, len(remote)+len(local))
+	var merged []HistoryRecord
+	for _, record := range append(append([]HistoryRecord{}, remote...), local...) {
+		k := key{timestamp: record.Timestamp.UnixNano(), host: record.Host, entry: record.Entry}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		merged = append(merged, record)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.Before(merged[j].Timestamp)
+	})
+
+	if len(merged) > maxHistoryEntries {
+		merged = merged[len(merged)-maxHistoryEntries:]
+	}
+	return merged
+}
+
+// renderAliases returns the content of the alias state file for the alias records.
+// If several aliases point to the same context, the most recently changed alias is used.
+func renderAliases(records map[string]AliasRecord) types.ContextAlias {
+	content := types.ContextAlias{
+		ContextToAliasMapping:   map[string]string{},
+		AliasToNamespaceMapping: map[string]string{},
+	}
+
+	for alias, record := range records {
+		if record.Deleted {
+			continue
+		}
+
+		if existing, ok := content.ContextToAliasMapping[record.Context]; ok {
+			existingRecord := records[existing]
+			if existingRecord.Modified.After(record.Modified) || (existingRecord.Modified.Equal(record.Modified) && existing < alias) {
+				continue
+			}
+			delete(content.AliasToNamespaceMapping, existing)
+		}
+
+		content.ContextToAliasMapping[record.Context] = alias
+		if len(record.Namespace) > 0 {
+			content.AliasToNamespaceMapping[alias] = record.Namespace
+		}
+	}
+	return content
+}
+
+// renderHistory returns the entries of the history file for the history records.
+// Consecutive identical entries are only written once.
+func renderHistory(records []HistoryRecord) []string {
+	var entries []string
+	for _, record := range records {
+		if len(entries) > 0 && entries[len(entries)-1] == record.Entry {
+			continue
+		}
+		entries = append(entries, record.Entry)
+	}
+	return entries
+}
+
+func loadLocalState(stateDir string) (*localState, error) {
+	state := &localState{}
+	content, err := os.ReadFile(localStatePath(stateDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("could not unmarshal sync state file with path '%s': %v", localStatePath(stateDir), err)
+	}
+	return state, nil
+}
+
+func writeLocalState(stateDir string, state localState) error {
+	output, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(localStatePath(stateDir), output, permissions.FileMode)
+}
+
+func localStatePath(stateDir string) string {
+	return fmt.Sprintf("%s/%s", stateDir, syncStateFileName)
+}
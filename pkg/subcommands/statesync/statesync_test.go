@@ -0,0 +1,145 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statesync
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("merging the state", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	Context("localAliasRecords", func() {
+		It("should record new and changed aliases as modified now and keep the time of unchanged aliases", func() {
+			last := map[string]AliasRecord{
+				"dev":  {Context: "dev-eu", Modified: now.Add(-time.Hour)},
+				"prod": {Context: "prod-eu", Modified: now.Add(-time.Hour)},
+			}
+			aliases := types.ContextAlias{
+				ContextToAliasMapping: map[string]string{
+					"dev-eu":  "dev",
+					"prod-us": "prod",
+					"qa-eu":   "qa",
+				},
+			}
+
+			Expect(localAliasRecords(aliases, last, now)).To(Equal(map[string]AliasRecord{
+				"dev":  {Context: "dev-eu", Modified: now.Add(-time.Hour)},
+				"prod": {Context: "prod-us", Modified: now},
+				"qa":   {Context: "qa-eu", Modified: now},
+			}))
+		})
+
+		It("should record aliases deleted since the last sync as tombstones", func() {
+			last := map[string]AliasRecord{
+				"dev": {Context: "dev-eu", Modified: now.Add(-time.Hour)},
+			}
+
+			Expect(localAliasRecords(types.ContextAlias{}, last, now)).To(Equal(map[string]AliasRecord{
+				"dev": {Deleted: true, Modified: now},
+			}))
+		})
+	})
+
+	Context("localHistoryRecords", func() {
+		It("should record the entries added since the last sync in order", func() {
+			records := localHistoryRecords([]string{"a", "b", "c"}, 1, "laptop", now)
+
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].Entry).To(Equal("b"))
+			Expect(records[1].Entry).To(Equal("c"))
+			Expect(records[0].Timestamp.Before(records[1].Timestamp)).To(BeTrue())
+			Expect(records[1].Host).To(Equal("laptop"))
+		})
+
+		It("should record all entries if the history file has been truncated", func() {
+			Expect(localHistoryRecords([]string{"a"}, 5, "laptop", now)).To(HaveLen(1))
+		})
+	})
+
+	Context("mergeAliases", func() {
+		It("should keep the most recent change of each alias", func() {
+			local := map[string]AliasRecord{
+				"dev":  {Context: "dev-local", Modified: now},
+				"prod": {Deleted: true, Modified: now.Add(-2 * time.Hour)},
+			}
+			remote := map[string]AliasRecord{
+				"dev":  {Context: "dev-remote", Modified: now.Add(-time.Hour)},
+				"prod": {Context: "prod-remote", Modified: now.Add(-time.Hour)},
+				"qa":   {Context: "qa-remote", Modified: now},
+			}
+
+			Expect(mergeAliases(local, remote)).To(Equal(map[string]AliasRecord{
+				"dev":  {Context: "dev-local", Modified: now},
+				"prod": {Context: "prod-remote", Modified: now.Add(-time.Hour)},
+				"qa":   {Context: "qa-remote", Modified: now},
+			}))
+		})
+	})
+
+	Context("mergeHistory", func() {
+		It("should combine the records ordered by time without duplicates", func() {
+			remote := []HistoryRecord{
+				{Timestamp: now.Add(-2 * time.Minute), Host: "desktop", Entry: "a"},
+				{Timestamp: now, Host: "desktop", Entry: "c"},
+			}
+			local := []HistoryRecord{
+				{Timestamp: now.Add(-2 * time.Minute), Host: "desktop", Entry: "a"},
+				{Timestamp: now.Add(-time.Minute), Host: "laptop", Entry: "b"},
+			}
+
+			Expect(renderHistory(mergeHistory(remote, local))).To(Equal([]string{"a", "b", "c"}))
+		})
+
+		It("should keep only the most recent entries", func() {
+			var local []HistoryRecord
+			for i := 0; i < maxHistoryEntries+10; i++ {
+				local = append(local, HistoryRecord{Timestamp: now.Add(time.Duration(i) * time.Second), Entry: "entry"})
+			}
+
+			merged := mergeHistory(nil, local)
+			Expect(merged).To(HaveLen(maxHistoryEntries))
+			Expect(merged[0].Timestamp).To(Equal(now.Add(10 * time.Second)))
+		})
+	})
+
+	Context("renderAliases", func() {
+		It("should skip deleted aliases and use the most recent alias of a context", func() {
+			content := renderAliases(map[string]AliasRecord{
+				"old":     {Context: "dev-eu", Modified: now.Add(-time.Hour)},
+				"dev":     {Context: "dev-eu", Namespace: "default", Modified: now},
+				"deleted": {Deleted: true, Modified: now},
+			})
+
+			Expect(content.ContextToAliasMapping).To(Equal(map[string]string{"dev-eu": "dev"}))
+			Expect(content.AliasToNamespaceMapping).To(Equal(map[string]string{"dev": "default"}))
+		})
+	})
+
+	Context("renderHistory", func() {
+		It("should write consecutive identical entries once", func() {
+			Expect(renderHistory([]HistoryRecord{{Entry: "a"}, {Entry: "a"}, {Entry: "b"}, {Entry: "a"}})).To(Equal([]string{"a", "b", "a"}))
+		})
+	})
+})
@@ -0,0 +1,279 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stats records performance metrics of the searches of each kubeconfig store,
+// so that slow or failing kubeconfig stores can be identified.
+package stats
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/database"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// databaseFileName is the filename of the database containing the recent runs of all kubeconfig stores
+	// located in the state directory
+	databaseFileName = "switch.stats.db"
+	// maxRuns is the number of recent runs kept per kubeconfig store
+	maxRuns = 50
+)
+
+// Run is the search of a single kubeconfig store
+type Run struct {
+	// Timestamp is the time the search of the kubeconfig store started
+	Timestamp time.Time `json:"timestamp"`
+	// StoreID is the ID of the kubeconfig store
+	StoreID string `json:"storeID"`
+	// StoreKind is the kind of the kubeconfig store
+	StoreKind types.StoreKind `json:"storeKind"`
+	// Duration is the time until all contexts of the kubeconfig store were found
+	Duration time.Duration `json:"duration"`
+	// Contexts is the number of contexts found
+	Contexts int `json:"contexts"`
+	// Errors is the number of errors returned during the search
+	Errors int `json:"errors"`
+	// FromIndex is true if the contexts were read from the search index instead of searching the kubeconfig store
+	FromIndex bool `json:"fromIndex,omitempty"`
+	// CacheHits is the number of kubeconfigs read from the kubeconfig cache during the search
+	CacheHits int `json:"cacheHits,omitempty"`
+	// CacheMisses is the number of kubeconfigs not found in the kubeconfig cache during the search
+	CacheMisses int `json:"cacheMisses,omitempty"`
+}
+
+// Summary aggregates the recent runs of a kubeconfig store
+type Summary struct {
+	// StoreID is the ID of the kubeconfig store
+	StoreID string `json:"storeID"`
+	// StoreKind is the kind of the kubeconfig store
+	StoreKind types.StoreKind `json:"storeKind"`
+	// Runs is the number of recent runs
+	Runs int `json:"runs"`
+	// AverageDuration is the average duration of the recent runs
+	AverageDuration time.Duration `json:"averageDuration"`
+	// MaxDuration is the longest duration of the recent runs
+	MaxDuration time.Duration `json:"maxDuration"`
+	// SearchDuration is the average duration of the recent runs that searched the kubeconfig store instead of reading the index
+	SearchDuration time.Duration `json:"searchDuration,omitempty"`
+	// Contexts is the number of contexts found by the last run
+	Contexts int `json:"contexts"`
+	// IndexHitRate is the fraction of the recent runs that read the contexts from the search index
+	IndexHitRate float64 `json:"indexHitRate"`
+	// CacheHitRate is the fraction of the kubeconfigs read from the kubeconfig cache. Nil if no kubeconfig cache has been used.
+	CacheHitRate *float64 `json:"cacheHitRate,omitempty"`
+	// ErrorRate is the fraction of the recent runs that returned an error
+	ErrorRate float64 `json:"errorRate"`
+	// LastRun is the time of the last run
+	LastRun time.Time `json:"lastRun"`
+}
+
+// Record adds the runs to the recent runs of their kubeconfig stores in a single transaction.
+// Only the most recent runs of each kubeconfig store are kept.
+func Record(stateDirectory string, runs []Run) error {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	return database.With(filepath.Join(stateDirectory, databaseFileName), false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			for _, run := range runs {
+				bucket, err := tx.CreateBucketIfNotExists([]byte(run.StoreID))
+				if err != nil {
+					return err
+				}
+
+				value, err := json.Marshal(run)
+				if err != nil {
+					return err
+				}
+				// the keys are ordered by the start of the run
+				key := make([]byte, 8)
+				binary.BigEndian.PutUint64(key, uint64(run.Timestamp.UnixNano()))
+				if err := bucket.Put(key, value); err != nil {
+					return err
+				}
+
+				// remove the oldest runs
+				count := 0
+				cursor := bucket.Cursor()
+				for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+					count++
+				}
+				for ; count > maxRuns; count-- {
+					oldest, _ := bucket.Cursor().First()
+					if err := bucket.Delete(oldest); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Load returns the recent runs by kubeconfig store ID, oldest first
+func Load(stateDirectory string) (map[string][]Run, error) {
+	runs := make(map[string][]Run)
+	err := database.With(filepath.Join(stateDirectory, databaseFileName), true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+				return bucket.ForEach(func(_, value []byte) error {
+					var run Run
+					if err := json.Unmarshal(value, &run); err != nil {
+						// skip runs that cannot be parsed
+						return nil
+					}
+					runs[string(name)] = append(runs[string(name)], run)
+					return nil
+				})
+			})
+		})
+	})
+	if os.IsNotExist(err) {
+		return runs, nil
+	}
+	return runs, err
+}
+
+// Reset removes the recent runs of all kubeconfig stores
+func Reset(stateDirectory string) error {
+	if err := os.Remove(filepath.Join(stateDirectory, databaseFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Summarize aggregates the recent runs of each kubeconfig store.
+// The kubeconfig stores are sorted by their average duration, slowest first.
+func Summarize(runsByStore map[string][]Run) []Summary {
+	summaries := make([]Summary, 0, len(runsByStore))
+	for storeID, runs := range runsByStore {
+		if len(runs) == 0 {
+			continue
+		}
+
+		summary := Summary{
+			StoreID: storeID,
+			Runs:    len(runs),
+		}
+
+		var (
+			total, searchTotal          time.Duration
+			searches, fromIndex, failed int
+			cacheHits, cacheMisses      int
+		)
+		for _, run := range runs {
+			total += run.Duration
+			if run.Duration > summary.MaxDuration {
+				summary.MaxDuration = run.Duration
+			}
+			if run.FromIndex {
+				fromIndex++
+			} else {
+				searches++
+				searchTotal += run.Duration
+			}
+			if run.Errors > 0 {
+				failed++
+			}
+			cacheHits += run.CacheHits
+			cacheMisses += run.CacheMisses
+		}
+
+		last := runs[len(runs)-1]
+		summary.StoreKind = last.StoreKind
+		summary.Contexts = last.Contexts
+		summary.LastRun = last.Timestamp
+		summary.AverageDuration = total / time.Duration(len(runs))
+		if searches > 0 {
+			summary.SearchDuration = searchTotal / time.Duration(searches)
+		}
+		summary.IndexHitRate = float64(fromIndex) / float64(len(runs))
+		summary.ErrorRate = float64(failed) / float64(len(runs))
+		if cacheHits+cacheMisses > 0 {
+			rate := float64(cacheHits) / float64(cacheHits+cacheMisses)
+			summary.CacheHitRate = &rate
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].AverageDuration != summaries[j].AverageDuration {
+			return summaries[i].AverageDuration > summaries[j].AverageDuration
+		}
+		return summaries[i].StoreID < summaries[j].StoreID
+	})
+	return summaries
+}
+
+// Print writes the summaries either as table or as one JSON object per line
+func Print(w io.Writer, summaries []Summary, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		for _, summary := range summaries {
+			if err := encoder.Encode(summary); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STORE\tKIND\tRUNS\tAVG\tMAX\tSEARCH AVG\tCONTEXTS\tINDEX HITS\tCACHE HITS\tERRORS\tLAST RUN")
+	for _, summary := range summaries {
+		searchDuration := "-"
+		if summary.SearchDuration > 0 {
+			searchDuration = formatDuration(summary.SearchDuration)
+		}
+		cacheHitRate := "-"
+		if summary.CacheHitRate != nil {
+			cacheHitRate = formatRate(*summary.CacheHitRate)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			summary.StoreID,
+			summary.StoreKind,
+			summary.Runs,
+			formatDuration(summary.AverageDuration),
+			formatDuration(summary.MaxDuration),
+			searchDuration,
+			summary.Contexts,
+			formatRate(summary.IndexHitRate),
+			cacheHitRate,
+			formatRate(summary.ErrorRate),
+			summary.LastRun.Local().Format(time.DateTime))
+	}
+	return writer.Flush()
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration < time.Second {
+		return duration.Round(time.Millisecond).String()
+	}
+	return duration.Round(10 * time.Millisecond).String()
+}
+
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
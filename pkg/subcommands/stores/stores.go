@@ -0,0 +1,293 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Store is a configured kubeconfig store with the state of its search index
+type Store struct {
+	// ID is the ID of the kubeconfig store
+	ID string `json:"id"`
+	// Kind is the kind of the kubeconfig store
+	Kind types.StoreKind `json:"kind"`
+	// Cache is the kind of the kubeconfig cache of the store, if configured
+	Cache string `json:"cache,omitempty"`
+	// Contexts is the number of contexts in the search index. Nil if the index has not been written yet.
+	Contexts *int `json:"contexts,omitempty"`
+	// IndexUpdated is when the search index has been refreshed the last time. Nil if the index has not been written yet.
+	IndexUpdated *time.Time `json:"indexUpdated,omitempty"`
+	// DegradedUntil is the end of the cooldown of the kubeconfig store after repeatedly failed searches
+	DegradedUntil *time.Time `json:"degradedUntil,omitempty"`
+}
+
+// List returns the kubeconfig stores with the state of their search index.
+// Does not call the APIs of the kubeconfig stores.
+func List(stores []storetypes.KubeconfigStore, stateDir string) ([]Store, error) {
+	list := make([]Store, 0, len(stores))
+	for _, kubeconfigStore := range stores {
+		store := Store{
+			ID:   kubeconfigStore.GetID(),
+			Kind: kubeconfigStore.GetKind(),
+		}
+		if cache := kubeconfigStore.GetStoreConfig().Cache; cache != nil {
+			store.Cache = cache.Kind
+		}
+
+		// the store from the environment variables and flags never writes an index
+		if pkg.Indexed(kubeconfigStore) {
+			searchIndex, err := index.New(kubeconfigStore.GetLogger(), store.Kind, stateDir, store.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the index of store %s: %v", store.ID, err)
+			}
+
+			if store.IndexUpdated, err = searchIndex.GetLastUpdateTime(); err != nil {
+				return nil, fmt.Errorf("failed to read the index of store %s: %v", store.ID, err)
+			}
+			if searchIndex.HasKind(store.Kind) {
+				contexts, _ := searchIndex.GetContent()
+				count := len(contexts)
+				store.Contexts = &count
+			}
+			if store.DegradedUntil, err = searchIndex.GetDegradedUntil(); err != nil {
+				return nil, fmt.Errorf("failed to read the index of store %s: %v", store.ID, err)
+			}
+		}
+		list = append(list, store)
+	}
+	return list, nil
+}
+
+// PrintList writes the kubeconfig stores as table or as JSON objects, one per line
+func PrintList(w io.Writer, list []Store, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		for _, store := range list {
+			if err := encoder.Encode(store); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STORE\tKIND\tCACHE\tCONTEXTS\tINDEX AGE\tSTATUS")
+	for _, store := range list {
+		cache := "-"
+		if len(store.Cache) > 0 {
+			cache = store.Cache
+		}
+		contexts := "-"
+		if store.Contexts != nil {
+			contexts = fmt.Sprintf("%d", *store.Contexts)
+		}
+		age := "-"
+		if store.IndexUpdated != nil {
+			age = formatAge(time.Since(*store.IndexUpdated))
+		}
+		status := "ok"
+		if store.DegradedUntil != nil {
+			status = fmt.Sprintf("degraded for %s", formatAge(time.Until(*store.DegradedUntil)))
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", store.ID, store.Kind, cache, contexts, age, status)
+	}
+	return writer.Flush()
+}
+
+// Step is a step of the test of a kubeconfig store
+type Step struct {
+	// Name is the name of the step
+	Name string `json:"name"`
+	// Duration is the latency of the step
+	Duration time.Duration `json:"duration"`
+	// Message describes the result of a successful step
+	Message string `json:"message,omitempty"`
+	// Error is the error of a failed step
+	Error string `json:"error,omitempty"`
+	// Skipped is true if the step does not apply to the kubeconfig store
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// Result is the result of the test of a kubeconfig store
+type Result struct {
+	// Store is the ID of the kubeconfig store
+	Store string `json:"store"`
+	// Kind is the kind of the kubeconfig store
+	Kind types.StoreKind `json:"kind"`
+	// Steps are the steps of the test in the order they ran. The test stops at the first failed step.
+	Steps []Step `json:"steps"`
+}
+
+// Failed returns true if a step of the test failed
+func (r Result) Failed() bool {
+	for _, step := range r.Steps {
+		if len(step.Error) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Test checks the credentials and the configuration of the kubeconfig store and searches it until the first context is found.
+// The search index and the kubeconfig cache are bypassed, so that the API of the kubeconfig store is called.
+// Each step fails if it does not complete within the timeout.
+func Test(store storetypes.KubeconfigStore, timeout time.Duration) Result {
+	result := Result{
+		Store: store.GetID(),
+		Kind:  store.GetKind(),
+	}
+
+	steps := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{name: "credentials", run: func() (string, error) { return checkCredentials(store) }},
+		{name: "configuration", run: func() (string, error) { return "valid", store.VerifyKubeconfigPaths() }},
+		{name: "search", run: func() (string, error) { return search(store) }},
+	}
+
+	for _, s := range steps {
+		step := runStep(s.name, s.run, timeout)
+		result.Steps = append(result.Steps, step)
+		if len(step.Error) > 0 {
+			break
+		}
+	}
+	return result
+}
+
+// errSkipped skips a step that does not apply to the kubeconfig store
+var errSkipped = errors.New("skipped")
+
+func runStep(name string, run func() (string, error), timeout time.Duration) Step {
+	type outcome struct {
+		message string
+		err     error
+	}
+
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		message, err := run()
+		done <- outcome{message, err}
+	}()
+
+	select {
+	case o := <-done:
+		step := Step{Name: name, Duration: time.Since(start), Message: o.message}
+		switch {
+		case errors.Is(o.err, errSkipped):
+			step.Skipped = true
+		case o.err != nil:
+			step.Error = o.err.Error()
+		}
+		return step
+	case <-time.After(timeout):
+		return Step{Name: name, Duration: time.Since(start), Error: fmt.Sprintf("no response within %s", timeout)}
+	}
+}
+
+// checkCredentials checks the credentials of the kubeconfig store without running an (interactive) authentication flow
+func checkCredentials(store storetypes.KubeconfigStore) (string, error) {
+	authenticator, ok := store.(storetypes.Authenticator)
+	if !ok {
+		return "no authentication required", errSkipped
+	}
+
+	expiry, err := authenticator.CheckCredentials()
+	switch {
+	case errors.Is(err, storetypes.ErrLoginNotSupported):
+		return "no authentication required", errSkipped
+	case err != nil:
+		return "", fmt.Errorf("%v. Run `switch login %s`", err, store.GetID())
+	case expiry == nil:
+		return "valid, no expiry", nil
+	case time.Until(*expiry) <= 0:
+		return "", fmt.Errorf("expired at %s. Run `switch login %s`", expiry.Local().Format(time.RFC3339), store.GetID())
+	default:
+		return fmt.Sprintf("valid until %s", expiry.Local().Format(time.RFC3339)), nil
+	}
+}
+
+// search searches the kubeconfig store until the first context is found
+func search(store storetypes.KubeconfigStore) (string, error) {
+	channel := make(chan storetypes.SearchResult)
+	go store.StartSearch(channel)
+
+	// the search is not drained after the first context: the store is abandoned when the process exits
+	for result := range channel {
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if len(result.KubeconfigPath) > 0 {
+			return fmt.Sprintf("found %q", result.KubeconfigPath), nil
+		}
+	}
+	return "no contexts found", nil
+}
+
+// PrintTest writes the steps of the tests as table or as JSON objects, one per kubeconfig store and line
+func PrintTest(w io.Writer, results []Result, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		for _, result := range results {
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STORE\tSTEP\tSTATUS\tLATENCY\tDETAILS")
+	for _, result := range results {
+		for _, step := range result.Steps {
+			status, details := "ok", step.Message
+			switch {
+			case step.Skipped:
+				status = "skipped"
+			case len(step.Error) > 0:
+				status, details = "failed", step.Error
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", result.Store, step.Name, status, step.Duration.Round(time.Millisecond), details)
+		}
+	}
+	return writer.Flush()
+}
+
+// formatAge formats the duration in seconds, minutes, hours or days
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return age.Round(time.Second).String()
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
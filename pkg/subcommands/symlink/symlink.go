@@ -0,0 +1,111 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symlink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultPath is the default path of the symlink pointing to the temporary kubeconfig of the last switch
+const defaultPath = "~/.kube/switch-current"
+
+// unsafeCharacters are replaced in the name of the terminal to form a valid filename
+var unsafeCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// IsEnabled returns true if every switch points the symlink to the new temporary kubeconfig
+func IsEnabled(config *types.Config) bool {
+	return config != nil && config.Symlink != nil && config.Symlink.Enabled != nil && *config.Symlink.Enabled
+}
+
+// GetPath returns the path of the symlink of the current terminal.
+// With one symlink per terminal, the path is suffixed with the name of the terminal, if any.
+func GetPath(config *types.Config) string {
+	path := basePath(config)
+	if config == nil || config.Symlink == nil || config.Symlink.PerTerminal == nil || !*config.Symlink.PerTerminal {
+		return path
+	}
+
+	terminal := strings.TrimPrefix(util.Terminal(), "/dev/")
+	if len(terminal) == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s-%s", path, strings.Trim(unsafeCharacters.ReplaceAllString(terminal, "-"), "-"))
+}
+
+// Update points the symlink to the temporary kubeconfig.
+// The symlink is replaced atomically, so that tools reading the kubeconfig never see a missing file.
+// Fails if the path exists but is not a symlink, so that a kubeconfig is never overwritten.
+func Update(path, kubeconfigPath string) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%q exists and is not a symlink", path)
+	}
+
+	target, err := filepath.Abs(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	temporaryPath := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	_ = os.Remove(temporaryPath)
+	if err := os.Symlink(target, temporaryPath); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, path); err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+	return nil
+}
+
+// Targets returns the temporary kubeconfigs the symlinks point to, including the symlinks of all terminals.
+// The garbage collection must not delete them, as tools may still read them via the symlinks.
+func Targets(config *types.Config) []string {
+	if !IsEnabled(config) {
+		return nil
+	}
+
+	path := basePath(config)
+	links, _ := filepath.Glob(path + "-*")
+	links = append(links, path)
+
+	var targets []string
+	for _, link := range links {
+		target, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		targets = append(targets, filepath.Clean(target))
+	}
+	return targets
+}
+
+func basePath(config *types.Config) string {
+	if config != nil && config.Symlink != nil && config.Symlink.Path != nil {
+		return util.ExpandEnv(*config.Symlink.Path)
+	}
+	return util.ExpandEnv(defaultPath)
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailscale
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/tailscale"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var logger = logging.New()
+
+// Tailscale prints the contexts whose clusters are reachable over the tailnet together with the state of the
+// nodes serving their API servers, and the API server proxies of the Tailscale Kubernetes operator
+// that are not referenced by any context yet.
+func Tailscale(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	tailscaleConfig := &types.Tailscale{}
+	if config != nil && config.Tailscale != nil {
+		tailscaleConfig = config.Tailscale
+	}
+
+	status, err := tailscale.GetStatus(tailscaleConfig)
+	if err != nil {
+		return err
+	}
+
+	tailnet := status.MagicDNSSuffix
+	if len(tailnet) == 0 {
+		tailnet = "tailnet"
+	}
+	fmt.Fprintf(os.Stdout, "%s: %s\n", tailnet, status.BackendState)
+
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	referenced := map[*tailscale.Peer]bool{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot list all contexts. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if !tailscale.Required(tailscaleConfig, discoveredContext.Server, discoveredContext.Name, discoveredContext.Alias) {
+			continue
+		}
+
+		name := discoveredContext.Name
+		if len(discoveredContext.Alias) > 0 {
+			name = fmt.Sprintf("%s (%s)", discoveredContext.Alias, discoveredContext.Name)
+		}
+
+		peer := status.PeerForServer(discoveredContext.Server)
+		switch {
+		case peer == nil:
+			lines = append(lines, fmt.Sprintf("[?] %s: %s is not a node of the tailnet", name, discoveredContext.Server))
+		case !peer.Online:
+			referenced[peer] = true
+			lines = append(lines, fmt.Sprintf("[✗] %s: %s is served by %q, which is offline", name, discoveredContext.Server, peer.Name()))
+		default:
+			referenced[peer] = true
+			lines = append(lines, fmt.Sprintf("[✓] %s: %s is served by %q", name, discoveredContext.Server, peer.Name()))
+		}
+	}
+
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(os.Stdout, line)
+	}
+	if len(lines) == 0 {
+		fmt.Fprintln(os.Stdout, "No contexts reachable over the tailnet found")
+	}
+
+	var operators []string
+	for _, peer := range status.Peer {
+		if peer.IsOperator() && !referenced[peer] {
+			operators = append(operators, peer.Name())
+		}
+	}
+	if len(operators) == 0 {
+		return nil
+	}
+
+	sort.Strings(operators)
+	fmt.Fprintln(os.Stdout, "\nKubernetes operators without a context:")
+	for _, operator := range operators {
+		fmt.Fprintf(os.Stdout, "    - %s: run \"tailscale configure kubeconfig %s\" to add a context\n", operator, operator)
+	}
+	return nil
+}
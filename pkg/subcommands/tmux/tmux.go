@@ -0,0 +1,102 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// KubeconfigOption is the tmux pane option holding the kubeconfig of the pane.
+// Used by the status line to show the context of the active pane.
+const KubeconfigOption = "@kubeswitch_kubeconfig"
+
+// ErrNotInTmux is returned if kubeswitch is not running inside a tmux session
+var ErrNotInTmux = fmt.Errorf("not running inside a tmux session")
+
+// CurrentPane returns the ID of the tmux pane kubeswitch is running in.
+// When run from a key binding (run-shell), returns the active pane of the client instead.
+func CurrentPane() (string, error) {
+	if len(os.Getenv("TMUX")) == 0 {
+		return "", ErrNotInTmux
+	}
+	if pane := os.Getenv("TMUX_PANE"); len(pane) > 0 {
+		return pane, nil
+	}
+
+	output, err := exec.Command("tmux", "display-message", "-p", "#{pane_id}").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the active tmux pane: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// OpenPopup runs kubeswitch with the given arguments in a tmux popup
+func OpenPopup(args []string, width, height string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	command := []string{quote(executable)}
+	for _, arg := range args {
+		command = append(command, quote(arg))
+	}
+	return run("display-popup", "-E", "-w", width, "-h", height, strings.Join(command, " "))
+}
+
+// SetPaneKubeconfig exports the kubeconfig in the shell of the pane and remembers it for the status line
+func SetPaneKubeconfig(pane, kubeconfigPath string) error {
+	if err := run("set-option", "-p", "-t", pane, KubeconfigOption, kubeconfigPath); err != nil {
+		return err
+	}
+
+	// the leading space keeps the command out of the shell history
+	if err := run("send-keys", "-t", pane, fmt.Sprintf(" export KUBECONFIG=%s", quote(kubeconfigPath)), "Enter"); err != nil {
+		return err
+	}
+
+	// update the status line right away. Fails if no client is attached, which does not matter
+	_ = run("refresh-client", "-S")
+	return nil
+}
+
+// NewWindow opens a new tmux window with the given name whose shell uses the kubeconfig
+func NewWindow(name, kubeconfigPath string) error {
+	if len(os.Getenv("TMUX")) == 0 {
+		return ErrNotInTmux
+	}
+
+	output, err := exec.Command("tmux", "new-window", "-d", "-P", "-F", "#{pane_id}", "-n", name, "-e", "KUBECONFIG="+kubeconfigPath).Output()
+	if err != nil {
+		return fmt.Errorf("failed to run tmux new-window: %v", err)
+	}
+	return run("set-option", "-p", "-t", strings.TrimSpace(string(output)), KubeconfigOption, kubeconfigPath)
+}
+
+func run(args ...string) error {
+	output, err := exec.Command("tmux", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run tmux %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// quote quotes the argument for the shell
+func quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/daemon"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+)
+
+const (
+	// healthTimeout is the maximum duration to wait for the API server of a cluster
+	healthTimeout = 5 * time.Second
+	// healthCacheDuration is the duration for which the health of a cluster is cached
+	healthCacheDuration = time.Minute
+	// tokenHeader contains the token that protects the API from requests of other websites
+	tokenHeader = "X-Kubeswitch-Token"
+)
+
+//go:embed index.html
+var indexHTML string
+
+// Options contains the configuration of the web UI
+type Options struct {
+	// Address is the local address the web UI is served on, e.g. "127.0.0.1:8765"
+	Address string
+	// SocketPath is the path of the Unix socket of the daemon
+	SocketPath string
+}
+
+// health is the health of a cluster
+type health struct {
+	Reachable bool   `json:"reachable"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+	checked   time.Time
+}
+
+type server struct {
+	client *daemon.Client
+	log    *logrus.Entry
+	// token is generated on startup and embedded into the page. Other websites cannot read the page, hence cannot call the API.
+	token string
+
+	healthMutex sync.Mutex
+	healthCache map[string]health
+}
+
+// Run serves the web UI until the process receives SIGINT or SIGTERM.
+// The web UI is backed by the API of a running daemon.
+func Run(options Options) error {
+	client := daemon.NewClient(options.SocketPath)
+	if !client.Available() {
+		return fmt.Errorf("the daemon is not running on socket %s. Please start it with `switch daemon`", options.SocketPath)
+	}
+
+	host, _, err := net.SplitHostPort(options.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", options.Address, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("the web UI exposes kubeconfigs and must only listen on a loopback address, not %q", host)
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+
+	s := &server{
+		client:      client,
+		log:         logging.New().WithField("component", "ui"),
+		token:       hex.EncodeToString(token),
+		healthCache: make(map[string]health),
+	}
+
+	listener, err := net.Listen("tcp", options.Address)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	httpServer := &http.Server{Handler: s.handler()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	s.log.Infof("Serving the web UI on http://%s", listener.Addr())
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /api/status", s.withToken(s.handleStatus))
+	mux.HandleFunc("GET /api/contexts", s.withToken(s.handleContexts))
+	mux.HandleFunc("GET /api/kubeconfig", s.withToken(s.handleKubeconfig))
+	mux.HandleFunc("GET /api/health", s.withToken(s.handleHealth))
+	mux.HandleFunc("POST /api/set-context", s.withToken(s.handleSetContext))
+	return s.withLocalHost(mux)
+}
+
+// withLocalHost rejects requests for other hosts, e.g. DNS rebinding attacks
+func (s *server) withLocalHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withToken rejects API requests without the token of the page
+func (s *server) withToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(tokenHeader) != s.token {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	_, _ = w.Write([]byte(strings.Replace(indexHTML, "{{TOKEN}}", s.token, 1)))
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	status, err := s.client.Status()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, status)
+}
+
+func (s *server) handleContexts(w http.ResponseWriter, _ *http.Request) {
+	contexts, err := s.client.ListContexts("*")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, contexts)
+}
+
+func (s *server) handleKubeconfig(w http.ResponseWriter, r *http.Request) {
+	kubeconfig, err := s.client.GetKubeconfig(r.URL.Query().Get("context"), r.URL.Query().Get("store"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(kubeconfig)
+}
+
+func (s *server) handleSetContext(w http.ResponseWriter, r *http.Request) {
+	request := daemon.SetContextRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.Exact = true
+
+	response, err := s.client.SetContextWithRequest(request)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, response)
+}
+
+// handleHealth checks if the API server of the cluster of the context is reachable and returns its version
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	contextName := r.URL.Query().Get("context")
+	storeID := r.URL.Query().Get("store")
+	key := storeID + "/" + contextName
+
+	s.healthMutex.Lock()
+	cached, ok := s.healthCache[key]
+	s.healthMutex.Unlock()
+	if ok && time.Since(cached.checked) < healthCacheDuration {
+		writeJSON(w, cached)
+		return
+	}
+
+	result := s.checkHealth(contextName, storeID)
+	result.checked = time.Now()
+
+	s.healthMutex.Lock()
+	s.healthCache[key] = result
+	s.healthMutex.Unlock()
+
+	writeJSON(w, result)
+}
+
+func (s *server) checkHealth(contextName, storeID string) health {
+	kubeconfig, err := s.client.GetKubeconfig(contextName, storeID)
+	if err != nil {
+		return health{Error: err.Error()}
+	}
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return health{Error: fmt.Sprintf("failed to parse kubeconfig: %v", err)}
+	}
+
+	// the discovered context name is prefixed by the kubeconfig store
+	name := ""
+	for candidate := range config.Contexts {
+		if (contextName == candidate || strings.HasSuffix(contextName, "/"+candidate)) && len(candidate) > len(name) {
+			name = candidate
+		}
+	}
+	if len(name) == 0 {
+		return health{Error: "context not found in kubeconfig"}
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*config, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return health{Error: err.Error()}
+	}
+	restConfig.Timeout = healthTimeout
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return health{Error: err.Error()}
+	}
+
+	version, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return health{Error: err.Error()}
+	}
+	return health{Reachable: true, Version: version.GitVersion}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
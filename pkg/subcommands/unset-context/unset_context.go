@@ -16,25 +16,33 @@ package setcontext
 
 import (
 	"fmt"
-	"os"
 
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
 )
 
+var logger = logging.New()
+
+// UnsetCurrentContext unsets the current context of the current kubeconfig.
+// Like kubectl, the current context is unset in the kubeconfig file setting it if the KUBECONFIG environment variable contains multiple files.
 func UnsetCurrentContext() error {
-	kcPath := os.Getenv("KUBECONFIG")
-	kubeconfig, err := kubeconfigutil.NewKubeconfigForPath(kcPath)
+	kubeconfigFiles, err := kubeconfigutil.LoadCurrentKubeconfigFiles()
 	if err != nil {
 		return err
 	}
+	kubeconfig := kubeconfigFiles.CurrentContextFile()
 
 	if err := kubeconfig.ModifyCurrentContext(""); err != nil {
 		return err
 	}
 
-	if _, err := kubeconfig.WriteKubeconfigFile(); err != nil {
+	kubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
+	if err != nil {
 		return fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
 	}
 
+	if len(kubeconfigFiles) > 1 {
+		logger.Infof("Unset the current context in kubeconfig file %q", kubeconfigPath)
+	}
 	return nil
 }
@@ -0,0 +1,120 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var logger = logging.New()
+
+// kubeconfig is a kubeconfig in a kubeconfig store containing at least one of the contexts to validate
+type kubeconfig struct {
+	store storetypes.KubeconfigStore
+	path  string
+	tags  map[string]string
+}
+
+// Validate retrieves the kubeconfigs of the contexts matching one of the patterns (all contexts without patterns)
+// from their kubeconfig stores and validates them. Prints the problems found per store and path.
+// Fails if at least one kubeconfig is invalid.
+func Validate(patterns []string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	// a kubeconfig contains many contexts, only validate it once
+	kubeconfigs := map[string]kubeconfig{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot validate all kubeconfigs. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Store == nil || !matches(patterns, discoveredContext) {
+			continue
+		}
+
+		store := *discoveredContext.Store
+		kubeconfigs[fmt.Sprintf("%s %s", store.GetID(), discoveredContext.Path)] = kubeconfig{
+			store: store,
+			path:  discoveredContext.Path,
+			tags:  discoveredContext.Tags,
+		}
+	}
+
+	if len(kubeconfigs) == 0 {
+		return fmt.Errorf("no contexts found")
+	}
+
+	keys := make([]string, 0, len(kubeconfigs))
+	for key := range kubeconfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	invalid := 0
+	for _, key := range keys {
+		problems := validate(kubeconfigs[key])
+		if len(problems) == 0 {
+			fmt.Fprintf(os.Stdout, "[✓] %s\n", key)
+			continue
+		}
+
+		invalid++
+		fmt.Fprintf(os.Stdout, "[✗] %s\n", key)
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stdout, "    - %s\n", problem)
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d kubeconfig(s) are invalid", invalid, len(kubeconfigs))
+	}
+	return nil
+}
+
+// validate retrieves the kubeconfig from its kubeconfig store and returns the problems found
+func validate(k kubeconfig) []string {
+	data, err := k.store.GetKubeconfigForPath(k.path, k.tags)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to retrieve kubeconfig: %v", err)}
+	}
+	return util.ValidateKubeconfig(data)
+}
+
+// matches returns true if there are no patterns or the context name or alias matches one of them
+func matches(patterns []string, discoveredContext pkg.DiscoveredContext) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		m := wildmatch.NewWildMatch(pattern)
+		if m.IsMatch(discoveredContext.Name) || (len(discoveredContext.Alias) > 0 && m.IsMatch(discoveredContext.Alias)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifycache
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var logger = logging.New()
+
+// kubeconfig is a kubeconfig in a kubeconfig store with a cache that can be verified
+type kubeconfig struct {
+	store storetypes.KubeconfigStore
+	path  string
+	tags  map[string]string
+}
+
+// VerifyCache compares the cached kubeconfigs of the kubeconfig stores with the kubeconfigs in the stores,
+// e.g. to detect rotated certificates or changed API server endpoints.
+// Only the changed kubeconfigs are replaced in the cache. Prints the changes per store and path.
+func VerifyCache(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	if err != nil {
+		return err
+	}
+
+	// a kubeconfig contains many contexts, only verify it once
+	kubeconfigs := map[string]kubeconfig{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil {
+			logger.Warnf("cannot verify all cached kubeconfigs. Error returned from search: %v", discoveredContext.Error)
+			continue
+		}
+		if discoveredContext.Store == nil {
+			continue
+		}
+
+		store := *discoveredContext.Store
+		if _, ok := store.(cache.Verifiable); !ok {
+			continue
+		}
+		kubeconfigs[fmt.Sprintf("%s (%s)", discoveredContext.Path, store.GetID())] = kubeconfig{
+			store: store,
+			path:  discoveredContext.Path,
+			tags:  discoveredContext.Tags,
+		}
+	}
+
+	keys := make([]string, 0, len(kubeconfigs))
+	for key := range kubeconfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		verified, changed int
+		failed            []string
+		verifiedStores    = map[string]struct{}{}
+	)
+	for _, key := range keys {
+		k := kubeconfigs[key]
+		drift, err := k.store.(cache.Verifiable).Verify(k.path, k.tags)
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "! %s: %v\n", key, err)
+			failed = append(failed, key)
+			continue
+		}
+		if drift == nil {
+			// not cached, the kubeconfig is loaded from the store on the next switch anyways
+			continue
+		}
+
+		verified++
+		verifiedStores[k.store.GetID()] = struct{}{}
+		if len(drift.Changes) > 0 {
+			changed++
+			fmt.Fprintf(os.Stdout, "~ %s: %s\n", key, strings.Join(drift.Changes, ", "))
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "Verified %d cached kubeconfig(s) of %d kubeconfig store(s): %d changed\n", verified, len(verifiedStores), changed)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to verify %d cached kubeconfig(s)", len(failed))
+	}
+	return nil
+}
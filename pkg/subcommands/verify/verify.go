@@ -0,0 +1,134 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/becheran/wildmatch-go"
+	"golang.org/x/term"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// timeout is the maximum duration to wait for the API server
+const timeout = 5 * time.Second
+
+// Cluster verifies that the API server of the current context in the kubeconfig is reachable
+// and accepts the credentials of the kubeconfig
+func Cluster(kubeconfigPath string) error {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+	restConfig.Timeout = timeout
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	if _, err := clientset.Discovery().ServerVersion(); err != nil {
+		if apierrors.IsUnauthorized(err) {
+			return fmt.Errorf("credentials are invalid or expired")
+		}
+		return fmt.Errorf("cluster unreachable: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// the API server version may be served to anonymous users, while every authenticated user may create self subject access reviews
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "namespaces"},
+		},
+	}
+	if _, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{}); err != nil {
+		if apierrors.IsUnauthorized(err) {
+			return fmt.Errorf("credentials are invalid or expired")
+		}
+		if !apierrors.IsForbidden(err) {
+			return fmt.Errorf("failed to verify credentials: %v", err)
+		}
+	}
+	return nil
+}
+
+// Confirm asks on the terminal whether to switch to the context despite the failed verification.
+// The context name may be colored by environment.
+// Returns false without asking if stdin is not a terminal.
+func Confirm(contextName string, verificationError error) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	// prompt on stderr, as stdout is read by the shell integration
+	fmt.Fprintf(os.Stderr, "Context %s: %v — switch anyway? [y/N] ", contextName, verificationError)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && len(answer) == 0 {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsProtected returns true if any of the context names matches any of the wildcard patterns of protected contexts
+func IsProtected(patterns []string, contextNames ...string) bool {
+	for _, pattern := range patterns {
+		m := wildmatch.NewWildMatch(pattern)
+		for _, contextName := range contextNames {
+			if len(contextName) > 0 && m.IsMatch(contextName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ConfirmProtected asks on the terminal to type the name of the protected context to switch to it.
+// The displayed context name may be colored by environment.
+// Returns false without asking if stdin is not a terminal.
+func ConfirmProtected(contextName, displayName string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	// prompt on stderr, as stdout is read by the shell integration
+	fmt.Fprintf(os.Stderr, "Context %s is protected. Type the context name to switch: ", displayName)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && len(answer) == 0 {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	return strings.TrimSpace(answer) == contextName
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/becheran/wildmatch-go"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+)
+
+// ContextTagSelector restricts the search results to the contexts with tags matching all requirements of the selector.
+// Set by the flag --tags of the command line.
+var ContextTagSelector *TagSelector
+
+// TagSelector is a list of requirements on the tags of a context
+type TagSelector struct {
+	requirements []tagRequirement
+}
+
+// tagRequirement requires a tag to exist, or to have a value matching or not matching a wildcard pattern
+type tagRequirement struct {
+	key     string
+	value   *wildmatch.WildMatch
+	negated bool
+}
+
+// NewTagSelector returns the selector for requirements like "env=prod", "env!=prod", "team=platform-*" or "owner".
+// A requirement without a value requires the tag to exist.
+func NewTagSelector(requirements []string) (*TagSelector, error) {
+	selector := &TagSelector{}
+	for _, requirement := range requirements {
+		key, value, hasValue := strings.Cut(requirement, "=")
+		negated := hasValue && strings.HasSuffix(key, "!")
+		key = strings.TrimSpace(strings.TrimSuffix(key, "!"))
+		if len(key) == 0 {
+			return nil, fmt.Errorf("invalid tag requirement %q: the key must not be empty", requirement)
+		}
+
+		r := tagRequirement{key: key, negated: negated}
+		if hasValue {
+			r.value = wildmatch.NewWildMatch(strings.TrimSpace(value))
+		}
+		selector.requirements = append(selector.requirements, r)
+	}
+	return selector, nil
+}
+
+// Matches returns true if the tags of a context match all requirements.
+// Cloud tags are matched by their key with or without the prefix "tag:".
+func (s TagSelector) Matches(tags map[string]string) bool {
+	for _, r := range s.requirements {
+		value, ok := tags[r.key]
+		if !ok {
+			value, ok = tags[storetypes.CloudTagPrefix+r.key]
+		}
+
+		switch {
+		case r.value == nil:
+			if !ok {
+				return false
+			}
+		case r.negated:
+			if ok && r.value.IsMatch(value) {
+				return false
+			}
+		default:
+			if !ok || !r.value.IsMatch(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,288 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tailscale detects the clusters reachable over a Tailscale network (tailnet), verifies that tailscaled
+// is connected before switching to them and rewrites tailnet IP addresses of API servers to MagicDNS names.
+package tailscale
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/becheran/wildmatch-go"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/logging"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultExecutable is the default tailscale CLI
+	defaultExecutable = "tailscale"
+	// statusTimeout is the timeout of querying the status of the tailnet
+	statusTimeout = 5 * time.Second
+	// backendStateRunning is the state of tailscaled when it is connected to the tailnet
+	backendStateRunning = "Running"
+	// operatorTag is the tag of the API server proxies of the Tailscale Kubernetes operator
+	operatorTag = "tag:k8s-operator"
+	// tailnetDomain is the domain of the MagicDNS names of all tailnets
+	tailnetDomain = ".ts.net"
+)
+
+var (
+	logger = logging.New()
+
+	// tailnetPrefixes are the address ranges Tailscale assigns to the nodes of a tailnet
+	tailnetPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("100.64.0.0/10"),
+		netip.MustParsePrefix("fd7a:115c:a1e0::/48"),
+	}
+)
+
+// Status is the status of the tailnet as returned by "tailscale status --json"
+type Status struct {
+	// BackendState is the state of tailscaled, e.g. "Running", "Stopped" or "NeedsLogin"
+	BackendState string `json:"BackendState"`
+	// Self is the local node
+	Self *Peer `json:"Self"`
+	// Peer are the other nodes of the tailnet by public key
+	Peer map[string]*Peer `json:"Peer"`
+	// MagicDNSSuffix is the domain of the MagicDNS names of the tailnet, e.g. "tailnet-1234.ts.net"
+	MagicDNSSuffix string `json:"MagicDNSSuffix"`
+}
+
+// Peer is a node of the tailnet
+type Peer struct {
+	// HostName is the host name of the node
+	HostName string `json:"HostName"`
+	// DNSName is the fully qualified MagicDNS name of the node including the trailing dot
+	DNSName string `json:"DNSName"`
+	// TailscaleIPs are the tailnet IP addresses of the node
+	TailscaleIPs []string `json:"TailscaleIPs"`
+	// Online is true if the node is connected to the tailnet
+	Online bool `json:"Online"`
+	// Tags are the ACL tags of the node
+	Tags []string `json:"Tags"`
+}
+
+// Name returns the MagicDNS name of the node without the trailing dot, or its host name if MagicDNS is disabled
+func (p *Peer) Name() string {
+	if name := strings.TrimSuffix(p.DNSName, "."); len(name) > 0 {
+		return name
+	}
+	return p.HostName
+}
+
+// IsOperator returns true if the node is an API server proxy of the Tailscale Kubernetes operator
+func (p *Peer) IsOperator() bool {
+	for _, tag := range p.Tags {
+		if tag == operatorTag {
+			return true
+		}
+	}
+	return false
+}
+
+// Running returns true if tailscaled is connected to the tailnet
+func (s *Status) Running() bool {
+	return s.BackendState == backendStateRunning
+}
+
+// PeerForHost returns the node of the tailnet with the given MagicDNS name, host name or IP address, or nil if not found
+func (s *Status) PeerForHost(host string) *Peer {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	peers := make([]*Peer, 0, len(s.Peer)+1)
+	if s.Self != nil {
+		peers = append(peers, s.Self)
+	}
+	for _, peer := range s.Peer {
+		peers = append(peers, peer)
+	}
+
+	for _, peer := range peers {
+		if strings.EqualFold(strings.TrimSuffix(peer.DNSName, "."), host) ||
+			(len(s.MagicDNSSuffix) > 0 && strings.EqualFold(fmt.Sprintf("%s.%s", peer.HostName, s.MagicDNSSuffix), host)) {
+			return peer
+		}
+		for _, ip := range peer.TailscaleIPs {
+			if ip == host {
+				return peer
+			}
+		}
+	}
+	return nil
+}
+
+// PeerForServer returns the node of the tailnet serving the API server URL, or nil if not found
+func (s *Status) PeerForServer(server string) *Peer {
+	host := hostOf(server)
+	if len(host) == 0 {
+		return nil
+	}
+	return s.PeerForHost(host)
+}
+
+// GetStatus queries the status of the tailnet with the tailscale CLI
+func GetStatus(config *types.Tailscale) (*Status, error) {
+	executable := defaultExecutable
+	if config != nil && config.Executable != nil && len(*config.Executable) > 0 {
+		executable = *config.Executable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := util.Command(ctx, executable, "status", "--json")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+			return nil, fmt.Errorf("failed to query the tailscale status: %v: %s", err, message)
+		}
+		return nil, fmt.Errorf("failed to query the tailscale status: %v", err)
+	}
+
+	status := &Status{}
+	if err := json.Unmarshal(stdout.Bytes(), status); err != nil {
+		return nil, fmt.Errorf("failed to parse the tailscale status: %v", err)
+	}
+	return status, nil
+}
+
+// IsTailnetServer returns true if the host of the API server URL is a tailnet IP address or a MagicDNS name
+func IsTailnetServer(server string) bool {
+	host := hostOf(server)
+	if len(host) == 0 {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		for _, prefix := range tailnetPrefixes {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.HasSuffix(strings.TrimSuffix(strings.ToLower(host), "."), tailnetDomain)
+}
+
+// Required returns true if the context is only reachable over the tailnet,
+// i.e. it matches one of the configured patterns or its API server is on the tailnet
+func Required(config *types.Tailscale, server string, contextNames ...string) bool {
+	if config == nil {
+		return false
+	}
+	for _, pattern := range config.Contexts {
+		m := wildmatch.NewWildMatch(pattern)
+		for _, contextName := range contextNames {
+			if len(contextName) > 0 && m.IsMatch(contextName) {
+				return true
+			}
+		}
+	}
+	return IsTailnetServer(server)
+}
+
+// Apply verifies that tailscaled is connected to the tailnet before switching to a context only reachable over the tailnet
+// and that the node serving its API server is online. Rewrites the tailnet IP addresses of the API servers of all clusters
+// to the MagicDNS names of their nodes. Does nothing if the Tailscale integration is not configured.
+func Apply(kubeconfig *kubeconfigutil.Kubeconfig, config *types.Config, contextNames ...string) error {
+	if config == nil || config.Tailscale == nil {
+		return nil
+	}
+
+	server, err := kubeconfig.ServerOfContext(kubeconfig.GetCurrentContext())
+	if err != nil {
+		return err
+	}
+	required := Required(config.Tailscale, server, contextNames...)
+
+	rewrite := config.Tailscale.RewriteServers == nil || *config.Tailscale.RewriteServers
+	servers := map[string]string{}
+	if rewrite {
+		for cluster, clusterServer := range kubeconfig.GetClusterServers() {
+			if net.ParseIP(hostOf(clusterServer)) != nil && IsTailnetServer(clusterServer) {
+				servers[cluster] = clusterServer
+			}
+		}
+	}
+
+	if !required && len(servers) == 0 {
+		return nil
+	}
+
+	status, err := GetStatus(config.Tailscale)
+	if err != nil {
+		if required {
+			return fmt.Errorf("context %q is only reachable over the tailnet: %v", kubeconfig.GetCurrentContext(), err)
+		}
+		logger.Debugf("not rewriting tailnet addresses of the API servers: %v", err)
+		return nil
+	}
+
+	if required {
+		if !status.Running() {
+			return fmt.Errorf("context %q is only reachable over the tailnet, but tailscale is not connected (state %q). Run \"tailscale up\" and switch again", kubeconfig.GetCurrentContext(), status.BackendState)
+		}
+		if peer := status.PeerForServer(server); peer != nil && !peer.Online {
+			return fmt.Errorf("the API server %q of context %q is served by the tailnet node %q, which is offline", server, kubeconfig.GetCurrentContext(), peer.Name())
+		}
+	}
+
+	for cluster, clusterServer := range servers {
+		peer := status.PeerForServer(clusterServer)
+		if peer == nil || len(strings.TrimSuffix(peer.DNSName, ".")) == 0 {
+			continue
+		}
+		rewritten, err := withHost(clusterServer, peer.Name())
+		if err != nil {
+			continue
+		}
+		logger.Debugf("rewriting API server %q of cluster %q to %q", clusterServer, cluster, rewritten)
+		kubeconfig.SetClusterServer(cluster, rewritten)
+	}
+	return nil
+}
+
+// hostOf returns the host of the API server URL without the port
+func hostOf(server string) string {
+	u, err := url.Parse(server)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// withHost replaces the host of the API server URL, keeping the scheme, port and path
+func withHost(server, host string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	if port := u.Port(); len(port) > 0 {
+		u.Host = net.JoinHostPort(host, port)
+	} else {
+		u.Host = host
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package theme
+
+import (
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultIcon is shown for store kinds without an icon
+const defaultIcon = "\U000f10fe" // nf-md-kubernetes
+
+// storeIcons are the default Nerd Font icons of the store kinds
+var storeIcons = map[types.StoreKind]string{
+	types.StoreKindFilesystem:   "\uf07b", // nf-fa-folder
+	types.StoreKindVault:        "\uf023", // nf-fa-lock
+	types.StoreKindGardener:     "\uf06c", // nf-fa-leaf
+	types.StoreKindGKE:          "\uf1a0", // nf-fa-google
+	types.StoreKindAzure:        "\uebd8", // nf-cod-azure
+	types.StoreKindEKS:          "\uf270", // nf-fa-aws
+	types.StoreKindExoscale:     "\uf0c2", // nf-fa-cloud
+	types.StoreKindOVH:          "\uf0c2", // nf-fa-cloud
+	types.StoreKindScaleway:     "\uf0c2", // nf-fa-cloud
+	types.StoreKindAkamai:       "\uf0c2", // nf-fa-cloud
+	types.StoreKindDigitalOcean: "\ue7ae", // nf-dev-digitalocean
+	types.StoreKindPlugin:       "\uf1e6", // nf-fa-plug
+	types.StoreKindExec:         "\uf120", // nf-fa-terminal
+	types.StoreKindMock:         "\uf0c3", // nf-fa-flask
+}
+
+// StoreIcon returns the icon of the store kind.
+// Configured icons take precedence over the default icons.
+func StoreIcon(kind types.StoreKind, icons map[types.StoreKind]string) string {
+	if icon, ok := icons[kind]; ok {
+		return icon
+	}
+	if icon, ok := storeIcons[kind]; ok {
+		return icon
+	}
+	return defaultIcon
+}
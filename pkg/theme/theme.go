@@ -0,0 +1,136 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package theme colors contexts by the environment they belong to, e.g. production contexts in red,
+// and provides the icons of the kubeconfig store kinds.
+package theme
+
+import (
+	"regexp"
+
+	"github.com/becheran/wildmatch-go"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/muesli/termenv"
+)
+
+// namedColors maps the color names to ANSI 256 color codes
+var namedColors = map[string]string{
+	"red":     "196",
+	"orange":  "208",
+	"yellow":  "226",
+	"green":   "40",
+	"cyan":    "51",
+	"blue":    "33",
+	"magenta": "201",
+	"white":   "15",
+	"gray":    "245",
+}
+
+// colorCode matches ANSI 256 color codes and hex colors
+var colorCode = regexp.MustCompile(`^([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5]|#[0-9a-fA-F]{6})$`)
+
+// IsValidColor returns true if the color is a color name, an ANSI 256 color code or a hex color
+func IsValidColor(color string) bool {
+	_, ok := namedColors[color]
+	return ok || colorCode.MatchString(color)
+}
+
+// Color returns the ANSI 256 color code or hex color of the color
+func Color(color string) string {
+	if code, ok := namedColors[color]; ok {
+		return code
+	}
+	return color
+}
+
+// Colorize renders the text in the color using ANSI escape sequences, regardless of whether the output is a terminal
+func Colorize(text, color string) string {
+	return termenv.String(text).Foreground(termenv.ANSI256.Color(Color(color))).String()
+}
+
+// Dim renders the text faint using ANSI escape sequences, regardless of whether the output is a terminal
+func Dim(text string) string {
+	return termenv.String(text).Faint().String()
+}
+
+// Theme matches contexts against the configured environments
+type Theme struct {
+	environments []environment
+}
+
+type environment struct {
+	types.Environment
+	patterns []*wildmatch.WildMatch
+}
+
+// New creates a theme for the environments.
+// Returns nil if no environments are configured.
+func New(environments []types.Environment) *Theme {
+	if len(environments) == 0 {
+		return nil
+	}
+
+	t := &Theme{}
+	for _, e := range environments {
+		env := environment{Environment: e}
+		for _, pattern := range e.Contexts {
+			env.patterns = append(env.patterns, wildmatch.NewWildMatch(pattern))
+		}
+		t.environments = append(t.environments, env)
+	}
+	return t
+}
+
+// Match returns the first environment matching either the context name (or alias) or the tags of the kubeconfig.
+// Returns nil if no environment matches.
+func (t *Theme) Match(contextName string, tags map[string]string) *types.Environment {
+	if t == nil {
+		return nil
+	}
+
+	for i := range t.environments {
+		if t.environments[i].matches(contextName, tags) {
+			return &t.environments[i].Environment
+		}
+	}
+	return nil
+}
+
+func (e environment) matches(contextName string, tags map[string]string) bool {
+	for _, pattern := range e.patterns {
+		if pattern.IsMatch(contextName) {
+			return true
+		}
+	}
+
+	if len(e.Tags) == 0 {
+		return false
+	}
+	for key, value := range e.Tags {
+		if tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Colorize renders the context name in the color of its environment.
+// Returns the context name unchanged if no environment matches.
+func (t *Theme) Colorize(contextName string, tags map[string]string) string {
+	env := t.Match(contextName, tags)
+	if env == nil {
+		return contextName
+	}
+	return Colorize(contextName, env.Color)
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing emits OpenTelemetry traces of the search and switch operations,
+// e.g. to find out which kubeconfig store, kubeconfig fetch or hook delays a switch.
+// Tracing is enabled if an OTLP endpoint is configured via the standard environment variables
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT. Otherwise, all spans are no-ops.
+package tracing
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// instrumentationName is the name of the tracer
+	instrumentationName = "github.com/danielfoehrkn/kubeswitch"
+	// serviceName is the default service name of the traces. Overridden by OTEL_SERVICE_NAME.
+	serviceName = "kubeswitch"
+	// shutdownTimeout is the maximum time to wait for the export of the remaining spans on exit
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	// rootContext contains the span of the command
+	rootContext            = context.Background()
+	rootSpan    trace.Span = trace.SpanFromContext(rootContext)
+	provider    *sdktrace.TracerProvider
+
+	// searchContexts contains the span of the running search by kubeconfig store ID
+	searchContexts     = make(map[string]context.Context)
+	searchContextsLock sync.RWMutex
+)
+
+// Enabled returns true if an OTLP endpoint is configured
+func Enabled() bool {
+	return len(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) > 0 || len(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")) > 0
+}
+
+// Init configures the export of the traces if enabled and starts the span of the command.
+// The returned function names the span after the executed command, e.g. "switcher set-context", ends it and exports the remaining spans.
+func Init(version string) func(command string, err error) {
+	if !Enabled() {
+		return func(string, error) {}
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		logrus.Debugf("failed to create the OTLP trace exporter: %v", err)
+		return func(string, error) {}
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName), semconv.ServiceVersion(version)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		logrus.Debugf("failed to detect the resource of the traces: %v", err)
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	rootContext, rootSpan = tracer().Start(ctx, "switch")
+
+	return func(command string, err error) {
+		rootSpan.SetName(command)
+		End(rootSpan, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logrus.Debugf("failed to export the traces: %v", err)
+		}
+	}
+}
+
+// Start starts a span for an operation of the command.
+// Spans of a kubeconfig store are children of the span of its running search, if any.
+// The span has to be ended with End.
+func Start(name, storeID string, attributes ...attribute.KeyValue) trace.Span {
+	ctx := rootContext
+	if len(storeID) > 0 {
+		searchContextsLock.RLock()
+		if searchContext, ok := searchContexts[storeID]; ok {
+			ctx = searchContext
+		}
+		searchContextsLock.RUnlock()
+		attributes = append(attributes, attribute.String("switch.store.id", storeID))
+	}
+
+	_, span := tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+	return span
+}
+
+// StartSearch starts the span of the search of a kubeconfig store.
+// Spans of the kubeconfig store started until the returned function is called are children of the span.
+// The returned function ends the span.
+func StartSearch(storeID string, started time.Time, attributes ...attribute.KeyValue) func(err error, attributes ...attribute.KeyValue) {
+	attributes = append(attributes, attribute.String("switch.store.id", storeID))
+	ctx, span := tracer().Start(rootContext, "search "+storeID, trace.WithTimestamp(started), trace.WithAttributes(attributes...))
+
+	searchContextsLock.Lock()
+	searchContexts[storeID] = ctx
+	searchContextsLock.Unlock()
+
+	return func(err error, attributes ...attribute.KeyValue) {
+		searchContextsLock.Lock()
+		delete(searchContexts, storeID)
+		searchContextsLock.Unlock()
+
+		span.SetAttributes(attributes...)
+		End(span, err)
+	}
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
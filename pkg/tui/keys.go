@@ -0,0 +1,236 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultKeys are the keys bound to the built-in actions if not configured otherwise
+var defaultKeys = map[types.PickerAction][]string{
+	types.PickerActionSelect:          {"enter"},
+	types.PickerActionAbort:           {"esc", "ctrl+c"},
+	types.PickerActionUp:              {"up", "ctrl+p", "ctrl+k"},
+	types.PickerActionDown:            {"down", "ctrl+n", "ctrl+j"},
+	types.PickerActionPageUp:          {"pgup"},
+	types.PickerActionPageDown:        {"pgdown"},
+	types.PickerActionClearQuery:      {"ctrl+u"},
+	types.PickerActionToggleFocus:     {"tab", "shift+tab"},
+	types.PickerActionToggleStore:     {"space", "enter"},
+	types.PickerActionCopyKubeconfig:  {"ctrl+y"},
+	types.PickerActionOpenConsole:     {"ctrl+o"},
+	types.PickerActionToggleSortOrder: {"ctrl+r"},
+	types.PickerActionToggleView:      {"ctrl+t"},
+	types.PickerActionCollapse:        {"left"},
+	types.PickerActionExpand:          {"right"},
+	types.PickerActionToggleMark:      {"ctrl+space"},
+	types.PickerActionDeleteContext:   {"ctrl+d"},
+	types.PickerActionEditNote:        {"ctrl+e"},
+	types.PickerActionQuickSelect:     {"alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"},
+}
+
+// resultsActions are the actions available when the results pane is focused
+var resultsActions = []types.PickerAction{
+	types.PickerActionSelect,
+	types.PickerActionAbort,
+	types.PickerActionUp,
+	types.PickerActionDown,
+	types.PickerActionPageUp,
+	types.PickerActionPageDown,
+	types.PickerActionClearQuery,
+	types.PickerActionToggleFocus,
+	types.PickerActionCopyKubeconfig,
+	types.PickerActionOpenConsole,
+	types.PickerActionToggleSortOrder,
+	types.PickerActionToggleView,
+	types.PickerActionCollapse,
+	types.PickerActionExpand,
+	types.PickerActionToggleMark,
+	types.PickerActionDeleteContext,
+	types.PickerActionQuickSelect,
+	types.PickerActionEditNote,
+}
+
+// storesActions are the actions available when the store sidebar is focused.
+// Toggling a store takes precedence, as it shares the enter key with the selection by default.
+var storesActions = []types.PickerAction{
+	types.PickerActionToggleStore,
+	types.PickerActionAbort,
+	types.PickerActionUp,
+	types.PickerActionDown,
+	types.PickerActionToggleFocus,
+}
+
+// helpEntry describes actions in the footer
+type helpEntry struct {
+	actions []types.PickerAction
+	label   string
+}
+
+var (
+	resultsHelp = []helpEntry{
+		{[]types.PickerAction{types.PickerActionSelect}, "switch"},
+		{[]types.PickerAction{types.PickerActionUp, types.PickerActionDown}, "move"},
+		{[]types.PickerAction{types.PickerActionToggleFocus}, "stores"},
+		{[]types.PickerAction{types.PickerActionClearQuery}, "clear"},
+		{[]types.PickerAction{types.PickerActionCopyKubeconfig}, "copy"},
+		{[]types.PickerAction{types.PickerActionOpenConsole}, "console"},
+		{[]types.PickerAction{types.PickerActionToggleSortOrder}, "sort"},
+		{[]types.PickerAction{types.PickerActionToggleView}, "tree"},
+		{[]types.PickerAction{types.PickerActionToggleMark}, "mark"},
+		{[]types.PickerAction{types.PickerActionQuickSelect}, "quick select"},
+		{[]types.PickerAction{types.PickerActionEditNote}, "note"},
+		{[]types.PickerAction{types.PickerActionAbort}, "quit"},
+	}
+	storesHelp = []helpEntry{
+		{[]types.PickerAction{types.PickerActionToggleStore}, "toggle store"},
+		{[]types.PickerAction{types.PickerActionUp, types.PickerActionDown}, "move"},
+		{[]types.PickerAction{types.PickerActionToggleFocus}, "results"},
+		{[]types.PickerAction{types.PickerActionAbort}, "quit"},
+	}
+)
+
+// Command is a custom command bound to a key
+type Command struct {
+	// Key is the key the command is bound to
+	Key string
+	// Description is shown in the footer. Defaults to the key.
+	Description string
+	// Run executes the command for the highlighted item and returns its output.
+	// If items are marked, the command is executed for each marked item.
+	Run func(item Item) (string, error)
+	// RunAll executes the command once for all marked items instead of Run and returns its output
+	RunAll func(items []Item) (string, error)
+	// Open returns the command started in the foreground of the terminal for the highlighted item instead of Run.
+	// The picker is suspended until the command exits. Afterwards, cleanup is called. Marked items are ignored.
+	Open func(item Item) (cmd *exec.Cmd, cleanup func(), err error)
+	// Confirm is called in the foreground of the terminal before the command to open is started, e.g. to confirm protected contexts.
+	// The command is not started if Confirm returns false.
+	Confirm func(item Item) bool
+}
+
+// keymap maps keys to the built-in actions and custom commands
+type keymap struct {
+	actions  map[types.PickerAction][]string
+	commands map[string]Command
+}
+
+// newKeymap creates the keymap from the default keys.
+// Configured keys of an action replace the default keys of the action.
+func newKeymap(keybindings map[types.PickerAction][]string, commands []Command) keymap {
+	k := keymap{
+		actions:  make(map[types.PickerAction][]string, len(defaultKeys)),
+		commands: make(map[string]Command, len(commands)),
+	}
+	for action, keys := range defaultKeys {
+		k.actions[action] = keys
+	}
+	for action, keys := range keybindings {
+		k.actions[action] = keys
+	}
+	for _, command := range commands {
+		k.commands[command.Key] = command
+	}
+	return k
+}
+
+// action returns the action bound to the key among the given actions
+func (k keymap) action(key string, actions []types.PickerAction) (types.PickerAction, bool) {
+	for _, action := range actions {
+		for _, bound := range k.actions[action] {
+			if bound == key {
+				return action, true
+			}
+		}
+	}
+	return "", false
+}
+
+// index returns the position of the key among the keys bound to the action, or -1 if the key is not bound to the action
+func (k keymap) index(action types.PickerAction, key string) int {
+	for i, bound := range k.actions[action] {
+		if bound == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// help returns the first key of each entry and optionally the custom commands for the footer
+func (k keymap) help(entries []helpEntry, withCommands bool) string {
+	var help []string
+	for _, entry := range entries {
+		var keys []string
+		for _, action := range entry.actions {
+			if bound := k.actions[action]; len(bound) > 0 {
+				keys = append(keys, displayKey(bound[0]))
+			}
+		}
+		if len(keys) > 0 {
+			help = append(help, strings.Join(keys, "/")+": "+entry.label)
+		}
+	}
+
+	if withCommands {
+		for _, key := range sortedKeys(k.commands) {
+			description := k.commands[key].Description
+			if len(description) == 0 {
+				description = "run command"
+			}
+			help = append(help, displayKey(key)+": "+description)
+		}
+	}
+	return strings.Join(help, " • ")
+}
+
+// keyName returns the name of the pressed key as used in the configuration
+func keyName(msg tea.KeyMsg) string {
+	switch msg.Type {
+	case tea.KeySpace:
+		return "space"
+	case tea.KeyCtrlAt:
+		// terminals send NUL for ctrl+space
+		return "ctrl+space"
+	}
+	return msg.String()
+}
+
+func displayKey(key string) string {
+	switch key {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	}
+	return key
+}
+
+func sortedKeys(commands map[string]Command) []string {
+	keys := make([]string, 0, len(commands))
+	for key := range commands {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,1174 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/ktr0731/go-fuzzyfinder/matching"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// refreshInterval is the interval in which contexts added during the search are shown
+	refreshInterval = 100 * time.Millisecond
+	// sidebarWidth is the width of the store sidebar
+	sidebarWidth = 28
+	// loadingPreview is shown while the preview is retrieved
+	loadingPreview = "loading preview..."
+	// maxProbes is the maximum number of clusters probed in parallel
+	maxProbes = 8
+	// maxAccessChecks is the maximum number of clusters whose access is checked in parallel
+	maxAccessChecks = 4
+	// slowLatency is the round-trip latency above which a reachable cluster is shown as slow
+	slowLatency = 200 * time.Millisecond
+	// latencyWidth is the width of the latency column in the results pane
+	latencyWidth = 7
+	// prefetchDelay is the time the results have to be unchanged before the top results are prefetched, to not prefetch on every key stroke
+	prefetchDelay = 300 * time.Millisecond
+)
+
+type focus int
+
+const (
+	focusResults focus = iota
+	focusStores
+)
+
+var (
+	spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+	borderStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240"))
+	focusedStyle   = borderStyle.Copy().BorderForeground(lipgloss.Color("63"))
+	titleStyle     = lipgloss.NewStyle().Bold(true)
+	cursorStyle    = lipgloss.NewStyle().Reverse(true)
+	matchStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+	disabledStyle  = lipgloss.NewStyle().Faint(true)
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	tagStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	reachableStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	slowStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	footerStyle    = lipgloss.NewStyle().Faint(true)
+	noteStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("229")).Italic(true)
+)
+
+type tickMsg struct{}
+
+type previewMsg struct {
+	key     string
+	preview string
+}
+
+type clusterInfoMsg struct {
+	key         string
+	clusterInfo clusterInfo
+}
+
+type commandMsg struct {
+	output string
+	err    error
+	// removed are the items to remove from the picker, e.g. after deleting them from the index
+	removed []Item
+	// noted is the item whose note has been replaced by note
+	noted *Item
+	note  string
+}
+
+// noteMsg carries the editor prepared to edit the note of an item
+type noteMsg struct {
+	item Item
+	cmd  *exec.Cmd
+	save func() (string, error)
+}
+
+// openMsg carries the command prepared to be started in the foreground of the terminal
+type openMsg struct {
+	name    string
+	cmd     *exec.Cmd
+	confirm func() bool
+	cleanup func()
+}
+
+// openCommand starts the command to open in the foreground of the terminal after it has been confirmed
+type openCommand struct {
+	cmd     *exec.Cmd
+	confirm func() bool
+}
+
+func (c *openCommand) Run() error {
+	if c.confirm != nil && !c.confirm() {
+		return fmt.Errorf("aborted")
+	}
+	return c.cmd.Run()
+}
+
+func (c *openCommand) SetStdin(r io.Reader) {
+	if c.cmd.Stdin == nil {
+		c.cmd.Stdin = r
+	}
+}
+
+func (c *openCommand) SetStdout(w io.Writer) {
+	if c.cmd.Stdout == nil {
+		c.cmd.Stdout = w
+	}
+}
+
+func (c *openCommand) SetStderr(w io.Writer) {
+	if c.cmd.Stderr == nil {
+		c.cmd.Stderr = w
+	}
+}
+
+type probeMsg struct {
+	key   string
+	probe probe
+}
+
+type accessMsg struct {
+	item   Item
+	denied bool
+}
+
+// probe is the reachability of the cluster of an item
+type probe struct {
+	pending bool
+	latency time.Duration
+	err     error
+}
+
+// clusterInfo is the live information about the cluster of an item
+type clusterInfo struct {
+	loading bool
+	info    string
+	err     error
+}
+
+// match is an item matching the query
+type match struct {
+	item Item
+	// position is the open interval of the matched characters of the item name
+	position [2]int
+}
+
+type model struct {
+	picker *Picker
+	keymap keymap
+
+	query  []rune
+	order  types.SortOrder
+	view   types.ResultsView
+	focus  focus
+	width  int
+	height int
+	frame  int
+
+	items          []Item
+	stores         []store
+	searchDone     bool
+	disabledStores map[string]bool
+	matches        []match
+	// rows are the shown lines of the results pane. The cursor and the offset refer to the rows.
+	rows []row
+	// collapsed are the keys of the collapsed groups of the tree view
+	collapsed map[string]bool
+	// marked are the keys of the items marked for the bulk actions
+	marked map[string]bool
+	// numbers are the quick select numbers of the recently or frequently used items by item key, starting at 1
+	numbers     map[string]int
+	cursor      int
+	offset      int
+	storeCursor int
+
+	// previews are the retrieved previews by item key
+	previews map[string]string
+	// clusterInfos are the retrieved cluster infos by item key.
+	// Cached for the lifetime of the picker to not query the cluster again when moving the cursor back.
+	clusterInfos map[string]clusterInfo
+	// probes are the probed clusters by item key
+	probes         map[string]probe
+	probesInFlight int
+	// accessChecked are the keys of the items whose access has been checked or is being checked
+	accessChecked        map[string]bool
+	accessChecksInFlight int
+	// inaccessible are the keys of the items whose cluster rejected the credentials during the access check
+	inaccessible map[string]bool
+	// prefetched are the keys of the items whose kubeconfigs have been prefetched
+	prefetched map[string]bool
+	// filteredAt is the time the results have changed last
+	filteredAt time.Time
+
+	// status is the output of the last custom command shown in the footer
+	status      string
+	statusError bool
+
+	selected *Item
+}
+
+func newModel(picker *Picker) *model {
+	return &model{
+		picker:         picker,
+		keymap:         newKeymap(picker.options.Keybindings, picker.options.Commands),
+		order:          picker.options.SortOrder,
+		view:           picker.options.ResultsView,
+		disabledStores: make(map[string]bool),
+		collapsed:      make(map[string]bool),
+		marked:         make(map[string]bool),
+		previews:       make(map[string]string),
+		clusterInfos:   make(map[string]clusterInfo),
+		probes:         make(map[string]probe),
+		accessChecked:  make(map[string]bool),
+		inaccessible:   make(map[string]bool),
+		prefetched:     make(map[string]bool),
+	}
+}
+
+func (m *model) Init() tea.Cmd {
+	return tea.Batch(tick(), func() tea.Msg { return tickMsg{} })
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case tickMsg:
+		m.frame++
+		items, stores, done := m.picker.snapshot()
+		m.stores = stores
+		m.searchDone = done
+		if len(items) != len(m.items) {
+			m.items = items
+			m.filter()
+		}
+		m.prefetch()
+		return m, tea.Batch(tick(), m.load(), m.probe(), m.checkAccess())
+	case previewMsg:
+		m.previews[msg.key] = msg.preview
+		return m, nil
+	case clusterInfoMsg:
+		m.clusterInfos[msg.key] = msg.clusterInfo
+		return m, nil
+	case probeMsg:
+		m.probes[msg.key] = msg.probe
+		m.probesInFlight--
+		return m, m.probe()
+	case accessMsg:
+		m.accessChecksInFlight--
+		if msg.denied && m.picker.options.HideInaccessible {
+			m.picker.Remove(msg.item)
+			m.items, _, _ = m.picker.snapshot()
+			m.filter()
+		} else if msg.denied {
+			m.inaccessible[itemKey(msg.item)] = true
+		}
+		return m, m.checkAccess()
+	case commandMsg:
+		m.status, m.statusError = msg.output, false
+		if msg.err != nil {
+			m.status, m.statusError = msg.err.Error(), true
+		}
+		if len(msg.removed) > 0 {
+			m.picker.Remove(msg.removed...)
+			for _, item := range msg.removed {
+				delete(m.marked, itemKey(item))
+			}
+			m.items, _, _ = m.picker.snapshot()
+			m.filter()
+		}
+		if msg.noted != nil {
+			m.picker.SetNote(*msg.noted, msg.note)
+			m.items, _, _ = m.picker.snapshot()
+			m.filter()
+		}
+		return m, m.load()
+	case noteMsg:
+		return m, tea.ExecProcess(msg.cmd, func(err error) tea.Msg {
+			if err != nil {
+				return commandMsg{err: fmt.Errorf("editor for the note of %s failed: %v", msg.item.Name, err)}
+			}
+			note, err := msg.save()
+			if err != nil {
+				return commandMsg{err: err}
+			}
+			return commandMsg{output: fmt.Sprintf("saved note of %s", msg.item.Name), noted: &msg.item, note: note}
+		})
+	case openMsg:
+		return m, tea.Exec(&openCommand{cmd: msg.cmd, confirm: msg.confirm}, func(err error) tea.Msg {
+			msg.cleanup()
+			if err != nil {
+				return commandMsg{err: fmt.Errorf("command for %s failed: %v", msg.name, err)}
+			}
+			return commandMsg{}
+		})
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := keyName(msg)
+	m.status = ""
+
+	if command, ok := m.keymap.commands[key]; ok && m.focus == focusResults {
+		return m, m.run(command)
+	}
+
+	if m.focus == focusStores {
+		action, _ := m.keymap.action(key, storesActions)
+		switch action {
+		case types.PickerActionAbort:
+			return m, tea.Quit
+		case types.PickerActionToggleFocus:
+			m.focus = focusResults
+		case types.PickerActionUp:
+			if m.storeCursor > 0 {
+				m.storeCursor--
+			}
+		case types.PickerActionDown:
+			if m.storeCursor < len(m.stores)-1 {
+				m.storeCursor++
+			}
+		case types.PickerActionToggleStore:
+			if m.storeCursor < len(m.stores) {
+				id := m.stores[m.storeCursor].id
+				m.disabledStores[id] = !m.disabledStores[id]
+				m.filter()
+			}
+		}
+		return m, m.load()
+	}
+
+	action, ok := m.keymap.action(key, resultsActions)
+	if !ok {
+		switch msg.Type {
+		case tea.KeyBackspace:
+			if len(m.query) > 0 {
+				m.query = m.query[:len(m.query)-1]
+				m.filter()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.query = append(m.query, msg.Runes...)
+			m.filter()
+		}
+		return m, m.load()
+	}
+
+	switch action {
+	case types.PickerActionSelect:
+		if m.cursor < len(m.rows) && m.rows[m.cursor].group != nil {
+			m.toggleGroup(m.cursor, !m.rows[m.cursor].group.collapsed)
+			return m, m.load()
+		}
+		if item := m.cursorItem(); item != nil {
+			selected := *item
+			m.selected = &selected
+		}
+		return m, tea.Quit
+	case types.PickerActionAbort:
+		return m, tea.Quit
+	case types.PickerActionToggleFocus:
+		m.focus = focusStores
+	case types.PickerActionUp:
+		m.moveCursor(-1)
+	case types.PickerActionDown:
+		m.moveCursor(1)
+	case types.PickerActionPageUp:
+		m.moveCursor(-m.resultsHeight())
+	case types.PickerActionPageDown:
+		m.moveCursor(m.resultsHeight())
+	case types.PickerActionClearQuery:
+		m.query = nil
+		m.filter()
+	case types.PickerActionQuickSelect:
+		if item := m.numberedItem(m.keymap.index(types.PickerActionQuickSelect, key) + 1); item != nil {
+			selected := *item
+			m.selected = &selected
+			return m, tea.Quit
+		}
+	case types.PickerActionToggleSortOrder:
+		if m.order == types.SortOrderFrecency {
+			m.order = types.SortOrderAlphabetical
+		} else {
+			m.order = types.SortOrderFrecency
+		}
+		m.filter()
+	case types.PickerActionToggleView:
+		if m.view == types.ResultsViewTree {
+			m.view = types.ResultsViewList
+		} else {
+			m.view = types.ResultsViewTree
+		}
+		m.buildRows(true)
+	case types.PickerActionCollapse:
+		m.collapse()
+	case types.PickerActionExpand:
+		if m.cursor < len(m.rows) && m.rows[m.cursor].group != nil {
+			m.toggleGroup(m.cursor, false)
+		}
+	case types.PickerActionToggleMark:
+		if item := m.cursorItem(); item != nil {
+			key := itemKey(*item)
+			m.marked[key] = !m.marked[key]
+			if !m.marked[key] {
+				delete(m.marked, key)
+			}
+			m.moveCursor(1)
+		}
+	case types.PickerActionCopyKubeconfig:
+		if copyKubeconfig := m.picker.options.CopyKubeconfig; copyKubeconfig != nil {
+			return m, m.run(Command{Key: key, RunAll: copyKubeconfig})
+		}
+	case types.PickerActionDeleteContext:
+		if deleteContexts := m.picker.options.DeleteContexts; deleteContexts != nil {
+			return m, m.runAndRemove(key, deleteContexts)
+		}
+	case types.PickerActionEditNote:
+		if editNote := m.picker.options.EditNote; editNote != nil {
+			return m, m.editNote(editNote)
+		}
+	case types.PickerActionOpenConsole:
+		if openConsole := m.picker.options.OpenConsole; openConsole != nil {
+			return m, m.run(Command{Key: key, Run: openConsole})
+		}
+	}
+	return m, m.load()
+}
+
+// run executes the custom command for the marked items or the item under the cursor asynchronously.
+// Commands to open are prepared asynchronously and then started in the foreground of the terminal.
+func (m *model) run(command Command) tea.Cmd {
+	cursorItem := m.cursorItem()
+	if cursorItem == nil && (command.Open != nil || len(m.marked) == 0) {
+		return nil
+	}
+
+	if items := m.markedItems(); len(items) > 0 && command.Open == nil {
+		m.status = fmt.Sprintf("running %q for %d contexts...", command.Key, len(items))
+		return func() tea.Msg {
+			output, err := runAll(command, items)
+			return commandMsg{output: strings.TrimSpace(output), err: err}
+		}
+	}
+
+	item := *cursorItem
+	if command.RunAll != nil {
+		m.status = fmt.Sprintf("running %q for %s...", command.Key, item.Name)
+		return func() tea.Msg {
+			output, err := command.RunAll([]Item{item})
+			return commandMsg{output: strings.TrimSpace(output), err: err}
+		}
+	}
+
+	if command.Open != nil {
+		m.status = fmt.Sprintf("opening %q for %s...", command.Key, item.Name)
+		return func() tea.Msg {
+			cmd, cleanup, err := command.Open(item)
+			if err != nil {
+				return commandMsg{err: err}
+			}
+
+			msg := openMsg{name: item.Name, cmd: cmd, cleanup: cleanup}
+			if command.Confirm != nil {
+				msg.confirm = func() bool {
+					return command.Confirm(item)
+				}
+			}
+			return msg
+		}
+	}
+
+	m.status = fmt.Sprintf("running %q for %s...", command.Key, item.Name)
+	return func() tea.Msg {
+		output, err := command.Run(item)
+		return commandMsg{output: strings.TrimSpace(output), err: err}
+	}
+}
+
+// editNote prepares the editor for the note of the item under the cursor asynchronously
+func (m *model) editNote(editNote func(item Item) (*exec.Cmd, func() (string, error), error)) tea.Cmd {
+	cursorItem := m.cursorItem()
+	if cursorItem == nil {
+		return nil
+	}
+
+	item := *cursorItem
+	return func() tea.Msg {
+		cmd, save, err := editNote(item)
+		if err != nil {
+			return commandMsg{err: err}
+		}
+		return noteMsg{item: item, cmd: cmd, save: save}
+	}
+}
+
+// runAndRemove executes the bulk action for the marked items or the item under the cursor asynchronously
+// and removes the items from the picker if the action succeeds
+func (m *model) runAndRemove(key string, action func(items []Item) (string, error)) tea.Cmd {
+	items := m.markedItems()
+	if len(items) == 0 {
+		cursorItem := m.cursorItem()
+		if cursorItem == nil {
+			return nil
+		}
+		items = []Item{*cursorItem}
+	}
+
+	m.status = fmt.Sprintf("running %q for %d contexts...", key, len(items))
+	return func() tea.Msg {
+		output, err := action(items)
+		if err != nil {
+			return commandMsg{err: err}
+		}
+		return commandMsg{output: strings.TrimSpace(output), removed: items}
+	}
+}
+
+// runAll executes the command for all items. Commands without RunAll are executed for each item,
+// and the output is summarized.
+func runAll(command Command, items []Item) (string, error) {
+	if command.RunAll != nil {
+		return command.RunAll(items)
+	}
+
+	var failed []string
+	var lastErr error
+	for _, item := range items {
+		if _, err := command.Run(item); err != nil {
+			failed = append(failed, item.Name)
+			lastErr = err
+		}
+	}
+	if len(failed) > 0 {
+		return "", fmt.Errorf("%q failed for %d of %d contexts (%s): %v", command.Key, len(failed), len(items), strings.Join(failed, ", "), lastErr)
+	}
+	return fmt.Sprintf("ran %q for %d contexts", command.Key, len(items)), nil
+}
+
+// markedItems returns the marked items in the order they were added to the picker
+func (m *model) markedItems() []Item {
+	if len(m.marked) == 0 {
+		return nil
+	}
+
+	var items []Item
+	for _, item := range m.items {
+		if m.marked[itemKey(item)] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (m *model) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// cursorItem returns the item under the cursor, or nil if the cursor is on a group of the tree view
+func (m *model) cursorItem() *Item {
+	if m.cursor >= len(m.rows) || m.rows[m.cursor].match == nil {
+		return nil
+	}
+	return &m.rows[m.cursor].match.item
+}
+
+// buildRows builds the rows of the results pane from the matches.
+// If keepCursor is set, the cursor stays on the same item or group, e.g. when toggling the view.
+func (m *model) buildRows(keepCursor bool) {
+	var key string
+	if keepCursor && m.cursor < len(m.rows) {
+		key = rowKey(m.rows[m.cursor])
+	}
+
+	if m.view == types.ResultsViewTree {
+		m.rows = treeRows(m.matches, m.collapsed)
+	} else {
+		m.rows = listRows(m.matches)
+	}
+
+	if len(key) > 0 {
+		for i, r := range m.rows {
+			if rowKey(r) == key {
+				m.cursor = i
+				break
+			}
+		}
+	}
+	m.moveCursor(0)
+	m.number()
+}
+
+// number numbers the shown items used before, i.e. the items with a frecency, in the order of the rows for the quick selection
+func (m *model) number() {
+	m.numbers = make(map[string]int)
+	keys := len(m.keymap.actions[types.PickerActionQuickSelect])
+	for _, r := range m.rows {
+		if len(m.numbers) == keys {
+			return
+		}
+		if r.match != nil && r.match.item.Frecency > 0 {
+			m.numbers[itemKey(r.match.item)] = len(m.numbers) + 1
+		}
+	}
+}
+
+// numberedItem returns the shown item with the quick select number, or nil if no item has the number
+func (m *model) numberedItem(number int) *Item {
+	for _, r := range m.rows {
+		if r.match != nil && m.numbers[itemKey(r.match.item)] == number {
+			return &r.match.item
+		}
+	}
+	return nil
+}
+
+// toggleGroup collapses or expands the group of the row
+func (m *model) toggleGroup(index int, collapsed bool) {
+	m.collapsed[m.rows[index].group.key] = collapsed
+	m.cursor = index
+	m.buildRows(true)
+}
+
+// collapse collapses the group under the cursor, or the group containing the item under the cursor
+func (m *model) collapse() {
+	if m.cursor >= len(m.rows) {
+		return
+	}
+
+	r := m.rows[m.cursor]
+	switch {
+	case r.group != nil && !r.group.collapsed:
+		m.toggleGroup(m.cursor, true)
+	case r.parent >= 0:
+		m.toggleGroup(r.parent, true)
+	}
+}
+
+func rowKey(r row) string {
+	if r.group != nil {
+		return "group:" + r.group.key
+	}
+	return itemKey(r.match.item)
+}
+
+// filter matches the items of enabled stores against the query
+func (m *model) filter() {
+	var (
+		visible []Item
+		names   []string
+		texts   []string
+	)
+	for _, item := range m.items {
+		if m.disabledStores[item.StoreID] {
+			continue
+		}
+		visible = append(visible, item)
+	}
+	m.sort(visible)
+	for _, item := range visible {
+		names = append(names, item.Name)
+		// the metadata and the note are matched after the name, so that only matches in the name are highlighted
+		texts = append(texts, strings.TrimSpace(item.Name+" "+item.Metadata+" "+item.Note))
+	}
+
+	m.matches = m.matches[:0]
+	q := parseQuery(string(m.query), m.picker.options.Matching, m.picker.options.CaseSensitivity)
+	var matched []matching.Matched
+	switch fuzzy, ok := q.fuzzy(); {
+	case len(q) == 0:
+		for _, item := range visible {
+			m.matches = append(m.matches, match{item: item})
+		}
+	case ok:
+		// a single fuzzy term is sorted by similarity
+		matched = matching.FindAll(fuzzy.text, texts, fuzzy.mode())
+	default:
+		matched = q.matchAll(names, texts)
+	}
+	for _, result := range matched {
+		m.matches = append(m.matches, match{item: visible[result.Idx], position: result.Pos})
+	}
+
+	m.buildRows(false)
+	m.filteredAt = time.Now()
+}
+
+// prefetch prefetches the kubeconfigs of the top results once the results did not change for the prefetch delay
+func (m *model) prefetch() {
+	if m.picker.options.Prefetch == nil || time.Since(m.filteredAt) < prefetchDelay {
+		return
+	}
+
+	for i := 0; i < len(m.matches) && i < m.picker.options.PrefetchCount; i++ {
+		item := m.matches[i].item
+		key := itemKey(item)
+		if m.prefetched[key] {
+			continue
+		}
+
+		m.prefetched[key] = true
+		m.picker.options.Prefetch(item)
+	}
+}
+
+// sort sorts the items by the sort order.
+// Items with the same frecency, e.g. contexts that are not in the history, are sorted alphabetically.
+func (m *model) sort(items []Item) {
+	switch m.order {
+	case types.SortOrderFrecency:
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Frecency != items[j].Frecency {
+				return items[i].Frecency > items[j].Frecency
+			}
+			return items[i].Name < items[j].Name
+		})
+	case types.SortOrderAlphabetical:
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+	}
+}
+
+// load retrieves the preview and the cluster info of the item under the cursor asynchronously
+func (m *model) load() tea.Cmd {
+	cursorItem := m.cursorItem()
+	if cursorItem == nil {
+		return nil
+	}
+
+	var (
+		item = *cursorItem
+		key  = itemKey(item)
+		cmds []tea.Cmd
+	)
+
+	if _, ok := m.previews[key]; !ok && m.picker.options.Preview != nil {
+		m.previews[key] = loadingPreview
+		cmds = append(cmds, func() tea.Msg {
+			return previewMsg{key: key, preview: m.picker.options.Preview(item)}
+		})
+	}
+
+	if _, ok := m.clusterInfos[key]; !ok && m.picker.options.ClusterInfo != nil {
+		m.clusterInfos[key] = clusterInfo{loading: true}
+		cmds = append(cmds, func() tea.Msg {
+			info, err := m.picker.options.ClusterInfo(item)
+			return clusterInfoMsg{key: key, clusterInfo: clusterInfo{info: info, err: err}}
+		})
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// probe probes the clusters of the visible results that have not been probed yet
+func (m *model) probe() tea.Cmd {
+	if m.picker.options.Probe == nil {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for i := m.offset; i < len(m.rows) && i < m.offset+m.resultsHeight() && m.probesInFlight < maxProbes; i++ {
+		if m.rows[i].match == nil {
+			continue
+		}
+		item := m.rows[i].match.item
+		key := itemKey(item)
+		if _, ok := m.probes[key]; ok {
+			continue
+		}
+
+		m.probes[key] = probe{pending: true}
+		m.probesInFlight++
+		cmds = append(cmds, func() tea.Msg {
+			latency, err := m.picker.options.Probe(item)
+			return probeMsg{key: key, probe: probe{latency: latency, err: err}}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// checkAccess checks the access to the clusters of the visible results that have not been checked yet
+func (m *model) checkAccess() tea.Cmd {
+	if m.picker.options.CheckAccess == nil {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for i := m.offset; i < len(m.rows) && i < m.offset+m.resultsHeight() && m.accessChecksInFlight < maxAccessChecks; i++ {
+		if m.rows[i].match == nil {
+			continue
+		}
+		item := m.rows[i].match.item
+		key := itemKey(item)
+		if m.accessChecked[key] || item.Inaccessible {
+			continue
+		}
+
+		m.accessChecked[key] = true
+		m.accessChecksInFlight++
+		cmds = append(cmds, func() tea.Msg {
+			return accessMsg{item: item, denied: m.picker.options.CheckAccess(item)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func itemKey(item Item) string {
+	return item.StoreID + "/" + item.Name
+}
+
+// resultsHeight is the number of visible results
+func (m *model) resultsHeight() int {
+	// header, footer and borders
+	return max(m.height-4, 1)
+}
+
+func (m *model) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	height := m.resultsHeight()
+	previewWidth := 0
+	if m.picker.options.Preview != nil || m.picker.options.ClusterInfo != nil {
+		previewWidth = (m.width - sidebarWidth) / 2
+	}
+	resultsWidth := m.width - sidebarWidth - previewWidth
+
+	panes := []string{
+		m.paneStyle(focusStores).Width(sidebarWidth - 2).Height(height).Render(m.viewStores(sidebarWidth-2, height)),
+		m.paneStyle(focusResults).Width(resultsWidth - 2).Height(height).Render(m.viewResults(resultsWidth-2, height)),
+	}
+	if previewWidth > 0 {
+		panes = append(panes, borderStyle.Width(previewWidth-2).Height(height).Render(m.viewPreview(previewWidth-2, height)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.viewHeader(),
+		lipgloss.JoinHorizontal(lipgloss.Top, panes...),
+		m.viewFooter(),
+	)
+}
+
+func (m *model) paneStyle(f focus) lipgloss.Style {
+	if m.focus == f {
+		return focusedStyle
+	}
+	return borderStyle
+}
+
+func (m *model) viewHeader() string {
+	status := fmt.Sprintf("%d/%d", len(m.matches), len(m.items))
+	if len(m.order) > 0 {
+		status = fmt.Sprintf("%s (%s)", status, m.order)
+	}
+	if m.view == types.ResultsViewTree {
+		status = fmt.Sprintf("%s (%s)", status, m.view)
+	}
+	if len(m.marked) > 0 {
+		status = fmt.Sprintf("%s (%d marked)", status, len(m.marked))
+	}
+	if m.picker.options.Offline {
+		status = fmt.Sprintf("%s (offline)", status)
+	}
+	if !m.searchDone {
+		status = fmt.Sprintf("%s %s searching", status, spinner[m.frame%len(spinner)])
+	}
+
+	prompt := titleStyle.Render("> ") + string(m.query) + cursorStyle.Render(" ")
+	gap := max(m.width-lipgloss.Width(prompt)-lipgloss.Width(status), 1)
+	return prompt + strings.Repeat(" ", gap) + footerStyle.Render(status)
+}
+
+func (m *model) viewFooter() string {
+	if len(m.status) > 0 {
+		// only show the first line of the command output
+		status := truncate(strings.SplitN(m.status, "\n", 2)[0], m.width)
+		if m.statusError {
+			return errorStyle.Render(status)
+		}
+		return footerStyle.Render(status)
+	}
+
+	if m.focus == focusStores {
+		return footerStyle.Render(truncate(m.keymap.help(storesHelp, false), m.width))
+	}
+	return footerStyle.Render(truncate(m.keymap.help(resultsHelp, true), m.width))
+}
+
+// viewStores shows the kubeconfig stores with the search progress of each store
+func (m *model) viewStores(width, height int) string {
+	lines := []string{titleStyle.Render("Stores")}
+	for i, s := range m.stores {
+		checkbox := "[x]"
+		if m.disabledStores[s.id] {
+			checkbox = "[ ]"
+		}
+
+		name := truncate(fmt.Sprintf("%s %s", checkbox, s.id), width)
+		switch {
+		case m.focus == focusStores && i == m.storeCursor:
+			name = cursorStyle.Render(name)
+		case m.disabledStores[s.id]:
+			name = disabledStyle.Render(name)
+		}
+
+		state := footerStyle.Render(spinner[m.frame%len(spinner)])
+		if s.done {
+			state = reachableStyle.Render("✓")
+		}
+		progress := fmt.Sprintf("    %s %s", state, footerStyle.Render(fmt.Sprintf("%d • %.1fs", s.contexts, s.elapsed.Seconds())))
+		if s.errors > 0 {
+			progress = fmt.Sprintf("%s %s", progress, errorStyle.Render(fmt.Sprintf("✗ %d", s.errors)))
+		}
+		if s.degraded {
+			progress = fmt.Sprintf("    %s", errorStyle.Render("degraded"))
+		}
+
+		lines = append(lines, name, progress)
+	}
+	return strings.Join(limit(lines, height), "\n")
+}
+
+func (m *model) viewResults(width, height int) string {
+	// keep the cursor visible
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+
+	var lines []string
+	for i := m.offset; i < len(m.rows) && i < m.offset+height; i++ {
+		r := m.rows[i]
+		indent := strings.Repeat("  ", r.depth)
+		if r.group != nil {
+			lines = append(lines, indent+viewGroup(*r.group, i == m.cursor, width-len(indent)))
+			continue
+		}
+		lines = append(lines, indent+m.viewResult(*r.match, m.view == types.ResultsViewTree, i == m.cursor, width-len(indent)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// viewGroup shows a group of the tree view with the number of matched items
+func viewGroup(g group, selected bool, width int) string {
+	marker := "▾ "
+	if g.collapsed {
+		marker = "▸ "
+	}
+	label := truncate(marker+g.label, width)
+	count := truncate(fmt.Sprintf(" (%d)", g.count), width-runewidth.StringWidth(label))
+	padding := strings.Repeat(" ", max(width-runewidth.StringWidth(label+count), 0))
+	if selected {
+		return cursorStyle.Render(label + count + padding)
+	}
+	return titleStyle.Render(label) + footerStyle.Render(count) + padding
+}
+
+// viewResult shows a matched item. In the tree view, only the name without the folders is shown.
+func (m *model) viewResult(r match, leaf bool, selected bool, width int) string {
+	var indicator, latency string
+	if m.picker.options.Probe != nil {
+		indicator, latency = m.viewProbe(r.item)
+		width -= lipgloss.Width(indicator) + latencyWidth
+	}
+	if len(m.marked) > 0 {
+		mark := "  "
+		if m.marked[itemKey(r.item)] {
+			mark = matchStyle.Render("✓ ")
+		}
+		indicator = mark + indicator
+		width -= 2
+	}
+	if len(m.numbers) > 0 {
+		digits := len(strconv.Itoa(len(m.numbers)))
+		number := strings.Repeat(" ", digits+1)
+		if n, ok := m.numbers[itemKey(r.item)]; ok {
+			number = footerStyle.Render(fmt.Sprintf("%*d ", digits, n))
+		}
+		indicator = number + indicator
+		width -= digits + 1
+	}
+	if len(r.item.Icon) > 0 {
+		icon := r.item.Icon + " "
+		indicator += icon
+		width -= runewidth.StringWidth(icon)
+	}
+
+	name, position := r.item.Name, r.position
+	if leaf {
+		name, position = leafLabel(r)
+	}
+	name = truncate(name, width)
+	var metadata string
+	if remaining := width - runewidth.StringWidth(name); len(r.item.Metadata) > 0 && remaining > 2 {
+		metadata = truncate("  "+r.item.Metadata, remaining)
+		width -= runewidth.StringWidth(metadata)
+	}
+	style := m.environmentStyle(r.item)
+	if r.item.Inaccessible || m.inaccessible[itemKey(r.item)] {
+		style = style.Faint(true)
+	}
+	if selected {
+		return indicator + style.Inherit(cursorStyle).Render(name+metadata+strings.Repeat(" ", max(width-runewidth.StringWidth(name), 0))) + latency
+	}
+	if r.item.Outdated || r.item.Stale {
+		metadata = slowStyle.Render(metadata)
+	} else {
+		metadata = footerStyle.Render(metadata)
+	}
+
+	// highlight the matched characters
+	runes := []rune(name)
+	start, end := min(position[0], len(runes)), min(position[1], len(runes))
+	if start < end {
+		name = style.Render(string(runes[:start])) + matchStyle.Render(string(runes[start:end])) + style.Render(string(runes[end:]))
+	} else {
+		name = style.Render(name)
+	}
+	return indicator + name + metadata + strings.Repeat(" ", max(width-lipgloss.Width(name), 0)) + latency
+}
+
+// environmentStyle returns the style of the item in the color of its environment
+func (m *model) environmentStyle(item Item) lipgloss.Style {
+	if env := m.picker.options.Theme.Match(item.Name, item.Tags); env != nil {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Color(env.Color)))
+	}
+	return lipgloss.NewStyle()
+}
+
+// viewProbe returns the colored reachability indicator and the right-aligned latency of the cluster of the item
+func (m *model) viewProbe(item Item) (string, string) {
+	p, ok := m.probes[itemKey(item)]
+	switch {
+	case !ok || p.pending:
+		return footerStyle.Render("○ "), strings.Repeat(" ", latencyWidth)
+	case p.err != nil:
+		return errorStyle.Render("● "), errorStyle.Render(fmt.Sprintf("%*s", latencyWidth, "down"))
+	}
+
+	style := reachableStyle
+	if p.latency > slowLatency {
+		style = slowStyle
+	}
+	return style.Render("● "), style.Render(fmt.Sprintf("%*s", latencyWidth, formatLatency(p.latency)))
+}
+
+// formatLatency formats the latency in milliseconds, or seconds if it exceeds a second
+func formatLatency(latency time.Duration) string {
+	if latency >= time.Second {
+		return fmt.Sprintf("%.1fs", latency.Seconds())
+	}
+	return fmt.Sprintf("%dms", latency.Milliseconds())
+}
+
+func (m *model) viewPreview(width, height int) string {
+	if m.cursor < len(m.rows) && m.rows[m.cursor].group != nil {
+		g := m.rows[m.cursor].group
+		return strings.Join(limit([]string{
+			titleStyle.Render(truncate(g.label, width)),
+			footerStyle.Render(truncate(fmt.Sprintf("%d contexts", g.count), width)),
+		}, height), "\n")
+	}
+
+	cursorItem := m.cursorItem()
+	if cursorItem == nil {
+		return ""
+	}
+	item := *cursorItem
+
+	lines := []string{titleStyle.Render(truncate(item.Name, width)), footerStyle.Render(truncate("store: "+item.StoreID, width))}
+	if env := m.picker.options.Theme.Match(item.Name, item.Tags); env != nil {
+		lines = append(lines, m.environmentStyle(item).Bold(true).Render(truncate("environment: "+env.Name, width)))
+	}
+	if len(item.Tags) > 0 {
+		var keys []string
+		for key := range item.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, tagStyle.Render(truncate(fmt.Sprintf("%s=%s", key, item.Tags[key]), width)))
+		}
+	}
+
+	for _, line := range strings.Split(item.Note, "\n") {
+		if len(line) > 0 {
+			lines = append(lines, noteStyle.Render(truncate(line, width)))
+		}
+	}
+
+	if info, ok := m.clusterInfos[itemKey(item)]; ok {
+		lines = append(lines, viewClusterInfo(info, width))
+	}
+	lines = append(lines, "")
+
+	for _, line := range strings.Split(m.previews[itemKey(item)], "\n") {
+		lines = append(lines, truncate(line, width))
+	}
+	return strings.Join(limit(lines, height), "\n")
+}
+
+func viewClusterInfo(info clusterInfo, width int) string {
+	switch {
+	case info.loading:
+		return footerStyle.Render(truncate("● querying cluster...", width))
+	case info.err != nil:
+		return errorStyle.Render(truncate(fmt.Sprintf("● unreachable: %v", info.err), width))
+	default:
+		return reachableStyle.Render(truncate("● reachable • "+info.info, width))
+	}
+}
+
+// truncate shortens the text to the given display width
+func truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return runewidth.Truncate(strings.ReplaceAll(text, "\t", "  "), width, "…")
+}
+
+func limit(lines []string, height int) []string {
+	if len(lines) > height {
+		return lines[:height]
+	}
+	return lines
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// newTestModel returns the model of a picker showing the items
+func newTestModel(options Options, items ...Item) *model {
+	picker := New([]string{"eks.default", "filesystem.default"}, options)
+	picker.Add(items...)
+
+	m := newModel(picker)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m.Update(tickMsg{})
+	return m
+}
+
+// press sends the key to the model and returns the message of the resulting command, if any
+func press(m *model, msg tea.KeyMsg) tea.Msg {
+	_, cmd := m.Update(msg)
+	if cmd == nil {
+		return nil
+	}
+	return cmd()
+}
+
+func typeQuery(m *model, query string) {
+	press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(query)})
+}
+
+func shownNames(m *model) []string {
+	var names []string
+	for _, r := range m.rows {
+		if r.match != nil {
+			names = append(names, r.match.item.Name)
+		}
+	}
+	return names
+}
+
+var _ = Describe("model", func() {
+	var items []Item
+
+	BeforeEach(func() {
+		items = []Item{
+			{Name: "prod", StoreID: "eks.default", Frecency: 1},
+			{Name: "dev", StoreID: "eks.default", Frecency: 3},
+			{Name: "kind", StoreID: "filesystem.default"},
+		}
+	})
+
+	It("should show the items in the order they were added", func() {
+		m := newTestModel(Options{}, items...)
+		Expect(shownNames(m)).To(Equal([]string{"prod", "dev", "kind"}))
+		Expect(m.View()).To(ContainSubstring("kind"))
+	})
+
+	It("should filter the items by the query and select the item under the cursor", func() {
+		m := newTestModel(Options{}, items...)
+
+		typeQuery(m, "d")
+		Expect(shownNames(m)).To(ConsistOf("dev", "prod", "kind"))
+
+		press(m, tea.KeyMsg{Type: tea.KeyCtrlU})
+		typeQuery(m, "'dev")
+		Expect(shownNames(m)).To(Equal([]string{"dev"}))
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		Expect(cmd).ToNot(BeNil())
+		Expect(m.selected).To(Equal(&items[1]))
+	})
+
+	It("should remove the last character of the query", func() {
+		m := newTestModel(Options{}, items...)
+		typeQuery(m, "'kindx")
+		Expect(shownNames(m)).To(BeEmpty())
+
+		press(m, tea.KeyMsg{Type: tea.KeyBackspace})
+		Expect(shownNames(m)).To(Equal([]string{"kind"}))
+	})
+
+	It("should move the cursor within the results", func() {
+		m := newTestModel(Options{}, items...)
+
+		press(m, tea.KeyMsg{Type: tea.KeyUp})
+		Expect(m.cursor).To(Equal(0))
+		press(m, tea.KeyMsg{Type: tea.KeyDown})
+		press(m, tea.KeyMsg{Type: tea.KeyDown})
+		press(m, tea.KeyMsg{Type: tea.KeyDown})
+		Expect(m.cursor).To(Equal(2))
+		Expect(m.cursorItem().Name).To(Equal("kind"))
+	})
+
+	It("should not select an item when aborted", func() {
+		m := newTestModel(Options{}, items...)
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		Expect(cmd).ToNot(BeNil())
+		Expect(m.selected).To(BeNil())
+	})
+
+	It("should toggle the sort order between frecency and alphabetical", func() {
+		m := newTestModel(Options{SortOrder: types.SortOrderFrecency}, items...)
+		Expect(shownNames(m)).To(Equal([]string{"dev", "prod", "kind"}))
+
+		press(m, tea.KeyMsg{Type: tea.KeyCtrlR})
+		Expect(shownNames(m)).To(Equal([]string{"dev", "kind", "prod"}))
+	})
+
+	It("should quick select the recently used items by number", func() {
+		m := newTestModel(Options{SortOrder: types.SortOrderFrecency}, items...)
+
+		press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2"), Alt: true})
+		Expect(m.selected).To(Equal(&items[0]))
+	})
+
+	It("should hide the items of disabled stores", func() {
+		m := newTestModel(Options{}, items...)
+
+		press(m, tea.KeyMsg{Type: tea.KeyTab})
+		Expect(m.focus).To(Equal(focusStores))
+		press(m, tea.KeyMsg{Type: tea.KeySpace})
+		Expect(shownNames(m)).To(Equal([]string{"kind"}))
+
+		press(m, tea.KeyMsg{Type: tea.KeyDown})
+		press(m, tea.KeyMsg{Type: tea.KeyEnter})
+		Expect(shownNames(m)).To(BeEmpty())
+		Expect(m.selected).To(BeNil())
+	})
+
+	It("should group the items in the tree view and collapse groups", func() {
+		m := newTestModel(Options{}, items...)
+
+		press(m, tea.KeyMsg{Type: tea.KeyCtrlT})
+		Expect(describeRows(m.rows)).To(Equal([]string{"eks.default/", "  prod", "  dev", "filesystem.default/", "  kind"}))
+
+		press(m, tea.KeyMsg{Type: tea.KeyDown})
+		press(m, tea.KeyMsg{Type: tea.KeyLeft})
+		Expect(describeRows(m.rows)).To(Equal([]string{"eks.default (collapsed)/", "filesystem.default/", "  kind"}))
+		Expect(m.cursor).To(Equal(0))
+
+		press(m, tea.KeyMsg{Type: tea.KeyRight})
+		Expect(shownNames(m)).To(Equal([]string{"prod", "dev", "kind"}))
+	})
+
+	It("should run custom commands for the item under the cursor and show their output", func() {
+		var ran []string
+		m := newTestModel(Options{Commands: []Command{{
+			Key: "ctrl+x",
+			Run: func(item Item) (string, error) {
+				ran = append(ran, item.Name)
+				return "done\n", nil
+			},
+		}}}, items...)
+
+		msg := press(m, tea.KeyMsg{Type: tea.KeyCtrlX})
+		Expect(ran).To(Equal([]string{"prod"}))
+
+		m.Update(msg)
+		Expect(m.status).To(Equal("done"))
+		Expect(m.statusError).To(BeFalse())
+	})
+
+	It("should run custom commands for the marked items and summarize failures", func() {
+		m := newTestModel(Options{Commands: []Command{{
+			Key: "ctrl+x",
+			Run: func(item Item) (string, error) {
+				if item.Name == "kind" {
+					return "", errors.New("unreachable")
+				}
+				return "", nil
+			},
+		}}}, items...)
+
+		press(m, tea.KeyMsg{Type: tea.KeyCtrlAt})
+		press(m, tea.KeyMsg{Type: tea.KeyDown})
+		press(m, tea.KeyMsg{Type: tea.KeyCtrlAt})
+		Expect(m.markedItems()).To(Equal([]Item{items[0], items[2]}))
+
+		m.Update(press(m, tea.KeyMsg{Type: tea.KeyCtrlX}))
+		Expect(m.statusError).To(BeTrue())
+		Expect(m.status).To(Equal(`"ctrl+x" failed for 1 of 2 contexts (kind): unreachable`))
+	})
+
+	It("should remove the deleted contexts", func() {
+		var deleted []Item
+		m := newTestModel(Options{DeleteContexts: func(items []Item) (string, error) {
+			deleted = items
+			return "deleted", nil
+		}}, items...)
+
+		m.Update(press(m, tea.KeyMsg{Type: tea.KeyCtrlD}))
+		Expect(deleted).To(Equal([]Item{items[0]}))
+		Expect(shownNames(m)).To(Equal([]string{"dev", "kind"}))
+		Expect(m.status).To(Equal("deleted"))
+	})
+
+	It("should confirm before opening a command", func() {
+		confirmed := false
+		m := newTestModel(Options{Commands: []Command{{
+			Key: "ctrl+x",
+			Open: func(item Item) (*exec.Cmd, func(), error) {
+				return exec.Command("true"), func() {}, nil
+			},
+			Confirm: func(item Item) bool {
+				confirmed = true
+				return false
+			},
+		}}}, items...)
+
+		msg, ok := press(m, tea.KeyMsg{Type: tea.KeyCtrlX}).(openMsg)
+		Expect(ok).To(BeTrue())
+		Expect(msg.name).To(Equal("prod"))
+
+		err := (&openCommand{cmd: msg.cmd, confirm: msg.confirm}).Run()
+		Expect(confirmed).To(BeTrue())
+		Expect(err).To(MatchError("aborted"))
+	})
+
+	It("should show the custom commands in the footer", func() {
+		m := newTestModel(Options{
+			Keybindings: map[types.PickerAction][]string{types.PickerActionSelect: {"ctrl+s"}},
+			Commands:    []Command{{Key: "ctrl+x", Description: "k9s"}},
+		}, items...)
+
+		Expect(m.viewFooter()).To(ContainSubstring("ctrl+s: switch"))
+
+		help := m.keymap.help(resultsHelp, true)
+		Expect(help).To(ContainSubstring("ctrl+x: k9s"))
+		Expect(strings.Contains(help, "enter: switch")).To(BeFalse())
+	})
+})
+
+var _ = Describe("keymap", func() {
+	It("should replace the default keys of configured actions", func() {
+		k := newKeymap(map[types.PickerAction][]string{types.PickerActionSelect: {"ctrl+s"}}, nil)
+
+		_, ok := k.action("enter", resultsActions)
+		Expect(ok).To(BeFalse())
+
+		action, ok := k.action("ctrl+s", resultsActions)
+		Expect(ok).To(BeTrue())
+		Expect(action).To(Equal(types.PickerActionSelect))
+
+		action, ok = k.action("ctrl+c", resultsActions)
+		Expect(ok).To(BeTrue())
+		Expect(action).To(Equal(types.PickerActionAbort))
+	})
+
+	It("should prefer toggling stores in the store sidebar", func() {
+		k := newKeymap(nil, nil)
+		action, ok := k.action("enter", storesActions)
+		Expect(ok).To(BeTrue())
+		Expect(action).To(Equal(types.PickerActionToggleStore))
+	})
+
+	It("should return the position of the key among the keys of the action", func() {
+		k := newKeymap(nil, nil)
+		Expect(k.index(types.PickerActionQuickSelect, "alt+3")).To(Equal(2))
+		Expect(k.index(types.PickerActionQuickSelect, "enter")).To(Equal(-1))
+	})
+
+	It("should name the keys like the configuration", func() {
+		Expect(keyName(tea.KeyMsg{Type: tea.KeySpace})).To(Equal("space"))
+		Expect(keyName(tea.KeyMsg{Type: tea.KeyCtrlAt})).To(Equal("ctrl+space"))
+		Expect(keyName(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1"), Alt: true})).To(Equal("alt+1"))
+	})
+})
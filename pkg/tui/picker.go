@@ -0,0 +1,269 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tui implements the interactive picker with a results pane, a preview pane,
+// a sidebar listing the kubeconfig stores and a footer showing the keybindings.
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/danielfoehrkn/kubeswitch/pkg/theme"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Item is a context that can be selected in the picker
+type Item struct {
+	// Name is the displayed context name (or alias)
+	Name string
+	// StoreID is the ID of the kubeconfig store containing the context
+	StoreID string
+	// Tags are the tags of the kubeconfig in the store
+	Tags map[string]string
+	// Icon is shown in front of the name, e.g. the icon of the store kind
+	Icon string
+	// Metadata is searched in addition to the name and shown next to it, e.g. the API server and the account of the cluster
+	Metadata string
+	// Note is the note attached to the context. It is searched in addition to the name and shown in the preview pane.
+	Note string
+	// Frecency ranks the item if the results are sorted by frecency. Higher scores are ranked first.
+	Frecency float64
+	// Inaccessible is true if the cluster rejected the credentials of the user. The item is shown dimmed.
+	Inaccessible bool
+	// Outdated is true if the Kubernetes version of the cluster is below the minimum supported version. The metadata is highlighted.
+	Outdated bool
+	// Stale is true if the item was read from the index in offline mode, as its credentials may be stale. The metadata is highlighted.
+	Stale bool
+}
+
+// store is a kubeconfig store shown in the sidebar
+type store struct {
+	id       string
+	contexts int
+	errors   int
+	done     bool
+	// degraded is true if the kubeconfig store is skipped after repeatedly failed searches
+	degraded bool
+	// elapsed is the duration of the search of the store
+	elapsed time.Duration
+}
+
+// Picker is an interactive picker for contexts.
+// Contexts can be added while the picker is shown, e.g. while the search over the kubeconfig stores is still running.
+type Picker struct {
+	mutex  sync.RWMutex
+	items  []Item
+	stores []*store
+	done   bool
+	// started is when the search has been started
+	started time.Time
+
+	options Options
+}
+
+// Options configures the optional panes and annotations of the picker.
+// All functions are called asynchronously.
+type Options struct {
+	// Preview returns the preview for an item
+	Preview func(item Item) string
+	// ClusterInfo returns live information about the cluster of an item
+	// or an error if the cluster is not reachable
+	ClusterInfo func(item Item) (string, error)
+	// Probe checks if the cluster of an item is reachable and returns the round-trip latency
+	Probe func(item Item) (time.Duration, error)
+	// CheckAccess checks whether the cluster of an item accepts the credentials of the user.
+	// Returns true if the credentials are rejected.
+	CheckAccess func(item Item) bool
+	// HideInaccessible removes the items whose cluster rejects the credentials instead of dimming them
+	HideInaccessible bool
+	// Theme colors the items by environment
+	Theme *theme.Theme
+	// Keybindings replace the default keys of the built-in actions
+	Keybindings map[types.PickerAction][]string
+	// Commands are custom commands bound to keys. They take precedence over the built-in actions.
+	Commands []Command
+	// CopyKubeconfig copies the kubeconfig of the items merged into a single kubeconfig to the clipboard and returns a status message
+	CopyKubeconfig func(items []Item) (string, error)
+	// DeleteContexts removes the items from the search index and returns a status message.
+	// The removed items are no longer shown in the picker.
+	DeleteContexts func(items []Item) (string, error)
+	// EditNote returns the command opening the note of an item in an editor.
+	// The returned save function is called once the editor exits and returns the saved note.
+	EditNote func(item Item) (cmd *exec.Cmd, save func() (string, error), err error)
+	// OpenConsole opens the page of the cluster of an item in the web console of the cloud provider and returns a status message
+	OpenConsole func(item Item) (string, error)
+	// SortOrder is the initial order of the results. Defaults to the order in which the items are added.
+	SortOrder types.SortOrder
+	// ResultsView is the initial view of the results. Defaults to the list view.
+	ResultsView types.ResultsView
+	// Matching is the algorithm matching the plain terms of the query. Defaults to fuzzy matching.
+	Matching types.MatchingAlgorithm
+	// CaseSensitivity configures if the query is matched case-sensitively. Defaults to smart case.
+	CaseSensitivity types.CaseSensitivity
+	// Prefetch starts to retrieve the kubeconfig of an item in the background. Must not block.
+	Prefetch func(item Item)
+	// PrefetchCount is the number of top results that are prefetched once the results did not change for a short time
+	PrefetchCount int
+	// Offline shows that the kubeconfig stores are not called, as the offline mode is enabled
+	Offline bool
+}
+
+// New creates a picker for the given kubeconfig store IDs
+func New(storeIDs []string, options Options) *Picker {
+	p := &Picker{options: options, started: time.Now()}
+	for _, id := range storeIDs {
+		p.stores = append(p.stores, &store{id: id})
+	}
+	sort.Slice(p.stores, func(i, j int) bool {
+		return p.stores[i].id < p.stores[j].id
+	})
+	return p
+}
+
+// Add adds contexts to the picker
+func (p *Picker) Add(items ...Item) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.items = append(p.items, items...)
+	for _, item := range items {
+		p.store(item.StoreID).contexts++
+	}
+}
+
+// Remove removes contexts from the picker
+func (p *Picker) Remove(items ...Item) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	removed := make(map[string]bool, len(items))
+	for _, item := range items {
+		removed[itemKey(item)] = true
+	}
+
+	kept := make([]Item, 0, len(p.items))
+	for _, item := range p.items {
+		if removed[itemKey(item)] {
+			p.store(item.StoreID).contexts--
+			continue
+		}
+		kept = append(kept, item)
+	}
+	p.items = kept
+}
+
+// SetNote replaces the note of a context
+func (p *Picker) SetNote(item Item, note string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i := range p.items {
+		if itemKey(p.items[i]) == itemKey(item) {
+			p.items[i].Note = note
+		}
+	}
+}
+
+// AddError counts an error of the kubeconfig store returned during the search
+func (p *Picker) AddError(storeID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.store(storeID).errors++
+}
+
+// StoreDegraded marks the kubeconfig store as skipped after repeatedly failed searches
+func (p *Picker) StoreDegraded(storeID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.store(storeID).degraded = true
+}
+
+// StoreDone marks the search of the kubeconfig store as completed
+func (p *Picker) StoreDone(storeID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s := p.store(storeID)
+	s.done = true
+	s.elapsed = time.Since(p.started)
+}
+
+// SearchDone marks the search over all kubeconfig stores as completed
+func (p *Picker) SearchDone() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.done = true
+}
+
+// store returns the store with the given ID. Adds unknown stores. Has to be called with the lock held.
+func (p *Picker) store(id string) *store {
+	for _, s := range p.stores {
+		if s.id == id {
+			return s
+		}
+	}
+	s := &store{id: id}
+	p.stores = append(p.stores, s)
+	return s
+}
+
+// snapshot returns a copy of the current state of the picker
+func (p *Picker) snapshot() ([]Item, []store, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	stores := make([]store, 0, len(p.stores))
+	for _, s := range p.stores {
+		snapshot := *s
+		if !snapshot.done {
+			snapshot.elapsed = time.Since(p.started)
+		}
+		stores = append(stores, snapshot)
+	}
+	return p.items[:len(p.items):len(p.items)], stores, p.done
+}
+
+// Run shows the picker on the terminal and returns the selected item.
+// Returns fuzzyfinder.ErrAbort if the selection was aborted, like the default picker.
+// The picker is rendered on stderr, as stdout is read by the shell integration.
+func (p *Picker) Run() (*Item, error) {
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		// lipgloss only detects the colors supported by the terminal if stdout is a terminal
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	}
+
+	program := tea.NewProgram(newModel(p), tea.WithAltScreen(), tea.WithOutput(os.Stderr), tea.WithInputTTY())
+	result, err := program.StartReturningModel()
+	if err != nil {
+		return nil, err
+	}
+
+	m := result.(*model)
+	if m.selected == nil {
+		return nil, fuzzyfinder.ErrAbort
+	}
+	return m.selected, nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ktr0731/go-fuzzyfinder/matching"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// termKind is how a term of the query is matched
+type termKind int
+
+const (
+	// termFuzzy matches if the text contains the characters of the term in order: foo
+	termFuzzy termKind = iota
+	// termExact matches if the text contains the term: 'foo
+	termExact
+	// termPrefix matches if the name starts with the term: ^foo
+	termPrefix
+	// termSuffix matches if the name ends with the term: foo$
+	termSuffix
+	// termEqual matches if the name is the term: ^foo$
+	termEqual
+	// termWord matches if the text contains the term as a whole word. Used for plain terms with the "exact" matching algorithm.
+	termWord
+)
+
+// queryTerm is a single term of the query
+type queryTerm struct {
+	text string
+	kind termKind
+	// inverse terms match if the text does not contain the term: !foo, !^foo, !foo$
+	inverse bool
+	// caseSensitive terms are matched case-sensitively
+	caseSensitive bool
+}
+
+// query is a search query in the extended search syntax of fzf (https://github.com/junegunn/fzf#search-syntax).
+// Terms separated by spaces all have to match, terms separated by " | " match if one of them matches.
+// Each element is a group of alternative terms.
+type query [][]queryTerm
+
+// parseQuery parses the query. Empty terms, e.g. a single "!", are ignored.
+// Plain terms are matched with the matching algorithm, which defaults to fuzzy matching.
+func parseQuery(input string, algorithm types.MatchingAlgorithm, caseSensitivity types.CaseSensitivity) query {
+	var (
+		q          query
+		alternates bool
+	)
+	for _, token := range strings.Fields(input) {
+		if token == "|" {
+			alternates = len(q) > 0
+			continue
+		}
+
+		t, ok := parseTerm(token, algorithm, caseSensitivity)
+		if !ok {
+			continue
+		}
+
+		if alternates {
+			q[len(q)-1] = append(q[len(q)-1], t)
+			alternates = false
+			continue
+		}
+		q = append(q, []queryTerm{t})
+	}
+	return q
+}
+
+func parseTerm(token string, algorithm types.MatchingAlgorithm, caseSensitivity types.CaseSensitivity) (queryTerm, bool) {
+	t := queryTerm{kind: termFuzzy}
+	switch algorithm {
+	case types.MatchingAlgorithmSubstring:
+		t.kind = termExact
+	case types.MatchingAlgorithmExact:
+		t.kind = termWord
+	}
+	if strings.HasPrefix(token, "!") {
+		// inverse terms are never fuzzy
+		t.inverse = true
+		if t.kind == termFuzzy {
+			t.kind = termExact
+		}
+		token = token[1:]
+	}
+
+	switch {
+	case strings.HasPrefix(token, "'"):
+		t.kind = termExact
+		token = token[1:]
+	case len(token) > 1 && strings.HasPrefix(token, "^") && strings.HasSuffix(token, "$"):
+		t.kind = termEqual
+		token = token[1 : len(token)-1]
+	case strings.HasPrefix(token, "^"):
+		t.kind = termPrefix
+		token = token[1:]
+	case strings.HasSuffix(token, "$"):
+		t.kind = termSuffix
+		token = token[:len(token)-1]
+	}
+
+	t.text = token
+	switch caseSensitivity {
+	case types.CaseSensitivitySensitive:
+		t.caseSensitive = true
+	case types.CaseSensitivityInsensitive:
+		t.text = strings.ToLower(t.text)
+	default:
+		// smart case like the fuzzy matching: case-sensitive only if the term contains an upper-case letter
+		t.caseSensitive = strings.IndexFunc(t.text, unicode.IsUpper) >= 0
+	}
+	return t, len(token) > 0
+}
+
+// mode returns the mode of the fuzzy matching of the term
+func (t queryTerm) mode() matching.Option {
+	if t.caseSensitive {
+		return matching.WithMode(matching.ModeCaseSensitive)
+	}
+	return matching.WithMode(matching.ModeCaseInsensitive)
+}
+
+// fuzzy returns the term of the query if it consists of a single fuzzy term
+func (q query) fuzzy() (queryTerm, bool) {
+	if len(q) != 1 || len(q[0]) != 1 || q[0][0].kind != termFuzzy || q[0][0].inverse {
+		return queryTerm{}, false
+	}
+	return q[0][0], true
+}
+
+// matchAll returns the items matching the query in their original order.
+// Anchored terms are matched against the names, the other terms against the texts (the names and their metadata).
+// The position of a match is the position of the first matching term that is not inverse.
+func (q query) matchAll(names, texts []string) []matching.Matched {
+	// the positions of each term by index of the item
+	positions := make([][]map[int][2]int, len(q))
+	for i, group := range q {
+		positions[i] = make([]map[int][2]int, len(group))
+		for j, t := range group {
+			if t.kind == termPrefix || t.kind == termSuffix || t.kind == termEqual {
+				positions[i][j] = t.matchAll(names)
+			} else {
+				positions[i][j] = t.matchAll(texts)
+			}
+		}
+	}
+
+	var matches []matching.Matched
+	for idx := range names {
+		var (
+			matched  = true
+			position *[2]int
+		)
+		for i, group := range q {
+			groupMatched := false
+			for j, t := range group {
+				pos, found := positions[i][j][idx]
+				if found == t.inverse {
+					continue
+				}
+				groupMatched = true
+				if !t.inverse && position == nil {
+					position = &pos
+				}
+				break
+			}
+			if !groupMatched {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			m := matching.Matched{Idx: idx}
+			if position != nil {
+				m.Pos = *position
+			}
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// matchAll returns the position of the term by index of the texts matching it
+func (t queryTerm) matchAll(texts []string) map[int][2]int {
+	positions := make(map[int][2]int)
+	if t.kind == termFuzzy {
+		for _, m := range matching.FindAll(t.text, texts, t.mode()) {
+			positions[m.Idx] = m.Pos
+		}
+		return positions
+	}
+
+	for idx, text := range texts {
+		if !t.caseSensitive {
+			text = strings.ToLower(text)
+		}
+
+		start := -1
+		switch t.kind {
+		case termExact:
+			start = strings.Index(text, t.text)
+		case termWord:
+			start = indexWord(text, t.text)
+		case termPrefix:
+			if strings.HasPrefix(text, t.text) {
+				start = 0
+			}
+		case termSuffix:
+			if strings.HasSuffix(text, t.text) {
+				start = len(text) - len(t.text)
+			}
+		case termEqual:
+			if text == t.text {
+				start = 0
+			}
+		}
+		if start < 0 {
+			continue
+		}
+
+		// positions are counted in runes
+		runeStart := len([]rune(text[:start]))
+		positions[idx] = [2]int{runeStart, runeStart + len([]rune(t.text))}
+	}
+	return positions
+}
+
+// indexWord returns the index of the first occurrence of the word in the text that is neither preceded
+// nor followed by a letter or digit, e.g. "prod" in "eu-prod-1" but not in "eu-preprod-1", or -1
+func indexWord(text, word string) int {
+	for offset := 0; offset <= len(text); {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return -1
+		}
+		start, end := offset+i, offset+i+len(word)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return start
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// matchedNames returns the names matching the query, using the names as texts
+func matchedNames(input string, algorithm types.MatchingAlgorithm, names ...string) []string {
+	var matched []string
+	for _, m := range parseQuery(input, algorithm, "").matchAll(names, names) {
+		matched = append(matched, names[m.Idx])
+	}
+	return matched
+}
+
+var _ = Describe("query", func() {
+	Context("parseQuery", func() {
+		It("should parse the extended search syntax", func() {
+			Expect(parseQuery("dev 'eu ^aws prod$ ^kind$ !test", "", "")).To(Equal(query{
+				{{text: "dev", kind: termFuzzy}},
+				{{text: "eu", kind: termExact}},
+				{{text: "aws", kind: termPrefix}},
+				{{text: "prod", kind: termSuffix}},
+				{{text: "kind", kind: termEqual}},
+				{{text: "test", kind: termExact, inverse: true}},
+			}))
+		})
+
+		It("should group alternative terms", func() {
+			Expect(parseQuery("dev | qa prod", "", "")).To(Equal(query{
+				{{text: "dev", kind: termFuzzy}, {text: "qa", kind: termFuzzy}},
+				{{text: "prod", kind: termFuzzy}},
+			}))
+		})
+
+		It("should ignore empty terms", func() {
+			Expect(parseQuery("! ' dev", "", "")).To(Equal(query{{{text: "dev", kind: termFuzzy}}}))
+		})
+
+		It("should match plain terms with the matching algorithm", func() {
+			Expect(parseQuery("dev", types.MatchingAlgorithmSubstring, "")[0][0].kind).To(Equal(termExact))
+			Expect(parseQuery("dev", types.MatchingAlgorithmExact, "")[0][0].kind).To(Equal(termWord))
+		})
+
+		It("should use smart case unless configured otherwise", func() {
+			Expect(parseQuery("Dev", "", "")[0][0].caseSensitive).To(BeTrue())
+			Expect(parseQuery("dev", "", "")[0][0].caseSensitive).To(BeFalse())
+			Expect(parseQuery("dev", "", types.CaseSensitivitySensitive)[0][0].caseSensitive).To(BeTrue())
+			Expect(parseQuery("Dev", "", types.CaseSensitivityInsensitive)[0][0]).To(Equal(queryTerm{text: "dev", kind: termFuzzy}))
+		})
+	})
+
+	Context("matchAll", func() {
+		names := []string{"aws/eu/dev", "aws/eu/prod", "gke/us/prod", "kind"}
+
+		It("should require all terms to match", func() {
+			Expect(matchedNames("aws prod", "", names...)).To(Equal([]string{"aws/eu/prod"}))
+		})
+
+		It("should match one of the alternative terms", func() {
+			Expect(matchedNames("^gke | dev", "", names...)).To(Equal([]string{"aws/eu/dev", "gke/us/prod"}))
+		})
+
+		It("should match anchored terms", func() {
+			Expect(matchedNames("^aws", "", names...)).To(Equal([]string{"aws/eu/dev", "aws/eu/prod"}))
+			Expect(matchedNames("prod$", "", names...)).To(Equal([]string{"aws/eu/prod", "gke/us/prod"}))
+			Expect(matchedNames("^kind$", "", names...)).To(Equal([]string{"kind"}))
+		})
+
+		It("should exclude inverse matches", func() {
+			Expect(matchedNames("!aws", "", names...)).To(Equal([]string{"gke/us/prod", "kind"}))
+		})
+
+		It("should match whole words with the exact matching algorithm", func() {
+			Expect(matchedNames("prod", types.MatchingAlgorithmExact, "eu-prod-1", "eu-preprod-1")).To(Equal([]string{"eu-prod-1"}))
+		})
+
+		It("should return the position of the first term in runes", func() {
+			matched := parseQuery("'prod", "", "").matchAll([]string{"ü/prod"}, []string{"ü/prod"})
+			Expect(matched).To(HaveLen(1))
+			Expect(matched[0].Pos).To(Equal([2]int{2, 6}))
+		})
+	})
+
+	Context("indexWord", func() {
+		It("should find the word only if it is not part of another word", func() {
+			Expect(indexWord("eu-prod-1", "prod")).To(Equal(3))
+			Expect(indexWord("preprod prod", "prod")).To(Equal(8))
+			Expect(indexWord("eu-preprod-1", "prod")).To(Equal(-1))
+		})
+	})
+})
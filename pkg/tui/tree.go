@@ -0,0 +1,144 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"strings"
+)
+
+// groupSeparator separates the folders of context names, e.g. "<account>/<region>/<cluster>"
+const groupSeparator = "/"
+
+// row is a line of the results pane: either a matched item or a group of items in the tree view
+type row struct {
+	match *match
+	group *group
+	// depth is the indentation level in the tree view
+	depth int
+	// parent is the index of the row of the enclosing group, or -1
+	parent int
+}
+
+// group is a kubeconfig store or a folder of context names in the tree view
+type group struct {
+	// key identifies the group across filtering, to keep it collapsed
+	key   string
+	label string
+	// count is the number of matched items in the group, including its subgroups
+	count     int
+	collapsed bool
+}
+
+// treeNode is a group while building the tree. Subgroups and items are kept in the order of their first match,
+// so that the tree keeps the order of the results.
+type treeNode struct {
+	group
+	entries  []treeEntry
+	children map[string]*treeNode
+}
+
+type treeEntry struct {
+	node  *treeNode
+	match *match
+}
+
+// listRows returns a row for each match
+func listRows(matches []match) []row {
+	rows := make([]row, 0, len(matches))
+	for i := range matches {
+		rows = append(rows, row{match: &matches[i], parent: -1})
+	}
+	return rows
+}
+
+// treeRows groups the matches by kubeconfig store and by the folders of their names.
+// Folders containing only a single folder are merged, e.g. "<account>/<region>", to keep the tree shallow.
+// The items of collapsed groups are omitted.
+func treeRows(matches []match, collapsed map[string]bool) []row {
+	root := newTreeNode("", "")
+	for i := range matches {
+		m := &matches[i]
+		node := root.child(m.item.StoreID, m.item.StoreID)
+		node.count++
+
+		folders := strings.Split(m.item.Name, groupSeparator)
+		for _, folder := range folders[:len(folders)-1] {
+			node = node.child(node.key+groupSeparator+folder, folder)
+			node.count++
+		}
+		node.entries = append(node.entries, treeEntry{match: m})
+	}
+
+	var rows []row
+	for _, entry := range root.entries {
+		rows = entry.node.flatten(rows, 0, -1, collapsed)
+	}
+	return rows
+}
+
+func newTreeNode(key, label string) *treeNode {
+	return &treeNode{group: group{key: key, label: label}, children: make(map[string]*treeNode)}
+}
+
+// child returns the subgroup with the given key, which is added if it does not exist yet
+func (n *treeNode) child(key, label string) *treeNode {
+	if child, ok := n.children[key]; ok {
+		return child
+	}
+	child := newTreeNode(key, label)
+	n.children[key] = child
+	n.entries = append(n.entries, treeEntry{node: child})
+	return child
+}
+
+// flatten appends the rows of the group and, unless it is collapsed, of its entries
+func (n *treeNode) flatten(rows []row, depth, parent int, collapsed map[string]bool) []row {
+	// merge folders that only contain a single folder. Kubeconfig stores are never merged.
+	for depth > 0 && len(n.entries) == 1 && n.entries[0].node != nil {
+		child := n.entries[0].node
+		child.label = n.label + groupSeparator + child.label
+		n = child
+	}
+
+	g := n.group
+	g.collapsed = collapsed[g.key]
+	rows = append(rows, row{group: &g, depth: depth, parent: parent})
+	if g.collapsed {
+		return rows
+	}
+
+	index := len(rows) - 1
+	for _, entry := range n.entries {
+		if entry.node != nil {
+			rows = entry.node.flatten(rows, depth+1, index, collapsed)
+			continue
+		}
+		rows = append(rows, row{match: entry.match, depth: depth + 1, parent: index})
+	}
+	return rows
+}
+
+// leafLabel returns the name of the item without its folders and the matched characters within it
+func leafLabel(m match) (string, [2]int) {
+	name := m.item.Name
+	i := strings.LastIndex(name, groupSeparator)
+	if i < 0 {
+		return name, m.position
+	}
+
+	offset := len([]rune(name[:i+1]))
+	position := [2]int{max(m.position[0]-offset, 0), max(m.position[1]-offset, 0)}
+	return name[i+1:], position
+}
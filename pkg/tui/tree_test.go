@@ -0,0 +1,110 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// describeRows returns the indented labels of the groups and the names of the items
+func describeRows(rows []row) []string {
+	var lines []string
+	for _, r := range rows {
+		indent := ""
+		for i := 0; i < r.depth; i++ {
+			indent += "  "
+		}
+		if r.group != nil {
+			label := r.group.label
+			if r.group.collapsed {
+				label += " (collapsed)"
+			}
+			lines = append(lines, indent+label+"/")
+			continue
+		}
+		lines = append(lines, indent+r.match.item.Name)
+	}
+	return lines
+}
+
+func matches(items ...Item) []match {
+	var result []match
+	for _, item := range items {
+		result = append(result, match{item: item})
+	}
+	return result
+}
+
+var _ = Describe("tree", func() {
+	items := matches(
+		Item{Name: "acme/eu-west-1/dev", StoreID: "eks.default"},
+		Item{Name: "acme/eu-west-1/prod", StoreID: "eks.default"},
+		Item{Name: "acme/us-east-1/qa", StoreID: "eks.default"},
+		Item{Name: "kind", StoreID: "filesystem.default"},
+	)
+
+	It("should group the items by store and folder", func() {
+		Expect(describeRows(treeRows(items, nil))).To(Equal([]string{
+			"eks.default/",
+			"  acme/",
+			"    eu-west-1/",
+			"      acme/eu-west-1/dev",
+			"      acme/eu-west-1/prod",
+			"    us-east-1/",
+			"      acme/us-east-1/qa",
+			"filesystem.default/",
+			"  kind",
+		}))
+	})
+
+	It("should merge folders containing a single folder", func() {
+		Expect(describeRows(treeRows(items[2:3], nil))).To(Equal([]string{
+			"eks.default/",
+			"  acme/us-east-1/",
+			"    acme/us-east-1/qa",
+		}))
+	})
+
+	It("should omit the items of collapsed groups", func() {
+		rows := treeRows(items, map[string]bool{"eks.default/acme/eu-west-1": true})
+		Expect(describeRows(rows)).To(Equal([]string{
+			"eks.default/",
+			"  acme/",
+			"    eu-west-1 (collapsed)/",
+			"    us-east-1/",
+			"      acme/us-east-1/qa",
+			"filesystem.default/",
+			"  kind",
+		}))
+	})
+
+	It("should reference the row of the enclosing group", func() {
+		rows := treeRows(items, nil)
+		Expect(rows[0].parent).To(Equal(-1))
+		Expect(rows[3].parent).To(Equal(2))
+		Expect(rows[8].parent).To(Equal(7))
+	})
+
+	It("should list the items without groups in the list view", func() {
+		Expect(describeRows(listRows(items))).To(HaveLen(4))
+	})
+
+	It("should return the name and the matched characters without the folders", func() {
+		label, position := leafLabel(match{item: Item{Name: "acme/eu/prod"}, position: [2]int{8, 12}})
+		Expect(label).To(Equal("prod"))
+		Expect(position).To(Equal([2]int{0, 4}))
+	})
+})
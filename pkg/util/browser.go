@@ -0,0 +1,44 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// OpenInBrowser opens the URL in the default browser.
+// Uses open on macOS, rundll32 on Windows and xdg-open on Linux.
+func OpenInBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	// do not wait for the browser, which might keep running
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %q in the browser with %q: %v", url, cmd.Args[0], err)
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands returns the commands that copy stdin to the system clipboard in the order of preference
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+
+	var commands [][]string
+	if len(os.Getenv("WAYLAND_DISPLAY")) > 0 {
+		commands = append(commands, []string{"wl-copy"})
+	}
+	return append(commands, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+}
+
+// CopyToClipboard copies the text to the system clipboard.
+// Uses pbcopy on macOS, clip on Windows and wl-copy, xclip or xsel on Linux.
+func CopyToClipboard(text string) error {
+	var names []string
+	for _, command := range clipboardCommands() {
+		names = append(names, command[0])
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+
+		// do not capture the output: xclip and xsel keep running in the background to serve the clipboard
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to copy to the clipboard with %q: %v", command[0], err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard command found. Please install one of %s", strings.Join(names, ", "))
+}
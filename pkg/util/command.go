@@ -0,0 +1,105 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// cmdMetaCharacters are the characters interpreted by cmd.exe, which have to be escaped with ^
+var cmdMetaCharacters = regexp.MustCompile("([()\\][%!^\"`<>&|;, *?])")
+
+// Command returns the command executing the executable with the given arguments.
+// The arguments of cmd.exe are passed verbatim, as cmd.exe does not parse its command line like other Windows programs
+// and the quoting of os/exec breaks commands containing quotes. Use QuoteCmdArgument to quote the arguments for cmd.exe.
+func Command(ctx context.Context, name string, arguments ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, arguments...)
+	if IsCmd(name) {
+		executable := cmd.Path
+		if strings.ContainsAny(executable, " \t") {
+			executable = `"` + executable + `"`
+		}
+		setCommandLine(cmd, strings.Join(append([]string{executable}, arguments...), " "))
+	}
+	return cmd
+}
+
+// CmdCommand returns the command executing the command line with cmd.exe.
+// The command line is executed as is, e.g. "echo %USERNAME% & exit /b 1".
+func CmdCommand(ctx context.Context, commandLine string) *exec.Cmd {
+	return Command(ctx, "cmd", CmdArguments(commandLine)...)
+}
+
+// CmdArguments returns the arguments of cmd.exe executing the command line as is
+func CmdArguments(commandLine string) []string {
+	// /s strips the outer quotes, so that the command line itself may contain quotes
+	return []string{"/d", "/s", "/c", `"` + commandLine + `"`}
+}
+
+// IsCmd returns true if the executable is the Windows command processor cmd.exe
+func IsCmd(name string) bool {
+	return strings.EqualFold(strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".exe"), "cmd")
+}
+
+// IsBatchFile returns true if the executable is a batch file run by cmd.exe
+func IsBatchFile(name string) bool {
+	extension := strings.ToLower(filepath.Ext(name))
+	return extension == ".bat" || extension == ".cmd"
+}
+
+// QuoteCmdPath escapes the path of an executable for a command line executed by cmd.exe
+func QuoteCmdPath(path string) string {
+	return cmdMetaCharacters.ReplaceAllString(path, "^$1")
+}
+
+// QuoteCmdArgument quotes the argument for a command line executed by cmd.exe.
+// The argument is quoted following the rules of the Windows C runtime and the characters interpreted by cmd.exe are escaped.
+// Arguments of batch files are escaped twice, as cmd.exe parses them again when executing the batch file.
+func QuoteCmdArgument(argument string, batchFile bool) string {
+	argument = cmdMetaCharacters.ReplaceAllString(quoteWindowsArgument(argument), "^$1")
+	if batchFile {
+		argument = cmdMetaCharacters.ReplaceAllString(argument, "^$1")
+	}
+	return argument
+}
+
+// quoteWindowsArgument quotes the argument so that it is parsed as a single argument by Windows programs.
+// Backslashes are only escaped if they precede a quote.
+func quoteWindowsArgument(argument string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	backslashes := 0
+	for _, r := range argument {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			quoted.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		default:
+			quoted.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		quoted.WriteRune(r)
+	}
+	// the closing quote must not be escaped
+	quoted.WriteString(strings.Repeat(`\`, 2*backslashes))
+	quoted.WriteByte('"')
+	return quoted.String()
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package database provides access to the embedded databases in the state directory, e.g. the search index.
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/encryption"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+)
+
+// openTimeout is the maximum time to wait for other kubeswitch processes to release the database
+const openTimeout = 5 * time.Second
+
+// lock serializes the access to the databases within the process,
+// as the file lock of a database is not reentrant (e.g. for the concurrent search of multiple stores)
+var lock sync.Mutex
+
+var (
+	// encryptionKey is the key the values of the databases are encrypted with. Encryption is disabled if nil.
+	encryptionKey []byte
+	// keyHashKey is the key used to hash the keys of the databases, derived from the encryption key
+	keyHashKey []byte
+)
+
+// ErrDecryption is returned if a value of an encrypted database cannot be decrypted, e.g. because the encryption key has changed
+var ErrDecryption = errors.New("failed to decrypt database value")
+
+// With opens the database with the given path for the duration of the function.
+// The database is not kept open, as other kubeswitch processes cannot write to the database while it is open.
+// Returns an error satisfying os.IsNotExist if the database should be opened read-only and does not exist.
+func With(path string, readOnly bool, fn func(db *bolt.DB) error) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if readOnly {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+
+	db, err := bolt.Open(path, permissions.FileMode, &bolt.Options{Timeout: openTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return fmt.Errorf("failed to open database %q: %w", path, err)
+	}
+	defer db.Close()
+
+	return fn(db)
+}
+
+// EnableEncryption encrypts the values of the databases with the given key.
+// Keys are replaced with their HMAC, so that neither context names nor paths are stored in plaintext.
+func EnableEncryption(key []byte) {
+	encryptionKey = key
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("kubeswitch-database-keys"))
+	keyHashKey = h.Sum(nil)
+}
+
+// Encrypted returns true if the values of the databases are encrypted
+func Encrypted() bool {
+	return encryptionKey != nil
+}
+
+// Key returns the database key for the given name
+func Key(name string) []byte {
+	if !Encrypted() {
+		return []byte(name)
+	}
+
+	h := hmac.New(sha256.New, keyHashKey)
+	h.Write([]byte(name))
+	return []byte(fmt.Sprintf("%x", h.Sum(nil)))
+}
+
+// EncryptValue encrypts the value if encryption is enabled
+func EncryptValue(value []byte) ([]byte, error) {
+	if !Encrypted() {
+		return value, nil
+	}
+
+	encrypted, err := encryption.Encrypt(encryptionKey, value)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(encrypted), nil
+}
+
+// DecryptValue decrypts the value if encryption is enabled.
+// Returns ErrDecryption if the value cannot be decrypted.
+func DecryptValue(value []byte) ([]byte, error) {
+	if !Encrypted() {
+		return value, nil
+	}
+
+	decrypted, err := encryption.Decrypt(encryptionKey, string(value))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryption, err)
+	}
+	return decrypted, nil
+}
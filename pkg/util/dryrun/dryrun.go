@@ -0,0 +1,35 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import "fmt"
+
+// enabled is set via the --dry-run flag of the state-changing commands
+var enabled bool
+
+// Enable configures whether state-changing commands only print the changes they would make
+func Enable(dryRun bool) {
+	enabled = dryRun
+}
+
+// Enabled returns true if the changes are only printed instead of made
+func Enabled() bool {
+	return enabled
+}
+
+// Printf prints a change that would have been made if not in dry-run mode
+func Printf(format string, a ...any) {
+	fmt.Printf("Would "+format+"\n", a...)
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CredentialsExpiryWarning is the duration before the expiry of the credentials of a context that causes a warning
+const CredentialsExpiryWarning = time.Hour
+
+// kubeconfigCredentials are the credentials of the users of a kubeconfig that expire
+type kubeconfigCredentials struct {
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			User string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			Token                 string `yaml:"token"`
+			AuthProvider          *struct {
+				Config map[string]string `yaml:"config"`
+			} `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// GetContextCredentialsExpiry takes kubeconfig bytes and returns when the credentials of the user of each context expire,
+// i.e. the client certificate, a JWT bearer token or the ID token of the OIDC auth provider, whichever expires first.
+// Contexts with credentials that do not expire or that cannot be parsed are omitted.
+// The context names carry the same prefix as returned by GetContextsNamesFromKubeconfig.
+func GetContextCredentialsExpiry(kubeconfigBytes []byte, contextPrefix string) (map[string]time.Time, error) {
+	var config kubeconfigCredentials
+	if err := yaml.Unmarshal(kubeconfigBytes, &config); err != nil {
+		return nil, fmt.Errorf("could not unmarshal kubeconfig: %v", err)
+	}
+
+	userExpiry := getUserCredentialsExpiry(config)
+
+	if len(contextPrefix) != 0 {
+		contextPrefix = fmt.Sprintf("%s/", contextPrefix)
+	}
+
+	contextToExpiry := make(map[string]time.Time, len(config.Contexts))
+	for _, context := range config.Contexts {
+		if expiry, ok := userExpiry[context.Context.User]; ok {
+			contextToExpiry[fmt.Sprintf("%s%s", contextPrefix, context.Name)] = expiry
+		}
+	}
+	return contextToExpiry, nil
+}
+
+// GetUserCredentialsExpiry takes kubeconfig bytes and returns when the credentials of each user expire.
+// Users with credentials that do not expire or that cannot be parsed are omitted.
+func GetUserCredentialsExpiry(kubeconfigBytes []byte) (map[string]time.Time, error) {
+	var config kubeconfigCredentials
+	if err := yaml.Unmarshal(kubeconfigBytes, &config); err != nil {
+		return nil, fmt.Errorf("could not unmarshal kubeconfig: %v", err)
+	}
+	return getUserCredentialsExpiry(config), nil
+}
+
+func getUserCredentialsExpiry(config kubeconfigCredentials) map[string]time.Time {
+	userExpiry := make(map[string]time.Time, len(config.Users))
+	for _, user := range config.Users {
+		expiries := []*time.Time{
+			certificateExpiry(user.User.ClientCertificateData, user.User.ClientCertificate),
+			TokenExpiry(user.User.Token),
+		}
+		if user.User.AuthProvider != nil {
+			expiries = append(expiries, TokenExpiry(user.User.AuthProvider.Config["id-token"]))
+		}
+		if expiry := earliest(expiries...); expiry != nil {
+			userExpiry[user.Name] = *expiry
+		}
+	}
+	return userExpiry
+}
+
+// GetCredentialsExpiry returns when the first credentials of the contexts in the kubeconfig expire.
+// Returns nil if no credentials expire.
+func GetCredentialsExpiry(kubeconfigBytes []byte) *time.Time {
+	contextToExpiry, err := GetContextCredentialsExpiry(kubeconfigBytes, "")
+	if err != nil {
+		return nil
+	}
+
+	var expiries []*time.Time
+	for _, expiry := range contextToExpiry {
+		expiry := expiry
+		expiries = append(expiries, &expiry)
+	}
+	return earliest(expiries...)
+}
+
+// DescribeCredentialsExpiry describes when credentials expire, e.g. "credentials expire in 25m"
+func DescribeCredentialsExpiry(expiry time.Time) string {
+	remaining := time.Until(expiry)
+	if remaining <= 0 {
+		return fmt.Sprintf("credentials expired %s ago", roundMinutes(-remaining))
+	}
+	return fmt.Sprintf("credentials expire in %s", roundMinutes(remaining))
+}
+
+// roundMinutes formats the duration in minutes, e.g. "1h5m" instead of "1h5m0s"
+func roundMinutes(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// certificateExpiry returns when the base64 encoded client certificate or the client certificate file expires
+func certificateExpiry(data, file string) *time.Time {
+	var certificate []byte
+	switch {
+	case len(data) > 0:
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil
+		}
+		certificate = decoded
+	case len(file) > 0:
+		content, err := os.ReadFile(ExpandEnv(file))
+		if err != nil {
+			return nil
+		}
+		certificate = content
+	default:
+		return nil
+	}
+
+	block, _ := pem.Decode(certificate)
+	if block == nil {
+		return nil
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return &parsed.NotAfter
+}
+
+// TokenExpiry returns when a JWT expires. Returns nil for opaque tokens and JWTs without expiry.
+func TokenExpiry(token string) *time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var claims struct {
+		Expiry int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Expiry == 0 {
+		return nil
+	}
+	expiry := time.Unix(claims.Expiry, 0)
+	return &expiry
+}
+
+// earliest returns the earliest of the given times, ignoring nil values
+func earliest(times ...*time.Time) *time.Time {
+	var first *time.Time
+	for _, t := range times {
+		if t != nil && (first == nil || t.Before(*first)) {
+			first = t
+		}
+	}
+	return first
+}
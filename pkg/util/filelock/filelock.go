@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filelock serializes concurrent writes of kubeswitch processes, e.g. of many terminals switching at the same time,
+// to the state files shared between them.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+)
+
+const (
+	// lockSuffix is appended to the path of the state file to get the path of its lock file
+	lockSuffix = ".lock"
+	// timeout is how long to wait for a lock held by another process
+	timeout = 10 * time.Second
+	// retryInterval is the interval in which the lock is tried to be acquired
+	retryInterval = 10 * time.Millisecond
+)
+
+// errLocked is returned by tryLock if the lock is held by another process
+var errLocked = errors.New("locked by another process")
+
+// Lock is an exclusive advisory lock of a state file.
+// The lock is held on a separate lock file next to the state file, so that the state file itself can be replaced atomically.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire acquires the exclusive lock of the state file with the given path.
+// Waits if the lock is held by another process and fails after a timeout.
+func Acquire(path string) (*Lock, error) {
+	lockPath := path + lockSuffix
+	if err := permissions.MkdirAll(filepath.Dir(lockPath)); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, permissions.FileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(file)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %q: %w", path, err)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Release releases the lock
+func (l *Lock) Release() error {
+	defer l.file.Close()
+	return unlock(l.file)
+}
+
+// WithLock runs the function while holding the exclusive lock of the state file with the given path
+func WithLock(path string, fn func() error) error {
+	lock, err := Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return fn()
+}
+
+// WriteFile atomically replaces the file with the given path, so that concurrent readers never see a partially written file.
+// The data is written to a temporary file in the same directory which is then renamed.
+// The data is flushed to disk before the rename, so that a crash leaves either the old or the new content, never a truncated file.
+// If the path is a symbolic link, the target of the link is replaced.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// no-op after a successful rename
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// persist the rename
+	return syncDir(filepath.Dir(path))
+}
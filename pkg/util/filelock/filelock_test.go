@@ -0,0 +1,147 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filelock_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+)
+
+var _ = Describe("filelock", func() {
+	var (
+		dir  string
+		path string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "filelock")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "state", "file")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	Context("Acquire", func() {
+		It("should create the lock file next to the file", func() {
+			lock, err := filelock.Acquire(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lock.Release()).To(Succeed())
+
+			Expect(path + ".lock").To(BeAnExistingFile())
+		})
+
+		It("should wait until the lock is released", func() {
+			lock, err := filelock.Acquire(path)
+			Expect(err).ToNot(HaveOccurred())
+
+			acquired := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				second, err := filelock.Acquire(path)
+				Expect(err).ToNot(HaveOccurred())
+				close(acquired)
+				Expect(second.Release()).To(Succeed())
+			}()
+
+			Consistently(acquired, 200*time.Millisecond).ShouldNot(BeClosed())
+			Expect(lock.Release()).To(Succeed())
+			Eventually(acquired, time.Second).Should(BeClosed())
+		})
+	})
+
+	Context("WithLock", func() {
+		It("should serialize concurrent updates of the file", func() {
+			Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+			Expect(os.WriteFile(path, []byte("0"), 0600)).To(Succeed())
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(filelock.WithLock(path, func() error {
+						data, err := os.ReadFile(path)
+						if err != nil {
+							return err
+						}
+						count, err := strconv.Atoi(string(data))
+						if err != nil {
+							return err
+						}
+						return os.WriteFile(path, []byte(strconv.Itoa(count+1)), 0600)
+					})).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(os.ReadFile(path)).To(Equal([]byte("20")))
+		})
+
+		It("should return the error of the function and release the lock", func() {
+			err := filelock.WithLock(path, func() error {
+				return os.ErrInvalid
+			})
+			Expect(err).To(MatchError(os.ErrInvalid))
+
+			Expect(filelock.WithLock(path, func() error { return nil })).To(Succeed())
+		})
+	})
+
+	Context("WriteFile", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		})
+
+		It("should replace the file with the given permissions without leaving temporary files", func() {
+			Expect(os.WriteFile(path, []byte("old"), 0644)).To(Succeed())
+
+			Expect(filelock.WriteFile(path, []byte("new"), 0600)).To(Succeed())
+
+			Expect(os.ReadFile(path)).To(Equal([]byte("new")))
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			entries, err := os.ReadDir(filepath.Dir(path))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+
+		It("should write the target of a symlink instead of replacing the symlink", func() {
+			target := filepath.Join(dir, "target")
+			Expect(os.WriteFile(target, []byte("old"), 0600)).To(Succeed())
+			Expect(os.Symlink(target, path)).To(Succeed())
+
+			Expect(filelock.WriteFile(path, []byte("new"), 0600)).To(Succeed())
+
+			Expect(os.ReadFile(target)).To(Equal([]byte("new")))
+			info, err := os.Lstat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode() & os.ModeSymlink).ToNot(BeZero())
+		})
+	})
+})
@@ -0,0 +1,278 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixtures records the requests of the kubeconfig stores to the APIs of cloud providers to a fixture file
+// and replays the recorded responses instead of sending the requests.
+// Fixtures can be attached to bug reports and used to reproduce the behavior of a store without access to the provider.
+// Credentials are removed from the recorded interactions, see Sanitize.
+package fixtures
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
+)
+
+// Interaction is a recorded request and its response.
+// The fixture file contains one interaction per line.
+type Interaction struct {
+	// Method is the method of the request
+	Method string `json:"method"`
+	// URL is the sanitized URL of the request
+	URL string `json:"url"`
+	// Status is the status code of the response
+	Status int `json:"status"`
+	// Header contains the sanitized headers of the response
+	Header http.Header `json:"header,omitempty"`
+	// Body is the sanitized body of the response
+	Body string `json:"body,omitempty"`
+	// BodyEncoding is "base64" if the body is not valid UTF-8
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+var (
+	lock     sync.Mutex
+	recorder *fileRecorder
+	replayer *fileReplayer
+)
+
+// Record records the interactions of the transports returned by Wrap to the given file.
+// The file is truncated and written as the responses are received, so that the interactions
+// are recorded also if the command fails.
+func Record(path string) error {
+	if err := permissions.MkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create the directory of the fixture file: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, permissions.FileMode)
+	if err != nil {
+		return fmt.Errorf("failed to create fixture file: %w", err)
+	}
+	if err := permissions.Restrict(path); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to restrict the access to the fixture file: %w", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	recorder = &fileRecorder{file: file}
+	replayer = nil
+	return nil
+}
+
+// Replay serves the interactions recorded in the given file to the transports returned by Wrap instead of sending the requests
+func Replay(path string) error {
+	interactions, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	r := &fileReplayer{path: path, interactions: map[string][]Interaction{}, next: map[string]int{}}
+	for _, interaction := range interactions {
+		key := interaction.Method + " " + interaction.URL
+		r.interactions[key] = append(r.interactions[key], interaction)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	replayer = r
+	recorder = nil
+	return nil
+}
+
+// Load reads the interactions of the given fixture file
+func Load(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(file)
+	// the bodies of the responses can be large
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("invalid interaction in line %d of fixture file %q: %w", line, path, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+	return interactions, nil
+}
+
+// Wrap returns a transport recording or replaying the requests of the base transport.
+// Returns the base transport if neither recording nor replaying is enabled.
+func Wrap(base http.RoundTripper) http.RoundTripper {
+	lock.Lock()
+	defer lock.Unlock()
+
+	switch {
+	case recorder != nil:
+		return &recordingTransport{base: base, recorder: recorder}
+	case replayer != nil:
+		return replayer
+	default:
+		return base
+	}
+}
+
+type fileRecorder struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+func (r *fileRecorder) write(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_, err = r.file.Write(append(line, '\n'))
+	return err
+}
+
+type recordingTransport struct {
+	base     http.RoundTripper
+	recorder *fileRecorder
+}
+
+// RoundTrip sends the request and records the sanitized response.
+// Failed requests without a response are not recorded.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	// the fixture contains the decompressed body
+	if strings.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		if decompressed, err := gunzip(body); err == nil {
+			body = decompressed
+			header.Del("Content-Encoding")
+			header.Del("Content-Length")
+		}
+	}
+
+	interaction := Interaction{
+		Method: req.Method,
+		URL:    SanitizeURL(req.URL),
+		Status: resp.StatusCode,
+		Header: SanitizeHeader(header),
+	}
+	body = SanitizeBody(body)
+	if utf8.Valid(body) {
+		interaction.Body = string(body)
+	} else {
+		interaction.Body = base64.StdEncoding.EncodeToString(body)
+		interaction.BodyEncoding = "base64"
+	}
+
+	if err := t.recorder.write(interaction); err != nil {
+		return nil, fmt.Errorf("failed to record request %s %s: %w", req.Method, interaction.URL, err)
+	}
+	return resp, nil
+}
+
+type fileReplayer struct {
+	lock         sync.Mutex
+	path         string
+	interactions map[string][]Interaction
+	next         map[string]int
+}
+
+// RoundTrip returns the recorded responses of requests with the same method and the same sanitized URL in the recorded order.
+// The last response is returned again once all responses have been replayed.
+func (r *fileReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	url := SanitizeURL(req.URL)
+	key := req.Method + " " + url
+
+	r.lock.Lock()
+	recorded := r.interactions[key]
+	if len(recorded) == 0 {
+		r.lock.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s %s in fixture file %q", req.Method, url, r.path)
+	}
+	i := min(r.next[key], len(recorded)-1)
+	r.next[key] = i + 1
+	interaction := recorded[i]
+	r.lock.Unlock()
+
+	body := []byte(interaction.Body)
+	if interaction.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(interaction.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body of the recorded response for %s %s: %w", req.Method, url, err)
+		}
+		body = decoded
+	}
+
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
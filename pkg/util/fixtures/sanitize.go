@@ -0,0 +1,206 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fixtures
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Redacted replaces the credentials in the recorded interactions
+const Redacted = "REDACTED"
+
+var (
+	// sensitiveNames are the parts of the names of query parameters, headers and fields that contain credentials.
+	// The names are compared in lower case and without "-" and "_".
+	sensitiveNames = []string{
+		"token",
+		"secret",
+		"password",
+		"passwd",
+		"credential",
+		"privatekey",
+		"apikey",
+		"accesskey",
+		"clientkey",
+		"cookie",
+		"signature",
+		"sessionid",
+		"authorization",
+	}
+	// sensitiveExactNames are the names that contain credentials only if they match exactly
+	sensitiveExactNames = []string{"key", "sig", "auth"}
+
+	// sensitiveXMLElement matches the content of XML elements with sensitive names, e.g. in the responses of AWS STS
+	sensitiveXMLElement = regexp.MustCompile(`(?i)<([\w:.-]*(?:token|secret|password|credential|accesskey|privatekey)[\w:.-]*)>[^<]*</`)
+)
+
+// IsSensitive returns true if the query parameter, header or field with the given name contains credentials
+func IsSensitive(name string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	for _, sensitive := range sensitiveExactNames {
+		if normalized == sensitive {
+			return true
+		}
+	}
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeURL returns the URL without user information and with the values of sensitive query parameters redacted
+func SanitizeURL(u *url.URL) string {
+	sanitized := *u
+	sanitized.User = nil
+
+	query := sanitized.Query()
+	for name := range query {
+		if IsSensitive(name) {
+			query[name] = []string{Redacted}
+		}
+	}
+	sanitized.RawQuery = query.Encode()
+	return sanitized.String()
+}
+
+// SanitizeHeader returns the headers without sensitive headers
+func SanitizeHeader(header http.Header) http.Header {
+	sanitized := http.Header{}
+	for name, values := range header {
+		if !IsSensitive(name) {
+			sanitized[name] = values
+		}
+	}
+	return sanitized
+}
+
+// SanitizeBody redacts the credentials in the body of a response:
+//   - the credentials of kubeconfigs, also if the kubeconfig is base64 encoded in a JSON field
+//   - the values of sensitive fields of JSON documents
+//   - the content of sensitive elements of XML documents
+func SanitizeBody(body []byte) []byte {
+	if sanitized, ok := sanitizeKubeconfig(string(body)); ok {
+		return []byte(sanitized)
+	}
+
+	var document any
+	if err := json.Unmarshal(body, &document); err == nil {
+		sanitized, err := json.Marshal(sanitizeJSON(document, false))
+		if err == nil {
+			return sanitized
+		}
+	}
+
+	return sensitiveXMLElement.ReplaceAll(body, []byte("<${1}>"+Redacted+"</"))
+}
+
+// sanitizeJSON redacts all strings below sensitive fields and the credentials of kubeconfigs
+func sanitizeJSON(value any, sensitive bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			v[key] = sanitizeJSON(field, sensitive || IsSensitive(key))
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = sanitizeJSON(item, sensitive)
+		}
+		return v
+	case string:
+		// the kubeconfig is kept for the replay, only its credentials are redacted
+		if sanitized, ok := sanitizeKubeconfig(v); ok {
+			return sanitized
+		}
+		if sensitive {
+			return Redacted
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// sanitizeKubeconfig redacts the credentials of the kubeconfig.
+// Returns false if the value is neither a kubeconfig nor a base64 encoded kubeconfig.
+func sanitizeKubeconfig(value string) (string, bool) {
+	if !bytes.Contains([]byte(value), []byte("clusters")) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil || !bytes.Contains(decoded, []byte("clusters")) {
+			return "", false
+		}
+		sanitized, ok := sanitizeKubeconfig(string(decoded))
+		if !ok {
+			return "", false
+		}
+		return base64.StdEncoding.EncodeToString([]byte(sanitized)), true
+	}
+
+	config, err := clientcmd.Load([]byte(value))
+	if err != nil || len(config.Clusters) == 0 {
+		return "", false
+	}
+	// e.g. the list of clusters of a provider
+	for _, cluster := range config.Clusters {
+		if len(cluster.Server) == 0 {
+			return "", false
+		}
+	}
+
+	for _, authInfo := range config.AuthInfos {
+		if len(authInfo.Token) > 0 {
+			authInfo.Token = Redacted
+		}
+		if len(authInfo.Password) > 0 {
+			authInfo.Password = Redacted
+		}
+		if len(authInfo.ClientKeyData) > 0 {
+			authInfo.ClientKeyData = []byte(Redacted)
+		}
+		if len(authInfo.ClientCertificateData) > 0 {
+			authInfo.ClientCertificateData = []byte(Redacted)
+		}
+		if authInfo.AuthProvider != nil {
+			for key := range authInfo.AuthProvider.Config {
+				if IsSensitive(key) {
+					authInfo.AuthProvider.Config[key] = Redacted
+				}
+			}
+		}
+		if authInfo.Exec != nil {
+			for i, env := range authInfo.Exec.Env {
+				if IsSensitive(env.Name) {
+					authInfo.Exec.Env[i].Value = Redacted
+				}
+			}
+		}
+	}
+
+	sanitized, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", false
+	}
+	return string(sanitized), true
+}
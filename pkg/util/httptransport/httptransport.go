@@ -0,0 +1,185 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httptransport provides the HTTP transport shared by the kubeconfig stores,
+// so that the discovery of clusters and the retrieval of kubeconfigs reuse connections
+// instead of establishing a new TLS connection for every request.
+package httptransport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultMaxIdleConns is the default maximum number of idle connections across all hosts
+	defaultMaxIdleConns = 100
+	// defaultMaxIdleConnsPerHost is the default maximum number of idle connections per host.
+	// Higher than the default of the standard library (2), as the stores send many concurrent requests to the same API.
+	defaultMaxIdleConnsPerHost = 10
+	// defaultIdleConnTimeout is the default time an idle connection is kept open
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
+var (
+	lock   sync.Mutex
+	config *types.HTTPTransport
+	shared *http.Transport
+)
+
+// Configure sets the configuration of the transports.
+// Must be called before the kubeconfig stores create their clients.
+func Configure(transportConfig *types.HTTPTransport) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	config = transportConfig
+	shared = nil
+}
+
+// Shared returns the transport shared by all kubeconfig stores
+func Shared() *http.Transport {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if shared == nil {
+		shared = newTransport(config)
+	}
+	return shared
+}
+
+// Apply applies the configured settings to the given transport.
+// Used for clients that configure their own transport (e.g. its TLS configuration) and hence cannot use the shared transport.
+func Apply(transport *http.Transport) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	apply(transport, config)
+}
+
+// ForStore returns the transport for the requests of the kubeconfig store to the API of its provider.
+// Returns the shared transport if the store does not configure its HTTP client.
+// Otherwise, returns a dedicated transport with the proxy and the TLS configuration of the store.
+func ForStore(store types.KubeconfigStore) (*http.Transport, error) {
+	if store.HTTPClient == nil {
+		return Shared(), nil
+	}
+
+	lock.Lock()
+	transport := newTransport(config)
+	lock.Unlock()
+
+	if err := ApplyClient(transport, *store.HTTPClient); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
+
+// ApplyClient applies the proxy, the certificate authorities and the client certificate of the HTTP client configuration of a store to the transport.
+// The certificate authorities are trusted in addition to the system certificate authorities.
+func ApplyClient(transport *http.Transport, client types.HTTPClient) error {
+	if client.Proxy != nil {
+		proxyURL, err := url.Parse(*client.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", *client.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if client.CAFile == nil && client.CertFile == nil {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+
+	if client.CAFile != nil {
+		caFile := util.ExpandEnv(*client.CAFile)
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the certificate authorities: %w", err)
+		}
+
+		pool := tlsConfig.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM encoded certificates found in %q", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if client.CertFile != nil && client.KeyFile != nil {
+		certificate, err := tls.LoadX509KeyPair(util.ExpandEnv(*client.CertFile), util.ExpandEnv(*client.KeyFile))
+		if err != nil {
+			return fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, certificate)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return nil
+}
+
+func newTransport(config *types.HTTPTransport) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	apply(transport, config)
+	return transport
+}
+
+func apply(transport *http.Transport, config *types.HTTPTransport) {
+	transport.MaxIdleConns = defaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	transport.ForceAttemptHTTP2 = true
+
+	if config == nil {
+		return
+	}
+
+	if config.MaxIdleConns != nil {
+		transport.MaxIdleConns = *config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost != nil {
+		transport.MaxIdleConnsPerHost = *config.MaxIdleConnsPerHost
+	}
+	if config.MaxConnsPerHost != nil {
+		transport.MaxConnsPerHost = *config.MaxConnsPerHost
+	}
+	if config.IdleConnTimeout != nil {
+		transport.IdleConnTimeout = *config.IdleConnTimeout
+	}
+	if config.DisableKeepAlives != nil {
+		transport.DisableKeepAlives = *config.DisableKeepAlives
+	}
+	if config.DisableHTTP2 != nil && *config.DisableHTTP2 {
+		// a non-nil, empty map disables HTTP/2 (see http.Transport.TLSNextProto)
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+}
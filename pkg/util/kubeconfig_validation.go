@@ -0,0 +1,150 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ValidateKubeconfig checks that the kubeconfig can be parsed, that the clusters and users referenced by its contexts exist,
+// that the certificate authorities and client certificates parse and that the binaries of exec credential plugins are on the PATH.
+// Returns the problems found, or nil if the kubeconfig is valid.
+func ValidateKubeconfig(kubeconfigBytes []byte) []string {
+	config, err := clientcmd.Load(kubeconfigBytes)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid kubeconfig: %v", err)}
+	}
+
+	var problems []string
+	if len(config.Contexts) == 0 {
+		problems = append(problems, "no contexts")
+	}
+	if len(config.CurrentContext) > 0 && config.Contexts[config.CurrentContext] == nil {
+		problems = append(problems, fmt.Sprintf("current-context %q not found", config.CurrentContext))
+	}
+
+	for _, name := range sortedKeys(config.Contexts) {
+		problems = append(problems, validateContext(config, name)...)
+	}
+	for _, name := range sortedKeys(config.Clusters) {
+		problems = append(problems, validateCluster(name, config.Clusters[name])...)
+	}
+	for _, name := range sortedKeys(config.AuthInfos) {
+		problems = append(problems, validateUser(name, config.AuthInfos[name])...)
+	}
+	return problems
+}
+
+func validateContext(config *clientcmdapi.Config, name string) []string {
+	context := config.Contexts[name]
+
+	var problems []string
+	if len(context.Cluster) == 0 {
+		problems = append(problems, fmt.Sprintf("context %q: no cluster", name))
+	} else if config.Clusters[context.Cluster] == nil {
+		problems = append(problems, fmt.Sprintf("context %q: cluster %q not found", name, context.Cluster))
+	}
+
+	// contexts without user use no credentials
+	if len(context.AuthInfo) > 0 && config.AuthInfos[context.AuthInfo] == nil {
+		problems = append(problems, fmt.Sprintf("context %q: user %q not found", name, context.AuthInfo))
+	}
+	return problems
+}
+
+func validateCluster(name string, cluster *clientcmdapi.Cluster) []string {
+	var problems []string
+	if len(cluster.Server) == 0 {
+		problems = append(problems, fmt.Sprintf("cluster %q: no server", name))
+	} else if server, err := url.Parse(cluster.Server); err != nil || len(server.Scheme) == 0 || len(server.Host) == 0 {
+		problems = append(problems, fmt.Sprintf("cluster %q: invalid server URL %q", name, cluster.Server))
+	}
+
+	if len(cluster.CertificateAuthorityData) > 0 && !x509.NewCertPool().AppendCertsFromPEM(cluster.CertificateAuthorityData) {
+		problems = append(problems, fmt.Sprintf("cluster %q: certificate-authority-data contains no valid PEM certificate", name))
+	}
+
+	if len(cluster.CertificateAuthority) > 0 {
+		data, err := os.ReadFile(cluster.CertificateAuthority)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("cluster %q: cannot read certificate-authority: %v", name, err))
+		} else if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			problems = append(problems, fmt.Sprintf("cluster %q: certificate-authority %q contains no valid PEM certificate", name, cluster.CertificateAuthority))
+		}
+	}
+	return problems
+}
+
+func validateUser(name string, user *clientcmdapi.AuthInfo) []string {
+	var problems []string
+
+	if user.Exec != nil {
+		if len(user.Exec.Command) == 0 {
+			problems = append(problems, fmt.Sprintf("user %q: exec credential plugin without command", name))
+		} else if _, err := exec.LookPath(user.Exec.Command); err != nil {
+			problems = append(problems, fmt.Sprintf("user %q: exec credential plugin %q not found on the PATH", name, user.Exec.Command))
+		}
+	}
+
+	certificate, key := user.ClientCertificateData, user.ClientKeyData
+	var err error
+	if len(user.ClientCertificate) > 0 {
+		if certificate, err = os.ReadFile(user.ClientCertificate); err != nil {
+			problems = append(problems, fmt.Sprintf("user %q: cannot read client-certificate: %v", name, err))
+		}
+	}
+	if len(user.ClientKey) > 0 {
+		if key, err = os.ReadFile(user.ClientKey); err != nil {
+			problems = append(problems, fmt.Sprintf("user %q: cannot read client-key: %v", name, err))
+		}
+	}
+
+	switch {
+	case len(certificate) > 0 && len(key) > 0:
+		if _, err := tls.X509KeyPair(certificate, key); err != nil {
+			problems = append(problems, fmt.Sprintf("user %q: invalid client certificate or key: %v", name, err))
+		}
+	case len(certificate) > 0 && len(user.ClientKey) == 0:
+		problems = append(problems, fmt.Sprintf("user %q: client certificate without client key", name))
+	case len(key) > 0 && len(user.ClientCertificate) == 0:
+		problems = append(problems, fmt.Sprintf("user %q: client key without client certificate", name))
+	}
+
+	if len(user.TokenFile) > 0 {
+		if _, err := os.Stat(user.TokenFile); err != nil {
+			problems = append(problems, fmt.Sprintf("user %q: cannot read tokenFile: %v", name, err))
+		}
+	}
+	return problems
+}
+
+// sortedKeys returns the keys of the map in a stable order for reproducible output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -45,6 +45,49 @@ func (k *Kubeconfig) ModifyKubeswitchContext(context string) error {
 	return nil
 }
 
+// ModifyKubeswitchStore adds the top-level fields "kubeswitch-store-kind" and "kubeswitch-store-id" to the kubeconfig file.
+// They identify the kubeconfig store the kubeconfig has been retrieved from (e.g. for post-switch hooks)
+func (k *Kubeconfig) ModifyKubeswitchStore(kind, id string) error {
+	for _, field := range [][2]string{{"kubeswitch-store-kind", kind}, {"kubeswitch-store-id", id}} {
+		key, value := field[0], field[1]
+		if node := valueOf(k.rootNode, key); node != nil {
+			node.Value = value
+			continue
+		}
+
+		keyNode := &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Value: key,
+			Tag:   "!!str"}
+		valueNode := &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Value: value,
+			Tag:   "!!str"}
+		k.rootNode.Content = append(k.rootNode.Content, keyNode, valueNode)
+	}
+	return nil
+}
+
+// ModifyKubeswitchRevertAt adds the top-level field "kubeswitch-revert-at" to the kubeconfig file.
+// It is the time (RFC 3339) at which the terminal is reverted to the previous kubeconfig (switch --for)
+func (k *Kubeconfig) ModifyKubeswitchRevertAt(revertAt string) error {
+	if node := valueOf(k.rootNode, "kubeswitch-revert-at"); node != nil {
+		node.Value = revertAt
+		return nil
+	}
+
+	keyNode := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Value: "kubeswitch-revert-at",
+		Tag:   "!!str"}
+	valueNode := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Value: revertAt,
+		Tag:   "!!str"}
+	k.rootNode.Content = append(k.rootNode.Content, keyNode, valueNode)
+	return nil
+}
+
 // ModifyGardenerLandscapeIdentity add a top-level field with the following identifiers to the kubeconfig file.
 // - "landscape-identity"
 // Only relevant for Gardener stores
@@ -81,6 +81,76 @@ func (k *Kubeconfig) GetKubeswitchContext() string {
 	return v.Value
 }
 
+// GetKubeswitchStoreKind returns the "kubeswitch-store-kind" value in given
+// kubeconfig object Node, or returns "" if not found.
+func (k *Kubeconfig) GetKubeswitchStoreKind() string {
+	v := valueOf(k.rootNode, "kubeswitch-store-kind")
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+// GetKubeswitchStoreID returns the "kubeswitch-store-id" value in given
+// kubeconfig object Node, or returns "" if not found.
+func (k *Kubeconfig) GetKubeswitchStoreID() string {
+	v := valueOf(k.rootNode, "kubeswitch-store-id")
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+// GetKubeswitchRevertAt returns the "kubeswitch-revert-at" value in given
+// kubeconfig object Node, or returns "" if not found.
+func (k *Kubeconfig) GetKubeswitchRevertAt() string {
+	v := valueOf(k.rootNode, "kubeswitch-revert-at")
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+// ServerOfContext returns the API server URL of the cluster referenced by the given context,
+// or returns "" if not found.
+func (k *Kubeconfig) ServerOfContext(contextName string) (string, error) {
+	ctx, err := k.contextNode(contextName)
+	if err != nil {
+		return "", err
+	}
+
+	ctxBody := valueOf(ctx, "context")
+	if ctxBody == nil {
+		return "", nil
+	}
+	clusterName := valueOf(ctxBody, "cluster")
+	if clusterName == nil {
+		return "", nil
+	}
+
+	clusters := valueOf(k.rootNode, "clusters")
+	if clusters == nil || clusters.Kind != yaml.SequenceNode {
+		return "", nil
+	}
+
+	for _, clusterNode := range clusters.Content {
+		nameNode := valueOf(clusterNode, "name")
+		if nameNode == nil || nameNode.Value != clusterName.Value {
+			continue
+		}
+
+		clusterBody := valueOf(clusterNode, "cluster")
+		if clusterBody == nil {
+			return "", nil
+		}
+		if server := valueOf(clusterBody, "server"); server != nil {
+			return server.Value, nil
+		}
+		return "", nil
+	}
+	return "", nil
+}
+
 // IsGardenerKubeconfig returns if this kubeconfig is a kubeconfig created by a kubeswitch Gardener Store
 // i.e needs to contain meta information added previously by the gardener store
 func (k *Kubeconfig) IsGardenerKubeconfig() bool {
@@ -139,3 +209,76 @@ func valueOf(mapNode *yaml.Node, key string) *yaml.Node {
 	}
 	return nil
 }
+
+// RemoveClusterField removes the field from the cluster of every cluster entry and returns the names of the modified clusters
+func (k *Kubeconfig) RemoveClusterField(key string) []string {
+	var modified []string
+	k.forEachCluster(func(name string, clusterBody *yaml.Node) {
+		for i := 0; i+1 < len(clusterBody.Content); i += 2 {
+			if clusterBody.Content[i].Value == key {
+				clusterBody.Content = append(clusterBody.Content[:i], clusterBody.Content[i+2:]...)
+				modified = append(modified, name)
+				return
+			}
+		}
+	})
+	return modified
+}
+
+// SetClusterField sets the field of the cluster of every cluster entry to the value
+func (k *Kubeconfig) SetClusterField(key, value string) {
+	k.forEachCluster(func(_ string, clusterBody *yaml.Node) {
+		setMappingValue(clusterBody, key, &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: "!!str"})
+	})
+}
+
+// SetClusterFieldOf sets the field of the cluster entry with the given name to the value
+func (k *Kubeconfig) SetClusterFieldOf(cluster, key, value string) {
+	k.forEachCluster(func(name string, clusterBody *yaml.Node) {
+		if name == cluster {
+			setMappingValue(clusterBody, key, &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: "!!str"})
+		}
+	})
+}
+
+// GetClusterFields returns the values of the field of all cluster entries having the field by cluster name
+func (k *Kubeconfig) GetClusterFields(key string) map[string]string {
+	values := map[string]string{}
+	k.forEachCluster(func(name string, clusterBody *yaml.Node) {
+		if value := valueOf(clusterBody, key); value != nil {
+			values[name] = value.Value
+		}
+	})
+	return values
+}
+
+// GetClusterServers returns the servers of all cluster entries by cluster name
+func (k *Kubeconfig) GetClusterServers() map[string]string {
+	return k.GetClusterFields("server")
+}
+
+// SetClusterServer sets the server of the cluster entry with the given name
+func (k *Kubeconfig) SetClusterServer(cluster, server string) {
+	k.SetClusterFieldOf(cluster, "server", server)
+}
+
+// forEachCluster calls fn with the name and body of every cluster entry
+func (k *Kubeconfig) forEachCluster(fn func(name string, clusterBody *yaml.Node)) {
+	clusters := valueOf(k.rootNode, "clusters")
+	if clusters == nil || clusters.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for _, clusterNode := range clusters.Content {
+		clusterBody := valueOf(clusterNode, "cluster")
+		if clusterBody == nil || clusterBody.Kind != yaml.MappingNode {
+			continue
+		}
+
+		var name string
+		if nameNode := valueOf(clusterNode, "name"); nameNode != nil {
+			name = nameNode.Value
+		}
+		fn(name, clusterBody)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfigutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	userKeyToken                 = "token"
+	userKeyClientCertificateData = "client-certificate-data"
+	userKeyClientKeyData         = "client-key-data"
+
+	// ExecCredentialAPIVersion is the API version of the exec credential plugins configured by kubeswitch
+	ExecCredentialAPIVersion = "client.authentication.k8s.io/v1"
+	// ExecCredentialCommand is the name of the switcher subcommand used as exec credential plugin
+	ExecCredentialCommand = "exec-credential"
+)
+
+// execCredential is the output of an exec credential plugin
+// see https://kubernetes.io/docs/reference/access-authn-authz/authentication/#input-and-output-formats
+type execCredential struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Status     execCredentialStatus `json:"status"`
+}
+
+type execCredentialStatus struct {
+	ExpirationTimestamp   *time.Time `json:"expirationTimestamp,omitempty"`
+	Token                 string     `json:"token,omitempty"`
+	ClientCertificateData string     `json:"clientCertificateData,omitempty"`
+	ClientKeyData         string     `json:"clientKeyData,omitempty"`
+}
+
+// UserCredentials are the static credentials of a kubeconfig user
+type UserCredentials struct {
+	// Token is a bearer token
+	Token string `json:"token,omitempty"`
+	// ClientCertificateData is the base64 encoded PEM client certificate
+	ClientCertificateData string `json:"clientCertificateData,omitempty"`
+	// ClientKeyData is the base64 encoded PEM client key
+	ClientKeyData string `json:"clientKeyData,omitempty"`
+}
+
+// ExecCredential returns the credentials as output of an exec credential plugin.
+// If the expiry is set, the client calls the plugin again once the credentials expired.
+func (c UserCredentials) ExecCredential(expiry *time.Time) ([]byte, error) {
+	status := execCredentialStatus{
+		Token: c.Token,
+	}
+
+	if expiry != nil {
+		expirationTimestamp := expiry.UTC().Truncate(time.Second)
+		status.ExpirationTimestamp = &expirationTimestamp
+	}
+
+	// the kubeconfig contains base64 encoded PEM data, the exec credential expects plain PEM data
+	if len(c.ClientCertificateData) > 0 {
+		certificate, err := base64.StdEncoding.DecodeString(c.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode client certificate: %v", err)
+		}
+		status.ClientCertificateData = string(certificate)
+	}
+
+	if len(c.ClientKeyData) > 0 {
+		clientKey, err := base64.StdEncoding.DecodeString(c.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode client key: %v", err)
+		}
+		status.ClientKeyData = string(clientKey)
+	}
+
+	return json.Marshal(execCredential{
+		APIVersion: ExecCredentialAPIVersion,
+		Kind:       "ExecCredential",
+		Status:     status,
+	})
+}
+
+// ExecConfig is the exec credential plugin configuration of a kubeconfig user
+type ExecConfig struct {
+	APIVersion         string   `yaml:"apiVersion"`
+	Command            string   `yaml:"command"`
+	Args               []string `yaml:"args"`
+	InteractiveMode    string   `yaml:"interactiveMode"`
+	ProvideClusterInfo bool     `yaml:"provideClusterInfo,omitempty"`
+}
+
+// ReplaceUserCredentials removes the static credentials (token, client certificate and key data) from every user in the kubeconfig
+// and configures the exec credential plugin returned by getExecConfig instead.
+// Users without static credentials are not modified.
+func (k *Kubeconfig) ReplaceUserCredentials(getExecConfig func(userName string, credentials UserCredentials) (*ExecConfig, error)) error {
+	users := valueOf(k.rootNode, "users")
+	if users == nil {
+		return nil
+	} else if users.Kind != yaml.SequenceNode {
+		return fmt.Errorf("users is not a sequence node")
+	}
+
+	for _, userNode := range users.Content {
+		userBody := valueOf(userNode, "user")
+		if userBody == nil || userBody.Kind != yaml.MappingNode {
+			continue
+		}
+
+		credentials := userCredentials(userBody)
+		var keep []*yaml.Node
+		for i := 0; i+1 < len(userBody.Content); i += 2 {
+			key, value := userBody.Content[i], userBody.Content[i+1]
+			switch key.Value {
+			case userKeyToken, userKeyClientCertificateData, userKeyClientKeyData:
+			case "exec":
+				// replaced by the new exec credential plugin
+			default:
+				keep = append(keep, key, value)
+			}
+		}
+
+		if credentials == (UserCredentials{}) {
+			continue
+		}
+
+		var userName string
+		if nameNode := valueOf(userNode, "name"); nameNode != nil {
+			userName = nameNode.Value
+		}
+
+		execConfig, err := getExecConfig(userName, credentials)
+		if err != nil {
+			return fmt.Errorf("failed to replace credentials of user %q: %w", userName, err)
+		}
+
+		if err := setExecConfig(userBody, keep, execConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceUserExecConfigs replaces all credentials of every user in the kubeconfig with the exec credential plugin returned by getExecConfig.
+// The current exec credential plugin of the user is passed, or nil if the user does not use one.
+// Users are not modified if getExecConfig returns nil.
+func (k *Kubeconfig) ReplaceUserExecConfigs(getExecConfig func(userName string, current *ExecConfig) (*ExecConfig, error)) error {
+	users := valueOf(k.rootNode, "users")
+	if users == nil {
+		return nil
+	} else if users.Kind != yaml.SequenceNode {
+		return fmt.Errorf("users is not a sequence node")
+	}
+
+	for _, userNode := range users.Content {
+		userBody := valueOf(userNode, "user")
+		if userBody == nil || userBody.Kind != yaml.MappingNode {
+			continue
+		}
+
+		var userName string
+		if nameNode := valueOf(userNode, "name"); nameNode != nil {
+			userName = nameNode.Value
+		}
+
+		var current *ExecConfig
+		if execNode := valueOf(userBody, "exec"); execNode != nil {
+			current = &ExecConfig{}
+			if err := execNode.Decode(current); err != nil {
+				return fmt.Errorf("failed to decode exec credential plugin of user %q: %w", userName, err)
+			}
+		}
+
+		execConfig, err := getExecConfig(userName, current)
+		if err != nil {
+			return fmt.Errorf("failed to replace credentials of user %q: %w", userName, err)
+		}
+		if execConfig == nil {
+			continue
+		}
+
+		var keep []*yaml.Node
+		for i := 0; i+1 < len(userBody.Content); i += 2 {
+			key, value := userBody.Content[i], userBody.Content[i+1]
+			switch key.Value {
+			case userKeyToken, "tokenFile", userKeyClientCertificateData, "client-certificate", userKeyClientKeyData, "client-key",
+				"username", "password", "auth-provider", "exec":
+				// replaced by the new exec credential plugin
+			default:
+				keep = append(keep, key, value)
+			}
+		}
+
+		if err := setExecConfig(userBody, keep, execConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setExecConfig sets the content of the user body to the kept nodes and the exec credential plugin
+func setExecConfig(userBody *yaml.Node, keep []*yaml.Node, execConfig *ExecConfig) error {
+	execNode := &yaml.Node{}
+	if err := execNode.Encode(execConfig); err != nil {
+		return err
+	}
+
+	keyNode := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Value: "exec",
+		Tag:   "!!str"}
+	userBody.Content = append(keep, keyNode, execNode)
+	return nil
+}
+
+// GetUserCredentials returns the static credentials (token, client certificate and key data) of the user in the kubeconfig
+func (k *Kubeconfig) GetUserCredentials(userName string) (*UserCredentials, error) {
+	users := valueOf(k.rootNode, "users")
+	if users == nil {
+		return nil, fmt.Errorf("user %q not found", userName)
+	} else if users.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("users is not a sequence node")
+	}
+
+	for _, userNode := range users.Content {
+		nameNode := valueOf(userNode, "name")
+		if nameNode == nil || nameNode.Value != userName {
+			continue
+		}
+		userBody := valueOf(userNode, "user")
+		if userBody == nil || userBody.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("user %q does not contain credentials", userName)
+		}
+		credentials := userCredentials(userBody)
+		return &credentials, nil
+	}
+	return nil, fmt.Errorf("user %q not found", userName)
+}
+
+// userCredentials returns the static credentials of the user body
+func userCredentials(userBody *yaml.Node) UserCredentials {
+	var credentials UserCredentials
+	for i := 0; i+1 < len(userBody.Content); i += 2 {
+		key, value := userBody.Content[i], userBody.Content[i+1]
+		switch key.Value {
+		case userKeyToken:
+			credentials.Token = value.Value
+		case userKeyClientCertificateData:
+			credentials.ClientCertificateData = value.Value
+		case userKeyClientKeyData:
+			credentials.ClientKeyData = value.Value
+		}
+	}
+	return credentials
+}
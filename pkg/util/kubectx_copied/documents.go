@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfigutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// namedListKeys are the lists of a kubeconfig whose entries are identified by their name
+var namedListKeys = map[string]bool{
+	"clusters": true,
+	"contexts": true,
+	"users":    true,
+}
+
+// MergeDocuments merges a kubeconfig consisting of multiple YAML documents into a single kubeconfig.
+// Kubeconfigs with a single document are returned unchanged.
+// Like kubectl merging the files of the KUBECONFIG environment variable, the first document setting a value wins:
+// clusters, contexts and users of later documents are only added if no entry with the same name exists.
+func MergeDocuments(kubeconfigData []byte) ([]byte, error) {
+	var documents []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(kubeconfigData))
+	for {
+		document := &yaml.Node{}
+		err := decoder.Decode(document)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode kubeconfig: %w", err)
+		}
+
+		// e.g. a leading "---" or a document containing only comments
+		if len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+			continue
+		}
+		if document.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("kubeconfig document is not a mapping")
+		}
+		documents = append(documents, document)
+	}
+
+	if len(documents) < 2 {
+		return kubeconfigData, nil
+	}
+
+	merged := documents[0].Content[0]
+	for _, document := range documents[1:] {
+		mergeDocument(merged, document.Content[0])
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(merged); err != nil {
+		return nil, fmt.Errorf("failed to encode merged kubeconfig: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// mergeDocument adds the fields of the document that are not set in the merged document yet
+func mergeDocument(merged, document *yaml.Node) {
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		key, value := document.Content[i], document.Content[i+1]
+
+		existing := valueOf(merged, key.Value)
+		switch {
+		case existing == nil || existing.Tag == "!!null":
+			setMappingValue(merged, key.Value, value)
+		case namedListKeys[key.Value] && existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			for _, entry := range value.Content {
+				if !containsName(existing, entry) {
+					existing.Content = append(existing.Content, entry)
+				}
+			}
+		case key.Value == "current-context" && len(existing.Value) == 0:
+			existing.Value = value.Value
+		}
+	}
+}
+
+// containsName returns true if the sequence contains an entry with the same name as the given entry
+func containsName(sequence, entry *yaml.Node) bool {
+	name := valueOf(entry, "name")
+	if name == nil {
+		return false
+	}
+	for _, existing := range sequence.Content {
+		if existingName := valueOf(existing, "name"); existingName != nil && existingName.Value == name.Value {
+			return true
+		}
+	}
+	return false
+}
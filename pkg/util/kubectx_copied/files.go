@@ -0,0 +1,115 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfigutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// KubeconfigFiles are the kubeconfig files of the KUBECONFIG environment variable in the order of precedence.
+// Like kubectl, the current context is taken from the first file setting it
+// and a context is modified in the first file defining it.
+type KubeconfigFiles []*Kubeconfig
+
+// CurrentKubeconfigPaths returns the paths of the kubeconfig files given by the KUBECONFIG environment variable
+// in the order of precedence or the default path. Empty entries are ignored like by kubectl.
+func CurrentKubeconfigPaths() ([]string, error) {
+	var paths []string
+	for _, path := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		return paths, nil
+	}
+
+	// default path
+	home := os.Getenv("HOME")
+	if home == "" {
+		return nil, fmt.Errorf("HOME environment variable not set")
+	}
+	return []string{filepath.Join(home, ".kube", "config")}, nil
+}
+
+// LoadCurrentKubeconfigFiles loads the kubeconfig files given by the KUBECONFIG environment variable or the default kubeconfig
+func LoadCurrentKubeconfigFiles() (KubeconfigFiles, error) {
+	paths, err := CurrentKubeconfigPaths()
+	if err != nil {
+		return nil, err
+	}
+	return LoadKubeconfigFiles(paths)
+}
+
+// LoadKubeconfigFiles loads the kubeconfig files with the given paths.
+// Like kubectl, files that do not exist are skipped if there are multiple paths.
+func LoadKubeconfigFiles(paths []string) (KubeconfigFiles, error) {
+	var files KubeconfigFiles
+	for _, path := range paths {
+		if len(paths) > 1 {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+		}
+
+		kubeconfig, err := NewKubeconfigForPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig %q: %v", path, err)
+		}
+		files = append(files, kubeconfig)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("none of the kubeconfig files %q exists", paths)
+	}
+	return files, nil
+}
+
+// CurrentContext returns the current context, which is set by the first file setting a current context
+func (f KubeconfigFiles) CurrentContext() string {
+	return f.CurrentContextFile().GetCurrentContext()
+}
+
+// CurrentContextFile returns the file setting the current context, which is modified when changing the current context.
+// Returns the first file if no file sets a current context.
+func (f KubeconfigFiles) CurrentContextFile() *Kubeconfig {
+	for _, kubeconfig := range f {
+		if len(kubeconfig.GetCurrentContext()) > 0 {
+			return kubeconfig
+		}
+	}
+	return f[0]
+}
+
+// ContextFile returns the first file defining the context, which is modified when changing the context, e.g. its namespace
+func (f KubeconfigFiles) ContextFile(contextName string) (*Kubeconfig, error) {
+	for _, kubeconfig := range f {
+		if _, err := kubeconfig.contextNode(contextName); err == nil {
+			return kubeconfig, nil
+		}
+	}
+	return nil, fmt.Errorf("context %q not found in the kubeconfig files %q", contextName, f.Paths())
+}
+
+// Paths returns the paths of the kubeconfig files
+func (f KubeconfigFiles) Paths() []string {
+	paths := make([]string, 0, len(f))
+	for _, kubeconfig := range f {
+		paths = append(paths, kubeconfig.path)
+	}
+	return paths
+}
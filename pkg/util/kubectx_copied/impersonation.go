@@ -0,0 +1,84 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfigutil
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetImpersonation configures the user of the current context to impersonate the given user and groups
+// ("as" and "as-groups"). Empty values are not set.
+func (k *Kubeconfig) SetImpersonation(user string, groups []string) error {
+	currentContext := k.GetCurrentContext()
+	if len(currentContext) == 0 {
+		return fmt.Errorf("current-context is not set")
+	}
+
+	ctx, err := k.contextNode(currentContext)
+	if err != nil {
+		return err
+	}
+	ctxBody := valueOf(ctx, "context")
+	if ctxBody == nil {
+		return fmt.Errorf("context %q has no body", currentContext)
+	}
+	userName := valueOf(ctxBody, "user")
+	if userName == nil {
+		return fmt.Errorf("context %q does not reference a user", currentContext)
+	}
+
+	users := valueOf(k.rootNode, "users")
+	if users == nil || users.Kind != yaml.SequenceNode {
+		return fmt.Errorf("users is not a sequence node")
+	}
+
+	for _, userNode := range users.Content {
+		nameNode := valueOf(userNode, "name")
+		if nameNode == nil || nameNode.Value != userName.Value {
+			continue
+		}
+
+		userBody := valueOf(userNode, "user")
+		if userBody == nil || userBody.Kind != yaml.MappingNode {
+			return fmt.Errorf("user %q has no body", userName.Value)
+		}
+
+		if len(user) > 0 {
+			setMappingValue(userBody, "as", &yaml.Node{Kind: yaml.ScalarNode, Value: user, Tag: "!!str"})
+		}
+		if len(groups) > 0 {
+			groupsNode := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for _, group := range groups {
+				groupsNode.Content = append(groupsNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: group, Tag: "!!str"})
+			}
+			setMappingValue(userBody, "as-groups", groupsNode)
+		}
+		return nil
+	}
+	return fmt.Errorf("user %q of context %q not found", userName.Value, currentContext)
+}
+
+// setMappingValue sets the value of the key in the mapping node, replacing an existing value
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key, Tag: "!!str"}, value)
+}
@@ -15,19 +15,16 @@
 package kubeconfigutil
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/pkg/errors"
-
 	"gopkg.in/yaml.v3"
-)
 
-const (
-	// TemporaryKubeconfigDir is a constant for the directory where the switcher stores the temporary kubeconfig files
-	TemporaryKubeconfigDir = "$HOME/.kube/.switch_tmp"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/filelock"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/permissions"
 )
 
 type Kubeconfig struct {
@@ -36,13 +33,14 @@ type Kubeconfig struct {
 	rootNode   *yaml.Node
 }
 
-// LoadCurrentKubeconfig loads the current kubeconfig
+// LoadCurrentKubeconfig loads the current kubeconfig.
+// If the KUBECONFIG environment variable contains multiple files, the file setting the current context is loaded.
 func LoadCurrentKubeconfig() (*Kubeconfig, error) {
-	path, err := kubeconfigPath()
+	files, err := LoadCurrentKubeconfigFiles()
 	if err != nil {
 		return nil, err
 	}
-	return NewKubeconfigForPath(path)
+	return files.CurrentContextFile(), nil
 }
 
 // NewKubeconfigForPath creates a kubeconfig representation based on an existing kubeconfig
@@ -68,6 +66,9 @@ func New(kubeconfigData []byte, path string, useTmpFile bool) (*Kubeconfig, erro
 	if err := yaml.Unmarshal(kubeconfigData, n); err != nil {
 		return nil, err
 	}
+	if len(n.Content) == 0 {
+		return nil, fmt.Errorf("kubeconfig file is empty")
+	}
 
 	k := &Kubeconfig{
 		rootNode:   n.Content[0],
@@ -115,6 +116,14 @@ func (k *Kubeconfig) SetKubeswitchContext(context string) error {
 	return nil
 }
 
+// SetKubeswitchStore sets the kind and ID of the kubeconfig store the kubeconfig has been retrieved from
+func (k *Kubeconfig) SetKubeswitchStore(kind, id string) error {
+	if err := k.ModifyKubeswitchStore(kind, id); err != nil {
+		return fmt.Errorf("failed to set switch store on selected kubeconfig: %v", err)
+	}
+	return nil
+}
+
 // SetGardenerStoreMetaInformation is a function to add meta information to kubeconfig which is required for subsequent runs of kubeswitch
 // Only relevant to the Gardener store
 func (k *Kubeconfig) SetGardenerStoreMetaInformation(landscapeIdentity, clusterType, project, name string) error {
@@ -150,60 +159,78 @@ func (k *Kubeconfig) SetNamespaceForCurrentContext(namespace string) error {
 }
 
 // WriteKubeconfigFile writes kubeconfig bytes to the local filesystem
-// and returns the kubeconfig path
+// and returns the kubeconfig path.
+// An existing kubeconfig file is replaced atomically, so that an interrupted write never leaves a truncated kubeconfig.
 func (k *Kubeconfig) WriteKubeconfigFile() (string, error) {
-	var (
-		file *os.File
-		err  error
-	)
-	// if we do not use a tmp file, then k.path is the path to a directory to create the tmp file in
-	if k.useTmpFile {
-		err = os.Mkdir(k.path, 0700)
-		if err != nil && !os.IsExist(err) {
-			return "", err
-		}
+	var content bytes.Buffer
+	enc := yaml.NewEncoder(&content)
+	enc.SetIndent(0)
+	if err := enc.Encode(k.rootNode); err != nil {
+		return "", err
+	}
 
-		// write temporary kubeconfig file
-		file, err = os.CreateTemp(k.path, "config.*.tmp")
-		if err != nil {
-			return "", err
+	// if we do not use a tmp file, then k.path is the path to a directory to create the tmp file in
+	if !k.useTmpFile {
+		// keep the permissions of an existing kubeconfig file
+		mode := permissions.FileMode
+		if info, err := os.Stat(k.path); err == nil {
+			mode = info.Mode().Perm()
 		}
-	} else {
-		file, err = os.OpenFile(k.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-		if err != nil {
-			return "", fmt.Errorf("failed to open existing kubeconfig file: %v", err)
+		if err := filelock.WriteFile(k.path, content.Bytes(), mode); err != nil {
+			return "", fmt.Errorf("failed to write kubeconfig file: %v", err)
 		}
+		return k.path, nil
 	}
 
-	enc := yaml.NewEncoder(file)
-	enc.SetIndent(0)
+	// the parent directory does not exist yet on Windows
+	if err := permissions.MkdirAll(k.path); err != nil {
+		return "", err
+	}
 
-	if err := enc.Encode(k.rootNode); err != nil {
+	// write temporary kubeconfig file
+	file, err := os.CreateTemp(k.path, "config.*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(content.Bytes()); err != nil {
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
 		return "", err
 	}
+	return file.Name(), file.Close()
+}
 
-	return file.Name(), nil
+// FilePath returns the path WriteKubeconfigFile() writes to.
+// For temporary kubeconfig files, the random part of the file name is replaced by "*".
+func (k *Kubeconfig) FilePath() string {
+	if !k.useTmpFile {
+		return k.path
+	}
+	return filepath.Join(k.path, "config.*.tmp")
 }
 
 func (k *Kubeconfig) GetBytes() ([]byte, error) {
 	return yaml.Marshal(k.rootNode)
 }
 
-func kubeconfigPath() (string, error) {
-	// KUBECONFIG env var
-	if v := os.Getenv("KUBECONFIG"); v != "" {
-		list := filepath.SplitList(v)
-		if len(list) > 1 {
-			// TODO KUBECONFIG=file1:file2 currently not supported
-			return "", errors.New("multiple files in KUBECONFIG are currently not supported")
-		}
-		return v, nil
+// CurrentKubeconfigPath returns the path of the current kubeconfig given by the KUBECONFIG environment variable
+// or the default path.
+// If the KUBECONFIG environment variable contains multiple files, the path of the file setting the current context is returned.
+func CurrentKubeconfigPath() (string, error) {
+	paths, err := CurrentKubeconfigPaths()
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 1 {
+		return paths[0], nil
 	}
 
-	// default path
-	home := os.Getenv("HOME")
-	if home == "" {
-		return "", errors.New("HOME environment variable not set")
+	files, err := LoadKubeconfigFiles(paths)
+	if err != nil {
+		return "", err
 	}
-	return filepath.Join(home, ".kube", "config"), nil
+	return files.CurrentContextFile().path, nil
 }
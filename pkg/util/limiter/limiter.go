@@ -0,0 +1,65 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package limiter bounds the number of concurrent list and read calls of a kubeconfig store to its backing API,
+// so that providers like Rancher or Vault are not overwhelmed when all kubeconfigs are discovered at once.
+package limiter
+
+import (
+	"sync"
+)
+
+var (
+	limiters     = map[string]*Limiter{}
+	limitersLock sync.Mutex
+)
+
+// Limiter bounds the number of concurrent calls. A nil Limiter does not limit the calls.
+type Limiter struct {
+	slots chan struct{}
+}
+
+// ForStore returns the limiter shared by all calls of the kubeconfig store with the given ID.
+// Returns nil if the maximum concurrency is not set, i.e. the calls are not limited.
+func ForStore(storeID string, maxConcurrency *int) *Limiter {
+	if maxConcurrency == nil || *maxConcurrency <= 0 {
+		return nil
+	}
+
+	limitersLock.Lock()
+	defer limitersLock.Unlock()
+
+	if l, ok := limiters[storeID]; ok && cap(l.slots) == *maxConcurrency {
+		return l
+	}
+	l := &Limiter{slots: make(chan struct{}, *maxConcurrency)}
+	limiters[storeID] = l
+	return l
+}
+
+// Acquire blocks until a call is allowed to run
+func (l *Limiter) Acquire() {
+	if l == nil {
+		return
+	}
+	l.slots <- struct{}{}
+}
+
+// Release frees the slot of a finished call
+func (l *Limiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
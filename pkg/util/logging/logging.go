@@ -0,0 +1,85 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging configures the level and the format of the loggers used throughout kubeswitch,
+// so that the log messages can be parsed by automation and the verbosity of single kubeconfig stores can be raised.
+package logging
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var (
+	lock      sync.Mutex
+	level     logrus.Level     = logrus.InfoLevel
+	formatter logrus.Formatter = &logrus.TextFormatter{}
+	// loggers are the loggers created with New.
+	// Maps to the level overriding the configured level, if any.
+	loggers = map[*logrus.Logger]*logrus.Level{}
+)
+
+// New returns a logger with the configured level and format.
+// The logger is updated when the logging is configured afterwards,
+// e.g. for package-level loggers created before the switch configuration has been read.
+func New() *logrus.Logger {
+	lock.Lock()
+	defer lock.Unlock()
+
+	logger := logrus.New()
+	logger.SetLevel(level)
+	logger.SetFormatter(formatter)
+	loggers[logger] = nil
+	return logger
+}
+
+// Configure sets the level and the format of the standard logger and all loggers created with New.
+// Levels set with SetLevel are kept.
+func Configure(configuredLevel logrus.Level, format types.LogFormat) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	level = configuredLevel
+	formatter = newFormatter(format)
+
+	logrus.SetLevel(level)
+	logrus.SetFormatter(formatter)
+	for logger, override := range loggers {
+		logger.SetFormatter(formatter)
+		if override == nil {
+			logger.SetLevel(level)
+		}
+	}
+}
+
+// SetLevel overrides the configured level for the given logger, e.g. the logger of a single kubeconfig store
+func SetLevel(logger *logrus.Logger, override logrus.Level) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	logger.SetLevel(override)
+	if _, ok := loggers[logger]; ok {
+		loggers[logger] = &override
+	}
+}
+
+func newFormatter(format types.LogFormat) logrus.Formatter {
+	if format == types.LogFormatJSON {
+		return &logrus.JSONFormatter{}
+	}
+	return &logrus.TextFormatter{}
+}
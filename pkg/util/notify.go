@@ -0,0 +1,63 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// windowsToastScript shows a toast notification with the title and message passed via environment variables
+const windowsToastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName("text")
+$text.Item(0).AppendChild($template.CreateTextNode($env:KUBESWITCH_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:KUBESWITCH_NOTIFY_MESSAGE)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier("kubeswitch").Show([Windows.UI.Notifications.ToastNotification]::new($template))
+`
+
+// Notify shows a desktop notification.
+// Uses the notification center on macOS (osascript), a toast notification on Windows and notify-send (libnotify) on Linux.
+// Urgent notifications stay visible until dismissed where supported.
+func Notify(title, message string, urgent bool) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", `display notification (system attribute "KUBESWITCH_NOTIFY_MESSAGE") with title (system attribute "KUBESWITCH_NOTIFY_TITLE")`)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+	default:
+		urgency := "normal"
+		if urgent {
+			urgency = "critical"
+		}
+		cmd = exec.Command("notify-send", "--app-name", "kubeswitch", "--urgency", urgency, title, message)
+	}
+
+	// set if the executable is not found
+	if cmd.Err != nil {
+		return fmt.Errorf("failed to show notification: %v", cmd.Err)
+	}
+
+	// pass the text via environment variables to not have to escape it for AppleScript or PowerShell
+	cmd.Env = append(os.Environ(), "KUBESWITCH_NOTIFY_TITLE="+title, "KUBESWITCH_NOTIFY_MESSAGE="+message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification with %q: %v: %s", cmd.Args[0], err, output)
+	}
+	return nil
+}
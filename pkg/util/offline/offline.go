@@ -0,0 +1,83 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// defaultProbeTimeout is the default maximum duration of connecting to the probe address
+const defaultProbeTimeout = time.Second
+
+// routeProbeAddresses are documentation addresses (RFC 5737, RFC 3849) used to look up a network route.
+// Dialing UDP does not send any packets.
+var routeProbeAddresses = []string{"192.0.2.1:9", "[2001:db8::1]:9"}
+
+// ErrOffline is returned for operations requiring the API of a kubeconfig store in offline mode
+var ErrOffline = errors.New("offline mode")
+
+// enabled is set via the --offline flag or if the network is unavailable
+var enabled bool
+
+// Enable configures whether the APIs of the kubeconfig stores are called
+func Enable(offline bool) {
+	enabled = offline
+}
+
+// Enabled returns true if the search results are served from the index and the kubeconfigs from the cache
+func Enabled() bool {
+	return enabled
+}
+
+// RequiresNetwork returns true if the kubeconfig store of the given kind is not available offline
+func RequiresNetwork(kind types.StoreKind) bool {
+	return kind != types.StoreKindFilesystem && kind != types.StoreKindMock
+}
+
+// Detect returns true if the offline mode is configured to be detected and the network is unavailable
+func Detect(config *types.OfflineConfig) bool {
+	if config == nil || config.Detect == nil || !*config.Detect {
+		return false
+	}
+
+	if config.ProbeAddress == nil {
+		for _, address := range routeProbeAddresses {
+			if conn, err := net.Dial("udp", address); err == nil {
+				conn.Close()
+				return false
+			}
+		}
+		logrus.Debugf("No network route found, switching to offline mode")
+		return true
+	}
+
+	timeout := defaultProbeTimeout
+	if config.ProbeTimeout != nil {
+		timeout = *config.ProbeTimeout
+	}
+	conn, err := net.DialTimeout("tcp", *config.ProbeAddress, timeout)
+	if err != nil {
+		logrus.Debugf("Probe address %q is unreachable, switching to offline mode: %v", *config.ProbeAddress, err)
+		return true
+	}
+	conn.Close()
+	return false
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package permissions restricts the access to the files written by kubeswitch, which may contain credentials
+// (temporary kubeconfigs, cached kubeconfigs and the state directory), to the current user.
+package permissions
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FileMode is the mode of all files written by kubeswitch
+	FileMode os.FileMode = 0600
+	// DirMode is the mode of all directories created by kubeswitch
+	DirMode os.FileMode = 0700
+
+	// maxListed is the number of restricted paths listed in the warning of Enforce
+	maxListed = 5
+)
+
+// Restrict restricts the access to the file or directory to the current user,
+// i.e. sets the mode 0600 (0700 for directories) or an ACL granting access only to the current user on Windows
+func Restrict(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return restrict(path, info.IsDir())
+}
+
+// MkdirAll creates the directory and its parents accessible only by the current user
+func MkdirAll(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(path, DirMode); err != nil {
+		return err
+	}
+	return restrict(path, true)
+}
+
+// Verify checks the files and directories below the given paths and restricts the access to those that are accessible by other users.
+// Returns the paths that have been restricted. Paths that do not exist are skipped.
+func Verify(paths ...string) ([]string, error) {
+	var restricted []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			// e.g. the socket of the daemon
+			if !entry.IsDir() && !entry.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if !accessibleByOthers(path, info) {
+				return nil
+			}
+
+			if err := restrict(path, entry.IsDir()); err != nil {
+				return err
+			}
+			restricted = append(restricted, path)
+			return nil
+		})
+		if err != nil {
+			return restricted, err
+		}
+	}
+	return restricted, nil
+}
+
+// Enforce restricts the access to the files and directories below the given paths that are accessible by other users
+// and warns about them, as they may have exposed credentials
+func Enforce(paths ...string) {
+	restricted, err := Verify(paths...)
+	if err != nil {
+		logrus.Debugf("failed to verify the permissions of %s: %v", strings.Join(paths, ", "), err)
+	}
+	if len(restricted) == 0 {
+		return
+	}
+
+	listed := restricted
+	if len(listed) > maxListed {
+		listed = append(listed[:maxListed:maxListed], fmt.Sprintf("and %d more", len(restricted)-maxListed))
+	}
+	logrus.Warnf("%d file(s) written by kubeswitch were accessible by other users and may have exposed credentials. Restricted the access to the current user: %s",
+		len(restricted), strings.Join(listed, ", "))
+}
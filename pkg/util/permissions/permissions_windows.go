@@ -0,0 +1,108 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package permissions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// restrict replaces the ACL of the file or directory with a protected ACL granting full access only to the current user.
+// Directories pass the ACL on to the files created in them.
+func restrict(path string, isDir bool) error {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+
+	inheritance := uint32(windows.NO_INHERITANCE)
+	if isDir {
+		inheritance = windows.SUB_CONTAINERS_AND_OBJECTS_INHERIT
+	}
+
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_ALL,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       inheritance,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_USER,
+			TrusteeValue: windows.TrusteeValueFromSID(user.User.Sid),
+		},
+	}}, nil)
+	if err != nil {
+		return err
+	}
+
+	// the protected DACL does not inherit the entries of the parent directory
+	return windows.SetNamedSecurityInfo(longPath(path), windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, acl, nil)
+}
+
+// accessibleByOthers returns true if the ACL of the file grants access to other users than the current user,
+// the local system and the administrators. The file mode is meaningless on Windows.
+func accessibleByOthers(path string, _ os.FileInfo) bool {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return false
+	}
+
+	securityDescriptor, err := windows.GetNamedSecurityInfo(longPath(path), windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return false
+	}
+	acl, _, err := securityDescriptor.DACL()
+	if err != nil || acl == nil {
+		// a missing DACL grants access to everyone
+		return err == nil
+	}
+
+	for i := uint32(0); i < uint32(acl.AceCount); i++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(acl, i, &ace); err != nil {
+			return false
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE {
+			continue
+		}
+
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if !sid.Equals(user.User.Sid) && !sid.IsWellKnown(windows.WinLocalSystemSid) && !sid.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+			return true
+		}
+	}
+	return false
+}
+
+// longPath returns the path with the extended-length prefix if it exceeds MAX_PATH.
+// Unlike the os package, the security functions of Windows do not support long paths otherwise.
+func longPath(path string) string {
+	if len(path) < windows.MAX_PATH || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if unc, found := strings.CutPrefix(absolute, `\\`); found {
+		return `\\?\UNC\` + unc
+	}
+	return `\\?\` + absolute
+}
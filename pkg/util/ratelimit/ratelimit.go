@@ -0,0 +1,160 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit limits the rate of the requests to the APIs of cloud providers and retries throttled and failed requests,
+// so that discovering all clusters of an organization does not exceed the quotas of the provider.
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/fixtures"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util/httptransport"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultRequestsPerSecond is the default maximum average number of requests per second
+	defaultRequestsPerSecond = 10
+	// defaultBurst is the default maximum number of requests sent at once
+	defaultBurst = 20
+	// defaultMaxRetries is the default maximum number of retries of a request
+	defaultMaxRetries = 5
+	// baseDelay is the delay before the first retry. The delay doubles with every retry.
+	baseDelay = 500 * time.Millisecond
+	// maxDelay is the maximum delay before a retry, also if the Retry-After header of the response requests a longer delay
+	maxDelay = 30 * time.Second
+)
+
+// Transport is a http.RoundTripper that limits the rate of the requests
+// and retries requests that are throttled (429) or failed with a server error (5xx).
+type Transport struct {
+	base       http.RoundTripper
+	limiter    *rate.Limiter
+	maxRetries int
+}
+
+// NewTransport returns a transport limiting and retrying the requests of the base transport as configured.
+// Uses the defaults if the configuration is nil. The base transport defaults to the transport shared by all kubeconfig stores.
+// The requests are recorded to or replayed from a fixture file if enabled (see fixtures.Wrap).
+func NewTransport(base http.RoundTripper, config *types.RateLimit) *Transport {
+	if base == nil {
+		base = httptransport.Shared()
+	}
+
+	var (
+		requestsPerSecond = float64(defaultRequestsPerSecond)
+		burst             = defaultBurst
+		maxRetries        = defaultMaxRetries
+	)
+	if config != nil {
+		if config.RequestsPerSecond != nil {
+			requestsPerSecond = *config.RequestsPerSecond
+		}
+		if config.Burst != nil {
+			burst = *config.Burst
+		}
+		if config.MaxRetries != nil {
+			maxRetries = *config.MaxRetries
+		}
+	}
+
+	return &Transport{
+		base:       fixtures.Wrap(base),
+		limiter:    rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+		maxRetries: maxRetries,
+	}
+}
+
+// NewClient returns an HTTP client limiting and retrying the requests of the base transport as configured (see NewTransport)
+func NewClient(base http.RoundTripper, config *types.RateLimit) *http.Client {
+	return &http.Client{Transport: NewTransport(base, config)}
+}
+
+// RoundTrip waits until the rate limit allows the request and retries the request if it is throttled or fails with a server error.
+// Requests with a body can only be retried if the body can be recreated (see http.Request.GetBody).
+// Returns the last response if all retries are exhausted, so that the client reports the error of the provider.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !retryable(resp.StatusCode) || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		delay := backoff(attempt, resp.Header.Get("Retry-After"))
+		// drain the body to reuse the connection
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable returns true for responses of throttled requests and server errors
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before the retry after the given attempt.
+// The delay is requested by the Retry-After header in seconds or grows exponentially with full jitter,
+// so that the requests of concurrent searches do not retry at the same time.
+func backoff(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, maxDelay)
+	}
+
+	delay := maxDelay
+	// prevent the shift from overflowing
+	if attempt < 16 {
+		delay = min(baseDelay<<attempt, maxDelay)
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
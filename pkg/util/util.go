@@ -15,8 +15,11 @@
 package util
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -44,6 +47,38 @@ func GetContextsNamesFromKubeconfig(kubeconfigBytes []byte, contextPrefix string
 	return &data, contextsFromKubeconfig, err
 }
 
+// GetContextClusters takes kubeconfig bytes and returns the cluster of each context, i.e. its API server URL and certificate authority.
+// The context names carry the same prefix as returned by GetContextsNamesFromKubeconfig.
+func GetContextClusters(kubeconfigBytes []byte, contextPrefix string) (map[string]types.Cluster, error) {
+	config, err := ParseSanitizedKubeconfig(kubeconfigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse Kubeconfig: %v", err)
+	}
+
+	clusters := make(map[string]types.Cluster, len(config.Clusters))
+	for _, cluster := range config.Clusters {
+		clusters[cluster.Name] = cluster.Cluster
+	}
+
+	contextNames := getContextNames(config, contextPrefix)
+	contextToCluster := make(map[string]types.Cluster, len(contextNames))
+	for i, context := range config.Contexts {
+		if cluster, ok := clusters[context.Context.Cluster]; ok && len(cluster.Server) > 0 {
+			contextToCluster[contextNames[i]] = cluster
+		}
+	}
+	return contextToCluster, nil
+}
+
+// HashCertificateAuthority returns a hash of the base64 encoded certificate authority data of a cluster.
+// Returns an empty string if the cluster does not contain the certificate authority data.
+func HashCertificateAuthority(certificateAuthorityData string) string {
+	if len(certificateAuthorityData) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.TrimSpace(certificateAuthorityData))))
+}
+
 // ParseSanitizedKubeconfig parses the kubeconfig bytes into a kubeconfig struct without credentials
 func ParseSanitizedKubeconfig(data []byte) (*types.KubeConfig, error) {
 	config := types.KubeConfig{}
@@ -79,6 +114,23 @@ func ExpandEnv(path string) string {
 	return os.ExpandEnv(path)
 }
 
+// SetDefaultEnvironment sets the environment variables referenced by the default paths, e.g. of the state directory, if they are not set.
+// On Windows, HOME is usually only set by shells like Git Bash and LOCALAPPDATA may be missing, e.g. for service accounts.
+// Otherwise, the paths would be expanded relative to the root of the current drive instead of the user profile.
+func SetDefaultEnvironment() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+
+	if len(os.Getenv("HOME")) == 0 {
+		_ = os.Setenv("HOME", home)
+	}
+	if runtime.GOOS == "windows" && len(os.Getenv("LOCALAPPDATA")) == 0 {
+		_ = os.Setenv("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+	}
+}
+
 // GetCurrentContext returns "current-context" value of current kubeconfig
 func GetCurrentContext() (string, error) {
 	kc, err := kubeconfigutil.LoadCurrentKubeconfig()
@@ -120,3 +172,20 @@ func SplitAdditionalArgs(args *[]string) []string {
 	}
 	return additionalArgs
 }
+
+// Terminal returns the terminal device connected to stdin, if any.
+// The standard output is captured by the shell function, hence stdin is used.
+// Windows has no terminal devices, instead the session of the terminal emulator is returned:
+// Windows Terminal identifies its tabs and panes by WT_SESSION and ConEmu its consoles by ConEmuServerPID.
+func Terminal() string {
+	if tty, err := os.Readlink("/proc/self/fd/0"); err == nil && strings.HasPrefix(tty, "/dev/") {
+		return tty
+	}
+	if session := os.Getenv("WT_SESSION"); len(session) > 0 {
+		return "WindowsTerminal/" + session
+	}
+	if server := os.Getenv("ConEmuServerPID"); len(server) > 0 {
+		return "ConEmu/" + server
+	}
+	return ""
+}
@@ -19,4 +19,6 @@ type ContextAlias struct {
 	// ContextToAliasMapping defines how alias names are written
 	// used internally by the kubeswitch tool
 	ContextToAliasMapping map[string]string `yaml:"contextToAliasMapping"`
+	// AliasToNamespaceMapping defines the namespace to switch to when switching to an alias
+	AliasToNamespaceMapping map[string]string `yaml:"aliasToNamespaceMapping,omitempty"`
 }
@@ -24,11 +24,259 @@ import (
 type StoreKind string
 
 // ValidStoreKinds contains all valid store kinds
-var ValidStoreKinds = sets.NewString(string(StoreKindVault), string(StoreKindFilesystem), string(StoreKindGardener), string(StoreKindGKE), string(StoreKindAzure), string(StoreKindEKS), string(StoreKindExoscale), string(StoreKindRancher), string(StoreKindOVH), string(StoreKindScaleway), string(StoreKindDigitalOcean), string(StoreKindAkamai), string(StoreKindCapi), string(StoreKindPlugin))
+var ValidStoreKinds = sets.NewString(string(StoreKindVault), string(StoreKindFilesystem), string(StoreKindGardener), string(StoreKindGKE), string(StoreKindAzure), string(StoreKindEKS), string(StoreKindExoscale), string(StoreKindRancher), string(StoreKindOVH), string(StoreKindScaleway), string(StoreKindDigitalOcean), string(StoreKindAkamai), string(StoreKindCapi), string(StoreKindPlugin), string(StoreKindExec), string(StoreKindMock))
 
 // ValidConfigVersions contains all valid config versions
 var ValidConfigVersions = sets.NewString("v1alpha1")
 
+// Picker identifies the interactive selection dialog
+type Picker string
+
+const (
+	// PickerFuzzyFinder is the fuzzy finder showing the contexts and an optional preview
+	PickerFuzzyFinder Picker = "fuzzyfinder"
+	// PickerTUI is the terminal UI showing the contexts, a preview and the kubeconfig stores in separate panes
+	PickerTUI Picker = "tui"
+	// PickerFZF pipes the contexts to an external fzf binary
+	PickerFZF Picker = "fzf"
+)
+
+// ValidPickers contains all valid pickers
+var ValidPickers = sets.NewString(string(PickerFuzzyFinder), string(PickerTUI), string(PickerFZF))
+
+// ExcludePatternRegexPrefix marks an exclude pattern as regular expression instead of a wildcard pattern
+const ExcludePatternRegexPrefix = "regex:"
+
+// CollisionSuffix identifies the suffix added to a context name that is found more than once
+type CollisionSuffix string
+
+const (
+	// CollisionSuffixStoreID uses the ID of the kubeconfig store
+	CollisionSuffixStoreID CollisionSuffix = "storeID"
+	// CollisionSuffixAccount uses the cloud account, project or subscription of the cluster
+	CollisionSuffixAccount CollisionSuffix = "account"
+	// CollisionSuffixRegion uses the region or zone of the cluster
+	CollisionSuffixRegion CollisionSuffix = "region"
+)
+
+// ValidCollisionSuffixes contains all valid collision suffixes
+var ValidCollisionSuffixes = sets.NewString(string(CollisionSuffixStoreID), string(CollisionSuffixAccount), string(CollisionSuffixRegion))
+
+// DuplicateClusters configures how contexts of the same cluster found in more than one kubeconfig store are shown
+type DuplicateClusters string
+
+const (
+	// DuplicateClustersMerge only shows the context of the kubeconfig store configured first
+	DuplicateClustersMerge DuplicateClusters = "merge"
+	// DuplicateClustersGroup shows all contexts and marks the duplicates with the context of the kubeconfig store configured first
+	DuplicateClustersGroup DuplicateClusters = "group"
+)
+
+// ValidDuplicateClusters contains all valid modes to show duplicate clusters
+var ValidDuplicateClusters = sets.NewString(string(DuplicateClustersMerge), string(DuplicateClustersGroup))
+
+// AccessCheckMode configures how contexts are shown whose cluster rejected the credentials of the user
+type AccessCheckMode string
+
+const (
+	// AccessCheckModeDim shows the contexts greyed out and annotated with the reason
+	AccessCheckModeDim AccessCheckMode = "dim"
+	// AccessCheckModeHide does not show the contexts in the search results. They can still be switched to by name.
+	AccessCheckModeHide AccessCheckMode = "hide"
+)
+
+// ValidAccessCheckModes contains all valid access check modes
+var ValidAccessCheckModes = sets.NewString(string(AccessCheckModeDim), string(AccessCheckModeHide))
+
+// NotifyMode configures when a desktop notification is shown after switching the context
+type NotifyMode string
+
+const (
+	// NotifyModeNever does not show desktop notifications
+	NotifyModeNever NotifyMode = "never"
+	// NotifyModeProtected shows a desktop notification when switching to a protected context
+	NotifyModeProtected NotifyMode = "protected"
+	// NotifyModeAlways shows a desktop notification on every switch
+	NotifyModeAlways NotifyMode = "always"
+)
+
+// ValidNotifyModes contains all valid notify modes
+var ValidNotifyModes = sets.NewString(string(NotifyModeNever), string(NotifyModeProtected), string(NotifyModeAlways))
+
+type EncryptionKeySource string
+
+const (
+	// EncryptionKeySourceFile reads the encryption key from a plaintext key file in the state directory
+	EncryptionKeySourceFile EncryptionKeySource = "file"
+	// EncryptionKeySourceKeychain reads the encryption key from the OS keychain (macOS Keychain or the Secret Service on Linux)
+	EncryptionKeySourceKeychain EncryptionKeySource = "keychain"
+	// EncryptionKeySourceAge reads the encryption key from a key file in the state directory encrypted with an age identity
+	EncryptionKeySourceAge EncryptionKeySource = "age"
+)
+
+// ValidEncryptionKeySources contains all valid encryption key sources
+var ValidEncryptionKeySources = sets.NewString(string(EncryptionKeySourceFile), string(EncryptionKeySourceKeychain), string(EncryptionKeySourceAge))
+
+// SyncKind is the kind of backend the user state is synchronized with
+type SyncKind string
+
+const (
+	// SyncKindGit synchronizes the user state via a file in a Git repository
+	SyncKindGit SyncKind = "git"
+	// SyncKindS3 synchronizes the user state via an object in an S3 bucket using the aws CLI
+	SyncKindS3 SyncKind = "s3"
+	// SyncKindGCS synchronizes the user state via an object in a GCS bucket using the gcloud CLI
+	SyncKindGCS SyncKind = "gcs"
+)
+
+// ValidSyncKinds contains all valid sync backend kinds
+var ValidSyncKinds = sets.NewString(string(SyncKindGit), string(SyncKindS3), string(SyncKindGCS))
+
+// WebhookFormat is the format of the payload posted to a webhook
+type WebhookFormat string
+
+const (
+	// WebhookFormatGeneric posts the context switch as JSON object
+	WebhookFormatGeneric WebhookFormat = "generic"
+	// WebhookFormatSlack posts a message compatible with Slack incoming webhooks (also accepted by Mattermost and Rocket.Chat)
+	WebhookFormatSlack WebhookFormat = "slack"
+)
+
+// ValidWebhookFormats contains all valid webhook payload formats
+var ValidWebhookFormats = sets.NewString(string(WebhookFormatGeneric), string(WebhookFormatSlack))
+
+// KubeconfigProvider configures how the kubeconfigs of a store provide the credentials of their users
+type KubeconfigProvider string
+
+const (
+	// KubeconfigProviderStatic embeds the credentials returned by the API of the kubeconfig store in the kubeconfigs
+	KubeconfigProviderStatic KubeconfigProvider = "static"
+	// KubeconfigProviderExec configures an exec credential plugin calling kubeswitch, which mints short-lived credentials when the client needs them
+	KubeconfigProviderExec KubeconfigProvider = "exec"
+)
+
+// ValidKubeconfigProviders contains all valid kubeconfig providers
+var ValidKubeconfigProviders = sets.NewString(string(KubeconfigProviderStatic), string(KubeconfigProviderExec))
+
+// DashboardTool is a local dashboard started by "switch dashboard"
+type DashboardTool string
+
+const (
+	// DashboardToolHeadlamp starts the Headlamp desktop application
+	DashboardToolHeadlamp DashboardTool = "headlamp"
+	// DashboardToolOctant starts Octant, which serves the dashboard on a local port
+	DashboardToolOctant DashboardTool = "octant"
+)
+
+// ValidDashboardTools contains all valid dashboard tools
+var ValidDashboardTools = sets.NewString(string(DashboardToolHeadlamp), string(DashboardToolOctant))
+
+// PickerAction is a built-in action of the "tui" picker that can be bound to keys
+type PickerAction string
+
+const (
+	// PickerActionSelect switches to the highlighted context
+	PickerActionSelect PickerAction = "select"
+	// PickerActionAbort closes the picker without switching
+	PickerActionAbort PickerAction = "abort"
+	// PickerActionUp moves the cursor up
+	PickerActionUp PickerAction = "up"
+	// PickerActionDown moves the cursor down
+	PickerActionDown PickerAction = "down"
+	// PickerActionPageUp moves the cursor up by one page
+	PickerActionPageUp PickerAction = "page-up"
+	// PickerActionPageDown moves the cursor down by one page
+	PickerActionPageDown PickerAction = "page-down"
+	// PickerActionClearQuery clears the search query
+	PickerActionClearQuery PickerAction = "clear-query"
+	// PickerActionToggleFocus toggles the focus between the results and the kubeconfig stores
+	PickerActionToggleFocus PickerAction = "toggle-focus"
+	// PickerActionToggleStore includes or excludes the highlighted kubeconfig store from the results
+	PickerActionToggleStore PickerAction = "toggle-store"
+	// PickerActionCopyKubeconfig copies the kubeconfig of the highlighted context to the clipboard
+	PickerActionCopyKubeconfig PickerAction = "copy-kubeconfig"
+	// PickerActionOpenConsole opens the page of the highlighted cluster in the web console of the cloud provider
+	PickerActionOpenConsole PickerAction = "open-console"
+	// PickerActionToggleSortOrder toggles the order of the results between frecency and alphabetical
+	PickerActionToggleSortOrder PickerAction = "toggle-sort-order"
+	// PickerActionToggleView toggles the results between the list and the tree view
+	PickerActionToggleView PickerAction = "toggle-view"
+	// PickerActionCollapse collapses the highlighted group of the tree view, or the group containing the highlighted context
+	PickerActionCollapse PickerAction = "collapse"
+	// PickerActionExpand expands the highlighted group of the tree view
+	PickerActionExpand PickerAction = "expand"
+	// PickerActionToggleMark marks or unmarks the highlighted context for the bulk actions and moves the cursor down.
+	// Custom commands, copy-kubeconfig and delete-context apply to all marked contexts.
+	PickerActionToggleMark PickerAction = "toggle-mark"
+	// PickerActionDeleteContext removes the marked or highlighted contexts from the search index and the cache of their kubeconfig stores
+	PickerActionDeleteContext PickerAction = "delete-context"
+	// PickerActionQuickSelect switches to the recently or frequently used context with the number of the key.
+	// The first key bound to the action selects the context numbered 1, the second key the context numbered 2 and so on.
+	PickerActionQuickSelect PickerAction = "quick-select"
+	// PickerActionEditNote opens the note of the highlighted context in the editor of the user
+	PickerActionEditNote PickerAction = "edit-note"
+)
+
+// ValidPickerActions contains all valid picker actions
+var ValidPickerActions = sets.NewString(string(PickerActionSelect), string(PickerActionAbort), string(PickerActionUp), string(PickerActionDown), string(PickerActionPageUp), string(PickerActionPageDown), string(PickerActionClearQuery), string(PickerActionToggleFocus), string(PickerActionToggleStore), string(PickerActionCopyKubeconfig), string(PickerActionOpenConsole), string(PickerActionToggleSortOrder), string(PickerActionToggleView), string(PickerActionCollapse), string(PickerActionExpand), string(PickerActionToggleMark), string(PickerActionDeleteContext), string(PickerActionQuickSelect), string(PickerActionEditNote))
+
+// SortOrder is the order of the search results of the "tui" picker
+type SortOrder string
+
+const (
+	// SortOrderFrecency ranks frequently and recently used contexts of the history first
+	SortOrderFrecency SortOrder = "frecency"
+	// SortOrderAlphabetical sorts the contexts by name
+	SortOrderAlphabetical SortOrder = "alphabetical"
+)
+
+// ValidSortOrders contains all valid sort orders
+var ValidSortOrders = sets.NewString(string(SortOrderFrecency), string(SortOrderAlphabetical))
+
+// ResultsView is how the search results of the "tui" picker are displayed
+type ResultsView string
+
+const (
+	// ResultsViewList shows the contexts as a flat list
+	ResultsViewList ResultsView = "list"
+	// ResultsViewTree groups the contexts by kubeconfig store and by the folders of the context names, e.g. the account and region
+	ResultsViewTree ResultsView = "tree"
+)
+
+// ValidResultsViews contains all valid views of the search results
+var ValidResultsViews = sets.NewString(string(ResultsViewList), string(ResultsViewTree))
+
+// MatchingAlgorithm is how the terms of the search query are matched
+type MatchingAlgorithm string
+
+const (
+	// MatchingAlgorithmFuzzy matches if the text contains the characters of the term in order
+	MatchingAlgorithmFuzzy MatchingAlgorithm = "fuzzy"
+	// MatchingAlgorithmSubstring matches if the text contains the term
+	MatchingAlgorithmSubstring MatchingAlgorithm = "substring"
+	// MatchingAlgorithmExact matches if the text contains the term as a whole word,
+	// e.g. "prod" matches "eu-prod-1", but not "eu-preprod-1"
+	MatchingAlgorithmExact MatchingAlgorithm = "exact"
+)
+
+// ValidMatchingAlgorithms contains all valid matching algorithms
+var ValidMatchingAlgorithms = sets.NewString(string(MatchingAlgorithmFuzzy), string(MatchingAlgorithmSubstring), string(MatchingAlgorithmExact))
+
+// CaseSensitivity configures if the search query is matched case-sensitively
+type CaseSensitivity string
+
+const (
+	// CaseSensitivitySmart matches case-sensitively only if the term contains an upper-case letter
+	CaseSensitivitySmart CaseSensitivity = "smart"
+	// CaseSensitivitySensitive always matches case-sensitively
+	CaseSensitivitySensitive CaseSensitivity = "sensitive"
+	// CaseSensitivityInsensitive never matches case-sensitively
+	CaseSensitivityInsensitive CaseSensitivity = "insensitive"
+)
+
+// ValidCaseSensitivities contains all valid case sensitivities
+var ValidCaseSensitivities = sets.NewString(string(CaseSensitivitySmart), string(CaseSensitivitySensitive), string(CaseSensitivityInsensitive))
+
 const (
 	// StoreKindFilesystem is an identifier for the filesystem store
 	StoreKindFilesystem StoreKind = "filesystem"
@@ -58,6 +306,10 @@ const (
 	StoreKindCapi StoreKind = "capi"
 	// StoreKindPlugin is an identifier for the Plugin store
 	StoreKindPlugin StoreKind = "plugin"
+	// StoreKindExec is an identifier for the Exec store
+	StoreKindExec StoreKind = "exec"
+	// StoreKindMock is an identifier for the Mock store generating fake clusters
+	StoreKindMock StoreKind = "mock"
 )
 
 type Config struct {
@@ -76,6 +328,164 @@ type Config struct {
 	// default: true
 	// + optional
 	ShowPreview *bool `yaml:"showPreview"`
+	// Picker configures the interactive selection dialog.
+	// Possible values: "fuzzyfinder", "tui", "fzf"
+	// default: fuzzyfinder
+	// + optional
+	Picker *Picker `yaml:"picker"`
+	// ShowClusterInfo configures if the preview queries the cluster of the selected context
+	// to show its reachability, Kubernetes version and node count.
+	// Only supported by the "tui" picker.
+	// default: false
+	// + optional
+	ShowClusterInfo *bool `yaml:"showClusterInfo"`
+	// ShowReachability configures if the clusters of the shown contexts are probed in the background
+	// to annotate the search results with their reachability and round-trip latency.
+	// Only supported by the "tui" picker.
+	// default: false
+	// + optional
+	ShowReachability *bool `yaml:"showReachability"`
+	// AccessCheck configures checking whether the clusters accept the credentials of the user,
+	// to dim or hide the contexts of clusters the user cannot authenticate to in the search results.
+	// + optional
+	AccessCheck *AccessCheckConfig `yaml:"accessCheck"`
+	// KubernetesVersion configures showing the Kubernetes version of the clusters next to the context names
+	// and highlighting the clusters below the minimum supported version, e.g. to plan upgrades.
+	// The version is only known if returned by the API of the kubeconfig store during the search.
+	// + optional
+	KubernetesVersion *KubernetesVersionConfig `yaml:"kubernetesVersion"`
+	// Offline configures the offline mode, which serves the search results from the index and the kubeconfigs from the cache
+	// without calling the APIs of the kubeconfig stores. Enabled with the flag --offline or automatically if the network is unavailable.
+	// + optional
+	Offline *OfflineConfig `yaml:"offline"`
+	// PrefetchKubeconfigs is the number of top results whose kubeconfigs are retrieved in the background while typing,
+	// so that selecting a context of a slow kubeconfig store (e.g. a cloud provider API) is near-instant.
+	// Only supported by the "tui" picker.
+	// default: 0 (disabled)
+	// + optional
+	PrefetchKubeconfigs *int `yaml:"prefetchKubeconfigs"`
+	// SortOrder is the initial order of the search results.
+	// The order can be toggled in the picker. A search query ranks the results by similarity first.
+	// Only supported by the "tui" picker.
+	// Possible values: "frecency", "alphabetical"
+	// default: the order in which the contexts are discovered
+	// + optional
+	SortOrder *SortOrder `yaml:"sortOrder"`
+	// ResultsView is the initial view of the search results.
+	// The tree view groups the contexts by kubeconfig store and by the folders of the context names (e.g. "<account>/<region>/<cluster>")
+	// in collapsible groups. The view can be toggled in the picker.
+	// Only supported by the "tui" picker.
+	// Possible values: "list", "tree"
+	// default: "list"
+	// + optional
+	ResultsView *ResultsView `yaml:"resultsView"`
+	// Matching is the algorithm matching the terms of the search query against the context names and their metadata.
+	// Fuzzy matching ranks the results of a single term by similarity, which may produce surprising orderings for systematic context names.
+	// The other algorithms keep the order of the results.
+	// Only supported by the "tui" picker and the "fzf" picker, which matches substrings for both "substring" and "exact".
+	// Possible values: "fuzzy", "substring", "exact"
+	// default: "fuzzy"
+	// + optional
+	Matching *MatchingAlgorithm `yaml:"matching"`
+	// CaseSensitivity configures if the search query is matched case-sensitively.
+	// With "smart", the query is only matched case-sensitively if it contains an upper-case letter.
+	// Possible values: "smart", "sensitive", "insensitive"
+	// default: "smart"
+	// + optional
+	CaseSensitivity *CaseSensitivity `yaml:"caseSensitivity"`
+	// SearchMetadata configures if the search also matches the metadata of each context,
+	// i.e. the host of the API server and the tags of the kubeconfig store like the account, project or region.
+	// The metadata is shown next to the context name.
+	// default: false
+	// + optional
+	SearchMetadata *bool `yaml:"searchMetadata"`
+	// ShowStoreIcons configures if the search results show an icon of the kubeconfig store kind next to each context.
+	// Requires a Nerd Font (https://www.nerdfonts.com) in the terminal.
+	// default: false
+	// + optional
+	ShowStoreIcons *bool `yaml:"showStoreIcons"`
+	// StoreIcons overrides the icons shown for the kubeconfig store kinds, e.g. "eks: \uf270"
+	// + optional
+	StoreIcons map[StoreKind]string `yaml:"storeIcons"`
+	// CollisionSuffix configures the suffix that disambiguates a context name found in more than one kubeconfig,
+	// e.g. "dev@eu-west-1" for "region". The context of the kubeconfig store configured first keeps its name.
+	// Falls back to the ID of the kubeconfig store if the account or region is not known.
+	// Possible values: "storeID", "account", "region"
+	// default: storeID
+	// + optional
+	CollisionSuffix *CollisionSuffix `yaml:"collisionSuffix"`
+	// AliasRules generate the aliases of contexts from their tags, e.g. "{{ .CloudTags.team }}-{{ .CloudTags.env }}".
+	// The aliases are generated during each search, so that they follow clusters being created and destroyed.
+	// The first matching rule generating a non-empty alias is used.
+	// Aliases defined with `switch alias` and context name templates of the kubeconfig stores take precedence.
+	// + optional
+	AliasRules []AliasRule `yaml:"aliasRules"`
+	// DuplicateClusters configures how contexts of the same cluster found in more than one kubeconfig store are shown,
+	// e.g. a cluster exported to a kubeconfig file that is also discovered via the API of the cloud provider.
+	// Clusters are identified by the URL of the API server and the certificate authority.
+	// "merge" only shows the context of the kubeconfig store configured first, "group" marks the duplicates with this context.
+	// Possible values: "merge", "group"
+	// default: all contexts are shown
+	// + optional
+	DuplicateClusters *DuplicateClusters `yaml:"duplicateClusters"`
+	// PreviewTemplate is a Go template defining the content of the preview.
+	// The template has access to the context name, the kubeconfig store, the kubeconfig path, tags,
+	// region, account, the age of the search index, the sanitized kubeconfig and the store specific preview.
+	// Defaults to the sanitized kubeconfig followed by the store specific preview.
+	// + optional
+	PreviewTemplate *string `yaml:"previewTemplate"`
+	// Keybindings binds keys to built-in actions or custom commands in the "tui" picker
+	// + optional
+	Keybindings []Keybinding `yaml:"keybindings"`
+	// Environments color contexts by environment, e.g. production contexts in red,
+	// in the "tui" and "fzf" picker and in prompts. The first matching environment is used.
+	// + optional
+	Environments []Environment `yaml:"environments"`
+	// VerifyBeforeSwitch configures if the API server of the selected context is checked for reachability
+	// and valid credentials before switching.
+	// If the check fails, asks for confirmation before switching to the context.
+	// default: false
+	// + optional
+	VerifyBeforeSwitch *bool `yaml:"verifyBeforeSwitch"`
+	// ValidateKubeconfigs configures if the kubeconfig of the selected context is validated before switching,
+	// e.g. that the referenced cluster and user exist and the binaries of exec credential plugins are on the PATH.
+	// If the kubeconfig is invalid, the switch fails with the problems found. See "switch validate".
+	// default: false
+	// + optional
+	ValidateKubeconfigs *bool `yaml:"validateKubeconfigs"`
+	// ProtectedContexts are wildcard patterns of context names (or aliases), e.g. "*prod*".
+	// Switching to a protected context requires typing the context name or passing the flag --yes-i-mean-prod.
+	// + optional
+	ProtectedContexts []string `yaml:"protectedContexts"`
+	// KubeconfigPolicy are security baselines enforced for the kubeconfigs of all kubeconfig stores
+	// before the temporary kubeconfig is written.
+	// + optional
+	KubeconfigPolicy *KubeconfigPolicy `yaml:"kubeconfigPolicy"`
+	// Proxies route the traffic to the API servers of the contexts matching their patterns through a proxy,
+	// e.g. the SOCKS5 proxy of an SSH tunnel to a jump host. The first matching proxy is used
+	// and takes precedence over the proxy of the kubeconfig store.
+	// + optional
+	Proxies []Proxy `yaml:"proxies"`
+	// Tailscale configures the access to clusters reachable over a Tailscale network (tailnet),
+	// e.g. clusters exposed by the Tailscale Kubernetes operator.
+	// + optional
+	Tailscale *Tailscale `yaml:"tailscale"`
+	// BoundaryTargets broker the access to the API servers of the contexts matching their patterns through HashiCorp Boundary.
+	// Switching to such a context connects to the Boundary target and points the cluster to the local listener of the session.
+	// The session is canceled once the terminal session ends. The first matching target is used.
+	// + optional
+	BoundaryTargets []BoundaryTarget `yaml:"boundaryTargets"`
+	// Notify configures when a desktop notification is shown after switching the context,
+	// so that the switch is noticed even if the terminal is in the background.
+	// Uses the notification center on macOS, notify-send (libnotify) on Linux and toast notifications on Windows.
+	// Possible values: "never", "protected", "always"
+	// default: never
+	// + optional
+	Notify *NotifyMode `yaml:"notify"`
+	// RepositoryContexts map Git repositories to the context (and namespace) that "switch auto" and the cd hook switch to.
+	// The first matching mapping is used. A ".kubeswitch" file in the current directory or its parent directories takes precedence.
+	// + optional
+	RepositoryContexts []RepositoryContext `yaml:"repositoryContexts"`
 	// ExecShell configures the shell to be used for switch exec -- "command"
 	// If a shell (bash, zsh, sh) is provided, the command is executed like so
 	// --> bash -c "your_command"
@@ -86,12 +496,410 @@ type Config struct {
 	// Can be overridden in the individual kubeconfig store configuration
 	// + optional
 	RefreshIndexAfter *time.Duration `yaml:"refreshIndexAfter"`
+	// StaleWhileRevalidate is the global default for reading an outdated index instead of searching the kubeconfig store.
+	// The index is then refreshed in a background process for the next search.
+	// Can be overridden in the individual kubeconfig store configuration
+	// defaults to false
+	// + optional
+	StaleWhileRevalidate *bool `yaml:"staleWhileRevalidate"`
+	// ExcludePatterns are matched against the kubeconfig path and the name of each context found in any kubeconfig store.
+	// Matching contexts are never shown in the search results.
+	// Patterns are wildcard patterns, e.g. "*-deprecated", or regular expressions prefixed with "regex:", e.g. "regex:^gke_.*_sandbox$"
+	// + optional
+	ExcludePatterns []string `yaml:"excludePatterns"`
+	// SearchConcurrency is the maximum number of kubeconfig stores that are searched at the same time.
+	// Reading from the index of a kubeconfig store is not limited.
+	// default: all kubeconfig stores are searched at the same time
+	// + optional
+	SearchConcurrency *int `yaml:"searchConcurrency"`
+	// SearchBatchSize is the maximum number of contexts found during the search that are buffered
+	// before they are added to the search results and staged for the index.
+	// Bounds the memory used to search kubeconfig stores with many contexts.
+	// default: 500
+	// + optional
+	SearchBatchSize *int `yaml:"searchBatchSize"`
+	// SearchTimeout is the global default for the maximum duration of the search of a kubeconfig store.
+	// The search of a kubeconfig store that takes longer is aborted and reported as an error.
+	// Contexts found until then are shown, but not written to the index.
+	// Can be overridden in the individual kubeconfig store configuration
+	// default: no timeout
+	// + optional
+	SearchTimeout *time.Duration `yaml:"searchTimeout"`
+	// CircuitBreaker configures skipping kubeconfig stores whose searches failed repeatedly,
+	// e.g. due to invalid credentials or an unreachable API, instead of waiting for them on every search.
+	// default: kubeconfig stores are never skipped
+	// + optional
+	CircuitBreaker *CircuitBreaker `yaml:"circuitBreaker"`
+	// HTTPTransport configures the HTTP transport shared by the kubeconfig stores of cloud providers and Vault,
+	// so that repeated requests reuse connections instead of establishing a new TLS connection per request.
+	// default: keep-alives and HTTP/2 are enabled
+	// + optional
+	HTTPTransport *HTTPTransport `yaml:"httpTransport"`
+	// LogLevel is the minimum level of the log messages shown.
+	// One of "panic", "fatal", "error", "warn", "info", "debug" or "trace".
+	// Overridden by the flags --log-level and --debug.
+	// Can be overridden in the individual kubeconfig store configuration
+	// default: info
+	// + optional
+	LogLevel *string `yaml:"logLevel"`
+	// LogFormat is the format of the log messages.
+	// Use "json" to write one JSON object per log message that can be parsed by automation.
+	// Overridden by the flag --log-format.
+	// default: console
+	// + optional
+	LogFormat *LogFormat `yaml:"logFormat"`
 	// Hooks defines configurations for commands that shall be executed prior to the search
 	Hooks []Hook `yaml:"hooks"`
+	// EncryptTemporaryKubeconfigs configures if the credentials in the temporary kubeconfig files are encrypted.
+	// The credentials are replaced with an exec credential plugin that decrypts them when needed.
+	// defaults to false
+	// + optional
+	EncryptTemporaryKubeconfigs *bool `yaml:"encryptTemporaryKubeconfigs"`
+	// TemporaryKubeconfigEncryptionKey configures where the key encrypting the temporary kubeconfig files is stored.
+	// + optional
+	TemporaryKubeconfigEncryptionKey *EncryptionKeyConfig `yaml:"temporaryKubeconfigEncryptionKey"`
+	// ThinKubeconfigs configures if the temporary kubeconfig files are written without the credentials of the users.
+	// The credentials are replaced with an exec credential plugin that retrieves them from the kubeconfig store
+	// only when kubectl needs them, and again once they expired.
+	// Takes precedence over encryptTemporaryKubeconfigs, as no credentials remain to be encrypted.
+	// defaults to false
+	// + optional
+	ThinKubeconfigs *bool `yaml:"thinKubeconfigs"`
+	// EncryptIndex configures the encryption of the search index and the database cache at rest.
+	// The index is only decrypted in memory.
+	// + optional
+	EncryptIndex *EncryptionKeyConfig `yaml:"encryptIndex"`
+	// Clean configures the garbage collection of temporary kubeconfig files
+	// + optional
+	Clean *CleanConfig `yaml:"clean"`
+	// Audit configures the audit log recording every context switch
+	// + optional
+	Audit *AuditConfig `yaml:"audit"`
+	// Webhooks are notified when switching to a protected context (see protectedContexts),
+	// e.g. to post the user, host and time of production access to a team channel
+	// + optional
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+	// GlobalMode configures writing the new context to the default kubeconfig in addition to the temporary kubeconfig
+	// of the terminal, for tools that ignore the KUBECONFIG environment variable. Can be enabled per switch with --global.
+	// + optional
+	GlobalMode *GlobalModeConfig `yaml:"globalMode"`
+	// Symlink configures a symlink at a stable path pointing to the temporary kubeconfig of the last switch,
+	// for IDEs and tools that are configured once with a kubeconfig path
+	// + optional
+	Symlink *SymlinkConfig `yaml:"symlink"`
+	// Sync configures the backend "switch sync" synchronizes the history and the aliases with
+	// + optional
+	Sync *SyncConfig `yaml:"sync"`
+	// SharedIndex configures the location of the index of the kubeconfig stores shared within a team.
+	// "switch index publish" publishes the index without credentials, "switch index pull" merges it into the local index,
+	// so that the contexts are listed before the kubeconfig stores have been searched.
+	// + optional
+	SharedIndex *SharedIndexConfig `yaml:"sharedIndex"`
+	// Dashboard configures the local dashboard started by "switch dashboard"
+	// + optional
+	Dashboard *DashboardConfig `yaml:"dashboard"`
+	// Includes are paths to other SwitchConfig files that are merged into this configuration.
+	// Relative paths are resolved relative to this file. Glob patterns are supported.
+	// Fields set in this file take precedence over the included files.
+	// + optional
+	Includes []string `yaml:"includes"`
+	// LoadProjectConfig configures if the project SwitchConfig ".switch-config.yaml" is loaded from the current working directory or its parent directories.
+	// Only has an effect in the system-wide or user SwitchConfig.
+	// The project SwitchConfig can only set presentation and search settings, but e.g. no kubeconfig stores, hooks or keybindings.
+	// defaults to false
+	// + optional
+	LoadProjectConfig *bool `yaml:"loadProjectConfig"`
+	// Profiles group kubeconfig stores. If a profile is selected, only the kubeconfig stores of the profile are used.
+	// A profile can be selected via the flag --profile or the environment variable SWITCH_PROFILE
+	// + optional
+	Profiles []Profile `yaml:"profiles"`
 	// KubeconfigStores contains the configuration for kubeconfig stores
 	KubeconfigStores []KubeconfigStore `yaml:"kubeconfigStores"`
 }
 
+// Profile is a named group of kubeconfig stores, e.g. "work" or "oncall"
+type Profile struct {
+	// Name is the name of the profile
+	Name string `yaml:"name"`
+	// Stores references the kubeconfig stores of the profile.
+	// A kubeconfig store is referenced by its ID, its kind (if the store has no ID) or "<kind>.<id>".
+	Stores []string `yaml:"stores"`
+}
+
+// Environment is a group of contexts shown in the same color, e.g. "production"
+type Environment struct {
+	// Name is the name of the environment
+	Name string `yaml:"name"`
+	// Color is the color of the contexts of the environment.
+	// Either one of "red", "orange", "yellow", "green", "cyan", "blue", "magenta", "white" and "gray",
+	// an ANSI 256 color code like "196" or a hex color like "#ff0000".
+	Color string `yaml:"color"`
+	// Contexts are wildcard patterns for the names (or aliases) of the contexts of the environment, e.g. "*prod*"
+	// + optional
+	Contexts []string `yaml:"contexts"`
+	// Tags match contexts whose kubeconfig is tagged with all of the given tags by the kubeconfig store, e.g. "env: prod"
+	// + optional
+	Tags map[string]string `yaml:"tags"`
+}
+
+// AliasRule generates the aliases of the matching contexts from a template
+type AliasRule struct {
+	// Template is a Go template generating the alias.
+	// Available fields: .StoreID, .StoreKind, .Path, .Prefix, .Name, .Context, .Account, .Region, .Cluster, .Tags and .CloudTags.
+	// The rule is skipped for a context if the template uses a tag that is not set.
+	Template string `yaml:"template"`
+	// Contexts are wildcard patterns for the names of the contexts the rule applies to, e.g. "gke_*"
+	// defaults to all contexts
+	// + optional
+	Contexts []string `yaml:"contexts"`
+	// Stores references the kubeconfig stores whose contexts the rule applies to.
+	// A kubeconfig store is referenced by its ID, its kind (if the store has no ID) or "<kind>.<id>".
+	// defaults to all kubeconfig stores
+	// + optional
+	Stores []string `yaml:"stores"`
+	// Tags restricts the rule to contexts whose kubeconfig is tagged with all of the given tags by the kubeconfig store, e.g. "tag:env: prod"
+	// + optional
+	Tags map[string]string `yaml:"tags"`
+}
+
+// RepositoryContext maps a Git repository to a context and an optional namespace
+type RepositoryContext struct {
+	// Remote is a wildcard pattern matched against the remote URLs of the repository.
+	// The URLs are matched without scheme, user and ".git" suffix, e.g. "github.com/acme/payments-*"
+	// + optional
+	Remote *string `yaml:"remote"`
+	// Path is a wildcard pattern matched against the root directory of the repository, e.g. "~/src/payments"
+	// + optional
+	Path *string `yaml:"path"`
+	// Context is the name (or alias) of the context to switch to
+	Context string `yaml:"context"`
+	// Namespace is the namespace to set for the context
+	// + optional
+	Namespace *string `yaml:"namespace"`
+}
+
+// Keybinding binds a key to either a built-in action, a custom command or a tool opened for the highlighted context of the "tui" picker
+type Keybinding struct {
+	// Key is the key, e.g. "ctrl+o", "alt+d", "enter", "space", "f2" or "y"
+	Key string `yaml:"key"`
+	// Action is the built-in action bound to the key.
+	// Replaces the default keys of the action.
+	// Possible values: "select", "abort", "up", "down", "page-up", "page-down", "clear-query", "toggle-focus", "toggle-store", "copy-kubeconfig", "open-console"
+	// + optional
+	Action *PickerAction `yaml:"action"`
+	// Command is an inline shell command executed for the highlighted context (sh -c, or cmd /C on Windows).
+	// The context is provided in the environment variables KUBESWITCH_CONTEXT, KUBESWITCH_STORE_ID, KUBESWITCH_STORE_KIND, KUBESWITCH_PATH and KUBESWITCH_TAG_<KEY>
+	// + optional
+	Command *string `yaml:"command"`
+	// Open is an inline shell command started in the foreground of the terminal for the highlighted context, e.g. "k9s".
+	// KUBECONFIG is set to a temporary kubeconfig of the context, which is removed when the command exits.
+	// The picker is suspended while the command runs.
+	// The context is provided in the same environment variables as for commands
+	// + optional
+	Open *string `yaml:"open"`
+	// Description describes the command in the footer of the picker
+	// + optional
+	Description *string `yaml:"description"`
+}
+
+// EncryptionKeyConfig configures where an encryption key is stored.
+// The environment variable SWITCH_ENCRYPTION_KEY takes precedence over the key source.
+type EncryptionKeyConfig struct {
+	// KeySource is where the encryption key is read from.
+	// "file" stores the key unencrypted and has to be configured explicitly.
+	// Possible values: "keychain", "age", "file"
+	// defaults to "keychain"
+	// + optional
+	KeySource *EncryptionKeySource `yaml:"keySource"`
+	// AgeIdentity is the path to the age identity file used to encrypt and decrypt the encryption key.
+	// Required for the key source "age"
+	// + optional
+	AgeIdentity *string `yaml:"ageIdentity"`
+}
+
+// CleanConfig configures the garbage collection of the temporary kubeconfig files
+// Temporary kubeconfig files that are still used by a running process (e.g a terminal session) are never deleted.
+type CleanConfig struct {
+	// MaxAge is the duration after which a temporary kubeconfig file is deleted
+	// + optional
+	MaxAge *time.Duration `yaml:"maxAge"`
+	// MaxTotalSize caps the total size of the temporary kubeconfig directory, e.g "10Mi".
+	// If exceeded, the oldest temporary kubeconfig files are deleted first.
+	// + optional
+	MaxTotalSize *string `yaml:"maxTotalSize"`
+	// Auto configures if the garbage collection runs automatically on each invocation of kubeswitch.
+	// Only supported on systems with /proc (e.g. Linux), where the temporary kubeconfig files used by other terminal sessions can be detected.
+	// defaults to false
+	// + optional
+	Auto *bool `yaml:"auto"`
+}
+
+// AuditConfig configures the audit log recording every context switch as one JSON object per line
+type AuditConfig struct {
+	// Enabled configures if context switches are recorded
+	// defaults to false
+	// + optional
+	Enabled *bool `yaml:"enabled"`
+	// Path is the path of the audit log file
+	// defaults to "switch.audit.jsonl" in the state directory
+	// + optional
+	Path *string `yaml:"path"`
+	// MaxSize is the size after which the audit log file is rotated, e.g "10Mi"
+	// defaults to 10Mi
+	// + optional
+	MaxSize *string `yaml:"maxSize"`
+	// MaxBackups is the number of rotated audit log files that are kept
+	// defaults to 5
+	// + optional
+	MaxBackups *int `yaml:"maxBackups"`
+}
+
+// WebhookConfig configures a webhook notified when switching to a protected context
+type WebhookConfig struct {
+	// URL is the http(s) URL the payload is posted to.
+	// Can be a secret reference (env://, file:// or cmd://), as e.g. Slack webhook URLs contain a secret.
+	URL string `yaml:"url"`
+	// Format is the format of the payload
+	// defaults to "generic"
+	// + optional
+	Format *WebhookFormat `yaml:"format"`
+	// Headers are added to the request, e.g. "Authorization".
+	// The values can be secret references (env://, file:// or cmd://).
+	// + optional
+	Headers map[string]string `yaml:"headers"`
+	// Contexts are wildcard patterns of context names (or aliases) the webhook is notified for
+	// defaults to protectedContexts
+	// + optional
+	Contexts []string `yaml:"contexts"`
+	// Timeout is the maximum duration of the request. The switch is not delayed longer than the timeout.
+	// defaults to 3s
+	// + optional
+	Timeout *time.Duration `yaml:"timeout"`
+}
+
+// GlobalModeConfig configures writing the new context to the default kubeconfig
+type GlobalModeConfig struct {
+	// Enabled configures if every switch sets the current context of the default kubeconfig.
+	// The context is merged into the default kubeconfig with its cluster and user if it does not exist there.
+	// defaults to false
+	// + optional
+	Enabled *bool `yaml:"enabled"`
+	// Path is the path of the default kubeconfig
+	// defaults to "~/.kube/config"
+	// + optional
+	Path *string `yaml:"path"`
+}
+
+// SymlinkConfig configures the symlink pointing to the temporary kubeconfig of the last switch
+type SymlinkConfig struct {
+	// Enabled configures if every switch points the symlink to the new temporary kubeconfig
+	// defaults to false
+	// + optional
+	Enabled *bool `yaml:"enabled"`
+	// Path is the path of the symlink
+	// defaults to "~/.kube/switch-current"
+	// + optional
+	Path *string `yaml:"path"`
+	// PerTerminal maintains a symlink per terminal, suffixed with the name of the terminal (e.g. "~/.kube/switch-current-pts-3"),
+	// instead of a single symlink following the last switch in any terminal.
+	// Switches without terminal update the symlink without suffix.
+	// defaults to false
+	// + optional
+	PerTerminal *bool `yaml:"perTerminal"`
+}
+
+// SyncConfig configures the backend the user state is synchronized with across machines
+type SyncConfig struct {
+	// Kind is the kind of the sync backend
+	// possible values are "git", "s3" and "gcs"
+	Kind SyncKind `yaml:"kind"`
+	// URL is the URL of the Git repository (e.g "git@github.com:user/kubeswitch-state.git"),
+	// or of the object in the bucket (e.g "s3://bucket/kubeswitch/state.yaml" or "gs://bucket/kubeswitch/state.yaml")
+	URL string `yaml:"url"`
+	// Path is the path of the state file in the Git repository
+	// defaults to "kubeswitch-state.yaml"
+	// + optional
+	Path *string `yaml:"path"`
+}
+
+// AccessCheckConfig configures checking whether the clusters accept the credentials of the user
+type AccessCheckConfig struct {
+	// Mode configures how contexts are shown whose cluster rejected the credentials
+	// possible values are "dim" and "hide"
+	// defaults to "dim"
+	// + optional
+	Mode *AccessCheckMode `yaml:"mode"`
+	// CacheDuration is the duration the result of the check of a context is cached in the search index
+	// before its cluster is checked again
+	// defaults to 24h
+	// + optional
+	CacheDuration *time.Duration `yaml:"cacheDuration"`
+}
+
+// KubernetesVersionConfig configures showing the Kubernetes version of the clusters
+type KubernetesVersionConfig struct {
+	// Show adds a column with the Kubernetes version of the cluster to the picker
+	// defaults to false
+	// + optional
+	Show *bool `yaml:"show"`
+	// MinSupported is the minimum supported Kubernetes version, e.g. "1.28".
+	// Contexts of clusters with an older Kubernetes version are highlighted in the picker.
+	// + optional
+	MinSupported *string `yaml:"minSupported"`
+}
+
+// OfflineConfig configures the detection of a missing network connection
+type OfflineConfig struct {
+	// Detect enables the offline mode automatically if the network is unavailable
+	// defaults to false
+	// + optional
+	Detect *bool `yaml:"detect"`
+	// ProbeAddress is a TCP address (host:port) that has to be reachable, e.g. a kubeconfig store only reachable via VPN.
+	// defaults to checking whether there is a network route
+	// + optional
+	ProbeAddress *string `yaml:"probeAddress"`
+	// ProbeTimeout is the maximum duration of connecting to the probe address
+	// defaults to 1s
+	// + optional
+	ProbeTimeout *time.Duration `yaml:"probeTimeout"`
+}
+
+// SharedIndexConfig configures the location of the index shared within a team
+type SharedIndexConfig struct {
+	// Kind is the kind of the backend storing the shared index
+	// possible values are "git", "s3" and "gcs"
+	Kind SyncKind `yaml:"kind"`
+	// URL is the URL of the Git repository (e.g "git@github.com:org/kubeswitch-index.git"),
+	// or of the object in the bucket (e.g "s3://bucket/kubeswitch/index.yaml" or "gs://bucket/kubeswitch/index.yaml")
+	URL string `yaml:"url"`
+	// Path is the path of the index file in the Git repository
+	// defaults to "kubeswitch-index.yaml"
+	// + optional
+	Path *string `yaml:"path"`
+	// Stores are the IDs of the kubeconfig stores (e.g "vault.default") whose contexts are published.
+	// Contexts of filesystem stores are never published, as their paths only exist on the local machine.
+	// defaults to all kubeconfig stores
+	// + optional
+	Stores []string `yaml:"stores"`
+}
+
+// DashboardConfig configures the local dashboard started by "switch dashboard"
+type DashboardConfig struct {
+	// Tool is the dashboard to start
+	// possible values are "headlamp" and "octant"
+	// defaults to "headlamp"
+	// + optional
+	Tool *DashboardTool `yaml:"tool"`
+	// Command is an inline shell command starting another dashboard instead of the tool (sh -c, or cmd /C on Windows).
+	// The command can use Go templates with the fields .Kubeconfig, .Context and .Port
+	// + optional
+	Command *string `yaml:"command"`
+	// Port is the local port the dashboard listens on, if supported by the tool
+	// defaults to 7777
+	// + optional
+	Port *int `yaml:"port"`
+}
+
 type KubeconfigStore struct {
 	// ID is the ID of the kubeconfig store.
 	// Used to write distinct index files for each store
@@ -112,6 +920,28 @@ type KubeconfigStore struct {
 	// Not setting this field will cause kubeswitch to not use an index
 	// + optional
 	RefreshIndexAfter *time.Duration `yaml:"refreshIndexAfter"`
+	// StaleWhileRevalidate defines if an outdated index of this kubeconfig store is read instead of searching the kubeconfig store.
+	// The index is then refreshed in a background process for the next search.
+	// Requires refreshIndexAfter to be set for the store or globally
+	// + optional
+	StaleWhileRevalidate *bool `yaml:"staleWhileRevalidate"`
+	// ExcludePatterns are matched against the kubeconfig path and the name of each context found in this kubeconfig store
+	// in addition to the global exclude patterns.
+	// + optional
+	ExcludePatterns []string `yaml:"excludePatterns"`
+	// SearchTimeout is the maximum duration of the search of this kubeconfig store.
+	// The time waiting for a free slot if the searchConcurrency is limited does not count.
+	// + optional
+	SearchTimeout *time.Duration `yaml:"searchTimeout"`
+	// MaxConcurrency is the maximum number of list and read calls of this kubeconfig store to its backing API running at once,
+	// e.g. to not overwhelm or get rate-limited by a Rancher instance or a Vault with many paths.
+	// Unlimited if not set.
+	// + optional
+	MaxConcurrency *int `yaml:"maxConcurrency"`
+	// LogLevel is the minimum level of the log messages of this kubeconfig store.
+	// Overrides the global log level, e.g to show debug logs of a single misbehaving store.
+	// + optional
+	LogLevel *string `yaml:"logLevel"`
 	// Required defines if errors when initializing this store should be logged
 	// defaults to true
 	// useful when configuring a kubeconfig store that is not always available
@@ -122,6 +952,11 @@ type KubeconfigStore struct {
 	// ShowPrefix configures if the search result should include store specific prefix (e.g for the filesystem store the parent directory name)
 	// default: true
 	ShowPrefix *bool `yaml:"showPrefix"`
+	// ContextNameTemplate is a Go template to generate the context names of this store instead of the <prefix>/<context> format.
+	// Available fields: .StoreID, .StoreKind, .Path, .Prefix, .Name, .Context, .Account, .Region, .Cluster, .Tags and .CloudTags
+	// Aliases defined with `switch alias` take precedence.
+	// + optional
+	ContextNameTemplate *string `yaml:"contextNameTemplate"`
 	// Config is store-specific configuration.
 	// Please check the documentation for each backing provider to see what configuration is
 	// possible here
@@ -129,6 +964,320 @@ type KubeconfigStore struct {
 	// Cache allows to cache the kubeconfigs in the backing store
 	// + optional
 	Cache *Cache `yaml:"cache"`
+	// FailoverStores are references to kubeconfig stores containing the same clusters, e.g. a mirror of this store.
+	// If this store cannot be searched or fails to return a kubeconfig, the failover stores are tried in the given order.
+	// A store is referenced by its ID, its kind (if the store has no ID) or "<kind>.<id>".
+	// Failover stores are not searched on their own and use the ID, index and cache of this store.
+	// + optional
+	FailoverStores []string `yaml:"failoverStores"`
+	// Proxy routes the traffic to the API servers of the contexts of this store through a proxy,
+	// e.g. the SOCKS5 proxy of an SSH tunnel to a jump host. The contexts patterns of the proxy are ignored.
+	// + optional
+	Proxy *Proxy `yaml:"proxy"`
+	// Impersonate configures the temporary kubeconfigs of this store to impersonate a user and groups by default.
+	// Overridden by the flags --as and --as-group.
+	// + optional
+	Impersonate *Impersonation `yaml:"impersonate"`
+	// RateLimit configures the client-side rate limit and the retries of the requests to the API of the cloud provider.
+	// Only supported by the stores of cloud providers. Rate limiting and retries are enabled with the defaults if not configured.
+	// + optional
+	RateLimit *RateLimit `yaml:"rateLimit"`
+	// HTTPClient configures the proxy and the TLS configuration of the requests to the API of the cloud provider, Rancher or Vault,
+	// e.g. for a corporate TLS-intercepting proxy or a private endpoint, independent of the environment variables HTTPS_PROXY and SSL_CERT_FILE.
+	// Not applied to the API servers of the contexts. Only supported by the stores of cloud providers and the Rancher and Vault stores.
+	// + optional
+	HTTPClient *HTTPClient `yaml:"httpClient"`
+	// CloudTags are the keys of the tags (labels) of the clusters to add to the metadata of their contexts, e.g. owner, team and cost-center.
+	// The tags are shown as additional columns in the picker and by "switch list-contexts -o json",
+	// and are available as "tag:<key>" in the tags of the context name template and the environments.
+	// Keys are matched case-insensitively. Only supported by the EKS, GKE, Azure and Exoscale stores.
+	// The EKS store describes each cluster to read its tags.
+	// + optional
+	CloudTags []string `yaml:"cloudTags"`
+	// OIDC configures the built-in OIDC login for the kubeconfigs of this store.
+	// Users of the kubeconfigs calling kubelogin (kubectl oidc-login) are replaced with an exec credential plugin
+	// calling kubeswitch, which runs the OIDC flow and caches and refreshes the tokens itself.
+	// + optional
+	OIDC *OIDCConfig `yaml:"oidc"`
+	// CloudflareAccess configures the login to API servers protected by Cloudflare Access (Zero Trust).
+	// The credentials of all users of the kubeconfigs are replaced with an exec credential plugin calling kubeswitch,
+	// which obtains the Cloudflare Access token with cloudflared and logs in with the browser if needed.
+	// + optional
+	CloudflareAccess *CloudflareAccess `yaml:"cloudflareAccess"`
+	// KubeconfigProvider configures how the kubeconfigs of the store provide the credentials of their users.
+	// With "static", the kubeconfigs contain the long-lived credentials returned by the API of the cloud provider.
+	// With "exec", the kubeconfigs contain no credentials, but an exec credential plugin calling "switcher exec-credential",
+	// which mints short-lived credentials with the API of the cloud provider only when the client needs them.
+	// "exec" is only supported by the Exoscale store.
+	// default: static
+	// + optional
+	KubeconfigProvider *KubeconfigProvider `yaml:"kubeconfigProvider"`
+}
+
+// Proxy configures the proxy-url of the clusters in the temporary kubeconfigs
+type Proxy struct {
+	// Contexts are wildcard patterns for the names (or aliases) of the contexts using the proxy, e.g. "*-private"
+	// + optional
+	Contexts []string `yaml:"contexts"`
+	// URL is set as proxy-url of the clusters, e.g. "socks5://localhost:1080"
+	URL string `yaml:"url"`
+	// SSH starts an SSH tunnel to a jump host providing the SOCKS5 proxy of the URL
+	// when switching to a context using the proxy, unless the proxy is already running.
+	// + optional
+	SSH *SSHTunnel `yaml:"ssh"`
+	// Cloudflared starts a Cloudflare Access TCP tunnel (cloudflared access tcp) providing the SOCKS5 proxy of the URL
+	// when switching to a context using the proxy, unless the proxy is already running. Cannot be combined with ssh.
+	// + optional
+	Cloudflared *CloudflaredTunnel `yaml:"cloudflared"`
+}
+
+// SSHTunnel is an SSH connection to a jump host forwarding the local port of the proxy URL as SOCKS5 proxy (ssh -D)
+type SSHTunnel struct {
+	// Host is the destination of ssh, e.g. "user@bastion.example.com" or a host of ~/.ssh/config
+	Host string `yaml:"host"`
+	// Args are additional arguments of ssh, e.g. ["-i", "~/.ssh/bastion"]
+	// + optional
+	Args []string `yaml:"args"`
+	// StartTimeout is the maximum duration to wait for the tunnel to accept connections
+	// default: 10s
+	// + optional
+	StartTimeout *time.Duration `yaml:"startTimeout"`
+}
+
+// CloudflaredTunnel is a Cloudflare Access TCP tunnel forwarding the local port of the proxy URL
+// to a Cloudflare Tunnel exposing the API server as SOCKS5 proxy (proxyType: socks)
+type CloudflaredTunnel struct {
+	// Hostname is the hostname of the Cloudflare Access application, e.g. "k8s.example.com"
+	Hostname string `yaml:"hostname"`
+	// Executable is the path of cloudflared
+	// default: cloudflared
+	// + optional
+	Executable *string `yaml:"executable"`
+	// StartTimeout is the maximum duration to wait for the tunnel to accept connections
+	// default: 10s
+	// + optional
+	StartTimeout *time.Duration `yaml:"startTimeout"`
+}
+
+// CloudflareAccess configures the login to API servers protected by Cloudflare Access
+type CloudflareAccess struct {
+	// Application is the URL of the Cloudflare Access application, e.g. "https://k8s.example.com".
+	// default: the API server URL of the cluster
+	// + optional
+	Application *string `yaml:"application"`
+	// Executable is the path of cloudflared
+	// default: cloudflared
+	// + optional
+	Executable *string `yaml:"executable"`
+}
+
+// OIDCGrantType is the flow used to obtain the tokens from the OIDC provider
+type OIDCGrantType string
+
+const (
+	// OIDCGrantTypeBrowser is the authorization code flow with PKCE, opening the browser
+	OIDCGrantTypeBrowser OIDCGrantType = "browser"
+	// OIDCGrantTypeDeviceCode is the device authorization flow, e.g. for machines without a browser
+	OIDCGrantTypeDeviceCode OIDCGrantType = "device-code"
+)
+
+// OIDCConfig configures the built-in OIDC login
+type OIDCConfig struct {
+	// IssuerURL is the URL of the OIDC provider.
+	// If set, the credentials of all users of the kubeconfigs are replaced with the built-in OIDC login.
+	// Otherwise, only users calling kubelogin are replaced and the issuer is taken from its arguments.
+	// + optional
+	IssuerURL *string `yaml:"issuerURL"`
+	// ClientID is the ID of the OIDC client. Required if the issuerURL is set.
+	// + optional
+	ClientID *string `yaml:"clientID"`
+	// ClientSecret is the secret of the OIDC client. Not needed for public clients.
+	// + optional
+	ClientSecret *string `yaml:"clientSecret"`
+	// ExtraScopes are requested in addition to the scopes "openid" and "offline_access"
+	// + optional
+	ExtraScopes []string `yaml:"extraScopes"`
+	// GrantType is the flow used to obtain the tokens. Either "browser" or "device-code".
+	// default: browser
+	// + optional
+	GrantType *OIDCGrantType `yaml:"grantType"`
+	// ListenAddress is the address of the local server receiving the redirect of the browser flow.
+	// The redirect URL http://localhost:<port> has to be allowed for the OIDC client.
+	// default: 127.0.0.1:8000
+	// + optional
+	ListenAddress *string `yaml:"listenAddress"`
+}
+
+// KubeconfigPolicy are security baselines enforced for the kubeconfigs of all kubeconfig stores
+type KubeconfigPolicy struct {
+	// StripInsecureSkipTLSVerify removes insecure-skip-tls-verify from all clusters,
+	// so that the certificate of the API server is always verified.
+	// default: false
+	// + optional
+	StripInsecureSkipTLSVerify *bool `yaml:"stripInsecureSkipTLSVerify"`
+	// ForbidStaticTokensForProtectedContexts fails the switch to a protected context (see protectedContexts)
+	// if its user authenticates with a static token (token or tokenFile).
+	// default: false
+	// + optional
+	ForbidStaticTokensForProtectedContexts *bool `yaml:"forbidStaticTokensForProtectedContexts"`
+	// ProxyURL is set as proxy-url of all clusters, e.g. to force the traffic to the API servers through a corporate proxy.
+	// + optional
+	ProxyURL *string `yaml:"proxyURL"`
+	// MaxCredentialsTTL fails the switch if the credentials (client certificate or token) of the context
+	// are valid for longer than the given duration, e.g. to only allow short-lived credentials.
+	// Credentials without a known expiry are not checked.
+	// + optional
+	MaxCredentialsTTL *time.Duration `yaml:"maxCredentialsTTL"`
+}
+
+// Tailscale configures the access to clusters reachable over a Tailscale network (tailnet)
+type Tailscale struct {
+	// Contexts are wildcard patterns of context names (or aliases) whose clusters are only reachable over the tailnet,
+	// in addition to the clusters whose API servers are detected as tailnet addresses or MagicDNS names.
+	// Switching to such a context fails if tailscaled is not running or not connected to the tailnet.
+	// + optional
+	Contexts []string `yaml:"contexts"`
+	// RewriteServers rewrites API server addresses that are tailnet IP addresses to the MagicDNS name of the peer,
+	// e.g. "https://100.101.102.103:443" to "https://my-cluster.tailnet-1234.ts.net:443".
+	// default: true
+	// + optional
+	RewriteServers *bool `yaml:"rewriteServers"`
+	// Executable is the path of the tailscale CLI used to query the status of the tailnet.
+	// default: tailscale
+	// + optional
+	Executable *string `yaml:"executable"`
+}
+
+// BoundaryTarget is a HashiCorp Boundary target forwarding to the API server of a cluster
+type BoundaryTarget struct {
+	// Contexts are wildcard patterns for the names (or aliases) of the contexts using the target, e.g. "prod-*"
+	Contexts []string `yaml:"contexts"`
+	// TargetID is the ID of the target, e.g. "ttcp_1234567890". Either the target ID or the target name is required.
+	// + optional
+	TargetID string `yaml:"targetID"`
+	// TargetName is the name of the target. Requires the target scope ID or name.
+	// + optional
+	TargetName string `yaml:"targetName"`
+	// TargetScopeID is the ID of the scope of the target name, e.g. "p_1234567890"
+	// + optional
+	TargetScopeID string `yaml:"targetScopeID"`
+	// TargetScopeName is the name of the scope of the target name
+	// + optional
+	TargetScopeName string `yaml:"targetScopeName"`
+	// Addr is the address of the Boundary controller.
+	// default: the environment variable BOUNDARY_ADDR
+	// + optional
+	Addr *string `yaml:"addr"`
+	// Executable is the path of the boundary CLI
+	// default: boundary
+	// + optional
+	Executable *string `yaml:"executable"`
+	// StartTimeout is the maximum duration to wait for the session to accept connections
+	// default: 30s
+	// + optional
+	StartTimeout *time.Duration `yaml:"startTimeout"`
+}
+
+// Impersonation is the identity the user of a kubeconfig impersonates
+type Impersonation struct {
+	// User is the user to impersonate
+	// + optional
+	User string `yaml:"user"`
+	// Groups are the groups to impersonate
+	// + optional
+	Groups []string `yaml:"groups"`
+}
+
+// CircuitBreaker configures skipping kubeconfig stores whose searches failed repeatedly
+type CircuitBreaker struct {
+	// Failures is the number of consecutive failed searches after which a kubeconfig store is skipped.
+	// A search fails if it returns an error without finding any context or if it times out.
+	// default: 3
+	// + optional
+	Failures *int `yaml:"failures"`
+	// Cooldown is how long a kubeconfig store is skipped. Afterwards, the kubeconfig store is searched again.
+	// If this search fails as well, the kubeconfig store is skipped for another cooldown.
+	// default: 10m
+	// + optional
+	Cooldown *time.Duration `yaml:"cooldown"`
+}
+
+// LogFormat is the format of the log messages
+type LogFormat string
+
+const (
+	// LogFormatConsole writes human-readable log messages
+	LogFormatConsole LogFormat = "console"
+	// LogFormatJSON writes one JSON object per log message
+	LogFormatJSON LogFormat = "json"
+)
+
+// HTTPTransport configures the HTTP transport shared by the kubeconfig stores
+type HTTPTransport struct {
+	// MaxIdleConns is the maximum number of idle (keep-alive) connections across all hosts.
+	// Set to 0 for no limit.
+	// default: 100
+	// + optional
+	MaxIdleConns *int `yaml:"maxIdleConns"`
+	// MaxIdleConnsPerHost is the maximum number of idle (keep-alive) connections per host
+	// default: 10
+	// + optional
+	MaxIdleConnsPerHost *int `yaml:"maxIdleConnsPerHost"`
+	// MaxConnsPerHost is the maximum number of connections per host, including connections that are in use.
+	// Requests exceeding the limit wait for a connection. Set to 0 for no limit.
+	// default: 0
+	// + optional
+	MaxConnsPerHost *int `yaml:"maxConnsPerHost"`
+	// IdleConnTimeout is how long an idle (keep-alive) connection is kept open
+	// default: 90s
+	// + optional
+	IdleConnTimeout *time.Duration `yaml:"idleConnTimeout"`
+	// DisableKeepAlives disables the reuse of connections. Every request establishes a new connection.
+	// default: false
+	// + optional
+	DisableKeepAlives *bool `yaml:"disableKeepAlives"`
+	// DisableHTTP2 disables HTTP/2. Otherwise, HTTP/2 is used if supported by the server,
+	// so that concurrent requests to the same host share a single connection.
+	// default: false
+	// + optional
+	DisableHTTP2 *bool `yaml:"disableHTTP2"`
+}
+
+// HTTPClient configures the HTTP client of a kubeconfig store
+type HTTPClient struct {
+	// Proxy is the URL of the proxy for the requests of the store, e.g. "http://proxy.example.com:3128".
+	// The schemes http, https and socks5 are supported.
+	// default: the environment variables HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// + optional
+	Proxy *string `yaml:"proxy"`
+	// CAFile is the path to a PEM file with the certificate authorities trusted in addition to the system certificate authorities,
+	// e.g. the certificate authority of a TLS-intercepting proxy or of a private endpoint.
+	// + optional
+	CAFile *string `yaml:"caFile"`
+	// CertFile is the path to the PEM encoded client certificate presented to the API. Requires the keyFile.
+	// + optional
+	CertFile *string `yaml:"certFile"`
+	// KeyFile is the path to the PEM encoded private key of the client certificate. Requires the certFile.
+	// + optional
+	KeyFile *string `yaml:"keyFile"`
+}
+
+// RateLimit configures the client-side rate limit and the retries of the requests of a kubeconfig store
+type RateLimit struct {
+	// RequestsPerSecond is the maximum average number of requests per second
+	// default: 10
+	// + optional
+	RequestsPerSecond *float64 `yaml:"requestsPerSecond"`
+	// Burst is the maximum number of requests sent at once
+	// default: 20
+	// + optional
+	Burst *int `yaml:"burst"`
+	// MaxRetries is the maximum number of retries of a throttled (429) or failed (5xx) request.
+	// Retries are delayed with an exponential backoff with jitter, or as requested by the Retry-After header of the response.
+	// Set to 0 to disable retries.
+	// default: 5
+	// + optional
+	MaxRetries *int `yaml:"maxRetries"`
 }
 
 // CacheConfig contains the configuration for the cache
@@ -144,6 +1293,10 @@ type StoreConfigVault struct {
 	VaultAPIAddress    string `yaml:"vaultAPIAddress"`
 	VaultEngineVersion string `yaml:"vaultEngineVersion"`
 	VaultKeyKubeconfig string `yaml:"vaultKeyKubeconfig"`
+	// VaultToken is the Vault API token.
+	// Only used if neither the token file nor the environment variable VAULT_TOKEN provide a token.
+	// + optional
+	VaultToken string `yaml:"vaultToken"`
 }
 
 type StoreConfigGardener struct {
@@ -251,6 +1404,31 @@ type GKEAuthentication struct {
 type StoreConfigExoscale struct {
 	ExoscaleAPIKey    string `yaml:"exoscaleAPIKey"`
 	ExoscaleSecretKey string `yaml:"exoscaleSecretKey"`
+	// Zones restricts the discovery of SKS clusters to the given zones, e.g. ch-gva-2 and de-fra-1
+	// defaults to all zones
+	// + optional
+	Zones []string `yaml:"zones"`
+	// Kubeconfig configures the client certificates of the generated kubeconfigs
+	// + optional
+	Kubeconfig *ExoscaleKubeconfig `yaml:"kubeconfig"`
+}
+
+// ExoscaleKubeconfig configures the client certificates of the kubeconfigs generated by the Exoscale store,
+// e.g. to issue read-only or team-scoped kubeconfigs bound to restricted roles instead of cluster-admin kubeconfigs
+type ExoscaleKubeconfig struct {
+	// User is the name of the user (common name of the client certificate)
+	// default: default
+	// + optional
+	User *string `yaml:"user"`
+	// Groups are the Kubernetes groups of the user (organizations of the client certificate).
+	// An empty list issues client certificates without groups, e.g. for role bindings of the user.
+	// defaults to [system:masters]
+	// + optional
+	Groups []string `yaml:"groups"`
+	// TTLSeconds is the validity of the client certificates in seconds
+	// defaults to 2592000 (30 days). With the kubeconfig provider "exec", the validity is capped at 3600 (1 hour).
+	// + optional
+	TTLSeconds *int64 `yaml:"ttlSeconds"`
 }
 
 type StoreConfigRancher struct {
@@ -288,3 +1466,35 @@ type StoreConfigPlugin struct {
 	CmdPath string   `yaml:"cmdPath"`
 	Args    []string `yaml:"args"`
 }
+
+type StoreConfigExec struct {
+	// Command is the executable implementing the exec store protocol
+	Command string `yaml:"command"`
+	// Args are the arguments with which the executable is called
+	// + optional
+	Args []string `yaml:"args"`
+	// Env contains additional environment variables for the executable
+	// + optional
+	Env map[string]string `yaml:"env"`
+	// Options are passed to the executable with each request
+	// + optional
+	Options map[string]interface{} `yaml:"options"`
+	// Timeout is the maximum duration of a single call of the executable
+	// defaults to 30s
+	// + optional
+	Timeout *time.Duration `yaml:"timeout"`
+}
+
+type StoreConfigMock struct {
+	// Clusters is the number of generated fake clusters
+	// defaults to 10
+	// + optional
+	Clusters *int `yaml:"clusters"`
+	// Server is the API server of all generated kubeconfigs, e.g. a local kind cluster.
+	// defaults to a unique, unreachable server per cluster
+	// + optional
+	Server *string `yaml:"server"`
+	// Latency delays the search and each kubeconfig to simulate a remote store
+	// + optional
+	Latency *time.Duration `yaml:"latency"`
+}
@@ -23,35 +23,117 @@ import (
 const (
 	// HookTypeExecutable defines a hook that contains an external binary executable to be called
 	HookTypeExecutable HookType = "Executable"
-	// HookTypeInlineCommand defines a hook that directly contains bourne shell
+	// HookTypeInlineCommand defines a hook that directly contains shell
 	// commands to be executed
 	HookTypeInlineCommand HookType = "InlineCommand"
+	// HookTypeTerminalTitle defines a built-in hook that sets the title of the terminal
+	// (and the badge in iTerm2) to the new context after a switch
+	HookTypeTerminalTitle HookType = "TerminalTitle"
+)
+
+const (
+	// HookTriggerPreSearch defines a hook that is executed prior to the search
+	HookTriggerPreSearch HookTrigger = "PreSearch"
+	// HookTriggerPostSwitch defines a hook that is executed after a successful switch to a context
+	HookTriggerPostSwitch HookTrigger = "PostSwitch"
+	// HookTriggerStoreFailure defines a hook that is executed when a kubeconfig store fails during the search
+	HookTriggerStoreFailure HookTrigger = "StoreFailure"
+)
+
+const (
+	// HookShellBash executes inline commands with bash
+	HookShellBash HookShell = "bash"
+	// HookShellSh executes inline commands with the POSIX shell sh
+	HookShellSh HookShell = "sh"
+	// HookShellPowerShell executes inline commands with Windows PowerShell
+	HookShellPowerShell HookShell = "powershell"
+	// HookShellPwsh executes inline commands with the cross-platform PowerShell
+	HookShellPwsh HookShell = "pwsh"
+	// HookShellCmd executes inline commands with the Windows command processor cmd.exe
+	HookShellCmd HookShell = "cmd"
 )
 
 // ValidHookTypes contains all valid hook types
-var ValidHookTypes = sets.NewString(string(HookTypeInlineCommand), string(HookTypeExecutable))
+var ValidHookTypes = sets.NewString(string(HookTypeInlineCommand), string(HookTypeExecutable), string(HookTypeTerminalTitle))
+
+// ValidHookTriggers contains all valid hook triggers
+var ValidHookTriggers = sets.NewString(string(HookTriggerPreSearch), string(HookTriggerPostSwitch), string(HookTriggerStoreFailure))
 
-// HookType is the type of hook (either "Executable" or "InlineCommand")
+// ValidHookShells contains all valid shells for inline commands
+var ValidHookShells = sets.NewString(string(HookShellBash), string(HookShellSh), string(HookShellPowerShell), string(HookShellPwsh), string(HookShellCmd))
+
+// HookType is the type of hook (either "Executable", "InlineCommand" or "TerminalTitle")
 type HookType string
 
+// HookTrigger defines when a hook is executed (either "PreSearch", "PostSwitch" or "StoreFailure")
+type HookTrigger string
+
+// HookShell is the shell executing the inline command of a hook (either "bash", "sh", "powershell", "pwsh" or "cmd")
+type HookShell string
+
 // Hook contains configurations for a Hook
 type Hook struct {
 	// Name is the name of the Hook
 	Name string `yaml:"name"`
-	// Type is the type of the Hook (either "Executable" or "InlineCommand")
+	// Type is the type of the Hook (either "Executable", "InlineCommand" or "TerminalTitle")
 	Type HookType `yaml:"type"`
+	// Trigger defines when the Hook is executed (either "PreSearch", "PostSwitch" or "StoreFailure")
+	// PostSwitch hooks get information about the new context and StoreFailure hooks about the failed kubeconfig store
+	// via environment variables and template fields
+	// defaults to "PreSearch" ("PostSwitch" for hooks of type "TerminalTitle")
+	// + optional
+	Trigger HookTrigger `yaml:"trigger"`
+	// Stores scopes the Hook to kubeconfig stores referenced by their ID, kind (if the store has no ID) or "<kind>.<id>".
+	// Scoped PreSearch hooks are executed prior to the search of the kubeconfig store and only if the store is used.
+	// Scoped PostSwitch and StoreFailure hooks are only executed for contexts of / failures of the referenced stores.
+	// + optional
+	Stores []string `yaml:"stores"`
 	// Path defines the path to the executable that shall be called when the
 	// Type is "Executable"
 	Path *string `yaml:"path"`
 	// Arguments are the arguments with which the external executable of a Hook of type
 	// "Executable" will be called
+	// Path and Arguments are expanded when the Hook is executed
 	Arguments []string `yaml:"arguments"`
+	// Shell is the shell executing the inline command of a Hook of type "InlineCommand"
+	// (either "bash", "sh", "powershell", "pwsh" or "cmd").
+	// For "bash" and "sh", the first argument is the command and the further arguments are its positional parameters.
+	// For the other shells, the arguments are commands executed one after another.
+	// defaults to "powershell" on Windows and "bash" otherwise
+	// + optional
+	Shell *HookShell `yaml:"shell"`
+	// Title is the title of the terminal set by a Hook of type "TerminalTitle".
+	// Expanded like the arguments, e.g. "k8s: {{ .Context }}".
+	// defaults to the context followed by the namespace in parentheses
+	// + optional
+	Title *string `yaml:"title"`
+	// Badge is the badge shown in iTerm2 set by a Hook of type "TerminalTitle". Expanded like the title.
+	// Set to an empty string to not show a badge.
+	// defaults to the title
+	// + optional
+	Badge *string `yaml:"badge"`
 	// Execution contains configuration regarding the execution of the Hook
-	Execution *struct {
-		// Interval defines the interval how often the Hook is executed
-		// if this field is not set, it can only be executed on demand with "switch hooks --hook-name <name>"
-		Interval *time.Duration `yaml:"interval"`
-	} `yaml:"execution"`
+	Execution *HookExecution `yaml:"execution"`
+}
+
+// HookExecution contains configuration regarding the execution of a Hook
+type HookExecution struct {
+	// Interval defines the interval how often the Hook is executed
+	// if this field is not set, it can only be executed on demand with "switch hooks --hook-name <name>"
+	Interval *time.Duration `yaml:"interval"`
+	// Timeout is the maximum duration of a single execution of the Hook. The Hook is killed afterwards.
+	// defaults to no timeout
+	// + optional
+	Timeout *time.Duration `yaml:"timeout"`
+	// Retries is the number of times a failed (or timed out) execution of the Hook is retried
+	// defaults to 0
+	// + optional
+	Retries *int `yaml:"retries"`
+	// Parallel defines if the Hook is executed concurrently with the other hooks of the same trigger.
+	// Hooks that are not parallel are executed sequentially in the configured order.
+	// defaults to false
+	// + optional
+	Parallel *bool `yaml:"parallel"`
 }
 
 // HookState contains the definition for the hook state
@@ -62,3 +144,51 @@ type HookState struct {
 	// used to check if the Hook has to be executed again
 	LastExecutionTime time.Time `yaml:"lastExecutionTime"`
 }
+
+// GetTrigger returns the trigger of the Hook including the default trigger of its type
+func (h Hook) GetTrigger() HookTrigger {
+	switch {
+	case len(h.Trigger) > 0:
+		return h.Trigger
+	case h.Type == HookTypeTerminalTitle:
+		return HookTriggerPostSwitch
+	default:
+		return HookTriggerPreSearch
+	}
+}
+
+// IsPreSearch returns if the Hook is executed prior to the search
+func (h Hook) IsPreSearch() bool {
+	return h.GetTrigger() == HookTriggerPreSearch
+}
+
+// IsPostSwitch returns if the Hook is executed after a successful switch to a context
+func (h Hook) IsPostSwitch() bool {
+	return h.GetTrigger() == HookTriggerPostSwitch
+}
+
+// IsStoreFailure returns if the Hook is executed when a kubeconfig store fails
+func (h Hook) IsStoreFailure() bool {
+	return h.Trigger == HookTriggerStoreFailure
+}
+
+// GetTimeout returns the timeout of a single execution of the Hook or nil if the Hook has no timeout
+func (h Hook) GetTimeout() *time.Duration {
+	if h.Execution == nil {
+		return nil
+	}
+	return h.Execution.Timeout
+}
+
+// GetRetries returns how often a failed execution of the Hook is retried
+func (h Hook) GetRetries() int {
+	if h.Execution == nil || h.Execution.Retries == nil {
+		return 0
+	}
+	return *h.Execution.Retries
+}
+
+// IsParallel returns if the Hook is executed concurrently with other hooks
+func (h Hook) IsParallel() bool {
+	return h.Execution != nil && h.Execution.Parallel != nil && *h.Execution.Parallel
+}
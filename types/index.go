@@ -27,6 +27,10 @@ type Index struct {
 	// For instance, the DigitalOcean store uses this as the getKubeconfigForPath() requires to know the cluster_ID of a DOKS cluster, which
 	// for beauty reasons, is not stored in the visible kubeconfig_path. The cluster_ID for a context_name is stored as a tag instead.
 	ContextToTags map[string]map[string]string `yaml:"contextToTags"`
+	// ContextToServer contains the API server URL for a context name, so that the search can match it
+	ContextToServer map[string]string `yaml:"contextToServer,omitempty"`
+	// ContextToCAHash contains the hash of the certificate authority of the cluster for a context name, to detect the same cluster in other stores
+	ContextToCAHash map[string]string `yaml:"contextToCAHash,omitempty"`
 }
 
 // IndexState defines how the state of an index for a kubeconfig store is written
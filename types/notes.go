@@ -0,0 +1,27 @@
+// Copyright 2025 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// ContextNotes contains the free-text notes attached to contexts
+type ContextNotes struct {
+	// ContextToNote maps the context names to their notes
+	ContextToNote map[string]string `yaml:"contextToNote"`
+}
+
+// TrustedDirectoryConfigs contains the ".kubeswitch" files the user allowed to switch the context
+type TrustedDirectoryConfigs struct {
+	// PathToHash maps the absolute paths of the files to the SHA-256 hash of their allowed content
+	PathToHash map[string]string `yaml:"pathToHash"`
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// NamedSessions contains the sessions saved with "switch session save"
+type NamedSessions struct {
+	// Sessions are the saved sessions by name
+	Sessions map[string]NamedSession `yaml:"sessions"`
+}
+
+// NamedSession is a set of terminals, each switched to a context and namespace
+type NamedSession struct {
+	// Slots are the terminals of the session
+	Slots []SessionSlot `yaml:"slots"`
+}
+
+// SessionSlot is a terminal of a named session
+type SessionSlot struct {
+	// Name is the name of the slot, e.g. "logs"
+	Name string `yaml:"name"`
+	// Context is the name of the context including the prefix of the kubeconfig store
+	Context string `yaml:"context"`
+	// Namespace is the namespace of the context
+	// + optional
+	Namespace string `yaml:"namespace,omitempty"`
+}